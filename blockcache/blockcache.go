@@ -0,0 +1,341 @@
+// Package blockcache is a cache of the contents of files, for the reads that are repeated over
+// the same ranges, such as scrubbing through a video on a FUSE mount.
+//
+// Cache holds fixed-size blocks of contents, up to a total size, and evicts the least recently
+// used blocks first. It holds them in memory, or in a cache folder where they persist across
+// runs. The blocks that are not cached are fetched with a FetchFunc: see PReadFetcher and
+// LinkFetcher.
+package blockcache
+
+import (
+	"container/list"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultBlockSize is the default size of the blocks of Cache.
+const DefaultBlockSize = 1 << 20
+
+// Key identifies a version of the contents of a file: the pCloud hash of a file changes with
+// its contents, so that the blocks of older versions are never read.
+type Key struct {
+	FileID uint64
+	Hash   uint64
+}
+
+// FetchFunc reads count bytes at offset off of a file. It returns fewer bytes at the end of the
+// file.
+type FetchFunc func(off int64, count int) ([]byte, error)
+
+// Option configures a Cache.
+type Option func(*Cache)
+
+// WithBlockSize sets the size of the blocks. It defaults to DefaultBlockSize.
+func WithBlockSize(size int) Option {
+	return func(c *Cache) {
+		c.blockSize = size
+	}
+}
+
+// WithDir persists the blocks in the folder dir, which is created if needed, rather than in
+// memory. The blocks found in dir are reused.
+func WithDir(dir string) Option {
+	return func(c *Cache) {
+		c.dir = dir
+	}
+}
+
+// Cache is a size-bounded LRU cache of blocks of contents of files. It is safe for concurrent
+// use.
+type Cache struct {
+	blockSize int
+	maxSize   int64
+	dir       string
+
+	lock    sync.Mutex
+	size    int64
+	lru     *list.List // of *block, most recently used first
+	entries map[blockKey]*list.Element
+}
+
+// blockKey identifies a block of the contents of a file.
+type blockKey struct {
+	Key
+	index int64
+}
+
+// name returns the name of the file that holds the block in the cache folder.
+func (k blockKey) name() string {
+	return fmt.Sprintf("%d-%x-%d", k.FileID, k.Hash, k.index)
+}
+
+// block is a block held by the Cache.
+type block struct {
+	key  blockKey
+	size int64
+
+	// data is nil when the block is held in the cache folder.
+	data []byte
+}
+
+// New creates a Cache that holds at most maxSize bytes.
+func New(maxSize int64, opts ...Option) (*Cache, error) {
+	c := &Cache{
+		blockSize: DefaultBlockSize,
+		maxSize:   maxSize,
+		lru:       list.New(),
+		entries:   map[blockKey]*list.Element{},
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.blockSize <= 0 {
+		return nil, errors.Errorf("invalid block size %d", c.blockSize)
+	}
+
+	if c.dir != "" {
+		err := c.load()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// load indexes the blocks found in the cache folder, the most recently used first.
+func (c *Cache) load() error {
+	err := os.MkdirAll(c.dir, 0o700)
+	if err != nil {
+		return errors.Wrap(err, "creating the cache folder")
+	}
+
+	des, err := os.ReadDir(c.dir)
+	if err != nil {
+		return errors.Wrap(err, "reading the cache folder")
+	}
+
+	type found struct {
+		key     blockKey
+		size    int64
+		modTime time.Time
+	}
+
+	var blocks []found
+
+	for _, de := range des {
+		var k blockKey
+
+		_, err := fmt.Sscanf(de.Name(), "%d-%x-%d", &k.FileID, &k.Hash, &k.index)
+		if err != nil || k.name() != de.Name() {
+			// not a block, such as an interrupted write.
+			continue
+		}
+
+		info, err := de.Info()
+		if err != nil {
+			continue
+		}
+
+		blocks = append(blocks, found{key: k, size: info.Size(), modTime: info.ModTime()})
+	}
+
+	sort.Slice(blocks, func(i, j int) bool { return blocks[i].modTime.After(blocks[j].modTime) })
+
+	for _, b := range blocks {
+		c.entries[b.key] = c.lru.PushBack(&block{key: b.key, size: b.size})
+		c.size += b.size
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.evict()
+
+	return nil
+}
+
+// ReadAt reads len(p) bytes at offset off of the contents k, like io.ReaderAt. The blocks that
+// are not cached are fetched with fetch and cached.
+func (c *Cache) ReadAt(k Key, p []byte, off int64, fetch FetchFunc) (int, error) {
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+
+	var n int
+
+	for n < len(p) {
+		pos := off + int64(n)
+		bk := blockKey{Key: k, index: pos / int64(c.blockSize)}
+
+		data, err := c.block(bk, fetch)
+		if err != nil {
+			return n, err
+		}
+
+		start := int(pos % int64(c.blockSize))
+		if start >= len(data) {
+			return n, io.EOF
+		}
+
+		n += copy(p[n:], data[start:])
+
+		if len(data) < c.blockSize && n < len(p) {
+			// the last block of the file.
+			return n, io.EOF
+		}
+	}
+
+	return n, nil
+}
+
+// block returns the data of the block bk, from the cache or else from fetch.
+func (c *Cache) block(bk blockKey, fetch FetchFunc) ([]byte, error) {
+	data, ok := c.get(bk)
+	if ok {
+		return data, nil
+	}
+
+	data, err := fetch(bk.index*int64(c.blockSize), c.blockSize)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) > 0 {
+		c.put(bk, data)
+	}
+
+	return data, nil
+}
+
+// get returns the data of the block bk when it is cached.
+func (c *Cache) get(bk blockKey) ([]byte, bool) {
+	c.lock.Lock()
+
+	e, ok := c.entries[bk]
+	if !ok {
+		c.lock.Unlock()
+		return nil, false
+	}
+
+	c.lru.MoveToFront(e)
+	b := e.Value.(*block)
+
+	c.lock.Unlock()
+
+	if b.data != nil {
+		return b.data, true
+	}
+
+	p := filepath.Join(c.dir, bk.name())
+
+	data, err := os.ReadFile(p)
+	if err != nil {
+		// the block was evicted meanwhile, or removed from the cache folder.
+		c.remove(bk)
+		return nil, false
+	}
+
+	// the modification time of the files orders the blocks when the cache is loaded.
+	now := time.Now()
+	_ = os.Chtimes(p, now, now)
+
+	return data, true
+}
+
+// put caches the data of the block bk.
+func (c *Cache) put(bk blockKey, data []byte) {
+	b := &block{key: bk, size: int64(len(data))}
+
+	if c.dir == "" {
+		b.data = data
+	} else if c.write(bk, data) != nil {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if e, ok := c.entries[bk]; ok {
+		// the block was cached concurrently.
+		c.lru.MoveToFront(e)
+		return
+	}
+
+	c.entries[bk] = c.lru.PushFront(b)
+	c.size += b.size
+
+	c.evict()
+}
+
+// write writes the data of the block bk to the cache folder. The data is written to a
+// temporary file first, so that blocks are never partially written.
+func (c *Cache) write(bk blockKey, data []byte) error {
+	f, err := os.CreateTemp(c.dir, "tmp-*")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), filepath.Join(c.dir, bk.name()))
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// remove drops the block bk from the index.
+func (c *Cache) remove(bk blockKey) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if e, ok := c.entries[bk]; ok {
+		c.drop(e)
+	}
+}
+
+// evict drops the least recently used blocks until the cache fits its maximum size. The caller
+// holds the lock.
+func (c *Cache) evict() {
+	for c.size > c.maxSize && c.lru.Len() > 0 {
+		e := c.lru.Back()
+		c.drop(e)
+
+		if c.dir != "" {
+			_ = os.Remove(filepath.Join(c.dir, e.Value.(*block).key.name()))
+		}
+	}
+}
+
+// drop drops the block of e from the index. The caller holds the lock.
+func (c *Cache) drop(e *list.Element) {
+	b := e.Value.(*block)
+
+	c.lru.Remove(e)
+	delete(c.entries, b.key)
+	c.size -= b.size
+}
+
+// Size returns the total size of the cached blocks.
+func (c *Cache) Size() int64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.size
+}
@@ -0,0 +1,182 @@
+package blockcache_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/blockcache"
+	"github.com/seborama/pcloud-sdk/sdk"
+	"github.com/seborama/pcloud-sdk/sdk/sdktest"
+)
+
+const contents = "0123456789abcdefghij"
+
+// counter is a FetchFunc over contents that counts its calls.
+type counter struct {
+	fetches int
+}
+
+func (c *counter) fetch(off int64, count int) ([]byte, error) {
+	c.fetches++
+
+	if off >= int64(len(contents)) {
+		return nil, nil
+	}
+
+	end := off + int64(count)
+	if end > int64(len(contents)) {
+		end = int64(len(contents))
+	}
+
+	return []byte(contents[off:end]), nil
+}
+
+func TestCache_ReadAt(t *testing.T) {
+	for name, opts := range map[string][]blockcache.Option{
+		"memory": {blockcache.WithBlockSize(8)},
+		"disk":   {blockcache.WithBlockSize(8), blockcache.WithDir(t.TempDir())},
+	} {
+		t.Run(name, func(t *testing.T) {
+			c, err := blockcache.New(1<<20, opts...)
+			require.NoError(t, err)
+
+			k := blockcache.Key{FileID: 1, Hash: 2}
+			cnt := &counter{}
+
+			p := make([]byte, 10)
+			n, err := c.ReadAt(k, p, 5, cnt.fetch)
+			require.NoError(t, err)
+			assert.Equal(t, "56789abcde", string(p[:n]))
+			assert.Equal(t, 2, cnt.fetches)
+
+			// served from the cache.
+			n, err = c.ReadAt(k, p, 3, cnt.fetch)
+			require.NoError(t, err)
+			assert.Equal(t, "3456789abc", string(p[:n]))
+			assert.Equal(t, 2, cnt.fetches)
+
+			n, err = c.ReadAt(k, p, 15, cnt.fetch)
+			assert.ErrorIs(t, err, io.EOF)
+			assert.Equal(t, "fghij", string(p[:n]))
+			assert.Equal(t, 3, cnt.fetches)
+
+			n, err = c.ReadAt(k, p, 20, cnt.fetch)
+			assert.ErrorIs(t, err, io.EOF)
+			assert.Zero(t, n)
+
+			n, err = c.ReadAt(k, p, 30, cnt.fetch)
+			assert.ErrorIs(t, err, io.EOF)
+			assert.Zero(t, n)
+
+			assert.EqualValues(t, len(contents), c.Size())
+
+			// another version of the file.
+			_, err = c.ReadAt(blockcache.Key{FileID: 1, Hash: 3}, p, 0, cnt.fetch)
+			require.NoError(t, err)
+			assert.Equal(t, 6, cnt.fetches)
+		})
+	}
+}
+
+func TestCache_Eviction(t *testing.T) {
+	dir := t.TempDir()
+
+	c, err := blockcache.New(16, blockcache.WithBlockSize(8), blockcache.WithDir(dir))
+	require.NoError(t, err)
+
+	k := blockcache.Key{FileID: 1, Hash: 2}
+	cnt := &counter{}
+	p := make([]byte, 1)
+
+	for _, off := range []int64{0, 8, 0, 16} {
+		_, err = c.ReadAt(k, p, off, cnt.fetch)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 3, cnt.fetches)
+	assert.EqualValues(t, 12, c.Size())
+
+	// the block at 8 was the least recently used.
+	_, err = c.ReadAt(k, p, 0, cnt.fetch)
+	require.NoError(t, err)
+	assert.Equal(t, 3, cnt.fetches)
+
+	_, err = c.ReadAt(k, p, 8, cnt.fetch)
+	require.NoError(t, err)
+	assert.Equal(t, 4, cnt.fetches)
+
+	des, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, des, 2)
+}
+
+func TestCache_Persistence(t *testing.T) {
+	dir := t.TempDir()
+	k := blockcache.Key{FileID: 1, Hash: 2}
+	cnt := &counter{}
+	p := make([]byte, 20)
+
+	c, err := blockcache.New(1<<20, blockcache.WithBlockSize(8), blockcache.WithDir(dir))
+	require.NoError(t, err)
+	_, err = c.ReadAt(k, p, 0, cnt.fetch)
+	require.NoError(t, err)
+	assert.Equal(t, 3, cnt.fetches)
+
+	require.NoError(t, os.WriteFile(dir+"/tmp-123", []byte("partial"), 0o600))
+
+	c, err = blockcache.New(1<<20, blockcache.WithBlockSize(8), blockcache.WithDir(dir))
+	require.NoError(t, err)
+	assert.EqualValues(t, len(contents), c.Size())
+
+	n, err := c.ReadAt(k, p, 0, cnt.fetch)
+	require.NoError(t, err)
+	assert.Equal(t, contents, string(p[:n]))
+	assert.Equal(t, 3, cnt.fetches)
+
+	// a smaller cache evicts blocks when it is loaded.
+	c, err = blockcache.New(8, blockcache.WithBlockSize(8), blockcache.WithDir(dir))
+	require.NoError(t, err)
+	assert.LessOrEqual(t, c.Size(), int64(8))
+}
+
+func TestFetchers(t *testing.T) {
+	ctx := context.Background()
+
+	srv := sdktest.NewServer()
+	t.Cleanup(srv.Close)
+
+	fileID, err := srv.WriteFile("/a.txt", []byte(contents))
+	require.NoError(t, err)
+
+	pcc := srv.NewClient()
+
+	fl, err := pcc.DownloadLink(ctx, sdk.ByPath("/a.txt"), sdk.DownloadLinkOptions{ForceDownload: true, SkipFilename: true})
+	require.NoError(t, err)
+
+	f, err := pcc.FileOpen(ctx, 0, sdk.ByPath("/a.txt"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = pcc.FileClose(ctx, f.FD) })
+
+	for name, fetch := range map[string]blockcache.FetchFunc{
+		"pread": blockcache.PReadFetcher(ctx, pcc, f.FD),
+		"link":  blockcache.LinkFetcher(ctx, srv.Client(), fl.Hosts[0]+fl.Path),
+	} {
+		t.Run(name, func(t *testing.T) {
+			c, err := blockcache.New(1<<20, blockcache.WithBlockSize(8))
+			require.NoError(t, err)
+
+			p := make([]byte, 30)
+			n, err := c.ReadAt(blockcache.Key{FileID: fileID}, p, 2, fetch)
+			assert.ErrorIs(t, err, io.EOF)
+			assert.Equal(t, contents[2:], string(p[:n]))
+
+			n, err = c.ReadAt(blockcache.Key{FileID: fileID}, p, 24, fetch)
+			assert.ErrorIs(t, err, io.EOF)
+			assert.Zero(t, n)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package blockcache
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/pkg/errors"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+// filePReader defines the SDK method used by PReadFetcher.
+type filePReader interface {
+	FilePRead(ctx context.Context, fd, count, offset uint64, opts ...sdk.ClientOption) ([]byte, error)
+}
+
+// PReadFetcher returns a FetchFunc that reads the file open as fd with the file operations of
+// pCloud (see sdk.Client.FilePRead).
+func PReadFetcher(ctx context.Context, pcc filePReader, fd uint64) FetchFunc {
+	return func(off int64, count int) ([]byte, error) {
+		data, err := pcc.FilePRead(ctx, fd, uint64(count), uint64(off))
+		if err != nil {
+			return nil, err
+		}
+
+		// data is a pooled buffer, that the cache may keep.
+		b := append([]byte(nil), data...)
+		sdk.PutBuffer(data)
+
+		return b, nil
+	}
+}
+
+// LinkFetcher returns a FetchFunc that downloads the file at url, a link obtained with
+// sdk.Client.DownloadLink, with range requests.
+func LinkFetcher(ctx context.Context, client *http.Client, url string) FetchFunc {
+	return func(off int64, count int) ([]byte, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to prepare request to download from pCloud")
+		}
+
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", off, off+int64(count)-1))
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, errors.Wrap(err, "executing HTTP request to download from pCloud")
+		}
+		defer func() { _ = resp.Body.Close() }()
+
+		switch resp.StatusCode {
+		case http.StatusPartialContent:
+		case http.StatusRequestedRangeNotSatisfiable:
+			// off is at, or past, the end of the file.
+			return nil, nil
+		default:
+			return nil, errors.Errorf("downloading from pCloud: unexpected status %s", resp.Status)
+		}
+
+		data, err := io.ReadAll(io.LimitReader(resp.Body, int64(count)))
+		if err != nil {
+			return nil, errors.Wrap(err, "reading body of the response to download data from pCloud")
+		}
+
+		return data, nil
+	}
+}
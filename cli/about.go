@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// AccountInfo describes the pCloud account, as written by About.
+type AccountInfo struct {
+	Email string `json:"email"`
+	// Plan is "free", "premium", "premium lifetime" or "business".
+	Plan           string     `json:"plan"`
+	PremiumExpires *time.Time `json:"premium_expires,omitempty"`
+	Quota          uint64     `json:"quota"`
+	UsedQuota      uint64     `json:"used_quota"`
+	// Crypto is "not set up", "set up" (without a subscription), "active" or "lifetime".
+	Crypto        string     `json:"crypto"`
+	CryptoExpires *time.Time `json:"crypto_expires,omitempty"`
+	TwoFactorAuth bool       `json:"two_factor_auth"`
+	Sessions      []Session  `json:"sessions"`
+}
+
+// Session is an active session of the account: an auth token.
+type Session struct {
+	ID      uint64    `json:"id"`
+	Device  string    `json:"device"`
+	Created time.Time `json:"created"`
+	Expires time.Time `json:"expires"`
+	// Current is set for the session of the command line.
+	Current bool `json:"current"`
+}
+
+// About writes the plan, the quota, the status of the crypto folder and the active sessions of
+// the pCloud account to w, or the AccountInfo as JSON with OutputJSON. OutputTable is the same
+// as OutputPlain.
+func (cli *CLI) About(ctx context.Context, w io.Writer, output Output) error {
+	ui, err := cli.pCloudClient.UserInfo(ctx)
+	if err != nil {
+		return err
+	}
+
+	tl, err := cli.pCloudClient.ListTokens(ctx)
+	if err != nil {
+		return err
+	}
+
+	info := AccountInfo{
+		Email:         ui.Email,
+		Plan:          "free",
+		Quota:         ui.Quota,
+		UsedQuota:     ui.UsedQuota,
+		Crypto:        "not set up",
+		TwoFactorAuth: ui.IsTwoFactorAuthActive,
+		Sessions:      make([]Session, 0, len(tl.Tokens)),
+	}
+
+	switch {
+	case ui.Business:
+		info.Plan = "business"
+	case ui.PremiumLifetime:
+		info.Plan = "premium lifetime"
+	case ui.Premium:
+		info.Plan = "premium"
+		info.PremiumExpires = timeOrNil(ui.PremiumExpires)
+	}
+
+	switch {
+	case ui.CryptoLifetime:
+		info.Crypto = "lifetime"
+	case ui.CryptoSubscription:
+		info.Crypto = "active"
+		info.CryptoExpires = timeOrNil(ui.CryptoExpires)
+	case ui.CryptoSetup:
+		info.Crypto = "set up"
+	}
+
+	for _, t := range tl.Tokens {
+		info.Sessions = append(info.Sessions, Session{
+			ID:      t.TokenID,
+			Device:  t.Device,
+			Created: t.Created,
+			Expires: t.Expires,
+			Current: t.Current,
+		})
+	}
+
+	if output == OutputJSON {
+		return writeJSON(w, info)
+	}
+
+	return info.write(w)
+}
+
+// write writes info to w, in a human-readable form.
+func (info *AccountInfo) write(w io.Writer) error {
+	const dateLayout = "2006-01-02"
+
+	plan := info.Plan
+	if info.PremiumExpires != nil {
+		plan += ", until " + info.PremiumExpires.Local().Format(dateLayout)
+	}
+
+	used := 0.0
+	if info.Quota > 0 {
+		used = 100 * float64(info.UsedQuota) / float64(info.Quota)
+	}
+
+	crypto := info.Crypto
+	if info.CryptoExpires != nil {
+		crypto += ", until " + info.CryptoExpires.Local().Format(dateLayout)
+	}
+
+	twoFactorAuth := "off"
+	if info.TwoFactorAuth {
+		twoFactorAuth = "on"
+	}
+
+	_, err := fmt.Fprintf(w, "Account:   %s\nPlan:      %s\nUsage:     %s of %s (%.1f%%)\nCrypto:    %s\n2FA:       %s\nSessions:  %d\n",
+		info.Email, plan, FormatSize(int64(info.UsedQuota)), FormatSize(int64(info.Quota)), used, crypto, twoFactorAuth, len(info.Sessions))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, s := range info.Sessions {
+		current := ""
+		if s.Current {
+			current = " (current)"
+		}
+
+		_, err = fmt.Fprintf(w, "  %-10d %-30s created %s, expires %s%s\n",
+			s.ID, s.Device, s.Created.Local().Format(dateLayout), s.Expires.Local().Format(dateLayout), current)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+// timeOrNil returns the time of t, or nil when it is zero.
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
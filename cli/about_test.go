@@ -0,0 +1,52 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/cli"
+	"github.com/seborama/pcloud-sdk/sdk"
+	"github.com/seborama/pcloud-sdk/sdk/sdktest"
+)
+
+func TestCLI_About(t *testing.T) {
+	ctx := context.Background()
+
+	srv := sdktest.NewServer(sdktest.WithCredentials("user@example.com", "secret"))
+	t.Cleanup(srv.Close)
+
+	// another session.
+	require.NoError(t, srv.NewClient().Login(ctx, "", sdk.WithGlobalOptionUsername("user@example.com"), sdk.WithGlobalOptionPassword("secret")))
+
+	pcc := srv.NewClient()
+	require.NoError(t, pcc.Login(ctx, "", sdk.WithGlobalOptionUsername("user@example.com"), sdk.WithGlobalOptionPassword("secret")))
+
+	_, err := srv.WriteFile("/a.txt", []byte("0123456789"))
+	require.NoError(t, err)
+
+	c := cli.NewCLI(pcc, srv.Client())
+
+	var out bytes.Buffer
+	require.NoError(t, c.About(ctx, &out, cli.OutputJSON))
+
+	var info cli.AccountInfo
+	require.NoError(t, json.Unmarshal(out.Bytes(), &info))
+	assert.Equal(t, "user@example.com", info.Email)
+	assert.Equal(t, "free", info.Plan)
+	assert.EqualValues(t, 10, info.UsedQuota)
+	assert.Equal(t, "not set up", info.Crypto)
+	require.Len(t, info.Sessions, 2)
+	assert.False(t, info.Sessions[0].Current)
+	assert.True(t, info.Sessions[1].Current)
+
+	out.Reset()
+	require.NoError(t, c.About(ctx, &out, cli.OutputPlain))
+	assert.Contains(t, out.String(), "Account:   user@example.com\n")
+	assert.Contains(t, out.String(), "Usage:     10 B of 10.0 GiB (0.0%)\n")
+	assert.Contains(t, out.String(), " (current)\n")
+}
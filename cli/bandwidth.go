@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// maxLimitedRead is the largest read of the readers of a bandwidthLimiter, so that a large
+// buffer does not hold the bandwidth for long at once.
+const maxLimitedRead = 32 << 10
+
+// bandwidthLimiter limits the rate at which the files transferred at the same time are read,
+// to rate bytes per second in total.
+type bandwidthLimiter struct {
+	rate int64
+
+	lock sync.Mutex
+	// next is the time at which the bytes read so far are within the rate.
+	next time.Time
+}
+
+// newBandwidthLimiter returns the limiter of rate bytes per second, or nil for no limit.
+func newBandwidthLimiter(rate int64) *bandwidthLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	return &bandwidthLimiter{rate: rate}
+}
+
+// reader returns a reader of r whose reads are limited by bl. It returns r when bl is nil.
+func (bl *bandwidthLimiter) reader(ctx context.Context, r io.Reader) io.Reader {
+	if bl == nil {
+		return r
+	}
+	return &limitedReader{ctx: ctx, r: r, bl: bl}
+}
+
+// wait waits until the n bytes just read are within the rate.
+func (bl *bandwidthLimiter) wait(ctx context.Context, n int) error {
+	bl.lock.Lock()
+	now := time.Now()
+	if bl.next.Before(now) {
+		bl.next = now
+	}
+	bl.next = bl.next.Add(time.Duration(n) * time.Second / time.Duration(bl.rate))
+	d := bl.next.Sub(now)
+	bl.lock.Unlock()
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return errors.WithStack(ctx.Err())
+	}
+}
+
+// limitedReader is a reader limited by a bandwidthLimiter.
+type limitedReader struct {
+	ctx context.Context
+	r   io.Reader
+	bl  *bandwidthLimiter
+}
+
+func (lr *limitedReader) Read(p []byte) (int, error) {
+	if len(p) > maxLimitedRead {
+		p = p[:maxLimitedRead]
+	}
+
+	n, err := lr.r.Read(p)
+	if n > 0 {
+		if waitErr := lr.bl.wait(lr.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+
+	return n, err
+}
@@ -1,27 +1,75 @@
+// Package cli implements the file commands of the pcloud command line: ls, cp, mv, rm, mkdir,
+// cat, put, upload and download.
+//
+// The paths of pCloud are prefixed with PCloudPrefix ("r:/Documents/a.txt") where a command
+// accepts both local and pCloud paths, as cp and mv do. The prefix is optional for the
+// commands that only accept pCloud paths.
 package cli
 
 import (
 	"context"
 	"fmt"
 	"io"
+	"io/fs"
 	"net/http"
 	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 
+	"github.com/seborama/pcloud-sdk/remote"
 	"github.com/seborama/pcloud-sdk/sdk"
 )
 
+// PCloudPrefix is the prefix of the paths of pCloud.
 const PCloudPrefix = "r:"
 
+// sdkClient defines the SDK methods used by the CLI.
 type sdkClient interface {
-	GetFileLink(ctx context.Context, file sdk.T3PathOrFileID, forceDownloadOpt bool, contentTypeOpt string, maxSpeedOpt uint64, skipFilenameOpt bool, opts ...sdk.ClientOption) (*sdk.FileLink, error)
+	List(ctx context.Context, folder sdk.FolderRef, o sdk.ListOptions, opts ...sdk.ClientOption) (*sdk.FSList, error)
+	CreateFolder(ctx context.Context, folder sdk.FolderRef, opts ...sdk.ClientOption) (*sdk.FSList, error)
+	CreateFolderIfNotExists(ctx context.Context, folder sdk.FolderRef, opts ...sdk.ClientOption) (*sdk.FSList, error)
+	DeleteFolder(ctx context.Context, folder sdk.FolderRef, opts ...sdk.ClientOption) (*sdk.FSList, error)
+	DeleteFolderRecursive(ctx context.Context, folder sdk.FolderRef, opts ...sdk.ClientOption) (*sdk.DeleteResult, error)
+	RenameFolder(ctx context.Context, folder sdk.FolderRef, toFolder sdk.FolderRef, opts ...sdk.ClientOption) (*sdk.FSList, error)
+	CopyFolderTo(ctx context.Context, folder sdk.FolderRef, toFolder sdk.FolderRef, o sdk.CopyFolderOptions, opts ...sdk.ClientOption) (*sdk.FSList, error)
+	Stat(ctx context.Context, file sdk.FileRef, opts ...sdk.ClientOption) (*sdk.FileResult, error)
+	ChecksumFile(ctx context.Context, file sdk.FileRef, opts ...sdk.ClientOption) (*sdk.FileChecksum, error)
+	DeleteFile(ctx context.Context, file sdk.FileRef, opts ...sdk.ClientOption) (*sdk.FileResult, error)
+	RenameFile(ctx context.Context, file sdk.FileRef, destination sdk.FolderRef, opts ...sdk.ClientOption) (*sdk.FileResult, error)
+	CopyFileTo(ctx context.Context, file sdk.FileRef, destination sdk.FolderRef, o sdk.CopyFileOptions, opts ...sdk.ClientOption) (*sdk.FileResult, error)
+	DownloadLink(ctx context.Context, file sdk.FileRef, o sdk.DownloadLinkOptions, opts ...sdk.ClientOption) (*sdk.FileLink, error)
+	FileOpen(ctx context.Context, flags uint64, file sdk.FileRef, opts ...sdk.ClientOption) (*sdk.File, error)
+	FileWrite(ctx context.Context, fd uint64, data []byte, opts ...sdk.ClientOption) (*sdk.FileDataTransfer, error)
+	FileClose(ctx context.Context, fd uint64, opts ...sdk.ClientOption) error
+	CreateFilePubLink(ctx context.Context, file sdk.FileRef, o sdk.PubLinkOptions, opts ...sdk.ClientOption) (*sdk.PubLinkResult, error)
+	CreateFolderPubLink(ctx context.Context, folder sdk.FolderRef, o sdk.PubLinkOptions, opts ...sdk.ClientOption) (*sdk.PubLinkResult, error)
+	ListPubLinks(ctx context.Context, opts ...sdk.ClientOption) (*sdk.PubLinksList, error)
+	UpdatePubLink(ctx context.Context, linkID uint64, o sdk.UpdatePubLinkOptions, opts ...sdk.ClientOption) error
+	DeletePubLink(ctx context.Context, linkID uint64, opts ...sdk.ClientOption) error
+	ShareFolder(ctx context.Context, folder sdk.FolderRef, mail string, permissions sdk.SharePermissions, nameOpt, messageOpt string, opts ...sdk.ClientOption) error
+	ListShares(ctx context.Context, opts ...sdk.ClientOption) (*sdk.SharesList, error)
+	AcceptShare(ctx context.Context, shareRequestID uint64, nameOpt string, folderOpt sdk.FolderRef, opts ...sdk.ClientOption) error
+	DeclineShare(ctx context.Context, shareRequestID uint64, opts ...sdk.ClientOption) error
+	ListTrash(ctx context.Context, o sdk.ListTrashOptions, opts ...sdk.ClientOption) (*sdk.FSList, error)
+	TrashRestore(ctx context.Context, item sdk.T6FileIDOrFolderID, restoreToOpt uint64, opts ...sdk.ClientOption) (*sdk.FSList, error)
+	TrashClear(ctx context.Context, item sdk.T6FileIDOrFolderID, opts ...sdk.ClientOption) error
+	ListRevisions(ctx context.Context, file sdk.FileRef, opts ...sdk.ClientOption) (*sdk.RevisionsList, error)
+	RevertRevision(ctx context.Context, file sdk.FileRef, revisionID uint64, opts ...sdk.ClientOption) (*sdk.FileResult, error)
+	UserInfo(ctx context.Context, opts ...sdk.ClientOption) (*sdk.UserInfo, error)
+	ListTokens(ctx context.Context, opts ...sdk.ClientOption) (*sdk.TokensList, error)
 }
 
+// CLI runs the file commands against a pCloud account.
 type CLI struct {
 	pCloudClient sdkClient
 	httpClient   *http.Client
+	remote       *remote.PCloud
 }
 
 // NewCLI creates a new initialised CLI struct.
@@ -29,61 +77,430 @@ func NewCLI(pCloudClient sdkClient, httpClient *http.Client) *CLI {
 	return &CLI{
 		pCloudClient: pCloudClient,
 		httpClient:   httpClient,
+		remote:       remote.New(pCloudClient, "/", remote.WithHTTPClient(httpClient)),
 	}
 }
 
+// Entry is a pCloud file or folder, as listed by List and Find.
+type Entry struct {
+	Path     string     `json:"path"`
+	Name     string     `json:"name"`
+	IsFolder bool       `json:"is_folder"`
+	Size     uint64     `json:"size"`
+	Modified *time.Time `json:"modified,omitempty"`
+	// ID is the fileid of the files, or the folderid of the folders.
+	ID uint64 `json:"id"`
+}
+
+// NewEntry returns the Entry of the pCloud file or folder m, at the path p.
+func NewEntry(p string, m *sdk.Metadata) Entry {
+	e := Entry{Path: p, Name: m.Name, IsFolder: m.IsFolder, Size: m.Size, ID: m.FileID}
+	if m.IsFolder {
+		e.ID = m.FolderID
+	}
+	e.Modified = timeOrNil(m.Modified)
+
+	return e
+}
+
+// WriteEntries writes entries to w, their paths one per line, or as a table or a JSON array,
+// depending on output.
+func WriteEntries(w io.Writer, entries []Entry, output Output) error {
+	return writeList(w, output, entries, func(e Entry) error {
+		_, err := fmt.Fprintln(w, e.Path)
+		return errors.WithStack(err)
+	})
+}
+
+func (Entry) tableHeader() []string {
+	return []string{"TYPE", "SIZE", "MODIFIED", "PATH"}
+}
+
+func (e Entry) tableRow() []string {
+	return []string{e.kind(), strconv.FormatUint(e.Size, 10), e.modified(), e.Path}
+}
+
+// kind returns "d" for the folders, and "-" for the files.
+func (e Entry) kind() string {
+	if e.IsFolder {
+		return "d"
+	}
+	return "-"
+}
+
+// modified returns the modification time of e, in local time.
+func (e Entry) modified() string {
+	if e.Modified == nil {
+		return ""
+	}
+	return e.Modified.Local().Format("2006-01-02 15:04")
+}
+
+// List writes the contents of the pCloud folder p to w, one entry per line, with the names of
+// folders followed by a slash, or as a table or a JSON array of Entry, depending on output.
+// When long is set, the lines also show the type, the size and the modification time of the
+// entries. When p is a file, only the file is listed.
+func (cli *CLI) List(ctx context.Context, w io.Writer, p string, long bool, output Output) error {
+	p = remotePath(p)
+
+	var entries []Entry
+
+	lf, err := cli.pCloudClient.List(ctx, sdk.ByPath(p), sdk.ListOptions{})
+	switch {
+	case err == nil:
+		for _, m := range lf.Metadata.Contents {
+			entries = append(entries, NewEntry(path.Join(p, m.Name), m))
+		}
+	case isNotExist(err):
+		fr, statErr := cli.pCloudClient.Stat(ctx, sdk.ByPath(p))
+		if statErr != nil {
+			return err
+		}
+		entries = []Entry{NewEntry(p, &fr.Metadata)}
+	default:
+		return err
+	}
+
+	return writeListing(w, entries, long, output)
+}
+
+// writeListing writes entries to w, sorted by name, as List does.
+func writeListing(w io.Writer, entries []Entry, long bool, output Output) error {
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+
+	return writeList(w, output, entries, func(e Entry) error {
+		name := e.Name
+		if e.IsFolder {
+			name += "/"
+		}
+
+		var err error
+		if !long {
+			_, err = fmt.Fprintln(w, name)
+		} else {
+			_, err = fmt.Fprintf(w, "%s %12d %16s %s\n", e.kind(), e.Size, e.modified(), name)
+		}
+
+		return errors.WithStack(err)
+	})
+}
+
+// Cat writes the contents of the pCloud file p to w.
+func (cli *CLI) Cat(ctx context.Context, w io.Writer, p string) error {
+	return cli.CatRange(ctx, w, p, 0, -1)
+}
+
+// CatRange writes length bytes of the contents of the pCloud file p to w, from offset, or up
+// to the end of the file when length is negative. A negative offset counts from the end of the
+// file: -10 writes its last 10 bytes (at most). The contents are streamed from the content
+// servers of pCloud: only the range is downloaded.
+func (cli *CLI) CatRange(ctx context.Context, w io.Writer, p string, offset, length int64) error {
+	p = remotePath(p)
+
+	if offset < 0 {
+		fr, err := cli.pCloudClient.Stat(ctx, sdk.ByPath(p))
+		if err != nil {
+			return err
+		}
+
+		offset += int64(fr.Metadata.Size)
+		if offset < 0 {
+			offset = 0
+		}
+	}
+
+	if length == 0 {
+		return nil
+	}
+
+	rc, err := cli.remote.Get(ctx, p, offset)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rc.Close() }()
+
+	var r io.Reader = rc
+	if length > 0 {
+		r = io.LimitReader(rc, length)
+	}
+
+	_, err = io.Copy(w, r)
+	if err != nil {
+		return errors.Wrap(err, "reading the contents of the file from pCloud")
+	}
+
+	return nil
+}
+
+// Put writes the contents of r to the pCloud file p, which it replaces if it exists. Its
+// folder is created as needed. r is read in chunks, as it is uploaded: it can be a pipe, such
+// as the standard input.
+func (cli *CLI) Put(ctx context.Context, r io.Reader, p string) error {
+	p = remotePath(p)
+
+	if cli.isFolder(ctx, p) {
+		return errors.Errorf("%s: is a folder", p)
+	}
+
+	_, err := cli.remote.Put(ctx, p, r)
+
+	return err
+}
+
+// ParseRange parses a range of bytes: "START-END" (END included), "START-" (up to the end of the
+// file) or "-COUNT" (the last COUNT bytes). It returns the offset and the length expected by
+// CatRange.
+func ParseRange(s string) (offset, length int64, err error) {
+	start, end, ok := strings.Cut(s, "-")
+	if !ok || (start == "" && end == "") {
+		return 0, 0, errors.Errorf("%s: invalid range, use 'START-END', 'START-' or '-COUNT'", s)
+	}
+
+	var n [2]int64
+	for i, v := range []string{start, end} {
+		if v == "" {
+			n[i] = -1
+			continue
+		}
+		n[i], err = strconv.ParseInt(v, 10, 64)
+		if err != nil || n[i] < 0 {
+			return 0, 0, errors.Errorf("%s: invalid range, use 'START-END', 'START-' or '-COUNT'", s)
+		}
+	}
+
+	switch {
+	case n[0] < 0 && n[1] == 0:
+		return 0, 0, errors.Errorf("%s: invalid range, the count must be positive", s)
+	case n[0] < 0:
+		return -n[1], -1, nil
+	case n[1] < 0:
+		return n[0], -1, nil
+	case n[1] < n[0]:
+		return 0, 0, errors.Errorf("%s: invalid range, the end is before the start", s)
+	}
+
+	return n[0], n[1] - n[0] + 1, nil
+}
+
+// Mkdir creates the pCloud folder p. When parents is set, the missing parent folders are
+// created too, and p may already exist.
+func (cli *CLI) Mkdir(ctx context.Context, p string, parents bool) error {
+	p = remotePath(p)
+
+	if !parents {
+		_, err := cli.pCloudClient.CreateFolder(ctx, sdk.ByPath(p))
+		return err
+	}
+
+	var dir string
+	for _, elem := range strings.Split(p, "/") {
+		if elem == "" {
+			continue
+		}
+
+		dir += "/" + elem
+
+		lf, err := cli.pCloudClient.CreateFolderIfNotExists(ctx, sdk.ByPath(dir))
+		if err != nil {
+			return err
+		}
+		if !lf.Metadata.IsFolder {
+			return errors.Errorf("%s: not a folder", dir)
+		}
+	}
+
+	return nil
+}
+
+// Remove removes the pCloud file or folder p. Folders must be empty, unless recursive is set.
+func (cli *CLI) Remove(ctx context.Context, p string, recursive bool) error {
+	p = remotePath(p)
+	if p == "/" {
+		return errors.New("the root folder cannot be removed")
+	}
+
+	_, err := cli.pCloudClient.DeleteFile(ctx, sdk.ByPath(p))
+	if !isNotExist(err) {
+		return err
+	}
+
+	// p is not a file.
+	if recursive {
+		_, err = cli.pCloudClient.DeleteFolderRecursive(ctx, sdk.ByPath(p))
+	} else {
+		_, err = cli.pCloudClient.DeleteFolder(ctx, sdk.ByPath(p))
+	}
+
+	return err
+}
+
+// Copy copies the file from to the file to, which it replaces if it exists. from and to are
+// local paths, or pCloud paths prefixed with PCloudPrefix. As with cp, when to is an existing
+// folder, the file is copied into it.
+// pCloud folders can be copied within pCloud: to is then created, or the folder is copied into
+// it when it exists.
 func (cli *CLI) Copy(ctx context.Context, from, to string) error {
-	if strings.HasPrefix(from, PCloudPrefix) && !strings.HasPrefix(to, PCloudPrefix) {
-		return cli.copyFromPCloudToLocal(ctx, from, to)
+	switch {
+	case isRemote(from) && isRemote(to):
+		return cli.copyWithinPCloud(ctx, remotePath(from), remotePath(to))
+
+	case isRemote(from):
+		return cli.copyFromPCloudToLocal(ctx, remotePath(from), to)
+
+	case isRemote(to):
+		return cli.copyFromLocalToPCloud(ctx, from, remotePath(to))
 	}
 
-	return errors.New("this type of Copy is not yet implemented")
+	return errors.New("copying local files is not supported: use cp")
 }
 
-// TODO: does not support folders - use pCloud's Archiving methods or folder.ListFolder?
-func (cli *CLI) copyFromPCloudToLocal(ctx context.Context, from, to string) error {
-	fl, err := cli.pCloudClient.GetFileLink(ctx, sdk.T3FileByPath(from[2:]), true, "", 0, false)
+// Move moves the file from to the file to, like Copy followed by the removal of from. Within
+// pCloud, files and folders are renamed.
+func (cli *CLI) Move(ctx context.Context, from, to string) error {
+	if !isRemote(from) || !isRemote(to) {
+		err := cli.Copy(ctx, from, to)
+		if err != nil {
+			return err
+		}
+
+		if isRemote(from) {
+			return cli.Remove(ctx, from, false)
+		}
+
+		return errors.WithStack(os.Remove(from))
+	}
+
+	from, to = remotePath(from), remotePath(to)
+
+	dst := cli.destination(ctx, from, to)
+
+	_, err := cli.pCloudClient.RenameFile(ctx, sdk.ByPath(from), sdk.ByPath(dst))
+	if !isNotExist(err) {
+		return err
+	}
+
+	// from is not a file.
+	_, err = cli.pCloudClient.RenameFolder(ctx, sdk.ByPath(from), sdk.ByPath(dst))
+
+	return err
+}
+
+// copyWithinPCloud copies the pCloud file or folder from to to.
+func (cli *CLI) copyWithinPCloud(ctx context.Context, from, to string) error {
+	dst := cli.destination(ctx, from, to)
+
+	_, err := cli.pCloudClient.CopyFileTo(ctx, sdk.ByPath(from), sdk.ByPath(dst), sdk.CopyFileOptions{})
+	if !isNotExist(err) {
+		return err
+	}
+
+	// from is not a file.
+	if cli.isFolder(ctx, to) {
+		_, err = cli.pCloudClient.CopyFolderTo(ctx, sdk.ByPath(from), sdk.ByPath(to), sdk.CopyFolderOptions{})
+		return err
+	}
+
+	// fail before to is created, when from does not exist.
+	_, err = cli.pCloudClient.List(ctx, sdk.ByPath(from), sdk.ListOptions{NoFiles: true})
 	if err != nil {
 		return err
 	}
-	if len(fl.Hosts) == 0 {
-		return errors.New("no hosts available to download the file from pCloud")
+
+	_, err = cli.pCloudClient.CreateFolder(ctx, sdk.ByPath(to))
+	if err != nil {
+		return err
 	}
 
-	u := fl.Hosts[0] + fl.Path
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	_, err = cli.pCloudClient.CopyFolderTo(ctx, sdk.ByPath(from), sdk.ByPath(to), sdk.CopyFolderOptions{CopyContentOnly: true})
+
+	return err
+}
+
+// copyFromPCloudToLocal downloads the pCloud file from to the local file to.
+func (cli *CLI) copyFromPCloudToLocal(ctx context.Context, from, to string) error {
+	if info, err := os.Stat(to); err == nil && info.IsDir() {
+		to = filepath.Join(to, path.Base(from))
+	}
+
+	return cli.download(ctx, from, to, nil)
+}
+
+// download downloads the pCloud file from to the local file to, which it replaces if it exists,
+// at the rate of bl (nil for no limit).
+func (cli *CLI) download(ctx context.Context, from, to string, bl *bandwidthLimiter) error {
+	rc, err := cli.remote.Get(ctx, from, 0)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rc.Close() }()
+
+	f, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
 	if err != nil {
-		return errors.Wrap(err, "unable to prepare request to download from pCloud")
-	}
-
-	resp, err := cli.httpClient.Do(req)
-	if resp != nil {
-		defer func() {
-			_, err = io.Copy(io.Discard, resp.Body)
-			if err != nil {
-				fmt.Println("discarding remainder of response body:", err.Error())
-			}
-			err = resp.Body.Close()
-			if err != nil {
-				fmt.Println("closing the response body:", err.Error())
-			}
-		}()
+		return errors.WithStack(err)
+	}
+
+	_, err = io.Copy(f, bl.reader(ctx, rc))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
 	}
+
+	return errors.Wrap(err, "downloading the file from pCloud")
+}
+
+// copyFromLocalToPCloud uploads the local file from to the pCloud file to.
+func (cli *CLI) copyFromLocalToPCloud(ctx context.Context, from, to string) error {
+	f, err := os.Open(from)
 	if err != nil {
-		return errors.Wrap(err, "executing HTTP request to download from pCloud")
+		return errors.WithStack(err)
 	}
+	defer func() { _ = f.Close() }()
 
-	// TODO: optimise this to read in chunks
-	body, err := io.ReadAll(resp.Body)
+	info, err := f.Stat()
 	if err != nil {
-		return errors.Wrap(err, "reading body of the response to download data from pCloud")
+		return errors.WithStack(err)
+	}
+	if info.IsDir() {
+		return errors.Errorf("%s: copying local folders is not supported", from)
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return errors.New(string(body))
+	_, err = cli.remote.Put(ctx, cli.destination(ctx, from, to), f)
+
+	return err
+}
+
+// destination returns the path of the copy of the file from to the pCloud path to: a file in to
+// when it is a folder, or to itself.
+func (cli *CLI) destination(ctx context.Context, from, to string) string {
+	if cli.isFolder(ctx, to) {
+		return path.Join(to, filepath.Base(from))
 	}
 
-	err = os.WriteFile(to, body, 0600)
+	return to
+}
+
+// isFolder returns whether the pCloud path p is a folder.
+func (cli *CLI) isFolder(ctx context.Context, p string) bool {
+	_, err := cli.pCloudClient.List(ctx, sdk.ByPath(p), sdk.ListOptions{NoFiles: true})
+	return err == nil
+}
+
+// isRemote returns whether p is a pCloud path.
+func isRemote(p string) bool {
+	return strings.HasPrefix(p, PCloudPrefix)
+}
+
+// remotePath returns the pCloud path p, without PCloudPrefix.
+func remotePath(p string) string {
+	return path.Clean("/" + strings.TrimPrefix(p, PCloudPrefix))
+}
+
+// isNotExist returns whether err, returned by the SDK or by remote, reports a file or folder
+// that does not exist.
+func isNotExist(err error) bool {
+	switch sdk.ErrorCode(err) {
+	case sdk.ErrFileNotFound, sdk.ErrDirectoryNotExists, sdk.ErrComponentOfParentDirectoryNotExists:
+		return true
+	}
 
-	return errors.WithStack(err)
+	return errors.Is(err, fs.ErrNotExist)
 }
@@ -0,0 +1,216 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/cli"
+	"github.com/seborama/pcloud-sdk/sdk/sdktest"
+)
+
+func newTestCLI(t *testing.T) (*sdktest.Server, *cli.CLI) {
+	t.Helper()
+
+	srv := sdktest.NewServer()
+	t.Cleanup(srv.Close)
+
+	_, err := srv.WriteFile("/Docs/a.txt", []byte("0123456789"))
+	require.NoError(t, err)
+	_, err = srv.WriteFile("/Docs/Sub/b.txt", []byte("hello"))
+	require.NoError(t, err)
+	_, err = srv.MkdirAll("/Archive")
+	require.NoError(t, err)
+
+	return srv, cli.NewCLI(srv.NewClient(), srv.Client())
+}
+
+func TestCLI_List(t *testing.T) {
+	ctx := context.Background()
+	_, c := newTestCLI(t)
+
+	var out bytes.Buffer
+	require.NoError(t, c.List(ctx, &out, "r:/Docs", false, cli.OutputPlain))
+	assert.Equal(t, "Sub/\na.txt\n", out.String())
+
+	out.Reset()
+	require.NoError(t, c.List(ctx, &out, "/", true, cli.OutputPlain))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[0], "d "))
+	assert.True(t, strings.HasSuffix(lines[0], " Archive/"))
+
+	out.Reset()
+	require.NoError(t, c.List(ctx, &out, "/Docs/a.txt", true, cli.OutputPlain))
+	assert.Contains(t, out.String(), "-           10 ")
+	assert.True(t, strings.HasSuffix(out.String(), " a.txt\n"))
+
+	assert.Error(t, c.List(ctx, &out, "/missing", false, cli.OutputPlain))
+}
+
+func TestCLI_Cat(t *testing.T) {
+	ctx := context.Background()
+	_, c := newTestCLI(t)
+
+	var out bytes.Buffer
+	require.NoError(t, c.Cat(ctx, &out, "/Docs/a.txt"))
+	assert.Equal(t, "0123456789", out.String())
+
+	assert.Error(t, c.Cat(ctx, &out, "/Docs/missing.txt"))
+}
+
+func TestCLI_CatRange(t *testing.T) {
+	ctx := context.Background()
+	_, c := newTestCLI(t)
+
+	for _, tc := range []struct {
+		rng  string
+		want string
+	}{
+		{"2-5", "2345"},
+		{"7-", "789"},
+		{"-3", "789"},
+		{"-20", "0123456789"},
+		{"8-20", "89"},
+		{"12-", ""},
+	} {
+		offset, length, err := cli.ParseRange(tc.rng)
+		require.NoError(t, err, tc.rng)
+
+		var out bytes.Buffer
+		require.NoError(t, c.CatRange(ctx, &out, "r:/Docs/a.txt", offset, length), tc.rng)
+		assert.Equal(t, tc.want, out.String(), tc.rng)
+	}
+
+	for _, rng := range []string{"", "-", "5", "a-b", "5-2", "-0", "1--2"} {
+		_, _, err := cli.ParseRange(rng)
+		assert.Error(t, err, rng)
+	}
+}
+
+func TestCLI_Put(t *testing.T) {
+	ctx := context.Background()
+	srv, c := newTestCLI(t)
+
+	require.NoError(t, c.Put(ctx, strings.NewReader("from a pipe"), "New/piped.txt"))
+
+	data, err := srv.ReadFile("/New/piped.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "from a pipe", string(data))
+
+	require.NoError(t, c.Put(ctx, strings.NewReader("replaced"), "r:/Docs/a.txt"))
+
+	data, err = srv.ReadFile("/Docs/a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "replaced", string(data))
+
+	assert.Error(t, c.Put(ctx, strings.NewReader("x"), "r:/Docs"))
+}
+
+func TestCLI_MkdirRemove(t *testing.T) {
+	ctx := context.Background()
+	srv, c := newTestCLI(t)
+
+	require.NoError(t, c.Mkdir(ctx, "/New", false))
+	assert.Error(t, c.Mkdir(ctx, "/Other/Sub", false))
+	require.NoError(t, c.Mkdir(ctx, "r:/Other/Sub", true))
+	require.NoError(t, c.Mkdir(ctx, "/Other/Sub", true))
+	assert.Error(t, c.Mkdir(ctx, "/Docs/a.txt/x", true))
+
+	require.NoError(t, c.Remove(ctx, "/New", false))
+	assert.Error(t, c.Remove(ctx, "/Other", false))
+	require.NoError(t, c.Remove(ctx, "/Other", true))
+	require.NoError(t, c.Remove(ctx, "/Docs/a.txt", false))
+	assert.Error(t, c.Remove(ctx, "/Docs/a.txt", false))
+	assert.Error(t, c.Remove(ctx, "/", true))
+
+	_, err := srv.ReadFile("/Docs/Sub/b.txt")
+	require.NoError(t, err)
+}
+
+func TestCLI_Copy(t *testing.T) {
+	ctx := context.Background()
+	srv, c := newTestCLI(t)
+	dir := t.TempDir()
+
+	// pCloud to local, into a folder.
+	require.NoError(t, c.Copy(ctx, "r:/Docs/a.txt", dir))
+	data, err := os.ReadFile(filepath.Join(dir, "a.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789", string(data))
+
+	// local to pCloud, as a new file and into a folder.
+	local := filepath.Join(dir, "local.txt")
+	require.NoError(t, os.WriteFile(local, []byte("local"), 0o600))
+
+	require.NoError(t, c.Copy(ctx, local, "r:/Docs/copy.txt"))
+	data, err = srv.ReadFile("/Docs/copy.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "local", string(data))
+
+	require.NoError(t, c.Copy(ctx, local, "r:/Archive"))
+	data, err = srv.ReadFile("/Archive/local.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "local", string(data))
+
+	// within pCloud: files, folders into existing folders and as new folders.
+	require.NoError(t, c.Copy(ctx, "r:/Docs/a.txt", "r:/Archive"))
+	data, err = srv.ReadFile("/Archive/a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789", string(data))
+
+	require.NoError(t, c.Copy(ctx, "r:/Docs/Sub", "r:/Archive"))
+	data, err = srv.ReadFile("/Archive/Sub/b.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+
+	require.NoError(t, c.Copy(ctx, "r:/Docs/Sub", "r:/Sub2"))
+	data, err = srv.ReadFile("/Sub2/b.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+
+	assert.Error(t, c.Copy(ctx, "r:/missing", "r:/Sub3"))
+	_, err = srv.ReadFile("/Sub3")
+	assert.Error(t, err)
+
+	assert.Error(t, c.Copy(ctx, local, dir))
+	assert.Error(t, c.Copy(ctx, dir, "r:/Archive"))
+}
+
+func TestCLI_Move(t *testing.T) {
+	ctx := context.Background()
+	srv, c := newTestCLI(t)
+	dir := t.TempDir()
+
+	require.NoError(t, c.Move(ctx, "r:/Docs/a.txt", "r:/Archive"))
+	data, err := srv.ReadFile("/Archive/a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789", string(data))
+
+	require.NoError(t, c.Move(ctx, "r:/Docs/Sub", "r:/Moved"))
+	data, err = srv.ReadFile("/Moved/b.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+
+	require.NoError(t, c.Move(ctx, "r:/Moved/b.txt", dir))
+	data, err = os.ReadFile(filepath.Join(dir, "b.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+	_, err = srv.ReadFile("/Moved/b.txt")
+	assert.Error(t, err)
+
+	require.NoError(t, c.Move(ctx, filepath.Join(dir, "b.txt"), "r:/Moved/c.txt"))
+	data, err = srv.ReadFile("/Moved/c.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+	_, err = os.Stat(filepath.Join(dir, "b.txt"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	assert.Error(t, c.Move(ctx, "r:/missing", "r:/Archive"))
+}
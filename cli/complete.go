@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+// CompletePath returns the completions of the pCloud path prefix, for shell completion: the
+// entries of its folder whose name starts with its last element, the names of the folders
+// followed by a slash. The completions keep the form of prefix: with or without PCloudPrefix,
+// absolute or relative to the root folder.
+func (cli *CLI) CompletePath(ctx context.Context, prefix string) ([]string, error) {
+	dir, name := "", prefix
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		dir, name = prefix[:i+1], prefix[i+1:]
+	} else if strings.HasPrefix(prefix, PCloudPrefix) {
+		dir, name = PCloudPrefix+"/", strings.TrimPrefix(prefix, PCloudPrefix)
+	}
+
+	lf, err := cli.pCloudClient.List(ctx, sdk.ByPath(remotePath(dir)), sdk.ListOptions{})
+	if err != nil {
+		if isNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var completions []string
+
+	for _, m := range lf.Metadata.Contents {
+		if !strings.HasPrefix(m.Name, name) {
+			continue
+		}
+
+		c := dir + m.Name
+		if m.IsFolder {
+			c += "/"
+		}
+
+		completions = append(completions, c)
+	}
+
+	sort.Strings(completions)
+
+	return completions, nil
+}
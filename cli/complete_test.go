@@ -0,0 +1,33 @@
+package cli_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCLI_CompletePath(t *testing.T) {
+	ctx := context.Background()
+	srv, c := newTestCLI(t)
+
+	_, err := srv.MkdirAll("/Downloads")
+	require.NoError(t, err)
+
+	for prefix, want := range map[string][]string{
+		"":            {"Archive/", "Docs/", "Downloads/"},
+		"/":           {"/Archive/", "/Docs/", "/Downloads/"},
+		"r:":          {"r:/Archive/", "r:/Docs/", "r:/Downloads/"},
+		"r:/Do":       {"r:/Docs/", "r:/Downloads/"},
+		"Docs/":       {"Docs/Sub/", "Docs/a.txt"},
+		"r:/Docs/a":   {"r:/Docs/a.txt"},
+		"/Docs/Sub/b": {"/Docs/Sub/b.txt"},
+		"/Docs/x":     nil,
+		"/Missing/":   nil,
+	} {
+		completions, err := c.CompletePath(ctx, prefix)
+		require.NoError(t, err, prefix)
+		assert.Equal(t, want, completions, prefix)
+	}
+}
@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+// The orders of the folders listed by DiskUsage.
+const (
+	SortBySize  = "size"
+	SortByFiles = "files"
+	SortByName  = "name"
+)
+
+// FolderUsage is the space used by a pCloud folder and its contents.
+type FolderUsage struct {
+	Path  string `json:"path"`
+	Size  int64  `json:"size"`
+	Files int    `json:"files"`
+	// Depth is the depth of the folder below the folder analysed, which has a depth of 0.
+	Depth int `json:"depth"`
+}
+
+// DiskUsage writes the space used by the pCloud folder p and its subfolders to w, one folder
+// per line with its size, its number of files and its path, the sizes and the counts including
+// the contents of the subfolders. Only the subfolders down to maxDepth levels below p are
+// listed (all of them when maxDepth is negative). sortBy is SortBySize (the largest first),
+// SortByFiles (the most files first) or SortByName (the path, with the folders before their
+// contents). Depending on output, the folders are written as a table or a JSON array of
+// FolderUsage instead.
+func (cli *CLI) DiskUsage(ctx context.Context, w io.Writer, p string, maxDepth int, sortBy string, output Output) error {
+	usage, err := cli.FolderUsages(ctx, p, maxDepth)
+	if err != nil {
+		return err
+	}
+
+	switch sortBy {
+	case SortBySize:
+		sort.SliceStable(usage, func(i, j int) bool { return usage[i].Size > usage[j].Size })
+	case SortByFiles:
+		sort.SliceStable(usage, func(i, j int) bool { return usage[i].Files > usage[j].Files })
+	case SortByName, "":
+	default:
+		return errors.Errorf("%s: unknown order, use '%s', '%s' or '%s'", sortBy, SortBySize, SortByFiles, SortByName)
+	}
+
+	return writeList(w, output, usage, func(u FolderUsage) error {
+		_, err := fmt.Fprintf(w, "%10s %8d %s\n", FormatSize(u.Size), u.Files, u.Path)
+		return errors.WithStack(err)
+	})
+}
+
+func (FolderUsage) tableHeader() []string {
+	return []string{"SIZE", "FILES", "PATH"}
+}
+
+func (u FolderUsage) tableRow() []string {
+	return []string{FormatSize(u.Size), strconv.Itoa(u.Files), u.Path}
+}
+
+// FolderUsages returns the space used by the pCloud folder p and its subfolders, down to
+// maxDepth levels below p (all of them when maxDepth is negative). The folders are sorted by
+// path, with the folders before their contents.
+// The whole tree of p is listed with a single recursive call to pCloud.
+func (cli *CLI) FolderUsages(ctx context.Context, p string, maxDepth int) ([]FolderUsage, error) {
+	p = remotePath(p)
+
+	lf, err := cli.pCloudClient.List(ctx, sdk.ByPath(p), sdk.ListOptions{Recursive: true})
+	if err != nil {
+		return nil, err
+	}
+
+	var usage []FolderUsage
+
+	var walk func(m *sdk.Metadata, p string, depth int) FolderUsage
+	walk = func(m *sdk.Metadata, p string, depth int) FolderUsage {
+		u := FolderUsage{Path: p, Depth: depth}
+
+		// the entry of the folder is inserted before those of its subfolders.
+		i := len(usage)
+		if maxDepth < 0 || depth <= maxDepth {
+			usage = append(usage, u)
+		}
+
+		entries := append([]*sdk.Metadata(nil), m.Contents...)
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+
+		for _, c := range entries {
+			if !c.IsFolder {
+				u.Size += int64(c.Size)
+				u.Files++
+				continue
+			}
+
+			cu := walk(c, path.Join(p, c.Name), depth+1)
+			u.Size += cu.Size
+			u.Files += cu.Files
+		}
+
+		if maxDepth < 0 || depth <= maxDepth {
+			usage[i] = u
+		}
+
+		return u
+	}
+
+	walk(lf.Metadata, p, 0)
+
+	return usage, nil
+}
@@ -0,0 +1,52 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/cli"
+)
+
+func TestCLI_FolderUsages(t *testing.T) {
+	ctx := context.Background()
+	srv, c := newTestCLI(t)
+
+	_, err := srv.WriteFile("/Docs/Sub/Deep/c.txt", []byte("0123456789012345"))
+	require.NoError(t, err)
+
+	usage, err := c.FolderUsages(ctx, "/", -1)
+	require.NoError(t, err)
+	assert.Equal(t, []cli.FolderUsage{
+		{Path: "/", Size: 31, Files: 3},
+		{Path: "/Archive", Depth: 1},
+		{Path: "/Docs", Size: 31, Files: 3, Depth: 1},
+		{Path: "/Docs/Sub", Size: 21, Files: 2, Depth: 2},
+		{Path: "/Docs/Sub/Deep", Size: 16, Files: 1, Depth: 3},
+	}, usage)
+
+	usage, err = c.FolderUsages(ctx, "r:/Docs", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []cli.FolderUsage{
+		{Path: "/Docs", Size: 31, Files: 3},
+		{Path: "/Docs/Sub", Size: 21, Files: 2, Depth: 1},
+	}, usage)
+}
+
+func TestCLI_DiskUsage(t *testing.T) {
+	ctx := context.Background()
+	_, c := newTestCLI(t)
+
+	var out bytes.Buffer
+	require.NoError(t, c.DiskUsage(ctx, &out, "/", 1, cli.SortBySize, cli.OutputPlain))
+	assert.Equal(t, ""+
+		"      15 B        2 /\n"+
+		"      15 B        2 /Docs\n"+
+		"       0 B        0 /Archive\n", out.String())
+
+	assert.Error(t, c.DiskUsage(ctx, &out, "/", 1, "colour", cli.OutputPlain))
+	assert.Error(t, c.DiskUsage(ctx, &out, "/missing", 1, cli.SortByName, cli.OutputPlain))
+}
@@ -0,0 +1,217 @@
+package cli
+
+import (
+	"context"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+// The types of the entries found by Find.
+const (
+	FindFiles   = "f"
+	FindFolders = "d"
+)
+
+// FindOption is a functional parameter for Find.
+type FindOption func(*findConfig)
+
+type findConfig struct {
+	names     []string
+	minSize   int64
+	maxSize   int64
+	newerThan time.Time
+	olderThan time.Time
+	kind      string
+}
+
+// WithName only finds the files and folders whose name matches one of the glob patterns (see
+// path.Match).
+func WithName(patterns ...string) FindOption {
+	return func(fc *findConfig) {
+		fc.names = append(fc.names, patterns...)
+	}
+}
+
+// WithMinSize only finds the files of at least size bytes. Folders have no size: they are not
+// found when a minimum size is set.
+func WithMinSize(size int64) FindOption {
+	return func(fc *findConfig) {
+		fc.minSize = size
+	}
+}
+
+// WithMaxFindSize only finds the files of at most size bytes. 0, the default, means no limit.
+func WithMaxFindSize(size int64) FindOption {
+	return func(fc *findConfig) {
+		fc.maxSize = size
+	}
+}
+
+// WithNewerThan only finds the files and folders modified after t.
+func WithNewerThan(t time.Time) FindOption {
+	return func(fc *findConfig) {
+		fc.newerThan = t
+	}
+}
+
+// WithOlderThan only finds the files and folders modified before t.
+func WithOlderThan(t time.Time) FindOption {
+	return func(fc *findConfig) {
+		fc.olderThan = t
+	}
+}
+
+// WithType only finds the files (FindFiles) or the folders (FindFolders).
+func WithType(kind string) FindOption {
+	return func(fc *findConfig) {
+		fc.kind = kind
+	}
+}
+
+// Find calls fn with the path and the metadata of each file and folder below the pCloud folder
+// p that matches all the options, in the order of their paths, with the folders before their
+// contents. fn may return an error to stop the search: Find then returns it.
+// The whole tree of p is listed with a single recursive call to pCloud, and filtered locally.
+func (cli *CLI) Find(ctx context.Context, p string, fn func(p string, m *sdk.Metadata) error, opts ...FindOption) error {
+	fc := &findConfig{}
+	for _, opt := range opts {
+		opt(fc)
+	}
+
+	switch fc.kind {
+	case "", FindFiles, FindFolders:
+	default:
+		return errors.Errorf("%s: unknown type, use '%s' for files or '%s' for folders", fc.kind, FindFiles, FindFolders)
+	}
+
+	for _, pattern := range fc.names {
+		_, err := path.Match(pattern, "")
+		if err != nil {
+			return errors.Wrap(err, pattern)
+		}
+	}
+
+	p = remotePath(p)
+
+	lf, err := cli.pCloudClient.List(ctx, sdk.ByPath(p), sdk.ListOptions{Recursive: true})
+	if err != nil {
+		return err
+	}
+
+	var walk func(m *sdk.Metadata, p string) error
+	walk = func(m *sdk.Metadata, p string) error {
+		entries := append([]*sdk.Metadata(nil), m.Contents...)
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+
+		for _, c := range entries {
+			cp := path.Join(p, c.Name)
+
+			if fc.matches(c) {
+				err := fn(cp, c)
+				if err != nil {
+					return err
+				}
+			}
+
+			if c.IsFolder {
+				err := walk(c, cp)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	}
+
+	return walk(lf.Metadata, p)
+}
+
+// matches returns whether m matches all the criteria of fc.
+func (fc *findConfig) matches(m *sdk.Metadata) bool {
+	switch {
+	case fc.kind == FindFiles && m.IsFolder, fc.kind == FindFolders && !m.IsFolder:
+		return false
+
+	case fc.minSize > 0 && (m.IsFolder || int64(m.Size) < fc.minSize):
+		return false
+
+	case fc.maxSize > 0 && (m.IsFolder || int64(m.Size) > fc.maxSize):
+		return false
+	}
+
+	if len(fc.names) > 0 {
+		found := false
+		for _, pattern := range fc.names {
+			if ok, _ := path.Match(pattern, m.Name); ok {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if !fc.newerThan.IsZero() || !fc.olderThan.IsZero() {
+		if m.Modified.IsZero() {
+			return false
+		}
+		if !fc.newerThan.IsZero() && !m.Modified.After(fc.newerThan) {
+			return false
+		}
+		if !fc.olderThan.IsZero() && !m.Modified.Before(fc.olderThan) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ParseSize parses a size in bytes, optionally followed by the unit "K", "M", "G" or "T" (with
+// or without a trailing "B" or "iB"), which are powers of 1024, such as "1.5G".
+func ParseSize(s string) (int64, error) {
+	n := strings.TrimSuffix(strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(s)), "B"), "I")
+
+	mult := int64(1)
+	if i := strings.IndexAny(n, "KMGT"); i >= 0 && i == len(n)-1 {
+		mult = int64(1) << (10 * (strings.IndexByte("KMGT", n[i]) + 1))
+		n = n[:i]
+	}
+
+	f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+	if err != nil || f < 0 {
+		return 0, errors.Errorf("%s: invalid size, use a number of bytes such as '2048', or '100K', '1.5G'", s)
+	}
+
+	return int64(f * float64(mult)), nil
+}
+
+// ParseSince parses a point in the past: a duration before now such as "36h" or "7d", or a date
+// such as "2024-12-31" (its start, in local time).
+func ParseSince(s string, now time.Time) (time.Time, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err == nil && n > 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+
+	if d, err := time.ParseDuration(s); err == nil && d > 0 {
+		return now.Add(-d), nil
+	}
+
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		return time.Time{}, errors.Errorf("%s: invalid time, use a duration such as '36h' or '7d', or a date such as '2024-12-31'", s)
+	}
+
+	return t, nil
+}
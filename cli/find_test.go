@@ -0,0 +1,94 @@
+package cli_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/cli"
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+func TestCLI_Find(t *testing.T) {
+	ctx := context.Background()
+	_, c := newTestCLI(t)
+
+	find := func(p string, opts ...cli.FindOption) []string {
+		t.Helper()
+
+		var found []string
+		err := c.Find(ctx, p, func(p string, _ *sdk.Metadata) error {
+			found = append(found, p)
+			return nil
+		}, opts...)
+		require.NoError(t, err)
+
+		return found
+	}
+
+	assert.Equal(t, []string{"/Archive", "/Docs", "/Docs/Sub", "/Docs/Sub/b.txt", "/Docs/a.txt"}, find("/"))
+	assert.Equal(t, []string{"/Docs/Sub/b.txt", "/Docs/a.txt"}, find("r:/Docs", cli.WithType(cli.FindFiles)))
+	assert.Equal(t, []string{"/Archive", "/Docs", "/Docs/Sub"}, find("/", cli.WithType(cli.FindFolders)))
+	assert.Equal(t, []string{"/Docs/a.txt"}, find("/", cli.WithName("a.*", "*.md")))
+	assert.Equal(t, []string{"/Docs/a.txt"}, find("/", cli.WithMinSize(6)))
+	assert.Equal(t, []string{"/Docs/Sub/b.txt"}, find("/", cli.WithMaxFindSize(5)))
+	assert.Equal(t, []string{"/Docs/Sub/b.txt", "/Docs/a.txt"}, find("/", cli.WithName("*.txt"), cli.WithNewerThan(time.Now().Add(-time.Hour))))
+	assert.Empty(t, find("/", cli.WithNewerThan(time.Now().Add(time.Hour))))
+	assert.Empty(t, find("/", cli.WithOlderThan(time.Now().Add(-time.Hour))))
+
+	errStop := errors.New("stop")
+	n := 0
+	err := c.Find(ctx, "/", func(string, *sdk.Metadata) error {
+		n++
+		return errStop
+	})
+	assert.ErrorIs(t, err, errStop)
+	assert.Equal(t, 1, n)
+
+	assert.Error(t, c.Find(ctx, "/", nil, cli.WithType("x")))
+	assert.Error(t, c.Find(ctx, "/", nil, cli.WithName("[")))
+	assert.Error(t, c.Find(ctx, "/Missing", nil))
+}
+
+func TestParseSize(t *testing.T) {
+	for s, want := range map[string]int64{
+		"2048":  2048,
+		"100K":  100 << 10,
+		"100kb": 100 << 10,
+		"1.5G":  3 << 29,
+		"2MiB":  2 << 20,
+		"1T":    1 << 40,
+	} {
+		got, err := cli.ParseSize(s)
+		require.NoError(t, err, s)
+		assert.Equal(t, want, got, s)
+	}
+
+	for _, s := range []string{"", "K", "-1", "12X", "1KK"} {
+		_, err := cli.ParseSize(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.Local)
+
+	got, err := cli.ParseSince("7d", now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 6, 8, 12, 0, 0, 0, time.Local), got)
+
+	got, err = cli.ParseSince("36h", now)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(-36*time.Hour), got)
+
+	got, err = cli.ParseSince("2024-01-31", now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 1, 31, 0, 0, 0, 0, time.Local), got)
+
+	_, err = cli.ParseSince("yesterday", now)
+	assert.Error(t, err)
+}
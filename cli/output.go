@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+)
+
+// Output is the format in which the commands write their results.
+type Output string
+
+// The formats of Output.
+const (
+	// OutputPlain is the text of each command, meant to be read.
+	OutputPlain Output = "plain"
+	// OutputTable lays the listings out as columns, under a line of headers.
+	OutputTable Output = "table"
+	// OutputJSON writes the results as JSON, for scripts. Each command documents the type of its
+	// results, whose JSON form is stable.
+	OutputJSON Output = "json"
+)
+
+// ParseOutput parses the name of an Output. The empty string is OutputPlain.
+func ParseOutput(s string) (Output, error) {
+	switch o := Output(s); o {
+	case "":
+		return OutputPlain, nil
+	case OutputPlain, OutputTable, OutputJSON:
+		return o, nil
+	}
+
+	return "", errors.Errorf("%s: unknown output, use '%s', '%s' or '%s'", s, OutputPlain, OutputTable, OutputJSON)
+}
+
+// tabular is implemented by the entries of the listings, for OutputTable.
+type tabular interface {
+	tableHeader() []string
+	tableRow() []string
+}
+
+// writeList writes items to w in the format output: as a JSON array, as a table, or with plain
+// for each item.
+func writeList[T tabular](w io.Writer, output Output, items []T, plain func(T) error) error {
+	switch output {
+	case OutputJSON:
+		if items == nil {
+			items = []T{}
+		}
+		return writeJSON(w, items)
+
+	case OutputTable:
+		var zero T
+		return writeTable(w, zero.tableHeader(), func(row func([]string)) {
+			for _, item := range items {
+				row(item.tableRow())
+			}
+		})
+	}
+
+	for _, item := range items {
+		err := plain(item)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeTable writes the header and the rows that rows gives to w, as aligned columns.
+func writeTable(w io.Writer, header []string, rows func(row func([]string))) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	_, _ = fmt.Fprintln(tw, strings.Join(header, "\t"))
+	rows(func(cells []string) {
+		_, _ = fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	})
+
+	return errors.WithStack(tw.Flush())
+}
+
+// writeJSON writes v to w, as indented JSON.
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return errors.WithStack(enc.Encode(v))
+}
+
+// writeJSONLine writes v to w, as JSON on a single line.
+func writeJSONLine(w io.Writer, v any) error {
+	return errors.WithStack(json.NewEncoder(w).Encode(v))
+}
@@ -0,0 +1,60 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/cli"
+)
+
+func TestParseOutput(t *testing.T) {
+	for s, want := range map[string]cli.Output{
+		"":      cli.OutputPlain,
+		"plain": cli.OutputPlain,
+		"table": cli.OutputTable,
+		"json":  cli.OutputJSON,
+	} {
+		got, err := cli.ParseOutput(s)
+		require.NoError(t, err, s)
+		assert.Equal(t, want, got, s)
+	}
+
+	_, err := cli.ParseOutput("yaml")
+	assert.Error(t, err)
+}
+
+func TestCLI_List_Output(t *testing.T) {
+	ctx := context.Background()
+	_, c := newTestCLI(t)
+
+	var out bytes.Buffer
+	require.NoError(t, c.List(ctx, &out, "r:/Docs", false, cli.OutputTable))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, []string{"TYPE", "SIZE", "MODIFIED", "PATH"}, strings.Fields(lines[0]))
+	assert.Equal(t, "d", strings.Fields(lines[1])[0])
+	assert.True(t, strings.HasSuffix(lines[1], " /Docs/Sub"), lines[1])
+	assert.Equal(t, []string{"-", "10"}, strings.Fields(lines[2])[:2])
+	assert.True(t, strings.HasSuffix(lines[2], " /Docs/a.txt"), lines[2])
+
+	out.Reset()
+	require.NoError(t, c.List(ctx, &out, "r:/Docs", false, cli.OutputJSON))
+	var entries []cli.Entry
+	require.NoError(t, json.Unmarshal(out.Bytes(), &entries))
+	require.Len(t, entries, 2)
+	assert.Equal(t, "/Docs/Sub", entries[0].Path)
+	assert.True(t, entries[0].IsFolder)
+	assert.Equal(t, "/Docs/a.txt", entries[1].Path)
+	assert.Equal(t, uint64(10), entries[1].Size)
+	assert.NotNil(t, entries[1].Modified)
+
+	out.Reset()
+	require.NoError(t, c.List(ctx, &out, "r:/Archive", false, cli.OutputJSON))
+	assert.Equal(t, "[]\n", out.String())
+}
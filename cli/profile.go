@@ -0,0 +1,220 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+// DefaultProfile is the name of the profile used when none is selected.
+const DefaultProfile = "default"
+
+// Profile holds the session of a pCloud account, saved by the login command so that the other
+// commands need not log in again.
+// Only one of AuthToken and OAuth2AccessToken is set.
+type Profile struct {
+	Username          string     `json:"username,omitempty"`
+	Region            sdk.Region `json:"region,omitempty"`
+	AuthToken         string     `json:"auth_token,omitempty"`
+	OAuth2AccessToken string     `json:"oauth2_access_token,omitempty"`
+	// Defaults are edited by hand in the configuration file.
+	Defaults *Defaults `json:"defaults,omitempty"`
+}
+
+// Defaults are the values that the commands run with a profile use for the options that are not
+// given on the command line or by environment variables.
+type Defaults struct {
+	// Parallel is the number of files transferred at the same time (see WithParallelism).
+	Parallel int `json:"parallel,omitempty"`
+	// BandwidthLimit is the rate of the transfers, in bytes per second as parsed by ParseSize,
+	// such as "1M" (see WithBandwidthLimit).
+	BandwidthLimit string `json:"bandwidth_limit,omitempty"`
+	// Exclude are the glob patterns of the files and folders not transferred (see WithExclude).
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// ClientOptions returns the options that create an sdk.Client authenticated by the session of
+// the profile.
+func (p *Profile) ClientOptions() []sdk.Option {
+	var opts []sdk.Option
+
+	if p.Region != "" {
+		opts = append(opts, sdk.WithRegion(p.Region))
+	}
+
+	switch {
+	case p.OAuth2AccessToken != "":
+		opts = append(opts, sdk.WithOAuth2AccessToken(p.OAuth2AccessToken))
+	case p.AuthToken != "":
+		opts = append(opts, sdk.WithAuthToken(p.AuthToken))
+	}
+
+	return opts
+}
+
+// Config is the configuration file of the command line, which holds the profiles of the pCloud
+// accounts that the user logged in to.
+// The file contains secrets: it is only readable by its owner.
+type Config struct {
+	Profiles map[string]*Profile `json:"profiles"`
+
+	path string
+}
+
+// DefaultConfigPath returns the location of the configuration file in the configuration
+// folder of the user, as given by os.UserConfigDir.
+func DefaultConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return filepath.Join(dir, "pcloud", "config.json"), nil
+}
+
+// LoadConfig reads the configuration file at path. An empty Config is returned when the file
+// does not exist.
+func LoadConfig(path string) (*Config, error) {
+	cfg := &Config{
+		Profiles: map[string]*Profile{},
+		path:     path,
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return cfg, nil
+		}
+		return nil, errors.WithStack(err)
+	}
+
+	err = json.Unmarshal(data, cfg)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s: invalid configuration file", path)
+	}
+
+	if cfg.Profiles == nil {
+		cfg.Profiles = map[string]*Profile{}
+	}
+
+	return cfg, nil
+}
+
+// Path returns the location of the configuration file.
+func (cfg *Config) Path() string {
+	return cfg.path
+}
+
+// Profile returns the profile called name.
+func (cfg *Config) Profile(name string) (*Profile, error) {
+	p, ok := cfg.Profiles[name]
+	if !ok {
+		return nil, errors.WithStack(&ProfileNotFoundError{Name: name})
+	}
+
+	return p, nil
+}
+
+// ProfileNotFoundError is the error of Config.Profile when the profile does not exist, which
+// means that the user did not log in with it.
+type ProfileNotFoundError struct {
+	Name string
+}
+
+func (e *ProfileNotFoundError) Error() string {
+	return fmt.Sprintf("profile '%s' not found: log in with 'pcloud --profile %s login'", e.Name, e.Name)
+}
+
+// ProfileNames returns the names of the profiles, in alphabetical order.
+func (cfg *Config) ProfileNames() []string {
+	names := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// ProfileEntry describes a profile, without its secrets, as listed by WriteProfiles.
+type ProfileEntry struct {
+	Name     string     `json:"name"`
+	Region   sdk.Region `json:"region,omitempty"`
+	Username string     `json:"username,omitempty"`
+	// OAuth2 is set when the session was opened with OAuth2, rather than with a username.
+	OAuth2 bool `json:"oauth2"`
+}
+
+// WriteProfiles writes the profiles to w, in alphabetical order and one per line: their name,
+// their region and their username. Depending on output, it writes them as a table or a JSON
+// array of ProfileEntry instead.
+func (cfg *Config) WriteProfiles(w io.Writer, output Output) error {
+	entries := make([]ProfileEntry, 0, len(cfg.Profiles))
+
+	for _, name := range cfg.ProfileNames() {
+		p := cfg.Profiles[name]
+		entries = append(entries, ProfileEntry{Name: name, Region: p.Region, Username: p.Username, OAuth2: p.OAuth2AccessToken != ""})
+	}
+
+	return writeList(w, output, entries, func(e ProfileEntry) error {
+		_, err := fmt.Fprintf(w, "%-16s %-16s %s\n", e.Name, e.Region, e.account())
+		return errors.WithStack(err)
+	})
+}
+
+func (ProfileEntry) tableHeader() []string {
+	return []string{"NAME", "REGION", "ACCOUNT"}
+}
+
+func (e ProfileEntry) tableRow() []string {
+	return []string{e.Name, string(e.Region), e.account()}
+}
+
+// account returns the username of e, or "(OAuth2)" for the OAuth2 sessions.
+func (e ProfileEntry) account() string {
+	if e.OAuth2 {
+		return "(OAuth2)"
+	}
+	return e.Username
+}
+
+// Save writes the configuration to the file it was loaded from. The file is replaced
+// atomically, so that a failure does not lose the profiles it held.
+func (cfg *Config) Save() error {
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	dir := filepath.Dir(cfg.path)
+
+	err = os.MkdirAll(dir, 0700)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	f, err := os.CreateTemp(dir, ".config-*.json")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() { _ = os.Remove(f.Name()) }()
+
+	// CreateTemp creates the file with mode 0600.
+	_, err = f.Write(append(data, '\n'))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return errors.WithStack(os.Rename(f.Name(), cfg.path))
+}
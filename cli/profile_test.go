@@ -0,0 +1,66 @@
+package cli_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/cli"
+	"github.com/seborama/pcloud-sdk/sdk"
+	"github.com/seborama/pcloud-sdk/sdk/sdktest"
+)
+
+func TestConfig_SaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pcloud", "config.json")
+
+	cfg, err := cli.LoadConfig(path)
+	require.NoError(t, err)
+	assert.Empty(t, cfg.ProfileNames())
+
+	_, err = cfg.Profile(cli.DefaultProfile)
+	var profileErr *cli.ProfileNotFoundError
+	require.ErrorAs(t, err, &profileErr)
+	assert.Equal(t, cli.DefaultProfile, profileErr.Name)
+
+	defaults := &cli.Defaults{Parallel: 8, BandwidthLimit: "1M", Exclude: []string{".git"}}
+	cfg.Profiles["work"] = &cli.Profile{Username: "me@work.com", Region: sdk.RegionUS, AuthToken: "token", Defaults: defaults}
+	cfg.Profiles[cli.DefaultProfile] = &cli.Profile{Region: sdk.RegionEU, OAuth2AccessToken: "access"}
+	require.NoError(t, cfg.Save())
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	cfg, err = cli.LoadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{cli.DefaultProfile, "work"}, cfg.ProfileNames())
+
+	p, err := cfg.Profile("work")
+	require.NoError(t, err)
+	assert.Equal(t, &cli.Profile{Username: "me@work.com", Region: sdk.RegionUS, AuthToken: "token", Defaults: defaults}, p)
+}
+
+func TestProfile_ClientOptions(t *testing.T) {
+	srv := sdktest.NewServer(sdktest.WithCredentials("user@example.com", "secret"))
+	defer srv.Close()
+
+	ctx := context.Background()
+
+	pcc := srv.NewClient()
+	err := pcc.Login(ctx, "", sdk.WithGlobalOptionUsername("user@example.com"), sdk.WithGlobalOptionPassword("secret"))
+	require.NoError(t, err)
+
+	p := &cli.Profile{AuthToken: pcc.AuthToken()}
+
+	_, err = srv.NewClient(p.ClientOptions()...).List(ctx, sdk.ByID(sdk.RootFolderID), sdk.ListOptions{})
+	require.NoError(t, err)
+
+	p = &cli.Profile{AuthToken: "invalid"}
+
+	_, err = srv.NewClient(p.ClientOptions()...).List(ctx, sdk.ByID(sdk.RootFolderID), sdk.ListOptions{})
+	assert.Equal(t, sdk.ErrLoginRequired, sdk.ErrorCode(err))
+}
@@ -0,0 +1,287 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+// Link is a public link, as listed by ListLinks.
+type Link struct {
+	ID           uint64     `json:"id"`
+	Link         string     `json:"link"`
+	Name         string     `json:"name"`
+	IsFolder     bool       `json:"is_folder"`
+	Created      time.Time  `json:"created"`
+	Expires      *time.Time `json:"expires,omitempty"`
+	Downloads    uint64     `json:"downloads"`
+	MaxDownloads uint64     `json:"max_downloads,omitempty"`
+	HasPassword  bool       `json:"has_password"`
+}
+
+// The kinds of ShareEntry.
+const (
+	ShareIncoming        = "incoming"
+	ShareOutgoing        = "outgoing"
+	ShareRequestIncoming = "incoming-request"
+	ShareRequestOutgoing = "outgoing-request"
+)
+
+// ShareEntry is a share, or a pending share request, as listed by ListShares.
+type ShareEntry struct {
+	Kind string `json:"kind"`
+	// ID is the shareid of the shares, or the sharerequestid of the share requests.
+	ID          uint64 `json:"id"`
+	Name        string `json:"name"`
+	Mail        string `json:"mail"`
+	Permissions string `json:"permissions"`
+	Message     string `json:"message,omitempty"`
+}
+
+// CreateLink creates a public link to the pCloud file or folder p, and returns its URL.
+// expire (if not zero) is the time at which the link stops working, and maxDownloads (if not
+// 0) the number of downloads it allows. password (if not empty) protects the link, which is a
+// premium feature of pCloud. When short is set, the short form of the link is returned.
+func (cli *CLI) CreateLink(ctx context.Context, p string, expire time.Time, maxDownloads uint64, password string, short bool) (string, error) {
+	p = remotePath(p)
+
+	pl, err := cli.pCloudClient.CreateFilePubLink(ctx, sdk.ByPath(p), sdk.PubLinkOptions{Expire: expire, MaxDownloads: maxDownloads, ShortLink: short})
+	if isNotExist(err) {
+		pl, err = cli.pCloudClient.CreateFolderPubLink(ctx, sdk.ByPath(p), sdk.PubLinkOptions{Expire: expire, MaxDownloads: maxDownloads, ShortLink: short})
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if password != "" {
+		err = cli.pCloudClient.UpdatePubLink(ctx, pl.LinkID, sdk.UpdatePubLinkOptions{Password: password})
+		if err != nil {
+			return "", errors.WithMessage(cli.pCloudClient.DeletePubLink(ctx, pl.LinkID), err.Error())
+		}
+	}
+
+	if short && pl.ShortLink != "" {
+		return pl.ShortLink, nil
+	}
+
+	return pl.Link, nil
+}
+
+// ListLinks writes the public links of the account to w, one per line, or as a table or a
+// JSON array of Link, depending on output.
+func (cli *CLI) ListLinks(ctx context.Context, w io.Writer, output Output) error {
+	pl, err := cli.pCloudClient.ListPubLinks(ctx)
+	if err != nil {
+		return err
+	}
+
+	links := make([]Link, 0, len(pl.PubLinks))
+
+	for _, l := range pl.PubLinks {
+		link := Link{
+			ID:           l.LinkID,
+			Link:         l.Link,
+			Downloads:    l.Downloads,
+			MaxDownloads: l.MaxDownloads,
+			HasPassword:  l.HasPassword,
+			Created:      l.Created,
+			Expires:      timeOrNil(l.Expires),
+		}
+		if l.Metadata != nil {
+			link.Name, link.IsFolder = l.Metadata.Name, l.Metadata.IsFolder
+		}
+
+		links = append(links, link)
+	}
+
+	return writeList(w, output, links, func(l Link) error {
+		_, err := fmt.Fprintf(w, "%-10d %-16s %6d %s %s\n", l.ID, l.expires(), l.Downloads, l.Link, l.name())
+		return errors.WithStack(err)
+	})
+}
+
+func (Link) tableHeader() []string {
+	return []string{"ID", "EXPIRES", "DOWNLOADS", "LINK", "NAME"}
+}
+
+func (l Link) tableRow() []string {
+	return []string{strconv.FormatUint(l.ID, 10), l.expires(), strconv.FormatUint(l.Downloads, 10), l.Link, l.name()}
+}
+
+// expires returns the expiry of l, in local time, or "never".
+func (l Link) expires() string {
+	if l.Expires == nil {
+		return "never"
+	}
+	return l.Expires.Local().Format("2006-01-02 15:04")
+}
+
+// name returns the name of the file or folder of l, followed by a slash for folders.
+func (l Link) name() string {
+	if l.IsFolder {
+		return l.Name + "/"
+	}
+	return l.Name
+}
+
+// RevokeLink deletes the public link linkID: it stops working.
+func (cli *CLI) RevokeLink(ctx context.Context, linkID uint64) error {
+	return cli.pCloudClient.DeletePubLink(ctx, linkID)
+}
+
+// InviteShare invites the user of the email address mail to share the pCloud folder p, with
+// the permissions granted. name (if not empty) is the name under which the folder is shared,
+// and message (if not empty) is sent with the invitation.
+func (cli *CLI) InviteShare(ctx context.Context, p, mail string, permissions sdk.SharePermissions, name, message string) error {
+	return cli.pCloudClient.ShareFolder(ctx, sdk.ByPath(remotePath(p)), mail, permissions, name, message)
+}
+
+// ListShares writes the shares of the account and the pending share requests to w, one per
+// line, or as a table or a JSON array of ShareEntry, depending on output.
+func (cli *CLI) ListShares(ctx context.Context, w io.Writer, output Output) error {
+	sl, err := cli.pCloudClient.ListShares(ctx)
+	if err != nil {
+		return err
+	}
+
+	var entries []ShareEntry
+
+	for _, group := range []struct {
+		kind   string
+		shares []*sdk.Share
+	}{
+		{ShareIncoming, sl.Shares.Incoming},
+		{ShareOutgoing, sl.Shares.Outgoing},
+		{ShareRequestIncoming, sl.Requests.Incoming},
+		{ShareRequestOutgoing, sl.Requests.Outgoing},
+	} {
+		for _, s := range group.shares {
+			e := ShareEntry{
+				Kind:        group.kind,
+				ID:          s.ShareID,
+				Name:        s.ShareName,
+				Mail:        s.FromMail,
+				Permissions: FormatSharePermissions(s.Permissions()),
+				Message:     s.Message,
+			}
+			if s.ShareRequestID != 0 {
+				e.ID = s.ShareRequestID
+			}
+			if e.Mail == "" {
+				e.Mail = s.ToMail
+			}
+
+			entries = append(entries, e)
+		}
+	}
+
+	return writeList(w, output, entries, func(e ShareEntry) error {
+		_, err := fmt.Fprintf(w, "%-16s %-10d %-20s %-24s %s\n", e.Kind, e.ID, e.Permissions, e.Mail, e.Name)
+		return errors.WithStack(err)
+	})
+}
+
+func (ShareEntry) tableHeader() []string {
+	return []string{"KIND", "ID", "PERMISSIONS", "MAIL", "NAME"}
+}
+
+func (e ShareEntry) tableRow() []string {
+	return []string{e.Kind, strconv.FormatUint(e.ID, 10), e.Permissions, e.Mail, e.Name}
+}
+
+// AcceptShare accepts the incoming share request shareRequestID. name (if not empty) renames
+// the shared folder, and into (if not empty) is the pCloud folder in which it appears, instead
+// of the root folder.
+func (cli *CLI) AcceptShare(ctx context.Context, shareRequestID uint64, name, into string) error {
+	var folder sdk.FolderRef
+	if into != "" {
+		folder = sdk.ByPath(remotePath(into))
+	}
+
+	return cli.pCloudClient.AcceptShare(ctx, shareRequestID, name, folder)
+}
+
+// DeclineShare declines the incoming share request shareRequestID.
+func (cli *CLI) DeclineShare(ctx context.Context, shareRequestID uint64) error {
+	return cli.pCloudClient.DeclineShare(ctx, shareRequestID)
+}
+
+// sharePermissionNames are the names of the share permissions, in the order of
+// FormatSharePermissions.
+var sharePermissionNames = []struct {
+	name       string
+	permission sdk.SharePermissions
+}{
+	{"create", sdk.ShareCanCreate},
+	{"modify", sdk.ShareCanModify},
+	{"delete", sdk.ShareCanDelete},
+}
+
+// ParseSharePermissions parses a comma-separated list of the permissions "create", "modify"
+// and "delete". "read" is accepted and ignored: reading is always permitted.
+func ParseSharePermissions(s string) (sdk.SharePermissions, error) {
+	var permissions sdk.SharePermissions
+
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || name == "read" {
+			continue
+		}
+
+		found := false
+		for _, p := range sharePermissionNames {
+			if p.name == name {
+				permissions |= p.permission
+				found = true
+			}
+		}
+		if !found {
+			return 0, errors.Errorf("%s: unknown permission, use 'create', 'modify' or 'delete'", name)
+		}
+	}
+
+	return permissions, nil
+}
+
+// FormatSharePermissions returns the comma-separated list of permissions, starting with
+// "read".
+func FormatSharePermissions(permissions sdk.SharePermissions) string {
+	names := []string{"read"}
+
+	for _, p := range sharePermissionNames {
+		if permissions&p.permission != 0 {
+			names = append(names, p.name)
+		}
+	}
+
+	return strings.Join(names, ",")
+}
+
+// ParseExpiry parses the expiry of a link: a duration from now such as "36h" or "7d", or a date
+// such as "2024-12-31" (the link expires at its start, in local time).
+func ParseExpiry(s string, now time.Time) (time.Time, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err == nil && n > 0 {
+			return now.AddDate(0, 0, n), nil
+		}
+	}
+
+	if d, err := time.ParseDuration(s); err == nil && d > 0 {
+		return now.Add(d), nil
+	}
+
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		return time.Time{}, errors.Errorf("%s: invalid expiry, use a duration such as '36h' or '7d', or a date such as '2024-12-31'", s)
+	}
+
+	return t, nil
+}
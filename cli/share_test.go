@@ -0,0 +1,125 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/cli"
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+func TestCLI_Links(t *testing.T) {
+	ctx := context.Background()
+	_, c := newTestCLI(t)
+
+	fileLink, err := c.CreateLink(ctx, "r:/Docs/a.txt", time.Now().Add(time.Hour), 3, "secret", false)
+	require.NoError(t, err)
+	assert.NotEmpty(t, fileLink)
+
+	folderLink, err := c.CreateLink(ctx, "r:/Archive", time.Time{}, 0, "", false)
+	require.NoError(t, err)
+	assert.NotEqual(t, fileLink, folderLink)
+
+	_, err = c.CreateLink(ctx, "r:/missing", time.Time{}, 0, "", false)
+	assert.Error(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, c.ListLinks(ctx, &out, cli.OutputJSON))
+
+	var links []cli.Link
+	require.NoError(t, json.Unmarshal(out.Bytes(), &links))
+	require.Len(t, links, 2)
+	assert.Equal(t, "a.txt", links[0].Name)
+	assert.Equal(t, fileLink, links[0].Link)
+	assert.EqualValues(t, 3, links[0].MaxDownloads)
+	assert.True(t, links[0].HasPassword)
+	assert.NotNil(t, links[0].Expires)
+	assert.Equal(t, "Archive", links[1].Name)
+	assert.True(t, links[1].IsFolder)
+	assert.Nil(t, links[1].Expires)
+
+	require.NoError(t, c.RevokeLink(ctx, links[0].ID))
+	assert.Error(t, c.RevokeLink(ctx, links[0].ID))
+
+	out.Reset()
+	require.NoError(t, c.ListLinks(ctx, &out, cli.OutputPlain))
+	assert.Equal(t, 1, strings.Count(out.String(), "\n"))
+	assert.Contains(t, out.String(), " never ")
+	assert.True(t, strings.HasSuffix(out.String(), " 0 "+folderLink+" Archive/\n"), out.String())
+}
+
+func TestCLI_Shares(t *testing.T) {
+	ctx := context.Background()
+	srv, c := newTestCLI(t)
+
+	require.NoError(t, c.InviteShare(ctx, "r:/Docs", "bob@example.com", sdk.ShareCanCreate|sdk.ShareCanDelete, "", "hi"))
+	assert.Error(t, c.InviteShare(ctx, "r:/missing", "bob@example.com", 0, "", ""))
+
+	accepted := srv.AddShareRequest("Photos", "alice@example.com", sdk.ShareCanModify)
+	declined := srv.AddShareRequest("Music", "carol@example.com", 0)
+
+	var out bytes.Buffer
+	require.NoError(t, c.ListShares(ctx, &out, cli.OutputJSON))
+
+	var entries []cli.ShareEntry
+	require.NoError(t, json.Unmarshal(out.Bytes(), &entries))
+	require.Len(t, entries, 3)
+	assert.Equal(t, cli.ShareRequestIncoming, entries[0].Kind)
+	assert.Equal(t, "alice@example.com", entries[0].Mail)
+	assert.Equal(t, "read,modify", entries[0].Permissions)
+	assert.Equal(t, cli.ShareRequestOutgoing, entries[2].Kind)
+	assert.Equal(t, "bob@example.com", entries[2].Mail)
+	assert.Equal(t, "read,create,delete", entries[2].Permissions)
+
+	require.NoError(t, c.AcceptShare(ctx, accepted, "", "r:/Archive"))
+	require.NoError(t, c.DeclineShare(ctx, declined))
+	assert.Error(t, c.DeclineShare(ctx, declined))
+
+	out.Reset()
+	require.NoError(t, c.ListShares(ctx, &out, cli.OutputPlain))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[0], cli.ShareIncoming+" "), lines[0])
+	assert.True(t, strings.HasSuffix(lines[0], " Photos"), lines[0])
+	assert.True(t, strings.HasPrefix(lines[1], cli.ShareRequestOutgoing+" "), lines[1])
+}
+
+func TestParseSharePermissions(t *testing.T) {
+	p, err := cli.ParseSharePermissions("read, create,delete")
+	require.NoError(t, err)
+	assert.Equal(t, sdk.ShareCanCreate|sdk.ShareCanDelete, p)
+	assert.Equal(t, "read,create,delete", cli.FormatSharePermissions(p))
+
+	p, err = cli.ParseSharePermissions("")
+	require.NoError(t, err)
+	assert.Zero(t, p)
+
+	_, err = cli.ParseSharePermissions("create,admin")
+	assert.Error(t, err)
+}
+
+func TestParseExpiry(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.Local)
+
+	for s, want := range map[string]time.Time{
+		"36h":        now.Add(36 * time.Hour),
+		"7d":         now.AddDate(0, 0, 7),
+		"2024-12-31": time.Date(2024, 12, 31, 0, 0, 0, 0, time.Local),
+	} {
+		got, err := cli.ParseExpiry(s, now)
+		require.NoError(t, err, s)
+		assert.True(t, want.Equal(got), s)
+	}
+
+	for _, s := range []string{"", "-1h", "0d", "tomorrow"} {
+		_, err := cli.ParseExpiry(s, now)
+		assert.Error(t, err, s)
+	}
+}
@@ -0,0 +1,469 @@
+package cli
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+// WithDeleteExtraneous makes Sync delete the files and folders of the destination that do not
+// exist in the source. It has no effect on Upload and Download.
+func WithDeleteExtraneous() TransferOption {
+	return func(tc *transferConfig) {
+		tc.deleteExtraneous = true
+	}
+}
+
+// WithDryRun makes Sync plan its actions without applying them. It has no effect on Upload and
+// Download.
+func WithDryRun() TransferOption {
+	return func(tc *transferConfig) {
+		tc.dryRun = true
+	}
+}
+
+// WithChecksum makes Sync compare the SHA-1 hashes of the files of the same size, rather than
+// their modification times. This is slower: the local files are read, and pCloud is called for
+// each remote file. It has no effect on Upload and Download.
+func WithChecksum() TransferOption {
+	return func(tc *transferConfig) {
+		tc.checksum = true
+	}
+}
+
+// The types of SyncAction.
+const (
+	SyncActionMkdir  = "mkdir"
+	SyncActionCopy   = "copy"
+	SyncActionUpdate = "update"
+	SyncActionDelete = "delete"
+)
+
+// SyncAction is an action of Sync on the destination, at Path relative to its root.
+type SyncAction struct {
+	Action string `json:"action"`
+	Path   string `json:"path"`
+	Size   int64  `json:"size,omitempty"`
+	// Applied is set when the action was carried out.
+	Applied bool `json:"applied"`
+}
+
+// SyncReport lists the actions planned by Sync, and whether they were applied.
+type SyncReport struct {
+	Source      string        `json:"source"`
+	Destination string        `json:"destination"`
+	DryRun      bool          `json:"dry_run"`
+	Actions     []*SyncAction `json:"actions"`
+	// Skipped is the number of files not synchronised because of WithMaxSize.
+	Skipped int `json:"skipped"`
+}
+
+// Write writes the actions of report to w, one per line, or as a table of the actions or as
+// the JSON form of report, depending on output.
+func (report *SyncReport) Write(w io.Writer, output Output) error {
+	if output == OutputJSON {
+		return writeJSON(w, report)
+	}
+
+	return writeList(w, output, report.Actions, func(a *SyncAction) error {
+		status := ""
+		if !report.DryRun && !a.Applied {
+			status = " (not applied)"
+		}
+
+		_, err := fmt.Fprintf(w, "%-6s %s%s\n", a.Action, a.Path, status)
+		return errors.WithStack(err)
+	})
+}
+
+func (*SyncAction) tableHeader() []string {
+	return []string{"ACTION", "PATH", "SIZE", "APPLIED"}
+}
+
+func (a *SyncAction) tableRow() []string {
+	return []string{a.Action, a.Path, strconv.FormatInt(a.Size, 10), strconv.FormatBool(a.Applied)}
+}
+
+// syncEntry is a file or folder of the tree of the source or the destination of Sync.
+type syncEntry struct {
+	size    int64
+	modTime time.Time
+	isDir   bool
+}
+
+// Sync mirrors the folder src to the folder dst, in one direction: one is a local path and the
+// other a pCloud path, prefixed with PCloudPrefix. dst is created as needed.
+// Files missing from dst are copied, and the files of dst that differ from src are updated: they
+// differ when their sizes do, or when the file of src is more recent (see WithChecksum). Files
+// and folders that only exist in dst are kept, unless WithDeleteExtraneous is set.
+// The filters of WithInclude, WithExclude and WithMaxSize apply: the files and folders they
+// leave out are neither copied nor deleted.
+// The report lists the planned actions, even when an error interrupts Sync.
+func (cli *CLI) Sync(ctx context.Context, src, dst string, opts ...TransferOption) (*SyncReport, error) {
+	if isRemote(src) == isRemote(dst) {
+		return nil, errors.New("one of the source and the destination must be a pCloud path, the other a local path")
+	}
+
+	tc, err := newTransferConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &SyncReport{Source: src, Destination: dst, DryRun: tc.dryRun}
+
+	srcTree, err := cli.syncTree(ctx, src, tc)
+	if err != nil {
+		return nil, err
+	}
+	if srcTree == nil {
+		return nil, errors.Errorf("%s: no such folder", src)
+	}
+
+	dstTree, err := cli.syncTree(ctx, dst, tc)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rel := range sortedPaths(srcTree) {
+		s := srcTree[rel]
+		d, exists := dstTree[rel]
+
+		switch {
+		case s.isDir:
+			if !exists || !d.isDir {
+				report.Actions = append(report.Actions, &SyncAction{Action: SyncActionMkdir, Path: rel})
+			}
+
+		case tc.maxSize > 0 && s.size > tc.maxSize:
+			report.Skipped++
+
+		case !exists || d.isDir:
+			report.Actions = append(report.Actions, &SyncAction{Action: SyncActionCopy, Path: rel, Size: s.size})
+
+		default:
+			differ, err := cli.differ(ctx, src, dst, rel, s, d, tc.checksum)
+			if err != nil {
+				return report, err
+			}
+			if differ {
+				report.Actions = append(report.Actions, &SyncAction{Action: SyncActionUpdate, Path: rel, Size: s.size})
+			}
+		}
+	}
+
+	if tc.deleteExtraneous {
+		deleted := map[string]bool{}
+
+		for _, rel := range sortedPaths(dstTree) {
+			s, exists := srcTree[rel]
+			if exists && s.isDir == dstTree[rel].isDir {
+				continue
+			}
+
+			// the contents of deleted folders go with them.
+			if inDeletedFolder(deleted, rel) {
+				continue
+			}
+			deleted[rel] = true
+
+			report.Actions = append(report.Actions, &SyncAction{Action: SyncActionDelete, Path: rel})
+		}
+	}
+
+	if tc.dryRun {
+		return report, nil
+	}
+
+	return report, cli.applySync(ctx, src, dst, report, tc, dstTree)
+}
+
+// applySync carries out the actions of report: the deletions first, as they make way for the
+// folders and files that replace the deleted entries, then the creation of folders and the
+// transfer of files.
+func (cli *CLI) applySync(ctx context.Context, src, dst string, report *SyncReport, tc *transferConfig, dstTree map[string]syncEntry) error {
+	var items []transferItem
+	actions := map[string]*SyncAction{}
+
+	for _, a := range report.Actions {
+		switch a.Action {
+		case SyncActionDelete:
+			err := cli.syncDelete(ctx, dst, a.Path, dstTree[a.Path].isDir)
+			if err != nil {
+				return errors.WithMessage(err, a.Path)
+			}
+
+		case SyncActionCopy, SyncActionUpdate:
+			items = append(items, transferItem{rel: a.Path, size: a.Size})
+			actions[a.Path] = a
+			continue
+
+		default:
+			continue
+		}
+
+		a.Applied = true
+	}
+
+	for _, a := range report.Actions {
+		if a.Action != SyncActionMkdir {
+			continue
+		}
+
+		var err error
+		if isRemote(dst) {
+			err = cli.Mkdir(ctx, path.Join(remotePath(dst), a.Path), true)
+		} else {
+			err = errors.WithStack(os.MkdirAll(filepath.Join(dst, filepath.FromSlash(a.Path)), 0755))
+		}
+		if err != nil {
+			return errors.WithMessage(err, a.Path)
+		}
+
+		a.Applied = true
+	}
+
+	_, err := tc.run(ctx, items, func(ctx context.Context, item transferItem) error {
+		var err error
+		if isRemote(dst) {
+			err = cli.syncUpload(ctx, filepath.Join(src, filepath.FromSlash(item.rel)), path.Join(remotePath(dst), item.rel), tc.limiter)
+		} else {
+			err = cli.syncDownload(ctx, path.Join(remotePath(src), item.rel), filepath.Join(dst, filepath.FromSlash(item.rel)), tc.limiter)
+		}
+		if err != nil {
+			return err
+		}
+
+		actions[item.rel].Applied = true
+
+		return nil
+	})
+
+	return err
+}
+
+// syncUpload uploads the local file from to the pCloud file to, at the rate of bl.
+func (cli *CLI) syncUpload(ctx context.Context, from, to string, bl *bandwidthLimiter) error {
+	f, err := os.Open(from)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	_, err = cli.remote.Put(ctx, to, bl.reader(ctx, f))
+
+	return err
+}
+
+// syncDownload downloads the pCloud file from to the local file to, to which it gives the
+// modification time of from, so that the next Sync finds them identical.
+func (cli *CLI) syncDownload(ctx context.Context, from, to string, bl *bandwidthLimiter) error {
+	fr, err := cli.pCloudClient.Stat(ctx, sdk.ByPath(from))
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(to), 0755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	err = cli.download(ctx, from, to, bl)
+	if err != nil || fr.Metadata.Modified.IsZero() {
+		return err
+	}
+
+	return errors.WithStack(os.Chtimes(to, fr.Metadata.Modified, fr.Metadata.Modified))
+}
+
+// syncDelete deletes the file or folder rel of the destination dst.
+func (cli *CLI) syncDelete(ctx context.Context, dst, rel string, isDir bool) error {
+	if !isRemote(dst) {
+		return errors.WithStack(os.RemoveAll(filepath.Join(dst, filepath.FromSlash(rel))))
+	}
+
+	p := path.Join(remotePath(dst), rel)
+
+	if isDir {
+		_, err := cli.pCloudClient.DeleteFolderRecursive(ctx, sdk.ByPath(p))
+		return err
+	}
+
+	_, err := cli.pCloudClient.DeleteFile(ctx, sdk.ByPath(p))
+
+	return err
+}
+
+// differ returns whether the file rel of the destination dst differs from the file of the
+// source src: s and d are their entries.
+func (cli *CLI) differ(ctx context.Context, src, dst, rel string, s, d syncEntry, checksum bool) (bool, error) {
+	if s.size != d.size {
+		return true, nil
+	}
+
+	if !checksum {
+		return s.modTime.Truncate(time.Second).After(d.modTime.Truncate(time.Second)), nil
+	}
+
+	srcHash, err := cli.sha1(ctx, src, rel)
+	if err != nil {
+		return false, err
+	}
+
+	dstHash, err := cli.sha1(ctx, dst, rel)
+	if err != nil {
+		return false, err
+	}
+
+	return srcHash != dstHash, nil
+}
+
+// sha1 returns the SHA-1 hash of the contents of the file rel of the folder root, a local or a
+// pCloud path.
+func (cli *CLI) sha1(ctx context.Context, root, rel string) (string, error) {
+	if isRemote(root) {
+		fc, err := cli.pCloudClient.ChecksumFile(ctx, sdk.ByPath(path.Join(remotePath(root), rel)))
+		if err != nil {
+			return "", err
+		}
+		return fc.SHA1, nil
+	}
+
+	f, err := os.Open(filepath.Join(root, filepath.FromSlash(rel)))
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	h := sha1.New()
+
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// syncTree returns the entries of the folder root, a local or a pCloud path, that pass the
+// filters of tc, by path relative to root. It returns nil when root does not exist.
+func (cli *CLI) syncTree(ctx context.Context, root string, tc *transferConfig) (map[string]syncEntry, error) {
+	tree := map[string]syncEntry{}
+
+	if isRemote(root) {
+		lf, err := cli.pCloudClient.List(ctx, sdk.ByPath(remotePath(root)), sdk.ListOptions{Recursive: true})
+		if err != nil {
+			if isNotExist(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+
+		tc.remoteTree(tree, lf.Metadata.Contents, "")
+
+		return tree, nil
+	}
+
+	info, err := os.Stat(root)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, errors.WithStack(err)
+	}
+	if !info.IsDir() {
+		return nil, errors.Errorf("%s: not a folder", root)
+	}
+
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if matchAny(tc.exclude, rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !d.IsDir() && (!d.Type().IsRegular() || (len(tc.include) > 0 && !matchAny(tc.include, rel))) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		tree[rel] = syncEntry{size: info.Size(), modTime: info.ModTime(), isDir: d.IsDir()}
+
+		return nil
+	})
+
+	return tree, errors.WithStack(err)
+}
+
+// remoteTree adds to tree the entries of the tree of pCloud entries, at the relative path dir,
+// that pass the filters.
+func (tc *transferConfig) remoteTree(tree map[string]syncEntry, entries []*sdk.Metadata, dir string) {
+	for _, m := range entries {
+		rel := path.Join(dir, m.Name)
+
+		switch {
+		case matchAny(tc.exclude, rel):
+			continue
+		case !m.IsFolder && len(tc.include) > 0 && !matchAny(tc.include, rel):
+			continue
+		}
+
+		e := syncEntry{size: int64(m.Size), isDir: m.IsFolder, modTime: m.Modified}
+
+		tree[rel] = e
+
+		if m.IsFolder {
+			tc.remoteTree(tree, m.Contents, rel)
+		}
+	}
+}
+
+// inDeletedFolder returns whether one of the parent folders of rel is deleted.
+func inDeletedFolder(deleted map[string]bool, rel string) bool {
+	for dir := path.Dir(rel); dir != "."; dir = path.Dir(dir) {
+		if deleted[dir] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sortedPaths returns the paths of tree, sorted so that folders come before their contents.
+func sortedPaths[T any](tree map[string]T) []string {
+	paths := make([]string, 0, len(tree))
+	for p := range tree {
+		paths = append(paths, p)
+	}
+
+	sort.Strings(paths)
+
+	return paths
+}
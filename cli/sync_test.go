@@ -0,0 +1,99 @@
+package cli_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/cli"
+)
+
+// actions returns the actions of report as "action path" strings.
+func actions(report *cli.SyncReport) []string {
+	var as []string
+	for _, a := range report.Actions {
+		as = append(as, a.Action+" "+a.Path)
+	}
+	return as
+}
+
+func TestCLI_Sync_Upload(t *testing.T) {
+	ctx := context.Background()
+	srv, c := newTestCLI(t)
+	dir := t.TempDir()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "Sub", "Empty"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("0123456789"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Sub", "b.txt"), []byte("changed"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "new.txt"), []byte("new"), 0o600))
+
+	// a.txt is older and of the same size: it is left alone.
+	old := time.Now().Add(-24 * time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "a.txt"), old, old))
+
+	report, err := c.Sync(ctx, dir, "r:/Docs", cli.WithDeleteExtraneous(), cli.WithDryRun())
+	require.NoError(t, err)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, []string{"mkdir Sub/Empty", "update Sub/b.txt", "copy new.txt"}, actions(report))
+
+	_, err = srv.ReadFile("/Docs/new.txt")
+	assert.Error(t, err)
+
+	_, err = srv.WriteFile("/Docs/extra/x.txt", []byte("x"))
+	require.NoError(t, err)
+	report, err = c.Sync(ctx, dir, "r:/Docs", cli.WithDeleteExtraneous())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"mkdir Sub/Empty", "update Sub/b.txt", "copy new.txt", "delete extra"}, actions(report))
+	for _, a := range report.Actions {
+		assert.True(t, a.Applied, a.Path)
+	}
+
+	data, err := srv.ReadFile("/Docs/Sub/b.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "changed", string(data))
+	_, err = srv.ReadFile("/Docs/extra/x.txt")
+	assert.Error(t, err)
+
+	report, err = c.Sync(ctx, dir, "r:/Docs", cli.WithDeleteExtraneous())
+	require.NoError(t, err)
+	assert.Empty(t, report.Actions)
+
+	// with checksums, a.txt is found identical, and an edit of the same size is found.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Sub", "b.txt"), []byte("CHANGED"), 0o600))
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "Sub", "b.txt"), old, old))
+	report, err = c.Sync(ctx, dir, "r:/Docs", cli.WithChecksum(), cli.WithDryRun())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"update Sub/b.txt"}, actions(report))
+}
+
+func TestCLI_Sync_Download(t *testing.T) {
+	ctx := context.Background()
+	_, c := newTestCLI(t)
+	dir := filepath.Join(t.TempDir(), "mirror")
+
+	report, err := c.Sync(ctx, "r:/Docs", dir, cli.WithExclude("a.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"mkdir Sub", "copy Sub/b.txt"}, actions(report))
+
+	data, err := os.ReadFile(filepath.Join(dir, "Sub", "b.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "local.txt"), []byte("local"), 0o600))
+
+	report, err = c.Sync(ctx, "r:/Docs", dir, cli.WithExclude("a.txt"), cli.WithDeleteExtraneous())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"delete local.txt"}, actions(report))
+	_, err = os.Stat(filepath.Join(dir, "local.txt"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	_, err = c.Sync(ctx, "r:/missing", dir)
+	assert.Error(t, err)
+	_, err = c.Sync(ctx, "r:/Docs", "r:/Archive")
+	assert.Error(t, err)
+}
@@ -0,0 +1,405 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+// TransferOption is a functional parameter for Upload, Download and Sync.
+type TransferOption func(*transferConfig)
+
+type transferConfig struct {
+	include  []string
+	exclude  []string
+	maxSize  int64
+	parallel int
+	progress io.Writer
+	limiter  *bandwidthLimiter
+
+	deleteExtraneous bool
+	dryRun           bool
+	checksum         bool
+}
+
+// WithInclude only transfers the files whose path, relative to the transferred folder, or whose
+// name matches one of the glob patterns (see path.Match). By default, all files are transferred.
+func WithInclude(patterns ...string) TransferOption {
+	return func(tc *transferConfig) {
+		tc.include = append(tc.include, patterns...)
+	}
+}
+
+// WithExclude does not transfer the files and folders whose path, relative to the transferred
+// folder, or whose name matches one of the glob patterns (see path.Match). Exclusions take
+// precedence over inclusions.
+func WithExclude(patterns ...string) TransferOption {
+	return func(tc *transferConfig) {
+		tc.exclude = append(tc.exclude, patterns...)
+	}
+}
+
+// WithMaxSize does not transfer the files larger than size bytes. 0, the default, means no
+// limit.
+func WithMaxSize(size int64) TransferOption {
+	return func(tc *transferConfig) {
+		tc.maxSize = size
+	}
+}
+
+// WithParallelism sets the number of files transferred at the same time. The default is 4.
+func WithParallelism(n int) TransferOption {
+	return func(tc *transferConfig) {
+		if n < 1 {
+			n = 1
+		}
+		tc.parallel = n
+	}
+}
+
+// WithBandwidthLimit limits the rate at which the files are transferred to rate bytes per
+// second, in total. 0, the default, means no limit.
+func WithBandwidthLimit(rate int64) TransferOption {
+	return func(tc *transferConfig) {
+		tc.limiter = newBandwidthLimiter(rate)
+	}
+}
+
+// WithProgress writes a line to w as each file is transferred.
+func WithProgress(w io.Writer) TransferOption {
+	return func(tc *transferConfig) {
+		tc.progress = w
+	}
+}
+
+// TransferStats sums up the outcome of Upload and Download.
+type TransferStats struct {
+	Files int   `json:"files"`
+	Bytes int64 `json:"bytes"`
+	// Skipped is the number of files not transferred because of WithMaxSize.
+	Skipped int `json:"skipped"`
+	// Duration is in nanoseconds, in JSON.
+	Duration time.Duration `json:"duration"`
+}
+
+// TransferError is the error of a file that failed to transfer. The other errors of the
+// transfers, such as a source folder that does not exist, are returned as they are.
+type TransferError struct {
+	// Path is the path of the file, relative to the transferred folder.
+	Path string
+	Err  error
+}
+
+func (e *TransferError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *TransferError) Unwrap() error {
+	return e.Err
+}
+
+// Format formats e like its Err, with its stack trace for %+v.
+func (e *TransferError) Format(s fmt.State, verb rune) {
+	if verb == 'v' && s.Flag('+') {
+		_, _ = fmt.Fprintf(s, "%+v", e.Err)
+		return
+	}
+	_, _ = io.WriteString(s, e.Error())
+}
+
+// transferItem is a file to transfer, at the path rel relative to the transferred folder.
+type transferItem struct {
+	rel     string
+	size    int64
+	modTime time.Time
+}
+
+// Upload uploads the local folder localDir and its contents into the pCloud folder remoteDir,
+// which is created as needed. Existing files are replaced. When localDir is a file, it is
+// uploaded into remoteDir.
+// Only regular files are uploaded: symbolic links and special files are ignored.
+func (cli *CLI) Upload(ctx context.Context, localDir, remoteDir string, opts ...TransferOption) (*TransferStats, error) {
+	tc, err := newTransferConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	remoteDir = remotePath(remoteDir)
+
+	localDir, items, err := tc.localItems(localDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return tc.run(ctx, items, func(ctx context.Context, item transferItem) error {
+		f, err := os.Open(filepath.Join(localDir, filepath.FromSlash(item.rel)))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		defer func() { _ = f.Close() }()
+
+		_, err = cli.remote.Put(ctx, path.Join(remoteDir, item.rel), tc.limiter.reader(ctx, f))
+
+		return err
+	})
+}
+
+// Download downloads the pCloud folder remoteDir and its contents into the local folder
+// localDir, which is created as needed. Existing files are replaced, and the downloaded files
+// get the modification time they have on pCloud. When remoteDir is a file, it is downloaded
+// into localDir.
+func (cli *CLI) Download(ctx context.Context, remoteDir, localDir string, opts ...TransferOption) (*TransferStats, error) {
+	tc, err := newTransferConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	remoteDir = remotePath(remoteDir)
+
+	var items []transferItem
+
+	lf, err := cli.pCloudClient.List(ctx, sdk.ByPath(remoteDir), sdk.ListOptions{Recursive: true})
+	switch {
+	case err == nil:
+		items = tc.remoteItems(lf.Metadata.Contents, "")
+
+	case isNotExist(err):
+		fr, statErr := cli.pCloudClient.Stat(ctx, sdk.ByPath(remoteDir))
+		if statErr != nil {
+			return nil, err
+		}
+
+		remoteDir = path.Dir(remoteDir)
+		items = []transferItem{metadataItem(&fr.Metadata, fr.Metadata.Name)}
+
+	default:
+		return nil, err
+	}
+
+	return tc.run(ctx, items, func(ctx context.Context, item transferItem) error {
+		to := filepath.Join(localDir, filepath.FromSlash(item.rel))
+
+		err := os.MkdirAll(filepath.Dir(to), 0755)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		err = cli.download(ctx, path.Join(remoteDir, item.rel), to, tc.limiter)
+		if err != nil {
+			return err
+		}
+
+		if item.modTime.IsZero() {
+			return nil
+		}
+
+		return errors.WithStack(os.Chtimes(to, item.modTime, item.modTime))
+	})
+}
+
+// localItems returns the files of the local folder localDir that pass the filters, and the
+// folder their relative paths start from: localDir, or its parent when localDir is a file.
+func (tc *transferConfig) localItems(localDir string) (string, []transferItem, error) {
+	info, err := os.Stat(localDir)
+	if err != nil {
+		return "", nil, errors.WithStack(err)
+	}
+
+	var items []transferItem
+
+	if !info.IsDir() {
+		localDir = filepath.Dir(localDir)
+		items = []transferItem{{rel: info.Name(), size: info.Size()}}
+	} else {
+		err = filepath.WalkDir(localDir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			rel, err := filepath.Rel(localDir, p)
+			if err != nil || rel == "." {
+				return err
+			}
+			rel = filepath.ToSlash(rel)
+
+			if matchAny(tc.exclude, rel) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			if !d.Type().IsRegular() || (len(tc.include) > 0 && !matchAny(tc.include, rel)) {
+				return nil
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+
+			items = append(items, transferItem{rel: rel, size: info.Size()})
+
+			return nil
+		})
+		if err != nil {
+			return "", nil, errors.WithStack(err)
+		}
+	}
+
+	return localDir, items, nil
+}
+
+// remoteItems returns the files of the tree of pCloud entries, at the relative path dir, that
+// pass the filters.
+func (tc *transferConfig) remoteItems(entries []*sdk.Metadata, dir string) []transferItem {
+	var items []transferItem
+
+	for _, m := range entries {
+		rel := path.Join(dir, m.Name)
+
+		switch {
+		case matchAny(tc.exclude, rel):
+		case m.IsFolder:
+			items = append(items, tc.remoteItems(m.Contents, rel)...)
+		case len(tc.include) == 0 || matchAny(tc.include, rel):
+			items = append(items, metadataItem(m, rel))
+		}
+	}
+
+	return items
+}
+
+// metadataItem returns the transferItem of the pCloud file m, at the relative path rel.
+func metadataItem(m *sdk.Metadata, rel string) transferItem {
+	return transferItem{rel: rel, size: int64(m.Size), modTime: m.Modified}
+}
+
+// run transfers the items with fn, tc.parallel at a time. The transfer stops at the first
+// error, which is returned.
+func (tc *transferConfig) run(ctx context.Context, items []transferItem, fn func(ctx context.Context, item transferItem) error) (*TransferStats, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	start := time.Now()
+	stats := &TransferStats{}
+
+	var (
+		lock     sync.Mutex
+		firstErr error
+		wg       sync.WaitGroup
+	)
+
+	sem := make(chan struct{}, tc.parallel)
+
+	for _, item := range items {
+		if tc.maxSize > 0 && item.size > tc.maxSize {
+			stats.Skipped++
+			continue
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(item transferItem) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			err := fn(ctx, item)
+
+			lock.Lock()
+			defer lock.Unlock()
+
+			if err != nil {
+				if firstErr == nil {
+					firstErr = &TransferError{Path: item.rel, Err: errors.WithMessage(err, item.rel)}
+					cancel()
+				}
+				return
+			}
+
+			stats.Files++
+			stats.Bytes += item.size
+
+			if tc.progress != nil {
+				_, _ = fmt.Fprintf(tc.progress, "[%d/%d] %s (%s)\n", stats.Files, len(items)-stats.Skipped, item.rel, FormatSize(item.size))
+			}
+		}(item)
+	}
+
+	wg.Wait()
+
+	stats.Duration = time.Since(start)
+
+	if firstErr == nil {
+		firstErr = errors.WithStack(ctx.Err())
+	}
+
+	return stats, firstErr
+}
+
+// newTransferConfig returns the transferConfig of opts, after it checks the glob patterns.
+func newTransferConfig(opts []TransferOption) (*transferConfig, error) {
+	tc := &transferConfig{parallel: 4}
+
+	for _, opt := range opts {
+		opt(tc)
+	}
+
+	for _, p := range append(tc.include, tc.exclude...) {
+		_, err := path.Match(p, "")
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s", p)
+		}
+	}
+
+	return tc, nil
+}
+
+// matchAny returns whether the slash-separated path rel, or its last element, matches one of
+// the glob patterns.
+func matchAny(patterns []string, rel string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, rel); ok {
+			return true
+		}
+		if ok, _ := path.Match(p, path.Base(rel)); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FormatSize returns size in bytes in a human-readable form, such as "1.5 MiB".
+func FormatSize(size int64) string {
+	const unit = 1024
+
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
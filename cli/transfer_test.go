@@ -0,0 +1,134 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/cli"
+)
+
+func TestCLI_Upload(t *testing.T) {
+	ctx := context.Background()
+	srv, c := newTestCLI(t)
+	dir := t.TempDir()
+
+	for p, data := range map[string]string{
+		"a.txt":          "a",
+		"big.bin":        "0123456789",
+		"sub/b.txt":      "b",
+		"sub/c.log":      "c",
+		"node_modules/x": "x",
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, p)), 0o700))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, p), []byte(data), 0o600))
+	}
+
+	var progress bytes.Buffer
+
+	stats, err := c.Upload(ctx, dir, "r:/Up",
+		cli.WithExclude("node_modules", "*.log"),
+		cli.WithMaxSize(5),
+		cli.WithParallelism(2),
+		cli.WithProgress(&progress),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.Files)
+	assert.EqualValues(t, 2, stats.Bytes)
+	assert.Equal(t, 1, stats.Skipped)
+	assert.Contains(t, progress.String(), "[2/2] ")
+
+	data, err := srv.ReadFile("/Up/sub/b.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "b", string(data))
+
+	for _, p := range []string{"/Up/big.bin", "/Up/sub/c.log", "/Up/node_modules/x"} {
+		_, err = srv.ReadFile(p)
+		assert.Error(t, err, p)
+	}
+
+	stats, err = c.Upload(ctx, filepath.Join(dir, "big.bin"), "r:/Docs")
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Files)
+	data, err = srv.ReadFile("/Docs/big.bin")
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789", string(data))
+
+	_, err = c.Upload(ctx, dir, "r:/Up", cli.WithInclude("[a-"))
+	assert.Error(t, err)
+}
+
+func TestCLI_Download(t *testing.T) {
+	ctx := context.Background()
+	_, c := newTestCLI(t)
+	dir := t.TempDir()
+
+	stats, err := c.Download(ctx, "r:/Docs", dir, cli.WithInclude("*.txt"), cli.WithExclude("a.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Files)
+
+	data, err := os.ReadFile(filepath.Join(dir, "Sub", "b.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+	_, err = os.Stat(filepath.Join(dir, "a.txt"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	stats, err = c.Download(ctx, "r:/Docs/a.txt", filepath.Join(dir, "new"))
+	require.NoError(t, err)
+	assert.EqualValues(t, 10, stats.Bytes)
+	data, err = os.ReadFile(filepath.Join(dir, "new", "a.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789", string(data))
+
+	// a folder is in the way of the file.
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "blocked", "a.txt"), 0o700))
+	_, err = c.Download(ctx, "r:/Docs/a.txt", filepath.Join(dir, "blocked"))
+	var transferErr *cli.TransferError
+	require.ErrorAs(t, err, &transferErr)
+	assert.Equal(t, "a.txt", transferErr.Path)
+	assert.True(t, strings.HasPrefix(err.Error(), "a.txt: "), err.Error())
+
+	_, err = c.Download(ctx, "r:/missing", dir)
+	assert.Error(t, err)
+	assert.False(t, errors.As(err, &transferErr))
+}
+
+func TestCLI_Upload_BandwidthLimit(t *testing.T) {
+	ctx := context.Background()
+	srv, c := newTestCLI(t)
+	dir := t.TempDir()
+
+	// 3 files of 20KiB, at 200KiB/s in total: 300ms.
+	for _, name := range []string{"a.bin", "b.bin", "c.bin"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(strings.Repeat("x", 20<<10)), 0o600))
+	}
+
+	stats, err := c.Upload(ctx, dir, "r:/Up", cli.WithBandwidthLimit(200<<10), cli.WithParallelism(3))
+	require.NoError(t, err)
+	assert.Equal(t, 3, stats.Files)
+	assert.GreaterOrEqual(t, stats.Duration, 250*time.Millisecond)
+
+	data, err := srv.ReadFile("/Up/c.bin")
+	require.NoError(t, err)
+	assert.Len(t, data, 20<<10)
+
+	ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+
+	_, err = c.Download(ctx, "r:/Up", t.TempDir(), cli.WithBandwidthLimit(1<<10))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestFormatSize(t *testing.T) {
+	assert.Equal(t, "512 B", cli.FormatSize(512))
+	assert.Equal(t, "1.5 KiB", cli.FormatSize(1536))
+	assert.Equal(t, "2.0 GiB", cli.FormatSize(2<<30))
+}
@@ -0,0 +1,187 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+// TrashEntry is a file or folder of the trash, as listed by ListTrash.
+type TrashEntry struct {
+	// ID is "f" followed by the fileid of the files, or "d" followed by the folderid of the
+	// folders: RestoreFromTrash and EmptyTrash take it.
+	ID       string     `json:"id"`
+	Name     string     `json:"name"`
+	IsFolder bool       `json:"is_folder"`
+	Size     uint64     `json:"size"`
+	Modified *time.Time `json:"modified,omitempty"`
+}
+
+// ListTrash writes the contents of the trash to w, one entry per line: its ID, its size, its
+// modification time and its name, followed by a slash for folders. Depending on output, it
+// writes them as a table or a JSON array of TrashEntry instead.
+func (cli *CLI) ListTrash(ctx context.Context, w io.Writer, output Output) error {
+	lf, err := cli.pCloudClient.ListTrash(ctx, sdk.ListTrashOptions{})
+	if err != nil {
+		return err
+	}
+
+	entries := make([]TrashEntry, 0, len(lf.Metadata.Contents))
+
+	for _, m := range lf.Metadata.Contents {
+		entries = append(entries, TrashEntry{ID: m.ID, Name: m.Name, IsFolder: m.IsFolder, Size: m.Size, Modified: timeOrNil(m.Modified)})
+	}
+
+	return writeList(w, output, entries, func(e TrashEntry) error {
+		_, err := fmt.Fprintf(w, "%-12s %12d %16s %s\n", e.ID, e.Size, e.modified(), e.name())
+		return errors.WithStack(err)
+	})
+}
+
+func (TrashEntry) tableHeader() []string {
+	return []string{"ID", "SIZE", "MODIFIED", "NAME"}
+}
+
+func (e TrashEntry) tableRow() []string {
+	return []string{e.ID, strconv.FormatUint(e.Size, 10), e.modified(), e.name()}
+}
+
+// name returns the name of e, followed by a slash for folders.
+func (e TrashEntry) name() string {
+	if e.IsFolder {
+		return e.Name + "/"
+	}
+	return e.Name
+}
+
+// modified returns the modification time of e, in local time.
+func (e TrashEntry) modified() string {
+	if e.Modified == nil {
+		return ""
+	}
+	return e.Modified.Local().Format("2006-01-02 15:04")
+}
+
+// RestoreFromTrash restores the file or folder id of the trash, as listed by ListTrash, into
+// the folder it was deleted from, or into the pCloud folder to when it is not empty.
+func (cli *CLI) RestoreFromTrash(ctx context.Context, id, to string) error {
+	item, err := parseTrashID(id)
+	if err != nil {
+		return err
+	}
+
+	var restoreTo uint64
+	if to != "" {
+		lf, err := cli.pCloudClient.List(ctx, sdk.ByPath(remotePath(to)), sdk.ListOptions{NoFiles: true, NoShares: true})
+		if err != nil {
+			return err
+		}
+
+		restoreTo = lf.Metadata.FolderID
+		if restoreTo == sdk.RootFolderID {
+			return errors.New("files and folders cannot be restored into the root folder, unless they were deleted from it")
+		}
+	}
+
+	_, err = cli.pCloudClient.TrashRestore(ctx, item, restoreTo)
+
+	return err
+}
+
+// EmptyTrash deletes the files and folders ids of the trash for good, or all of its contents
+// when no id is given.
+func (cli *CLI) EmptyTrash(ctx context.Context, ids ...string) error {
+	if len(ids) == 0 {
+		return cli.pCloudClient.TrashClear(ctx, sdk.T6FolderByID(0))
+	}
+
+	for _, id := range ids {
+		item, err := parseTrashID(id)
+		if err != nil {
+			return err
+		}
+
+		err = cli.pCloudClient.TrashClear(ctx, item)
+		if err != nil {
+			return errors.WithMessage(err, id)
+		}
+	}
+
+	return nil
+}
+
+// RevisionEntry is a revision of a file, as listed by ListRevisions.
+type RevisionEntry struct {
+	// ID is the revisionid, which RestoreRevision takes.
+	ID      uint64     `json:"id"`
+	Size    uint64     `json:"size"`
+	Created *time.Time `json:"created,omitempty"`
+}
+
+// ListRevisions writes the revisions of the pCloud file p to w, one per line and the most
+// recent first: their ID, their size and their creation time. Depending on output, it writes
+// them as a table or a JSON array of RevisionEntry instead.
+func (cli *CLI) ListRevisions(ctx context.Context, w io.Writer, p string, output Output) error {
+	rl, err := cli.pCloudClient.ListRevisions(ctx, sdk.ByPath(remotePath(p)))
+	if err != nil {
+		return err
+	}
+
+	revisions := make([]RevisionEntry, 0, len(rl.Revisions))
+
+	for _, r := range rl.Revisions {
+		revisions = append(revisions, RevisionEntry{ID: r.RevisionID, Size: r.Size, Created: timeOrNil(r.Created)})
+	}
+
+	return writeList(w, output, revisions, func(e RevisionEntry) error {
+		_, err := fmt.Fprintf(w, "%-12d %12d %s\n", e.ID, e.Size, e.created())
+		return errors.WithStack(err)
+	})
+}
+
+func (RevisionEntry) tableHeader() []string {
+	return []string{"ID", "SIZE", "CREATED"}
+}
+
+func (e RevisionEntry) tableRow() []string {
+	return []string{strconv.FormatUint(e.ID, 10), strconv.FormatUint(e.Size, 10), e.created()}
+}
+
+// created returns the creation time of e, in local time.
+func (e RevisionEntry) created() string {
+	if e.Created == nil {
+		return ""
+	}
+	return e.Created.Local().Format("2006-01-02 15:04:05")
+}
+
+// RestoreRevision replaces the contents of the pCloud file p with its revision revisionID. The
+// current contents become a revision, so that the restore can be undone.
+func (cli *CLI) RestoreRevision(ctx context.Context, p string, revisionID uint64) error {
+	_, err := cli.pCloudClient.RevertRevision(ctx, sdk.ByPath(remotePath(p)), revisionID)
+	return err
+}
+
+// parseTrashID parses the ID of a file ("f" followed by its fileid) or of a folder ("d"
+// followed by its folderid) of the trash.
+func parseTrashID(id string) (sdk.T6FileIDOrFolderID, error) {
+	if len(id) > 1 {
+		n, err := strconv.ParseUint(id[1:], 10, 64)
+		if err == nil {
+			switch id[0] {
+			case 'f':
+				return sdk.T6FileByID(n), nil
+			case 'd':
+				return sdk.T6FolderByID(n), nil
+			}
+		}
+	}
+
+	return nil, errors.Errorf("%s: invalid ID, use the IDs listed by 'trash list', such as 'f123' or 'd456'", id)
+}
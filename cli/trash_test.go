@@ -0,0 +1,83 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/cli"
+)
+
+func TestCLI_Trash(t *testing.T) {
+	ctx := context.Background()
+	srv, c := newTestCLI(t)
+
+	require.NoError(t, c.Remove(ctx, "r:/Docs/a.txt", false))
+	require.NoError(t, c.Remove(ctx, "r:/Docs/Sub", true))
+
+	var out bytes.Buffer
+	require.NoError(t, c.ListTrash(ctx, &out, cli.OutputPlain))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.True(t, strings.HasSuffix(lines[0], " a.txt"), lines[0])
+	assert.True(t, strings.HasSuffix(lines[1], " Sub/"), lines[1])
+
+	fileID, folderID := strings.Fields(lines[0])[0], strings.Fields(lines[1])[0]
+
+	require.NoError(t, c.RestoreFromTrash(ctx, fileID, ""))
+	data, err := srv.ReadFile("/Docs/a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789", string(data))
+
+	require.NoError(t, c.RestoreFromTrash(ctx, folderID, "r:/Archive"))
+	data, err = srv.ReadFile("/Archive/Sub/b.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+
+	assert.Error(t, c.RestoreFromTrash(ctx, folderID, ""))
+	assert.Error(t, c.RestoreFromTrash(ctx, "x1", ""))
+
+	require.NoError(t, c.Remove(ctx, "r:/Docs/a.txt", false))
+	require.NoError(t, c.Remove(ctx, "r:/Archive/Sub/b.txt", false))
+	require.NoError(t, c.EmptyTrash(ctx, fileID))
+
+	out.Reset()
+	require.NoError(t, c.ListTrash(ctx, &out, cli.OutputPlain))
+	assert.Equal(t, 1, strings.Count(out.String(), "\n"))
+	assert.Contains(t, out.String(), " b.txt\n")
+
+	require.NoError(t, c.EmptyTrash(ctx))
+	out.Reset()
+	require.NoError(t, c.ListTrash(ctx, &out, cli.OutputPlain))
+	assert.Empty(t, out.String())
+}
+
+func TestCLI_Revisions(t *testing.T) {
+	ctx := context.Background()
+	srv, c := newTestCLI(t)
+
+	_, err := srv.WriteFile("/Docs/a.txt", []byte("changed"))
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, c.ListRevisions(ctx, &out, "r:/Docs/a.txt", cli.OutputPlain))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 1)
+	assert.Equal(t, "10", strings.Fields(lines[0])[1])
+
+	revisionID, err := strconv.ParseUint(strings.Fields(lines[0])[0], 10, 64)
+	require.NoError(t, err)
+	require.NoError(t, c.RestoreRevision(ctx, "r:/Docs/a.txt", revisionID))
+
+	data, err := srv.ReadFile("/Docs/a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789", string(data))
+
+	assert.Error(t, c.RestoreRevision(ctx, "r:/Docs/a.txt", revisionID+100))
+	assert.Error(t, c.ListRevisions(ctx, &out, "r:/missing", cli.OutputPlain))
+}
@@ -0,0 +1,163 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// The statuses of VerifyResult.
+const (
+	// VerifyMissing is a local file that does not exist in pCloud.
+	VerifyMissing = "missing"
+	// VerifyChanged is a file whose sizes differ, or whose local copy was modified after the
+	// pCloud copy and whose hashes differ.
+	VerifyChanged = "changed"
+	// VerifyCorrupted is a file whose hashes differ although its sizes are the same and its
+	// local copy was not modified after the pCloud copy.
+	VerifyCorrupted = "corrupted"
+)
+
+// VerifyResult is a file that failed the verification of Verify, at Path relative to the
+// verified folders.
+type VerifyResult struct {
+	Status string `json:"status"`
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	// RemoteSize is the size of the pCloud copy of the changed and corrupted files.
+	RemoteSize int64 `json:"remote_size,omitempty"`
+}
+
+// VerifyReport lists the files that failed the verification of Verify.
+type VerifyReport struct {
+	Local  string `json:"local"`
+	Remote string `json:"remote"`
+	// Verified is the number of files whose copies are identical.
+	Verified int             `json:"verified"`
+	Results  []*VerifyResult `json:"results"`
+	// Skipped is the number of files not verified because of WithMaxSize.
+	Skipped int `json:"skipped"`
+}
+
+// OK returns whether all the files verified are identical.
+func (report *VerifyReport) OK() bool {
+	return len(report.Results) == 0
+}
+
+// Write writes the files that failed the verification to w, one per line, or as a table of
+// the results or as the JSON form of report, depending on output.
+func (report *VerifyReport) Write(w io.Writer, output Output) error {
+	if output == OutputJSON {
+		return writeJSON(w, report)
+	}
+
+	return writeList(w, output, report.Results, func(r *VerifyResult) error {
+		_, err := fmt.Fprintf(w, "%-9s %s\n", r.Status, r.Path)
+		return errors.WithStack(err)
+	})
+}
+
+func (*VerifyResult) tableHeader() []string {
+	return []string{"STATUS", "PATH", "SIZE", "REMOTE SIZE"}
+}
+
+func (r *VerifyResult) tableRow() []string {
+	return []string{r.Status, r.Path, strconv.FormatInt(r.Size, 10), strconv.FormatInt(r.RemoteSize, 10)}
+}
+
+// Verify checks that the files of the local folder localDir have an identical copy in the
+// pCloud folder remoteDir, without transferring their contents: the SHA-1 hashes that pCloud
+// computes (see sdk.Client.ChecksumFile) are compared to those of the local files, when their
+// sizes are the same. Files that only exist in pCloud are not reported.
+// The filters of WithInclude, WithExclude and WithMaxSize apply, and WithParallelism and
+// WithProgress are honoured.
+// The report lists the files verified so far, even when an error interrupts Verify.
+func (cli *CLI) Verify(ctx context.Context, localDir, remoteDir string, opts ...TransferOption) (*VerifyReport, error) {
+	tc, err := newTransferConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	remoteDir = PCloudPrefix + remotePath(remoteDir)
+
+	report := &VerifyReport{Local: localDir, Remote: remoteDir, Results: []*VerifyResult{}}
+
+	localTree, err := cli.syncTree(ctx, localDir, tc)
+	if err != nil {
+		return nil, err
+	}
+	if localTree == nil {
+		return nil, errors.Errorf("%s: no such folder", localDir)
+	}
+
+	remoteTree, err := cli.syncTree(ctx, remoteDir, tc)
+	if err != nil {
+		return nil, err
+	}
+	if remoteTree == nil {
+		return nil, errors.Errorf("%s: no such folder", remoteDir)
+	}
+
+	var items []transferItem
+
+	for _, rel := range sortedPaths(localTree) {
+		l := localTree[rel]
+		r, exists := remoteTree[rel]
+
+		switch {
+		case l.isDir:
+
+		case tc.maxSize > 0 && l.size > tc.maxSize:
+			report.Skipped++
+
+		case !exists || r.isDir:
+			report.Results = append(report.Results, &VerifyResult{Status: VerifyMissing, Path: rel, Size: l.size})
+
+		case l.size != r.size:
+			report.Results = append(report.Results, &VerifyResult{Status: VerifyChanged, Path: rel, Size: l.size, RemoteSize: r.size})
+
+		default:
+			items = append(items, transferItem{rel: rel, size: l.size, modTime: l.modTime})
+		}
+	}
+
+	var lock sync.Mutex
+
+	_, err = tc.run(ctx, items, func(ctx context.Context, item transferItem) error {
+		localHash, err := cli.sha1(ctx, localDir, item.rel)
+		if err != nil {
+			return err
+		}
+
+		remoteHash, err := cli.sha1(ctx, remoteDir, item.rel)
+		if err != nil {
+			return err
+		}
+
+		lock.Lock()
+		defer lock.Unlock()
+
+		if localHash == remoteHash {
+			report.Verified++
+			return nil
+		}
+
+		status := VerifyCorrupted
+		if item.modTime.Truncate(time.Second).After(remoteTree[item.rel].modTime.Truncate(time.Second)) {
+			status = VerifyChanged
+		}
+		report.Results = append(report.Results, &VerifyResult{Status: status, Path: item.rel, Size: item.size, RemoteSize: item.size})
+
+		return nil
+	})
+
+	sort.Slice(report.Results, func(i, j int) bool { return report.Results[i].Path < report.Results[j].Path })
+
+	return report, err
+}
@@ -0,0 +1,64 @@
+package cli_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/cli"
+)
+
+func TestCLI_Verify(t *testing.T) {
+	ctx := context.Background()
+	_, c := newTestCLI(t)
+	dir := t.TempDir()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "Sub"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("0123456789"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Sub", "b.txt"), []byte("hello"), 0o600))
+
+	report, err := c.Verify(ctx, dir, "r:/Docs")
+	require.NoError(t, err)
+	assert.True(t, report.OK())
+	assert.Equal(t, 2, report.Verified)
+
+	// same size, older than the copy: corrupted.
+	old := time.Now().Add(-24 * time.Hour)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("0123456780"), 0o600))
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "a.txt"), old, old))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Sub", "b.txt"), []byte("hello!"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "new.txt"), []byte("new"), 0o600))
+
+	report, err = c.Verify(ctx, dir, "/Docs", cli.WithParallelism(1))
+	require.NoError(t, err)
+	assert.False(t, report.OK())
+	assert.Equal(t, 0, report.Verified)
+	assert.Equal(t, []*cli.VerifyResult{
+		{Status: cli.VerifyChanged, Path: "Sub/b.txt", Size: 6, RemoteSize: 5},
+		{Status: cli.VerifyCorrupted, Path: "a.txt", Size: 10, RemoteSize: 10},
+		{Status: cli.VerifyMissing, Path: "new.txt", Size: 3},
+	}, report.Results)
+
+	// same size, more recent than the copy: changed.
+	future := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "a.txt"), future, future))
+
+	report, err = c.Verify(ctx, dir, "r:/Docs", cli.WithInclude("a.txt"), cli.WithMaxSize(8))
+	require.NoError(t, err)
+	assert.True(t, report.OK())
+	assert.Equal(t, 1, report.Skipped)
+
+	report, err = c.Verify(ctx, dir, "r:/Docs", cli.WithInclude("a.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, []*cli.VerifyResult{{Status: cli.VerifyChanged, Path: "a.txt", Size: 10, RemoteSize: 10}}, report.Results)
+
+	_, err = c.Verify(ctx, dir, "r:/Missing")
+	assert.Error(t, err)
+	_, err = c.Verify(ctx, filepath.Join(dir, "missing"), "r:/Docs")
+	assert.Error(t, err)
+}
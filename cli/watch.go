@@ -0,0 +1,199 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+// The kinds of WatchEvent.
+const (
+	WatchCreate  = "create"
+	WatchModify  = "modify"
+	WatchDelete  = "delete"
+	WatchShare   = "share"
+	WatchAccount = "account"
+)
+
+// WatchEvent is a change of the account, as written by Watch.
+type WatchEvent struct {
+	Time time.Time `json:"time"`
+	Kind string    `json:"kind"`
+	// Event is the pCloud event, such as "createfile" or "requestsharein" (see sdk.Event).
+	Event string `json:"event"`
+	// Name is the name of the file or folder, or of the shared folder.
+	Name string `json:"name,omitempty"`
+	// Path is the path of the file or folder, when its folder is known.
+	Path     string `json:"path,omitempty"`
+	IsFolder bool   `json:"is_folder,omitempty"`
+	// ID is the fileid of the files, the folderid of the folders and of the shares.
+	ID   uint64 `json:"id,omitempty"`
+	Size uint64 `json:"size,omitempty"`
+	// Mail is the email address of the other user of a share.
+	Mail string `json:"mail,omitempty"`
+}
+
+// watchFolder is a folder of the tree of the account, as tracked by Watch.
+type watchFolder struct {
+	name     string
+	parentID uint64
+}
+
+// Watch writes the changes of the account received from entries (see sdk.Client.Subscribe) to
+// w, one per line, or as JSON lines of WatchEvent with OutputJSON, until entries is closed or
+// ctx is cancelled. The lines are written as the changes are received: OutputTable is the same
+// as OutputPlain, whose columns are already aligned.
+// The paths of the files and folders are resolved from the tree of the folders of the account,
+// which is listed first and then kept up to date with the entries.
+func (cli *CLI) Watch(ctx context.Context, w io.Writer, entries <-chan sdk.Entry, output Output) error {
+	lf, err := cli.pCloudClient.List(ctx, sdk.ByID(sdk.RootFolderID), sdk.ListOptions{Recursive: true, NoFiles: true})
+	if err != nil {
+		return err
+	}
+
+	folders := map[uint64]watchFolder{}
+
+	var walk func(m *sdk.Metadata)
+	walk = func(m *sdk.Metadata) {
+		for _, c := range m.Contents {
+			if c.IsFolder {
+				folders[c.FolderID] = watchFolder{name: c.Name, parentID: m.FolderID}
+				walk(c)
+			}
+		}
+	}
+	walk(lf.Metadata)
+
+	for {
+		var e sdk.Entry
+		var ok bool
+
+		select {
+		case e, ok = <-entries:
+		case <-ctx.Done():
+			return errors.WithStack(ctx.Err())
+		}
+		if !ok {
+			return nil
+		}
+
+		we, ok := watchEvent(e, folders)
+		if !ok {
+			continue
+		}
+
+		if output == OutputJSON {
+			err = writeJSONLine(w, we)
+		} else {
+			err = writeWatchEvent(w, we)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// watchEvent returns the WatchEvent of e, and updates folders with it. It returns false for the
+// entries that are not reported.
+func watchEvent(e sdk.Entry, folders map[uint64]watchFolder) (*WatchEvent, bool) {
+	we := &WatchEvent{Time: e.Time, Event: string(e.Event)}
+
+	switch {
+	case e.Event.IsFolderEvent(), e.Event.IsFileEvent():
+		m := e.Metadata
+
+		switch e.Event {
+		case sdk.CreateFolder, sdk.CreateFile:
+			we.Kind = WatchCreate
+		case sdk.ModifyFolder, sdk.ModifyFile:
+			we.Kind = WatchModify
+		default:
+			we.Kind = WatchDelete
+		}
+
+		we.Name, we.IsFolder, we.Size = m.Name, m.IsFolder, m.Size
+		we.ID = m.FileID
+		if m.IsFolder {
+			we.ID = m.FolderID
+		}
+
+		if parent, ok := folderPath(folders, m.ParentFolderID); ok {
+			we.Path = path.Join(parent, m.Name)
+		}
+
+		switch e.Event {
+		case sdk.CreateFolder, sdk.ModifyFolder:
+			folders[m.FolderID] = watchFolder{name: m.Name, parentID: m.ParentFolderID}
+		case sdk.DeleteFolder:
+			delete(folders, m.FolderID)
+		}
+
+	case e.Event.IsShareEvent():
+		we.Kind = WatchShare
+
+		if s := e.Share; s != nil {
+			we.Name, we.ID, we.Mail = s.ShareName, s.FolderID, s.FromMail
+			if we.Mail == "" {
+				we.Mail = s.ToMail
+			}
+		}
+
+	case e.Event == sdk.ModifyUserInfo:
+		we.Kind = WatchAccount
+
+		if u := e.UserInfo; u != nil {
+			we.Mail = u.Email
+		}
+
+	default:
+		return nil, false
+	}
+
+	return we, true
+}
+
+// folderPath returns the path of the folder id, if it is known.
+func folderPath(folders map[uint64]watchFolder, id uint64) (string, bool) {
+	p := ""
+
+	// the depth is bounded in case of a cycle.
+	for i := 0; i < 1024; i++ {
+		if id == sdk.RootFolderID {
+			return "/" + p, true
+		}
+
+		f, ok := folders[id]
+		if !ok {
+			return "", false
+		}
+
+		p = path.Join(f.name, p)
+		id = f.parentID
+	}
+
+	return "", false
+}
+
+// writeWatchEvent writes we to w, as a line of text.
+func writeWatchEvent(w io.Writer, we *WatchEvent) error {
+	subject := we.Path
+	switch {
+	case subject == "":
+		subject = we.Name
+	case we.IsFolder:
+		subject += "/"
+	}
+	if we.Mail != "" {
+		subject += " (" + we.Mail + ")"
+	}
+
+	_, err := fmt.Fprintf(w, "%s %-7s %-16s %s\n", we.Time.Local().Format("2006-01-02 15:04:05"), we.Kind, we.Event, subject)
+
+	return errors.WithStack(err)
+}
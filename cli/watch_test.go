@@ -0,0 +1,65 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/cli"
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+func TestCLI_Watch(t *testing.T) {
+	ctx := context.Background()
+	srv, c := newTestCLI(t)
+
+	docsID, err := srv.MkdirAll("/Docs")
+	require.NoError(t, err)
+
+	at := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	entries := func() <-chan sdk.Entry {
+		ch := make(chan sdk.Entry, 8)
+		ch <- sdk.Entry{Event: sdk.CreateFolder, Time: at, Metadata: sdk.Metadata{Name: "New", IsFolder: true, FolderID: 1000, ParentFolderID: docsID}}
+		ch <- sdk.Entry{Event: sdk.CreateFile, Time: at, Metadata: sdk.Metadata{Name: "c.txt", FileID: 2000, ParentFolderID: 1000, Size: 3}}
+		ch <- sdk.Entry{Event: sdk.DeleteFile, Time: at, Metadata: sdk.Metadata{Name: "x.txt", FileID: 2001, ParentFolderID: 9999}}
+		ch <- sdk.Entry{Event: sdk.Reset, Time: at}
+		ch <- sdk.Entry{Event: sdk.RequestShareIn, Time: at, Share: &sdk.EventShare{ShareName: "Team", FolderID: 3000, FromMail: "bob@example.com"}}
+		close(ch)
+		return ch
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, c.Watch(ctx, &out, entries(), cli.OutputPlain))
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	require.Len(t, lines, 4)
+	assert.Contains(t, lines[0], " create  createfolder     /Docs/New/")
+	assert.Contains(t, lines[1], " create  createfile       /Docs/New/c.txt")
+	assert.Contains(t, lines[2], " delete  deletefile       x.txt")
+	assert.Contains(t, lines[3], " share   requestsharein   Team (bob@example.com)")
+
+	out.Reset()
+	require.NoError(t, c.Watch(ctx, &out, entries(), cli.OutputJSON))
+
+	dec := json.NewDecoder(&out)
+	var events []cli.WatchEvent
+	for dec.More() {
+		var e cli.WatchEvent
+		require.NoError(t, dec.Decode(&e))
+		events = append(events, e)
+	}
+	require.Len(t, events, 4)
+	assert.Equal(t, cli.WatchEvent{Time: at, Kind: cli.WatchCreate, Event: "createfile", Name: "c.txt", Path: "/Docs/New/c.txt", ID: 2000, Size: 3}, events[1])
+	assert.Equal(t, cli.WatchEvent{Time: at, Kind: cli.WatchShare, Event: "requestsharein", Name: "Team", ID: 3000, Mail: "bob@example.com"}, events[3])
+
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.ErrorIs(t, c.Watch(cctx, &out, make(chan sdk.Entry), cli.OutputPlain), context.Canceled)
+}
@@ -4,13 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
-	"time"
 
 	"github.com/urfave/cli/v2"
 	"go.uber.org/zap"
 
-	"github.com/seborama/pcloud-sdk/sdk"
 	"github.com/seborama/pcloud-sdk/tracker"
 	"github.com/seborama/pcloud-sdk/tracker/db"
 	"github.com/seborama/pcloud-sdk/tracker/filesystem"
@@ -20,24 +17,7 @@ func analyse(c *cli.Context) error {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	httpClient := &http.Client{
-		Transport: &http.Transport{
-			MaxIdleConnsPerHost:   1,
-			MaxConnsPerHost:       1,
-			ResponseHeaderTimeout: 20 * time.Second,
-			Proxy:                 http.ProxyFromEnvironment,
-		},
-		Timeout: 0,
-	}
-
-	pCloudClient := sdk.NewClient(httpClient)
-
-	err := pCloudClient.Login(
-		ctx,
-		c.String("pcloud-otp-code"),
-		sdk.WithGlobalOptionUsername(c.String("pcloud-username")),
-		sdk.WithGlobalOptionPassword(c.String("pcloud-password")),
-	)
+	pCloudClient, err := newPCloudClient(ctx, c)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,326 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	ucli "github.com/urfave/cli/v2"
+	"go.uber.org/zap"
+
+	pcli "github.com/seborama/pcloud-sdk/cli"
+	"github.com/seborama/pcloud-sdk/sdk"
+	"github.com/seborama/pcloud-sdk/tracker"
+	"github.com/seborama/pcloud-sdk/tracker/db"
+	"github.com/seborama/pcloud-sdk/tracker/filesystem"
+)
+
+// bisync synchronises a local folder and a pCloud folder in both directions, against the state
+// of their last sync that is kept in the tracker database.
+func bisync(c *ucli.Context) error {
+	if c.NArg() != 2 {
+		return errors.Errorf("usage: %s %s", c.Command.Name, c.Command.ArgsUsage)
+	}
+
+	localRoot := c.Args().Get(0)
+	remoteRoot := c.Args().Get(1)
+	if !strings.HasPrefix(remoteRoot, pcli.PCloudPrefix) {
+		return errors.Errorf("the pCloud folder must be prefixed with '%s': %s", pcli.PCloudPrefix, remoteRoot)
+	}
+
+	pairName := c.String("pair")
+	if pairName == "" {
+		var err error
+		pairName, err = defaultPairName(localRoot, remoteRoot)
+		if err != nil {
+			return err
+		}
+	}
+
+	policy, err := tracker.ParseConflictPolicy(c.String("conflict"))
+	if err != nil {
+		return err
+	}
+
+	direction, err := tracker.ParseSyncDirection(c.String("direction"))
+	if err != nil {
+		return err
+	}
+
+	symlinks, err := filesystem.ParseSymlinkPolicy(c.String("symlinks"))
+	if err != nil {
+		return err
+	}
+
+	specialFiles, err := filesystem.ParseSpecialFilePolicy(c.String("special-files"))
+	if err != nil {
+		return err
+	}
+
+	if c.Int("delete-threshold") < 0 || c.Int("delete-threshold") > 100 {
+		return errors.Errorf("invalid --delete-threshold: %d%%", c.Int("delete-threshold"))
+	}
+
+	if c.Bool("dry-run") && c.Bool("watch") {
+		return errors.New("--dry-run and --watch cannot be used together")
+	}
+
+	selectAll := c.Bool("select-all")
+	selection := c.StringSlice("select")
+	if selectAll && len(selection) > 0 {
+		return errors.New("--select and --select-all cannot be used together")
+	}
+	if len(selection) > 0 {
+		selection, err = tracker.ParseSelection(selection)
+		if err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	pCloudClient, err := newPCloudClient(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	store, err := db.NewSQLite3(ctx, c.String("db-path"))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = store.Close() }()
+
+	if selectAll || len(selection) > 0 {
+		err = store.ReplaceSyncSelection(ctx, db.PairName(pairName), selection)
+		if err != nil {
+			return err
+		}
+	}
+
+	logger, _ := zap.NewProduction()
+	defer func() { _ = logger.Sync() }()
+
+	s := tracker.NewTwoWay(
+		logger,
+		store,
+		pCloudClient,
+		db.PairName(pairName),
+		localRoot,
+		strings.TrimPrefix(remoteRoot, pcli.PCloudPrefix),
+		tracker.WithHTTPClient(sdk.NewHTTPClient(sdk.DefaultTransportConfig())),
+		tracker.WithConflictPolicy(policy),
+		tracker.WithConflictAsker(askConflict(c)),
+		tracker.WithDirection(direction),
+		tracker.WithIgnoreFile(c.String("ignore-file")),
+		tracker.WithHashWorkers(c.Int("hash-workers")),
+		tracker.WithSymlinks(symlinks),
+		tracker.WithSpecialFiles(specialFiles),
+		tracker.WithFileModes(c.Bool("file-modes")),
+		tracker.WithDeleteThreshold(c.Int("delete-threshold")),
+		tracker.WithPermanentDeletes(c.Bool("permanent-deletes")),
+	)
+
+	if c.Bool("dry-run") {
+		actions, err := s.Plan(ctx)
+		if err != nil {
+			return err
+		}
+		printPlan(actions, output(c))
+		return nil
+	}
+
+	if c.Bool("watch") {
+		return s.Watch(ctx,
+			tracker.WithFullScanInterval(c.Duration("full-scan-interval")),
+			tracker.WithSyncReport(func(stats *tracker.SyncStats, err error) {
+				if stats != nil {
+					printSyncStats(stats, output(c))
+				}
+				if err != nil {
+					fmt.Fprintln(os.Stderr, "sync failed:", err)
+				}
+			}),
+		)
+	}
+
+	stats, err := s.Sync(ctx)
+	if stats != nil {
+		printSyncStats(stats, output(c))
+	}
+
+	return err
+}
+
+// syncHistory prints the most recent syncs of a sync pair, as recorded in the tracker database: the
+// pair of --pair, or else that of the two folders.
+func syncHistory(c *ucli.Context) error {
+	pairName := c.String("pair")
+
+	switch {
+	case pairName == "" && c.NArg() == 2:
+		remoteRoot := c.Args().Get(1)
+		if !strings.HasPrefix(remoteRoot, pcli.PCloudPrefix) {
+			return errors.Errorf("the pCloud folder must be prefixed with '%s': %s", pcli.PCloudPrefix, remoteRoot)
+		}
+
+		var err error
+		pairName, err = defaultPairName(c.Args().Get(0), remoteRoot)
+		if err != nil {
+			return err
+		}
+
+	case pairName == "" || c.NArg() != 0:
+		return errors.Errorf("usage: %s %s", c.Command.Name, c.Command.ArgsUsage)
+	}
+
+	ctx := context.Background()
+
+	store, err := db.NewSQLite3(ctx, c.String("db-path"))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = store.Close() }()
+
+	runs, err := store.GetSyncRuns(ctx, db.PairName(pairName), c.Int("limit"))
+	if err != nil {
+		return err
+	}
+
+	printSyncRuns(runs, output(c))
+
+	return nil
+}
+
+// defaultPairName returns the name of the sync pair of the local folder localRoot and the pCloud
+// folder remoteRoot, prefixed with 'r:', when it is not set.
+func defaultPairName(localRoot, remoteRoot string) (string, error) {
+	// the state of the sync must not be shared by different folders: it would make each of them
+	// delete the files of the other one.
+	abs, err := filepath.Abs(localRoot)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return abs + " " + remoteRoot, nil
+}
+
+// askConflict returns the ConflictAsker of the "ask" conflict policy, which asks on the terminal
+// which file to keep.
+func askConflict(c *ucli.Context) tracker.ConflictAsker {
+	answers := map[string]tracker.ConflictPolicy{
+		"l": tracker.ConflictPreferLocal,
+		"r": tracker.ConflictPreferRemote,
+		"b": tracker.ConflictKeepBoth,
+		"n": tracker.ConflictKeepNewest,
+		"s": tracker.ConflictSkip,
+	}
+
+	return func(_ context.Context, conflict tracker.Conflict) (tracker.ConflictPolicy, error) {
+		fmt.Fprintf(os.Stderr, "conflict: %s changed on both sides\n  local:  %s, modified %s\n  pCloud: %s, modified %s\n",
+			conflict.Path,
+			pcli.FormatSize(int64(conflict.LocalSize)), conflict.LocalModified.Format(time.RFC3339),
+			pcli.FormatSize(int64(conflict.RemoteSize)), conflict.RemoteModified.Format(time.RFC3339))
+
+		for {
+			answer, err := prompt(c, "keep the [l]ocal file, the [r]emote file, [b]oth, the [n]ewest, or [s]kip? ", "the resolution of the conflict is required (see --conflict)")
+			if err != nil {
+				return "", err
+			}
+
+			if policy, ok := answers[strings.ToLower(strings.TrimSpace(answer))]; ok {
+				return policy, nil
+			}
+		}
+	}
+}
+
+func printSyncStats(stats *tracker.SyncStats, output pcli.Output) {
+	if output == pcli.OutputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(stats)
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "%d files uploaded, %d downloaded, %d moved locally, %d moved in pCloud, %d deleted locally, %d deleted from pCloud, %d conflicts resolved\n",
+		stats.Uploaded, stats.Downloaded, stats.MovedLocal, stats.MovedRemote, stats.DeletedLocal, stats.DeletedRemote, stats.ResolvedConflicts)
+	for _, p := range stats.Conflicts {
+		fmt.Fprintf(os.Stderr, "conflict: %s changed on both sides, it was left untouched\n", p)
+	}
+}
+
+// printSyncRuns prints the syncs of a pair: one per line, the most recent first, or as a JSON
+// array.
+func printSyncRuns(runs []db.SyncRun, output pcli.Output) {
+	if output == pcli.OutputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(runs)
+		return
+	}
+
+	for _, run := range runs {
+		status := "ok"
+		if run.Error != "" {
+			status = "failed"
+		}
+
+		fmt.Printf("%s  %-6s  %8s  %d scanned, %d uploaded (%s), %d downloaded (%s), %d deleted, %d moved, %d conflicts, %d errors\n",
+			run.Started.Local().Format("2006-01-02 15:04:05"),
+			status,
+			run.Duration.Round(time.Second),
+			run.Scanned,
+			run.Uploaded, pcli.FormatSize(run.UploadedBytes),
+			run.Downloaded, pcli.FormatSize(run.DownloadedBytes),
+			run.DeletedLocal+run.DeletedRemote,
+			run.MovedLocal+run.MovedRemote,
+			run.Conflicts,
+			run.Errors,
+		)
+		if run.Error != "" {
+			fmt.Printf("    %s\n", run.Error)
+		}
+	}
+
+	if len(runs) == 0 {
+		fmt.Fprintln(os.Stderr, "the pair has not been synced yet")
+	}
+}
+
+// printPlan prints the changes that a sync would apply: one per line, or as a JSON array.
+func printPlan(actions []tracker.PlannedAction, output pcli.Output) {
+	if output == pcli.OutputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(actions)
+		return
+	}
+
+	for _, a := range actions {
+		p := a.Path
+		if a.IsFolder {
+			p += "/"
+		}
+
+		switch {
+		case a.From != "":
+			fmt.Printf("%-13s %s -> %s\n", a.Action, a.From, p)
+		case a.Action == "conflict":
+			fmt.Printf("%-13s %s (%s)\n", a.Action, p, a.Resolution)
+		case a.Size > 0:
+			fmt.Printf("%-13s %s (%s)\n", a.Action, p, pcli.FormatSize(int64(a.Size)))
+		default:
+			fmt.Printf("%-13s %s\n", a.Action, p)
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "%d changes planned, none applied (dry run)\n", len(actions))
+}
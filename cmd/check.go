@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/pkg/errors"
+	ucli "github.com/urfave/cli/v2"
+	"go.uber.org/zap"
+
+	pcli "github.com/seborama/pcloud-sdk/cli"
+	"github.com/seborama/pcloud-sdk/sdk"
+	"github.com/seborama/pcloud-sdk/tracker"
+	"github.com/seborama/pcloud-sdk/tracker/db"
+	"github.com/seborama/pcloud-sdk/tracker/filesystem"
+)
+
+// check validates the state of a sync pair, as held in the tracker database, against the local
+// folder and the pCloud folder, and rebuilds it from them with --repair.
+func check(c *ucli.Context) error {
+	if c.NArg() != 2 {
+		return errors.Errorf("usage: %s %s", c.Command.Name, c.Command.ArgsUsage)
+	}
+
+	localRoot := c.Args().Get(0)
+	remoteRoot := c.Args().Get(1)
+	if !strings.HasPrefix(remoteRoot, pcli.PCloudPrefix) {
+		return errors.Errorf("the pCloud folder must be prefixed with '%s': %s", pcli.PCloudPrefix, remoteRoot)
+	}
+
+	pairName := c.String("pair")
+	if pairName == "" {
+		var err error
+		pairName, err = defaultPairName(localRoot, remoteRoot)
+		if err != nil {
+			return err
+		}
+	}
+
+	symlinks, err := filesystem.ParseSymlinkPolicy(c.String("symlinks"))
+	if err != nil {
+		return err
+	}
+
+	specialFiles, err := filesystem.ParseSpecialFilePolicy(c.String("special-files"))
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	pCloudClient, err := newPCloudClient(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	store, err := db.NewSQLite3(ctx, c.String("db-path"))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = store.Close() }()
+
+	logger, _ := zap.NewProduction()
+	defer func() { _ = logger.Sync() }()
+
+	s := tracker.NewTwoWay(
+		logger,
+		store,
+		pCloudClient,
+		db.PairName(pairName),
+		localRoot,
+		strings.TrimPrefix(remoteRoot, pcli.PCloudPrefix),
+		tracker.WithHTTPClient(sdk.NewHTTPClient(sdk.DefaultTransportConfig())),
+		tracker.WithIgnoreFile(c.String("ignore-file")),
+		tracker.WithHashWorkers(c.Int("hash-workers")),
+		tracker.WithSymlinks(symlinks),
+		tracker.WithSpecialFiles(specialFiles),
+	)
+
+	report, err := s.Check(ctx, c.Bool("repair"))
+	if err != nil {
+		return err
+	}
+
+	printCheckReport(report, output(c))
+
+	if len(report.Issues) > 0 && !report.Repaired {
+		return errors.Errorf("%d divergences found: use --repair to rebuild the state of the pair", len(report.Issues))
+	}
+
+	return nil
+}
+
+// printCheckReport prints the divergences of a check, and a summary to the standard error unless
+// the output is JSON.
+func printCheckReport(report *tracker.CheckReport, output pcli.Output) {
+	if output == pcli.OutputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(report)
+		return
+	}
+
+	for _, issue := range report.Issues {
+		fmt.Printf("%-11s %s (%s)\n", issue.Kind, issue.Path, issue.Detail)
+	}
+
+	fmt.Fprintf(os.Stderr, "%d paths checked, %d divergences", report.Checked, len(report.Issues))
+	if report.Repaired {
+		fmt.Fprint(os.Stderr, ", repaired")
+	}
+	fmt.Fprintln(os.Stderr)
+}
@@ -2,9 +2,14 @@ package main
 
 import (
 	"context"
-	"net/http"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
+	"github.com/pkg/errors"
 	ucli "github.com/urfave/cli/v2"
 
 	pcli "github.com/seborama/pcloud-sdk/cli"
@@ -15,44 +20,330 @@ func pCLI(c *ucli.Context) error {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	sdkHTTPClient := &http.Client{
-		Transport: &http.Transport{
-			MaxIdleConnsPerHost:   1,
-			MaxConnsPerHost:       1,
-			ResponseHeaderTimeout: 20 * time.Second,
-			Proxy:                 http.ProxyFromEnvironment,
-		},
-		Timeout: 0,
+	pCli, err := newCLI(ctx, c)
+	if err != nil {
+		return err
 	}
 
-	pCloudClient := sdk.NewClient(sdkHTTPClient)
-
-	err := pCloudClient.Login(
-		ctx,
-		c.String("pcloud-otp-code"),
-		sdk.WithGlobalOptionUsername(c.String("pcloud-username")),
-		sdk.WithGlobalOptionPassword(c.String("pcloud-password")),
-	)
+	err = pCli.Copy(ctx, c.String("from"), c.String("to"))
 	if err != nil {
 		return err
 	}
 
-	cliHTTPClient := &http.Client{
-		Transport: &http.Transport{
-			MaxIdleConnsPerHost:   1,
-			MaxConnsPerHost:       1,
-			ResponseHeaderTimeout: 20 * time.Second,
-			Proxy:                 http.ProxyFromEnvironment,
-		},
-		Timeout: 0,
+	return nil
+}
+
+func about(c *ucli.Context) error {
+	return withCLI(c, 0, 0, func(ctx context.Context, pCli *pcli.CLI) error {
+		return pCli.About(ctx, os.Stdout, output(c))
+	})
+}
+
+func ls(c *ucli.Context) error {
+	return withCLI(c, 0, 1, func(ctx context.Context, pCli *pcli.CLI) error {
+		p := c.Args().First()
+		if p == "" {
+			p = "/"
+		}
+		return pCli.List(ctx, os.Stdout, p, c.Bool("long"), output(c))
+	})
+}
+
+func cp(c *ucli.Context) error {
+	return withCLI(c, 2, 2, func(ctx context.Context, pCli *pcli.CLI) error {
+		return pCli.Copy(ctx, c.Args().Get(0), c.Args().Get(1))
+	})
+}
+
+func mv(c *ucli.Context) error {
+	return withCLI(c, 2, 2, func(ctx context.Context, pCli *pcli.CLI) error {
+		return pCli.Move(ctx, c.Args().Get(0), c.Args().Get(1))
+	})
+}
+
+func rm(c *ucli.Context) error {
+	return withCLI(c, 1, -1, func(ctx context.Context, pCli *pcli.CLI) error {
+		for _, p := range c.Args().Slice() {
+			err := pCli.Remove(ctx, p, c.Bool("recursive"))
+			if err != nil {
+				return errors.WithMessage(err, p)
+			}
+		}
+		return nil
+	})
+}
+
+func mkdir(c *ucli.Context) error {
+	return withCLI(c, 1, -1, func(ctx context.Context, pCli *pcli.CLI) error {
+		for _, p := range c.Args().Slice() {
+			err := pCli.Mkdir(ctx, p, c.Bool("parents"))
+			if err != nil {
+				return errors.WithMessage(err, p)
+			}
+		}
+		return nil
+	})
+}
+
+func cat(c *ucli.Context) error {
+	return withCLI(c, 1, -1, func(ctx context.Context, pCli *pcli.CLI) error {
+		offset, length := int64(0), int64(-1)
+		if c.IsSet("range") {
+			var err error
+			offset, length, err = pcli.ParseRange(c.String("range"))
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, p := range c.Args().Slice() {
+			err := pCli.CatRange(ctx, os.Stdout, p, offset, length)
+			if err != nil {
+				return errors.WithMessage(err, p)
+			}
+		}
+		return nil
+	})
+}
+
+func put(c *ucli.Context) error {
+	return withCLI(c, 2, 2, func(ctx context.Context, pCli *pcli.CLI) error {
+		src := c.Args().Get(0)
+		if src == "-" {
+			return pCli.Put(ctx, os.Stdin, c.Args().Get(1))
+		}
+
+		f, err := os.Open(src)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		defer func() { _ = f.Close() }()
+
+		return pCli.Put(ctx, f, c.Args().Get(1))
+	})
+}
+
+func du(c *ucli.Context) error {
+	return withCLI(c, 0, 1, func(ctx context.Context, pCli *pcli.CLI) error {
+		p := c.Args().First()
+		if p == "" {
+			p = "/"
+		}
+		return pCli.DiskUsage(ctx, os.Stdout, p, c.Int("depth"), c.String("sort"), output(c))
+	})
+}
+
+func upload(c *ucli.Context) error {
+	return withCLI(c, 2, 2, func(ctx context.Context, pCli *pcli.CLI) error {
+		opts, err := transferOptions(c)
+		if err != nil {
+			return err
+		}
+
+		stats, err := pCli.Upload(ctx, c.Args().Get(0), c.Args().Get(1), opts...)
+		if stats != nil {
+			printTransferStats(stats, output(c))
+		}
+		return err
+	})
+}
+
+func download(c *ucli.Context) error {
+	return withCLI(c, 2, 2, func(ctx context.Context, pCli *pcli.CLI) error {
+		opts, err := transferOptions(c)
+		if err != nil {
+			return err
+		}
+
+		stats, err := pCli.Download(ctx, c.Args().Get(0), c.Args().Get(1), opts...)
+		if stats != nil {
+			printTransferStats(stats, output(c))
+		}
+		return err
+	})
+}
+
+func syncCmd(c *ucli.Context) error {
+	return withCLI(c, 2, 2, func(ctx context.Context, pCli *pcli.CLI) error {
+		opts, err := transferOptions(c)
+		if err != nil {
+			return err
+		}
+
+		if c.Bool("delete-extraneous") {
+			opts = append(opts, pcli.WithDeleteExtraneous())
+		}
+		if c.Bool("dry-run") {
+			opts = append(opts, pcli.WithDryRun())
+		}
+		if c.Bool("checksum") {
+			opts = append(opts, pcli.WithChecksum())
+		}
+
+		report, err := pCli.Sync(ctx, c.Args().Get(0), c.Args().Get(1), opts...)
+		if report != nil {
+			_ = report.Write(os.Stdout, output(c))
+		}
+		return err
+	})
+}
+
+func verify(c *ucli.Context) error {
+	return withCLI(c, 2, 2, func(ctx context.Context, pCli *pcli.CLI) error {
+		opts, err := transferOptions(c)
+		if err != nil {
+			return err
+		}
+
+		report, err := pCli.Verify(ctx, c.Args().Get(0), c.Args().Get(1), opts...)
+		if report != nil {
+			printVerifyReport(report, output(c))
+		}
+		if err != nil {
+			return err
+		}
+
+		if !report.OK() {
+			return errors.Errorf("%d files failed the verification", len(report.Results))
+		}
+		return nil
+	})
+}
+
+// printVerifyReport writes the files that failed a verification to the standard output, and
+// a summary to the standard error unless the output is JSON.
+func printVerifyReport(report *pcli.VerifyReport, output pcli.Output) {
+	_ = report.Write(os.Stdout, output)
+	if output == pcli.OutputJSON {
+		return
 	}
 
-	pCli := pcli.NewCLI(pCloudClient, cliHTTPClient)
+	fmt.Fprintf(os.Stderr, "%d files verified, %d failed", report.Verified, len(report.Results))
+	if report.Skipped > 0 {
+		fmt.Fprintf(os.Stderr, " (%d files skipped: too large)", report.Skipped)
+	}
+	fmt.Fprintln(os.Stderr)
+}
 
-	err = pCli.Copy(ctx, c.String("from"), c.String("to"))
+// transferOptions returns the options of upload and download given on the command line, by
+// environment variables or else by the defaults of the profile.
+func transferOptions(c *ucli.Context) ([]pcli.TransferOption, error) {
+	defaults, err := profileDefaults(c)
+	if err != nil {
+		return nil, err
+	}
+
+	exclude := c.StringSlice("exclude")
+	if !c.IsSet("exclude") {
+		exclude = defaults.Exclude
+	}
+
+	parallel := c.Int("parallel")
+	if !c.IsSet("parallel") && defaults.Parallel > 0 {
+		parallel = defaults.Parallel
+	}
+
+	bandwidthLimit := c.String("bandwidth-limit")
+	if !c.IsSet("bandwidth-limit") {
+		bandwidthLimit = defaults.BandwidthLimit
+	}
+
+	opts := []pcli.TransferOption{
+		pcli.WithInclude(c.StringSlice("include")...),
+		pcli.WithExclude(exclude...),
+		pcli.WithMaxSize(c.Int64("max-size")),
+		pcli.WithParallelism(parallel),
+	}
+
+	if bandwidthLimit != "" {
+		rate, err := pcli.ParseSize(bandwidthLimit)
+		if err != nil {
+			return nil, errors.WithMessage(err, "bandwidth limit")
+		}
+		opts = append(opts, pcli.WithBandwidthLimit(rate))
+	}
+
+	if !c.Bool("quiet") {
+		opts = append(opts, pcli.WithProgress(os.Stderr))
+	}
+
+	return opts, nil
+}
+
+// profileDefaults returns the defaults of the selected profile, which are empty when the
+// profile has none or when the command does not use a profile (see hasCredentials).
+func profileDefaults(c *ucli.Context) (*pcli.Defaults, error) {
+	if hasCredentials(c) {
+		return &pcli.Defaults{}, nil
+	}
+
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, ok := cfg.Profiles[c.String("profile")]
+	if !ok || profile.Defaults == nil {
+		return &pcli.Defaults{}, nil
+	}
+
+	return profile.Defaults, nil
+}
+
+// printTransferStats writes the summary of a transfer to the standard error, or to the standard
+// output as JSON.
+func printTransferStats(stats *pcli.TransferStats, output pcli.Output) {
+	if output == pcli.OutputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(stats)
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "%d files, %s transferred in %s", stats.Files, pcli.FormatSize(stats.Bytes), stats.Duration.Round(time.Millisecond))
+	if stats.Skipped > 0 {
+		fmt.Fprintf(os.Stderr, " (%d files skipped: too large)", stats.Skipped)
+	}
+	fmt.Fprintln(os.Stderr)
+}
+
+// output returns the format of the results of the command: JSON when its --json flag is set,
+// or the format of the global --output flag, which is checked by the Before hook of the app.
+func output(c *ucli.Context) pcli.Output {
+	if c.Bool("json") {
+		return pcli.OutputJSON
+	}
+
+	o, _ := pcli.ParseOutput(c.String("output"))
+
+	return o
+}
+
+// withCLI checks that the command has between minArgs and maxArgs arguments (-1 for no
+// maximum), logs in to pCloud and runs fn, which is cancelled on interrupt.
+func withCLI(c *ucli.Context, minArgs, maxArgs int, fn func(ctx context.Context, pCli *pcli.CLI) error) error {
+	if c.NArg() < minArgs || (maxArgs >= 0 && c.NArg() > maxArgs) {
+		return errors.Errorf("usage: %s %s", c.Command.Name, c.Command.ArgsUsage)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	pCli, err := newCLI(ctx, c)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return fn(ctx, pCli)
+}
+
+func newCLI(ctx context.Context, c *ucli.Context) (*pcli.CLI, error) {
+	pCloudClient, err := newPCloudClient(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	cliHTTPClient := sdk.NewHTTPClient(sdk.DefaultTransportConfig())
+
+	return pcli.NewCLI(pCloudClient, cliHTTPClient), nil
 }
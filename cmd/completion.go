@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	ucli "github.com/urfave/cli/v2"
+
+	pcli "github.com/seborama/pcloud-sdk/cli"
+)
+
+// The completion scripts call pcloud with the words of the command line up to the word being
+// completed, followed by --generate-bash-completion: pcloud writes the completions of that last
+// word, one per line (see completePaths). When there are none, the shell completes local paths.
+var completionScripts = map[string]string{
+	"bash": `_%[1]s_complete() {
+    local line="${COMP_LINE:0:COMP_POINT}"
+    local cur="${line##*[[:space:]]}"
+    local -a words
+    read -r -a words <<< "${line:0:${#line}-${#cur}}"
+
+    local IFS=$'\n'
+    COMPREPLY=($(compgen -W "$("${words[0]}" "${words[@]:1}" "$cur" --generate-bash-completion 2>/dev/null)" -- "$cur"))
+
+    # bash splits the word being completed at colons, as in r:/path.
+    local prefix="${cur%%"${COMP_WORDS[COMP_CWORD]}"}"
+    if [[ -n "$prefix" ]]; then
+        COMPREPLY=("${COMPREPLY[@]#"$prefix"}")
+    fi
+
+    if [[ ${#COMPREPLY[@]} -eq 1 && "${COMPREPLY[0]}" == */ ]]; then
+        compopt -o nospace
+    fi
+}
+
+complete -o default -F _%[1]s_complete %[1]s
+`,
+	"zsh": `#compdef %[1]s
+
+_%[1]s_complete() {
+    local -a completions
+    completions=("${(@f)$("${words[1]}" "${(@)words[2,CURRENT-1]}" "${words[CURRENT]}" --generate-bash-completion 2>/dev/null)}")
+    completions=(${completions:#})
+
+    if (( ${#completions} == 0 )); then
+        _files
+        return
+    fi
+
+    compadd -S '' -- ${(M)completions:#*/}
+    compadd -- ${completions:#*/}
+}
+
+compdef _%[1]s_complete %[1]s
+`,
+	"fish": `function __%[1]s_complete
+    set -l words (commandline -opc)
+    command $words (commandline -ct) --generate-bash-completion 2>/dev/null
+end
+
+complete -c %[1]s -a '(__%[1]s_complete)'
+`,
+}
+
+func completion(c *ucli.Context) error {
+	if c.NArg() != 1 {
+		return errors.Errorf("usage: %s %s", c.Command.Name, c.Command.ArgsUsage)
+	}
+
+	script, ok := completionScripts[c.Args().First()]
+	if !ok {
+		return errors.Errorf("%s: unsupported shell, use 'bash', 'zsh' or 'fish'", c.Args().First())
+	}
+
+	_, err := fmt.Fprintf(c.App.Writer, script, c.App.Name)
+
+	return errors.WithStack(err)
+}
+
+// completePaths returns the completion of the arguments of the commands that take pCloud paths:
+// the last argument is completed with the flags of the command when it starts with a dash, or
+// with the entries of the pCloud folder it names. When remoteOnly is false, only the arguments
+// prefixed with 'r:' are pCloud paths: the shell completes the others as local paths.
+func completePaths(remoteOnly bool) ucli.BashCompleteFunc {
+	return func(c *ucli.Context) {
+		cur := ""
+		if c.NArg() > 0 {
+			cur = c.Args().Get(c.NArg() - 1)
+		}
+
+		switch {
+		case strings.HasPrefix(cur, "-"):
+			ucli.DefaultCompleteWithFlags(c.Command)(c)
+			return
+
+		case !remoteOnly && !strings.HasPrefix(cur, pcli.PCloudPrefix):
+			return
+		}
+
+		// completions must be quick: they are given up on rather than waited for.
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		pCli, err := newCLI(ctx, c)
+		if err != nil {
+			return
+		}
+
+		completions, err := pCli.CompletePath(ctx, cur)
+		if err != nil {
+			return
+		}
+
+		for _, p := range completions {
+			_, _ = fmt.Fprintln(c.App.Writer, p)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	ucli "github.com/urfave/cli/v2"
+	"go.uber.org/zap"
+
+	pcli "github.com/seborama/pcloud-sdk/cli"
+	"github.com/seborama/pcloud-sdk/sdk"
+	"github.com/seborama/pcloud-sdk/tracker"
+	"github.com/seborama/pcloud-sdk/tracker/db"
+)
+
+// daemon syncs the pairs of the daemon configuration file continuously, until it is interrupted.
+// Each pair is watched on its own, with its own settings. SIGHUP reloads the configuration file:
+// the syncs restart with it, once the changes in progress completed. The limits of the transfers,
+// which all the pairs share, can also be changed at /limits of the health address, which serves
+// the metrics of the syncs at /metrics and their progress at /status too.
+func daemon(c *ucli.Context) error {
+	cfgPath := c.String("config")
+
+	cfg, err := tracker.LoadDaemonConfig(cfgPath)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	pCloudClient, err := newPCloudClient(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	store, err := db.NewSQLite3(ctx, c.String("db-path"))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = store.Close() }()
+
+	logger, _ := zap.NewProduction()
+	defer func() { _ = logger.Sync() }()
+
+	monitor := tracker.NewMonitor()
+	limiter := tracker.NewLimiter(cfg.Limits)
+
+	if addr := c.String("health-addr"); addr != "" {
+		stop, err := serveHealth(logger, addr, monitor, limiter)
+		if err != nil {
+			return err
+		}
+		defer stop()
+	}
+
+	httpClient := sdk.NewHTTPClient(sdk.DefaultTransportConfig())
+
+	for {
+		runCtx, stopRun := context.WithCancel(ctx)
+		done := make(chan error, len(cfg.PairConfigs()))
+
+		names, err := startPairs(runCtx, logger, store, pCloudClient, httpClient, cfg, monitor, limiter, done)
+		if err != nil {
+			stopRun()
+			waitPairs(done, len(names))
+			return err
+		}
+		monitor.Retain(names...)
+
+		var newCfg *tracker.DaemonConfig
+		for newCfg == nil {
+			select {
+			case err = <-done:
+				stopRun()
+				errWait := waitPairs(done, len(names)-1)
+				if err == nil {
+					err = errWait
+				}
+				return err
+
+			case <-hup:
+				newCfg, err = tracker.LoadDaemonConfig(cfgPath)
+				if err != nil {
+					logger.Error("reloading the configuration failed, the current one is kept", zap.Error(err))
+				}
+			}
+		}
+
+		logger.Info("configuration reloaded, restarting the syncs")
+		stopRun()
+
+		err = waitPairs(done, len(names))
+		if err != nil {
+			return err
+		}
+		cfg = newCfg
+
+		// the limits changed at /limits are replaced by those of the configuration.
+		err = limiter.SetLimits(cfg.Limits)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// startPairs starts watching each pair of the configuration cfg until ctx is cancelled. The result
+// of each Watch is sent to done. It returns the names of the pairs that were started.
+func startPairs(
+	ctx context.Context,
+	logger *zap.Logger,
+	store *db.SQLite3,
+	pCloudClient *sdk.Client,
+	httpClient *http.Client,
+	cfg *tracker.DaemonConfig,
+	monitor *tracker.Monitor,
+	limiter *tracker.Limiter,
+	done chan<- error,
+) ([]string, error) {
+	var names []string
+
+	for _, pc := range cfg.PairConfigs() {
+		pairName := pc.Pair
+		if pairName == "" {
+			var err error
+			pairName, err = defaultPairName(pc.Local, pcli.PCloudPrefix+pc.Remote)
+			if err != nil {
+				return names, err
+			}
+		}
+		for _, name := range names {
+			if name == pairName {
+				return names, errors.Errorf("duplicate pair '%s'", pairName)
+			}
+		}
+
+		err := store.ReplaceSyncSelection(ctx, db.PairName(pairName), pc.Selection)
+		if err != nil {
+			return names, err
+		}
+
+		health, progress := monitor.Pair(pairName)
+		notifications := cfg.Notifications(logger, db.PairName(pairName))
+		pairLogger := logger.With(zap.String("pair", pairName))
+		report := func(stats *tracker.SyncStats, err error) {
+			health.Report(stats, err)
+			notifications.Report(stats, err)
+			if err != nil {
+				pairLogger.Error("sync failed", zap.Any("stats", stats), zap.Error(err))
+				return
+			}
+			pairLogger.Info("sync completed", zap.Any("stats", stats))
+		}
+
+		s := tracker.NewTwoWay(
+			pairLogger,
+			store,
+			pCloudClient,
+			db.PairName(pairName),
+			pc.Local,
+			pc.Remote,
+			append(pc.TwoWayOptions(), tracker.WithHTTPClient(httpClient), tracker.WithLimiter(limiter), tracker.WithProgress(progress))...,
+		)
+
+		go func(opts []tracker.WatchOption) {
+			done <- s.Watch(ctx, append(opts, tracker.WithSyncReport(report))...)
+		}(pc.WatchOptions())
+
+		names = append(names, pairName)
+
+		pairLogger.Info("daemon started", zap.String("local", pc.Local), zap.String("remote", pc.Remote), zap.String("direction", string(pc.Direction)))
+	}
+
+	return names, nil
+}
+
+// waitPairs waits for n pairs to stop, and returns the first of their errors.
+func waitPairs(done <-chan error, n int) error {
+	var err error
+
+	for i := 0; i < n; i++ {
+		errPair := <-done
+		if err == nil {
+			err = errPair
+		}
+	}
+
+	return err
+}
+
+// serveHealth serves the health of the syncs of the monitor at /health on addr, their metrics for
+// Prometheus at /metrics, their progress at /status and the limits of their transfers at /limits.
+// It returns the function that stops the server.
+func serveHealth(logger *zap.Logger, addr string, monitor *tracker.Monitor, limiter *tracker.Limiter) (func(), error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", monitor.ServeHealth)
+	mux.HandleFunc("/metrics", monitor.ServeMetrics)
+	mux.HandleFunc("/status", monitor.ServeStatus)
+	mux.Handle("/limits", limiter)
+
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		err := srv.Serve(ln)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("serving the health endpoint failed", zap.Error(err))
+		}
+	}()
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(ctx)
+	}, nil
+}
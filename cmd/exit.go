@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	ucli "github.com/urfave/cli/v2"
+
+	pcli "github.com/seborama/pcloud-sdk/cli"
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+// The exit codes of the command line, which let scripts and CI pipelines tell the failures
+// apart.
+const (
+	// exitFailure is the exit code of the failures that have no exit code of their own.
+	exitFailure = 1
+	// exitAuth is the exit code of the commands that have no valid credentials or session.
+	exitAuth = 2
+	// exitNotFound is the exit code of the commands whose file or folder does not exist.
+	exitNotFound = 3
+	// exitTransfer is the exit code of the transfers that failed to transfer a file.
+	exitTransfer = 4
+)
+
+// exitCode returns the exit code of the command that failed with err.
+func exitCode(err error) int {
+	var profileErr *pcli.ProfileNotFoundError
+	var transferErr *pcli.TransferError
+
+	switch {
+	case sdk.IsAuthError(err) || errors.As(err, &profileErr):
+		return exitAuth
+	case errors.As(err, &transferErr):
+		return exitTransfer
+	case sdk.IsNotFound(err) || errors.Is(err, fs.ErrNotExist):
+		return exitNotFound
+	}
+
+	return exitFailure
+}
+
+// prompt writes message to the standard error and returns the line read from the standard
+// input. With --non-interactive, it fails with the hint of the option that gives the value.
+func prompt(c *ucli.Context, message, hint string) (string, error) {
+	if c.Bool("non-interactive") {
+		return "", errors.Errorf("%s: it is not asked for with --non-interactive", hint)
+	}
+
+	fmt.Fprint(os.Stderr, message)
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return "", errors.Wrap(err, "reading the standard input")
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	ucli "github.com/urfave/cli/v2"
+
+	pcli "github.com/seborama/pcloud-sdk/cli"
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+func find(c *ucli.Context) error {
+	return withCLI(c, 0, 1, func(ctx context.Context, pCli *pcli.CLI) error {
+		opts, err := findOptions(c)
+		if err != nil {
+			return err
+		}
+
+		p := c.Args().First()
+		if p == "" {
+			p = "/"
+		}
+
+		var (
+			matches []string
+			entries []pcli.Entry
+		)
+
+		err = pCli.Find(ctx, p, func(p string, m *sdk.Metadata) error {
+			switch {
+			case c.String("exec") != "":
+				matches = append(matches, p)
+			case output(c) != pcli.OutputPlain:
+				entries = append(entries, pcli.NewEntry(p, m))
+			default:
+				// the matches are printed as they are found.
+				_, err := fmt.Println(p)
+				return errors.WithStack(err)
+			}
+			return nil
+		}, opts...)
+		if err != nil {
+			return err
+		}
+
+		if c.String("exec") == "" && output(c) != pcli.OutputPlain {
+			return pcli.WriteEntries(os.Stdout, entries, output(c))
+		}
+
+		// the matches are all found before any command runs: the commands may change the tree.
+		for _, p := range matches {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			err = c.App.RunContext(ctx, execArgs(c, pcli.PCloudPrefix+p))
+			if err != nil {
+				return errors.WithMessage(err, p)
+			}
+		}
+
+		return nil
+	})
+}
+
+func findOptions(c *ucli.Context) ([]pcli.FindOption, error) {
+	opts := []pcli.FindOption{
+		pcli.WithName(c.StringSlice("name")...),
+		pcli.WithType(c.String("type")),
+	}
+
+	for _, f := range []struct {
+		name string
+		opt  func(int64) pcli.FindOption
+	}{
+		{"min-size", pcli.WithMinSize},
+		{"max-size", pcli.WithMaxFindSize},
+	} {
+		if !c.IsSet(f.name) {
+			continue
+		}
+		size, err := pcli.ParseSize(c.String(f.name))
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, f.opt(size))
+	}
+
+	now := time.Now()
+
+	for _, f := range []struct {
+		name string
+		opt  func(time.Time) pcli.FindOption
+	}{
+		{"newer-than", pcli.WithNewerThan},
+		{"older-than", pcli.WithOlderThan},
+	} {
+		if !c.IsSet(f.name) {
+			continue
+		}
+		t, err := pcli.ParseSince(c.String(f.name), now)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, f.opt(t))
+	}
+
+	return opts, nil
+}
+
+// execArgs returns the command line that runs the pcloud command of the exec flag on the match
+// p: p replaces the "{}" arguments, or is appended when there are none. The global flags set on
+// the command line, such as the profile, are passed on.
+func execArgs(c *ucli.Context, p string) []string {
+	args := []string{c.App.Name}
+
+	for _, f := range c.App.Flags {
+		name := f.Names()[0]
+		if c.IsSet(name) {
+			args = append(args, "--"+name+"="+c.String(name))
+		}
+	}
+
+	replaced := false
+	for _, arg := range strings.Fields(c.String("exec")) {
+		if arg == "{}" {
+			arg, replaced = p, true
+		}
+		args = append(args, arg)
+	}
+	if !replaced {
+		args = append(args, p)
+	}
+
+	return args
+}
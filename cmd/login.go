@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+
+	pcli "github.com/seborama/pcloud-sdk/cli"
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+// login logs in to pCloud and saves the session under the selected profile, for the other
+// commands to use.
+func login(c *cli.Context) error {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	region, err := parseRegion(c.String("region"))
+	if err != nil {
+		return err
+	}
+
+	pCloudClient := sdk.NewClient(sdk.NewHTTPClient(sdk.DefaultTransportConfig()), sdk.WithRegion(region))
+
+	profile := &pcli.Profile{Region: region}
+
+	if clientID := c.String("oauth2-client-id"); clientID != "" {
+		code := c.String("oauth2-code")
+		if code == "" {
+			message := fmt.Sprintf("Grant access to your account on:\n%s\nthen enter the code displayed by pCloud: ", sdk.OAuth2AuthorizeURL(clientID, "", ""))
+
+			code, err = prompt(c, message, "the code displayed by pCloud is required (see --oauth2-code)")
+			if err != nil {
+				return err
+			}
+		}
+
+		t, err := pCloudClient.OAuth2Token(ctx, clientID, c.String("oauth2-client-secret"), strings.TrimSpace(code))
+		if err != nil {
+			return err
+		}
+
+		profile.Region = t.Region()
+		profile.OAuth2AccessToken = t.AccessToken
+	} else {
+		username, password := c.String("pcloud-username"), c.String("pcloud-password")
+		if username == "" || password == "" {
+			return errors.New("the username and password of the account are required (see --pcloud-username and --pcloud-password), or the OAuth2 client ID of an application (see --oauth2-client-id)")
+		}
+
+		if c.Bool("digest") {
+			err = pCloudClient.LoginDigest(ctx, c.String("pcloud-otp-code"), username, password)
+		} else {
+			err = pCloudClient.Login(
+				ctx,
+				c.String("pcloud-otp-code"),
+				sdk.WithGlobalOptionUsername(username),
+				sdk.WithGlobalOptionPassword(password),
+			)
+		}
+		if err != nil {
+			return err
+		}
+
+		profile.Username = username
+		profile.AuthToken = pCloudClient.AuthToken()
+	}
+
+	cfg.Profiles[c.String("profile")] = profile
+
+	err = cfg.Save()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Logged in: profile '%s' saved to %s\n", c.String("profile"), cfg.Path())
+
+	return nil
+}
+
+// logout invalidates the session of the selected profile and removes the profile.
+func logout(c *cli.Context) error {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	profile, err := cfg.Profile(c.String("profile"))
+	if err != nil {
+		return err
+	}
+
+	// OAuth2 access tokens are revoked from the settings of the account, not with logout.
+	if profile.AuthToken != "" {
+		pCloudClient := sdk.NewClient(sdk.NewHTTPClient(sdk.DefaultTransportConfig()), profile.ClientOptions()...)
+
+		_, err = pCloudClient.Logout(ctx)
+		if err != nil && sdk.ErrorCode(err) != sdk.ErrLoginRequired {
+			return err
+		}
+	}
+
+	delete(cfg.Profiles, c.String("profile"))
+
+	return cfg.Save()
+}
+
+// profiles lists the profiles of the configuration file.
+func profiles(c *cli.Context) error {
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	return cfg.WriteProfiles(os.Stdout, output(c))
+}
+
+// newPCloudClient returns a Client authenticated by the auth token or logged in with the
+// credentials given on the command line (or by environment variables) when there are any, or
+// else authenticated by the session saved in the selected profile.
+func newPCloudClient(ctx context.Context, c *cli.Context) (*sdk.Client, error) {
+	httpClient := sdk.NewHTTPClient(sdk.DefaultTransportConfig())
+
+	if !hasCredentials(c) {
+		cfg, err := loadConfig(c)
+		if err != nil {
+			return nil, err
+		}
+
+		profile, err := cfg.Profile(c.String("profile"))
+		if err != nil {
+			return nil, err
+		}
+
+		return sdk.NewClient(httpClient, profile.ClientOptions()...), nil
+	}
+
+	region, err := parseRegion(c.String("pcloud-region"))
+	if err != nil {
+		return nil, err
+	}
+
+	if token := c.String("pcloud-token"); token != "" {
+		return sdk.NewClient(httpClient, sdk.WithRegion(region), sdk.WithAuthToken(token)), nil
+	}
+
+	pCloudClient := sdk.NewClient(httpClient, sdk.WithRegion(region))
+
+	err = pCloudClient.Login(
+		ctx,
+		c.String("pcloud-otp-code"),
+		sdk.WithGlobalOptionUsername(c.String("pcloud-username")),
+		sdk.WithGlobalOptionPassword(c.String("pcloud-password")),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return pCloudClient, nil
+}
+
+// hasCredentials returns whether the command authenticates with the auth token or the
+// username given on the command line, rather than with the session of a profile.
+func hasCredentials(c *cli.Context) bool {
+	return c.String("pcloud-token") != "" || c.String("pcloud-username") != ""
+}
+
+// loadConfig loads the configuration file selected on the command line, or the default one.
+func loadConfig(c *cli.Context) (*pcli.Config, error) {
+	p := c.String("config")
+	if p == "" {
+		var err error
+
+		p, err = pcli.DefaultConfigPath()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return pcli.LoadConfig(p)
+}
+
+// parseRegion returns the data region called name: "eu" or "us".
+func parseRegion(name string) (sdk.Region, error) {
+	switch strings.ToLower(name) {
+	case "eu":
+		return sdk.RegionEU, nil
+	case "us":
+		return sdk.RegionUS, nil
+	}
+
+	return "", errors.Errorf("%s: unknown region, use 'eu' or 'us'", name)
+}
@@ -5,31 +5,171 @@ import (
 	"os"
 
 	"github.com/urfave/cli/v2"
+
+	pcli "github.com/seborama/pcloud-sdk/cli"
+	"github.com/seborama/pcloud-sdk/fuse"
+	"github.com/seborama/pcloud-sdk/tracker"
+	"github.com/seborama/pcloud-sdk/tracker/filesystem"
 )
 
+// pcloudfsFlags are the flags of the commands that serve a folder through pcloudfs: serve webdav,
+// serve sftp and sftp-server.
+var pcloudfsFlags = []cli.Flag{
+	&cli.BoolFlag{
+		Name:  "read-only",
+		Usage: "Refuse the requests that change files or folders",
+	},
+	&cli.IntFlag{
+		Name:  "read-ahead",
+		Usage: "Number of 1MiB chunks prefetched when files are read, 0 to disable read-ahead",
+		Value: 4,
+	},
+	&cli.IntFlag{
+		Name:  "write-back",
+		Usage: "Size in bytes of the buffer that coalesces the writes to files, 0 to disable write-back",
+		Value: 4 << 20,
+	},
+}
+
+// transferFlags are the flags of the upload, download and sync commands.
+var transferFlags = []cli.Flag{
+	&cli.StringSliceFlag{
+		Name:  "include",
+		Usage: "Only transfer the files whose relative path or name matches the glob `PATTERN` (repeatable)",
+	},
+	&cli.StringSliceFlag{
+		Name:    "exclude",
+		EnvVars: []string{"PCLOUD_EXCLUDE"},
+		Usage:   "Do not transfer the files and folders whose relative path or name matches the glob `PATTERN` (repeatable)",
+	},
+	&cli.Int64Flag{
+		Name:  "max-size",
+		Usage: "Do not transfer the files larger than this size in bytes, 0 for no limit",
+	},
+	&cli.IntFlag{
+		Name:    "parallel",
+		EnvVars: []string{"PCLOUD_PARALLEL"},
+		Usage:   "Number of files transferred at the same time",
+		Value:   4,
+	},
+	&cli.StringFlag{
+		Name:    "bandwidth-limit",
+		EnvVars: []string{"PCLOUD_BANDWIDTH_LIMIT"},
+		Usage:   "Limit the transfer to `RATE` bytes per second in total, such as '512K' or '2M'",
+	},
+	&cli.BoolFlag{
+		Name:    "quiet",
+		Aliases: []string{"q"},
+		Usage:   "Do not display the progress of the transfer",
+	},
+}
+
 func main() {
 	app := &cli.App{
+		Name:  "pcloud",
+		Usage: "pCloud command line",
+		// the completion of the commands, their flags and the pCloud paths (see completion).
+		EnableBashCompletion: true,
 		Flags: []cli.Flag{
 			&cli.StringFlag{
-				Name:     "pcloud-username",
-				EnvVars:  []string{"PCLOUD_USERNAME"},
-				Usage:    "pCloud account username",
-				Required: true,
+				Name:    "pcloud-username",
+				EnvVars: []string{"PCLOUD_USERNAME"},
+				Usage:   "pCloud account username (the session of the profile is used when it is not set)",
+			},
+			&cli.StringFlag{
+				Name:    "pcloud-password",
+				EnvVars: []string{"PCLOUD_PASSWORD"},
+				Usage:   "pCloud account password",
+			},
+			&cli.StringFlag{
+				Name:    "pcloud-token",
+				EnvVars: []string{"PCLOUD_TOKEN"},
+				Usage:   "Auth token of a pCloud session, used rather than the username and password or the session of the profile",
 			},
 			&cli.StringFlag{
-				Name:     "pcloud-password",
-				EnvVars:  []string{"PCLOUD_PASSWORD"},
-				Usage:    "pCloud account password",
-				Required: true,
+				Name:    "pcloud-region",
+				EnvVars: []string{"PCLOUD_REGION"},
+				Usage:   "Data region of the account of --pcloud-username or --pcloud-token: 'eu' or 'us'",
+				Value:   "eu",
 			},
 			&cli.StringFlag{
 				Name:    "pcloud-otp-code",
 				EnvVars: []string{"PCLOUD_OTP_CODE"},
 				Usage:   "pCloud account login One-Time-Password (for two-factor authentication)",
 			},
+			&cli.StringFlag{
+				Name:    "profile",
+				EnvVars: []string{"PCLOUD_PROFILE"},
+				Usage:   "Name of the profile whose saved session is used (see login)",
+				Value:   pcli.DefaultProfile,
+			},
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				EnvVars: []string{"PCLOUD_OUTPUT"},
+				Usage:   "Format of the results of the commands: 'plain' (to be read), 'table' (aligned columns) or 'json' (for scripts)",
+				Value:   string(pcli.OutputPlain),
+			},
+			&cli.BoolFlag{
+				Name:    "non-interactive",
+				EnvVars: []string{"PCLOUD_NON_INTERACTIVE"},
+				Usage:   "Fail rather than ask for the values that are not given, such as the OAuth2 code of login",
+			},
+			&cli.StringFlag{
+				Name:        "config",
+				EnvVars:     []string{"PCLOUD_CONFIG"},
+				Usage:       "Location of the configuration file that holds the profiles and their defaults",
+				DefaultText: "pcloud/config.json in the user configuration folder",
+			},
+		},
+
+		Before: func(c *cli.Context) error {
+			_, err := pcli.ParseOutput(c.String("output"))
+			return err
 		},
 
 		Commands: []*cli.Command{
+			{
+				Name:   "login",
+				Usage:  "log in to pCloud and save the session under the profile",
+				Action: login,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "region",
+						EnvVars: []string{"PCLOUD_REGION"},
+						Usage:   "Data region of the account: 'eu' or 'us'",
+						Value:   "eu",
+					},
+					&cli.BoolFlag{
+						Name:  "digest",
+						Usage: "Use digest authentication, which does not send the password to pCloud",
+					},
+					&cli.StringFlag{
+						Name:    "oauth2-client-id",
+						EnvVars: []string{"PCLOUD_OAUTH2_CLIENT_ID"},
+						Usage:   "Client ID of the application to log in with OAuth2, rather than with the username and password",
+					},
+					&cli.StringFlag{
+						Name:    "oauth2-client-secret",
+						EnvVars: []string{"PCLOUD_OAUTH2_CLIENT_SECRET"},
+						Usage:   "Client secret of the application",
+					},
+					&cli.StringFlag{
+						Name:  "oauth2-code",
+						Usage: "Code displayed by pCloud once access is granted (it is asked for when not set)",
+					},
+				},
+			},
+			{
+				Name:   "logout",
+				Usage:  "invalidate the session of the profile and remove the profile",
+				Action: logout,
+			},
+			{
+				Name:   "profiles",
+				Usage:  "list the profiles",
+				Action: profiles,
+			},
 			{
 				Name:    "analyse",
 				Aliases: []string{"a"},
@@ -44,6 +184,239 @@ func main() {
 					},
 				},
 			},
+			{
+				Name:      "bisync",
+				Usage:     "synchronise a local folder and a pCloud folder in both directions (use prefix 'r:' for pCloud)",
+				ArgsUsage: "LOCAL r:/REMOTE",
+				Action:    bisync,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "db-path",
+						EnvVars:  []string{"DB_PATH"},
+						Usage:    "Location of the database that holds the state of the sync (it will be created if inexistent)",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:  "pair",
+						Usage: "Name under which the state of the sync of the two folders is saved in the database (default: the two folders)",
+					},
+					&cli.StringFlag{
+						Name:    "conflict",
+						EnvVars: []string{"PCLOUD_CONFLICT"},
+						Usage:   "Resolution of the files changed on both sides: 'skip', 'keep-newest', 'keep-both', 'prefer-local', 'prefer-remote' or 'ask'",
+						Value:   string(tracker.ConflictSkip),
+					},
+					&cli.StringFlag{
+						Name:  "direction",
+						Usage: "Direction of the sync: 'both', 'upload' (the local changes only, and the local files win the conflicts) or 'download' (the pCloud changes only, and the pCloud files win the conflicts)",
+						Value: string(tracker.DirectionBoth),
+					},
+					&cli.StringFlag{
+						Name:    "ignore-file",
+						EnvVars: []string{"PCLOUD_IGNORE_FILE"},
+						Usage:   "Ignore file whose patterns apply to the whole sync, before those of the " + tracker.IgnoreFileName + " files of the local folder",
+					},
+					&cli.StringFlag{
+						Name:  "symlinks",
+						Usage: "Handling of the symbolic links of the local folder: 'skip', 'follow' their targets, or sync them as 'placeholder' files holding their targets",
+						Value: string(filesystem.SymlinkSkip),
+					},
+					&cli.StringFlag{
+						Name:  "special-files",
+						Usage: "Handling of the named pipes, sockets and devices of the local folder, whose contents cannot be synced: 'skip' or 'fail' the sync",
+						Value: string(filesystem.SpecialFileSkip),
+					},
+					&cli.IntFlag{
+						Name:  "delete-threshold",
+						Usage: "Percentage of the files of the pair that a sync may delete on either side: a sync that would delete more of them, such as from an empty mount point, fails without applying any change (100 to disable)",
+						Value: tracker.DefaultDeleteThreshold,
+					},
+					&cli.BoolFlag{
+						Name:  "permanent-deletes",
+						Usage: "Clear the files deleted from the pCloud folder from the trash of pCloud, rather than keeping them there",
+					},
+					&cli.BoolFlag{
+						Name:  "file-modes",
+						Usage: "Record the modes of the uploaded files, such as their executable bit, in the " + tracker.MetadataFileName + " file of the pCloud folder, and restore them on download",
+					},
+					&cli.IntFlag{
+						Name:  "hash-workers",
+						Usage: "Number of local files hashed concurrently (default: the number of CPUs)",
+					},
+					&cli.StringSliceFlag{
+						Name:  "select",
+						Usage: "Sync only this folder of the pair, relative to its roots, such as 'Photos' or 'Work/Projects' (repeatable). The selection is saved for the next syncs of the pair",
+					},
+					&cli.BoolFlag{
+						Name:  "select-all",
+						Usage: "Sync the whole pair again, clearing its saved selection of folders",
+					},
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "Print the changes that the sync would apply, without applying them",
+					},
+					&cli.BoolFlag{
+						Name:  "watch",
+						Usage: "Keep running after the first sync, and sync the changes of the local folder as they happen, until interrupted",
+					},
+					&cli.DurationFlag{
+						Name:  "full-scan-interval",
+						Usage: "With --watch, interval of the syncs that scan the local folder in full, which catch the changes of pCloud and any missed local change (0 to disable)",
+						Value: tracker.DefaultFullScanInterval,
+					},
+				},
+			},
+			{
+				Name:      "history",
+				Usage:     "display the most recent syncs of a pair of bisync or daemon, with their statistics and errors (use prefix 'r:' for pCloud)",
+				ArgsUsage: "[LOCAL r:/REMOTE]",
+				Action:    syncHistory,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "db-path",
+						EnvVars:  []string{"DB_PATH"},
+						Usage:    "Location of the database that holds the state of the sync",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:  "pair",
+						Usage: "Name of the pair, rather than its two folders",
+					},
+					&cli.IntFlag{
+						Name:  "limit",
+						Usage: "Number of syncs displayed",
+						Value: 20,
+					},
+				},
+			},
+			{
+				Name:      "check",
+				Usage:     "check the state of a pair of bisync or daemon in the database against the local folder and the pCloud folder, and rebuild it with --repair without transferring the files again (use prefix 'r:' for pCloud)",
+				ArgsUsage: "LOCAL r:/REMOTE",
+				Action:    check,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "db-path",
+						EnvVars:  []string{"DB_PATH"},
+						Usage:    "Location of the database that holds the state of the sync",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:  "pair",
+						Usage: "Name of the pair in the database (default: the two folders)",
+					},
+					&cli.StringFlag{
+						Name:    "ignore-file",
+						EnvVars: []string{"PCLOUD_IGNORE_FILE"},
+						Usage:   "Ignore file of the syncs of the pair, whose patterns apply before those of the " + tracker.IgnoreFileName + " files of the local folder",
+					},
+					&cli.StringFlag{
+						Name:  "symlinks",
+						Usage: "Handling of the symbolic links of the local folder by the syncs of the pair: 'skip', 'follow' or 'placeholder'",
+						Value: string(filesystem.SymlinkSkip),
+					},
+					&cli.StringFlag{
+						Name:  "special-files",
+						Usage: "Handling of the named pipes, sockets and devices of the local folder by the syncs of the pair: 'skip' or 'fail'",
+						Value: string(filesystem.SpecialFileSkip),
+					},
+					&cli.IntFlag{
+						Name:  "hash-workers",
+						Usage: "Number of local files hashed concurrently (default: the number of CPUs)",
+					},
+					&cli.BoolFlag{
+						Name:  "repair",
+						Usage: "Rebuild the state of the pair where it diverges from the folders: the files that are the same on both sides are recorded as synced, and the state of the files that are on neither side is dropped",
+					},
+				},
+			},
+			{
+				Name:      "snapshot",
+				Usage:     "back up a local folder to a new dated snapshot folder of a pCloud folder, where the files that did not change since the last snapshot are copied by pCloud rather than uploaded (use prefix 'r:' for pCloud)",
+				ArgsUsage: "LOCAL r:/REMOTE",
+				Action:    snapshot,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "db-path",
+						EnvVars:  []string{"DB_PATH"},
+						Usage:    "Location of the database that holds the snapshots (it will be created if inexistent)",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:  "pair",
+						Usage: "Name under which the snapshots of the two folders are saved in the database (default: the two folders)",
+					},
+					&cli.StringFlag{
+						Name:    "ignore-file",
+						EnvVars: []string{"PCLOUD_IGNORE_FILE"},
+						Usage:   "Ignore file whose patterns apply to the whole snapshot, before those of the " + tracker.IgnoreFileName + " files of the local folder",
+					},
+					&cli.IntFlag{
+						Name:  "keep",
+						Usage: "Number of snapshots kept: the oldest ones are deleted to the trash once the new one is complete (0 keeps all of them)",
+					},
+					&cli.BoolFlag{
+						Name:  "list",
+						Usage: "List the snapshots rather than creating one",
+					},
+				},
+			},
+			{
+				Name:      "restore",
+				Usage:     "rebuild a local folder from a pCloud folder, as it is, as of a recent sync of its pair or from one of its snapshots, checking the downloaded files against their pCloud checksums (use prefix 'r:' for pCloud)",
+				ArgsUsage: "r:/REMOTE LOCAL",
+				Action:    restore,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "db-path",
+						EnvVars: []string{"DB_PATH"},
+						Usage:   "Location of the database that holds the state of the sync or the snapshots, required by --run and --snapshot",
+					},
+					&cli.StringFlag{
+						Name:  "pair",
+						Usage: "Name of the pair of bisync, daemon or snapshot in the database (default: the two folders)",
+					},
+					&cli.IntFlag{
+						Name:  "run",
+						Usage: "Restore the files as of the end of this sync of the pair, 1 being the most recent one as listed by history (the files of the 10 most recent syncs are kept)",
+					},
+					&cli.StringFlag{
+						Name:  "snapshot",
+						Usage: "Restore this snapshot of the pair, by name, or 'latest'",
+					},
+					&cli.StringSliceFlag{
+						Name:  "include",
+						Usage: "Restore only the files that match this pattern of the syntax of the " + tracker.IgnoreFileName + " files, or whose folders do (repeatable)",
+					},
+					&cli.StringSliceFlag{
+						Name:  "exclude",
+						Usage: "Do not restore the files that match this pattern of the syntax of the " + tracker.IgnoreFileName + " files, or whose folders do (repeatable)",
+					},
+				},
+			},
+			{
+				Name:   "daemon",
+				Usage:  "synchronise pairs of a local folder and a pCloud folder continuously, as configured by a configuration file (SIGHUP reloads it)",
+				Action: daemon,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "config",
+						EnvVars:  []string{"PCLOUD_DAEMON_CONFIG"},
+						Usage:    "Location of the JSON configuration file of the daemon",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:     "db-path",
+						EnvVars:  []string{"DB_PATH"},
+						Usage:    "Location of the database that holds the state of the sync (it will be created if inexistent)",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:  "health-addr",
+						Usage: "Address on which to serve the health of the syncs at /health, their metrics for Prometheus at /metrics, their progress at /status and the limits of their transfers at /limits, such as 'localhost:8080' (default: not served)",
+					},
+				},
+			},
 			{
 				Name:    "cli",
 				Aliases: []string{"c"},
@@ -62,11 +435,500 @@ func main() {
 					},
 				},
 			},
+			{
+				Name:   "about",
+				Usage:  "display the plan, the quota, the crypto status and the active sessions of the account",
+				Action: about,
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "Display the account information as JSON",
+					},
+				},
+			},
+			{
+				Name:         "ls",
+				Usage:        "list a pCloud folder",
+				ArgsUsage:    "[r:/folder]",
+				Action:       ls,
+				BashComplete: completePaths(true),
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:    "long",
+						Aliases: []string{"l"},
+						Usage:   "Show the type, size and modification time of the entries",
+					},
+				},
+			},
+			{
+				Name:         "cp",
+				Usage:        "copy a file between the local file system and pCloud, or within pCloud (use prefix 'r:' for pCloud)",
+				ArgsUsage:    "SOURCE DESTINATION",
+				Action:       cp,
+				BashComplete: completePaths(false),
+			},
+			{
+				Name:         "mv",
+				Usage:        "move a file between the local file system and pCloud, or within pCloud (use prefix 'r:' for pCloud)",
+				ArgsUsage:    "SOURCE DESTINATION",
+				Action:       mv,
+				BashComplete: completePaths(false),
+			},
+			{
+				Name:         "rm",
+				Usage:        "remove pCloud files or folders",
+				ArgsUsage:    "r:/path...",
+				Action:       rm,
+				BashComplete: completePaths(true),
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:    "recursive",
+						Aliases: []string{"r"},
+						Usage:   "Remove folders and their contents",
+					},
+				},
+			},
+			{
+				Name:         "mkdir",
+				Usage:        "create pCloud folders",
+				ArgsUsage:    "r:/folder...",
+				Action:       mkdir,
+				BashComplete: completePaths(true),
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:    "parents",
+						Aliases: []string{"p"},
+						Usage:   "Create the missing parent folders, and do not fail if the folder exists",
+					},
+				},
+			},
+			{
+				Name:         "cat",
+				Usage:        "write the contents of pCloud files to the standard output",
+				ArgsUsage:    "r:/file...",
+				Action:       cat,
+				BashComplete: completePaths(true),
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "range",
+						Usage: "Only write the bytes of `RANGE`: 'START-END' (END included), 'START-' or '-COUNT' (the last COUNT bytes)",
+					},
+				},
+			},
+			{
+				Name:         "put",
+				Usage:        "write the standard input ('-') or a local file to a pCloud file, which is replaced if it exists",
+				ArgsUsage:    "-|FILE r:/file",
+				Action:       put,
+				BashComplete: completePaths(false),
+			},
+			{
+				Name:         "du",
+				Usage:        "display the space used by a pCloud folder and its subfolders (the root folder by default)",
+				ArgsUsage:    "[r:/folder]",
+				Action:       du,
+				BashComplete: completePaths(true),
+				Flags: []cli.Flag{
+					&cli.IntFlag{
+						Name:    "depth",
+						Aliases: []string{"d"},
+						Usage:   "Number of levels of subfolders listed, -1 for all",
+						Value:   1,
+					},
+					&cli.StringFlag{
+						Name:  "sort",
+						Usage: "Order of the folders: 'size' (the largest first), 'files' (the most files first) or 'name'",
+						Value: pcli.SortBySize,
+					},
+				},
+			},
+			{
+				Name:         "find",
+				Usage:        "find the files and folders below a pCloud folder (the root folder by default) that match all the filters",
+				ArgsUsage:    "[r:/folder]",
+				Action:       find,
+				BashComplete: completePaths(true),
+				Flags: []cli.Flag{
+					&cli.StringSliceFlag{
+						Name:  "name",
+						Usage: "Only find the entries whose name matches the glob `PATTERN` (repeatable)",
+					},
+					&cli.StringFlag{
+						Name:  "type",
+						Usage: "Only find the files ('f') or the folders ('d')",
+					},
+					&cli.StringFlag{
+						Name:  "min-size",
+						Usage: "Only find the files of at least `SIZE`, such as '2048', '100K' or '1.5G'",
+					},
+					&cli.StringFlag{
+						Name:  "max-size",
+						Usage: "Only find the files of at most `SIZE`",
+					},
+					&cli.StringFlag{
+						Name:  "newer-than",
+						Usage: "Only find the entries modified after `TIME`: a duration such as '36h' or '7d', or a date such as '2024-12-31'",
+					},
+					&cli.StringFlag{
+						Name:  "older-than",
+						Usage: "Only find the entries modified before `TIME`",
+					},
+					&cli.StringFlag{
+						Name:  "exec",
+						Usage: "Run the pcloud `COMMAND` (such as 'rm' or 'link create --expire 7d') on each match, which replaces '{}' or is appended to it, rather than printing the matches",
+					},
+				},
+			},
+			{
+				Name:   "watch",
+				Usage:  "display the changes of the account as they happen: files and folders created, modified and deleted, shares and account changes",
+				Action: watch,
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "Display the changes as JSON, one per line",
+					},
+				},
+			},
+			{
+				Name:         "upload",
+				Usage:        "upload a local folder and its contents into a pCloud folder",
+				ArgsUsage:    "DIR r:/folder",
+				Action:       upload,
+				BashComplete: completePaths(false),
+				Flags:        transferFlags,
+			},
+			{
+				Name:         "download",
+				Usage:        "download a pCloud folder and its contents into a local folder",
+				ArgsUsage:    "r:/folder DIR",
+				Action:       download,
+				BashComplete: completePaths(false),
+				Flags:        transferFlags,
+			},
+			{
+				Name:         "sync",
+				Usage:        "mirror a local folder to a pCloud folder, or a pCloud folder to a local folder (use prefix 'r:' for pCloud)",
+				ArgsUsage:    "SOURCE DESTINATION",
+				Action:       syncCmd,
+				BashComplete: completePaths(false),
+				Flags: append([]cli.Flag{
+					&cli.BoolFlag{
+						Name:  "delete-extraneous",
+						Usage: "Delete the files and folders of the destination that do not exist in the source",
+					},
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "Display the actions of the sync without applying them",
+					},
+					&cli.BoolFlag{
+						Name:  "checksum",
+						Usage: "Compare the SHA-1 hashes of the files of the same size, rather than their modification times",
+					},
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "Display the actions of the sync as JSON",
+					},
+				}, transferFlags...),
+			},
+			{
+				Name:         "verify",
+				Usage:        "check that the files of a local folder have an identical copy in a pCloud folder, by their checksums, without transferring them",
+				ArgsUsage:    "DIR r:/folder",
+				Action:       verify,
+				BashComplete: completePaths(false),
+				Flags: append([]cli.Flag{
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "Display the outcome of the verification as JSON",
+					},
+				}, transferFlags...),
+			},
+			{
+				Name:  "link",
+				Usage: "manage the public links to pCloud files and folders",
+				Subcommands: []*cli.Command{
+					{
+						Name:         "create",
+						Usage:        "create a public link to a pCloud file or folder, and display its URL",
+						ArgsUsage:    "r:/path",
+						Action:       linkCreate,
+						BashComplete: completePaths(true),
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "expire",
+								Usage: "Expiry of the link: a duration such as '36h' or '7d', or a date such as '2024-12-31'",
+							},
+							&cli.Uint64Flag{
+								Name:  "max-downloads",
+								Usage: "Number of downloads allowed by the link (0 for no limit)",
+							},
+							&cli.StringFlag{
+								Name:    "password",
+								Usage:   "Password that protects the link (premium accounts)",
+								EnvVars: []string{"PCLOUD_LINK_PASSWORD"},
+							},
+							&cli.BoolFlag{
+								Name:  "short",
+								Usage: "Display the short form of the link",
+							},
+						},
+					},
+					{
+						Name:   "list",
+						Usage:  "list the public links, with their ID",
+						Action: linkList,
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:  "json",
+								Usage: "Display the links as JSON",
+							},
+						},
+					},
+					{
+						Name:      "revoke",
+						Usage:     "delete public links",
+						ArgsUsage: "ID...",
+						Action:    linkRevoke,
+					},
+				},
+			},
+			{
+				Name:  "share",
+				Usage: "manage the pCloud folders shared with other users",
+				Subcommands: []*cli.Command{
+					{
+						Name:         "invite",
+						Usage:        "invite a pCloud user to a folder",
+						ArgsUsage:    "r:/folder MAIL",
+						Action:       shareInvite,
+						BashComplete: completePaths(true),
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "permissions",
+								Usage: "Comma-separated permissions granted, besides reading: 'create', 'modify' and 'delete'",
+							},
+							&cli.StringFlag{
+								Name:  "name",
+								Usage: "Name under which the folder is shared",
+							},
+							&cli.StringFlag{
+								Name:  "message",
+								Usage: "Message sent with the invitation",
+							},
+						},
+					},
+					{
+						Name:   "list",
+						Usage:  "list the shares and the pending share requests, with their ID",
+						Action: shareList,
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:  "json",
+								Usage: "Display the shares as JSON",
+							},
+						},
+					},
+					{
+						Name:      "accept",
+						Usage:     "accept a share request",
+						ArgsUsage: "ID",
+						Action:    shareAccept,
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "name",
+								Usage: "Name of the shared folder",
+							},
+							&cli.StringFlag{
+								Name:  "into",
+								Usage: "pCloud folder in which the shared folder appears (the root folder by default)",
+							},
+						},
+					},
+					{
+						Name:      "decline",
+						Usage:     "decline a share request",
+						ArgsUsage: "ID",
+						Action:    shareDecline,
+					},
+				},
+			},
+			{
+				Name:  "trash",
+				Usage: "list, restore and delete for good the files and folders deleted from pCloud",
+				Subcommands: []*cli.Command{
+					{
+						Name:   "list",
+						Usage:  "list the contents of the trash, with their ID",
+						Action: trashList,
+					},
+					{
+						Name:         "restore",
+						Usage:        "restore files and folders from the trash, into the folder they were deleted from",
+						ArgsUsage:    "ID...",
+						Action:       trashRestore,
+						BashComplete: completePaths(true),
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "to",
+								Usage: "pCloud folder to restore into, rather than the folder they were deleted from",
+							},
+						},
+					},
+					{
+						Name:      "empty",
+						Usage:     "delete files and folders of the trash for good, or all of its contents",
+						ArgsUsage: "[ID...]",
+						Action:    trashEmpty,
+					},
+				},
+			},
+			{
+				Name:  "revisions",
+				Usage: "list and restore the previous versions of pCloud files",
+				Subcommands: []*cli.Command{
+					{
+						Name:         "list",
+						Usage:        "list the revisions of a file, with their ID, the most recent first",
+						ArgsUsage:    "r:/file",
+						Action:       revisionsList,
+						BashComplete: completePaths(true),
+					},
+					{
+						Name:         "restore",
+						Usage:        "replace the contents of a file with one of its revisions",
+						ArgsUsage:    "r:/file ID",
+						Action:       revisionsRestore,
+						BashComplete: completePaths(true),
+					},
+				},
+			},
+			{
+				Name:      "mount",
+				Aliases:   []string{"m"},
+				Usage:     "mount the pCloud account as a local file system (Linux), until interrupted",
+				ArgsUsage: "MOUNTPOINT",
+				Action:    mount,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "mountpoint",
+						Usage: "Location of the (existing) folder to mount the account on, rather than the MOUNTPOINT argument",
+					},
+					&cli.BoolFlag{
+						Name:  "read-only",
+						Usage: "Mount the account read-only",
+					},
+					&cli.BoolFlag{
+						Name:  "allow-other",
+						Usage: "Let the other users of the system access the mount (requires user_allow_other in /etc/fuse.conf)",
+					},
+					&cli.DurationFlag{
+						Name:  "attr-timeout",
+						Usage: "How long the attributes of files and the contents of folders are cached",
+						Value: fuse.DefaultAttrTimeout,
+					},
+					&cli.IntFlag{
+						Name:  "read-ahead",
+						Usage: "Size in bytes of the chunks in which files are read ahead",
+						Value: fuse.DefaultReadAhead,
+					},
+					&cli.Int64Flag{
+						Name:  "cache-size",
+						Usage: "Maximum size in bytes of the cache of the contents of files read, 0 to disable it",
+					},
+					&cli.StringFlag{
+						Name:  "cache-dir",
+						Usage: "Location of the folder that persists the cache of the contents of files, rather than memory",
+					},
+				},
+			},
+			{
+				Name:      "completion",
+				Usage:     "write the completion script of a shell: 'source <(pcloud completion bash)'",
+				ArgsUsage: "bash|zsh|fish",
+				Action:    completion,
+			},
+			{
+				Name:  "serve",
+				Usage: "serve a pCloud folder over WebDAV or HTTP, or over SFTP as the sftp subsystem of an SSH server",
+				Subcommands: []*cli.Command{
+					{
+						Name:      "webdav",
+						Usage:     "serve a pCloud folder over WebDAV, until interrupted",
+						ArgsUsage: "[r:/folder]",
+						Action:    serveWebDAV,
+						Flags: append([]cli.Flag{
+							&cli.StringFlag{
+								Name:  "addr",
+								Usage: "`ADDRESS` (host:port) to listen on",
+								Value: "localhost:8080",
+							},
+							&cli.StringFlag{
+								Name:    "user",
+								EnvVars: []string{"PCLOUD_SERVE_USER"},
+								Usage:   "User name of the HTTP basic authentication required from the clients",
+							},
+							&cli.StringFlag{
+								Name:    "password",
+								EnvVars: []string{"PCLOUD_SERVE_PASSWORD"},
+								Usage:   "Password of the HTTP basic authentication required from the clients",
+							},
+						}, pcloudfsFlags...),
+					},
+					{
+						Name:      "http",
+						Usage:     "serve the files of a pCloud folder over HTTP (read-only), until interrupted",
+						ArgsUsage: "[r:/folder]",
+						Action:    serveHTTP,
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "addr",
+								Usage: "`ADDRESS` (host:port) to listen on",
+								Value: "localhost:8080",
+							},
+							&cli.StringFlag{
+								Name:    "user",
+								EnvVars: []string{"PCLOUD_SERVE_USER"},
+								Usage:   "User name of the HTTP basic authentication required from the clients",
+							},
+							&cli.StringFlag{
+								Name:    "password",
+								EnvVars: []string{"PCLOUD_SERVE_PASSWORD"},
+								Usage:   "Password of the HTTP basic authentication required from the clients",
+							},
+							&cli.BoolFlag{
+								Name:  "no-dir-listing",
+								Usage: "Answer 403 Forbidden for the folders that have no index.html, rather than listing them",
+							},
+						},
+					},
+					{
+						Name:      "sftp",
+						Usage:     "serve an SFTP session of a pCloud folder over stdin/stdout, as the sftp subsystem of an SSH server (experimental)",
+						ArgsUsage: "[r:/folder]",
+						Action:    serveSFTP,
+						Flags:     pcloudfsFlags,
+					},
+				},
+			},
+			{
+				Name:   "sftp-server",
+				Usage:  "serve an SFTP session over stdin/stdout, as the sftp subsystem of an SSH server (experimental)",
+				Action: sftpServer,
+				Flags: append([]cli.Flag{
+					&cli.StringFlag{
+						Name:  "root",
+						Usage: "Location of the pCloud folder to serve",
+						Value: "/",
+					},
+				}, pcloudfsFlags...),
+			},
 		},
 	}
 
 	err := app.Run(os.Args)
 	if err != nil {
-		log.Fatalf("%+v", err)
+		log.Printf("%+v", err)
+		os.Exit(exitCode(err))
 	}
 }
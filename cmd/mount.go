@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+
+	"github.com/seborama/pcloud-sdk/blockcache"
+	"github.com/seborama/pcloud-sdk/fuse"
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+func mount(c *cli.Context) error {
+	mountpoint := c.Args().First()
+	if mountpoint == "" {
+		mountpoint = c.String("mountpoint")
+	}
+	if mountpoint == "" || c.NArg() > 1 {
+		return errors.Errorf("usage: %s %s", c.Command.Name, c.Command.ArgsUsage)
+	}
+
+	// the file system keeps serving the requests of the kernel until it is unmounted: an
+	// interrupt unmounts it rather than cancelling the calls in progress.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pCloudClient, err := newPCloudClient(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	// the subscription long-polls pCloud: it gets a client of its own.
+	diffClient, err := newPCloudClient(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	// only the changes made from now on invalidate the caches.
+	dr, err := diffClient.Changes(ctx, 0, sdk.ChangesOptions{After: time.Now()})
+	if err != nil {
+		return err
+	}
+
+	entries, err := diffClient.Subscribe(ctx, dr.DiffID)
+	if err != nil {
+		return err
+	}
+
+	opts := []fuse.Option{
+		fuse.WithAttrTimeout(c.Duration("attr-timeout")),
+		fuse.WithReadAhead(c.Int("read-ahead")),
+	}
+
+	if c.Bool("read-only") {
+		opts = append(opts, fuse.WithReadOnly())
+	}
+
+	if c.Int64("cache-size") > 0 {
+		var cacheOpts []blockcache.Option
+		if c.String("cache-dir") != "" {
+			cacheOpts = append(cacheOpts, blockcache.WithDir(c.String("cache-dir")))
+		}
+
+		cache, err := blockcache.New(c.Int64("cache-size"), cacheOpts...)
+		if err != nil {
+			return err
+		}
+
+		opts = append(opts, fuse.WithBlockCache(cache))
+	}
+
+	var mountOpts []fuse.MountOption
+	if c.Bool("allow-other") {
+		mountOpts = append(mountOpts, fuse.WithAllowOther())
+	}
+
+	fsys := fuse.New(pCloudClient, opts...)
+
+	go fsys.Watch(ctx, entries)
+
+	conn, err := fuse.Mount(ctx, mountpoint, fsys, mountOpts...)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("pCloud mounted on %s (interrupt to unmount)\n", mountpoint)
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		<-sig
+		// a second interrupt terminates the process.
+		signal.Stop(sig)
+
+		fmt.Fprintf(os.Stderr, "unmounting %s\n", mountpoint)
+
+		if err := conn.Unmount(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}()
+
+	err = conn.Wait()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("pCloud unmounted from %s\n", mountpoint)
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/pkg/errors"
+	ucli "github.com/urfave/cli/v2"
+	"go.uber.org/zap"
+
+	pcli "github.com/seborama/pcloud-sdk/cli"
+	"github.com/seborama/pcloud-sdk/sdk"
+	"github.com/seborama/pcloud-sdk/tracker"
+	"github.com/seborama/pcloud-sdk/tracker/db"
+)
+
+// restore rebuilds a local folder from a pCloud folder: as it is, as of one of the recent syncs
+// of its pair with --run, or from one of its snapshots with --snapshot.
+func restore(c *ucli.Context) error {
+	if c.NArg() != 2 {
+		return errors.Errorf("usage: %s %s", c.Command.Name, c.Command.ArgsUsage)
+	}
+
+	remoteRoot := c.Args().Get(0)
+	localRoot := c.Args().Get(1)
+	if !strings.HasPrefix(remoteRoot, pcli.PCloudPrefix) {
+		return errors.Errorf("the pCloud folder must be prefixed with '%s': %s", pcli.PCloudPrefix, remoteRoot)
+	}
+
+	pairName := c.String("pair")
+	if pairName == "" {
+		var err error
+		pairName, err = defaultPairName(localRoot, remoteRoot)
+		if err != nil {
+			return err
+		}
+	}
+
+	if c.Int("run") < 0 {
+		return errors.Errorf("invalid --run: %d", c.Int("run"))
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	opts := []tracker.RestoreOption{
+		tracker.WithRestoreHTTPClient(sdk.NewHTTPClient(sdk.DefaultTransportConfig())),
+		tracker.WithRestoreFilter(c.StringSlice("include"), c.StringSlice("exclude")),
+	}
+
+	// the database is only needed by the restores of a sync or of a snapshot.
+	var store *db.SQLite3
+	if c.Int("run") > 0 || c.String("snapshot") != "" {
+		if c.String("db-path") == "" {
+			return errors.New("--db-path is required by --run and --snapshot")
+		}
+
+		var err error
+		store, err = db.NewSQLite3(ctx, c.String("db-path"))
+		if err != nil {
+			return err
+		}
+		defer func() { _ = store.Close() }()
+	}
+
+	if n := c.Int("run"); n > 0 {
+		runs, err := store.GetSyncRuns(ctx, db.PairName(pairName), n)
+		if err != nil {
+			return err
+		}
+		if len(runs) < n {
+			return errors.Errorf("the pair has %d syncs in its history: --run %d", len(runs), n)
+		}
+		opts = append(opts, tracker.WithRestoreSyncRun(runs[n-1].Started))
+	}
+
+	if c.String("snapshot") != "" {
+		opts = append(opts, tracker.WithRestoreSnapshot(c.String("snapshot")))
+	}
+
+	pCloudClient, err := newPCloudClient(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	logger, _ := zap.NewProduction()
+	defer func() { _ = logger.Sync() }()
+
+	r := tracker.NewRestorer(
+		logger,
+		store,
+		pCloudClient,
+		db.PairName(pairName),
+		strings.TrimPrefix(remoteRoot, pcli.PCloudPrefix),
+		opts...,
+	)
+
+	stats, err := r.Restore(ctx, localRoot)
+	if stats != nil {
+		if output(c) == pcli.OutputJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			_ = enc.Encode(stats)
+		} else {
+			fmt.Fprintf(os.Stderr, "%d files restored (%s), %d unchanged, %d errors\n",
+				stats.Restored, pcli.FormatSize(stats.RestoredBytes), stats.Unchanged, stats.Errors)
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	ucli "github.com/urfave/cli/v2"
+
+	pcli "github.com/seborama/pcloud-sdk/cli"
+	"github.com/seborama/pcloud-sdk/fileserver"
+	"github.com/seborama/pcloud-sdk/pcloudfs"
+	"github.com/seborama/pcloud-sdk/sdk"
+	"github.com/seborama/pcloud-sdk/webdavserver"
+)
+
+// serveHTTP serves the files of a pCloud folder over HTTP, until interrupted. Logs go to the
+// standard error.
+func serveHTTP(c *ucli.Context) error {
+	root, err := serveRoot(c)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	pCloudClient, err := newPCloudClient(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+	opts := []fileserver.Option{
+		fileserver.WithHTTPClient(sdk.NewHTTPClient(sdk.DefaultTransportConfig())),
+		fileserver.WithDirListing(!c.Bool("no-dir-listing")),
+		fileserver.WithLogger(logger),
+	}
+	if c.String("user") != "" || c.String("password") != "" {
+		opts = append(opts, fileserver.WithBasicAuth(c.String("user"), c.String("password")))
+	}
+
+	return listenAndServe(ctx, logger, "HTTP", root, &http.Server{
+		Addr:              c.String("addr"),
+		Handler:           fileserver.New(pCloudClient, root, opts...),
+		ReadHeaderTimeout: 10 * time.Second,
+	})
+}
+
+// serveWebDAV serves a pCloud folder over WebDAV, until interrupted. Logs go to the standard
+// error.
+func serveWebDAV(c *ucli.Context) error {
+	root, err := serveRoot(c)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	pCloudClient, err := newPCloudClient(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+	opts := []webdavserver.Option{
+		webdavserver.WithLogger(logger),
+	}
+	if c.String("user") != "" || c.String("password") != "" {
+		opts = append(opts, webdavserver.WithBasicAuth(c.String("user"), c.String("password")))
+	}
+	if c.Bool("read-only") {
+		opts = append(opts, webdavserver.WithReadOnly())
+	}
+
+	fsys := pcloudfs.New(
+		pCloudClient,
+		root,
+		pcloudfs.WithReadAhead(c.Int("read-ahead")),
+		pcloudfs.WithWriteBack(c.Int("write-back")),
+	)
+
+	return listenAndServe(ctx, logger, "WebDAV", root, &http.Server{
+		Addr:              c.String("addr"),
+		Handler:           webdavserver.New(fsys, opts...),
+		ReadHeaderTimeout: 10 * time.Second,
+	})
+}
+
+// listenAndServe serves the pCloud folder root with hs over protocol, until ctx is done.
+func listenAndServe(ctx context.Context, logger *slog.Logger, protocol, root string, hs *http.Server) error {
+	errCh := make(chan error, 1)
+	go func() { errCh <- hs.ListenAndServe() }()
+
+	logger.Info("serving over "+protocol, "folder", root, "addr", hs.Addr)
+
+	select {
+	case err := <-errCh:
+		return errors.Wrapf(err, "serving over %s", protocol)
+	case <-ctx.Done():
+	}
+
+	// the requests in progress are given some time to complete.
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelShutdown()
+
+	return errors.Wrapf(hs.Shutdown(shutdownCtx), "shutting down the %s server", protocol)
+}
+
+// serveSFTP serves an SFTP session of a pCloud folder over the standard input and output, as
+// the sftp subsystem of an SSH server, which listens and authenticates the users.
+func serveSFTP(c *ucli.Context) error {
+	root, err := serveRoot(c)
+	if err != nil {
+		return err
+	}
+
+	return serveSFTPSession(c, root)
+}
+
+// serveRoot returns the pCloud folder of the PATH argument of the serve commands, which
+// defaults to the root folder.
+func serveRoot(c *ucli.Context) (string, error) {
+	if c.NArg() > 1 {
+		return "", errors.Errorf("usage: %s %s", c.Command.Name, c.Command.ArgsUsage)
+	}
+
+	root := strings.TrimPrefix(c.Args().First(), pcli.PCloudPrefix)
+	if root == "" {
+		root = "/"
+	}
+
+	return root, nil
+}
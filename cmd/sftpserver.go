@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/seborama/pcloud-sdk/pcloudfs"
+	"github.com/seborama/pcloud-sdk/sftpserver"
+)
+
+// sftpServer serves an SFTP session over the standard input and output, as the sftp subsystem
+// of an SSH server. Logs go to the standard error.
+func sftpServer(c *cli.Context) error {
+	return serveSFTPSession(c, c.String("root"))
+}
+
+// serveSFTPSession serves an SFTP session of the pCloud folder root over the standard input and
+// output.
+func serveSFTPSession(c *cli.Context, root string) error {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	pCloudClient, err := newPCloudClient(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	opts := []sftpserver.Option{
+		sftpserver.WithLogger(slog.New(slog.NewTextHandler(os.Stderr, nil))),
+	}
+	if c.Bool("read-only") {
+		opts = append(opts, sftpserver.WithReadOnly())
+	}
+
+	s := sftpserver.New(
+		pcloudfs.New(
+			pCloudClient,
+			root,
+			pcloudfs.WithReadAhead(c.Int("read-ahead")),
+			pcloudfs.WithWriteBack(c.Int("write-back")),
+		),
+		opts...,
+	)
+
+	stdio := struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}
+
+	return s.Serve(ctx, stdio)
+}
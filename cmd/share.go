@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	ucli "github.com/urfave/cli/v2"
+
+	pcli "github.com/seborama/pcloud-sdk/cli"
+)
+
+func linkCreate(c *ucli.Context) error {
+	return withCLI(c, 1, 1, func(ctx context.Context, pCli *pcli.CLI) error {
+		var expire time.Time
+		if s := c.String("expire"); s != "" {
+			var err error
+			expire, err = pcli.ParseExpiry(s, time.Now())
+			if err != nil {
+				return err
+			}
+		}
+
+		link, err := pCli.CreateLink(ctx, c.Args().First(), expire, c.Uint64("max-downloads"), c.String("password"), c.Bool("short"))
+		if err != nil {
+			return err
+		}
+
+		if output(c) == pcli.OutputJSON {
+			return errors.WithStack(json.NewEncoder(os.Stdout).Encode(map[string]string{"link": link}))
+		}
+
+		fmt.Println(link)
+
+		return nil
+	})
+}
+
+func linkList(c *ucli.Context) error {
+	return withCLI(c, 0, 0, func(ctx context.Context, pCli *pcli.CLI) error {
+		return pCli.ListLinks(ctx, os.Stdout, output(c))
+	})
+}
+
+func linkRevoke(c *ucli.Context) error {
+	return withCLI(c, 1, -1, func(ctx context.Context, pCli *pcli.CLI) error {
+		for _, arg := range c.Args().Slice() {
+			linkID, err := parseID(arg)
+			if err != nil {
+				return err
+			}
+
+			err = pCli.RevokeLink(ctx, linkID)
+			if err != nil {
+				return errors.WithMessage(err, arg)
+			}
+		}
+		return nil
+	})
+}
+
+func shareInvite(c *ucli.Context) error {
+	return withCLI(c, 2, 2, func(ctx context.Context, pCli *pcli.CLI) error {
+		permissions, err := pcli.ParseSharePermissions(c.String("permissions"))
+		if err != nil {
+			return err
+		}
+
+		return pCli.InviteShare(ctx, c.Args().Get(0), c.Args().Get(1), permissions, c.String("name"), c.String("message"))
+	})
+}
+
+func shareList(c *ucli.Context) error {
+	return withCLI(c, 0, 0, func(ctx context.Context, pCli *pcli.CLI) error {
+		return pCli.ListShares(ctx, os.Stdout, output(c))
+	})
+}
+
+func shareAccept(c *ucli.Context) error {
+	return withCLI(c, 1, 1, func(ctx context.Context, pCli *pcli.CLI) error {
+		shareRequestID, err := parseID(c.Args().First())
+		if err != nil {
+			return err
+		}
+
+		return pCli.AcceptShare(ctx, shareRequestID, c.String("name"), c.String("into"))
+	})
+}
+
+func shareDecline(c *ucli.Context) error {
+	return withCLI(c, 1, 1, func(ctx context.Context, pCli *pcli.CLI) error {
+		shareRequestID, err := parseID(c.Args().First())
+		if err != nil {
+			return err
+		}
+
+		return pCli.DeclineShare(ctx, shareRequestID)
+	})
+}
+
+// parseID parses the ID of a link or of a share request, as listed by 'link list' and
+// 'share list'.
+func parseID(s string) (uint64, error) {
+	id, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, errors.Errorf("%s: invalid ID", s)
+	}
+
+	return id, nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/pkg/errors"
+	ucli "github.com/urfave/cli/v2"
+	"go.uber.org/zap"
+
+	pcli "github.com/seborama/pcloud-sdk/cli"
+	"github.com/seborama/pcloud-sdk/tracker"
+	"github.com/seborama/pcloud-sdk/tracker/db"
+)
+
+// snapshot backs up a local folder to a new dated snapshot of a pCloud folder, or lists the
+// snapshots with --list.
+func snapshot(c *ucli.Context) error {
+	if c.NArg() != 2 {
+		return errors.Errorf("usage: %s %s", c.Command.Name, c.Command.ArgsUsage)
+	}
+
+	localRoot := c.Args().Get(0)
+	remoteRoot := c.Args().Get(1)
+	if !strings.HasPrefix(remoteRoot, pcli.PCloudPrefix) {
+		return errors.Errorf("the pCloud folder must be prefixed with '%s': %s", pcli.PCloudPrefix, remoteRoot)
+	}
+
+	pairName := c.String("pair")
+	if pairName == "" {
+		var err error
+		pairName, err = defaultPairName(localRoot, remoteRoot)
+		if err != nil {
+			return err
+		}
+	}
+
+	if c.Int("keep") < 0 {
+		return errors.Errorf("invalid --keep: %d", c.Int("keep"))
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	store, err := db.NewSQLite3(ctx, c.String("db-path"))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = store.Close() }()
+
+	if c.Bool("list") {
+		snaps, err := store.GetSnapshots(ctx, db.PairName(pairName))
+		if err != nil {
+			return err
+		}
+		printSnapshots(snaps, output(c))
+		return nil
+	}
+
+	pCloudClient, err := newPCloudClient(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	logger, _ := zap.NewProduction()
+	defer func() { _ = logger.Sync() }()
+
+	s := tracker.NewSnapshotter(
+		logger,
+		store,
+		pCloudClient,
+		db.PairName(pairName),
+		localRoot,
+		strings.TrimPrefix(remoteRoot, pcli.PCloudPrefix),
+		tracker.WithSnapshotIgnoreFile(c.String("ignore-file")),
+		tracker.WithSnapshotRetention(c.Int("keep")),
+	)
+
+	stats, err := s.Snapshot(ctx)
+	if err != nil {
+		return err
+	}
+
+	if output(c) == pcli.OutputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(stats)
+	}
+
+	fmt.Fprintf(os.Stderr, "snapshot %s: %d files, %d uploaded (%s), %d copied, %d older snapshots deleted\n",
+		stats.Name, stats.Files, stats.Uploaded, pcli.FormatSize(stats.UploadedBytes), stats.Copied, stats.Pruned)
+
+	return nil
+}
+
+// printSnapshots prints the snapshots snaps, the oldest first.
+func printSnapshots(snaps []db.Snapshot, output pcli.Output) {
+	if output == pcli.OutputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(snaps)
+		return
+	}
+
+	for _, snap := range snaps {
+		fmt.Printf("%s  %s  %d files  %s\n",
+			snap.Name,
+			snap.Started.Local().Format("2006-01-02 15:04:05"),
+			snap.Files,
+			pcli.FormatSize(int64(snap.Size)),
+		)
+	}
+}
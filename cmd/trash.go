@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	ucli "github.com/urfave/cli/v2"
+
+	pcli "github.com/seborama/pcloud-sdk/cli"
+)
+
+func trashList(c *ucli.Context) error {
+	return withCLI(c, 0, 0, func(ctx context.Context, pCli *pcli.CLI) error {
+		return pCli.ListTrash(ctx, os.Stdout, output(c))
+	})
+}
+
+func trashRestore(c *ucli.Context) error {
+	return withCLI(c, 1, -1, func(ctx context.Context, pCli *pcli.CLI) error {
+		for _, id := range c.Args().Slice() {
+			err := pCli.RestoreFromTrash(ctx, id, c.String("to"))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func trashEmpty(c *ucli.Context) error {
+	return withCLI(c, 0, -1, func(ctx context.Context, pCli *pcli.CLI) error {
+		return pCli.EmptyTrash(ctx, c.Args().Slice()...)
+	})
+}
+
+func revisionsList(c *ucli.Context) error {
+	return withCLI(c, 1, 1, func(ctx context.Context, pCli *pcli.CLI) error {
+		return pCli.ListRevisions(ctx, os.Stdout, c.Args().First(), output(c))
+	})
+}
+
+func revisionsRestore(c *ucli.Context) error {
+	return withCLI(c, 2, 2, func(ctx context.Context, pCli *pcli.CLI) error {
+		revisionID, err := parseID(c.Args().Get(1))
+		if err != nil {
+			return err
+		}
+
+		return pCli.RestoreRevision(ctx, c.Args().First(), revisionID)
+	})
+}
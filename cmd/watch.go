@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"os"
+	"time"
+
+	ucli "github.com/urfave/cli/v2"
+
+	pcli "github.com/seborama/pcloud-sdk/cli"
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+func watch(c *ucli.Context) error {
+	return withCLI(c, 0, 0, func(ctx context.Context, pCli *pcli.CLI) error {
+		// the subscription long-polls pCloud: it gets a client of its own.
+		diffClient, err := newPCloudClient(ctx, c)
+		if err != nil {
+			return err
+		}
+
+		// only the changes made from now on are reported.
+		dr, err := diffClient.Changes(ctx, 0, sdk.ChangesOptions{After: time.Now()})
+		if err != nil {
+			return err
+		}
+
+		entries, err := diffClient.Subscribe(ctx, dr.DiffID)
+		if err != nil {
+			return err
+		}
+
+		err = pCli.Watch(ctx, os.Stdout, entries, output(c))
+		if ctx.Err() != nil {
+			// interrupted.
+			return nil
+		}
+		return err
+	})
+}
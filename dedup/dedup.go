@@ -0,0 +1,303 @@
+package dedup
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+	"github.com/seborama/pcloud-sdk/tracker/db"
+)
+
+// pCloudSDK defines the SDK methods used to find and remove duplicate files.
+type pCloudSDK interface {
+	List(ctx context.Context, folder sdk.FolderRef, o sdk.ListOptions, opts ...sdk.ClientOption) (*sdk.FSList, error)
+	ChecksumFile(ctx context.Context, file sdk.FileRef, opts ...sdk.ClientOption) (*sdk.FileChecksum, error)
+	DeleteFile(ctx context.Context, file sdk.FileRef, opts ...sdk.ClientOption) (*sdk.FileResult, error)
+}
+
+// File is a file that has duplicates.
+type File struct {
+	// ID is the pCloud fileid of the file.
+	ID       uint64
+	Path     string
+	Size     uint64
+	Modified time.Time
+}
+
+// Group is a set of files with the same contents.
+type Group struct {
+	Hash  string
+	Size  uint64
+	Files []File
+}
+
+// Reclaimable returns the space that removing all the files of the group but one would free.
+func (g Group) Reclaimable() uint64 {
+	if len(g.Files) < 2 {
+		return 0
+	}
+	return g.Size * uint64(len(g.Files)-1)
+}
+
+// Report lists the duplicate files, by group of identical files.
+// The groups are sorted by decreasing reclaimable space.
+type Report struct {
+	Groups []Group
+
+	// Reclaimable is the total space that removing the duplicates would free.
+	Reclaimable uint64
+}
+
+// FromAccount walks the pCloud folder at path, recursively, and reports the duplicate files it
+// contains.
+// Files are identified by pCloud's hash and their size.
+func FromAccount(ctx context.Context, pcc pCloudSDK, path string) (*Report, error) {
+	lf, err := pcc.List(ctx, sdk.ByPath(path), sdk.ListOptions{Recursive: true})
+	if err != nil {
+		return nil, err
+	}
+
+	b := newBuilder()
+	b.addMetadata(path, lf.Metadata)
+
+	return b.report(), nil
+}
+
+// FromEntries reports the duplicate files in the file system entries of the tracker database
+// (see db.SQLite3.GetLatestFileSystemEntries).
+// Files are identified by their hash and their size.
+func FromEntries(entries []db.FSEntry) *Report {
+	b := newBuilder()
+
+	for _, e := range entries {
+		if e.IsFolder || e.Hash == "" {
+			continue
+		}
+
+		b.add(e.Hash, File{
+			ID:       e.EntryID,
+			Path:     path.Join(e.Path, e.Name),
+			Size:     e.Size,
+			Modified: e.Modified,
+		})
+	}
+
+	return b.report()
+}
+
+type groupKey struct {
+	hash string
+	size uint64
+}
+
+type builder struct {
+	groups map[groupKey][]File
+}
+
+func newBuilder() *builder {
+	return &builder{
+		groups: map[groupKey][]File{},
+	}
+}
+
+func (b *builder) add(hash string, f File) {
+	k := groupKey{hash: hash, size: f.Size}
+	b.groups[k] = append(b.groups[k], f)
+}
+
+// addMetadata adds the files of m, and of its contents, whose path is p.
+func (b *builder) addMetadata(p string, m *sdk.Metadata) {
+	if m == nil || m.IsDeleted {
+		return
+	}
+
+	if !m.IsFolder {
+		b.add(fmt.Sprintf("%d", m.Hash), File{
+			ID:       m.FileID,
+			Path:     p,
+			Size:     m.Size,
+			Modified: m.Modified,
+		})
+		return
+	}
+
+	for _, cm := range m.Contents {
+		b.addMetadata(path.Join(p, cm.Name), cm)
+	}
+}
+
+func (b *builder) report() *Report {
+	r := &Report{}
+
+	for k, files := range b.groups {
+		if len(files) < 2 {
+			continue
+		}
+
+		sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+
+		g := Group{Hash: k.hash, Size: k.size, Files: files}
+		r.Groups = append(r.Groups, g)
+		r.Reclaimable += g.Reclaimable()
+	}
+
+	sort.Slice(r.Groups, func(i, j int) bool {
+		ri, rj := r.Groups[i].Reclaimable(), r.Groups[j].Reclaimable()
+		if ri != rj {
+			return ri > rj
+		}
+		return r.Groups[i].Hash < r.Groups[j].Hash
+	})
+
+	return r
+}
+
+// WriteTo writes the report to w in CSV format, one line per file.
+// This is an implementation of Go's "io.WriterTo" interface.
+func (r *Report) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	csvw := csv.NewWriter(cw)
+
+	err := csvw.Write([]string{"group", "hash", "size", "fileid", "path"})
+	if err != nil {
+		return cw.n, errors.WithStack(err)
+	}
+
+	for i, g := range r.Groups {
+		for _, f := range g.Files {
+			err = csvw.Write([]string{
+				fmt.Sprintf("%d", i+1),
+				g.Hash,
+				fmt.Sprintf("%d", g.Size),
+				fmt.Sprintf("%d", f.ID),
+				f.Path,
+			})
+			if err != nil {
+				return cw.n, errors.WithStack(err)
+			}
+		}
+	}
+
+	csvw.Flush()
+
+	return cw.n, errors.WithStack(csvw.Error())
+}
+
+// WriteFile writes the report to the file name. See WriteTo.
+func (r *Report) WriteFile(name string) (err error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() {
+		e := f.Close()
+		if e != nil && err == nil {
+			err = errors.WithStack(e)
+		}
+	}()
+
+	_, err = r.WriteTo(f)
+
+	return err
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+// KeepFunc chooses the file of g to keep when removing duplicates. It returns its index.
+type KeepFunc func(g Group) int
+
+// KeepOldest keeps the file of the group that was modified first.
+func KeepOldest(g Group) int {
+	keep := 0
+	for i, f := range g.Files {
+		if f.Modified.Before(g.Files[keep].Modified) {
+			keep = i
+		}
+	}
+	return keep
+}
+
+// KeepShortestPath keeps the file of the group with the shortest path.
+func KeepShortestPath(g Group) int {
+	keep := 0
+	for i, f := range g.Files {
+		if len(f.Path) < len(g.Files[keep].Path) {
+			keep = i
+		}
+	}
+	return keep
+}
+
+// Delete removes the duplicate files of a report obtained from pCloud (see FromAccount), and
+// keeps one file per group, as chosen by keep. It returns the files it deleted.
+// Before a file is deleted, its checksum is compared to that of the file that is kept: files
+// with different contents are not deleted.
+// pCloud has no notion of file links: duplicates can only be deleted.
+func Delete(ctx context.Context, pcc pCloudSDK, r *Report, keep KeepFunc) ([]File, error) {
+	var deleted []File
+
+	for _, g := range r.Groups {
+		k := keep(g)
+
+		want, err := checksum(ctx, pcc, g.Files[k].ID)
+		if err != nil {
+			return deleted, err
+		}
+
+		for i, f := range g.Files {
+			if i == k {
+				continue
+			}
+
+			got, err := checksum(ctx, pcc, f.ID)
+			if err != nil {
+				return deleted, err
+			}
+
+			if got != want {
+				continue
+			}
+
+			_, err = pcc.DeleteFile(ctx, sdk.ByID(f.ID))
+			if err != nil {
+				return deleted, errors.WithMessagef(err, "delete '%s'", f.Path)
+			}
+
+			deleted = append(deleted, f)
+		}
+	}
+
+	return deleted, nil
+}
+
+// checksum returns the strongest checksum pCloud provides for the file fileID.
+// The checksums available depend on the data region of the account.
+func checksum(ctx context.Context, pcc pCloudSDK, fileID uint64) (string, error) {
+	fc, err := pcc.ChecksumFile(ctx, sdk.ByID(fileID))
+	if err != nil {
+		return "", err
+	}
+
+	if fc.SHA256 != "" {
+		return fc.SHA256, nil
+	}
+
+	return fc.SHA1, nil
+}
@@ -0,0 +1,126 @@
+package dedup_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/dedup"
+	"github.com/seborama/pcloud-sdk/sdk"
+	"github.com/seborama/pcloud-sdk/tracker/db"
+)
+
+type mockPCloudSDK struct {
+	mock.Mock
+}
+
+func (m *mockPCloudSDK) List(ctx context.Context, folder sdk.FolderRef, o sdk.ListOptions, opts ...sdk.ClientOption) (*sdk.FSList, error) {
+	args := m.Called(ctx, folder, o, opts)
+	return args.Get(0).(*sdk.FSList), args.Error(1)
+}
+
+func (m *mockPCloudSDK) ChecksumFile(ctx context.Context, file sdk.FileRef, opts ...sdk.ClientOption) (*sdk.FileChecksum, error) {
+	args := m.Called(ctx, file, opts)
+	return args.Get(0).(*sdk.FileChecksum), args.Error(1)
+}
+
+func (m *mockPCloudSDK) DeleteFile(ctx context.Context, file sdk.FileRef, opts ...sdk.ClientOption) (*sdk.FileResult, error) {
+	args := m.Called(ctx, file, opts)
+	return args.Get(0).(*sdk.FileResult), args.Error(1)
+}
+
+func TestFromAccount_Delete(t *testing.T) {
+	ctx := context.Background()
+	t0 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	root := &sdk.Metadata{
+		IsFolder: true,
+		Contents: []*sdk.Metadata{
+			{Name: "a.jpg", FileID: 1, Hash: 111, Size: 100, Modified: t0.Add(time.Hour)},
+			{Name: "b.txt", FileID: 2, Hash: 222, Size: 10, Modified: t0},
+			{Name: "gone.jpg", FileID: 9, Hash: 111, Size: 100, IsDeleted: true},
+			{
+				Name:     "photos",
+				IsFolder: true,
+				Contents: []*sdk.Metadata{
+					{Name: "a copy.jpg", FileID: 3, Hash: 111, Size: 100, Modified: t0},
+					{Name: "a again.jpg", FileID: 4, Hash: 111, Size: 100, Modified: t0.Add(2 * time.Hour)},
+					{Name: "c.txt", FileID: 5, Hash: 222, Size: 11, Modified: t0},
+				},
+			},
+		},
+	}
+
+	pcc := &mockPCloudSDK{}
+	defer func() { _ = pcc.AssertExpectations(t) }()
+	pcc.
+		On("List", ctx, mock.Anything, sdk.ListOptions{Recursive: true}, []sdk.ClientOption(nil)).
+		Return(&sdk.FSList{Metadata: root}, nil).
+		Once()
+
+	r, err := dedup.FromAccount(ctx, pcc, "/")
+	require.NoError(t, err)
+
+	require.Len(t, r.Groups, 1)
+	g := r.Groups[0]
+	assert.Equal(t, "111", g.Hash)
+	assert.EqualValues(t, 200, g.Reclaimable())
+	assert.EqualValues(t, 200, r.Reclaimable)
+	require.Len(t, g.Files, 3)
+	assert.Equal(t, "/a.jpg", g.Files[0].Path)
+	assert.Equal(t, "/photos/a again.jpg", g.Files[1].Path)
+	assert.Equal(t, "/photos/a copy.jpg", g.Files[2].Path)
+
+	buf := &bytes.Buffer{}
+	n, err := r.WriteTo(buf)
+	require.NoError(t, err)
+	assert.EqualValues(t, buf.Len(), n)
+	assert.Equal(t, "group,hash,size,fileid,path\n1,111,100,1,/a.jpg\n1,111,100,4,/photos/a again.jpg\n1,111,100,3,/photos/a copy.jpg\n", buf.String())
+
+	// file 3 is the oldest: it is kept. file 4 differs despite the identical hash.
+	pcc.
+		On("ChecksumFile", ctx, sdk.ByID(3), []sdk.ClientOption(nil)).
+		Return(&sdk.FileChecksum{SHA1: "aaa"}, nil).
+		Once().
+		On("ChecksumFile", ctx, sdk.ByID(1), []sdk.ClientOption(nil)).
+		Return(&sdk.FileChecksum{SHA1: "aaa"}, nil).
+		Once().
+		On("ChecksumFile", ctx, sdk.ByID(4), []sdk.ClientOption(nil)).
+		Return(&sdk.FileChecksum{SHA1: "bbb"}, nil).
+		Once().
+		On("DeleteFile", ctx, sdk.ByID(1), []sdk.ClientOption(nil)).
+		Return(&sdk.FileResult{}, nil).
+		Once()
+
+	deleted, err := dedup.Delete(ctx, pcc, r, dedup.KeepOldest)
+	require.NoError(t, err)
+	require.Len(t, deleted, 1)
+	assert.EqualValues(t, 1, deleted[0].ID)
+}
+
+func TestFromEntries(t *testing.T) {
+	entries := []db.FSEntry{
+		{EntryID: 1, Path: "/x", Name: "a", Size: 5, Hash: "h1"},
+		{EntryID: 2, Path: "/y", Name: "a", Size: 5, Hash: "h1"},
+		{EntryID: 3, Path: "/y", Name: "b", Size: 7, Hash: "h2"},
+		{EntryID: 4, Path: "/z", Name: "b", Size: 7, Hash: "h2"},
+		{EntryID: 5, Path: "/zz", Name: "b", Size: 7, Hash: "h2"},
+		{EntryID: 6, Path: "/", Name: "x", IsFolder: true},
+		{EntryID: 7, Path: "/", Name: "y", IsFolder: true},
+	}
+
+	r := dedup.FromEntries(entries)
+	require.Len(t, r.Groups, 2)
+	assert.Equal(t, "h2", r.Groups[0].Hash)
+	assert.EqualValues(t, 14, r.Groups[0].Reclaimable())
+	assert.Equal(t, "h1", r.Groups[1].Hash)
+	assert.EqualValues(t, 19, r.Reclaimable)
+
+	assert.Equal(t, 0, dedup.KeepShortestPath(r.Groups[0]))
+	assert.Equal(t, "/y/b", r.Groups[0].Files[0].Path)
+}
@@ -0,0 +1,286 @@
+// Package fileserver serves a pCloud folder tree over HTTP, for instance to self-host static
+// assets stored in pCloud.
+//
+// Handler sets Content-Type, ETag (from the pCloud hash of the file) and Last-Modified, and
+// answers conditional and range requests. The contents of files are proxied from the content
+// servers of pCloud, with the links of getfilelink.
+package fileserver
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"html"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+// pCloudSDK defines the SDK methods used to serve files.
+type pCloudSDK interface {
+	List(ctx context.Context, folder sdk.FolderRef, o sdk.ListOptions, opts ...sdk.ClientOption) (*sdk.FSList, error)
+	Stat(ctx context.Context, file sdk.FileRef, opts ...sdk.ClientOption) (*sdk.FileResult, error)
+	DownloadLink(ctx context.Context, file sdk.FileRef, o sdk.DownloadLinkOptions, opts ...sdk.ClientOption) (*sdk.FileLink, error)
+}
+
+// indexName is the name of the file served in place of the folder that holds it.
+const indexName = "index.html"
+
+// Option configures a Handler.
+type Option func(*Handler)
+
+// WithHTTPClient sets the HTTP client that downloads the contents of files from the content
+// servers of pCloud. It defaults to http.DefaultClient.
+func WithHTTPClient(c *http.Client) Option {
+	return func(h *Handler) {
+		h.httpClient = c
+	}
+}
+
+// WithDirListing sets whether the contents of folders that have no index.html are listed.
+// They are by default.
+func WithDirListing(enabled bool) Option {
+	return func(h *Handler) {
+		h.dirListing = enabled
+	}
+}
+
+// WithLogger sets the logger of the errors that Handler answers with 502 Bad Gateway.
+func WithLogger(l *slog.Logger) Option {
+	return func(h *Handler) {
+		h.logger = l
+	}
+}
+
+// WithBasicAuth requires the requests to authenticate with HTTP basic authentication, as user
+// with password.
+func WithBasicAuth(user, password string) Option {
+	return func(h *Handler) {
+		h.user, h.password = user, password
+	}
+}
+
+// Handler is an http.Handler that serves the files of a pCloud folder, much like
+// http.FileServer: the path of the request is relative to the folder, folders are served by
+// their index.html or are listed, and only GET and HEAD are allowed.
+type Handler struct {
+	pcc        pCloudSDK
+	root       string
+	httpClient *http.Client
+	dirListing bool
+	logger     *slog.Logger
+	user       string
+	password   string
+}
+
+var _ http.Handler = (*Handler)(nil)
+
+// New creates a Handler that serves the pCloud folder at path root.
+func New(pcc pCloudSDK, root string, opts ...Option) *Handler {
+	h := &Handler{
+		pcc:        pcc,
+		root:       path.Clean("/" + root),
+		httpClient: http.DefaultClient,
+		dirListing: true,
+	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="pCloud", charset="UTF-8"`)
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	upath := r.URL.Path
+	if !strings.HasPrefix(upath, "/") {
+		upath = "/" + upath
+	}
+	name := path.Clean(upath)
+
+	m, err := h.stat(r.Context(), name, strings.HasSuffix(upath, "/"))
+	if err != nil {
+		h.error(w, err)
+		return
+	}
+
+	if !m.IsFolder {
+		h.serveFile(w, r, m)
+		return
+	}
+
+	// as with http.FileServer, relative links in the folder need the trailing slash.
+	if !strings.HasSuffix(upath, "/") {
+		localRedirect(w, r, path.Base(upath)+"/")
+		return
+	}
+
+	for _, c := range m.Contents {
+		if c.Name == indexName && !c.IsFolder {
+			h.serveFile(w, r, c)
+			return
+		}
+	}
+
+	if !h.dirListing {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	serveDirListing(w, r, m)
+}
+
+// authorized returns whether r holds the credentials of WithBasicAuth, if any.
+func (h *Handler) authorized(r *http.Request) bool {
+	if h.user == "" && h.password == "" {
+		return true
+	}
+
+	user, password, ok := r.BasicAuth()
+
+	// both are compared in constant time, not to tell which one is wrong.
+	userOK := subtle.ConstantTimeCompare([]byte(user), []byte(h.user)) == 1
+	passwordOK := subtle.ConstantTimeCompare([]byte(password), []byte(h.password)) == 1
+
+	return ok && userOK && passwordOK
+}
+
+// stat returns the metadata of the file or folder name, with the contents of folders.
+// pCloud stats files and lists folders: the one that is more likely to succeed is tried first.
+func (h *Handler) stat(ctx context.Context, name string, isFolder bool) (*sdk.Metadata, error) {
+	p := path.Join(h.root, name)
+
+	if !isFolder && name != "/" {
+		fr, err := h.pcc.Stat(ctx, sdk.ByPath(p))
+		if err == nil {
+			return &fr.Metadata, nil
+		}
+		if sdk.ErrorCode(err) != sdk.ErrFileNotFound {
+			return nil, err
+		}
+	}
+
+	lf, err := h.pcc.List(ctx, sdk.ByPath(p), sdk.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return lf.Metadata, nil
+}
+
+// serveFile serves the file m. http.ServeContent handles the conditional and range requests,
+// and reads the contents it needs from the content servers of pCloud (see remoteFile).
+func (h *Handler) serveFile(w http.ResponseWriter, r *http.Request, m *sdk.Metadata) {
+	f := &remoteFile{ctx: r.Context(), h: h, m: m}
+	defer f.close()
+
+	// the link is obtained before any response is sent, so that failing to obtain it can be
+	// answered with an error.
+	if r.Method == http.MethodGet {
+		err := f.getLink()
+		if err != nil {
+			h.error(w, err)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", contentType(m))
+	w.Header().Set("ETag", etag(m))
+
+	http.ServeContent(w, r, m.Name, m.Modified, f)
+}
+
+// error answers the request with the status that corresponds to err.
+func (h *Handler) error(w http.ResponseWriter, err error) {
+	switch sdk.ErrorCode(err) {
+	case sdk.ErrFileNotFound, sdk.ErrDirectoryNotExists, sdk.ErrComponentOfParentDirectoryNotExists:
+		http.Error(w, "404 page not found", http.StatusNotFound)
+		return
+	case sdk.ErrAccessDenied:
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+
+	if h.logger != nil {
+		h.logger.Error("fileserver: pCloud call failed", "error", err)
+	}
+
+	http.Error(w, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+}
+
+// etag returns the entity tag of the file m, which is derived from its pCloud hash and thus
+// changes with its contents.
+func etag(m *sdk.Metadata) string {
+	return fmt.Sprintf(`"%x"`, m.Hash)
+}
+
+// contentType returns the content type of the file m: that of pCloud, or else that of its
+// extension.
+func contentType(m *sdk.Metadata) string {
+	if m.ContentType != "" {
+		return m.ContentType
+	}
+
+	if ct := mime.TypeByExtension(path.Ext(m.Name)); ct != "" {
+		return ct
+	}
+
+	return "application/octet-stream"
+}
+
+// serveDirListing lists the contents of the folder m, as http.FileServer does.
+func serveDirListing(w http.ResponseWriter, r *http.Request, m *sdk.Metadata) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	_, _ = fmt.Fprintf(w, "<!doctype html>\n<meta name=\"viewport\" content=\"width=device-width\">\n<pre>\n")
+
+	for _, c := range m.Contents {
+		name := c.Name
+		if c.IsFolder {
+			name += "/"
+		}
+
+		u := url.URL{Path: name}
+		_, _ = fmt.Fprintf(w, "<a href=\"%s\">%s</a>\n", u.String(), html.EscapeString(name))
+	}
+
+	_, _ = fmt.Fprintf(w, "</pre>\n")
+}
+
+// localRedirect redirects the request to the relative path newPath, keeping its query.
+func localRedirect(w http.ResponseWriter, r *http.Request, newPath string) {
+	if q := r.URL.RawQuery; q != "" {
+		newPath += "?" + q
+	}
+
+	w.Header().Set("Location", newPath)
+	w.WriteHeader(http.StatusMovedPermanently)
+}
@@ -0,0 +1,168 @@
+package fileserver_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/fileserver"
+	"github.com/seborama/pcloud-sdk/sdk/sdktest"
+)
+
+func newTestServer(t *testing.T, opts ...fileserver.Option) *httptest.Server {
+	t.Helper()
+
+	srv := sdktest.NewServer()
+	t.Cleanup(srv.Close)
+
+	_, err := srv.WriteFile("/Site/css/style.css", []byte("body { color: red; }"))
+	require.NoError(t, err)
+	_, err = srv.WriteFile("/Site/docs/a <b>.txt", []byte("0123456789"))
+	require.NoError(t, err)
+	_, err = srv.WriteFile("/Site/index.html", []byte("<h1>home</h1>"))
+	require.NoError(t, err)
+	_, err = srv.WriteFile("/Secret.txt", []byte("secret"))
+	require.NoError(t, err)
+
+	opts = append([]fileserver.Option{fileserver.WithHTTPClient(srv.Client())}, opts...)
+
+	hs := httptest.NewServer(fileserver.New(srv.NewClient(), "/Site", opts...))
+	t.Cleanup(hs.Close)
+
+	return hs
+}
+
+func do(t *testing.T, method, url string, header http.Header) (*http.Response, string) {
+	t.Helper()
+
+	req, err := http.NewRequest(method, url, nil)
+	require.NoError(t, err)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	// redirects are checked by the tests.
+	client := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	return resp, string(body)
+}
+
+func TestHandler_File(t *testing.T) {
+	hs := newTestServer(t)
+
+	resp, body := do(t, http.MethodGet, hs.URL+"/css/style.css", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "body { color: red; }", body)
+	assert.Equal(t, "text/css; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "bytes", resp.Header.Get("Accept-Ranges"))
+	assert.NotEmpty(t, resp.Header.Get("Last-Modified"))
+
+	etag := resp.Header.Get("ETag")
+	assert.Regexp(t, `^"[0-9a-f]+"$`, etag)
+
+	resp, body = do(t, http.MethodGet, hs.URL+"/css/style.css", http.Header{"If-None-Match": {etag}})
+	assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+	assert.Empty(t, body)
+
+	resp, body = do(t, http.MethodHead, hs.URL+"/css/style.css", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "20", resp.Header.Get("Content-Length"))
+	assert.Empty(t, body)
+
+	resp, _ = do(t, http.MethodGet, hs.URL+"/css/missing.css", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// the handler does not serve files outside of its folder.
+	resp, _ = do(t, http.MethodGet, hs.URL+"/../Secret.txt", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, _ = do(t, http.MethodPost, hs.URL+"/css/style.css", nil)
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+	assert.Equal(t, "GET, HEAD", resp.Header.Get("Allow"))
+}
+
+func TestHandler_Range(t *testing.T) {
+	hs := newTestServer(t)
+	u := hs.URL + "/docs/a%20%3Cb%3E.txt"
+
+	resp, body := do(t, http.MethodGet, u, http.Header{"Range": {"bytes=2-4"}})
+	require.Equal(t, http.StatusPartialContent, resp.StatusCode)
+	assert.Equal(t, "234", body)
+	assert.Equal(t, "bytes 2-4/10", resp.Header.Get("Content-Range"))
+
+	resp, body = do(t, http.MethodGet, u, http.Header{"Range": {"bytes=-3"}})
+	require.Equal(t, http.StatusPartialContent, resp.StatusCode)
+	assert.Equal(t, "789", body)
+
+	resp, body = do(t, http.MethodGet, u, http.Header{"Range": {"bytes=0-1,8-"}})
+	require.Equal(t, http.StatusPartialContent, resp.StatusCode)
+	assert.True(t, strings.HasPrefix(resp.Header.Get("Content-Type"), "multipart/byteranges"))
+	assert.Contains(t, body, "\r\n01\r\n")
+	assert.Contains(t, body, "\r\n89\r\n")
+
+	resp, _ = do(t, http.MethodGet, u, http.Header{"Range": {"bytes=20-"}})
+	assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, resp.StatusCode)
+
+	// the range is ignored when the file has changed.
+	resp, body = do(t, http.MethodGet, u, http.Header{"Range": {"bytes=2-4"}, "If-Range": {`"123"`}})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "0123456789", body)
+}
+
+func TestHandler_Folders(t *testing.T) {
+	hs := newTestServer(t)
+
+	resp, body := do(t, http.MethodGet, hs.URL+"/", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "<h1>home</h1>", body)
+
+	resp, _ = do(t, http.MethodGet, hs.URL+"/docs?x=1", nil)
+	assert.Equal(t, http.StatusMovedPermanently, resp.StatusCode)
+	assert.Equal(t, "docs/?x=1", resp.Header.Get("Location"))
+
+	resp, body = do(t, http.MethodGet, hs.URL+"/docs/", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/html; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.Contains(t, body, `<a href="a%20%3Cb%3E.txt">a &lt;b&gt;.txt</a>`)
+
+	resp, _ = do(t, http.MethodGet, hs.URL+"/css/style.css/", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	hs = newTestServer(t, fileserver.WithDirListing(false))
+
+	resp, _ = do(t, http.MethodGet, hs.URL+"/docs/", nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
+
+func TestHandler_BasicAuth(t *testing.T) {
+	hs := newTestServer(t, fileserver.WithBasicAuth("user", "secret"))
+
+	resp, _ := do(t, http.MethodGet, hs.URL+"/css/style.css", nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("WWW-Authenticate"), "Basic ")
+
+	for _, creds := range []string{"user:wrong", "other:secret"} {
+		u := strings.Replace(hs.URL, "://", "://"+creds+"@", 1)
+		resp, _ = do(t, http.MethodGet, u+"/css/style.css", nil)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, creds)
+	}
+
+	u := strings.Replace(hs.URL, "://", "://user:secret@", 1)
+	resp, body := do(t, http.MethodGet, u+"/css/style.css", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "body { color: red; }", body)
+}
@@ -0,0 +1,124 @@
+package fileserver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/pkg/errors"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+// remoteFile is an io.ReadSeeker over the contents of a file on the content servers of pCloud.
+// Seeking is free: the contents are requested from the offset of the first read that follows,
+// with a range request.
+type remoteFile struct {
+	ctx  context.Context
+	h    *Handler
+	m    *sdk.Metadata
+	link string
+	off  int64
+	body io.ReadCloser
+}
+
+// getLink gets the link to the contents of the file, once.
+func (f *remoteFile) getLink() error {
+	if f.link != "" {
+		return nil
+	}
+
+	fl, err := f.h.pcc.DownloadLink(f.ctx, sdk.ByID(f.m.FileID), sdk.DownloadLinkOptions{SkipFilename: true})
+	if err != nil {
+		return err
+	}
+
+	if len(fl.Hosts) == 0 {
+		return errors.New("no hosts available to download the file from pCloud")
+	}
+
+	f.link = fl.Hosts[0] + fl.Path
+
+	return nil
+}
+
+// Seek implements io.Seeker.
+func (f *remoteFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += f.off
+	case io.SeekEnd:
+		offset += int64(f.m.Size)
+	}
+
+	if offset < 0 {
+		return 0, errors.New("seeking to a negative offset")
+	}
+
+	if offset != f.off {
+		f.close()
+		f.off = offset
+	}
+
+	return offset, nil
+}
+
+// Read implements io.Reader.
+func (f *remoteFile) Read(p []byte) (int, error) {
+	if f.off >= int64(f.m.Size) {
+		return 0, io.EOF
+	}
+
+	if f.body == nil {
+		err := f.open()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.body.Read(p)
+	f.off += int64(n)
+
+	return n, err
+}
+
+// open requests the contents of the file from the current offset.
+func (f *remoteFile) open() error {
+	err := f.getLink()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(f.ctx, http.MethodGet, f.link, nil)
+	if err != nil {
+		return errors.Wrap(err, "unable to prepare request to download from pCloud")
+	}
+
+	if f.off > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", f.off))
+	}
+
+	resp, err := f.h.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "executing HTTP request to download from pCloud")
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent ||
+		f.off > 0 && resp.StatusCode != http.StatusPartialContent {
+		_ = resp.Body.Close()
+		return errors.Errorf("downloading from pCloud: unexpected status %s", resp.Status)
+	}
+
+	f.body = resp.Body
+
+	return nil
+}
+
+// close closes the response that the contents are read from, if any.
+func (f *remoteFile) close() {
+	if f.body != nil {
+		_ = f.body.Close()
+		f.body = nil
+	}
+}
@@ -0,0 +1,877 @@
+// Package fuse mounts a pCloud account as a local file system with FUSE, as an alternative to
+// pCloud Drive.
+//
+// FS implements the file system over the file operations and folder methods of the SDK. It
+// caches the attributes of files and the contents of folders, and reads files ahead in chunks.
+// Mount attaches it to a mount point and serves the requests of the kernel.
+//
+// Mounting is supported on Linux, where it talks to the kernel via /dev/fuse without the need
+// for libfuse.
+package fuse
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/seborama/pcloud-sdk/blockcache"
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+// pCloudSDK defines the SDK methods used by the file system.
+type pCloudSDK interface {
+	UserInfo(ctx context.Context, opts ...sdk.ClientOption) (*sdk.UserInfo, error)
+	List(ctx context.Context, folder sdk.FolderRef, o sdk.ListOptions, opts ...sdk.ClientOption) (*sdk.FSList, error)
+	CreateFolder(ctx context.Context, folder sdk.FolderRef, opts ...sdk.ClientOption) (*sdk.FSList, error)
+	DeleteFolder(ctx context.Context, folder sdk.FolderRef, opts ...sdk.ClientOption) (*sdk.FSList, error)
+	RenameFolder(ctx context.Context, folder sdk.FolderRef, toFolder sdk.FolderRef, opts ...sdk.ClientOption) (*sdk.FSList, error)
+	Stat(ctx context.Context, file sdk.FileRef, opts ...sdk.ClientOption) (*sdk.FileResult, error)
+	DeleteFile(ctx context.Context, file sdk.FileRef, opts ...sdk.ClientOption) (*sdk.FileResult, error)
+	RenameFile(ctx context.Context, file sdk.FileRef, destination sdk.FolderRef, opts ...sdk.ClientOption) (*sdk.FileResult, error)
+	FileOpen(ctx context.Context, flags uint64, file sdk.FileRef, opts ...sdk.ClientOption) (*sdk.File, error)
+	FilePRead(ctx context.Context, fd, count, offset uint64, opts ...sdk.ClientOption) ([]byte, error)
+	FileWrite(ctx context.Context, fd uint64, data []byte, opts ...sdk.ClientOption) (*sdk.FileDataTransfer, error)
+	FileSeek(ctx context.Context, fd, offset uint64, whenceOpt sdk.Whence, opts ...sdk.ClientOption) (*sdk.FileSeek, error)
+	FileClose(ctx context.Context, fd uint64, opts ...sdk.ClientOption) error
+}
+
+const (
+	// DefaultAttrTimeout is the default duration for which FS caches attributes and folder
+	// contents.
+	DefaultAttrTimeout = 10 * time.Second
+
+	// DefaultReadAhead is the default size of the chunks FS reads files by.
+	DefaultReadAhead = 1 << 20
+)
+
+// rootNodeID is the node ID of the root folder.
+const rootNodeID = 1
+
+var (
+	errIsDir       = errors.New("is a directory")
+	errNotDir      = errors.New("not a directory")
+	errNotEmpty    = errors.New("directory not empty")
+	errBadHandle   = errors.New("bad file handle")
+	errUnsupported = errors.New("operation not supported")
+	errReadOnly    = errors.New("read-only file system")
+)
+
+// Option configures an FS.
+type Option func(*FS)
+
+// WithAttrTimeout sets the duration for which FS caches the attributes of files and the contents
+// of folders. The changes made to the account by other clients are seen after at most d, unless
+// FS is kept up to date with Watch, in which case d may be much longer.
+func WithAttrTimeout(d time.Duration) Option {
+	return func(fsys *FS) {
+		fsys.attrTimeout = d
+	}
+}
+
+// WithReadAhead sets the size of the chunks FS reads files by. Reads that fall within the last
+// chunk read are served from memory. A size of 0 disables read-ahead.
+func WithReadAhead(size int) Option {
+	return func(fsys *FS) {
+		fsys.readAhead = size
+	}
+}
+
+// WithBlockCache makes FS read the files opened read-only through the block cache c, rather than
+// with read-ahead, so that the ranges read repeatedly, across handles or mounts when c persists
+// its blocks, are downloaded once.
+func WithBlockCache(c *blockcache.Cache) Option {
+	return func(fsys *FS) {
+		fsys.cache = c
+	}
+}
+
+// WithOwner sets the user and group that own the files. They default to those of the process.
+func WithOwner(uid, gid uint32) Option {
+	return func(fsys *FS) {
+		fsys.uid = uid
+		fsys.gid = gid
+	}
+}
+
+// MountOption configures the mount of an FS by Mount.
+type MountOption func(*mountConfig)
+
+type mountConfig struct {
+	allowOther bool
+}
+
+// WithAllowOther lets the other users of the system access the mounted file system, which is
+// otherwise only accessible to the user that mounted it. Unless mounting as root, this requires
+// user_allow_other in /etc/fuse.conf.
+func WithAllowOther() MountOption {
+	return func(mc *mountConfig) {
+		mc.allowOther = true
+	}
+}
+
+// WithReadOnly makes FS refuse the changes to the account: files can only be opened for
+// reading. Mount mounts such a file system read-only.
+func WithReadOnly() Option {
+	return func(fsys *FS) {
+		fsys.readOnly = true
+	}
+}
+
+// WithLogger sets the logger of the errors that the file system returns to the kernel as EIO.
+func WithLogger(l *slog.Logger) Option {
+	return func(fsys *FS) {
+		fsys.logger = l
+	}
+}
+
+// FS is a file system backed by a pCloud account.
+type FS struct {
+	pcc         pCloudSDK
+	attrTimeout time.Duration
+	readAhead   int
+	cache       *blockcache.Cache
+	uid, gid    uint32
+	readOnly    bool
+	logger      *slog.Logger
+
+	lock       sync.Mutex
+	attrs      map[uint64]*cachedAttr // by node ID
+	listings   map[uint64]*listing    // by folder ID
+	handles    map[uint64]*handle
+	lastHandle uint64
+}
+
+// cachedAttr is the cached metadata of a file or folder.
+type cachedAttr struct {
+	m       *sdk.Metadata
+	expires time.Time
+}
+
+// listing is the cached contents of a folder. It is replaced rather than modified.
+type listing struct {
+	children map[string]*sdk.Metadata
+	expires  time.Time
+}
+
+// handle is a file opened on pCloud.
+type handle struct {
+	node  uint64
+	fd    uint64
+	write bool
+
+	lock sync.Mutex
+	// pos is the offset of the file descriptor on pCloud.
+	pos uint64
+	// buf holds the data last read ahead, from offset bufOff.
+	buf    []byte
+	bufOff uint64
+	// key identifies the contents of the file in the block cache, when it is read through it.
+	key *blockcache.Key
+	// stale is set when the file was written to through another handle.
+	stale atomic.Bool
+}
+
+// New creates an FS for the pCloud account of pcc.
+func New(pcc pCloudSDK, opts ...Option) *FS {
+	fsys := &FS{
+		pcc:         pcc,
+		attrTimeout: DefaultAttrTimeout,
+		readAhead:   DefaultReadAhead,
+		uid:         uint32(os.Getuid()),
+		gid:         uint32(os.Getgid()),
+		attrs:       map[uint64]*cachedAttr{},
+		listings:    map[uint64]*listing{},
+		handles:     map[uint64]*handle{},
+	}
+
+	for _, opt := range opts {
+		opt(fsys)
+	}
+
+	return fsys
+}
+
+// Watch keeps the caches of fsys up to date with the changes to the account that entries
+// delivers, until ctx is cancelled or entries is closed. entries is typically obtained with
+// sdk.Client.Subscribe, using a Client dedicated to it.
+func (fsys *FS) Watch(ctx context.Context, entries <-chan sdk.Entry) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case e, ok := <-entries:
+			if !ok {
+				return
+			}
+			fsys.invalidate(&e)
+		}
+	}
+}
+
+// invalidate drops the cached data that e changes.
+func (fsys *FS) invalidate(e *sdk.Entry) {
+	fsys.lock.Lock()
+	defer fsys.lock.Unlock()
+
+	switch e.Event {
+	case sdk.Reset:
+		fsys.attrs = map[uint64]*cachedAttr{}
+		fsys.listings = map[uint64]*listing{}
+
+	case sdk.CreateFolder, sdk.DeleteFolder, sdk.ModifyFolder, sdk.CreateFile, sdk.ModifyFile, sdk.DeleteFile:
+		m := &e.Metadata
+		node := nodeOf(m)
+
+		// the file or folder may have moved from another folder.
+		if c, ok := fsys.attrs[node]; ok {
+			delete(fsys.listings, c.m.ParentFolderID)
+		}
+		delete(fsys.attrs, node)
+		delete(fsys.listings, m.ParentFolderID)
+		if m.IsFolder {
+			delete(fsys.listings, m.FolderID)
+		}
+
+		fsys.markStale(node)
+	}
+}
+
+// folderNode returns the node ID of the folder folderID.
+func folderNode(folderID uint64) uint64 {
+	return folderID*2 + rootNodeID
+}
+
+// fileNode returns the node ID of the file fileID.
+func fileNode(fileID uint64) uint64 {
+	return fileID*2 + 2
+}
+
+// nodeOf returns the node ID of the file or folder m.
+func nodeOf(m *sdk.Metadata) uint64 {
+	if m.IsFolder {
+		return folderNode(m.FolderID)
+	}
+	return fileNode(m.FileID)
+}
+
+// parseNode returns the ID of the file or folder of node.
+func parseNode(node uint64) (id uint64, isFolder bool) {
+	if node%2 == 1 {
+		return (node - rootNodeID) / 2, true
+	}
+	return (node - 2) / 2, false
+}
+
+// folderOf returns the ID of the folder of node.
+func folderOf(node uint64) (uint64, error) {
+	id, isFolder := parseNode(node)
+	if !isFolder {
+		return 0, errNotDir
+	}
+	return id, nil
+}
+
+// fsError converts err, returned by the SDK, to the errors of the io/fs package where they
+// exist.
+func fsError(err error) error {
+	switch sdk.ErrorCode(err) {
+	case sdk.ErrFileNotFound, sdk.ErrDirectoryNotExists, sdk.ErrComponentOfParentDirectoryNotExists:
+		return fs.ErrNotExist
+	case sdk.ErrFileOrFolderAlreadyExists:
+		return fs.ErrExist
+	case sdk.ErrAccessDenied:
+		return fs.ErrPermission
+	case sdk.ErrFolderNotEmpty:
+		return errNotEmpty
+	}
+	return err
+}
+
+// cacheAttr caches the metadata m. The caller must hold fsys.lock.
+func (fsys *FS) cacheAttr(m *sdk.Metadata) {
+	m2 := *m
+	m2.Contents = nil
+	fsys.attrs[nodeOf(m)] = &cachedAttr{m: &m2, expires: time.Now().Add(fsys.attrTimeout)}
+}
+
+// forget drops the cached metadata of node, along with the cached contents of its folder and of
+// the folders folderIDs.
+func (fsys *FS) forget(node uint64, folderIDs ...uint64) {
+	fsys.lock.Lock()
+	defer fsys.lock.Unlock()
+
+	if c, ok := fsys.attrs[node]; ok {
+		delete(fsys.listings, c.m.ParentFolderID)
+	}
+	delete(fsys.attrs, node)
+	for _, id := range folderIDs {
+		delete(fsys.listings, id)
+	}
+}
+
+// markStale discards the data read ahead by the handles of node. The caller must hold
+// fsys.lock.
+func (fsys *FS) markStale(node uint64) {
+	for _, h := range fsys.handles {
+		if h.node == node {
+			h.stale.Store(true)
+		}
+	}
+}
+
+// listing returns the contents of the folder folderID.
+func (fsys *FS) listing(ctx context.Context, folderID uint64) (*listing, error) {
+	fsys.lock.Lock()
+	l, ok := fsys.listings[folderID]
+	fsys.lock.Unlock()
+
+	if ok && time.Now().Before(l.expires) {
+		return l, nil
+	}
+
+	return fsys.fetchListing(ctx, folderID)
+}
+
+// fetchListing lists the folder folderID and caches its contents, its metadata and that of its
+// children.
+func (fsys *FS) fetchListing(ctx context.Context, folderID uint64) (*listing, error) {
+	lf, err := fsys.pcc.List(ctx, sdk.ByID(folderID), sdk.ListOptions{})
+	if err != nil {
+		return nil, fsError(err)
+	}
+
+	l := &listing{
+		children: make(map[string]*sdk.Metadata, len(lf.Metadata.Contents)),
+		expires:  time.Now().Add(fsys.attrTimeout),
+	}
+
+	fsys.lock.Lock()
+	defer fsys.lock.Unlock()
+
+	for _, m := range lf.Metadata.Contents {
+		l.children[m.Name] = m
+		fsys.cacheAttr(m)
+	}
+	fsys.cacheAttr(lf.Metadata)
+	fsys.listings[folderID] = l
+
+	return l, nil
+}
+
+// getattr returns the metadata of node.
+func (fsys *FS) getattr(ctx context.Context, node uint64) (*sdk.Metadata, error) {
+	fsys.lock.Lock()
+	c, ok := fsys.attrs[node]
+	fsys.lock.Unlock()
+
+	if ok && time.Now().Before(c.expires) {
+		return c.m, nil
+	}
+
+	id, isFolder := parseNode(node)
+
+	if isFolder {
+		_, err := fsys.fetchListing(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		fsys.lock.Lock()
+		defer fsys.lock.Unlock()
+
+		return fsys.attrs[node].m, nil
+	}
+
+	fr, err := fsys.pcc.Stat(ctx, sdk.ByID(id))
+	if err != nil {
+		return nil, fsError(err)
+	}
+
+	fsys.lock.Lock()
+	defer fsys.lock.Unlock()
+
+	fsys.cacheAttr(&fr.Metadata)
+
+	return fsys.attrs[node].m, nil
+}
+
+// lookup returns the metadata of the file or folder name in the folder parent.
+func (fsys *FS) lookup(ctx context.Context, parent uint64, name string) (*sdk.Metadata, error) {
+	folderID, err := folderOf(parent)
+	if err != nil {
+		return nil, err
+	}
+
+	l, err := fsys.listing(ctx, folderID)
+	if err != nil {
+		return nil, err
+	}
+
+	m, ok := l.children[name]
+	if !ok {
+		return nil, fs.ErrNotExist
+	}
+
+	// the attributes may be more recent than the listing, after a write for instance.
+	fsys.lock.Lock()
+	defer fsys.lock.Unlock()
+
+	if c, ok := fsys.attrs[nodeOf(m)]; ok {
+		return c.m, nil
+	}
+
+	return m, nil
+}
+
+// readDir returns the contents of the folder node, sorted by name.
+func (fsys *FS) readDir(ctx context.Context, node uint64) ([]*sdk.Metadata, error) {
+	folderID, err := folderOf(node)
+	if err != nil {
+		return nil, err
+	}
+
+	l, err := fsys.listing(ctx, folderID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*sdk.Metadata, 0, len(l.children))
+	for _, m := range l.children {
+		entries = append(entries, m)
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+
+	return entries, nil
+}
+
+// statfs returns the quota of the account and the space used.
+func (fsys *FS) statfs(ctx context.Context) (quota, used uint64, err error) {
+	ui, err := fsys.pcc.UserInfo(ctx)
+	if err != nil {
+		return 0, 0, fsError(err)
+	}
+
+	return ui.Quota, ui.UsedQuota, nil
+}
+
+// open opens the file node, with the flags of os.OpenFile, and returns its handle.
+func (fsys *FS) open(ctx context.Context, node uint64, flags int) (uint64, error) {
+	fileID, isFolder := parseNode(node)
+	if isFolder {
+		return 0, errIsDir
+	}
+
+	var pflags uint64
+	write := flags&(os.O_WRONLY|os.O_RDWR) != 0
+	if fsys.readOnly && (write || flags&os.O_TRUNC != 0) {
+		return 0, errReadOnly
+	}
+	if write {
+		pflags |= sdk.O_WRITE
+	}
+	if flags&os.O_TRUNC != 0 {
+		pflags |= sdk.O_TRUNC
+	}
+
+	f, err := fsys.pcc.FileOpen(ctx, pflags, sdk.ByID(fileID))
+	if err != nil {
+		return 0, fsError(err)
+	}
+
+	if flags&os.O_TRUNC != 0 {
+		fsys.truncated(node)
+	}
+
+	fh := fsys.newHandle(node, f.FD, write)
+
+	if fsys.cache != nil && !write {
+		// the hash of the file identifies its current contents in the cache.
+		fr, err := fsys.pcc.Stat(ctx, sdk.ByID(fileID))
+		if err != nil {
+			_ = fsys.release(ctx, fh)
+			return 0, fsError(err)
+		}
+
+		h, _ := fsys.handle(fh)
+		h.key = &blockcache.Key{FileID: fileID, Hash: fr.Metadata.Hash}
+	}
+
+	return fh, nil
+}
+
+// create creates the file name in the folder parent, opens it with the flags of os.OpenFile
+// and returns its metadata and its handle.
+func (fsys *FS) create(ctx context.Context, parent uint64, name string, flags int) (*sdk.Metadata, uint64, error) {
+	if fsys.readOnly {
+		return nil, 0, errReadOnly
+	}
+
+	folderID, err := folderOf(parent)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	pflags := uint64(sdk.O_WRITE | sdk.O_CREAT | sdk.O_TRUNC)
+	if flags&os.O_EXCL != 0 {
+		pflags |= sdk.O_EXCL
+	}
+
+	f, err := fsys.pcc.FileOpen(ctx, pflags, sdk.ByIDName(folderID, name))
+	if err != nil {
+		return nil, 0, fsError(err)
+	}
+
+	now := time.Now()
+	m := &sdk.Metadata{
+		Name:           name,
+		Created:        now,
+		Modified:       now,
+		IsMine:         true,
+		ParentFolderID: folderID,
+		FileID:         f.FileID,
+	}
+
+	fsys.lock.Lock()
+	fsys.cacheAttr(m)
+	delete(fsys.listings, folderID)
+	fsys.lock.Unlock()
+
+	return m, fsys.newHandle(fileNode(f.FileID), f.FD, true), nil
+}
+
+// newHandle registers the file descriptor fd of node and returns its handle.
+func (fsys *FS) newHandle(node, fd uint64, write bool) uint64 {
+	fsys.lock.Lock()
+	defer fsys.lock.Unlock()
+
+	fsys.lastHandle++
+	fsys.handles[fsys.lastHandle] = &handle{node: node, fd: fd, write: write}
+
+	return fsys.lastHandle
+}
+
+// handle returns the handle fh.
+func (fsys *FS) handle(fh uint64) (*handle, error) {
+	fsys.lock.Lock()
+	defer fsys.lock.Unlock()
+
+	h, ok := fsys.handles[fh]
+	if !ok {
+		return nil, errBadHandle
+	}
+
+	return h, nil
+}
+
+// read reads up to size bytes at offset off of the file handle fh. It reads through the block
+// cache when the handle has a key, or else reads ahead fsys.readAhead bytes, to serve the reads
+// that follow from memory.
+func (fsys *FS) read(ctx context.Context, fh, off uint64, size int) ([]byte, error) {
+	h, err := fsys.handle(fh)
+	if err != nil {
+		return nil, err
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.stale.Swap(false) {
+		h.buf = nil
+		// the cached contents are those of a previous version of the file.
+		h.key = nil
+	}
+
+	if h.key != nil {
+		data := make([]byte, size)
+
+		n, err := fsys.cache.ReadAt(*h.key, data, int64(off), blockcache.PReadFetcher(ctx, fsys.pcc, h.fd))
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fsError(err)
+		}
+
+		return data[:n], nil
+	}
+
+	if off >= h.bufOff && off+uint64(size) <= h.bufOff+uint64(len(h.buf)) {
+		return h.buf[off-h.bufOff : off-h.bufOff+uint64(size)], nil
+	}
+
+	count := size
+	if count < fsys.readAhead {
+		count = fsys.readAhead
+	}
+
+	data, err := fsys.pcc.FilePRead(ctx, h.fd, uint64(count), off)
+	if err != nil {
+		return nil, fsError(err)
+	}
+
+	if fsys.readAhead > 0 {
+		// the buffer is not returned to the pool: it may still be used by the caller of a
+		// previous read.
+		h.buf = data
+		h.bufOff = off
+	}
+
+	if len(data) > size {
+		data = data[:size]
+	}
+
+	return data, nil
+}
+
+// write writes data at offset off of the file handle fh.
+func (fsys *FS) write(ctx context.Context, fh, off uint64, data []byte) (int, error) {
+	h, err := fsys.handle(fh)
+	if err != nil {
+		return 0, err
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if !h.write {
+		return 0, fs.ErrPermission
+	}
+
+	h.buf = nil
+
+	if h.pos != off {
+		_, err = fsys.pcc.FileSeek(ctx, h.fd, off, sdk.WhenceFromBeginning)
+		if err != nil {
+			return 0, fsError(err)
+		}
+		h.pos = off
+	}
+
+	fdt, err := fsys.pcc.FileWrite(ctx, h.fd, data)
+	if err != nil {
+		return 0, fsError(err)
+	}
+	h.pos += fdt.Bytes
+
+	fsys.lock.Lock()
+	defer fsys.lock.Unlock()
+
+	if c, ok := fsys.attrs[h.node]; ok {
+		m := *c.m
+		if h.pos > m.Size {
+			m.Size = h.pos
+		}
+		m.Modified = time.Now()
+		fsys.attrs[h.node] = &cachedAttr{m: &m, expires: c.expires}
+	}
+
+	for _, h2 := range fsys.handles {
+		if h2 != h && h2.node == h.node {
+			h2.stale.Store(true)
+		}
+	}
+
+	return int(fdt.Bytes), nil
+}
+
+// release closes the file handle fh.
+func (fsys *FS) release(ctx context.Context, fh uint64) error {
+	fsys.lock.Lock()
+	h, ok := fsys.handles[fh]
+	delete(fsys.handles, fh)
+	fsys.lock.Unlock()
+
+	if !ok {
+		return errBadHandle
+	}
+
+	err := fsys.pcc.FileClose(ctx, h.fd)
+	if err != nil {
+		return fsError(err)
+	}
+
+	if h.write {
+		// pCloud sets the final metadata of the file, such as its hash, when it is closed.
+		fsys.forget(h.node)
+	}
+
+	return nil
+}
+
+// truncate changes the size of the file node to size. pCloud can only truncate files to 0.
+func (fsys *FS) truncate(ctx context.Context, node, size uint64) error {
+	if fsys.readOnly {
+		return errReadOnly
+	}
+
+	m, err := fsys.getattr(ctx, node)
+	if err != nil {
+		return err
+	}
+
+	if m.IsFolder {
+		return errIsDir
+	}
+
+	if size == m.Size {
+		return nil
+	}
+
+	if size != 0 {
+		return errUnsupported
+	}
+
+	f, err := fsys.pcc.FileOpen(ctx, sdk.O_WRITE|sdk.O_TRUNC, sdk.ByID(m.FileID))
+	if err != nil {
+		return fsError(err)
+	}
+
+	err = fsys.pcc.FileClose(ctx, f.FD)
+	if err != nil {
+		return fsError(err)
+	}
+
+	fsys.truncated(node)
+
+	return nil
+}
+
+// truncated updates the cached metadata of node, that was truncated to 0.
+func (fsys *FS) truncated(node uint64) {
+	fsys.lock.Lock()
+	defer fsys.lock.Unlock()
+
+	if c, ok := fsys.attrs[node]; ok {
+		m := *c.m
+		m.Size = 0
+		m.Modified = time.Now()
+		fsys.attrs[node] = &cachedAttr{m: &m, expires: c.expires}
+	}
+
+	fsys.markStale(node)
+}
+
+// mkdir creates the folder name in the folder parent.
+func (fsys *FS) mkdir(ctx context.Context, parent uint64, name string) (*sdk.Metadata, error) {
+	if fsys.readOnly {
+		return nil, errReadOnly
+	}
+
+	folderID, err := folderOf(parent)
+	if err != nil {
+		return nil, err
+	}
+
+	lf, err := fsys.pcc.CreateFolder(ctx, sdk.ByIDName(folderID, name))
+	if err != nil {
+		return nil, fsError(err)
+	}
+
+	fsys.lock.Lock()
+	defer fsys.lock.Unlock()
+
+	fsys.cacheAttr(lf.Metadata)
+	delete(fsys.listings, folderID)
+
+	return lf.Metadata, nil
+}
+
+// unlink deletes the file name from the folder parent.
+func (fsys *FS) unlink(ctx context.Context, parent uint64, name string) error {
+	if fsys.readOnly {
+		return errReadOnly
+	}
+
+	m, err := fsys.lookup(ctx, parent, name)
+	if err != nil {
+		return err
+	}
+
+	if m.IsFolder {
+		return errIsDir
+	}
+
+	_, err = fsys.pcc.DeleteFile(ctx, sdk.ByID(m.FileID))
+	if err != nil {
+		return fsError(err)
+	}
+
+	fsys.forget(nodeOf(m), m.ParentFolderID)
+
+	return nil
+}
+
+// rmdir deletes the empty folder name from the folder parent.
+func (fsys *FS) rmdir(ctx context.Context, parent uint64, name string) error {
+	if fsys.readOnly {
+		return errReadOnly
+	}
+
+	m, err := fsys.lookup(ctx, parent, name)
+	if err != nil {
+		return err
+	}
+
+	if !m.IsFolder {
+		return errNotDir
+	}
+
+	_, err = fsys.pcc.DeleteFolder(ctx, sdk.ByID(m.FolderID))
+	if err != nil {
+		return fsError(err)
+	}
+
+	fsys.forget(nodeOf(m), m.ParentFolderID, m.FolderID)
+
+	return nil
+}
+
+// rename renames (moves) the file or folder name of the folder parent to newName in the folder
+// newParent. A file replaces the file newName when it exists.
+func (fsys *FS) rename(ctx context.Context, parent uint64, name string, newParent uint64, newName string) error {
+	if fsys.readOnly {
+		return errReadOnly
+	}
+
+	newFolderID, err := folderOf(newParent)
+	if err != nil {
+		return err
+	}
+
+	m, err := fsys.lookup(ctx, parent, name)
+	if err != nil {
+		return err
+	}
+
+	// the file that is replaced, if any.
+	var replaced *sdk.Metadata
+	fsys.lock.Lock()
+	if l, ok := fsys.listings[newFolderID]; ok {
+		replaced = l.children[newName]
+	}
+	fsys.lock.Unlock()
+
+	if m.IsFolder {
+		_, err = fsys.pcc.RenameFolder(ctx, sdk.ByID(m.FolderID), sdk.ByIDName(newFolderID, newName))
+	} else {
+		_, err = fsys.pcc.RenameFile(ctx, sdk.ByID(m.FileID), sdk.ByIDName(newFolderID, newName))
+	}
+	if err != nil {
+		return fsError(err)
+	}
+
+	if replaced != nil {
+		fsys.forget(nodeOf(replaced))
+	}
+
+	fsys.forget(nodeOf(m), m.ParentFolderID, newFolderID)
+
+	return nil
+}
@@ -0,0 +1,378 @@
+package fuse
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/blockcache"
+	"github.com/seborama/pcloud-sdk/sdk"
+	"github.com/seborama/pcloud-sdk/sdk/sdktest"
+)
+
+// countingSDK counts the calls to FilePRead and List.
+type countingSDK struct {
+	pCloudSDK
+	preads   int
+	listings int
+}
+
+func (c *countingSDK) FilePRead(ctx context.Context, fd, count, offset uint64, opts ...sdk.ClientOption) ([]byte, error) {
+	c.preads++
+	return c.pCloudSDK.FilePRead(ctx, fd, count, offset, opts...)
+}
+
+func (c *countingSDK) List(ctx context.Context, folder sdk.FolderRef, o sdk.ListOptions, opts ...sdk.ClientOption) (*sdk.FSList, error) {
+	c.listings++
+	return c.pCloudSDK.List(ctx, folder, o, opts...)
+}
+
+func newTestFS(t *testing.T, opts ...Option) (*sdktest.Server, *countingSDK, *FS) {
+	t.Helper()
+
+	srv := sdktest.NewServer()
+	t.Cleanup(srv.Close)
+
+	_, err := srv.WriteFile("/Docs/a.txt", []byte("0123456789"))
+	require.NoError(t, err)
+	_, err = srv.MkdirAll("/Docs/Sub")
+	require.NoError(t, err)
+
+	pcc := &countingSDK{pCloudSDK: srv.NewClient()}
+
+	return srv, pcc, New(pcc, opts...)
+}
+
+func TestNodes(t *testing.T) {
+	assert.EqualValues(t, rootNodeID, folderNode(0))
+
+	id, isFolder := parseNode(folderNode(42))
+	assert.EqualValues(t, 42, id)
+	assert.True(t, isFolder)
+
+	id, isFolder = parseNode(fileNode(42))
+	assert.EqualValues(t, 42, id)
+	assert.False(t, isFolder)
+
+	assert.NotEqual(t, fileNode(0), folderNode(0))
+}
+
+func TestFS_LookupAndReadDir(t *testing.T) {
+	ctx := context.Background()
+	_, pcc, fsys := newTestFS(t)
+
+	root, err := fsys.getattr(ctx, rootNodeID)
+	require.NoError(t, err)
+	assert.True(t, root.IsFolder)
+
+	docs, err := fsys.lookup(ctx, rootNodeID, "Docs")
+	require.NoError(t, err)
+	assert.True(t, docs.IsFolder)
+
+	entries, err := fsys.readDir(ctx, nodeOf(docs))
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "Sub", entries[0].Name)
+	assert.Equal(t, "a.txt", entries[1].Name)
+
+	a, err := fsys.lookup(ctx, nodeOf(docs), "a.txt")
+	require.NoError(t, err)
+	assert.EqualValues(t, 10, a.Size)
+
+	m, err := fsys.getattr(ctx, nodeOf(a))
+	require.NoError(t, err)
+	assert.Equal(t, "a.txt", m.Name)
+
+	// served from the cache.
+	assert.Equal(t, 2, pcc.listings)
+
+	_, err = fsys.lookup(ctx, nodeOf(docs), "missing")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	_, err = fsys.lookup(ctx, nodeOf(a), "x")
+	assert.ErrorIs(t, err, errNotDir)
+
+	_, err = fsys.getattr(ctx, fileNode(12345))
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestFS_ReadAhead(t *testing.T) {
+	ctx := context.Background()
+	_, pcc, fsys := newTestFS(t, WithReadAhead(8))
+
+	docs, err := fsys.lookup(ctx, rootNodeID, "Docs")
+	require.NoError(t, err)
+	a, err := fsys.lookup(ctx, nodeOf(docs), "a.txt")
+	require.NoError(t, err)
+
+	fh, err := fsys.open(ctx, nodeOf(a), os.O_RDONLY)
+	require.NoError(t, err)
+
+	var got []byte
+	for off := uint64(0); off < 10; off += 2 {
+		data, err := fsys.read(ctx, fh, off, 2)
+		require.NoError(t, err)
+		got = append(got, data...)
+	}
+	assert.Equal(t, "0123456789", string(got))
+	assert.Equal(t, 2, pcc.preads)
+
+	data, err := fsys.read(ctx, fh, 10, 2)
+	require.NoError(t, err)
+	assert.Empty(t, data)
+
+	_, err = fsys.write(ctx, fh, 0, []byte("x"))
+	assert.ErrorIs(t, err, fs.ErrPermission)
+
+	require.NoError(t, fsys.release(ctx, fh))
+	assert.ErrorIs(t, fsys.release(ctx, fh), errBadHandle)
+}
+
+func TestFS_BlockCache(t *testing.T) {
+	ctx := context.Background()
+
+	cache, err := blockcache.New(1<<20, blockcache.WithBlockSize(4))
+	require.NoError(t, err)
+
+	_, pcc, fsys := newTestFS(t, WithBlockCache(cache))
+
+	docs, err := fsys.lookup(ctx, rootNodeID, "Docs")
+	require.NoError(t, err)
+	a, err := fsys.lookup(ctx, nodeOf(docs), "a.txt")
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		fh, err := fsys.open(ctx, nodeOf(a), os.O_RDONLY)
+		require.NoError(t, err)
+
+		data, err := fsys.read(ctx, fh, 2, 5)
+		require.NoError(t, err)
+		assert.Equal(t, "23456", string(data))
+
+		data, err = fsys.read(ctx, fh, 8, 5)
+		require.NoError(t, err)
+		assert.Equal(t, "89", string(data))
+
+		require.NoError(t, fsys.release(ctx, fh))
+	}
+
+	// the second handle reads from the cache.
+	assert.Equal(t, 3, pcc.preads)
+
+	// the blocks of the previous contents are not read.
+	fh, err := fsys.open(ctx, nodeOf(a), os.O_WRONLY)
+	require.NoError(t, err)
+	_, err = fsys.write(ctx, fh, 0, []byte("abcdefghij"))
+	require.NoError(t, err)
+	require.NoError(t, fsys.release(ctx, fh))
+
+	fh, err = fsys.open(ctx, nodeOf(a), os.O_RDONLY)
+	require.NoError(t, err)
+
+	data, err := fsys.read(ctx, fh, 2, 5)
+	require.NoError(t, err)
+	assert.Equal(t, "cdefg", string(data))
+
+	require.NoError(t, fsys.release(ctx, fh))
+}
+
+func TestFS_CreateAndWrite(t *testing.T) {
+	ctx := context.Background()
+	srv, _, fsys := newTestFS(t)
+
+	m, fh, err := fsys.create(ctx, rootNodeID, "new.txt", os.O_WRONLY)
+	require.NoError(t, err)
+
+	n, err := fsys.write(ctx, fh, 0, []byte("hello"))
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+
+	_, err = fsys.write(ctx, fh, 5, []byte(" world"))
+	require.NoError(t, err)
+
+	_, err = fsys.write(ctx, fh, 0, []byte("H"))
+	require.NoError(t, err)
+
+	// the size is known before the file is closed.
+	m2, err := fsys.lookup(ctx, rootNodeID, "new.txt")
+	require.NoError(t, err)
+	assert.Equal(t, m.FileID, m2.FileID)
+	assert.EqualValues(t, 11, m2.Size)
+
+	rfh, err := fsys.open(ctx, nodeOf(m), os.O_RDONLY)
+	require.NoError(t, err)
+
+	data, err := fsys.read(ctx, rfh, 0, 100)
+	require.NoError(t, err)
+	assert.Equal(t, "Hello world", string(data))
+
+	// the data read ahead is discarded when the file is written through another handle.
+	_, err = fsys.write(ctx, fh, 6, []byte("W"))
+	require.NoError(t, err)
+
+	data, err = fsys.read(ctx, rfh, 0, 100)
+	require.NoError(t, err)
+	assert.Equal(t, "Hello World", string(data))
+
+	require.NoError(t, fsys.release(ctx, rfh))
+	require.NoError(t, fsys.release(ctx, fh))
+
+	data, err = srv.ReadFile("/new.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "Hello World", string(data))
+
+	_, _, err = fsys.create(ctx, rootNodeID, "new.txt", os.O_WRONLY|os.O_EXCL)
+	assert.ErrorIs(t, err, fs.ErrExist)
+}
+
+func TestFS_Truncate(t *testing.T) {
+	ctx := context.Background()
+	srv, _, fsys := newTestFS(t)
+
+	docs, err := fsys.lookup(ctx, rootNodeID, "Docs")
+	require.NoError(t, err)
+	a, err := fsys.lookup(ctx, nodeOf(docs), "a.txt")
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, fsys.truncate(ctx, nodeOf(a), 5), errUnsupported)
+	require.NoError(t, fsys.truncate(ctx, nodeOf(a), 10))
+	require.NoError(t, fsys.truncate(ctx, nodeOf(a), 0))
+
+	m, err := fsys.getattr(ctx, nodeOf(a))
+	require.NoError(t, err)
+	assert.EqualValues(t, 0, m.Size)
+
+	data, err := srv.ReadFile("/Docs/a.txt")
+	require.NoError(t, err)
+	assert.Empty(t, data)
+
+	fileID, err := srv.WriteFile("/Docs/a.txt", []byte("abc"))
+	require.NoError(t, err)
+
+	fh, err := fsys.open(ctx, fileNode(fileID), os.O_WRONLY|os.O_TRUNC)
+	require.NoError(t, err)
+	require.NoError(t, fsys.release(ctx, fh))
+
+	data, err = srv.ReadFile("/Docs/a.txt")
+	require.NoError(t, err)
+	assert.Empty(t, data)
+}
+
+func TestFS_ReadOnly(t *testing.T) {
+	ctx := context.Background()
+	srv, _, fsys := newTestFS(t, WithReadOnly())
+
+	docs, err := fsys.lookup(ctx, rootNodeID, "Docs")
+	require.NoError(t, err)
+	a, err := fsys.lookup(ctx, nodeOf(docs), "a.txt")
+	require.NoError(t, err)
+
+	fh, err := fsys.open(ctx, nodeOf(a), os.O_RDONLY)
+	require.NoError(t, err)
+	data, err := fsys.read(ctx, fh, 0, 100)
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789", string(data))
+	require.NoError(t, fsys.release(ctx, fh))
+
+	_, err = fsys.open(ctx, nodeOf(a), os.O_RDWR)
+	assert.ErrorIs(t, err, errReadOnly)
+	_, err = fsys.open(ctx, nodeOf(a), os.O_RDONLY|os.O_TRUNC)
+	assert.ErrorIs(t, err, errReadOnly)
+	_, _, err = fsys.create(ctx, rootNodeID, "new.txt", os.O_WRONLY)
+	assert.ErrorIs(t, err, errReadOnly)
+	_, err = fsys.mkdir(ctx, rootNodeID, "New")
+	assert.ErrorIs(t, err, errReadOnly)
+	assert.ErrorIs(t, fsys.truncate(ctx, nodeOf(a), 0), errReadOnly)
+	assert.ErrorIs(t, fsys.rename(ctx, nodeOf(docs), "a.txt", rootNodeID, "b.txt"), errReadOnly)
+	assert.ErrorIs(t, fsys.unlink(ctx, nodeOf(docs), "a.txt"), errReadOnly)
+	assert.ErrorIs(t, fsys.rmdir(ctx, nodeOf(docs), "Sub"), errReadOnly)
+
+	data, err = srv.ReadFile("/Docs/a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789", string(data))
+}
+
+func TestFS_Folders(t *testing.T) {
+	ctx := context.Background()
+	srv, _, fsys := newTestFS(t)
+
+	docs, err := fsys.lookup(ctx, rootNodeID, "Docs")
+	require.NoError(t, err)
+
+	m, err := fsys.mkdir(ctx, nodeOf(docs), "New")
+	require.NoError(t, err)
+	assert.True(t, m.IsFolder)
+
+	_, err = fsys.mkdir(ctx, nodeOf(docs), "New")
+	assert.ErrorIs(t, err, fs.ErrExist)
+
+	require.NoError(t, fsys.rename(ctx, nodeOf(docs), "a.txt", nodeOf(m), "b.txt"))
+	require.NoError(t, fsys.rename(ctx, nodeOf(docs), "New", rootNodeID, "Moved"))
+
+	data, err := srv.ReadFile("/Moved/b.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789", string(data))
+
+	_, err = fsys.lookup(ctx, nodeOf(docs), "a.txt")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	assert.ErrorIs(t, fsys.rmdir(ctx, rootNodeID, "Moved"), errNotEmpty)
+	assert.ErrorIs(t, fsys.unlink(ctx, rootNodeID, "Moved"), errIsDir)
+	assert.ErrorIs(t, fsys.rmdir(ctx, folderNode(m.FolderID), "b.txt"), errNotDir)
+
+	require.NoError(t, fsys.unlink(ctx, folderNode(m.FolderID), "b.txt"))
+	require.NoError(t, fsys.rmdir(ctx, rootNodeID, "Moved"))
+
+	entries, err := fsys.readDir(ctx, rootNodeID)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "Docs", entries[0].Name)
+}
+
+func TestFS_Watch(t *testing.T) {
+	ctx := context.Background()
+	srv, pcc, fsys := newTestFS(t, WithAttrTimeout(time.Hour))
+
+	docs, err := fsys.lookup(ctx, rootNodeID, "Docs")
+	require.NoError(t, err)
+
+	_, err = fsys.readDir(ctx, nodeOf(docs))
+	require.NoError(t, err)
+
+	fileID, err := srv.WriteFile("/Docs/b.txt", []byte("b"))
+	require.NoError(t, err)
+
+	// the listing is cached.
+	_, err = fsys.lookup(ctx, nodeOf(docs), "b.txt")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	entries := make(chan sdk.Entry, 1)
+	entries <- sdk.Entry{
+		Event:    sdk.CreateFile,
+		Metadata: sdk.Metadata{FileID: fileID, ParentFolderID: docs.FolderID, Name: "b.txt"},
+	}
+	close(entries)
+
+	fsys.Watch(ctx, entries)
+
+	listings := pcc.listings
+
+	m, err := fsys.lookup(ctx, nodeOf(docs), "b.txt")
+	require.NoError(t, err)
+	assert.Equal(t, fileID, m.FileID)
+	assert.Equal(t, listings+1, pcc.listings)
+}
+
+func TestFS_Statfs(t *testing.T) {
+	_, _, fsys := newTestFS(t)
+
+	quota, used, err := fsys.statfs(context.Background())
+	require.NoError(t, err)
+	assert.Greater(t, quota, used)
+	assert.EqualValues(t, 10, used)
+}
@@ -0,0 +1,770 @@
+package fuse
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"github.com/pkg/errors"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+const (
+	// kernelTimeout is the duration for which the kernel caches entries and attributes. It is
+	// short because FS is the one that caches them and keeps them up to date.
+	kernelTimeout = time.Second
+
+	// maxWrite is the largest write that the kernel sends.
+	maxWrite = 128 * 1024
+
+	// bufSize is the size of the buffers requests are read into. It holds the largest write
+	// along with its headers.
+	bufSize = maxWrite + 4096
+
+	// blockSize is the block size reported to the kernel.
+	blockSize = 4096
+
+	// pollHackName and pollHackNode are the name and the node ID of the hidden file opened by
+	// pollHack.
+	pollHackName = ".pcloud-fuse-poll-hack"
+	pollHackNode = math.MaxUint64
+)
+
+// Conn is a connection to the kernel for an FS mounted with Mount.
+type Conn struct {
+	fsys       *FS
+	mountpoint string
+	allowOther bool
+	fd         int
+	fusermount string
+
+	unmountOnce sync.Once
+	unmountErr  error
+
+	done chan struct{}
+	err  error
+
+	lock    sync.Mutex
+	dirs    map[uint64][]*sdk.Metadata
+	lastDir uint64
+}
+
+// Mount mounts fsys at mountpoint, which must be an existing folder, and serves the requests of
+// the kernel until the file system is unmounted, with Conn.Unmount or by an external command
+// such as umount. When ctx is cancelled, the file system is unmounted. The calls made to pCloud
+// use ctx.
+// Mounting requires root privileges, or fusermount3 (or fusermount), which is part of the fuse
+// package of most Linux distributions.
+// The file system is mounted read-only when fsys was created WithReadOnly.
+func Mount(ctx context.Context, mountpoint string, fsys *FS, opts ...MountOption) (*Conn, error) {
+	var mc mountConfig
+	for _, opt := range opts {
+		opt(&mc)
+	}
+
+	c := &Conn{
+		fsys:       fsys,
+		mountpoint: mountpoint,
+		allowOther: mc.allowOther,
+		done:       make(chan struct{}),
+		dirs:       map[uint64][]*sdk.Metadata{},
+	}
+
+	err := c.mount()
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		c.err = c.serve(ctx)
+		close(c.done)
+	}()
+
+	err = c.pollHack()
+	if err != nil {
+		_ = c.Unmount()
+		<-c.done
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// mount mounts the file system and opens the connection to the kernel.
+func (c *Conn) mount() error {
+	fd, err := syscall.Open("/dev/fuse", syscall.O_RDWR|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return errors.Wrap(err, "opening /dev/fuse")
+	}
+
+	opts := fmt.Sprintf("fd=%d,rootmode=%o,user_id=%d,group_id=%d,default_permissions", fd, syscall.S_IFDIR, os.Getuid(), os.Getgid())
+	if c.allowOther {
+		opts += ",allow_other"
+	}
+
+	flags := uintptr(syscall.MS_NOSUID | syscall.MS_NODEV)
+	if c.fsys.readOnly {
+		flags |= syscall.MS_RDONLY
+	}
+
+	err = syscall.Mount("pcloud", c.mountpoint, "fuse.pcloud", flags, opts)
+	if err == nil {
+		c.fd = fd
+		return nil
+	}
+
+	_ = syscall.Close(fd)
+
+	if !errors.Is(err, syscall.EPERM) {
+		return errors.Wrapf(err, "mounting %s", c.mountpoint)
+	}
+
+	// unprivileged users mount with fusermount, that passes the file descriptor of /dev/fuse
+	// over a socket.
+	opts = "fsname=pcloud,subtype=pcloud,default_permissions"
+	if c.allowOther {
+		opts += ",allow_other"
+	}
+	if c.fsys.readOnly {
+		opts += ",ro"
+	}
+
+	c.fd, c.fusermount, err = fusermount(c.mountpoint, opts)
+
+	return err
+}
+
+// pollHack stops the kernel from polling the files of the file system.
+// Go registers the files it opens with its netpoller, which makes the kernel send a poll
+// request to the file system. When the file system is served by the same process, this can
+// deadlock: the thread blocked in epoll_ctl holds the processor that the goroutines serving the
+// requests need. The kernel stops sending poll requests once one of them is answered with
+// ENOSYS, which is done here with a hidden file, before Mount returns.
+func (c *Conn) pollHack() error {
+	fd, err := syscall.Open(filepath.Join(c.mountpoint, pollHackName), syscall.O_RDONLY|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return errors.Wrap(err, "opening the file system")
+	}
+	defer func() { _ = syscall.Close(fd) }()
+
+	epfd, err := syscall.EpollCreate1(syscall.EPOLL_CLOEXEC)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() { _ = syscall.Close(epfd) }()
+
+	// syscall.EpollCtl is a raw system call, that would hold the processor.
+	event := syscall.EpollEvent{Events: syscall.EPOLLIN, Fd: int32(fd)}
+	_, _, _ = syscall.Syscall6(syscall.SYS_EPOLL_CTL, uintptr(epfd), syscall.EPOLL_CTL_ADD, uintptr(fd), uintptr(unsafe.Pointer(&event)), 0, 0)
+
+	return nil
+}
+
+// fusermount mounts mountpoint with fusermount3 or fusermount, with the mount options opts, and
+// returns the file descriptor of /dev/fuse along with the path of the fusermount binary.
+func fusermount(mountpoint, opts string) (int, string, error) {
+	bin, err := exec.LookPath("fusermount3")
+	if err != nil {
+		bin, err = exec.LookPath("fusermount")
+		if err != nil {
+			return 0, "", errors.New("mounting requires root privileges or fusermount")
+		}
+	}
+
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_STREAM|syscall.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return 0, "", errors.WithStack(err)
+	}
+
+	local := os.NewFile(uintptr(fds[0]), "fusermount-local")
+	remote := os.NewFile(uintptr(fds[1]), "fusermount-remote")
+	defer func() { _ = local.Close() }()
+	defer func() { _ = remote.Close() }()
+
+	cmd := exec.Command(bin, "-o", opts, "--", mountpoint)
+	cmd.ExtraFiles = []*os.File{remote} // file descriptor 3
+	cmd.Env = append(os.Environ(), "_FUSE_COMMFD=3")
+	cmd.Stderr = os.Stderr
+
+	err = cmd.Run()
+	if err != nil {
+		return 0, "", errors.Wrapf(err, "running %s", bin)
+	}
+
+	buf := make([]byte, 4)
+	oob := make([]byte, syscall.CmsgSpace(4))
+
+	_, oobn, _, _, err := syscall.Recvmsg(fds[0], buf, oob, 0)
+	if err != nil {
+		return 0, "", errors.Wrapf(err, "receiving the file descriptor from %s", bin)
+	}
+
+	msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	if err != nil || len(msgs) != 1 {
+		return 0, "", errors.Errorf("no file descriptor received from %s", bin)
+	}
+
+	rights, err := syscall.ParseUnixRights(&msgs[0])
+	if err != nil || len(rights) != 1 {
+		return 0, "", errors.Errorf("no file descriptor received from %s", bin)
+	}
+
+	return rights[0], bin, nil
+}
+
+// Wait waits until the file system is unmounted and returns the error that stopped serving
+// its requests, if any.
+func (c *Conn) Wait() error {
+	<-c.done
+	return c.err
+}
+
+// Unmount unmounts the file system. Wait returns once it is unmounted.
+// When the file system is busy, it is detached and unmounted as soon as it is no longer busy.
+func (c *Conn) Unmount() error {
+	c.unmountOnce.Do(func() {
+		if c.fusermount != "" {
+			out, err := exec.Command(c.fusermount, "-u", "-z", c.mountpoint).CombinedOutput()
+			if err != nil {
+				c.unmountErr = errors.Wrapf(err, "unmounting %s: %s", c.mountpoint, bytes.TrimSpace(out))
+			}
+			return
+		}
+
+		err := syscall.Unmount(c.mountpoint, 0)
+		if errors.Is(err, syscall.EBUSY) {
+			err = syscall.Unmount(c.mountpoint, syscall.MNT_DETACH)
+		}
+		if err != nil {
+			c.unmountErr = errors.Wrapf(err, "unmounting %s", c.mountpoint)
+		}
+	})
+
+	return c.unmountErr
+}
+
+// serve serves the requests of the kernel until the file system is unmounted, or ctx is
+// cancelled.
+func (c *Conn) serve(ctx context.Context) error {
+	defer func() { _ = syscall.Close(c.fd) }()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = c.Unmount()
+		case <-done:
+		}
+	}()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		buf := sdk.GetBuffer(bufSize)
+
+		n, err := syscall.Read(c.fd, buf[:bufSize])
+		if err != nil {
+			sdk.PutBuffer(buf)
+
+			switch {
+			case errors.Is(err, syscall.EINTR), errors.Is(err, syscall.EAGAIN), errors.Is(err, syscall.ENOENT):
+				// the request was interrupted: read the next one.
+				continue
+			case errors.Is(err, syscall.ENODEV):
+				// the file system was unmounted.
+				return nil
+			}
+
+			return errors.Wrap(err, "reading /dev/fuse")
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer sdk.PutBuffer(buf)
+
+			c.handle(ctx, buf[:n])
+		}()
+	}
+}
+
+// handle serves the request req.
+func (c *Conn) handle(ctx context.Context, req []byte) {
+	if len(req) < inHeaderSize {
+		return
+	}
+
+	var h inHeader
+	_ = binary.Read(bytes.NewReader(req), binary.NativeEndian, &h)
+	body := req[inHeaderSize:]
+
+	var (
+		out []any
+		err error
+	)
+
+	switch {
+	case h.Opcode == opForget || h.Opcode == opBatchForget:
+		// node IDs are derived from pCloud IDs: there is nothing to forget, nor to reply.
+		return
+
+	case h.NodeID == pollHackNode || h.Opcode == opLookup && h.NodeID == rootNodeID && cstring(body) == pollHackName:
+		out, err = c.handlePollHack(h.Opcode)
+		c.reply(h.Unique, err, out...)
+		return
+	}
+
+	switch h.Opcode {
+	case opInit:
+		out, err = c.init(body)
+
+	case opLookup:
+		out, err = c.lookup(ctx, h.NodeID, cstring(body))
+
+	case opGetattr:
+		out, err = c.getattr(ctx, h.NodeID)
+
+	case opSetattr:
+		out, err = c.setattr(ctx, h.NodeID, body)
+
+	case opOpen:
+		out, err = c.open(ctx, h.NodeID, body)
+
+	case opCreate:
+		out, err = c.create(ctx, h.NodeID, body)
+
+	case opRead:
+		out, err = c.read(ctx, body)
+
+	case opWrite:
+		out, err = c.write(ctx, body)
+
+	case opRelease:
+		var in releaseIn
+		decode(body, &in)
+		err = c.fsys.release(ctx, in.Fh)
+
+	case opOpendir:
+		out, err = c.opendir(ctx, h.NodeID)
+
+	case opReaddir:
+		out, err = c.readdir(h.NodeID, body)
+
+	case opReleasedir:
+		var in releaseIn
+		decode(body, &in)
+		c.lock.Lock()
+		delete(c.dirs, in.Fh)
+		c.lock.Unlock()
+
+	case opMkdir:
+		out, err = c.mkdir(ctx, h.NodeID, body)
+
+	case opUnlink:
+		err = c.fsys.unlink(ctx, h.NodeID, cstring(body))
+
+	case opRmdir:
+		err = c.fsys.rmdir(ctx, h.NodeID, cstring(body))
+
+	case opRename:
+		err = c.rename(ctx, h.NodeID, body, renameInSize)
+
+	case opRename2:
+		var in rename2In
+		decode(body, &in)
+		if in.Flags != 0 {
+			err = syscall.EINVAL
+			break
+		}
+		err = c.rename(ctx, h.NodeID, body, rename2InSize)
+
+	case opStatfs:
+		out, err = c.statfs(ctx)
+
+	case opFlush, opFsync, opFsyncdir, opDestroy:
+		// writes are sent to pCloud as they are made.
+
+	default:
+		// this includes opInterrupt: replying ENOSYS tells the kernel not to send interrupts.
+		err = syscall.ENOSYS
+	}
+
+	c.reply(h.Unique, err, out...)
+}
+
+// handlePollHack serves the requests made by pollHack for its hidden file.
+// Replying ENOSYS to its poll request is what disables polling.
+func (c *Conn) handlePollHack(opcode uint32) ([]any, error) {
+	a := attr{Ino: pollHackNode, Mode: syscall.S_IFREG | 0444, Nlink: 1}
+
+	switch opcode {
+	case opLookup:
+		return []any{&entryOut{NodeID: pollHackNode, Attr: a}}, nil
+	case opGetattr:
+		return []any{&attrOut{Attr: a}}, nil
+	case opOpen:
+		return []any{&openOut{}}, nil
+	case opFlush, opRelease:
+		return nil, nil
+	}
+
+	return nil, syscall.ENOSYS
+}
+
+// reply sends the reply to the request unique, made of out, or the error err.
+func (c *Conn) reply(unique uint64, err error, out ...any) {
+	var buf bytes.Buffer
+	buf.Write(make([]byte, outHeaderSize))
+
+	errno := c.errno(err)
+	if errno == 0 {
+		for _, o := range out {
+			if b, ok := o.([]byte); ok {
+				buf.Write(b)
+				continue
+			}
+			_ = binary.Write(&buf, binary.NativeEndian, o)
+		}
+	}
+
+	b := buf.Bytes()
+	binary.NativeEndian.PutUint32(b[0:], uint32(len(b)))
+	binary.NativeEndian.PutUint32(b[4:], uint32(-int32(errno)))
+	binary.NativeEndian.PutUint64(b[8:], unique)
+
+	// ENOENT means that the request was interrupted: its reply is no longer expected.
+	_, _ = syscall.Write(c.fd, b)
+}
+
+// errno returns the error number of err.
+func (c *Conn) errno(err error) syscall.Errno {
+	var errno syscall.Errno
+
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &errno):
+		return errno
+	case errors.Is(err, fs.ErrNotExist):
+		return syscall.ENOENT
+	case errors.Is(err, fs.ErrExist):
+		return syscall.EEXIST
+	case errors.Is(err, fs.ErrPermission):
+		return syscall.EACCES
+	case errors.Is(err, errIsDir):
+		return syscall.EISDIR
+	case errors.Is(err, errNotDir):
+		return syscall.ENOTDIR
+	case errors.Is(err, errNotEmpty):
+		return syscall.ENOTEMPTY
+	case errors.Is(err, errBadHandle):
+		return syscall.EBADF
+	case errors.Is(err, errUnsupported):
+		return syscall.ENOTSUP
+	case errors.Is(err, errReadOnly):
+		return syscall.EROFS
+	case errors.Is(err, context.Canceled):
+		return syscall.EINTR
+	}
+
+	if c.fsys.logger != nil {
+		c.fsys.logger.Error("fuse: pCloud call failed", "error", err)
+	}
+
+	return syscall.EIO
+}
+
+// decode decodes the start of body into in.
+func decode(body []byte, in any) {
+	_ = binary.Read(bytes.NewReader(body), binary.NativeEndian, in)
+}
+
+// cstring returns the NUL-terminated string at the start of b.
+func cstring(b []byte) string {
+	if n := bytes.IndexByte(b, 0); n >= 0 {
+		return string(b[:n])
+	}
+	return string(b)
+}
+
+// attr returns the attributes of m.
+func (c *Conn) attr(m *sdk.Metadata) attr {
+	a := attr{
+		Ino:     nodeOf(m),
+		Size:    m.Size,
+		Blocks:  (m.Size + 511) / 512,
+		UID:     c.fsys.uid,
+		GID:     c.fsys.gid,
+		Blksize: blockSize,
+	}
+
+	if !m.Modified.IsZero() {
+		a.Mtime = uint64(m.Modified.Unix())
+		a.Atime = a.Mtime
+		a.Ctime = a.Mtime
+	}
+
+	if m.IsFolder {
+		a.Mode = syscall.S_IFDIR | 0755
+		a.Nlink = 2
+	} else {
+		a.Mode = syscall.S_IFREG | 0644
+		a.Nlink = 1
+	}
+
+	return a
+}
+
+// entry returns the entry of m.
+func (c *Conn) entry(m *sdk.Metadata) entryOut {
+	return entryOut{
+		NodeID:     nodeOf(m),
+		EntryValid: uint64(kernelTimeout / time.Second),
+		AttrValid:  uint64(kernelTimeout / time.Second),
+		Attr:       c.attr(m),
+	}
+}
+
+func (c *Conn) init(body []byte) ([]any, error) {
+	var in initIn
+	decode(body, &in)
+
+	if in.Major != protoMajor || in.Minor < 12 {
+		return nil, syscall.EPROTO
+	}
+
+	return []any{&initOut{
+		Major:               protoMajor,
+		Minor:               protoMinor,
+		MaxReadahead:        in.MaxReadahead,
+		Flags:               in.Flags & (initAsyncRead | initAtomicOTrunc | initBigWrites),
+		MaxBackground:       initMaxBackground,
+		CongestionThreshold: initMaxBackground * 3 / 4,
+		MaxWrite:            maxWrite,
+		TimeGran:            uint32(time.Second),
+	}}, nil
+}
+
+func (c *Conn) lookup(ctx context.Context, parent uint64, name string) ([]any, error) {
+	m, err := c.fsys.lookup(ctx, parent, name)
+	if err != nil {
+		return nil, err
+	}
+
+	e := c.entry(m)
+
+	return []any{&e}, nil
+}
+
+func (c *Conn) getattr(ctx context.Context, node uint64) ([]any, error) {
+	m, err := c.fsys.getattr(ctx, node)
+	if err != nil {
+		return nil, err
+	}
+
+	return []any{&attrOut{AttrValid: uint64(kernelTimeout / time.Second), Attr: c.attr(m)}}, nil
+}
+
+// setattr only changes the size of files. pCloud has no notion of permissions or ownership,
+// and the other changes are ignored so that tools such as cp -p do not fail.
+func (c *Conn) setattr(ctx context.Context, node uint64, body []byte) ([]any, error) {
+	var in setattrIn
+	decode(body, &in)
+
+	if in.Valid&setattrSize != 0 {
+		err := c.fsys.truncate(ctx, node, in.Size)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return c.getattr(ctx, node)
+}
+
+func (c *Conn) open(ctx context.Context, node uint64, body []byte) ([]any, error) {
+	var in openIn
+	decode(body, &in)
+
+	fh, err := c.fsys.open(ctx, node, int(in.Flags))
+	if err != nil {
+		return nil, err
+	}
+
+	return []any{&openOut{Fh: fh}}, nil
+}
+
+func (c *Conn) create(ctx context.Context, parent uint64, body []byte) ([]any, error) {
+	if len(body) < createInSize {
+		return nil, syscall.EINVAL
+	}
+
+	var in createIn
+	decode(body, &in)
+
+	m, fh, err := c.fsys.create(ctx, parent, cstring(body[createInSize:]), int(in.Flags))
+	if err != nil {
+		return nil, err
+	}
+
+	e := c.entry(m)
+
+	return []any{&e, &openOut{Fh: fh}}, nil
+}
+
+func (c *Conn) read(ctx context.Context, body []byte) ([]any, error) {
+	var in readIn
+	decode(body, &in)
+
+	data, err := c.fsys.read(ctx, in.Fh, in.Offset, int(in.Size))
+	if err != nil {
+		return nil, err
+	}
+
+	return []any{data}, nil
+}
+
+func (c *Conn) write(ctx context.Context, body []byte) ([]any, error) {
+	var in readIn
+	decode(body, &in)
+
+	if len(body) < writeInSize+int(in.Size) {
+		return nil, syscall.EINVAL
+	}
+
+	n, err := c.fsys.write(ctx, in.Fh, in.Offset, body[writeInSize:writeInSize+int(in.Size)])
+	if err != nil {
+		return nil, err
+	}
+
+	return []any{&writeOut{Size: uint32(n)}}, nil
+}
+
+// opendir takes a snapshot of the contents of the folder, so that they are consistent across
+// the calls to readdir.
+func (c *Conn) opendir(ctx context.Context, node uint64) ([]any, error) {
+	entries, err := c.fsys.readDir(ctx, node)
+	if err != nil {
+		return nil, err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.lastDir++
+	c.dirs[c.lastDir] = entries
+
+	return []any{&openOut{Fh: c.lastDir}}, nil
+}
+
+// readdir returns the entries of the folder, starting with "." and "..", from in.Offset, that
+// fit in in.Size.
+func (c *Conn) readdir(node uint64, body []byte) ([]any, error) {
+	var in readIn
+	decode(body, &in)
+
+	c.lock.Lock()
+	entries, ok := c.dirs[in.Fh]
+	c.lock.Unlock()
+
+	if !ok {
+		return nil, errBadHandle
+	}
+
+	var buf bytes.Buffer
+
+	for off := in.Offset; off < uint64(len(entries))+2; off++ {
+		name, ino, typ := ".", node, uint32(direntDir)
+		switch off {
+		case 0:
+		case 1:
+			name = ".."
+		default:
+			m := entries[off-2]
+			name, ino, typ = m.Name, nodeOf(m), direntReg
+			if m.IsFolder {
+				typ = direntDir
+			}
+		}
+
+		size := (direntSize + len(name) + 7) &^ 7
+		if buf.Len()+size > int(in.Size) {
+			break
+		}
+
+		_ = binary.Write(&buf, binary.NativeEndian, &dirent{Ino: ino, Off: off + 1, Namelen: uint32(len(name)), Type: typ})
+		buf.WriteString(name)
+		buf.Write(make([]byte, size-direntSize-len(name)))
+	}
+
+	return []any{buf.Bytes()}, nil
+}
+
+func (c *Conn) mkdir(ctx context.Context, parent uint64, body []byte) ([]any, error) {
+	if len(body) < mkdirInSize {
+		return nil, syscall.EINVAL
+	}
+
+	m, err := c.fsys.mkdir(ctx, parent, cstring(body[mkdirInSize:]))
+	if err != nil {
+		return nil, err
+	}
+
+	e := c.entry(m)
+
+	return []any{&e}, nil
+}
+
+// rename serves the requests to rename, whose body starts with a renameIn or a rename2In of size
+// inSize, followed by the old and new names.
+func (c *Conn) rename(ctx context.Context, parent uint64, body []byte, inSize int) error {
+	if len(body) < inSize {
+		return syscall.EINVAL
+	}
+
+	var in renameIn
+	decode(body, &in)
+
+	names := body[inSize:]
+	oldName := cstring(names)
+	newName := cstring(names[min(len(oldName)+1, len(names)):])
+
+	return c.fsys.rename(ctx, parent, oldName, in.NewDir, newName)
+}
+
+func (c *Conn) statfs(ctx context.Context) ([]any, error) {
+	quota, used, err := c.fsys.statfs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	free := uint64(0)
+	if quota > used {
+		free = quota - used
+	}
+
+	return []any{&statfsOut{
+		Blocks:  quota / blockSize,
+		Bfree:   free / blockSize,
+		Bavail:  free / blockSize,
+		Bsize:   blockSize,
+		Frsize:  blockSize,
+		Namelen: 255,
+	}}, nil
+}
@@ -0,0 +1,113 @@
+package fuse_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/fuse"
+	"github.com/seborama/pcloud-sdk/sdk/sdktest"
+)
+
+// mount mounts the account of srv in a temporary folder. The test is skipped when FUSE is not
+// available.
+func mount(t *testing.T, srv *sdktest.Server) string {
+	t.Helper()
+
+	mountpoint := t.TempDir()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	conn, err := fuse.Mount(ctx, mountpoint, fuse.New(srv.NewClient()))
+	if err != nil {
+		cancel()
+		t.Skipf("FUSE is not available: %v", err)
+	}
+
+	done := make(chan error, 1)
+
+	go func() { done <- conn.Wait() }()
+
+	t.Cleanup(func() {
+		cancel()
+		select {
+		case err := <-done:
+			assert.NoError(t, err)
+		case <-time.After(10 * time.Second):
+			t.Error("the file system was not unmounted")
+		}
+	})
+
+	return mountpoint
+}
+
+func TestMount(t *testing.T) {
+	srv := sdktest.NewServer()
+	t.Cleanup(srv.Close)
+
+	_, err := srv.WriteFile("/Docs/a.txt", []byte("hello"))
+	require.NoError(t, err)
+
+	mnt := mount(t, srv)
+
+	entries, err := os.ReadDir(mnt)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "Docs", entries[0].Name())
+	assert.True(t, entries[0].IsDir())
+
+	data, err := os.ReadFile(filepath.Join(mnt, "Docs", "a.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+
+	_, err = os.Stat(filepath.Join(mnt, "missing"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	// write a file large enough to be written and read in several requests.
+	large := bytes.Repeat([]byte("0123456789abcdef"), 200_000)
+	require.NoError(t, os.Mkdir(filepath.Join(mnt, "New"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(mnt, "New", "large.bin"), large, 0644))
+
+	data, err = srv.ReadFile("/New/large.bin")
+	require.NoError(t, err)
+	assert.True(t, bytes.Equal(large, data))
+
+	data, err = os.ReadFile(filepath.Join(mnt, "New", "large.bin"))
+	require.NoError(t, err)
+	assert.True(t, bytes.Equal(large, data))
+
+	info, err := os.Stat(filepath.Join(mnt, "New", "large.bin"))
+	require.NoError(t, err)
+	assert.EqualValues(t, len(large), info.Size())
+
+	require.NoError(t, os.WriteFile(filepath.Join(mnt, "Docs", "a.txt"), []byte("hi"), 0644))
+
+	data, err = os.ReadFile(filepath.Join(mnt, "Docs", "a.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "hi", string(data))
+
+	require.NoError(t, os.Rename(filepath.Join(mnt, "Docs", "a.txt"), filepath.Join(mnt, "New", "b.txt")))
+
+	data, err = srv.ReadFile("/New/b.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "hi", string(data))
+
+	err = os.Remove(filepath.Join(mnt, "New"))
+	assert.ErrorIs(t, err, syscall.ENOTEMPTY)
+
+	require.NoError(t, os.RemoveAll(filepath.Join(mnt, "New")))
+
+	_, err = srv.ReadFile("/New/b.txt")
+	assert.Error(t, err)
+
+	var st syscall.Statfs_t
+	require.NoError(t, syscall.Statfs(mnt, &st))
+	assert.NotZero(t, st.Blocks)
+}
@@ -0,0 +1,30 @@
+//go:build !linux
+
+package fuse
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// errNotSupported is returned by Mount on the platforms where mounting is not supported.
+var errNotSupported = errors.New("fuse: mounting is only supported on Linux")
+
+// Conn is a connection to the kernel for an FS mounted with Mount.
+type Conn struct{}
+
+// Mount mounts fsys at mountpoint. It is only supported on Linux.
+func Mount(context.Context, string, *FS, ...MountOption) (*Conn, error) {
+	return nil, errNotSupported
+}
+
+// Unmount unmounts the file system.
+func (*Conn) Unmount() error {
+	return errNotSupported
+}
+
+// Wait waits until the file system is unmounted.
+func (*Conn) Wait() error {
+	return errNotSupported
+}
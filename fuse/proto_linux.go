@@ -0,0 +1,244 @@
+package fuse
+
+// This file defines the messages of the FUSE kernel protocol that FS uses, as described in
+// linux/fuse.h. Their layout must match that of the kernel.
+
+// The version of the protocol that Conn implements.
+const (
+	protoMajor = 7
+	protoMinor = 31
+)
+
+// opcodes.
+const (
+	opLookup      = 1
+	opForget      = 2
+	opGetattr     = 3
+	opSetattr     = 4
+	opMkdir       = 9
+	opUnlink      = 10
+	opRmdir       = 11
+	opRename      = 12
+	opOpen        = 14
+	opRead        = 15
+	opWrite       = 16
+	opStatfs      = 17
+	opRelease     = 18
+	opFsync       = 20
+	opFlush       = 25
+	opInit        = 26
+	opOpendir     = 27
+	opReaddir     = 28
+	opReleasedir  = 29
+	opFsyncdir    = 30
+	opCreate      = 35
+	opInterrupt   = 36
+	opDestroy     = 38
+	opBatchForget = 42
+	opRename2     = 45
+)
+
+// flags of initOut.
+const (
+	initAsyncRead     = 1 << 0
+	initAtomicOTrunc  = 1 << 3
+	initBigWrites     = 1 << 5
+	initMaxBackground = 16
+)
+
+// flags of setattrIn.Valid.
+const (
+	setattrSize = 1 << 3
+)
+
+// types of dirent.
+const (
+	direntDir = 4
+	direntReg = 8
+)
+
+type inHeader struct {
+	Len    uint32
+	Opcode uint32
+	Unique uint64
+	NodeID uint64
+	UID    uint32
+	GID    uint32
+	PID    uint32
+	_      uint32
+}
+
+const inHeaderSize = 40
+
+type outHeader struct {
+	Len    uint32
+	Error  int32
+	Unique uint64
+}
+
+const outHeaderSize = 16
+
+type attr struct {
+	Ino       uint64
+	Size      uint64
+	Blocks    uint64
+	Atime     uint64
+	Mtime     uint64
+	Ctime     uint64
+	AtimeNsec uint32
+	MtimeNsec uint32
+	CtimeNsec uint32
+	Mode      uint32
+	Nlink     uint32
+	UID       uint32
+	GID       uint32
+	Rdev      uint32
+	Blksize   uint32
+	Flags     uint32
+}
+
+type entryOut struct {
+	NodeID         uint64
+	Generation     uint64
+	EntryValid     uint64
+	AttrValid      uint64
+	EntryValidNsec uint32
+	AttrValidNsec  uint32
+	Attr           attr
+}
+
+type attrOut struct {
+	AttrValid     uint64
+	AttrValidNsec uint32
+	_             uint32
+	Attr          attr
+}
+
+type initIn struct {
+	Major        uint32
+	Minor        uint32
+	MaxReadahead uint32
+	Flags        uint32
+}
+
+type initOut struct {
+	Major               uint32
+	Minor               uint32
+	MaxReadahead        uint32
+	Flags               uint32
+	MaxBackground       uint16
+	CongestionThreshold uint16
+	MaxWrite            uint32
+	TimeGran            uint32
+	MaxPages            uint16
+	MapAlignment        uint16
+	Flags2              uint32
+	_                   [7]uint32
+}
+
+type setattrIn struct {
+	Valid     uint32
+	_         uint32
+	Fh        uint64
+	Size      uint64
+	LockOwner uint64
+	Atime     uint64
+	Mtime     uint64
+	Ctime     uint64
+	AtimeNsec uint32
+	MtimeNsec uint32
+	CtimeNsec uint32
+	Mode      uint32
+	_         uint32
+	UID       uint32
+	GID       uint32
+	_         uint32
+}
+
+type openIn struct {
+	Flags     uint32
+	OpenFlags uint32
+}
+
+type openOut struct {
+	Fh        uint64
+	OpenFlags uint32
+	_         uint32
+}
+
+type createIn struct {
+	Flags     uint32
+	Mode      uint32
+	Umask     uint32
+	OpenFlags uint32
+}
+
+const createInSize = 16
+
+type mkdirIn struct {
+	Mode  uint32
+	Umask uint32
+}
+
+const mkdirInSize = 8
+
+// readIn is also the layout of the requests to write and to read folders.
+type readIn struct {
+	Fh        uint64
+	Offset    uint64
+	Size      uint32
+	Flags     uint32
+	LockOwner uint64
+	OpenFlags uint32
+	_         uint32
+}
+
+const writeInSize = 40
+
+type writeOut struct {
+	Size uint32
+	_    uint32
+}
+
+type releaseIn struct {
+	Fh           uint64
+	Flags        uint32
+	ReleaseFlags uint32
+	LockOwner    uint64
+}
+
+type renameIn struct {
+	NewDir uint64
+}
+
+const renameInSize = 8
+
+type rename2In struct {
+	NewDir uint64
+	Flags  uint32
+	_      uint32
+}
+
+const rename2InSize = 16
+
+type statfsOut struct {
+	Blocks  uint64
+	Bfree   uint64
+	Bavail  uint64
+	Files   uint64
+	Ffree   uint64
+	Bsize   uint32
+	Namelen uint32
+	Frsize  uint32
+	_       uint32
+	_       [6]uint32
+}
+
+type dirent struct {
+	Ino     uint64
+	Off     uint64
+	Namelen uint32
+	Type    uint32
+}
+
+const direntSize = 24
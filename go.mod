@@ -3,13 +3,17 @@ module github.com/seborama/pcloud-sdk
 go 1.21
 
 require (
-	github.com/google/go-cmp v0.5.4
-	github.com/google/uuid v1.1.2
+	github.com/fsnotify/fsnotify v1.7.0
+	github.com/google/go-cmp v0.5.9
+	github.com/google/uuid v1.4.0
 	github.com/mattn/go-sqlite3 v1.14.22
 	github.com/pkg/errors v0.9.1
+	github.com/spf13/afero v1.11.0
 	github.com/stretchr/testify v1.8.1
 	github.com/urfave/cli/v2 v2.27.1
 	go.uber.org/zap v1.27.0
+	golang.org/x/net v0.20.0
+	golang.org/x/text v0.14.0
 )
 
 require (
@@ -20,6 +24,6 @@ require (
 	github.com/stretchr/objx v0.5.0 // indirect
 	github.com/xrash/smetrics v0.0.0-20240312152122-5f08fbb34913 // indirect
 	go.uber.org/multierr v1.11.0 // indirect
-	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1 // indirect
+	golang.org/x/sys v0.16.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,122 @@
+package pcloudfs
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// Afero is an afero.Fs over an FS, so that pCloud can be used where afero file systems are.
+// Unlike those of FS, its names are those of the os package: slash-separated, and either
+// absolute or relative to the root of the FS.
+type Afero struct {
+	fsys *FS
+}
+
+var (
+	_ afero.Fs   = (*Afero)(nil)
+	_ afero.File = (*File)(nil)
+)
+
+// NewAfero returns the afero.Fs of fsys.
+func NewAfero(fsys *FS) *Afero {
+	return &Afero{fsys: fsys}
+}
+
+// Name implements afero.Fs.
+func (a *Afero) Name() string {
+	return "pcloudfs"
+}
+
+// Create implements afero.Fs.
+func (a *Afero) Create(name string) (afero.File, error) {
+	return a.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o666)
+}
+
+// Open implements afero.Fs.
+func (a *Afero) Open(name string) (afero.File, error) {
+	return a.OpenFile(name, os.O_RDONLY, 0)
+}
+
+// OpenFile implements afero.Fs.
+func (a *Afero) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	f, err := a.fsys.OpenFile(fsName(name), flag, perm)
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Mkdir implements afero.Fs.
+func (a *Afero) Mkdir(name string, perm os.FileMode) error {
+	return a.fsys.Mkdir(fsName(name), perm)
+}
+
+// MkdirAll implements afero.Fs.
+func (a *Afero) MkdirAll(name string, perm os.FileMode) error {
+	return a.fsys.MkdirAll(fsName(name), perm)
+}
+
+// Remove implements afero.Fs.
+func (a *Afero) Remove(name string) error {
+	return a.fsys.Remove(fsName(name))
+}
+
+// RemoveAll implements afero.Fs.
+func (a *Afero) RemoveAll(name string) error {
+	return a.fsys.RemoveAll(fsName(name))
+}
+
+// Rename implements afero.Fs.
+func (a *Afero) Rename(oldname, newname string) error {
+	return a.fsys.Rename(fsName(oldname), fsName(newname))
+}
+
+// Stat implements afero.Fs.
+func (a *Afero) Stat(name string) (os.FileInfo, error) {
+	return a.fsys.Stat(fsName(name))
+}
+
+// Chmod implements afero.Fs. pCloud has no notion of permissions: it fails for the files that
+// exist.
+func (a *Afero) Chmod(name string, _ os.FileMode) error {
+	return a.unsupported("chmod", name)
+}
+
+// Chown implements afero.Fs. pCloud has no notion of ownership: it fails for the files that
+// exist.
+func (a *Afero) Chown(name string, _, _ int) error {
+	return a.unsupported("chown", name)
+}
+
+// Chtimes implements afero.Fs. pCloud does not change the times of the files once written: it
+// fails for the files that exist.
+func (a *Afero) Chtimes(name string, _, _ time.Time) error {
+	return a.unsupported("chtimes", name)
+}
+
+// unsupported returns the error of the operation op that pCloud does not support on name: that
+// of its Stat when it fails, such as fs.ErrNotExist, and errUnsupported otherwise.
+func (a *Afero) unsupported(op, name string) error {
+	_, err := a.fsys.stat(op, fsName(name))
+	if err != nil {
+		return err
+	}
+
+	return &fs.PathError{Op: op, Path: name, Err: errUnsupported}
+}
+
+// fsName returns the name in FS of the os name name.
+func fsName(name string) string {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if name == "" {
+		return "."
+	}
+
+	return name
+}
@@ -0,0 +1,62 @@
+package pcloudfs_test
+
+import (
+	"io/fs"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/pcloudfs"
+)
+
+func TestAfero(t *testing.T) {
+	srv, fsys := newTestFS(t)
+
+	var afs afero.Fs = pcloudfs.NewAfero(fsys)
+	assert.Equal(t, "pcloudfs", afs.Name())
+
+	// the names are those of the os package.
+	data, err := afero.ReadFile(afs, "/docs/readme.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "read me", string(data))
+
+	require.NoError(t, afs.Mkdir("/reports", 0o755))
+	require.NoError(t, afero.WriteFile(afs, "reports/2024.csv", []byte("a,b,c\n"), 0o644))
+
+	data, err = srv.ReadFile("/Site/reports/2024.csv")
+	require.NoError(t, err)
+	assert.Equal(t, "a,b,c\n", string(data))
+
+	require.NoError(t, afs.MkdirAll("/archive/2024", 0o755))
+	require.NoError(t, afs.Rename("/reports/2024.csv", "/archive/2024/report.csv"))
+
+	ok, err := afero.Exists(afs, "/reports/2024.csv")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	var walked []string
+	err = afero.Walk(afs, "/archive", func(p string, info os.FileInfo, err error) error {
+		walked = append(walked, p)
+		return err
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/archive", "/archive/2024", "/archive/2024/report.csv"}, walked)
+
+	f, err := afs.Open("/archive/2024/report.csv")
+	require.NoError(t, err)
+	assert.Equal(t, "archive/2024/report.csv", f.Name())
+	require.NoError(t, f.Close())
+
+	// pCloud has no permissions, ownership or times to change.
+	assert.ErrorContains(t, afs.Chmod("/archive/2024/report.csv", 0o600), "operation not supported")
+	assert.ErrorIs(t, afs.Chtimes("/missing.txt", time.Now(), time.Now()), fs.ErrNotExist)
+
+	require.NoError(t, afs.RemoveAll("/archive"))
+
+	_, err = afs.Stat("/archive")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
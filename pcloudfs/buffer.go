@@ -0,0 +1,150 @@
+package pcloudfs
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+// Option configures an FS.
+type Option func(*FS)
+
+// WithReadAhead makes the files read in chunks of 1MiB, and prefetch the chunks that follow the
+// one being read in the background, up to chunks of them, so that sequential reads do not wait
+// on a round trip to pCloud for every chunk. A value of 0, the default, disables read-ahead:
+// files are then read exactly as requested.
+func WithReadAhead(chunks int) Option {
+	return func(fsys *FS) {
+		fsys.readAhead = chunks
+	}
+}
+
+// WithWriteBack makes the files opened for writing coalesce the sequential writes made to them
+// into writes of up to size bytes, so that many small writes do not cost a call to pCloud each.
+// The buffered data is written when the file is read, seeked, synced or closed, and the errors
+// of these writes are returned then. Files opened with os.O_APPEND are not buffered. A size of
+// 0, the default, disables write-back.
+func WithWriteBack(size int) Option {
+	return func(fsys *FS) {
+		fsys.writeBack = size
+	}
+}
+
+// chunk is a chunk of a file read, or being read, by a readAhead.
+type chunk struct {
+	done chan struct{}
+	data []byte
+	err  error
+}
+
+// readAhead reads a file opened on pCloud as fd in chunks of readChunkSize, and prefetches the
+// chunks that follow the one read.
+type readAhead struct {
+	ctx    context.Context
+	pcc    pCloudSDK
+	fd     uint64
+	chunks int
+
+	lock   sync.Mutex
+	cached map[int64]*chunk // by index
+	// fetches tracks the chunks being read, including those dropped meanwhile.
+	fetches sync.WaitGroup
+}
+
+// newReadAhead returns the readAhead of the file opened as fd by fsys.
+func newReadAhead(fsys *FS, fd uint64) *readAhead {
+	return &readAhead{
+		ctx:    fsys.ctx,
+		pcc:    fsys.pcc,
+		fd:     fd,
+		chunks: fsys.readAhead,
+		cached: map[int64]*chunk{},
+	}
+}
+
+// ReadAt reads len(p) bytes at offset off, like io.ReaderAt. The errors are those of the SDK.
+func (ra *readAhead) ReadAt(p []byte, off int64) (int, error) {
+	var n int
+
+	for n < len(p) {
+		pos := off + int64(n)
+		index := pos / readChunkSize
+
+		c := ra.chunk(index)
+		<-c.done
+		if c.err != nil {
+			return n, c.err
+		}
+
+		start := int(pos % readChunkSize)
+		if start >= len(c.data) {
+			return n, io.EOF
+		}
+
+		n += copy(p[n:], c.data[start:])
+
+		if len(c.data) < readChunkSize && n < len(p) {
+			// the last chunk of the file.
+			return n, io.EOF
+		}
+	}
+
+	return n, nil
+}
+
+// chunk returns the chunk index, and starts to fetch those that follow it. The chunks before
+// index, and those too far ahead of it, are dropped.
+func (ra *readAhead) chunk(index int64) *chunk {
+	ra.lock.Lock()
+	defer ra.lock.Unlock()
+
+	for i := range ra.cached {
+		if i < index || i > index+int64(ra.chunks) {
+			delete(ra.cached, i)
+		}
+	}
+
+	for i := index; i <= index+int64(ra.chunks); i++ {
+		if _, ok := ra.cached[i]; !ok {
+			ra.cached[i] = ra.fetch(i)
+		}
+	}
+
+	return ra.cached[index]
+}
+
+// fetch starts to read the chunk index.
+func (ra *readAhead) fetch(index int64) *chunk {
+	c := &chunk{done: make(chan struct{})}
+
+	ra.fetches.Add(1)
+
+	go func() {
+		defer ra.fetches.Done()
+		defer close(c.done)
+
+		data, err := ra.pcc.FilePRead(ra.ctx, ra.fd, readChunkSize, uint64(index)*readChunkSize)
+		if err != nil {
+			c.err = err
+			return
+		}
+
+		// data is a pooled buffer: the chunk keeps a copy of it.
+		c.data = append([]byte(nil), data...)
+		sdk.PutBuffer(data)
+	}()
+
+	return c
+}
+
+// reset drops the chunks read, for instance when the file is written to. It waits for the
+// chunks being read, so that none is read after the file is closed.
+func (ra *readAhead) reset() {
+	ra.lock.Lock()
+	ra.cached = map[int64]*chunk{}
+	ra.lock.Unlock()
+
+	ra.fetches.Wait()
+}
@@ -0,0 +1,152 @@
+package pcloudfs_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/pcloudfs"
+	"github.com/seborama/pcloud-sdk/sdk"
+	"github.com/seborama/pcloud-sdk/sdk/sdktest"
+)
+
+// countingClient counts the calls to FilePRead and FileWrite.
+type countingClient struct {
+	*sdk.Client
+	preads atomic.Int32
+	writes atomic.Int32
+}
+
+func (c *countingClient) FilePRead(ctx context.Context, fd, count, offset uint64, opts ...sdk.ClientOption) ([]byte, error) {
+	c.preads.Add(1)
+	return c.Client.FilePRead(ctx, fd, count, offset, opts...)
+}
+
+func (c *countingClient) FileWrite(ctx context.Context, fd uint64, data []byte, opts ...sdk.ClientOption) (*sdk.FileDataTransfer, error) {
+	c.writes.Add(1)
+	return c.Client.FileWrite(ctx, fd, data, opts...)
+}
+
+func newCountingFS(t *testing.T, opts ...pcloudfs.Option) (*sdktest.Server, *countingClient, *pcloudfs.FS) {
+	t.Helper()
+
+	srv := sdktest.NewServer()
+	t.Cleanup(srv.Close)
+
+	pcc := &countingClient{Client: srv.NewClient()}
+
+	return srv, pcc, pcloudfs.New(pcc, "/", opts...)
+}
+
+func TestFS_ReadAhead(t *testing.T) {
+	_, fsys := newTestFS(t, pcloudfs.WithReadAhead(2))
+
+	err := fstest.TestFS(fsys, "index.html", "css/style.css", "img/logo.bin", "docs/readme.txt", "docs/guide/a.txt", "empty/.keep")
+	require.NoError(t, err)
+
+	srv, pcc, fsys := newCountingFS(t, pcloudfs.WithReadAhead(2))
+
+	data := bytes.Repeat([]byte("0123456789abcdef"), 300_000) // about 4.6MiB
+	_, err = srv.WriteFile("/large.bin", data)
+	require.NoError(t, err)
+
+	for _, open := range map[string]func() (io.ReadCloser, error){
+		"Open":     func() (io.ReadCloser, error) { return fsys.Open("large.bin") },
+		"OpenFile": func() (io.ReadCloser, error) { return fsys.OpenFile("large.bin", os.O_RDONLY, 0) },
+	} {
+		pcc.preads.Store(0)
+
+		f, err := open()
+		require.NoError(t, err)
+
+		got, err := io.ReadAll(f)
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+		assert.True(t, bytes.Equal(data, got))
+
+		// 5 chunks, and the 2 chunks prefetched past the end of the file at most.
+		assert.LessOrEqual(t, pcc.preads.Load(), int32(7))
+	}
+}
+
+func TestFS_WriteBack(t *testing.T) {
+	srv, pcc, fsys := newCountingFS(t, pcloudfs.WithWriteBack(64), pcloudfs.WithReadAhead(1))
+
+	f, err := fsys.Create("new.txt")
+	require.NoError(t, err)
+
+	var want []byte
+	for i := 0; i < 100; i++ {
+		n, err := f.WriteString("0123456789")
+		require.NoError(t, err)
+		assert.Equal(t, 10, n)
+		want = append(want, "0123456789"...)
+	}
+	assert.LessOrEqual(t, pcc.writes.Load(), int32(16))
+
+	// larger than the write-back size.
+	big := bytes.Repeat([]byte("x"), 100)
+	_, err = f.Write(big)
+	require.NoError(t, err)
+	want = append(want, big...)
+
+	// the buffered writes are read back.
+	_, err = f.Write([]byte("end"))
+	require.NoError(t, err)
+	want = append(want, "end"...)
+
+	got := make([]byte, len(want))
+	_, err = f.ReadAt(got, 0)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(got))
+
+	_, err = f.WriteAt([]byte("ABC"), 0)
+	require.NoError(t, err)
+	copy(want, "ABC")
+
+	_, err = f.Write([]byte("!"))
+	require.NoError(t, err)
+	want = append(want, '!')
+
+	info, err := f.Stat()
+	require.NoError(t, err)
+	assert.EqualValues(t, len(want), info.Size())
+
+	_, err = f.Write([]byte("?"))
+	require.NoError(t, err)
+	want = append(want, '?')
+
+	_, err = f.Seek(0, io.SeekStart)
+	require.NoError(t, err)
+	got, err = io.ReadAll(f)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(got))
+
+	_, err = f.WriteString("#")
+	require.NoError(t, err)
+	want = append(want, '#')
+
+	// the buffered writes are sent when the file is closed.
+	require.NoError(t, f.Close())
+
+	data, err := srv.ReadFile("/new.txt")
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(data))
+
+	// appends are not buffered.
+	pcc.writes.Store(0)
+
+	f, err = fsys.OpenFile("new.txt", os.O_WRONLY|os.O_APPEND, 0)
+	require.NoError(t, err)
+	_, err = f.WriteString("appended")
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, pcc.writes.Load())
+	require.NoError(t, f.Close())
+}
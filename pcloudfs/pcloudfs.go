@@ -0,0 +1,404 @@
+// Package pcloudfs exposes the contents of a pCloud account as an io/fs file system, so that
+// standard library consumers such as http.FileServer, template.ParseFS and fs.WalkDir can read
+// pCloud content directly.
+package pcloudfs
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+// pCloudSDK defines the SDK methods used to read and write the file system.
+type pCloudSDK interface {
+	List(ctx context.Context, folder sdk.FolderRef, o sdk.ListOptions, opts ...sdk.ClientOption) (*sdk.FSList, error)
+	CreateFolder(ctx context.Context, folder sdk.FolderRef, opts ...sdk.ClientOption) (*sdk.FSList, error)
+	CreateFolderIfNotExists(ctx context.Context, folder sdk.FolderRef, opts ...sdk.ClientOption) (*sdk.FSList, error)
+	DeleteFolder(ctx context.Context, folder sdk.FolderRef, opts ...sdk.ClientOption) (*sdk.FSList, error)
+	DeleteFolderRecursive(ctx context.Context, folder sdk.FolderRef, opts ...sdk.ClientOption) (*sdk.DeleteResult, error)
+	RenameFolder(ctx context.Context, folder sdk.FolderRef, toFolder sdk.FolderRef, opts ...sdk.ClientOption) (*sdk.FSList, error)
+	DeleteFile(ctx context.Context, file sdk.FileRef, opts ...sdk.ClientOption) (*sdk.FileResult, error)
+	RenameFile(ctx context.Context, file sdk.FileRef, destination sdk.FolderRef, opts ...sdk.ClientOption) (*sdk.FileResult, error)
+	FileOpen(ctx context.Context, flags uint64, file sdk.FileRef, opts ...sdk.ClientOption) (*sdk.File, error)
+	FilePRead(ctx context.Context, fd, count, offset uint64, opts ...sdk.ClientOption) ([]byte, error)
+	FileWrite(ctx context.Context, fd uint64, data []byte, opts ...sdk.ClientOption) (*sdk.FileDataTransfer, error)
+	FileSeek(ctx context.Context, fd, offset uint64, whenceOpt sdk.Whence, opts ...sdk.ClientOption) (*sdk.FileSeek, error)
+	FileClose(ctx context.Context, fd uint64, opts ...sdk.ClientOption) error
+}
+
+// readChunkSize is the size of the chunks ReadFile reads files by.
+const readChunkSize = 1 << 20
+
+// FS is a file system backed by a pCloud folder.
+// It implements fs.FS, fs.ReadDirFS, fs.StatFS and fs.ReadFileFS, which are read-only, and
+// provides the write operations of os: Create, OpenFile, Mkdir, MkdirAll, Remove, RemoveAll and
+// Rename. NewAfero adapts it to afero.Fs.
+// The files it opens implement io.Seeker and io.ReaderAt, as http.FileServer expects.
+type FS struct {
+	ctx       context.Context
+	pcc       pCloudSDK
+	root      string
+	readAhead int
+	writeBack int
+}
+
+var (
+	_ fs.ReadDirFS  = (*FS)(nil)
+	_ fs.StatFS     = (*FS)(nil)
+	_ fs.ReadFileFS = (*FS)(nil)
+)
+
+// New creates an FS for the pCloud folder at path root. The names of the file system are
+// relative to root.
+func New(pcc pCloudSDK, root string, opts ...Option) *FS {
+	fsys := &FS{
+		ctx:  context.Background(),
+		pcc:  pcc,
+		root: path.Clean("/" + root),
+	}
+
+	for _, opt := range opts {
+		opt(fsys)
+	}
+
+	return fsys
+}
+
+// WithContext returns a copy of fsys that makes its calls to pCloud with ctx. This lets the
+// callers of fs.FS, which has no notion of context, cancel calls or give them a deadline.
+func (fsys *FS) WithContext(ctx context.Context) *FS {
+	fsys2 := *fsys
+	fsys2.ctx = ctx
+	return &fsys2
+}
+
+// Open implements fs.FS.
+func (fsys *FS) Open(name string) (fs.File, error) {
+	info, err := fsys.stat("open", name)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		return &dir{fsys: fsys, name: name, info: info}, nil
+	}
+
+	return &file{fsys: fsys, name: name, info: info}, nil
+}
+
+// Stat implements fs.StatFS.
+func (fsys *FS) Stat(name string) (fs.FileInfo, error) {
+	return fsys.stat("stat", name)
+}
+
+// stat returns the information about the file or folder name. The folder that holds it is
+// listed because pCloud can only stat files.
+func (fsys *FS) stat(op, name string) (*fileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	if name == "." {
+		lf, err := fsys.pcc.List(fsys.ctx, sdk.ByPath(fsys.root), sdk.ListOptions{NoFiles: true})
+		if err != nil {
+			return nil, pathError(op, name, err)
+		}
+		lf.Metadata.Contents = nil
+		return &fileInfo{m: lf.Metadata, name: "."}, nil
+	}
+
+	dir, base := path.Split(name)
+
+	lf, err := fsys.pcc.List(fsys.ctx, sdk.ByPath(fsys.fullPath(dir)), sdk.ListOptions{})
+	if err != nil {
+		return nil, pathError(op, name, err)
+	}
+
+	for _, m := range lf.Metadata.Contents {
+		if m.Name == base {
+			return &fileInfo{m: m, name: base}, nil
+		}
+	}
+
+	return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+}
+
+// ReadDir implements fs.ReadDirFS.
+func (fsys *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+
+	lf, err := fsys.pcc.List(fsys.ctx, sdk.ByPath(fsys.fullPath(name)), sdk.ListOptions{})
+	if err != nil {
+		return nil, pathError("readdir", name, err)
+	}
+
+	entries := make([]fs.DirEntry, 0, len(lf.Metadata.Contents))
+	for _, m := range lf.Metadata.Contents {
+		entries = append(entries, fs.FileInfoToDirEntry(&fileInfo{m: m, name: m.Name}))
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	return entries, nil
+}
+
+// ReadFile implements fs.ReadFileFS.
+func (fsys *FS) ReadFile(name string) ([]byte, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	if _, ok := f.(*dir); ok {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: errIsDir}
+	}
+
+	return io.ReadAll(f)
+}
+
+// fullPath returns the path on pCloud of name.
+func (fsys *FS) fullPath(name string) string {
+	return path.Join(fsys.root, name)
+}
+
+// errIsDir is returned when reading the contents of a folder as a file.
+var errIsDir = errors.New("is a directory")
+
+// pathError converts err, returned by the SDK, to an *fs.PathError. The pCloud errors that
+// indicate that a file or folder does not exist, or that it cannot be accessed, are converted
+// to fs.ErrNotExist and fs.ErrPermission, and those that indicate that it already exists to
+// fs.ErrExist.
+func pathError(op, name string, err error) error {
+	switch sdk.ErrorCode(err) {
+	case sdk.ErrFileNotFound, sdk.ErrDirectoryNotExists, sdk.ErrComponentOfParentDirectoryNotExists:
+		err = fs.ErrNotExist
+	case sdk.ErrFileOrFolderAlreadyExists:
+		err = fs.ErrExist
+	case sdk.ErrAccessDenied:
+		err = fs.ErrPermission
+	}
+
+	return &fs.PathError{Op: op, Path: name, Err: err}
+}
+
+// fileInfo implements fs.FileInfo over the metadata of a file or folder.
+type fileInfo struct {
+	m    *sdk.Metadata
+	name string
+}
+
+// Name implements fs.FileInfo.
+func (fi *fileInfo) Name() string { return fi.name }
+
+// Size implements fs.FileInfo.
+func (fi *fileInfo) Size() int64 { return int64(fi.m.Size) }
+
+// Mode implements fs.FileInfo. pCloud has no notion of permissions: all files and folders are
+// readable and writable.
+func (fi *fileInfo) Mode() fs.FileMode {
+	if fi.m.IsFolder {
+		return fs.ModeDir | 0755
+	}
+	return 0644
+}
+
+// ModTime implements fs.FileInfo.
+func (fi *fileInfo) ModTime() time.Time {
+	return fi.m.Modified
+}
+
+// IsDir implements fs.FileInfo.
+func (fi *fileInfo) IsDir() bool { return fi.m.IsFolder }
+
+// Sys implements fs.FileInfo. It returns the *sdk.Metadata of the file or folder.
+func (fi *fileInfo) Sys() any { return fi.m }
+
+// file is a file opened by FS. It is opened on pCloud on its first read.
+type file struct {
+	fsys *FS
+	name string
+	info *fileInfo
+
+	fd     uint64
+	opened bool
+	offset int64
+
+	// ra is set when the file is read ahead.
+	ra *readAhead
+}
+
+// Stat implements fs.File.
+func (f *file) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+// Read implements fs.File.
+func (f *file) Read(p []byte) (int, error) {
+	n, err := f.ReadAt(p, f.offset)
+	f.offset += int64(n)
+	return n, err
+}
+
+// ReadAt implements io.ReaderAt.
+func (f *file) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, &fs.PathError{Op: "read", Path: f.name, Err: fs.ErrInvalid}
+	}
+
+	if off >= f.info.Size() {
+		return 0, io.EOF
+	}
+
+	if !f.opened {
+		pf, err := f.fsys.pcc.FileOpen(f.fsys.ctx, 0, sdk.ByID(f.info.m.FileID))
+		if err != nil {
+			return 0, pathError("read", f.name, err)
+		}
+		f.fd = pf.FD
+		f.opened = true
+
+		if f.fsys.readAhead > 0 {
+			f.ra = newReadAhead(f.fsys, f.fd)
+		}
+	}
+
+	if f.ra != nil {
+		n, err := f.ra.ReadAt(p, off)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return n, pathError("read", f.name, err)
+		}
+		return n, err
+	}
+
+	var n int
+	for n < len(p) && off+int64(n) < f.info.Size() {
+		count := len(p) - n
+		if count > readChunkSize {
+			count = readChunkSize
+		}
+
+		data, err := f.fsys.pcc.FilePRead(f.fsys.ctx, f.fd, uint64(count), uint64(off)+uint64(n))
+		if err != nil {
+			return n, pathError("read", f.name, err)
+		}
+		if len(data) == 0 {
+			break
+		}
+
+		n += copy(p[n:], data)
+		sdk.PutBuffer(data)
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+
+	return n, nil
+}
+
+// Seek implements io.Seeker.
+func (f *file) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += f.info.Size()
+	default:
+		return 0, &fs.PathError{Op: "seek", Path: f.name, Err: fs.ErrInvalid}
+	}
+
+	if offset < 0 {
+		return 0, &fs.PathError{Op: "seek", Path: f.name, Err: fs.ErrInvalid}
+	}
+
+	f.offset = offset
+
+	return offset, nil
+}
+
+// Close implements fs.File.
+func (f *file) Close() error {
+	if !f.opened {
+		return nil
+	}
+
+	f.opened = false
+
+	if f.ra != nil {
+		f.ra.reset()
+	}
+
+	err := f.fsys.pcc.FileClose(f.fsys.ctx, f.fd)
+	if err != nil {
+		return pathError("close", f.name, err)
+	}
+
+	return nil
+}
+
+// dir is a folder opened by FS. It is listed on its first ReadDir.
+type dir struct {
+	fsys *FS
+	name string
+	info *fileInfo
+
+	entries []fs.DirEntry
+	listed  bool
+}
+
+// Stat implements fs.File.
+func (d *dir) Stat() (fs.FileInfo, error) {
+	return d.info, nil
+}
+
+// Read implements fs.File.
+func (d *dir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: errIsDir}
+}
+
+// ReadDir implements fs.ReadDirFile.
+func (d *dir) ReadDir(count int) ([]fs.DirEntry, error) {
+	if !d.listed {
+		entries, err := d.fsys.ReadDir(d.name)
+		if err != nil {
+			return nil, err
+		}
+		d.entries = entries
+		d.listed = true
+	}
+
+	if count <= 0 {
+		entries := d.entries
+		d.entries = nil
+		return entries, nil
+	}
+
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+
+	if count > len(d.entries) {
+		count = len(d.entries)
+	}
+
+	entries := d.entries[:count]
+	d.entries = d.entries[count:]
+
+	return entries, nil
+}
+
+// Close implements fs.File.
+func (d *dir) Close() error {
+	return nil
+}
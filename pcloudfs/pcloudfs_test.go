@@ -0,0 +1,125 @@
+package pcloudfs_test
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/pcloudfs"
+	"github.com/seborama/pcloud-sdk/sdk/sdktest"
+)
+
+func newTestFS(t *testing.T, opts ...pcloudfs.Option) (*sdktest.Server, *pcloudfs.FS) {
+	t.Helper()
+
+	srv := sdktest.NewServer()
+	t.Cleanup(srv.Close)
+
+	for p, data := range map[string][]byte{
+		"/Site/index.html":       []byte("<h1>Hello</h1>"),
+		"/Site/css/style.css":    []byte("h1 { color: red; }"),
+		"/Site/img/logo.bin":     bytes.Repeat([]byte("0123456789"), 10),
+		"/Site/empty/.keep":      nil,
+		"/Elsewhere/secret.txt":  []byte("not served"),
+		"/Site/docs/readme.txt":  []byte("read me"),
+		"/Site/docs/guide/a.txt": []byte("a"),
+	} {
+		_, err := srv.WriteFile(p, data)
+		require.NoError(t, err)
+	}
+
+	return srv, pcloudfs.New(srv.NewClient(), "/Site", opts...)
+}
+
+func TestFS(t *testing.T) {
+	_, fsys := newTestFS(t)
+
+	err := fstest.TestFS(fsys, "index.html", "css/style.css", "img/logo.bin", "docs/readme.txt", "docs/guide/a.txt", "empty/.keep")
+	require.NoError(t, err)
+}
+
+func TestFS_Errors(t *testing.T) {
+	_, fsys := newTestFS(t)
+
+	_, err := fsys.Open("missing.txt")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	_, err = fsys.Stat("missing/a.txt")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	_, err = fsys.ReadDir("index.html")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	_, err = fsys.Open("../Elsewhere/secret.txt")
+	assert.ErrorIs(t, err, fs.ErrInvalid)
+
+	_, err = fsys.ReadFile("docs")
+	assert.Error(t, err)
+
+	data, err := fsys.ReadFile("docs/readme.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "read me", string(data))
+}
+
+func TestFS_WalkDir(t *testing.T) {
+	_, fsys := newTestFS(t)
+
+	var files []string
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			files = append(files, p)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"css/style.css", "docs/guide/a.txt", "docs/readme.txt", "empty/.keep", "img/logo.bin", "index.html"}, files)
+}
+
+func TestFS_HTTPFileServer(t *testing.T) {
+	_, fsys := newTestFS(t)
+
+	web := httptest.NewServer(http.FileServer(http.FS(fsys)))
+	defer web.Close()
+
+	resp, err := http.Get(web.URL + "/")
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "<h1>Hello</h1>", string(body))
+
+	req, err := http.NewRequest(http.MethodGet, web.URL+"/img/logo.bin", nil)
+	require.NoError(t, err)
+	req.Header.Set("Range", "bytes=10-19")
+
+	resp2, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer func() { _ = resp2.Body.Close() }()
+	body, err = io.ReadAll(resp2.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusPartialContent, resp2.StatusCode)
+	assert.Equal(t, "0123456789", string(body))
+}
+
+func TestFS_ReadFile_Large(t *testing.T) {
+	srv, fsys := newTestFS(t)
+
+	data := bytes.Repeat([]byte("0123456789"), 300_000)
+	_, err := srv.WriteFile("/Site/big.bin", data)
+	require.NoError(t, err)
+
+	got, err := fsys.ReadFile("big.bin")
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+}
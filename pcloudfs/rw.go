@@ -0,0 +1,602 @@
+package pcloudfs
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+// The methods in this file make FS writable. Their signatures follow those of os (see Afero for
+// the afero.Fs of FS).
+
+// Create creates or truncates the file name, and opens it for reading and writing, like
+// os.Create.
+func (fsys *FS) Create(name string) (*File, error) {
+	return fsys.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+}
+
+// OpenFile opens the file name with the flags of os.OpenFile (os.O_RDONLY, os.O_CREATE, etc).
+// perm is ignored: pCloud has no notion of permissions.
+// Folders can only be opened read-only, to list their contents with File.ReadDir.
+func (fsys *FS) OpenFile(name string, flag int, _ fs.FileMode) (*File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND) == 0 {
+		info, err := fsys.stat("open", name)
+		if err != nil {
+			return nil, err
+		}
+
+		if info.IsDir() {
+			return &File{fsys: fsys, name: name, flag: flag, dir: &dir{fsys: fsys, name: name, info: info}}, nil
+		}
+
+		pf, err := fsys.pcc.FileOpen(fsys.ctx, 0, sdk.ByID(info.m.FileID))
+		if err != nil {
+			return nil, pathError("open", name, err)
+		}
+
+		return newFile(fsys, name, flag, pf.FD), nil
+	}
+
+	var flags uint64
+	if flag&os.O_CREATE != 0 {
+		flags |= sdk.O_CREAT
+	}
+	if flag&os.O_EXCL != 0 {
+		flags |= sdk.O_EXCL
+	}
+	if flag&os.O_TRUNC != 0 {
+		flags |= sdk.O_TRUNC
+	}
+	if flag&os.O_APPEND != 0 {
+		flags |= sdk.O_APPEND
+	}
+
+	pf, err := fsys.pcc.FileOpen(fsys.ctx, flags, sdk.ByPath(fsys.fullPath(name)))
+	if err != nil {
+		return nil, pathError("open", name, err)
+	}
+
+	return newFile(fsys, name, flag, pf.FD), nil
+}
+
+// Mkdir creates the folder name. perm is ignored.
+func (fsys *FS) Mkdir(name string, _ fs.FileMode) error {
+	if !fs.ValidPath(name) || name == "." {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: fs.ErrInvalid}
+	}
+
+	_, err := fsys.pcc.CreateFolder(fsys.ctx, sdk.ByPath(fsys.fullPath(name)))
+	if err != nil {
+		return pathError("mkdir", name, err)
+	}
+
+	return nil
+}
+
+// MkdirAll creates the folder name, along with any necessary parents, like os.MkdirAll.
+// perm is ignored.
+func (fsys *FS) MkdirAll(name string, _ fs.FileMode) error {
+	if !fs.ValidPath(name) {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: fs.ErrInvalid}
+	}
+
+	if name == "." {
+		return nil
+	}
+
+	var p string
+	for _, elem := range strings.Split(name, "/") {
+		p = path.Join(p, elem)
+
+		lf, err := fsys.pcc.CreateFolderIfNotExists(fsys.ctx, sdk.ByPath(fsys.fullPath(p)))
+		if err != nil {
+			return pathError("mkdir", p, err)
+		}
+
+		if !lf.Metadata.IsFolder {
+			return &fs.PathError{Op: "mkdir", Path: p, Err: errNotDir}
+		}
+	}
+
+	return nil
+}
+
+// Remove removes the file or the empty folder name.
+func (fsys *FS) Remove(name string) error {
+	if name == "." {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrInvalid}
+	}
+
+	info, err := fsys.stat("remove", name)
+	if err != nil {
+		return err
+	}
+
+	if info.IsDir() {
+		_, err = fsys.pcc.DeleteFolder(fsys.ctx, sdk.ByID(info.m.FolderID))
+	} else {
+		_, err = fsys.pcc.DeleteFile(fsys.ctx, sdk.ByID(info.m.FileID))
+	}
+	if err != nil {
+		return pathError("remove", name, err)
+	}
+
+	return nil
+}
+
+// RemoveAll removes name and any children it contains, like os.RemoveAll.
+// It returns nil when name does not exist.
+func (fsys *FS) RemoveAll(name string) error {
+	if name == "." {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrInvalid}
+	}
+
+	info, err := fsys.stat("remove", name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if info.IsDir() {
+		_, err = fsys.pcc.DeleteFolderRecursive(fsys.ctx, sdk.ByID(info.m.FolderID))
+	} else {
+		_, err = fsys.pcc.DeleteFile(fsys.ctx, sdk.ByID(info.m.FileID))
+	}
+	if err != nil {
+		return pathError("remove", name, err)
+	}
+
+	return nil
+}
+
+// Rename renames (moves) the file or folder oldname to newname. As with os.Rename, a file
+// replaces the file newname when it exists.
+func (fsys *FS) Rename(oldname, newname string) error {
+	if oldname == "." || !fs.ValidPath(newname) || newname == "." {
+		return linkError(oldname, newname, fs.ErrInvalid)
+	}
+
+	info, err := fsys.stat("rename", oldname)
+	if err != nil {
+		return linkError(oldname, newname, err)
+	}
+
+	if info.IsDir() {
+		_, err = fsys.pcc.RenameFolder(fsys.ctx, sdk.ByID(info.m.FolderID), sdk.ByPath(fsys.fullPath(newname)))
+	} else {
+		_, err = fsys.pcc.RenameFile(fsys.ctx, sdk.ByID(info.m.FileID), sdk.ByPath(fsys.fullPath(newname)))
+	}
+	if err != nil {
+		return linkError(oldname, newname, pathError("rename", newname, err))
+	}
+
+	return nil
+}
+
+// linkError returns the *os.LinkError of a Rename that failed with err.
+func linkError(oldname, newname string, err error) error {
+	var pe *fs.PathError
+	if errors.As(err, &pe) {
+		err = pe.Err
+	}
+
+	return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: err}
+}
+
+var (
+	// errNotDir is returned when a folder is expected but a file is found.
+	errNotDir = errors.New("not a directory")
+
+	// errUnsupported is returned by the operations that pCloud does not support.
+	errUnsupported = errors.New("operation not supported")
+)
+
+// File is a file or folder opened by FS.OpenFile. Its methods follow those of os.File.
+// Writes are sent to pCloud as they are made, unless FS buffers them (see WithWriteBack).
+type File struct {
+	fsys *FS
+	name string
+	flag int
+
+	fd     uint64
+	offset int64
+	closed bool
+	// pos is the offset of the file descriptor on pCloud, which reads do not move.
+	pos int64
+
+	// ra is set when the file is read ahead.
+	ra *readAhead
+	// wb holds the data written but not yet sent to pCloud, from offset wbOff.
+	wb    []byte
+	wbOff int64
+
+	// dir is set when the File is a folder.
+	dir *dir
+}
+
+// newFile returns the File of the file name, opened on pCloud as fd with the flags flag.
+func newFile(fsys *FS, name string, flag int, fd uint64) *File {
+	f := &File{fsys: fsys, name: name, flag: flag, fd: fd}
+
+	if fsys.readAhead > 0 && flag&(os.O_RDONLY|os.O_WRONLY|os.O_RDWR) != os.O_WRONLY {
+		f.ra = newReadAhead(fsys, fd)
+	}
+
+	return f
+}
+
+// Name returns the name of the file, as passed to FS.OpenFile.
+func (f *File) Name() string {
+	return f.name
+}
+
+// Stat returns the information about the file.
+func (f *File) Stat() (fs.FileInfo, error) {
+	if f.dir != nil {
+		return f.dir.info, nil
+	}
+
+	if !f.closed {
+		// the size of the file includes the buffered writes.
+		err := f.flush("stat")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return f.fsys.Stat(f.name)
+}
+
+// Read reads up to len(p) bytes at the current offset.
+func (f *File) Read(p []byte) (int, error) {
+	n, err := f.ReadAt(p, f.offset)
+	f.offset += int64(n)
+	return n, err
+}
+
+// ReadAt reads len(p) bytes at offset off.
+func (f *File) ReadAt(p []byte, off int64) (int, error) {
+	err := f.check("read", os.O_WRONLY)
+	if err != nil {
+		return 0, err
+	}
+
+	if off < 0 {
+		return 0, &fs.PathError{Op: "read", Path: f.name, Err: fs.ErrInvalid}
+	}
+
+	err = f.flush("read")
+	if err != nil {
+		return 0, err
+	}
+
+	if f.ra != nil {
+		n, err := f.ra.ReadAt(p, off)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return n, pathError("read", f.name, err)
+		}
+		return n, err
+	}
+
+	var n int
+	for n < len(p) {
+		count := len(p) - n
+		if count > readChunkSize {
+			count = readChunkSize
+		}
+
+		data, err := f.fsys.pcc.FilePRead(f.fsys.ctx, f.fd, uint64(count), uint64(off)+uint64(n))
+		if err != nil {
+			return n, pathError("read", f.name, err)
+		}
+		if len(data) == 0 {
+			break
+		}
+
+		n += copy(p[n:], data)
+		sdk.PutBuffer(data)
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+
+	return n, nil
+}
+
+// Write writes p at the current offset, or at the end of the file when it was opened with
+// os.O_APPEND.
+func (f *File) Write(p []byte) (int, error) {
+	err := f.check("write", os.O_RDONLY)
+	if err != nil {
+		return 0, err
+	}
+
+	if f.fsys.writeBack > 0 && f.flag&os.O_APPEND == 0 {
+		return f.writeBack(p)
+	}
+
+	if f.ra != nil {
+		f.ra.reset()
+	}
+
+	if f.flag&os.O_APPEND == 0 {
+		err = f.seek("write", f.offset)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	fdt, err := f.fsys.pcc.FileWrite(f.fsys.ctx, f.fd, p)
+	if err != nil {
+		return 0, pathError("write", f.name, err)
+	}
+
+	if f.flag&os.O_APPEND != 0 {
+		// the offset of the file descriptor moved to the end of the file.
+		sk, err := f.fsys.pcc.FileSeek(f.fsys.ctx, f.fd, 0, sdk.WhenceFromCurrent)
+		if err != nil {
+			return int(fdt.Bytes), pathError("write", f.name, err)
+		}
+		f.offset = int64(sk.Offset)
+	} else {
+		f.offset += int64(fdt.Bytes)
+	}
+	f.pos = f.offset
+
+	if int(fdt.Bytes) < len(p) {
+		return int(fdt.Bytes), &fs.PathError{Op: "write", Path: f.name, Err: io.ErrShortWrite}
+	}
+
+	return len(p), nil
+}
+
+// writeBack buffers p, and sends the buffered data to pCloud when it exceeds the write-back
+// size.
+func (f *File) writeBack(p []byte) (int, error) {
+	if len(f.wb)+len(p) > f.fsys.writeBack {
+		err := f.flush("write")
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if len(f.wb) == 0 {
+		f.wbOff = f.offset
+	}
+
+	if len(p) >= f.fsys.writeBack {
+		// too large to be coalesced.
+		f.wb = p
+		err := f.flush("write")
+		if err != nil {
+			return 0, err
+		}
+	} else {
+		f.wb = append(f.wb, p...)
+	}
+
+	f.offset += int64(len(p))
+
+	return len(p), nil
+}
+
+// flush sends the data buffered by writeBack to pCloud. op is the operation that flushes it.
+func (f *File) flush(op string) error {
+	if len(f.wb) == 0 {
+		return nil
+	}
+
+	if f.ra != nil {
+		f.ra.reset()
+	}
+
+	wb := f.wb
+	f.wb = f.wb[:0:0]
+
+	err := f.seek(op, f.wbOff)
+	if err != nil {
+		return err
+	}
+
+	fdt, err := f.fsys.pcc.FileWrite(f.fsys.ctx, f.fd, wb)
+	if err != nil {
+		return pathError(op, f.name, err)
+	}
+	f.pos += int64(fdt.Bytes)
+
+	if int(fdt.Bytes) < len(wb) {
+		return &fs.PathError{Op: op, Path: f.name, Err: io.ErrShortWrite}
+	}
+
+	return nil
+}
+
+// WriteAt writes p at offset off. The current offset is left unchanged.
+// As with os.File, WriteAt fails when the file was opened with os.O_APPEND.
+func (f *File) WriteAt(p []byte, off int64) (int, error) {
+	if f.flag&os.O_APPEND != 0 {
+		return 0, &fs.PathError{Op: "writeat", Path: f.name, Err: errors.New("invalid use of WriteAt on file opened with O_APPEND")}
+	}
+
+	if off < 0 {
+		return 0, &fs.PathError{Op: "writeat", Path: f.name, Err: fs.ErrInvalid}
+	}
+
+	offset := f.offset
+
+	_, err := f.Seek(off, io.SeekStart)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := f.Write(p)
+
+	_, seekErr := f.Seek(offset, io.SeekStart)
+	if err == nil {
+		err = seekErr
+	}
+
+	return n, err
+}
+
+// WriteString is like Write, but writes the contents of s.
+func (f *File) WriteString(s string) (int, error) {
+	return f.Write([]byte(s))
+}
+
+// Seek sets the offset for the next Read or Write, like os.File.Seek.
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	err := f.check("seek", -1)
+	if err != nil {
+		return 0, err
+	}
+
+	err = f.flush("seek")
+	if err != nil {
+		return 0, err
+	}
+
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		sk, err := f.fsys.pcc.FileSeek(f.fsys.ctx, f.fd, 0, sdk.WhenceFromEnd)
+		if err != nil {
+			return 0, pathError("seek", f.name, err)
+		}
+		f.pos = int64(sk.Offset)
+		offset += f.pos
+	default:
+		return 0, &fs.PathError{Op: "seek", Path: f.name, Err: fs.ErrInvalid}
+	}
+
+	if offset < 0 {
+		return 0, &fs.PathError{Op: "seek", Path: f.name, Err: fs.ErrInvalid}
+	}
+
+	f.offset = offset
+
+	return offset, nil
+}
+
+// seek moves the file descriptor on pCloud to offset, unless it is there already.
+func (f *File) seek(op string, offset int64) error {
+	if f.pos == offset {
+		return nil
+	}
+
+	_, err := f.fsys.pcc.FileSeek(f.fsys.ctx, f.fd, uint64(offset), sdk.WhenceFromBeginning)
+	if err != nil {
+		return pathError(op, f.name, err)
+	}
+
+	f.pos = offset
+
+	return nil
+}
+
+// Sync sends the writes buffered by the file to pCloud. It does nothing otherwise: writes are
+// sent to pCloud as they are made.
+func (f *File) Sync() error {
+	err := f.check("sync", -1)
+	if err != nil {
+		return err
+	}
+
+	return f.flush("sync")
+}
+
+// Truncate is not supported by pCloud, other than when opening a file with os.O_TRUNC.
+func (f *File) Truncate(int64) error {
+	return &fs.PathError{Op: "truncate", Path: f.name, Err: errUnsupported}
+}
+
+// ReadDir reads the contents of the folder, like os.File.ReadDir.
+func (f *File) ReadDir(count int) ([]fs.DirEntry, error) {
+	if f.dir == nil {
+		return nil, &fs.PathError{Op: "readdir", Path: f.name, Err: errNotDir}
+	}
+	return f.dir.ReadDir(count)
+}
+
+// Readdir reads the contents of the folder, like os.File.Readdir.
+func (f *File) Readdir(count int) ([]fs.FileInfo, error) {
+	entries, err := f.ReadDir(count)
+
+	infos := make([]fs.FileInfo, 0, len(entries))
+	for _, e := range entries {
+		info, _ := e.Info()
+		infos = append(infos, info)
+	}
+
+	return infos, err
+}
+
+// Readdirnames reads the names of the contents of the folder, like os.File.Readdirnames.
+func (f *File) Readdirnames(count int) ([]string, error) {
+	entries, err := f.ReadDir(count)
+
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+
+	return names, err
+}
+
+// Close sends the writes buffered by the file to pCloud, and closes the file on pCloud.
+func (f *File) Close() error {
+	if f.closed {
+		return &fs.PathError{Op: "close", Path: f.name, Err: fs.ErrClosed}
+	}
+
+	f.closed = true
+
+	if f.dir != nil {
+		return nil
+	}
+
+	err := f.flush("close")
+
+	if f.ra != nil {
+		f.ra.reset()
+	}
+
+	closeErr := f.fsys.pcc.FileClose(f.fsys.ctx, f.fd)
+	if err != nil {
+		return err
+	}
+	if closeErr != nil {
+		return pathError("close", f.name, closeErr)
+	}
+
+	return nil
+}
+
+// check returns an error if the file cannot be used for op: when it is closed, when it is a
+// folder, or when it was opened with the access mode deniedMode.
+func (f *File) check(op string, deniedMode int) error {
+	switch {
+	case f.closed:
+		return &fs.PathError{Op: op, Path: f.name, Err: fs.ErrClosed}
+	case f.dir != nil:
+		return &fs.PathError{Op: op, Path: f.name, Err: errIsDir}
+	case f.flag&(os.O_RDONLY|os.O_WRONLY|os.O_RDWR) == deniedMode:
+		return &fs.PathError{Op: op, Path: f.name, Err: fs.ErrPermission}
+	}
+	return nil
+}
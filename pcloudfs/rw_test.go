@@ -0,0 +1,175 @@
+package pcloudfs_test
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFS_Create(t *testing.T) {
+	srv, fsys := newTestFS(t)
+
+	f, err := fsys.Create("new.txt")
+	require.NoError(t, err)
+
+	n, err := f.WriteString("hello world")
+	require.NoError(t, err)
+	assert.Equal(t, 11, n)
+
+	_, err = f.WriteAt([]byte("W"), 6)
+	require.NoError(t, err)
+
+	_, err = f.Write([]byte("!"))
+	require.NoError(t, err)
+
+	pos, err := f.Seek(0, io.SeekStart)
+	require.NoError(t, err)
+	assert.EqualValues(t, 0, pos)
+
+	data, err := io.ReadAll(f)
+	require.NoError(t, err)
+	assert.Equal(t, "hello World!", string(data))
+
+	pos, err = f.Seek(-1, io.SeekEnd)
+	require.NoError(t, err)
+	assert.EqualValues(t, 11, pos)
+
+	require.NoError(t, f.Sync())
+	require.NoError(t, f.Close())
+	assert.ErrorIs(t, f.Close(), fs.ErrClosed)
+
+	data, err = srv.ReadFile("/Site/new.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "hello World!", string(data))
+
+	// Create truncates existing files.
+	f, err = fsys.Create("new.txt")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	data, err = srv.ReadFile("/Site/new.txt")
+	require.NoError(t, err)
+	assert.Empty(t, data)
+}
+
+func TestFS_OpenFile(t *testing.T) {
+	srv, fsys := newTestFS(t)
+
+	f, err := fsys.OpenFile("index.html", os.O_WRONLY|os.O_APPEND, 0)
+	require.NoError(t, err)
+
+	_, err = f.WriteString("<p>World</p>")
+	require.NoError(t, err)
+
+	_, err = f.Read(make([]byte, 1))
+	assert.ErrorIs(t, err, fs.ErrPermission)
+
+	_, err = f.WriteAt([]byte("x"), 0)
+	assert.Error(t, err)
+
+	require.NoError(t, f.Close())
+
+	data, err := srv.ReadFile("/Site/index.html")
+	require.NoError(t, err)
+	assert.Equal(t, "<h1>Hello</h1><p>World</p>", string(data))
+
+	_, err = fsys.OpenFile("index.html", os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0)
+	assert.ErrorIs(t, err, fs.ErrExist)
+
+	_, err = fsys.OpenFile("missing.txt", os.O_WRONLY, 0)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	f, err = fsys.OpenFile("index.html", os.O_RDONLY, 0)
+	require.NoError(t, err)
+
+	_, err = f.WriteString("x")
+	assert.ErrorIs(t, err, fs.ErrPermission)
+
+	data, err = io.ReadAll(f)
+	require.NoError(t, err)
+	assert.Equal(t, "<h1>Hello</h1><p>World</p>", string(data))
+	require.NoError(t, f.Close())
+
+	d, err := fsys.OpenFile("docs", os.O_RDONLY, 0)
+	require.NoError(t, err)
+
+	names, err := d.Readdirnames(-1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"guide", "readme.txt"}, names)
+
+	_, err = d.Read(make([]byte, 1))
+	assert.Error(t, err)
+	require.NoError(t, d.Close())
+}
+
+func TestFS_Mkdir(t *testing.T) {
+	srv, fsys := newTestFS(t)
+
+	require.NoError(t, fsys.Mkdir("new", 0755))
+	assert.ErrorIs(t, fsys.Mkdir("new", 0755), fs.ErrExist)
+	assert.ErrorIs(t, fsys.Mkdir("a/b", 0755), fs.ErrNotExist)
+
+	require.NoError(t, fsys.MkdirAll("a/b/c", 0755))
+	require.NoError(t, fsys.MkdirAll("a/b/c", 0755))
+	assert.Error(t, fsys.MkdirAll("index.html/a", 0755))
+
+	info, err := fsys.Stat("a/b/c")
+	require.NoError(t, err)
+	assert.True(t, info.IsDir())
+
+	_, err = srv.MkdirAll("/Site/a/b/c")
+	require.NoError(t, err)
+}
+
+func TestFS_Remove(t *testing.T) {
+	_, fsys := newTestFS(t)
+
+	require.NoError(t, fsys.Remove("index.html"))
+	assert.ErrorIs(t, fsys.Remove("index.html"), fs.ErrNotExist)
+	assert.Error(t, fsys.Remove("docs"))
+
+	require.NoError(t, fsys.RemoveAll("docs"))
+	require.NoError(t, fsys.RemoveAll("docs"))
+	require.NoError(t, fsys.RemoveAll("css/style.css"))
+
+	require.NoError(t, fsys.Remove("css"))
+
+	entries, err := fsys.ReadDir(".")
+	require.NoError(t, err)
+
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	assert.Equal(t, []string{"empty", "img"}, names)
+}
+
+func TestFS_Rename(t *testing.T) {
+	srv, fsys := newTestFS(t)
+
+	require.NoError(t, fsys.Rename("index.html", "docs/index.html"))
+	require.NoError(t, fsys.Rename("docs", "documents"))
+
+	data, err := srv.ReadFile("/Site/documents/index.html")
+	require.NoError(t, err)
+	assert.Equal(t, "<h1>Hello</h1>", string(data))
+
+	// a file replaces an existing file.
+	require.NoError(t, fsys.Rename("documents/index.html", "documents/readme.txt"))
+
+	data, err = srv.ReadFile("/Site/documents/readme.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "<h1>Hello</h1>", string(data))
+
+	err = fsys.Rename("missing.txt", "other.txt")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	var linkErr *os.LinkError
+	assert.ErrorAs(t, err, &linkErr)
+
+	assert.ErrorIs(t, fsys.Rename("documents", "img"), fs.ErrExist)
+}
@@ -0,0 +1,360 @@
+package remote
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+// pCloudSDK defines the SDK methods used by PCloud.
+type pCloudSDK interface {
+	List(ctx context.Context, folder sdk.FolderRef, o sdk.ListOptions, opts ...sdk.ClientOption) (*sdk.FSList, error)
+	CreateFolderIfNotExists(ctx context.Context, folder sdk.FolderRef, opts ...sdk.ClientOption) (*sdk.FSList, error)
+	DeleteFolder(ctx context.Context, folder sdk.FolderRef, opts ...sdk.ClientOption) (*sdk.FSList, error)
+	RenameFolder(ctx context.Context, folder sdk.FolderRef, toFolder sdk.FolderRef, opts ...sdk.ClientOption) (*sdk.FSList, error)
+	Stat(ctx context.Context, file sdk.FileRef, opts ...sdk.ClientOption) (*sdk.FileResult, error)
+	ChecksumFile(ctx context.Context, file sdk.FileRef, opts ...sdk.ClientOption) (*sdk.FileChecksum, error)
+	DeleteFile(ctx context.Context, file sdk.FileRef, opts ...sdk.ClientOption) (*sdk.FileResult, error)
+	RenameFile(ctx context.Context, file sdk.FileRef, destination sdk.FolderRef, opts ...sdk.ClientOption) (*sdk.FileResult, error)
+	CopyFileTo(ctx context.Context, file sdk.FileRef, destination sdk.FolderRef, o sdk.CopyFileOptions, opts ...sdk.ClientOption) (*sdk.FileResult, error)
+	DownloadLink(ctx context.Context, file sdk.FileRef, o sdk.DownloadLinkOptions, opts ...sdk.ClientOption) (*sdk.FileLink, error)
+	FileOpen(ctx context.Context, flags uint64, file sdk.FileRef, opts ...sdk.ClientOption) (*sdk.File, error)
+	FileWrite(ctx context.Context, fd uint64, data []byte, opts ...sdk.ClientOption) (*sdk.FileDataTransfer, error)
+	FileClose(ctx context.Context, fd uint64, opts ...sdk.ClientOption) error
+}
+
+// putChunkSize is the size of the chunks Put writes files by.
+const putChunkSize = 4 << 20
+
+// ModTimeSuffix is appended to the name of the copy of a file that SetModTime makes, until the
+// copy replaces the file.
+const ModTimeSuffix = ".pcloud-modtime"
+
+// Option configures a PCloud.
+type Option func(*PCloud)
+
+// WithHTTPClient sets the HTTP client that downloads the contents of files from the content
+// servers of pCloud. It defaults to http.DefaultClient.
+func WithHTTPClient(c *http.Client) Option {
+	return func(r *PCloud) {
+		r.httpClient = c
+	}
+}
+
+// PCloud is a Remote backed by a pCloud folder.
+type PCloud struct {
+	pcc        pCloudSDK
+	root       string
+	httpClient *http.Client
+}
+
+var _ Remote = (*PCloud)(nil)
+
+// New creates a PCloud for the pCloud folder at path root.
+func New(pcc pCloudSDK, root string, opts ...Option) *PCloud {
+	r := &PCloud{
+		pcc:        pcc,
+		root:       path.Clean("/" + root),
+		httpClient: http.DefaultClient,
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// List implements Remote.
+func (r *PCloud) List(ctx context.Context, dir string) ([]Object, error) {
+	lf, err := r.pcc.List(ctx, sdk.ByPath(r.fullPath(dir)), sdk.ListOptions{})
+	if err != nil {
+		return nil, r.error("list", dir, err)
+	}
+
+	objects := make([]Object, 0, len(lf.Metadata.Contents))
+	for _, m := range lf.Metadata.Contents {
+		objects = append(objects, *r.object(path.Join(clean(dir), m.Name), m))
+	}
+
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Path < objects[j].Path })
+
+	return objects, nil
+}
+
+// Put implements Remote. The contents are written with the file operations of pCloud, in
+// chunks, so that r needs not be seekable nor its size known.
+func (r *PCloud) Put(ctx context.Context, p string, rd io.Reader) (*Object, error) {
+	err := r.mkdirAll(ctx, path.Dir(clean(p)))
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := r.pcc.FileOpen(ctx, sdk.O_CREAT|sdk.O_TRUNC, sdk.ByPath(r.fullPath(p)))
+	if err != nil {
+		return nil, r.error("put", p, err)
+	}
+
+	err = r.write(ctx, f.FD, rd)
+
+	closeErr := r.pcc.FileClose(ctx, f.FD)
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, r.error("put", p, err)
+	}
+
+	fr, err := r.pcc.Stat(ctx, sdk.ByID(f.FileID))
+	if err != nil {
+		return nil, r.error("put", p, err)
+	}
+
+	return r.object(clean(p), &fr.Metadata), nil
+}
+
+// Append appends the contents of rd to the file p, which is created if it does not exist, so that
+// an interrupted Put can be resumed at the end of a partial file. The parent folders of p are
+// created as needed.
+func (r *PCloud) Append(ctx context.Context, p string, rd io.Reader) (*Object, error) {
+	err := r.mkdirAll(ctx, path.Dir(clean(p)))
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := r.pcc.FileOpen(ctx, sdk.O_CREAT|sdk.O_APPEND, sdk.ByPath(r.fullPath(p)))
+	if err != nil {
+		return nil, r.error("append", p, err)
+	}
+
+	err = r.write(ctx, f.FD, rd)
+
+	closeErr := r.pcc.FileClose(ctx, f.FD)
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, r.error("append", p, err)
+	}
+
+	fr, err := r.pcc.Stat(ctx, sdk.ByID(f.FileID))
+	if err != nil {
+		return nil, r.error("append", p, err)
+	}
+
+	return r.object(clean(p), &fr.Metadata), nil
+}
+
+// write writes the contents of rd to the file descriptor fd.
+func (r *PCloud) write(ctx context.Context, fd uint64, rd io.Reader) error {
+	buf := make([]byte, putChunkSize)
+
+	for {
+		n, err := io.ReadFull(rd, buf)
+		if n > 0 {
+			_, werr := r.pcc.FileWrite(ctx, fd, buf[:n])
+			if werr != nil {
+				return werr
+			}
+		}
+
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "reading the contents to put")
+		}
+	}
+}
+
+// Get implements Remote. The contents are downloaded from the content servers of pCloud.
+func (r *PCloud) Get(ctx context.Context, p string, offset int64) (io.ReadCloser, error) {
+	fl, err := r.pcc.DownloadLink(ctx, sdk.ByPath(r.fullPath(p)), sdk.DownloadLinkOptions{ForceDownload: true, SkipFilename: true})
+	if err != nil {
+		return nil, r.error("get", p, err)
+	}
+	if len(fl.Hosts) == 0 {
+		return nil, pathError("get", p, errors.New("no hosts available to download the file from pCloud"))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fl.Hosts[0]+fl.Path, nil)
+	if err != nil {
+		return nil, pathError("get", p, errors.Wrap(err, "unable to prepare request to download from pCloud"))
+	}
+
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, pathError("get", p, errors.Wrap(err, "executing HTTP request to download from pCloud"))
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		// offset is at, or past, the end of the file.
+		_ = resp.Body.Close()
+		return io.NopCloser(strings.NewReader("")), nil
+
+	case offset > 0 && resp.StatusCode != http.StatusPartialContent,
+		resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent:
+		_ = resp.Body.Close()
+		return nil, pathError("get", p, errors.Errorf("downloading from pCloud: unexpected status %s", resp.Status))
+	}
+
+	return resp.Body, nil
+}
+
+// Move implements Remote.
+func (r *PCloud) Move(ctx context.Context, src, dst string) (*Object, error) {
+	err := r.mkdirAll(ctx, path.Dir(clean(dst)))
+	if err != nil {
+		return nil, err
+	}
+
+	fr, err := r.pcc.RenameFile(ctx, sdk.ByPath(r.fullPath(src)), sdk.ByPath(r.fullPath(dst)))
+	if err == nil {
+		return r.object(clean(dst), &fr.Metadata), nil
+	}
+	if sdk.ErrorCode(err) != sdk.ErrFileNotFound {
+		return nil, r.error("move", src, err)
+	}
+
+	// src is not a file.
+	lf, err := r.pcc.RenameFolder(ctx, sdk.ByPath(r.fullPath(src)), sdk.ByPath(r.fullPath(dst)))
+	if err != nil {
+		return nil, r.error("move", src, err)
+	}
+
+	return r.object(clean(dst), lf.Metadata), nil
+}
+
+// SetModTime sets the modification time of the file p to t. pCloud only sets the modification
+// times of the files that it uploads or copies: p is copied with the time t next to itself, and
+// the copy replaces it. The contents are not transferred again, but the file gets a new ID.
+func (r *PCloud) SetModTime(ctx context.Context, p string, t time.Time) (*Object, error) {
+	tmp := r.fullPath(p) + ModTimeSuffix
+
+	_, err := r.pcc.CopyFileTo(ctx, sdk.ByPath(r.fullPath(p)), sdk.ByPath(tmp), sdk.CopyFileOptions{ModifiedTime: t})
+	if err != nil {
+		return nil, r.error("setmodtime", p, err)
+	}
+
+	fr, err := r.pcc.RenameFile(ctx, sdk.ByPath(tmp), sdk.ByPath(r.fullPath(p)))
+	if err != nil {
+		_, _ = r.pcc.DeleteFile(ctx, sdk.ByPath(tmp))
+		return nil, r.error("setmodtime", p, err)
+	}
+
+	return r.object(clean(p), &fr.Metadata), nil
+}
+
+// Hashes implements Remote.
+func (r *PCloud) Hashes(ctx context.Context, p string) (map[HashType]string, error) {
+	fc, err := r.pcc.ChecksumFile(ctx, sdk.ByPath(r.fullPath(p)))
+	if err != nil {
+		return nil, r.error("hashes", p, err)
+	}
+
+	hashes := map[HashType]string{}
+	for t, h := range map[HashType]string{MD5: fc.MD5, SHA1: fc.SHA1, SHA256: fc.SHA256} {
+		if h != "" {
+			hashes[t] = h
+		}
+	}
+
+	return hashes, nil
+}
+
+// Remove implements Remote.
+func (r *PCloud) Remove(ctx context.Context, p string) error {
+	if clean(p) == "" {
+		return pathError("remove", p, fs.ErrInvalid)
+	}
+
+	_, err := r.pcc.DeleteFile(ctx, sdk.ByPath(r.fullPath(p)))
+	if err == nil {
+		return nil
+	}
+	if sdk.ErrorCode(err) != sdk.ErrFileNotFound {
+		return r.error("remove", p, err)
+	}
+
+	// p is not a file.
+	_, err = r.pcc.DeleteFolder(ctx, sdk.ByPath(r.fullPath(p)))
+	if err != nil {
+		return r.error("remove", p, err)
+	}
+
+	return nil
+}
+
+// mkdirAll creates the folder dir, along with any missing parent folders.
+func (r *PCloud) mkdirAll(ctx context.Context, dir string) error {
+	var p string
+
+	for _, elem := range strings.Split(clean(dir), "/") {
+		if elem == "" {
+			continue
+		}
+
+		p = path.Join(p, elem)
+
+		lf, err := r.pcc.CreateFolderIfNotExists(ctx, sdk.ByPath(r.fullPath(p)))
+		if err != nil {
+			return r.error("mkdir", p, err)
+		}
+		if !lf.Metadata.IsFolder {
+			return pathError("mkdir", p, errors.New("not a directory"))
+		}
+	}
+
+	return nil
+}
+
+// object returns the Object at path p, of metadata m.
+func (r *PCloud) object(p string, m *sdk.Metadata) *Object {
+	o := &Object{
+		Path:    p,
+		Size:    int64(m.Size),
+		IsDir:   m.IsFolder,
+		ModTime: m.Modified,
+	}
+
+	return o
+}
+
+// fullPath returns the path on pCloud of p.
+func (r *PCloud) fullPath(p string) string {
+	return path.Join(r.root, clean(p))
+}
+
+// error returns the error of op on the path p that failed with err, returned by the SDK.
+func (r *PCloud) error(op, p string, err error) error {
+	switch sdk.ErrorCode(err) {
+	case sdk.ErrFileNotFound, sdk.ErrDirectoryNotExists, sdk.ErrComponentOfParentDirectoryNotExists:
+		err = fs.ErrNotExist
+	case sdk.ErrFileOrFolderAlreadyExists:
+		err = fs.ErrExist
+	case sdk.ErrAccessDenied:
+		err = fs.ErrPermission
+	}
+
+	return pathError(op, p, err)
+}
+
+// clean returns the canonical form of the path p of a Remote.
+func clean(p string) string {
+	return strings.TrimPrefix(path.Clean("/"+p), "/")
+}
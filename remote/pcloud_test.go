@@ -0,0 +1,203 @@
+package remote_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/fs"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/remote"
+	"github.com/seborama/pcloud-sdk/sdk/sdktest"
+)
+
+func newTestRemote(t *testing.T) (*sdktest.Server, *remote.PCloud) {
+	t.Helper()
+
+	srv := sdktest.NewServer()
+	t.Cleanup(srv.Close)
+
+	_, err := srv.WriteFile("/Remote/Docs/a.txt", []byte("0123456789"))
+	require.NoError(t, err)
+	_, err = srv.WriteFile("/Remote/b.txt", []byte("hello"))
+	require.NoError(t, err)
+	_, err = srv.WriteFile("/Elsewhere/c.txt", []byte("c"))
+	require.NoError(t, err)
+
+	return srv, remote.New(srv.NewClient(), "/Remote", remote.WithHTTPClient(srv.Client()))
+}
+
+func TestPCloud_List(t *testing.T) {
+	ctx := context.Background()
+	_, r := newTestRemote(t)
+
+	objects, err := r.List(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, objects, 2)
+	assert.Equal(t, "Docs", objects[0].Path)
+	assert.True(t, objects[0].IsDir)
+	assert.Equal(t, "b.txt", objects[1].Path)
+	assert.EqualValues(t, 5, objects[1].Size)
+	assert.False(t, objects[1].ModTime.IsZero())
+
+	objects, err = r.List(ctx, "/Docs/")
+	require.NoError(t, err)
+	require.Len(t, objects, 1)
+	assert.Equal(t, "Docs/a.txt", objects[0].Path)
+
+	_, err = r.List(ctx, "missing")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestPCloud_PutGet(t *testing.T) {
+	ctx := context.Background()
+	srv, r := newTestRemote(t)
+
+	// larger than a chunk, and not seekable.
+	data := bytes.Repeat([]byte("0123456789abcdef"), 300_000)
+
+	o, err := r.Put(ctx, "New/Sub/large.bin", io.MultiReader(bytes.NewReader(data)))
+	require.NoError(t, err)
+	assert.Equal(t, "New/Sub/large.bin", o.Path)
+	assert.EqualValues(t, len(data), o.Size)
+
+	got, err := srv.ReadFile("/Remote/New/Sub/large.bin")
+	require.NoError(t, err)
+	assert.True(t, bytes.Equal(data, got))
+
+	rc, err := r.Get(ctx, "New/Sub/large.bin", 0)
+	require.NoError(t, err)
+	got, err = io.ReadAll(rc)
+	require.NoError(t, err)
+	require.NoError(t, rc.Close())
+	assert.True(t, bytes.Equal(data, got))
+
+	rc, err = r.Get(ctx, "New/Sub/large.bin", int64(len(data))-3)
+	require.NoError(t, err)
+	got, err = io.ReadAll(rc)
+	require.NoError(t, err)
+	require.NoError(t, rc.Close())
+	assert.Equal(t, "def", string(got))
+
+	rc, err = r.Get(ctx, "New/Sub/large.bin", int64(len(data)))
+	require.NoError(t, err)
+	got, err = io.ReadAll(rc)
+	require.NoError(t, err)
+	require.NoError(t, rc.Close())
+	assert.Empty(t, got)
+
+	// Put replaces the file.
+	_, err = r.Put(ctx, "b.txt", bytes.NewReader(nil))
+	require.NoError(t, err)
+
+	got, err = srv.ReadFile("/Remote/b.txt")
+	require.NoError(t, err)
+	assert.Empty(t, got)
+
+	_, err = r.Get(ctx, "missing.txt", 0)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	_, err = r.Put(ctx, "b.txt/x", bytes.NewReader(nil))
+	assert.Error(t, err)
+}
+
+func TestPCloud_Append(t *testing.T) {
+	ctx := context.Background()
+	srv, r := newTestRemote(t)
+
+	o, err := r.Append(ctx, "b.txt", bytes.NewReader([]byte(" world")))
+	require.NoError(t, err)
+	assert.EqualValues(t, 11, o.Size)
+
+	got, err := srv.ReadFile("/Remote/b.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(got))
+
+	// the file is created as needed.
+	_, err = r.Append(ctx, "New/c.txt", bytes.NewReader([]byte("c")))
+	require.NoError(t, err)
+
+	got, err = srv.ReadFile("/Remote/New/c.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "c", string(got))
+}
+
+func TestPCloud_Move(t *testing.T) {
+	ctx := context.Background()
+	srv, r := newTestRemote(t)
+
+	o, err := r.Move(ctx, "b.txt", "Archive/2024/b.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "Archive/2024/b.txt", o.Path)
+	assert.False(t, o.IsDir)
+
+	o, err = r.Move(ctx, "Docs", "Archive/Docs")
+	require.NoError(t, err)
+	assert.True(t, o.IsDir)
+
+	data, err := srv.ReadFile("/Remote/Archive/Docs/a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789", string(data))
+
+	_, err = r.Move(ctx, "missing", "x")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestPCloud_SetModTime(t *testing.T) {
+	ctx := context.Background()
+	srv, r := newTestRemote(t)
+
+	mtime := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+
+	o, err := r.SetModTime(ctx, "b.txt", mtime)
+	require.NoError(t, err)
+	assert.Equal(t, "b.txt", o.Path)
+	assert.True(t, mtime.Equal(o.ModTime), o.ModTime)
+
+	data, err := srv.ReadFile("/Remote/b.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+
+	objects, err := r.List(ctx, "")
+	require.NoError(t, err)
+	for _, o := range objects {
+		assert.NotContains(t, o.Path, "modtime")
+	}
+
+	_, err = r.SetModTime(ctx, "missing", mtime)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestPCloud_Hashes(t *testing.T) {
+	_, r := newTestRemote(t)
+
+	hashes, err := r.Hashes(context.Background(), "b.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "5d41402abc4b2a76b9719d911017c592", hashes[remote.MD5])
+	assert.Equal(t, "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d", hashes[remote.SHA1])
+
+	_, err = r.Hashes(context.Background(), "missing.txt")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestPCloud_Remove(t *testing.T) {
+	ctx := context.Background()
+	srv, r := newTestRemote(t)
+
+	assert.Error(t, r.Remove(ctx, "Docs"))
+	require.NoError(t, r.Remove(ctx, "Docs/a.txt"))
+	require.NoError(t, r.Remove(ctx, "Docs"))
+	assert.ErrorIs(t, r.Remove(ctx, "Docs"), fs.ErrNotExist)
+	assert.ErrorIs(t, r.Remove(ctx, "/"), fs.ErrInvalid)
+
+	objects, err := r.List(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, objects, 1)
+
+	_, err = srv.ReadFile("/Elsewhere/c.txt")
+	require.NoError(t, err)
+}
@@ -0,0 +1,65 @@
+// Package remote adapts pCloud to a minimal, generic interface of remote file systems, in the
+// style of the backends of rclone. It is designed for embedding in other storage tools, so that
+// they can reuse the SDK rather than re-implement the pCloud protocol.
+//
+// Remote is the interface. PCloud implements it; storage tools typically wrap it in their own
+// backend abstraction.
+package remote
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"time"
+)
+
+// Remote is a remote file system. Its paths are slash-separated and relative to its root, with
+// no leading slash; "" is the root.
+// The errors of the paths that do not exist match fs.ErrNotExist with errors.Is.
+type Remote interface {
+	// List lists the contents of the folder dir, sorted by name.
+	List(ctx context.Context, dir string) ([]Object, error)
+
+	// Put stores the contents of r as the file p, which it replaces if it exists. The parent
+	// folders of p are created as needed.
+	Put(ctx context.Context, p string, r io.Reader) (*Object, error)
+
+	// Get reads the contents of the file p from offset on. The caller must close the returned
+	// reader.
+	Get(ctx context.Context, p string, offset int64) (io.ReadCloser, error)
+
+	// Move moves the file or folder src to dst, which it replaces if it is a file. The parent
+	// folders of dst are created as needed.
+	Move(ctx context.Context, src, dst string) (*Object, error)
+
+	// Hashes returns the hashes of the contents of the file p that the remote provides.
+	Hashes(ctx context.Context, p string) (map[HashType]string, error)
+
+	// Remove removes the file, or the empty folder, p.
+	Remove(ctx context.Context, p string) error
+}
+
+// Object is a file or a folder of a Remote.
+type Object struct {
+	// Path is the path of the object, relative to the root of the Remote.
+	Path    string
+	Size    int64
+	ModTime time.Time
+	IsDir   bool
+}
+
+// HashType is a type of hash of the contents of files.
+type HashType string
+
+// The types of hashes. pCloud provides SHA-1 in all regions, with MD5 in the US region and
+// SHA-256 in the European region.
+const (
+	MD5    HashType = "md5"
+	SHA1   HashType = "sha1"
+	SHA256 HashType = "sha256"
+)
+
+// pathError returns the error of op on the path p.
+func pathError(op, p string, err error) error {
+	return &fs.PathError{Op: op, Path: p, Err: err}
+}
@@ -6,49 +6,352 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 )
 
 // Client contains the data necessary to make API calls to pCloud.
+// A Client is safe for concurrent use by multiple goroutines, whose requests to pCloud are sent
+// concurrently. The exception is the file operations that use a file descriptor, which pCloud
+// binds to the connection it was opened on: these are sent one at a time over a connection of
+// their own (see fdEndpoints).
 type Client struct {
 	httpClient *http.Client
 	apiURL     string
 
+	// fdHTTPClient sends the requests to the fdEndpoints, over a single connection.
+	fdHTTPClient *http.Client
+	// fdLock serialises the requests to the fdEndpoints so that they share that connection.
+	fdLock sync.Mutex
+
 	// Auth tokens are at most 64 bytes long and can be passed back instead of username/password
 	// credentials by `auth` parameter. This token is especially good for setting the `auth` cookie
 	// to keep the user logged in.
-	auth string
+	auth     string
+	authLock sync.RWMutex
+	// oauth2 is set when auth is an OAuth 2.0 access token rather than an auth token.
+	oauth2 bool
+
+	// see WithRetryPolicy.
+	retryPolicy RetryPolicy
+
+	// see WithRateLimit.
+	limiter *rateLimiter
+
+	// see WithAPIServerDiscovery.
+	discoverAPIServer bool
+	discoveredAPIHost string
+	discovering       bool
+	connErrors        int
+	hostLock          sync.Mutex
+
+	// see WithInterceptor.
+	interceptors []Interceptor
+
+	// see WithLogger.
+	logger *slog.Logger
+
+	// binapi is set when the Client uses the binary protocol. See Pipeline.
+	binapi *BinAPITransport
+
+	// see WithMetadataCache.
+	cache *metadataCache
+
+	// see WithExpectContinue.
+	expectContinue int
+
+	// see WithStrictDecoding.
+	strictDecoding bool
+}
+
+// Region identifies the data region in which a pCloud account is registered.
+// Accounts can only be accessed through the API host of their region: calls made to the
+// wrong region fail with a login error.
+type Region string
+
+const (
+	// RegionUS is the data region of accounts registered in the United States.
+	RegionUS Region = "api.pcloud.com"
+
+	// RegionEU is the data region of accounts registered in Europe.
+	RegionEU Region = "eapi.pcloud.com"
+)
+
+// Option is a functional parameter for NewClient.
+type Option func(*Client)
+
+// WithRegion sets the data region of the pCloud account.
+// The default is RegionEU.
+func WithRegion(r Region) Option {
+	return func(c *Client) {
+		c.apiURL = string(r)
+	}
+}
+
+// WithBaseHost sets the API host explicitly, for instance when pCloud indicates a specific
+// API server to use (see UserInfo.APIServer).
+// host is a host name, optionally with a port, without scheme: HTTPS is always used.
+func WithBaseHost(host string) Option {
+	return func(c *Client) {
+		c.apiURL = host
+	}
+}
+
+// WithAuthToken sets the auth token of a session opened earlier, for instance by Login and
+// saved with Client.AuthToken, so that the Client needs not log in again.
+func WithAuthToken(auth string) Option {
+	return func(c *Client) {
+		c.auth = auth
+	}
+}
+
+// WithOAuth2AccessToken sets the OAuth 2.0 access token that authenticates the calls of the
+// Client, as obtained with OAuth2Token.
+// https://docs.pcloud.com/methods/oauth_2.0/authorize.html
+func WithOAuth2AccessToken(token string) Option {
+	return func(c *Client) {
+		c.auth = token
+		c.oauth2 = true
+	}
+}
 
-	lock sync.Mutex
+// WithAPIServerDiscovery lets the Client select the API server that is best placed to serve it,
+// as indicated by pCloud's getapiserver method (see GetAPIServer).
+// The region (or base host) of the Client is used to perform the discovery, which takes place
+// in NewClient. The calls are sent to the region (or base host) when discovery fails. The
+// choice of server is discarded after maxConsecutiveConnErrors consecutive connection errors:
+// the calls then go to the region (or base host) while discovery runs again in the background.
+func WithAPIServerDiscovery() Option {
+	return func(c *Client) {
+		c.discoverAPIServer = true
+	}
 }
 
+// maxConsecutiveConnErrors is the number of consecutive connection errors after which a
+// discovered API server is abandoned.
+const maxConsecutiveConnErrors = 3
+
+// apiServerDiscoveryTimeout limits the duration of the discovery of the API server.
+const apiServerDiscoveryTimeout = 10 * time.Second
+
 // NewClient creates a new initialised pCloud Client.
-func NewClient(c *http.Client) *Client {
-	return &Client{
-		httpClient: c,
-		apiURL:     "eapi.pcloud.com", // TODO: have a retry strategy that sets the URL when logon is successful with one of the datacentres (US or EU)
+// c is the HTTP client used to make requests to pCloud. This allows the caller to configure
+// proxies, TLS, timeouts, etc. See NewHTTPClient for a convenient way to create one.
+// If c is nil, an HTTP client with DefaultTransportConfig is used.
+func NewClient(c *http.Client, opts ...Option) *Client {
+	if c == nil {
+		c = NewHTTPClient(DefaultTransportConfig())
+	}
+
+	pcc := &Client{
+		httpClient:  c,
+		apiURL:      string(RegionEU),
+		retryPolicy: DefaultRetryPolicy(),
+	}
+
+	for _, opt := range opts {
+		opt(pcc)
+	}
+
+	pcc.binapi, _ = pcc.httpClient.Transport.(*BinAPITransport)
+	pcc.fdHTTPClient = withInterceptors(singleConnClient(pcc.httpClient), pcc.interceptors)
+	pcc.httpClient = withInterceptors(pcc.httpClient, pcc.interceptors)
+
+	if pcc.discoverAPIServer {
+		pcc.discovering = true
+		pcc.discover()
 	}
+
+	return pcc
 }
 
 // do executes an HTTPS (enforced) request to the pCloud API endpoint.
 // it returns the content-type string, the data from the response and an error, if applicable.
-func (c *Client) do(ctx context.Context, method, endpoint string, query url.Values, contentType string, data []byte) (string, []byte, error) {
-	if c.auth != "" {
-		query.Add("auth", c.auth)
+// When decode is not nil, the response is decoded by it rather than read whole, and the data
+// returned only holds its result (see stream).
+func (c *Client) do(ctx context.Context, method, endpoint string, query url.Values, contentType string, data []byte, decode streamDecoder) (string, []byte, error) {
+	policy := c.retryPolicyFor(ctx, endpoint, query)
+
+	ctx, cancel := withCallTimeout(ctx)
+	defer cancel()
+
+	start := time.Now()
+
+	for attempt := 1; ; attempt++ {
+		host := c.apiHost()
+
+		if c.limiter != nil {
+			err := c.limiter.wait(ctx)
+			if err != nil {
+				return "", nil, errors.WithStack(err)
+			}
+		}
+
+		ct, body, err := c.doOnHost(withAttempt(ctx, attempt), host, method, endpoint, query, contentType, data, decode)
+		c.trackConnError(err)
+
+		if attempt >= policy.MaxAttempts || !policy.shouldRetry(body, err) {
+			c.logCall(ctx, endpoint, query, start, attempt, body, err)
+			captureResponse(ctx, endpoint, host, start, attempt, body)
+			return ct, body, err
+		}
+
+		delay := policy.delay(attempt)
+		c.logRetry(ctx, endpoint, attempt, delay, body, err)
+
+		if c.limiter != nil && IsRateLimited(&Error{Code: resultCode(body)}) {
+			c.limiter.throttle(delay)
+			delay = 0 // the limiter now holds back this call along with all the others
+		}
+
+		if sleepErr := sleep(ctx, delay); sleepErr != nil {
+			if te, ok := context.Cause(ctx).(*TimeoutError); ok {
+				err = errors.WithStack(te)
+			}
+			c.logCall(ctx, endpoint, query, start, attempt, body, err)
+			captureResponse(ctx, endpoint, host, start, attempt, body)
+			return ct, body, err
+		}
+	}
+}
+
+// apiHost returns the API host to send requests to: the discovered API server, if any, or else
+// the region (or base host) of the Client.
+func (c *Client) apiHost() string {
+	if !c.discoverAPIServer {
+		return c.apiURL
+	}
+
+	c.hostLock.Lock()
+	defer c.hostLock.Unlock()
+
+	if c.discoveredAPIHost != "" {
+		return c.discoveredAPIHost
+	}
+
+	return c.apiURL
+}
+
+// discover selects the API server with getapiserver. c.discovering must be set by the caller.
+// The lock is not held during the call, so that the calls made meanwhile are not held back:
+// they go to the region (or base host) of the Client.
+func (c *Client) discover() {
+	ctx, cancel := context.WithTimeout(context.Background(), apiServerDiscoveryTimeout)
+	defer cancel()
+
+	as := &APIServerResult{}
+
+	err := c.parseAPIOutput(as)(c.getOnHost(ctx, c.apiURL, "getapiserver", url.Values{}))
+	if err == nil && len(as.API) == 0 {
+		err = errors.New("pCloud did not return any API server")
+	}
+	if err != nil {
+		c.logDiscoveryFailure(ctx, err)
+	}
+
+	c.hostLock.Lock()
+	defer c.hostLock.Unlock()
+
+	c.discovering = false
+
+	if err != nil {
+		return
+	}
+
+	c.discoveredAPIHost = as.API[0]
+	c.connErrors = 0
+}
+
+// trackConnError counts consecutive connection errors and discards the discovered API server
+// when there are too many.
+func (c *Client) trackConnError(err error) {
+	if !c.discoverAPIServer {
+		return
+	}
+
+	c.hostLock.Lock()
+	defer c.hostLock.Unlock()
+
+	var urlErr *url.Error
+	if err == nil || !errors.As(err, &urlErr) {
+		c.connErrors = 0
+		return
+	}
+
+	c.connErrors++
+	if c.connErrors >= maxConsecutiveConnErrors && !c.discovering {
+		c.discoveredAPIHost = ""
+		c.connErrors = 0
+		c.discovering = true
+		go c.discover()
+	}
+}
+
+// AuthToken returns the auth token of the current session, or the OAuth 2.0 access token of the
+// Client, if any. The token can be saved to create Clients that need not log in: see
+// WithAuthToken and WithOAuth2AccessToken.
+func (c *Client) AuthToken() string {
+	return c.authToken()
+}
+
+// authToken returns the auth token of the current session, if any.
+func (c *Client) authToken() string {
+	c.authLock.RLock()
+	defer c.authLock.RUnlock()
+
+	return c.auth
+}
+
+// setAuthToken sets the auth token of the current session.
+func (c *Client) setAuthToken(auth string) {
+	c.authLock.Lock()
+	defer c.authLock.Unlock()
+
+	c.auth = auth
+	c.oauth2 = false
+}
+
+// addAuth adds the token of the current session, if any, to the query q.
+func (c *Client) addAuth(q url.Values) {
+	c.authLock.RLock()
+	defer c.authLock.RUnlock()
+
+	switch {
+	case c.auth == "":
+	case c.oauth2:
+		q.Set("access_token", c.auth)
+	default:
+		q.Set("auth", c.auth)
 	}
+}
+
+// getOnHost is like get but it does not use the API host of the Client.
+func (c *Client) getOnHost(ctx context.Context, host, endpoint string, query url.Values) ([]byte, error) {
+	_, body, err := c.doOnHost(ctx, host, http.MethodGet, endpoint, query, "application/json", nil, nil)
+	return body, err
+}
+
+// doOnHost executes an HTTPS (enforced) request to the pCloud API endpoint of the specified host.
+// See do for decode.
+func (c *Client) doOnHost(ctx context.Context, host, method, endpoint string, query url.Values, contentType string, data []byte, decode streamDecoder) (string, []byte, error) {
+	c.addAuth(query)
 
 	u := url.URL{
 		Scheme:   "https",
-		Host:     c.apiURL,
+		Host:     host,
 		Path:     endpoint,
 		RawQuery: query.Encode(),
 	}
 
+	ctx, timers := newAttemptTimers(ctx)
+	defer timers.stop()
+
 	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(data))
 	if err != nil {
 		return "", nil, errors.Wrapf(err, "http request: %s", method)
@@ -57,12 +360,24 @@ func (c *Client) do(ctx context.Context, method, endpoint string, query url.Valu
 	req.Header.Add("Connection", "Keep-Alive")
 	// consider adding parameters to add: req.Header.Add("Keep-Alive", "timeout=nnn, max=nnn")
 	req.Header.Add("Content-Type", contentType)
+	if c.expectContinue > 0 && len(data) >= c.expectContinue {
+		req.Header.Add("Expect", "100-continue")
+	}
 
-	c.lock.Lock()
-	defer c.lock.Unlock()
+	hc := c.httpClient
+	if fdEndpoints[endpoint] {
+		// the lock is held until the response body is consumed and the connection is free again.
+		c.fdLock.Lock()
+		defer c.fdLock.Unlock()
 
-	resp, err := c.httpClient.Do(req)
+		hc = c.fdHTTPClient
+	}
+
+	timers.startHeader()
+	sent := time.Now()
+	resp, err := hc.Do(req)
 	if resp != nil {
+		captureAttempt(ctx, resp.StatusCode, sent)
 		defer func() {
 			_, err = io.Copy(io.Discard, resp.Body)
 			if err != nil {
@@ -75,16 +390,28 @@ func (c *Client) do(ctx context.Context, method, endpoint string, query url.Valu
 		}()
 	}
 	if err != nil {
-		return "", nil, errors.Wrap(err, "http Do")
+		stripURLQuery(err)
+		return "", nil, errors.Wrap(timers.err(ctx, err), "http Do")
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	timers.startBody()
+	if decode != nil && resp.StatusCode == http.StatusOK {
+		r, err := decode(resp.Body)
+		if err != nil {
+			return resp.Header.Get("content-type"), nil, errors.Wrap(timers.err(ctx, err), "body")
+		}
+
+		body, err := json.Marshal(r)
+		return resp.Header.Get("content-type"), body, errors.WithStack(err)
+	}
+
+	body, err := readBody(resp)
 	if err != nil {
-		return resp.Header.Get("content-type"), nil, errors.Wrap(err, "body")
+		return resp.Header.Get("content-type"), nil, errors.Wrap(timers.err(ctx, err), "body")
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return resp.Header.Get("content-type"), nil, errors.New(string(body))
+		return resp.Header.Get("content-type"), nil, errors.WithStack(&statusError{statusCode: resp.StatusCode, body: string(body)})
 	}
 
 	return resp.Header.Get("content-type"), body, nil
@@ -92,7 +419,14 @@ func (c *Client) do(ctx context.Context, method, endpoint string, query url.Valu
 
 // get executes an HTTPS (enforced) GET to the pCloud API endpoint.
 func (c *Client) get(ctx context.Context, endpoint string, query url.Values) ([]byte, error) {
-	_, body, err := c.do(ctx, http.MethodGet, endpoint, query, "application/json", nil)
+	if c.cache != nil && cachedEndpoints[endpoint] {
+		return c.cachedGet(ctx, endpoint, query)
+	}
+
+	_, body, err := c.do(ctx, http.MethodGet, endpoint, query, "application/json", nil, nil)
+	if err == nil {
+		c.observeMutation(endpoint, query, body)
+	}
 	return body, err
 }
 
@@ -101,7 +435,7 @@ func (c *Client) get(ctx context.Context, endpoint string, query url.Values) ([]
 // When the content-type is application/json and the 'X-Error: xxxx' header is present, it
 // returns an error instead.
 func (c *Client) binget(ctx context.Context, endpoint string, query url.Values) ([]byte, error) {
-	ct, body, err := c.do(ctx, http.MethodGet, endpoint, query, "application/octet-stream", nil)
+	ct, body, err := c.do(ctx, http.MethodGet, endpoint, query, "application/octet-stream", nil, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -120,19 +454,34 @@ func (c *Client) binget(ctx context.Context, endpoint string, query url.Values)
 
 // put executes an HTTPS (enforced) PUT to the pCloud API endpoint.
 func (c *Client) put(ctx context.Context, endpoint string, query url.Values, data []byte) ([]byte, error) {
-	_, body, err := c.do(ctx, http.MethodPut, endpoint, query, "application/octet-stream", data)
+	_, body, err := c.do(ctx, http.MethodPut, endpoint, query, "application/octet-stream", data, nil)
+	if err == nil {
+		c.observeMutation(endpoint, query, body)
+	}
 	return body, err
 }
 
 // post executes an HTTPS (enforced) POST with multipart/form-data to the pCloud API endpoint.
 func (c *Client) post(ctx context.Context, endpoint string, query url.Values, contentType string, data []byte) ([]byte, error) {
-	_, body, err := c.do(ctx, http.MethodPost, endpoint, query, contentType, data)
+	_, body, err := c.do(ctx, http.MethodPost, endpoint, query, contentType, data, nil)
 	if err != nil {
 		return nil, err
 	}
+	c.observeMutation(endpoint, query, body)
 	return body, err
 }
 
+// statusError is returned when pCloud responds with an HTTP status other than 200 OK.
+type statusError struct {
+	statusCode int
+	body       string
+}
+
+// Error implements Go's error interface.
+func (e *statusError) Error() string {
+	return e.body
+}
+
 type result struct {
 	Result int    `json:"result"`
 	Error  string `json:"error"`
@@ -152,9 +501,14 @@ type resulter interface {
 }
 
 // parseAPIOutput is a curry for parseResult.
-func parseAPIOutput(r resulter) func(body []byte, err error) error {
+// In strict decoding mode, it checks that r captures all the fields of the response too.
+func (c *Client) parseAPIOutput(r resulter) func(body []byte, err error) error {
 	return func(body []byte, err error) error {
-		return parseResult(body, err, r)
+		err = parseResult(body, err, r)
+		if err == nil && c.strictDecoding {
+			err = checkUnknownFields(body, r)
+		}
+		return err
 	}
 }
 
@@ -169,19 +523,23 @@ func parseResult(body []byte, err error, r resulter) error {
 		return errors.Wrap(err, "unmarshal")
 	}
 	if r.Result_() != 0 {
-		return errors.Errorf("error %d: %s", r.Result_(), r.Error_())
+		return errors.WithStack(&Error{Code: r.Result_(), Message: r.Error_()})
 	}
 	return nil
 }
 
 // toQuery create a blank url.Value object for use as a query with an HTTPS request.
 // It applies the options specified by opts to it and returns it.
-func toQuery(opts ...ClientOption) url.Values {
-	q := url.Values{}
+// The settings in opts that apply to the call rather than to the query are carried by the
+// returned context.
+func toQuery(ctx context.Context, opts ...ClientOption) (context.Context, url.Values) {
+	co := &callOptions{
+		query: url.Values{},
+	}
 
 	for _, opt := range opts {
-		opt(&q)
+		opt(co)
 	}
 
-	return q
+	return context.WithValue(ctx, callOptionsKey{}, co), co.query
 }
@@ -78,11 +78,11 @@ func (testsuite *IntegrationTestSuite) initAuthenticatedClient(c *http.Client) {
 
 func (testsuite *IntegrationTestSuite) initSuiteTestFolder() {
 	testsuite.testFolderPath = "/goPCloudSDK_TestFolder_" + uuid.New().String()
-	lf, err := testsuite.pcc.CreateFolder(testsuite.ctx, sdk.T2FolderByPath(testsuite.testFolderPath))
+	lf, err := testsuite.pcc.CreateFolder(testsuite.ctx, sdk.ByPath(testsuite.testFolderPath))
 	testsuite.Require().NoError(err)
 	testsuite.testFolderID = lf.Metadata.FolderID
 
-	f, err := testsuite.pcc.FileOpen(testsuite.ctx, sdk.O_CREAT, sdk.T4FileByFolderIDName(testsuite.testFolderID, "sample.file"))
+	f, err := testsuite.pcc.FileOpen(testsuite.ctx, sdk.O_CREAT, sdk.ByIDName(testsuite.testFolderID, "sample.file"))
 	testsuite.Require().NoError(err)
 	testsuite.testFileID = f.FileID
 
@@ -98,6 +98,6 @@ func (testsuite *IntegrationTestSuite) logout() {
 }
 
 func (testsuite *IntegrationTestSuite) deleteSuiteTestFolder() {
-	_, err := testsuite.pcc.DeleteFolderRecursive(testsuite.ctx, sdk.T1FolderByID(testsuite.testFolderID))
+	_, err := testsuite.pcc.DeleteFolderRecursive(testsuite.ctx, sdk.ByID(testsuite.testFolderID))
 	testsuite.NoError(err)
 }
@@ -0,0 +1,82 @@
+package sdk_test
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+// newAPIServer returns a server that answers getip and, with apiServer, getapiserver.
+// It counts the calls made to each endpoint.
+func newAPIServer(t *testing.T, apiServer func() string) (*httptest.Server, func(endpoint string) int) {
+	t.Helper()
+
+	var lock sync.Mutex
+	calls := map[string]int{}
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		calls[r.URL.Path]++
+		lock.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case r.URL.Path == "/getip":
+			fmt.Fprint(w, `{"result": 0, "ip": "1.2.3.4"}`)
+		case r.URL.Path == "/getapiserver" && apiServer != nil:
+			fmt.Fprintf(w, `{"result": 0, "api": [%q], "binapi": []}`, apiServer())
+		default:
+			fmt.Fprint(w, `{"result": 5000, "error": "Internal error. Try again later."}`)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, func(endpoint string) int {
+		lock.Lock()
+		defer lock.Unlock()
+		return calls[endpoint]
+	}
+}
+
+func TestAPIServerDiscovery(t *testing.T) {
+	// the servers of the test have certificates of their own.
+	hc := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}} //nolint:gosec
+
+	t.Run("discovered by NewClient", func(t *testing.T) {
+		api, apiCalls := newAPIServer(t, nil)
+		region, regionCalls := newAPIServer(t, func() string { return strings.TrimPrefix(api.URL, "https://") })
+
+		pcc := sdk.NewClient(hc, sdk.WithBaseHost(strings.TrimPrefix(region.URL, "https://")), sdk.WithAPIServerDiscovery())
+		assert.Equal(t, 1, regionCalls("/getapiserver"))
+
+		_, err := pcc.GetIP(context.Background())
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, apiCalls("/getip"))
+		assert.Equal(t, 0, regionCalls("/getip"))
+	})
+
+	t.Run("falls back on the base host", func(t *testing.T) {
+		region, regionCalls := newAPIServer(t, nil)
+
+		pcc := sdk.NewClient(hc, sdk.WithBaseHost(strings.TrimPrefix(region.URL, "https://")), sdk.WithAPIServerDiscovery(), sdk.WithRetryPolicy(sdk.NoRetryPolicy()))
+		assert.Equal(t, 1, regionCalls("/getapiserver"))
+
+		_, err := pcc.GetIP(context.Background())
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, regionCalls("/getip"))
+		assert.Equal(t, 1, regionCalls("/getapiserver"))
+	})
+}
@@ -2,12 +2,18 @@ package sdk
 
 import (
 	"fmt"
+	"math"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/pkg/errors"
 )
 
 // APITime contains a Go time.Time. It is used to provide a custom JSON
 // marshaler for the times received from pCloud's APIs.
+// The result structs of the SDK expose the times as plain time.Time's, which are zero when
+// pCloud did not return them: APITime is what they are decoded with.
 // https://docs.pcloud.com/structures/datetime.html
 type APITime struct {
 	time.Time
@@ -15,25 +21,89 @@ type APITime struct {
 
 const ctLayout = time.RFC1123Z
 
+// apiTimeLayouts are the layouts of the times that pCloud returns as strings, tried in turn:
+// RFC 2822 in its variations, and RFC 3339, which is how a time.Time is marshalled.
+var apiTimeLayouts = []string{
+	ctLayout,                         // Thu, 21 Mar 2013 18:31:45 +0000
+	"Mon, 2 Jan 2006 15:04:05 -0700", // Thu, 1 Mar 2013 18:31:45 +0000
+	"Mon, 2 Jan 2006 15:04:05 MST",   // Thu, 1 Mar 2013 18:31:45 GMT
+	"2 Jan 2006 15:04:05 -0700",      // 1 Mar 2013 18:31:45 +0000
+	"2 Jan 2006 15:04:05 MST",        // 1 Mar 2013 18:31:45 GMT
+	time.RFC3339Nano,
+}
+
 // UnmarshalJSON parses the JSON-encoded APITime value and stores the result
 // in the value pointed to by v. If v is nil or not a pointer,
 // Unmarshal returns an InvalidUnmarshalError.
 // This is an implementation of Go's "json.Unmarshaler" interface.
-func (ct *APITime) UnmarshalJSON(v []byte) (err error) {
-	s := strings.Trim(string(v), "\"")
-	if s == "null" {
-		ct.Time = time.Time{}
-		return
-	}
-	ct.Time, err = time.Parse(ctLayout, s)
-	return
+// The time may be a string in RFC 2822 format, which is the default format of pCloud, or a Unix
+// timestamp, as a number or a string, which some methods and API servers return. null, an empty
+// string and a timestamp of 0 are the zero time.
+// A time in another format is the zero time too, rather than an error: a response is not
+// rejected for one of its times.
+func (ct *APITime) UnmarshalJSON(v []byte) error {
+	s := string(v)
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+
+	t, err := parseAPITime(s)
+	if err != nil {
+		t = time.Time{}
+	}
+
+	ct.Time = t
+
+	return nil
 }
 
 // MarshalJSON returns the JSON encoding of an APITime.
 // This is an implementation of Go's "json.Marshaler" interface.
-func (ct *APITime) MarshalJSON() ([]byte, error) {
+func (ct APITime) MarshalJSON() ([]byte, error) {
 	if ct.Time.IsZero() {
 		return []byte("null"), nil
 	}
 	return []byte(fmt.Sprintf("\"%s\"", ct.Time.Format(ctLayout))), nil
 }
+
+// parseAPITime parses the time s, as returned by pCloud (see APITime.UnmarshalJSON).
+func parseAPITime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "null" {
+		return time.Time{}, nil
+	}
+
+	if sec, err := strconv.ParseFloat(s, 64); err == nil {
+		return unixTime(sec)
+	}
+
+	for _, layout := range apiTimeLayouts {
+		t, err := time.Parse(layout, s)
+		if err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, errors.Errorf("unexpected time format: %q", s)
+}
+
+// minUnixTime and maxUnixTime bound the timestamps parsed by unixTime, to the years 1 to 9999.
+const (
+	minUnixTime = -62135596800
+	maxUnixTime = 253402300799
+)
+
+// unixTime returns the time of the Unix timestamp sec, in seconds and fractions of seconds.
+func unixTime(sec float64) (time.Time, error) {
+	if math.IsNaN(sec) || sec < minUnixTime || sec > maxUnixTime {
+		return time.Time{}, errors.Errorf("unexpected timestamp: %v", sec)
+	}
+
+	if sec == 0 {
+		return time.Time{}, nil
+	}
+
+	whole, frac := math.Modf(sec)
+
+	return time.Unix(int64(whole), int64(frac*1e9)).UTC(), nil
+}
@@ -0,0 +1,107 @@
+package sdk_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+func TestAPITime_UnmarshalJSON(t *testing.T) {
+	want := time.Date(2013, 3, 21, 18, 31, 45, 0, time.UTC)
+
+	tcs := []struct {
+		name string
+		json string
+		want time.Time
+	}{
+		{name: "RFC 2822", json: `"Thu, 21 Mar 2013 18:31:45 +0000"`, want: want},
+		{name: "RFC 2822 with an offset", json: `"Thu, 21 Mar 2013 19:31:45 +0100"`, want: want},
+		{name: "RFC 2822 with a single digit day", json: `"Fri, 1 Mar 2013 18:31:45 +0000"`, want: time.Date(2013, 3, 1, 18, 31, 45, 0, time.UTC)},
+		{name: "RFC 2822 with a zone name", json: `"Thu, 21 Mar 2013 18:31:45 GMT"`, want: want},
+		{name: "RFC 2822 without the day of the week", json: `"21 Mar 2013 18:31:45 +0000"`, want: want},
+		{name: "Unix timestamp", json: `1363890705`, want: want},
+		{name: "Unix timestamp with milliseconds", json: `1363890705.5`, want: want.Add(500 * time.Millisecond)},
+		{name: "Unix timestamp as a string", json: `"1363890705"`, want: want},
+		{name: "RFC 3339", json: `"2013-03-21T18:31:45Z"`, want: want},
+		{name: "null", json: `null`},
+		{name: "empty string", json: `""`},
+		{name: "zero timestamp", json: `0`},
+		{name: "unexpected format", json: `"yesterday"`},
+		{name: "timestamp out of range", json: `1e300`},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			var at sdk.APITime
+			require.NoError(t, json.Unmarshal([]byte(tc.json), &at))
+			assert.True(t, tc.want.Equal(at.Time), at.Time)
+			assert.Equal(t, tc.want.IsZero(), at.IsZero())
+		})
+	}
+}
+
+func TestAPITime_ResultStructs(t *testing.T) {
+	var m sdk.Metadata
+	err := json.Unmarshal([]byte(`{"name": "a.txt", "created": 1363890705, "modified": "Thu, 21 Mar 2013 18:31:45 +0000"}`), &m)
+	require.NoError(t, err)
+	assert.Equal(t, "a.txt", m.Name)
+	assert.True(t, time.Date(2013, 3, 21, 18, 31, 45, 0, time.UTC).Equal(m.Created))
+	assert.True(t, m.Created.Equal(m.Modified))
+
+	var e sdk.Entry
+	err = json.Unmarshal([]byte(`{"event": "createfile", "time": 1363890705, "metadata": {"name": "a.txt"}}`), &e)
+	require.NoError(t, err)
+	assert.True(t, m.Created.Equal(e.Time))
+	assert.Equal(t, "a.txt", e.Metadata.Name)
+	assert.True(t, e.Metadata.Modified.IsZero())
+
+	var pl sdk.PubLink
+	err = json.Unmarshal([]byte(`{"code": "abc", "created": "Thu, 21 Mar 2013 18:31:45 +0000", "expires": null}`), &pl)
+	require.NoError(t, err)
+	assert.Equal(t, "abc", pl.Code)
+	assert.True(t, pl.Expires.IsZero())
+
+	// a result struct that is marshalled is unmarshalled with its times.
+	data, err := json.Marshal(m)
+	require.NoError(t, err)
+
+	var m2 sdk.Metadata
+	require.NoError(t, json.Unmarshal(data, &m2))
+	assert.True(t, m.Modified.Equal(m2.Modified))
+}
+
+func FuzzAPITime_UnmarshalJSON(f *testing.F) {
+	for _, seed := range []string{
+		`"Thu, 21 Mar 2013 18:31:45 +0000"`,
+		`"Fri, 1 Mar 2013 18:31:45 GMT"`,
+		`"21 Mar 2013 18:31:45 -0700"`,
+		`"2013-03-21T18:31:45.123Z"`,
+		`1363890705`,
+		`"1363890705.25"`,
+		`-1e9`,
+		`null`,
+		`""`,
+		`"garbage"`,
+	} {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var at sdk.APITime
+		require.NoError(t, at.UnmarshalJSON(data))
+
+		// the times that are parsed are marshalled back to the same second.
+		b, err := at.MarshalJSON()
+		require.NoError(t, err)
+
+		var at2 sdk.APITime
+		require.NoError(t, at2.UnmarshalJSON(b))
+		assert.Equal(t, at.IsZero(), at2.IsZero(), "%s: %s", data, b)
+		assert.Equal(t, at.Truncate(time.Second).Unix(), at2.Unix(), "%s: %s", data, b)
+	})
+}
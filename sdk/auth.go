@@ -2,10 +2,15 @@ package sdk
 
 import (
 	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 )
@@ -21,23 +26,23 @@ type LogoutResult struct {
 // This is not an SDK method per-se, rather a wrapper around UserInfo.
 // https://docs.pcloud.com/methods/intro/authentication.html
 func (c *Client) LoginV1(ctx context.Context, opts ...ClientOption) error {
-	if c.auth != "" {
+	if c.authToken() != "" {
 		return errors.New("'Login' called while already logged in. Please call Logout first")
 	}
 
-	q := toQuery(opts...)
+	ctx, q := toQuery(ctx, opts...)
 
 	q.Add("getauth", "1")
 	q.Add("logout", "1")
 
 	ui := &UserInfo{}
 
-	err := parseAPIOutput(ui)(c.get(ctx, "userinfo", q))
+	err := c.parseAPIOutput(ui)(c.get(ctx, "userinfo", q))
 	if err != nil {
 		return err
 	}
 
-	c.auth = ui.Auth
+	c.setAuthToken(ui.Auth)
 
 	return nil
 }
@@ -67,28 +72,43 @@ func osID() string {
 	}
 }
 
+// addDeviceIdentification adds the parameters that identify this device to pCloud, unless
+// they were already supplied by the caller with the corresponding ClientOption's.
+// pCloud uses them to label the session in the list of tokens (see ListTokens) and in the
+// security settings of the account.
+func addDeviceIdentification(q url.Values) {
+	if q.Get("os") == "" {
+		q.Set("os", osID())
+	}
+
+	if q.Get("device") == "" {
+		q.Set("device", deviceID())
+	}
+
+	if q.Get("deviceid") == "" {
+		q.Set("deviceid", deviceID())
+	}
+}
+
 // Login performs a user login by credentials supplied via opts.
 // Login will handle two-factor authentication where applicable.
 // Typically this would be by username and password.
 // This is not a documented SDK method.
 // https://docs.pcloud.com/methods/intro/authentication.html
 func (c *Client) Login(ctx context.Context, otpCodeOpt string, opts ...ClientOption) error {
-	if c.auth != "" {
+	if c.authToken() != "" {
 		return errors.New("'Login' called while already logged in. Please call Logout first")
 	}
 
-	q := toQuery(opts...)
-	fmt.Println("deviceID", deviceID())
+	ctx, q := toQuery(ctx, opts...)
 
 	q.Add("getauth", "1")
 	q.Add("logout", "1")
-	q.Add("os", osID())
-	q.Add("device", deviceID()) // NOTE: is this needed?
-	q.Add("deviceid", deviceID())
+	addDeviceIdentification(q)
 
 	ui := &UserInfo{}
 
-	err := parseAPIOutput(ui)(c.get(ctx, "login", q))
+	err := c.parseAPIOutput(ui)(c.get(ctx, "login", q))
 	if err != nil {
 		if ui.Result != ErrTFARequired {
 			// NOTE: there may be other flows in the login procedure for consideration, such as:
@@ -105,50 +125,117 @@ func (c *Client) Login(ctx context.Context, otpCodeOpt string, opts ...ClientOpt
 		return c.loginTFA(ctx, ui.Token, otpCodeOpt) // is the Token worth saving in Client and to what purpose?
 	}
 
-	c.auth = ui.Auth
+	c.setAuthToken(ui.Auth)
 
 	return nil
 }
 
 func (c *Client) loginTFA(ctx context.Context, token, otpCode string, opts ...ClientOption) error {
-	q := toQuery(opts...)
+	ctx, q := toQuery(ctx, opts...)
 
 	q.Add("getauth", "1")
 	q.Add("logout", "1")
-	q.Add("os", osID())
-	q.Add("device", deviceID()) // NOTE: is this needed?
-	q.Add("deviceid", deviceID())
+	addDeviceIdentification(q)
 	q.Add("token", token)     // TFA challenge
 	q.Add("code", otpCode)    // TFA response
 	q.Add("trustdevice", "1") // TODO: make this configurable
 
 	ui := &UserInfo{}
 
-	err := parseAPIOutput(ui)(c.get(ctx, "tfa_login", q))
+	err := c.parseAPIOutput(ui)(c.get(ctx, "tfa_login", q))
+	if err != nil {
+		return err
+	}
+
+	c.setAuthToken(ui.Auth)
+
+	return nil
+}
+
+// Digest contains the properties returned from an API call to GetDigest.
+type Digest struct {
+	result
+	Digest  string
+	Expires time.Time
+}
+
+// UnmarshalJSON decodes the Digest, with its times in any of the formats of APITime.
+func (d *Digest) UnmarshalJSON(data []byte) error {
+	type digest Digest
+	times := struct {
+		*digest
+		Expires APITime
+	}{digest: (*digest)(d)}
+
+	err := json.Unmarshal(data, &times)
 	if err != nil {
 		return err
 	}
 
-	c.auth = ui.Auth
+	d.Expires = times.Expires.Time
 
 	return nil
 }
 
+// GetDigest returns a digest for digest authentication. Digests are valid for 30 seconds.
+// https://docs.pcloud.com/methods/auth/getdigest.html
+func (c *Client) GetDigest(ctx context.Context, opts ...ClientOption) (*Digest, error) {
+	ctx, q := toQuery(ctx, opts...)
+
+	d := &Digest{}
+
+	err := c.parseAPIOutput(d)(c.get(ctx, "getdigest", q))
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// PasswordDigest returns the password digest of username and password for digest, as obtained
+// with GetDigest: sha1(password + sha1(lowercase(username)) + digest), in hexadecimal.
+// https://docs.pcloud.com/methods/intro/authentication.html
+func PasswordDigest(username, password, digest string) string {
+	userHash := sha1.Sum([]byte(strings.ToLower(username)))
+	h := sha1.Sum([]byte(password + hex.EncodeToString(userHash[:]) + digest))
+	return hex.EncodeToString(h[:])
+}
+
+// LoginDigest is like Login, but authenticates with digest authentication: the password is not
+// sent to pCloud, even encrypted.
+// https://docs.pcloud.com/methods/intro/authentication.html
+func (c *Client) LoginDigest(ctx context.Context, otpCodeOpt, username, password string, opts ...ClientOption) error {
+	d, err := c.GetDigest(ctx, opts...)
+	if err != nil {
+		return err
+	}
+
+	return c.Login(
+		ctx,
+		otpCodeOpt,
+		append(
+			opts,
+			WithGlobalOptionUsername(username),
+			WithGlobalOptionPasswordDigest(d.Digest, PasswordDigest(username, password, d.Digest)),
+		)...,
+	)
+}
+
 // Logout gets a token and invalidates it.
 // Returns bool auth_deleted if the token invalidation was successful
 // (token was correct and it was actually invalidated).
 // https://docs.pcloud.com/methods/auth/logout.html
 func (c *Client) Logout(ctx context.Context, opts ...ClientOption) (*LogoutResult, error) {
-	q := toQuery(opts...)
+	ctx, q := toQuery(ctx, opts...)
 
 	lr := &LogoutResult{}
 
-	err := parseAPIOutput(lr)(c.get(ctx, "logout", q))
+	err := c.parseAPIOutput(lr)(c.get(ctx, "logout", q))
 	if err != nil {
 		return nil, err
 	}
 
-	c.auth = ""
+	c.setAuthToken("")
 
 	return lr, nil
 }
@@ -163,22 +250,322 @@ type TokensList struct {
 type Token struct {
 	TokenID         uint64
 	Device          string
-	Created         APITime
-	ExpiresInactive APITime
-	Expires         APITime
+	Created         time.Time
+	ExpiresInactive time.Time
+	Expires         time.Time
+	// Current is set for the token of the session that lists the tokens.
+	Current bool
+}
+
+// UnmarshalJSON decodes the Token, with its times in any of the formats of APITime.
+func (t *Token) UnmarshalJSON(data []byte) error {
+	type token Token
+	times := struct {
+		*token
+		Created         APITime
+		ExpiresInactive APITime
+		Expires         APITime
+	}{token: (*token)(t)}
+
+	err := json.Unmarshal(data, &times)
+	if err != nil {
+		return err
+	}
+
+	t.Created, t.ExpiresInactive, t.Expires = times.Created.Time, times.ExpiresInactive.Time, times.Expires.Time
+
+	return nil
 }
 
 // ListTokens gets a list of currently active tokens associated with the current user.
 // https://docs.pcloud.com/methods/auth/listtokens.html
 func (c *Client) ListTokens(ctx context.Context, opts ...ClientOption) (*TokensList, error) {
-	q := toQuery(opts...)
+	ctx, q := toQuery(ctx, opts...)
 
 	tl := &TokensList{}
 
-	err := parseAPIOutput(tl)(c.get(ctx, "listtokens", q))
+	err := c.parseAPIOutput(tl)(c.get(ctx, "listtokens", q))
 	if err != nil {
 		return nil, err
 	}
 
 	return tl, nil
 }
+
+// RegisterResult is returned by the SDK Register() method.
+type RegisterResult struct {
+	result
+	UserID uint64
+}
+
+// Register registers a new user account.
+// termsAccepted must be true: it indicates that the user has accepted pCloud's Terms of Service
+// and all other agreements.
+// The optional parameter languageOpt sets the language of the new account (see
+// supportedlanguages).
+// The optional parameter referrerOpt is the userid of the user that referred the new user
+// (typically from an invitation).
+// https://docs.pcloud.com/methods/auth/register.html
+func (c *Client) Register(ctx context.Context, mail, password string, termsAccepted bool, languageOpt string, referrerOpt uint64, opts ...ClientOption) (*RegisterResult, error) {
+	ctx, q := toQuery(ctx, opts...)
+
+	q.Add("mail", mail)
+	q.Add("password", password)
+
+	if termsAccepted {
+		q.Add("termsaccepted", "yes")
+	}
+
+	if languageOpt != "" {
+		q.Add("language", languageOpt)
+	}
+
+	if referrerOpt > 0 {
+		q.Add("ref", fmt.Sprintf("%d", referrerOpt))
+	}
+
+	addDeviceIdentification(q)
+
+	rr := &RegisterResult{}
+
+	err := c.parseAPIOutput(rr)(c.get(ctx, "register", q))
+	if err != nil {
+		return nil, err
+	}
+
+	return rr, nil
+}
+
+// Invite sends an invitation to join pCloud to the e-mail address mail.
+// The optional parameter messageOpt is included in the invitation e-mail.
+// The optional parameter nameOpt is the name of the invited person.
+// https://docs.pcloud.com/methods/auth/invite.html
+func (c *Client) Invite(ctx context.Context, mail, messageOpt, nameOpt string, opts ...ClientOption) error {
+	ctx, q := toQuery(ctx, opts...)
+
+	q.Add("mail", mail)
+
+	if messageOpt != "" {
+		q.Add("message", messageOpt)
+	}
+
+	if nameOpt != "" {
+		q.Add("name", nameOpt)
+	}
+
+	r := &result{}
+
+	err := c.parseAPIOutput(r)(c.get(ctx, "invite", q))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// InvitesList is returned by the SDK ListInvites() method.
+type InvitesList struct {
+	result
+	Invites []Invite
+}
+
+// Invite contains information about an invitation sent by the current user.
+type Invite struct {
+	InviteID   uint64
+	Email      string
+	Invited    time.Time
+	Registered time.Time // zero unless the invited user has registered
+	Rewarded   bool
+}
+
+// UnmarshalJSON decodes the Invite, with its times in any of the formats of APITime.
+func (i *Invite) UnmarshalJSON(data []byte) error {
+	type invite Invite
+	times := struct {
+		*invite
+		Invited    APITime
+		Registered APITime
+	}{invite: (*invite)(i)}
+
+	err := json.Unmarshal(data, &times)
+	if err != nil {
+		return err
+	}
+
+	i.Invited, i.Registered = times.Invited.Time, times.Registered.Time
+
+	return nil
+}
+
+// ListInvites gets a list of the invitations sent by the current user, along with their
+// status (i.e. whether the invited user has since registered).
+// https://docs.pcloud.com/methods/auth/userinvites.html
+func (c *Client) ListInvites(ctx context.Context, opts ...ClientOption) (*InvitesList, error) {
+	ctx, q := toQuery(ctx, opts...)
+
+	il := &InvitesList{}
+
+	err := c.parseAPIOutput(il)(c.get(ctx, "userinvites", q))
+	if err != nil {
+		return nil, err
+	}
+
+	return il, nil
+}
+
+// SendVerificationEmail sends to the current user an e-mail with a link to verify their
+// e-mail address.
+// https://docs.pcloud.com/methods/auth/sendverificationemail.html
+func (c *Client) SendVerificationEmail(ctx context.Context, opts ...ClientOption) error {
+	ctx, q := toQuery(ctx, opts...)
+
+	r := &result{}
+
+	err := c.parseAPIOutput(r)(c.get(ctx, "sendverificationemail", q))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// VerifyEmailResult is returned by the SDK VerifyEmail() method.
+type VerifyEmailResult struct {
+	result
+	Email  string
+	UserID uint64
+}
+
+// VerifyEmail verifies the e-mail address of a user with the code received by e-mail from
+// SendVerificationEmail.
+// Upon success, it returns the verified e-mail address and the userid it belongs to.
+// https://docs.pcloud.com/methods/auth/verifyemail.html
+func (c *Client) VerifyEmail(ctx context.Context, code string, opts ...ClientOption) (*VerifyEmailResult, error) {
+	ctx, q := toQuery(ctx, opts...)
+
+	q.Add("code", code)
+
+	ver := &VerifyEmailResult{}
+
+	err := c.parseAPIOutput(ver)(c.get(ctx, "verifyemail", q))
+	if err != nil {
+		return nil, err
+	}
+
+	return ver, nil
+}
+
+// ChangePassword changes the password of the current user.
+// Note that all auth tokens of the user, other than the one used to make this call, are
+// invalidated by pCloud.
+// https://docs.pcloud.com/methods/auth/changepassword.html
+func (c *Client) ChangePassword(ctx context.Context, oldPassword, newPassword string, opts ...ClientOption) error {
+	ctx, q := toQuery(ctx, opts...)
+
+	q.Add("oldpassword", oldPassword)
+	q.Add("newpassword", newPassword)
+
+	r := &result{}
+
+	err := c.parseAPIOutput(r)(c.get(ctx, "changepassword", q))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// LostPassword sends to the e-mail address mail a message with a link to reset the password
+// of the account.
+// The code contained in the link is then passed to ResetPassword.
+// https://docs.pcloud.com/methods/auth/lostpassword.html
+func (c *Client) LostPassword(ctx context.Context, mail string, opts ...ClientOption) error {
+	ctx, q := toQuery(ctx, opts...)
+
+	q.Add("mail", mail)
+
+	r := &result{}
+
+	err := c.parseAPIOutput(r)(c.get(ctx, "lostpassword", q))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ResetPassword sets the password of an account to newPassword, using the code received by
+// e-mail from LostPassword.
+// https://docs.pcloud.com/methods/auth/resetpassword.html
+func (c *Client) ResetPassword(ctx context.Context, code, newPassword string, opts ...ClientOption) error {
+	ctx, q := toQuery(ctx, opts...)
+
+	q.Add("code", code)
+	q.Add("newpassword", newPassword)
+
+	r := &result{}
+
+	err := c.parseAPIOutput(r)(c.get(ctx, "resetpassword", q))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SendChangeMail starts the flow to change the e-mail address of the current user.
+// When called without newMailOpt, it sends to the current e-mail address of the user a message
+// containing a code that proves ownership of the account.
+// When called with newMailOpt and the codeOpt received from the first step, it sends to the
+// new e-mail address a message with a link to confirm the change. The code contained in the
+// link is then passed to ChangeMail.
+// https://docs.pcloud.com/methods/auth/sendchangemail.html
+func (c *Client) SendChangeMail(ctx context.Context, newMailOpt, codeOpt string, opts ...ClientOption) error {
+	ctx, q := toQuery(ctx, opts...)
+
+	if newMailOpt != "" {
+		q.Add("newmail", newMailOpt)
+	}
+
+	if codeOpt != "" {
+		q.Add("code", codeOpt)
+	}
+
+	r := &result{}
+
+	err := c.parseAPIOutput(r)(c.get(ctx, "sendchangemail", q))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ChangeMailResult is returned by the SDK ChangeMail() method.
+type ChangeMailResult struct {
+	result
+	Email string
+
+	// EmailVerified is false when pCloud requires the new e-mail address to be verified (see
+	// SendVerificationEmail) before some operations can be performed on the account.
+	EmailVerified bool
+}
+
+// ChangeMail changes the e-mail address of the current user, using the code received by
+// e-mail from SendChangeMail and the current password of the user.
+// https://docs.pcloud.com/methods/auth/changemail.html
+func (c *Client) ChangeMail(ctx context.Context, password, code string, opts ...ClientOption) (*ChangeMailResult, error) {
+	ctx, q := toQuery(ctx, opts...)
+
+	q.Add("password", password)
+	q.Add("code", code)
+
+	cmr := &ChangeMailResult{}
+
+	err := c.parseAPIOutput(cmr)(c.get(ctx, "changemail", q))
+	if err != nil {
+		return nil, err
+	}
+
+	return cmr, nil
+}
@@ -4,3 +4,8 @@ func (testsuite *IntegrationTestSuite) Test_ListTokens() {
 	_, err := testsuite.pcc.ListTokens(testsuite.ctx)
 	testsuite.Require().NoError(err)
 }
+
+func (testsuite *IntegrationTestSuite) Test_ListInvites() {
+	_, err := testsuite.pcc.ListInvites(testsuite.ctx)
+	testsuite.Require().NoError(err)
+}
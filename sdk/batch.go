@@ -0,0 +1,184 @@
+package sdk
+
+import (
+	"context"
+	"sync"
+)
+
+// BatchResult is the outcome of one item of a batch operation.
+type BatchResult struct {
+	// Index is the position of the item in the batch.
+	Index int
+
+	// Metadata describes the file or folder after the operation, when pCloud returned it.
+	Metadata *Metadata
+
+	// Attempts is the number of times the operation of the item was attempted.
+	Attempts int
+
+	Err error
+}
+
+// BatchFileOp is an item of BatchCopyFiles and BatchMoveFiles.
+type BatchFileOp struct {
+	File        FileRef
+	Destination FolderRef
+}
+
+// BatchOption is a functional parameter for the batch operations, such as BatchDeleteFiles.
+type BatchOption func(*batchConfig)
+
+type batchConfig struct {
+	concurrency int
+	retryPolicy RetryPolicy
+}
+
+// WithBatchConcurrency sets the maximum number of items of a batch that are processed at the
+// same time, each with its own request to pCloud. The default is 4.
+// Over HTTP/1.1, the number of requests in flight is also bounded by the connections of the
+// HTTP client of the Client (see TransportConfig.MaxConnsPerHost).
+func WithBatchConcurrency(n int) BatchOption {
+	return func(bc *batchConfig) {
+		if n < 1 {
+			n = 1
+		}
+		bc.concurrency = n
+	}
+}
+
+// WithBatchRetryPolicy sets the retry policy applied to each item of a batch.
+// The default is DefaultRetryPolicy.
+func WithBatchRetryPolicy(p RetryPolicy) BatchOption {
+	return func(bc *batchConfig) {
+		bc.retryPolicy = p
+	}
+}
+
+// BatchDeleteFiles deletes files. A failure does not stop the batch: the outcome of each file
+// is reported in the BatchResult at the same index. Failed deletions are retried as per the
+// retry policy of the batch (see WithBatchRetryPolicy).
+// A file that no longer exists when its deletion is retried is considered deleted by the
+// previous attempt.
+// https://docs.pcloud.com/methods/file/deletefile.html
+func (c *Client) BatchDeleteFiles(ctx context.Context, files []FileRef, opts ...BatchOption) []BatchResult {
+	return c.batch(ctx, len(files), ErrFileNotFound, func(ctx context.Context, i int) (*Metadata, error) {
+		fr, err := c.DeleteFile(ctx, files[i], WithCallRetryPolicy(NoRetryPolicy()))
+		if err != nil {
+			return nil, err
+		}
+		return &fr.Metadata, nil
+	}, opts...)
+}
+
+// BatchDeleteFolders deletes folders and all their contents. See BatchDeleteFiles.
+// https://docs.pcloud.com/methods/folder/deletefolderrecursive.html
+func (c *Client) BatchDeleteFolders(ctx context.Context, folders []FolderRef, opts ...BatchOption) []BatchResult {
+	return c.batch(ctx, len(folders), ErrDirectoryNotExists, func(ctx context.Context, i int) (*Metadata, error) {
+		_, err := c.DeleteFolderRecursive(ctx, folders[i], WithCallRetryPolicy(NoRetryPolicy()))
+		return nil, err
+	}, opts...)
+}
+
+// BatchCopyFiles copies files. See BatchDeleteFiles.
+// Existing destination files are overwritten, which makes retries safe.
+// https://docs.pcloud.com/methods/file/copyfile.html
+func (c *Client) BatchCopyFiles(ctx context.Context, ops []BatchFileOp, opts ...BatchOption) []BatchResult {
+	return c.batch(ctx, len(ops), 0, func(ctx context.Context, i int) (*Metadata, error) {
+		fr, err := c.CopyFileTo(ctx, ops[i].File, ops[i].Destination, CopyFileOptions{}, WithCallRetryPolicy(NoRetryPolicy()))
+		if err != nil {
+			return nil, err
+		}
+		return &fr.Metadata, nil
+	}, opts...)
+}
+
+// BatchMoveFiles moves (and/or renames) files. See BatchDeleteFiles.
+// A file that no longer exists when its move is retried is considered moved by the previous
+// attempt.
+// https://docs.pcloud.com/methods/file/renamefile.html
+func (c *Client) BatchMoveFiles(ctx context.Context, ops []BatchFileOp, opts ...BatchOption) []BatchResult {
+	return c.batch(ctx, len(ops), ErrFileNotFound, func(ctx context.Context, i int) (*Metadata, error) {
+		fr, err := c.RenameFile(ctx, ops[i].File, ops[i].Destination, WithCallRetryPolicy(NoRetryPolicy()))
+		if err != nil {
+			return nil, err
+		}
+		return &fr.Metadata, nil
+	}, opts...)
+}
+
+// batch calls op for the n items of a batch, with bounded concurrency, and retries the failed
+// items.
+// When a retry fails with the result code doneCode (if not 0) after an attempt whose response
+// was lost, that attempt is deemed to have succeeded.
+func (c *Client) batch(ctx context.Context, n int, doneCode int, op func(ctx context.Context, i int) (*Metadata, error), opts ...BatchOption) []BatchResult {
+	bc := batchConfig{
+		concurrency: 4,
+		retryPolicy: DefaultRetryPolicy(),
+	}
+
+	for _, opt := range opts {
+		opt(&bc)
+	}
+
+	results := make([]BatchResult, n)
+	sem := make(chan struct{}, bc.concurrency)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < n; i++ {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			results[i] = BatchResult{Index: i, Err: ctx.Err()}
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			results[i] = batchItem(ctx, i, bc.retryPolicy, doneCode, op)
+		}(i)
+	}
+
+	wg.Wait()
+
+	return results
+}
+
+// batchItem performs the operation of item i of a batch.
+func batchItem(ctx context.Context, i int, policy RetryPolicy, doneCode int, op func(ctx context.Context, i int) (*Metadata, error)) BatchResult {
+	br := BatchResult{Index: i}
+	responseLost := false
+
+	for {
+		br.Attempts++
+
+		br.Metadata, br.Err = op(ctx, i)
+		if br.Err == nil {
+			return br
+		}
+
+		if responseLost && doneCode != 0 && ErrorCode(br.Err) == doneCode {
+			br.Err = nil
+			return br
+		}
+
+		// without a result code, the operation may have taken place.
+		responseLost = ErrorCode(br.Err) == 0
+
+		retry := policy.shouldRetry(nil, br.Err)
+		if code := ErrorCode(br.Err); code != 0 {
+			retry = policy.shouldRetryCode(code)
+		}
+
+		if br.Attempts >= policy.MaxAttempts || !retry {
+			return br
+		}
+
+		if sleep(ctx, policy.delay(br.Attempts)) != nil {
+			return br
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package sdk_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+func TestBatchDeleteFiles(t *testing.T) {
+	var lock sync.Mutex
+	calls := map[string]int{}
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/deletefile", r.URL.Path)
+
+		id := r.URL.Query().Get("fileid")
+
+		lock.Lock()
+		calls[id]++
+		n := calls[id]
+		lock.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case id == "2":
+			fmt.Fprint(w, `{"result": 2009, "error": "File not found."}`)
+		case id == "3" && n == 1:
+			// the file is deleted but the response is lost.
+			w.WriteHeader(http.StatusBadGateway)
+		case id == "3":
+			fmt.Fprint(w, `{"result": 2009, "error": "File not found."}`)
+		case id == "4" && n == 1:
+			fmt.Fprint(w, `{"result": 5000, "error": "Internal error. Try again later."}`)
+		default:
+			fmt.Fprintf(w, `{"result": 0, "metadata": {"fileid": %s, "isdeleted": true}}`, id)
+		}
+	}))
+	defer srv.Close()
+
+	pcc := sdk.NewClient(srv.Client(), sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")))
+
+	policy := sdk.DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+
+	files := []sdk.FileRef{sdk.ByID(1), sdk.ByID(2), sdk.ByID(3), sdk.ByID(4)}
+
+	results := pcc.BatchDeleteFiles(context.Background(), files, sdk.WithBatchConcurrency(2), sdk.WithBatchRetryPolicy(policy))
+	require.Len(t, results, 4)
+
+	for i, br := range results {
+		assert.Equal(t, i, br.Index)
+	}
+
+	assert.NoError(t, results[0].Err)
+	assert.EqualValues(t, 1, results[0].Metadata.FileID)
+	assert.Equal(t, 1, results[0].Attempts)
+
+	assert.Equal(t, sdk.ErrFileNotFound, sdk.ErrorCode(results[1].Err))
+	assert.Equal(t, 1, results[1].Attempts)
+
+	assert.NoError(t, results[2].Err)
+	assert.Equal(t, 2, results[2].Attempts)
+
+	assert.NoError(t, results[3].Err)
+	assert.Equal(t, 2, results[3].Attempts)
+}
+
+func TestBatchCopyFiles_Cancelled(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected call to %s", r.URL.Path)
+	}))
+	defer srv.Close()
+
+	pcc := sdk.NewClient(srv.Client(), sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	ops := []sdk.BatchFileOp{
+		{File: sdk.ByID(1), Destination: sdk.ByPath("/a")},
+		{File: sdk.ByID(2), Destination: sdk.ByPath("/b")},
+	}
+
+	results := pcc.BatchCopyFiles(ctx, ops, sdk.WithBatchConcurrency(1))
+	require.Len(t, results, 2)
+	for _, br := range results {
+		assert.Error(t, br.Err)
+	}
+}
+
+func TestBatchMoveFiles_Concurrency(t *testing.T) {
+	const concurrency = 3
+
+	var lock sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	full := make(chan struct{})
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/renamefile", r.URL.Path)
+
+		lock.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+			if maxInFlight == concurrency {
+				close(full)
+			}
+		}
+		lock.Unlock()
+
+		defer func() {
+			lock.Lock()
+			inFlight--
+			lock.Unlock()
+		}()
+
+		// the first items wait for the others to be in flight.
+		select {
+		case <-full:
+		case <-time.After(5 * time.Second):
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"result": 0, "metadata": {"fileid": %s}}`, r.URL.Query().Get("fileid"))
+	}))
+	defer srv.Close()
+
+	pcc := sdk.NewClient(srv.Client(), sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")))
+
+	var ops []sdk.BatchFileOp
+	for i := 1; i <= 2*concurrency; i++ {
+		ops = append(ops, sdk.BatchFileOp{File: sdk.ByID(uint64(i)), Destination: sdk.ByPath("/dest/")})
+	}
+
+	results := pcc.BatchMoveFiles(context.Background(), ops, sdk.WithBatchConcurrency(concurrency))
+	require.Len(t, results, len(ops))
+	for _, br := range results {
+		assert.NoError(t, br.Err)
+	}
+
+	// the items were processed in parallel, within the bound of the concurrency.
+	assert.Equal(t, concurrency, maxInFlight)
+}
@@ -0,0 +1,477 @@
+package sdk
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// BinAPITransport is an http.RoundTripper that sends the requests of a Client over pCloud's
+// binary protocol instead of HTTP. The binary protocol removes the overhead of HTTP and JSON,
+// which speeds up workloads that make many small calls, such as full account scans.
+//
+// The requests are sent over a single, persistent TLS connection, which is re-established
+// when it breaks. Use it as the transport of the *http.Client passed to NewClient:
+//
+//	pcc := sdk.NewClient(&http.Client{Transport: sdk.NewBinAPITransport("binapi.pcloud.com:443")})
+//
+// The host of the request is ignored: requests are always sent to Addr. Accounts registered in
+// Europe must use the binary API server returned by GetAPIServer on eapi.pcloud.com.
+// Calls that upload multipart forms, such as UploadFiles, are not supported.
+// https://docs.pcloud.com/protocols/binary_protocol/
+type BinAPITransport struct {
+	// Addr is the address (host:port) of the binary API server. See APIServer.BinAPI for the
+	// servers pCloud recommends.
+	Addr string
+
+	// TLSClientConfig is the TLS configuration. nil means Go's default configuration.
+	TLSClientConfig *tls.Config
+
+	// DialContext, if set, is used to establish connections. TLS is not applied to connections
+	// obtained this way.
+	DialContext func(ctx context.Context, network, addr string) (net.Conn, error)
+
+	lock sync.Mutex
+	conn net.Conn
+	rd   *bufio.Reader
+}
+
+// NewBinAPITransport creates a new BinAPITransport that connects to the binary API server at
+// addr (host:port) over TLS.
+func NewBinAPITransport(addr string) *BinAPITransport {
+	return &BinAPITransport{Addr: addr}
+}
+
+// binParamString is the type of string parameters in the binary protocol.
+const binParamString = 0
+
+// RoundTrip implements http.RoundTripper.
+func (t *BinAPITransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		defer req.Body.Close() //nolint:errcheck
+	}
+
+	if mt, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type")); strings.HasPrefix(mt, "multipart/") {
+		return nil, errors.Errorf("binapi: multipart requests are not supported: %s", req.URL.Path)
+	}
+
+	var data []byte
+	if req.Body != nil {
+		var err error
+		data, err = io.ReadAll(req.Body)
+		if err != nil {
+			return nil, errors.Wrap(err, "binapi: request body")
+		}
+		if len(data) == 0 {
+			data = nil
+		}
+	}
+
+	msg, err := encodeBinRequest(strings.TrimPrefix(req.URL.Path, "/"), req.URL.Query(), data)
+	if err != nil {
+		return nil, err
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	ct, body, err := t.exchange(req.Context(), msg)
+	if err != nil {
+		// the state of the connection is unknown: start afresh with the next request.
+		t.closeConn()
+		return nil, err
+	}
+
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{ct}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// CloseIdleConnections closes the connection to the binary API server.
+// Note that pCloud file descriptors are bound to the connection they were opened on.
+func (t *BinAPITransport) CloseIdleConnections() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.closeConn()
+}
+
+// exchange sends a request and reads its response.
+// It returns the response translated to what the HTTP API would have returned: JSON, or the
+// data of the response when there is data.
+func (t *BinAPITransport) exchange(ctx context.Context, msg []byte) (string, []byte, error) {
+	resps, err := t.exchangeAll(ctx, [][]byte{msg})
+	if err != nil {
+		return "", nil, err
+	}
+
+	return resps[0].contentType, resps[0].body, nil
+}
+
+// binResponse is a response of the binary API translated to its HTTP API equivalent.
+type binResponse struct {
+	// id is the value of the "id" global parameter of the request, if any.
+	id          string
+	contentType string
+	body        []byte
+}
+
+// exchangeAll sends requests without waiting for their responses, then reads the responses,
+// which it returns in the order they were received.
+func (t *BinAPITransport) exchangeAll(ctx context.Context, msgs [][]byte) ([]binResponse, error) {
+	if t.conn == nil {
+		err := t.connect(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// unblock the connection when ctx is done.
+	stop := context.AfterFunc(ctx, func() { _ = t.conn.Close() })
+	defer stop()
+
+	// requests are written concurrently with the reading of responses, otherwise the
+	// connection could deadlock when pCloud waits for its responses to be read.
+	writeErr := make(chan error, 1)
+	go func() {
+		for _, msg := range msgs {
+			_, err := t.conn.Write(msg)
+			if err != nil {
+				writeErr <- err
+				return
+			}
+		}
+		writeErr <- nil
+	}()
+
+	resps := make([]binResponse, 0, len(msgs))
+
+	for range msgs {
+		resp, err := t.readResponse()
+		if err != nil {
+			_ = t.conn.Close() // unblocks the writer
+			<-writeErr
+			return nil, binCtxErr(ctx, err)
+		}
+		resps = append(resps, resp)
+	}
+
+	err := <-writeErr
+	if err != nil {
+		return nil, binCtxErr(ctx, errors.Wrap(err, "binapi: write"))
+	}
+
+	return resps, nil
+}
+
+// readResponse reads a response, and the data that follows it, if any.
+func (t *BinAPITransport) readResponse() (binResponse, error) {
+	var lenBuf [4]byte
+
+	_, err := io.ReadFull(t.rd, lenBuf[:])
+	if err != nil {
+		return binResponse{}, errors.Wrap(err, "binapi: read")
+	}
+
+	buf := make([]byte, binary.LittleEndian.Uint32(lenBuf[:]))
+
+	_, err = io.ReadFull(t.rd, buf)
+	if err != nil {
+		return binResponse{}, errors.Wrap(err, "binapi: read")
+	}
+
+	dec := &binDecoder{buf: buf, dataLen: -1}
+
+	v, err := dec.value()
+	if err != nil {
+		return binResponse{}, err
+	}
+
+	resp := binResponse{}
+
+	if h, ok := v.(map[string]interface{}); ok && h["id"] != nil {
+		resp.id = fmt.Sprint(h["id"])
+	}
+
+	if dec.dataLen < 0 {
+		resp.contentType = "application/json; charset=utf-8"
+		resp.body, err = json.Marshal(v)
+		if err != nil {
+			return binResponse{}, errors.Wrap(err, "binapi: marshal")
+		}
+		return resp, nil
+	}
+
+	resp.contentType = "application/octet-stream"
+	resp.body = make([]byte, dec.dataLen)
+
+	_, err = io.ReadFull(t.rd, resp.body)
+	if err != nil {
+		return binResponse{}, errors.Wrap(err, "binapi: read data")
+	}
+
+	return resp, nil
+}
+
+func (t *BinAPITransport) connect(ctx context.Context) error {
+	var conn net.Conn
+	var err error
+
+	if t.DialContext != nil {
+		conn, err = t.DialContext(ctx, "tcp", t.Addr)
+	} else {
+		d := &tls.Dialer{Config: t.TLSClientConfig}
+		conn, err = d.DialContext(ctx, "tcp", t.Addr)
+	}
+	if err != nil {
+		return errors.Wrap(err, "binapi: dial")
+	}
+
+	t.conn = conn
+	t.rd = bufio.NewReader(conn)
+
+	return nil
+}
+
+func (t *BinAPITransport) closeConn() {
+	if t.conn != nil {
+		_ = t.conn.Close()
+		t.conn = nil
+		t.rd = nil
+	}
+}
+
+// binCtxErr returns the error of ctx if it is done, since it is the cause of err, or err.
+func binCtxErr(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return errors.WithStack(context.Cause(ctx))
+	}
+	return err
+}
+
+// encodeBinRequest encodes a request in the binary protocol.
+// All parameters are sent as strings, as they are with the HTTP API.
+func encodeBinRequest(method string, params url.Values, data []byte) ([]byte, error) {
+	if len(method) == 0 || len(method) > 127 {
+		return nil, errors.Errorf("binapi: invalid method name: '%s'", method)
+	}
+
+	var b bytes.Buffer
+
+	methodLen := byte(len(method))
+	if data != nil {
+		methodLen |= 0x80
+	}
+	b.WriteByte(methodLen)
+
+	if data != nil {
+		_ = binary.Write(&b, binary.LittleEndian, uint64(len(data)))
+	}
+
+	b.WriteString(method)
+
+	if len(params) > 255 {
+		return nil, errors.Errorf("binapi: too many parameters: %d", len(params))
+	}
+	b.WriteByte(byte(len(params)))
+
+	for name, values := range params {
+		if len(name) == 0 || len(name) > 63 {
+			return nil, errors.Errorf("binapi: invalid parameter name: '%s'", name)
+		}
+
+		value := ""
+		if len(values) > 0 {
+			value = values[0]
+		}
+
+		b.WriteByte(byte(len(name)) | binParamString<<6)
+		b.WriteString(name)
+		_ = binary.Write(&b, binary.LittleEndian, uint32(len(value)))
+		b.WriteString(value)
+	}
+
+	if b.Len() > 0xFFFF {
+		return nil, errors.Errorf("binapi: request is too large: %d bytes", b.Len())
+	}
+
+	msg := make([]byte, 2, 2+b.Len()+len(data))
+	binary.LittleEndian.PutUint16(msg, uint16(b.Len()))
+	msg = append(msg, b.Bytes()...)
+	msg = append(msg, data...)
+
+	return msg, nil
+}
+
+// binDecoder decodes the values of a binary protocol response.
+type binDecoder struct {
+	buf     []byte
+	pos     int
+	strings []string
+
+	// dataLen is the length of the data that follows the response, or -1 if there is none.
+	dataLen int64
+}
+
+func (d *binDecoder) value() (interface{}, error) {
+	t, err := d.byte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case t <= 3: // string, with length on t+1 bytes
+		n, err := d.uint(int(t) + 1)
+		if err != nil {
+			return nil, err
+		}
+		return d.string(int(n))
+
+	case t <= 7: // string reused, with id on t-3 bytes
+		id, err := d.uint(int(t) - 3)
+		if err != nil {
+			return nil, err
+		}
+		return d.reused(int(id))
+
+	case t <= 15: // number on t-7 bytes
+		return d.uint(int(t) - 7)
+
+	case t == 16: // hash
+		h := map[string]interface{}{}
+		for {
+			if d.peek() == 255 {
+				d.pos++
+				return h, nil
+			}
+
+			k, err := d.value()
+			if err != nil {
+				return nil, err
+			}
+
+			key, ok := k.(string)
+			if !ok {
+				return nil, errors.Errorf("binapi: hash key is not a string: %v", k)
+			}
+
+			h[key], err = d.value()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+	case t == 17: // array
+		a := []interface{}{}
+		for {
+			if d.peek() == 255 {
+				d.pos++
+				return a, nil
+			}
+
+			v, err := d.value()
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, v)
+		}
+
+	case t == 18:
+		return false, nil
+
+	case t == 19:
+		return true, nil
+
+	case t == 20: // data, which follows the response
+		n, err := d.uint(8)
+		if err != nil {
+			return nil, err
+		}
+		d.dataLen = int64(n)
+		return n, nil
+
+	case t >= 100 && t <= 149: // short string
+		return d.string(int(t) - 100)
+
+	case t >= 150 && t <= 199: // short string reused
+		return d.reused(int(t) - 150)
+
+	case t >= 200 && t <= 219: // small number
+		return uint64(t) - 200, nil
+
+	default:
+		return nil, errors.Errorf("binapi: unknown value type: %d", t)
+	}
+}
+
+func (d *binDecoder) byte() (byte, error) {
+	if d.pos >= len(d.buf) {
+		return 0, errors.New("binapi: truncated response")
+	}
+
+	b := d.buf[d.pos]
+	d.pos++
+
+	return b, nil
+}
+
+func (d *binDecoder) peek() byte {
+	if d.pos >= len(d.buf) {
+		return 0
+	}
+	return d.buf[d.pos]
+}
+
+func (d *binDecoder) uint(n int) (uint64, error) {
+	if d.pos+n > len(d.buf) {
+		return 0, errors.New("binapi: truncated response")
+	}
+
+	var v uint64
+	for i := n - 1; i >= 0; i-- {
+		v = v<<8 | uint64(d.buf[d.pos+i])
+	}
+	d.pos += n
+
+	return v, nil
+}
+
+func (d *binDecoder) string(n int) (string, error) {
+	if d.pos+n > len(d.buf) {
+		return "", errors.New("binapi: truncated response")
+	}
+
+	s := string(d.buf[d.pos : d.pos+n])
+	d.pos += n
+	d.strings = append(d.strings, s)
+
+	return s, nil
+}
+
+func (d *binDecoder) reused(id int) (string, error) {
+	if id >= len(d.strings) {
+		return "", errors.Errorf("binapi: unknown string id: %d", id)
+	}
+	return d.strings[id], nil
+}
@@ -0,0 +1,206 @@
+package sdk_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+// binRequest is a request received by the fake binary API server.
+type binRequest struct {
+	method string
+	params map[string]string
+	data   []byte
+}
+
+// readBinRequest reads a binary protocol request.
+func readBinRequest(t *testing.T, rd *bufio.Reader) binRequest {
+	t.Helper()
+
+	var reqLen uint16
+	require.NoError(t, binary.Read(rd, binary.LittleEndian, &reqLen))
+
+	msg := make([]byte, reqLen)
+	_, err := io.ReadFull(rd, msg)
+	require.NoError(t, err)
+
+	r := bytes.NewReader(msg)
+
+	methodLen, _ := r.ReadByte()
+
+	var dataLen uint64
+	if methodLen&0x80 != 0 {
+		require.NoError(t, binary.Read(r, binary.LittleEndian, &dataLen))
+	}
+
+	method := make([]byte, methodLen&0x7F)
+	_, _ = io.ReadFull(r, method)
+
+	req := binRequest{method: string(method), params: map[string]string{}}
+
+	count, _ := r.ReadByte()
+	for i := 0; i < int(count); i++ {
+		nameLen, _ := r.ReadByte()
+		require.Zero(t, nameLen>>6, "only string parameters are expected")
+
+		name := make([]byte, nameLen&0x3F)
+		_, _ = io.ReadFull(r, name)
+
+		var valueLen uint32
+		require.NoError(t, binary.Read(r, binary.LittleEndian, &valueLen))
+
+		value := make([]byte, valueLen)
+		_, _ = io.ReadFull(r, value)
+
+		req.params[string(name)] = string(value)
+	}
+
+	req.data = make([]byte, dataLen)
+	_, err = io.ReadFull(rd, req.data)
+	require.NoError(t, err)
+
+	return req
+}
+
+// binResponse encodes a binary protocol response. Values are already encoded.
+func binResponse(values ...[]byte) []byte {
+	body := bytes.Join(values, nil)
+
+	resp := make([]byte, 4, 4+len(body))
+	binary.LittleEndian.PutUint32(resp, uint32(len(body)))
+
+	return append(resp, body...)
+}
+
+func binString(s string) []byte {
+	if len(s) < 50 {
+		return append([]byte{byte(100 + len(s))}, s...)
+	}
+	b := []byte{3, 0, 0, 0, 0}
+	binary.LittleEndian.PutUint32(b[1:], uint32(len(s)))
+	return append(b, s...)
+}
+
+func binNumber(n uint64) []byte {
+	if n < 20 {
+		return []byte{byte(200 + n)}
+	}
+	b := make([]byte, 9)
+	b[0] = 15
+	binary.LittleEndian.PutUint64(b[1:], n)
+	return b
+}
+
+func binReusedString(id int) []byte {
+	return []byte{byte(150 + id)}
+}
+
+func binArray(values ...[]byte) []byte {
+	return append(append([]byte{17}, bytes.Join(values, nil)...), 255)
+}
+
+func binHash(kvs ...[]byte) []byte {
+	return append(append([]byte{16}, bytes.Join(kvs, nil)...), 255)
+}
+
+func TestBinAPITransport(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close() //nolint:errcheck
+
+	requests := make(chan binRequest, 10)
+
+	go func() {
+		defer server.Close() //nolint:errcheck
+
+		rd := bufio.NewReader(server)
+
+		for {
+			if _, err := rd.Peek(1); err != nil {
+				return
+			}
+
+			req := readBinRequest(t, rd)
+			requests <- req
+
+			switch {
+			case req.method == "listfolder" && req.params["path"] == "":
+				_, _ = server.Write(binResponse(binHash(
+					binString("result"), binNumber(0),
+					binString("metadata"), binHash(
+						binString("name"), binString("/"),
+						binString("isfolder"), []byte{19},
+						binString("folderid"), binNumber(0),
+						binString("contents"), binArray(binHash(
+							binReusedString(2), binString("sub"), // 2 is the id of "name"
+						)),
+					),
+				)))
+
+			case req.method == "file_read":
+				_, _ = server.Write(binResponse(binHash(
+					binString("result"), binNumber(0),
+					binString("data"), append([]byte{20}, binary.LittleEndian.AppendUint64(nil, 5)...),
+				)))
+				_, _ = server.Write([]byte("hello"))
+
+			case req.method == "file_write":
+				_, _ = server.Write(binResponse(binHash(
+					binString("result"), binNumber(0),
+					binString("bytes"), binNumber(uint64(len(req.data))),
+				)))
+
+			default:
+				_, _ = server.Write(binResponse(binHash(
+					binString("result"), binNumber(2005),
+					binString("error"), binString("Directory does not exist."),
+				)))
+			}
+		}
+	}()
+
+	transport := sdk.NewBinAPITransport("binapi.test:443")
+	transport.DialContext = func(context.Context, string, string) (net.Conn, error) {
+		return client, nil
+	}
+
+	pcc := sdk.NewClient(&http.Client{Transport: transport})
+	ctx := context.Background()
+
+	lf, err := pcc.List(ctx, sdk.ByID(sdk.RootFolderID), sdk.ListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "/", lf.Metadata.Name)
+	assert.True(t, lf.Metadata.IsFolder)
+	require.Len(t, lf.Metadata.Contents, 1)
+	assert.Equal(t, "sub", lf.Metadata.Contents[0].Name)
+
+	req := <-requests
+	assert.Equal(t, "listfolder", req.method)
+	assert.Equal(t, "0", req.params["folderid"])
+
+	data, err := pcc.FileRead(ctx, 7, 5)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+
+	req = <-requests
+	assert.Equal(t, "file_read", req.method)
+	assert.Equal(t, "7", req.params["fd"])
+
+	fdt, err := pcc.FileWrite(ctx, 7, []byte("hello, world"))
+	require.NoError(t, err)
+	assert.EqualValues(t, 12, fdt.Bytes)
+	assert.Equal(t, "hello, world", string((<-requests).data))
+
+	_, err = pcc.List(ctx, sdk.ByPath("/nope"), sdk.ListOptions{})
+	require.Error(t, err)
+	assert.Equal(t, sdk.ErrDirectoryNotExists, sdk.ErrorCode(err))
+}
@@ -0,0 +1,73 @@
+package sdk
+
+import (
+	"io"
+	"net/http"
+	"sync"
+)
+
+// bufferClasses are the capacities of the buffers held by the buffer pool.
+var bufferClasses = []int{64 << 10, 256 << 10, 1 << 20, 4 << 20, 16 << 20}
+
+// bufferPools holds one pool per buffer class. The pools hold *[]byte.
+var bufferPools = func() []*sync.Pool {
+	pools := make([]*sync.Pool, len(bufferClasses))
+	for i := range pools {
+		pools[i] = &sync.Pool{}
+	}
+	return pools
+}()
+
+// GetBuffer returns a byte slice of length n. Its capacity is the smallest buffer class that
+// fits n bytes. When n is larger than the largest class, the slice is allocated.
+// The slice is taken from a pool of buffers to avoid allocating large transient slices when
+// transferring data. It should be returned with PutBuffer once done with.
+func GetBuffer(n int) []byte {
+	for i, size := range bufferClasses {
+		if n > size {
+			continue
+		}
+
+		if bp, ok := bufferPools[i].Get().(*[]byte); ok {
+			return (*bp)[:n]
+		}
+		return make([]byte, n, size)
+	}
+
+	return make([]byte, n)
+}
+
+// PutBuffer returns b to the pool of buffers, for re-use by GetBuffer.
+// b must not be used once returned. Slices whose capacity is not that of a buffer class are
+// ignored, so that any slice may be passed to PutBuffer.
+// The data returned by FileRead, FilePRead and FilePReadIfMod may be returned to the pool.
+func PutBuffer(b []byte) {
+	for i, size := range bufferClasses {
+		if cap(b) == size {
+			b = b[:0]
+			bufferPools[i].Put(&b)
+			return
+		}
+	}
+}
+
+// readBody reads the body of resp.
+// File data of a known, large enough, length is read into a pooled buffer (see GetBuffer).
+// Other responses are small and may be kept (see WithMetadataCache): they are not pooled.
+func readBody(resp *http.Response) ([]byte, error) {
+	n := resp.ContentLength
+	if resp.Header.Get("Content-Type") != "application/octet-stream" ||
+		n < int64(bufferClasses[0]/2) || n > int64(bufferClasses[len(bufferClasses)-1]) {
+		return io.ReadAll(resp.Body)
+	}
+
+	b := GetBuffer(int(n))
+
+	_, err := io.ReadFull(resp.Body, b)
+	if err != nil {
+		PutBuffer(b)
+		return nil, err
+	}
+
+	return b, nil
+}
@@ -0,0 +1,62 @@
+package sdk_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+func TestGetBuffer(t *testing.T) {
+	b := sdk.GetBuffer(100)
+	assert.Len(t, b, 100)
+	assert.Equal(t, 64<<10, cap(b))
+	sdk.PutBuffer(b)
+
+	b = sdk.GetBuffer(1 << 20)
+	assert.Len(t, b, 1<<20)
+	assert.Equal(t, 1<<20, cap(b))
+	sdk.PutBuffer(b)
+
+	b = sdk.GetBuffer(5 << 20)
+	assert.Equal(t, 16<<20, cap(b))
+	sdk.PutBuffer(b)
+
+	b = sdk.GetBuffer(32 << 20)
+	assert.Len(t, b, 32<<20)
+
+	// not a buffer class: ignored.
+	sdk.PutBuffer(b)
+	sdk.PutBuffer(make([]byte, 10))
+	sdk.PutBuffer(nil)
+}
+
+func TestFileRead_PooledBuffer(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789abcdef"), 100_000)
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/file_read", r.URL.Path)
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		_, _ = w.Write(data)
+	}))
+	defer srv.Close()
+
+	pcc := sdk.NewClient(srv.Client(), sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")))
+
+	for i := 0; i < 3; i++ {
+		got, err := pcc.FileRead(context.Background(), 1, uint64(len(data)))
+		require.NoError(t, err)
+		assert.Equal(t, data, got)
+		assert.Equal(t, 4<<20, cap(got))
+		sdk.PutBuffer(got)
+	}
+}
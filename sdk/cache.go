@@ -0,0 +1,374 @@
+package sdk
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// WithMetadataCache enables an in-process cache of the responses of listfolder and stat (see
+// List and Stat), so that repeated calls during a sync pass don't hammer the API.
+// The cache holds at most size responses, the least recently used ones being evicted first.
+// Responses expire ttl after they were fetched. When ttl is 0, they do not expire.
+//
+// The cache is kept coherent by the diff events seen by the Client (see Changes and Subscribe):
+// a cached response is discarded when an event concerns one of the files or folders it
+// contains. It is also kept coherent with the changes made by the Client itself: the folder and
+// file methods (CreateFolder, RenameFile, TrashRestore, etc) discard the responses they
+// affect, and the other methods that are not read-only (FileWrite, ShareFolder, etc) discard
+// the whole cache.
+// Use WithCallCacheBypass to skip the cache for a single call.
+func WithMetadataCache(size int, ttl time.Duration) Option {
+	return func(c *Client) {
+		if size <= 0 {
+			c.cache = nil
+			return
+		}
+
+		c.cache = newMetadataCache(size, ttl)
+	}
+}
+
+// WithCallCacheBypass makes the call fetch its response from pCloud rather than from the
+// metadata cache (see WithMetadataCache). The cache is refreshed with the response.
+func WithCallCacheBypass() ClientOption {
+	return func(co *callOptions) {
+		co.bypassCache = true
+	}
+}
+
+// cachedEndpoints are the endpoints whose responses are kept in the metadata cache.
+var cachedEndpoints = map[string]bool{
+	"listfolder": true,
+	"stat":       true,
+}
+
+// mutatingEndpoints are the endpoints that change the file system. Their responses are used
+// to invalidate the metadata cache.
+var mutatingEndpoints = map[string]bool{
+	"copyfile":                true,
+	"copyfolder":              true,
+	"createfolder":            true,
+	"createfolderifnotexists": true,
+	"deletefile":              true,
+	"deletefolder":            true,
+	"deletefolderrecursive":   true,
+	"renamefile":              true,
+	"renamefolder":            true,
+	"revertrevision":          true,
+	"trash_restore":           true,
+	"uploadfile":              true,
+}
+
+// readOnlyEndpoints are the endpoints that do not change the files and folders. The endpoints
+// that are neither read-only nor in mutatingEndpoints purge the metadata cache: this covers
+// the changes made through file descriptors, to the shares and the public links, and by the
+// endpoints added to the SDK later on.
+var readOnlyEndpoints = map[string]bool{
+	"changemail":                   true,
+	"changepassword":               true,
+	"checksumfile":                 true,
+	"crypto_changeuserprivate":     true,
+	"crypto_getfilekey":            true,
+	"crypto_getfolderkey":          true,
+	"crypto_getuserhint":           true,
+	"crypto_getuserkeys":           true,
+	"crypto_sendchangeuserprivate": true,
+	"crypto_setuserkeys":           true,
+	"currentserver":                true,
+	"diff":                         true,
+	"feedback":                     true,
+	"file_checksum":                true,
+	"file_lock":                    true,
+	"file_pread":                   true,
+	"file_pread_ifmod":             true,
+	"file_read":                    true,
+	"file_seek":                    true,
+	"file_size":                    true,
+	"getapiserver":                 true,
+	"getdigest":                    true,
+	"getfilehistory":               true,
+	"getfilelink":                  true,
+	"getip":                        true,
+	"getnotifications":             true,
+	"getthumblink":                 true,
+	"listfolder":                   true,
+	"listpublinks":                 true,
+	"listrevisions":                true,
+	"listshares":                   true,
+	"listtokens":                   true,
+	"login":                        true,
+	"logout":                       true,
+	"lostpassword":                 true,
+	"readnotifications":            true,
+	"register":                     true,
+	"resetpassword":                true,
+	"sendchangemail":               true,
+	"sendverificationemail":        true,
+	"setlanguage":                  true,
+	"stat":                         true,
+	"supportedlanguages":           true,
+	"tfa_login":                    true,
+	"trash_list":                   true,
+	"userinfo":                     true,
+	"userinvites":                  true,
+	"verifyemail":                  true,
+}
+
+// metadataCache is an LRU cache of API responses.
+type metadataCache struct {
+	lock    sync.Mutex
+	size    int
+	ttl     time.Duration
+	lru     *list.List // of *cacheEntry, most recently used first
+	entries map[string]*list.Element
+}
+
+// cacheEntry is a response held in the metadataCache.
+type cacheEntry struct {
+	key     string
+	body    []byte
+	expires time.Time
+
+	// covers holds the files and folders contained in the response (see fileKey and folderKey).
+	covers map[string]bool
+}
+
+func newMetadataCache(size int, ttl time.Duration) *metadataCache {
+	return &metadataCache{
+		size:    size,
+		ttl:     ttl,
+		lru:     list.New(),
+		entries: map[string]*list.Element{},
+	}
+}
+
+func fileKey(fileID uint64) string {
+	return fmt.Sprintf("f%d", fileID)
+}
+
+func folderKey(folderID uint64) string {
+	return fmt.Sprintf("d%d", folderID)
+}
+
+// cachedGet is get with the metadata cache.
+func (c *Client) cachedGet(ctx context.Context, endpoint string, query url.Values) ([]byte, error) {
+	// the key must be computed before the call: the auth token is added to the query then.
+	key := endpoint + "?" + query.Encode()
+
+	if !callOptionsFromContext(ctx).bypassCache {
+		if body, ok := c.cache.get(key); ok {
+			return body, nil
+		}
+	}
+
+	_, body, err := c.do(ctx, http.MethodGet, endpoint, query, "application/json", nil, nil)
+	if err == nil && resultCode(body) == 0 && !filtersMetadata(query) {
+		c.cache.put(key, body)
+	}
+	return body, err
+}
+
+// filtersMetadata returns true when the call of query filters the fields of the metadata of its
+// response (see WithGlobalOptionFilterMeta): the IDs that the metadata cache relies on may be
+// missing from it.
+func filtersMetadata(query url.Values) bool {
+	return query.Has("filtermeta")
+}
+
+// observeMutation invalidates the metadata cache, if any, after a successful call to an
+// endpoint that is not read-only, with the query query.
+func (c *Client) observeMutation(endpoint string, query url.Values, body []byte) {
+	if c.cache == nil || readOnlyEndpoints[endpoint] || resultCode(body) != 0 {
+		return
+	}
+
+	// the responses of the other endpoints do not describe their changes, and a moved folder
+	// changes the paths of all its descendants.
+	if !mutatingEndpoints[endpoint] || endpoint == "renamefolder" || filtersMetadata(query) {
+		c.cache.purge()
+		return
+	}
+
+	c.cache.invalidateByResponse(body)
+}
+
+// get returns the response stored under key, if any and not expired.
+func (mc *metadataCache) get(key string) ([]byte, bool) {
+	mc.lock.Lock()
+	defer mc.lock.Unlock()
+
+	el, ok := mc.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	ce := el.Value.(*cacheEntry)
+	if mc.ttl > 0 && time.Now().After(ce.expires) {
+		mc.remove(el)
+		return nil, false
+	}
+
+	mc.lru.MoveToFront(el)
+
+	return ce.body, true
+}
+
+// put stores the response body under key.
+func (mc *metadataCache) put(key string, body []byte) {
+	r := &struct {
+		Metadata *Metadata
+	}{}
+
+	if err := json.Unmarshal(body, r); err != nil || r.Metadata == nil {
+		return
+	}
+
+	ce := &cacheEntry{
+		key:     key,
+		body:    body,
+		expires: time.Now().Add(mc.ttl),
+		covers:  map[string]bool{},
+	}
+	addCovers(ce.covers, r.Metadata)
+
+	mc.lock.Lock()
+	defer mc.lock.Unlock()
+
+	if el, ok := mc.entries[key]; ok {
+		mc.remove(el)
+	}
+
+	mc.entries[key] = mc.lru.PushFront(ce)
+
+	for mc.lru.Len() > mc.size {
+		mc.remove(mc.lru.Back())
+	}
+}
+
+// addCovers adds the files and folders of m, and of its contents, to covers.
+func addCovers(covers map[string]bool, m *Metadata) {
+	if m.IsFolder {
+		covers[folderKey(m.FolderID)] = true
+	} else {
+		covers[fileKey(m.FileID)] = true
+	}
+
+	for _, cm := range m.Contents {
+		addCovers(covers, cm)
+	}
+}
+
+// remove removes el from the cache. The lock must be held.
+func (mc *metadataCache) remove(el *list.Element) {
+	mc.lru.Remove(el)
+	delete(mc.entries, el.Value.(*cacheEntry).key)
+}
+
+// purge empties the cache.
+func (mc *metadataCache) purge() {
+	mc.lock.Lock()
+	defer mc.lock.Unlock()
+
+	mc.lru.Init()
+	mc.entries = map[string]*list.Element{}
+}
+
+// invalidate removes the responses that contain any of keys (see fileKey and folderKey).
+func (mc *metadataCache) invalidate(keys ...string) {
+	mc.lock.Lock()
+	defer mc.lock.Unlock()
+
+	for el := mc.lru.Front(); el != nil; {
+		next := el.Next()
+
+		ce := el.Value.(*cacheEntry)
+		for _, k := range keys {
+			if ce.covers[k] {
+				mc.remove(el)
+				break
+			}
+		}
+
+		el = next
+	}
+}
+
+// invalidateByMetadata removes the responses affected by a change to the file or folder
+// described by m.
+func (mc *metadataCache) invalidateByMetadata(m *Metadata) {
+	keys := []string{folderKey(m.ParentFolderID)}
+
+	if m.IsFolder {
+		keys = append(keys, folderKey(m.FolderID))
+	} else {
+		keys = append(keys, fileKey(m.FileID))
+	}
+
+	if m.DeletedFileID != 0 {
+		keys = append(keys, fileKey(m.DeletedFileID))
+	}
+
+	mc.invalidate(keys...)
+}
+
+// invalidateByResponse removes the responses affected by a successful call to a mutating
+// endpoint. The whole cache is purged when the response does not describe the change.
+func (mc *metadataCache) invalidateByResponse(body []byte) {
+	r := &struct {
+		Metadata json.RawMessage
+	}{}
+
+	if err := json.Unmarshal(body, r); err != nil || len(r.Metadata) == 0 {
+		mc.purge()
+		return
+	}
+
+	// uploadfile returns a list of metadata, the other methods a single one.
+	var ms []*Metadata
+	if err := json.Unmarshal(r.Metadata, &ms); err != nil {
+		m := &Metadata{}
+		if err := json.Unmarshal(r.Metadata, m); err != nil {
+			mc.purge()
+			return
+		}
+		ms = []*Metadata{m}
+	}
+
+	for _, m := range ms {
+		mc.invalidateByMetadata(m)
+	}
+}
+
+// observeEvents invalidates the metadata cache, if any, with the diff events in entries, which
+// were returned by a call with the query query.
+func (c *Client) observeEvents(query url.Values, entries []Entry) {
+	switch {
+	case c.cache == nil:
+	case filtersMetadata(query):
+		c.cache.purge()
+	default:
+		c.cache.invalidateByEvents(entries)
+	}
+}
+
+// invalidateByEvents removes the responses affected by the diff events in entries.
+func (mc *metadataCache) invalidateByEvents(entries []Entry) {
+	for i := range entries {
+		e := &entries[i]
+
+		switch {
+		// a moved folder changes the paths of all its descendants.
+		case e.Event == Reset, e.Event == ModifyFolder:
+			mc.purge()
+
+		case e.Event.IsFolderEvent() || e.Event.IsFileEvent():
+			mc.invalidateByMetadata(&e.Metadata)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package sdk_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+// newMetadataServer returns a server with folder 1 containing file 10, folder 2 containing
+// file 20, and a diff reporting a change to file 10.
+// It counts the calls made to each endpoint.
+func newMetadataServer(t *testing.T) (*httptest.Server, func(endpoint string) int) {
+	var lock sync.Mutex
+	calls := map[string]int{}
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		calls[r.URL.Path]++
+		lock.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		q := r.URL.Query()
+
+		switch r.URL.Path {
+		case "/listfolder":
+			id := q.Get("folderid")
+			fmt.Fprintf(w, `{"result": 0, "metadata": {"isfolder": true, "folderid": %s, "contents": [{"name": "f", "fileid": %s0, "parentfolderid": %s}]}}`, id, id, id)
+		case "/stat":
+			fmt.Fprintf(w, `{"result": 0, "metadata": {"name": "f", "fileid": %s, "parentfolderid": 2}}`, q.Get("fileid"))
+		case "/diff":
+			fmt.Fprint(w, `{"result": 0, "diffid": 2, "entries": [{"event": "modifyfile", "diffid": 2, "metadata": {"name": "f", "fileid": 10, "parentfolderid": 1}}]}`)
+		case "/deletefile":
+			fmt.Fprintf(w, `{"result": 0, "metadata": {"name": "f", "fileid": %s, "parentfolderid": 2, "isdeleted": true}}`, q.Get("fileid"))
+		case "/deletefolderrecursive":
+			fmt.Fprint(w, `{"result": 0, "deletedfiles": 1, "deletedfolders": 1}`)
+		case "/trash_restore":
+			fmt.Fprintf(w, `{"result": 0, "metadata": {"name": "f", "fileid": %s, "parentfolderid": 2}}`, q.Get("fileid"))
+		case "/file_close":
+			fmt.Fprint(w, `{"result": 0}`)
+		default:
+			t.Errorf("unexpected call to %s", r.URL.Path)
+		}
+	}))
+
+	return srv, func(endpoint string) int {
+		lock.Lock()
+		defer lock.Unlock()
+		return calls[endpoint]
+	}
+}
+
+func TestMetadataCache(t *testing.T) {
+	ctx := context.Background()
+
+	listFolder := func(pcc *sdk.Client, folderID uint64, opts ...sdk.ClientOption) {
+		lf, err := pcc.List(ctx, sdk.ByID(folderID), sdk.ListOptions{}, opts...)
+		require.NoError(t, err)
+		require.EqualValues(t, folderID, lf.Metadata.FolderID)
+	}
+
+	stat := func(pcc *sdk.Client, fileID uint64) {
+		fr, err := pcc.Stat(ctx, sdk.ByID(fileID))
+		require.NoError(t, err)
+		require.EqualValues(t, fileID, fr.Metadata.FileID)
+	}
+
+	t.Run("serves repeated calls from the cache", func(t *testing.T) {
+		srv, calls := newMetadataServer(t)
+		defer srv.Close()
+
+		pcc := sdk.NewClient(srv.Client(), sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")), sdk.WithMetadataCache(10, 0))
+
+		listFolder(pcc, 1)
+		listFolder(pcc, 1)
+		listFolder(pcc, 2)
+		stat(pcc, 20)
+		stat(pcc, 20)
+
+		assert.Equal(t, 2, calls("/listfolder"))
+		assert.Equal(t, 1, calls("/stat"))
+	})
+
+	t.Run("bypass", func(t *testing.T) {
+		srv, calls := newMetadataServer(t)
+		defer srv.Close()
+
+		pcc := sdk.NewClient(srv.Client(), sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")), sdk.WithMetadataCache(10, 0))
+
+		listFolder(pcc, 1)
+		listFolder(pcc, 1, sdk.WithCallCacheBypass())
+		listFolder(pcc, 1)
+
+		assert.Equal(t, 2, calls("/listfolder"))
+	})
+
+	t.Run("ttl", func(t *testing.T) {
+		srv, calls := newMetadataServer(t)
+		defer srv.Close()
+
+		pcc := sdk.NewClient(srv.Client(), sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")), sdk.WithMetadataCache(10, 50*time.Millisecond))
+
+		listFolder(pcc, 1)
+		time.Sleep(100 * time.Millisecond)
+		listFolder(pcc, 1)
+
+		assert.Equal(t, 2, calls("/listfolder"))
+	})
+
+	t.Run("evicts the least recently used", func(t *testing.T) {
+		srv, calls := newMetadataServer(t)
+		defer srv.Close()
+
+		pcc := sdk.NewClient(srv.Client(), sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")), sdk.WithMetadataCache(2, 0))
+
+		listFolder(pcc, 1)
+		listFolder(pcc, 2)
+		listFolder(pcc, 1)
+		listFolder(pcc, 3) // evicts folder 2
+		listFolder(pcc, 1)
+		listFolder(pcc, 2)
+
+		assert.Equal(t, 4, calls("/listfolder"))
+	})
+
+	t.Run("invalidated by diff events", func(t *testing.T) {
+		srv, calls := newMetadataServer(t)
+		defer srv.Close()
+
+		pcc := sdk.NewClient(srv.Client(), sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")), sdk.WithMetadataCache(10, 0))
+
+		listFolder(pcc, 1)
+		listFolder(pcc, 2)
+
+		_, err := pcc.Changes(ctx, 1, sdk.ChangesOptions{})
+		require.NoError(t, err)
+
+		listFolder(pcc, 1) // contains file 10
+		listFolder(pcc, 2)
+
+		assert.Equal(t, 3, calls("/listfolder"))
+	})
+
+	t.Run("invalidated by the changes of the client", func(t *testing.T) {
+		srv, calls := newMetadataServer(t)
+		defer srv.Close()
+
+		pcc := sdk.NewClient(srv.Client(), sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")), sdk.WithMetadataCache(10, 0))
+
+		listFolder(pcc, 1)
+		stat(pcc, 20)
+
+		_, err := pcc.DeleteFile(ctx, sdk.ByID(20))
+		require.NoError(t, err)
+
+		listFolder(pcc, 1)
+		stat(pcc, 20)
+
+		assert.Equal(t, 1, calls("/listfolder"))
+		assert.Equal(t, 2, calls("/stat"))
+
+		// the response of deletefolderrecursive does not describe the change.
+		_, err = pcc.DeleteFolderRecursive(ctx, sdk.ByID(3))
+		require.NoError(t, err)
+
+		listFolder(pcc, 1)
+
+		assert.Equal(t, 2, calls("/listfolder"))
+	})
+
+	t.Run("invalidated by a restore", func(t *testing.T) {
+		srv, calls := newMetadataServer(t)
+		defer srv.Close()
+
+		pcc := sdk.NewClient(srv.Client(), sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")), sdk.WithMetadataCache(10, 0))
+
+		listFolder(pcc, 1)
+		listFolder(pcc, 2)
+
+		// file 20 is restored into folder 2.
+		_, err := pcc.TrashRestore(ctx, sdk.T6FileByID(20), 0)
+		require.NoError(t, err)
+
+		listFolder(pcc, 1)
+		listFolder(pcc, 2)
+
+		assert.Equal(t, 3, calls("/listfolder"))
+	})
+
+	t.Run("purged by the endpoints that are not read-only", func(t *testing.T) {
+		srv, calls := newMetadataServer(t)
+		defer srv.Close()
+
+		pcc := sdk.NewClient(srv.Client(), sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")), sdk.WithMetadataCache(10, 0))
+
+		listFolder(pcc, 1)
+
+		// the file written through the file descriptor is not known.
+		require.NoError(t, pcc.FileClose(ctx, 1))
+
+		listFolder(pcc, 1)
+
+		assert.Equal(t, 2, calls("/listfolder"))
+	})
+
+	t.Run("filtered metadata", func(t *testing.T) {
+		srv, calls := newMetadataServer(t)
+		defer srv.Close()
+
+		pcc := sdk.NewClient(srv.Client(), sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")), sdk.WithMetadataCache(10, 0))
+
+		// the responses with filtered metadata are not cached.
+		listFolder(pcc, 1, sdk.WithGlobalOptionFilterMeta("folderid", "name"))
+		listFolder(pcc, 1, sdk.WithGlobalOptionFilterMeta("folderid", "name"))
+		assert.Equal(t, 2, calls("/listfolder"))
+
+		listFolder(pcc, 1)
+		listFolder(pcc, 1)
+		assert.Equal(t, 3, calls("/listfolder"))
+
+		// the changes reported with filtered metadata purge the cache.
+		_, err := pcc.DeleteFile(ctx, sdk.ByID(20), sdk.WithGlobalOptionFilterMeta("name"))
+		require.NoError(t, err)
+
+		listFolder(pcc, 1)
+		assert.Equal(t, 4, calls("/listfolder"))
+	})
+}
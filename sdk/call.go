@@ -0,0 +1,50 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+// Call calls the pCloud method with the parameters params and decodes its response into out,
+// which is typically a pointer to a struct, like json.Unmarshal does. It is for the methods
+// that the SDK does not provide yet, and which respond with JSON.
+// The call is made like those of the other methods of the Client: the auth token of the
+// session, the global parameters and the options of the Client, such as retries, apply to it.
+// The parameters in params take precedence over those set by opts.
+// A response with a non-zero result is returned as an *Error. out may be nil when the response
+// is not needed.
+// https://docs.pcloud.com/methods/
+func (c *Client) Call(ctx context.Context, method string, params url.Values, out interface{}, opts ...ClientOption) error {
+	ctx, q := toQuery(ctx, opts...)
+
+	for k, v := range params {
+		q[k] = append([]string(nil), v...)
+	}
+
+	body, err := c.get(ctx, method, q)
+
+	r := &result{}
+
+	err = parseResult(body, err, r)
+	if err != nil {
+		return err
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	err = json.Unmarshal(body, out)
+	if err != nil {
+		return errors.Wrap(err, "unmarshal")
+	}
+
+	if c.strictDecoding {
+		return checkUnknownFields(body, out)
+	}
+
+	return nil
+}
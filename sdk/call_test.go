@@ -0,0 +1,61 @@
+package sdk_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+func TestCall(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.Path {
+		case "/getthumblink":
+			assert.Equal(t, "my-token", q.Get("auth"))
+			assert.Equal(t, "req-1", q.Get("id"))
+			assert.Equal(t, "10", q.Get("fileid"))
+			assert.Equal(t, []string{"64x64"}, q["size"])
+			fmt.Fprint(w, `{"result": 0, "path": "/thumb.jpg", "hosts": ["c1.pcloud.com"], "size": "64x64"}`)
+		case "/deletetoken":
+			fmt.Fprint(w, `{"result": 2102, "error": "Provided 'tokenid' not found."}`)
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	pcc := sdk.NewClient(srv.Client(), sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")), sdk.WithAuthToken("my-token"))
+
+	params := url.Values{"fileid": {"10"}, "size": {"64x64"}}
+
+	var thumb struct {
+		Path  string
+		Hosts []string
+		Size  string
+	}
+
+	err := pcc.Call(context.Background(), "getthumblink", params, &thumb, sdk.WithGlobalOptionID("req-1"))
+	require.NoError(t, err)
+	assert.Equal(t, "/thumb.jpg", thumb.Path)
+	assert.Equal(t, []string{"c1.pcloud.com"}, thumb.Hosts)
+	assert.Equal(t, url.Values{"fileid": {"10"}, "size": {"64x64"}}, params)
+
+	err = pcc.Call(context.Background(), "deletetoken", url.Values{"tokenid": {"1"}}, nil)
+	require.Error(t, err)
+
+	var e *sdk.Error
+	require.True(t, errors.As(err, &e))
+	assert.Equal(t, 2102, e.Code)
+}
@@ -0,0 +1,78 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// ResponseInfo describes how a call was served. See WithCallCaptureResponse.
+type ResponseInfo struct {
+	// Method is the pCloud API method of the call.
+	Method string
+
+	// Result is the result code returned by pCloud. It is 0 when the call succeeded or when no
+	// response was received.
+	Result int
+
+	// Host is the API host that served the call.
+	Host string
+
+	// RequestID is the id that pCloud echoes back when the call sets one (see
+	// WithGlobalOptionID).
+	RequestID string
+
+	// StatusCode is the HTTP status of the response. It is 0 when no response was received.
+	StatusCode int
+
+	// Attempts is the number of attempts of the call (see RetryPolicy).
+	Attempts int
+
+	// Duration is the total duration of the call, including retries.
+	Duration time.Duration
+
+	// TimeToFirstByte is the time taken by pCloud to respond to the last attempt, from the
+	// moment the request was sent until the response headers were received.
+	TimeToFirstByte time.Duration
+}
+
+// WithCallCaptureResponse stores in info how the call was served: result code, host, timings,
+// etc. This helps debugging and SLO tracking without enabling full logging (see WithLogger).
+// info is written before the SDK method returns.
+func WithCallCaptureResponse(info *ResponseInfo) ClientOption {
+	return func(co *callOptions) {
+		co.capture = info
+	}
+}
+
+// captureResponse completes the ResponseInfo of the call, if any, once the call is over.
+func captureResponse(ctx context.Context, endpoint, host string, start time.Time, attempts int, body []byte) {
+	info := callOptionsFromContext(ctx).capture
+	if info == nil {
+		return
+	}
+
+	r := &struct {
+		result
+		ID string `json:"id"`
+	}{}
+	_ = json.Unmarshal(body, r)
+
+	info.Method = endpoint
+	info.Result = r.Result
+	info.Host = host
+	info.RequestID = r.ID
+	info.Attempts = attempts
+	info.Duration = time.Since(start)
+}
+
+// captureAttempt records the outcome of an attempt of the call in its ResponseInfo, if any.
+func captureAttempt(ctx context.Context, statusCode int, sent time.Time) {
+	info := callOptionsFromContext(ctx).capture
+	if info == nil {
+		return
+	}
+
+	info.StatusCode = statusCode
+	info.TimeToFirstByte = time.Since(sent)
+}
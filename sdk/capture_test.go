@@ -0,0 +1,64 @@
+package sdk_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+func TestWithCallCaptureResponse(t *testing.T) {
+	calls := 0
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/getip":
+			fmt.Fprintf(w, `{"result": 0, "id": "%s", "ip": "1.2.3.4", "country": "fr"}`, r.URL.Query().Get("id"))
+		default:
+			fmt.Fprint(w, `{"result": 2009, "error": "File not found."}`)
+		}
+	}))
+	defer srv.Close()
+
+	host := strings.TrimPrefix(srv.URL, "https://")
+	pcc := sdk.NewClient(srv.Client(), sdk.WithBaseHost(host))
+
+	policy := sdk.DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+
+	info := sdk.ResponseInfo{}
+
+	_, err := pcc.GetIP(context.Background(), sdk.WithGlobalOptionID("req-1"), sdk.WithCallRetryPolicy(policy), sdk.WithCallCaptureResponse(&info))
+	require.NoError(t, err)
+	assert.Equal(t, "getip", info.Method)
+	assert.Equal(t, 0, info.Result)
+	assert.Equal(t, host, info.Host)
+	assert.Equal(t, "req-1", info.RequestID)
+	assert.Equal(t, http.StatusOK, info.StatusCode)
+	assert.Equal(t, 2, info.Attempts)
+	assert.Greater(t, info.Duration, time.Duration(0))
+	assert.Greater(t, info.TimeToFirstByte, time.Duration(0))
+	assert.LessOrEqual(t, info.TimeToFirstByte, info.Duration)
+
+	_, err = pcc.Stat(context.Background(), sdk.ByID(1), sdk.WithCallCaptureResponse(&info))
+	require.Error(t, err)
+	assert.Equal(t, "stat", info.Method)
+	assert.Equal(t, sdk.ErrFileNotFound, info.Result)
+	assert.Empty(t, info.RequestID)
+	assert.Equal(t, 1, info.Attempts)
+}
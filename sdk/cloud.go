@@ -0,0 +1,147 @@
+package sdk
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"time"
+)
+
+// Cloud is the interface of the pCloud API methods of the Client.
+// Code that depends on the SDK can accept a Cloud rather than a *Client so that it may be
+// unit tested with a mock such as sdkmock.Cloud, or with a *Client pointed at the fake server of
+// sdktest.
+// The interface covers all the exported methods of the Client: it grows with the SDK, so code
+// that only needs a few methods may prefer to define its own, smaller, interface.
+type Cloud interface {
+	// authentication
+	AuthToken() string
+	ChangeMail(ctx context.Context, password, code string, opts ...ClientOption) (*ChangeMailResult, error)
+	ChangePassword(ctx context.Context, oldPassword, newPassword string, opts ...ClientOption) error
+	GetDigest(ctx context.Context, opts ...ClientOption) (*Digest, error)
+	Invite(ctx context.Context, mail, messageOpt, nameOpt string, opts ...ClientOption) error
+	ListInvites(ctx context.Context, opts ...ClientOption) (*InvitesList, error)
+	ListTokens(ctx context.Context, opts ...ClientOption) (*TokensList, error)
+	Login(ctx context.Context, otpCodeOpt string, opts ...ClientOption) error
+	LoginDigest(ctx context.Context, otpCodeOpt, username, password string, opts ...ClientOption) error
+	LoginV1(ctx context.Context, opts ...ClientOption) error
+	Logout(ctx context.Context, opts ...ClientOption) (*LogoutResult, error)
+	LostPassword(ctx context.Context, mail string, opts ...ClientOption) error
+	OAuth2Token(ctx context.Context, clientID, clientSecret, code string, opts ...ClientOption) (*OAuth2AccessToken, error)
+	Register(ctx context.Context, mail, password string, termsAccepted bool, languageOpt string, referrerOpt uint64, opts ...ClientOption) (*RegisterResult, error)
+	ResetPassword(ctx context.Context, code, newPassword string, opts ...ClientOption) error
+	SendChangeMail(ctx context.Context, newMailOpt, codeOpt string, opts ...ClientOption) error
+	SendVerificationEmail(ctx context.Context, opts ...ClientOption) error
+	VerifyEmail(ctx context.Context, code string, opts ...ClientOption) (*VerifyEmailResult, error)
+
+	// batches
+	BatchCopyFiles(ctx context.Context, ops []BatchFileOp, opts ...BatchOption) []BatchResult
+	BatchDeleteFiles(ctx context.Context, files []FileRef, opts ...BatchOption) []BatchResult
+	BatchDeleteFolders(ctx context.Context, folders []FolderRef, opts ...BatchOption) []BatchResult
+	BatchMoveFiles(ctx context.Context, ops []BatchFileOp, opts ...BatchOption) []BatchResult
+
+	// crypto folders
+	CryptoChangeUserPrivate(ctx context.Context, privateKey, code, hintOpt string, opts ...ClientOption) error
+	CryptoGetFileKey(ctx context.Context, fileID uint64, opts ...ClientOption) (*CryptoKey, error)
+	CryptoGetFolderKey(ctx context.Context, folderID uint64, opts ...ClientOption) (*CryptoKey, error)
+	CryptoGetUserHint(ctx context.Context, opts ...ClientOption) (*CryptoUserHint, error)
+	CryptoGetUserKeys(ctx context.Context, opts ...ClientOption) (*CryptoUserKeys, error)
+	CryptoSendChangeUserPrivate(ctx context.Context, opts ...ClientOption) error
+	CryptoSetUserKeys(ctx context.Context, privateKey, publicKey, hintOpt string, opts ...ClientOption) error
+
+	// files
+	ChecksumFile(ctx context.Context, file FileRef, opts ...ClientOption) (*FileChecksum, error)
+	CopyFile(ctx context.Context, file FileRef, destination FolderRef, noOverOpt bool, mTime, cTime time.Time, opts ...ClientOption) (*FileResult, error)
+	CopyFileTo(ctx context.Context, file FileRef, destination FolderRef, o CopyFileOptions, opts ...ClientOption) (*FileResult, error)
+	DeleteFile(ctx context.Context, file FileRef, opts ...ClientOption) (*FileResult, error)
+	RenameFile(ctx context.Context, file FileRef, destination FolderRef, opts ...ClientOption) (*FileResult, error)
+	Stat(ctx context.Context, file FileRef, opts ...ClientOption) (*FileResult, error)
+	UploadFile(ctx context.Context, folder FolderRef, files map[string]*os.File, noPartialOpt bool, progressHashOpt string, renameIfExistsOpt bool, mTimeOpt, cTimeOpt time.Time, opts ...ClientOption) (*FileUpload, error)
+	UploadFiles(ctx context.Context, folder FolderRef, files map[string]*os.File, o UploadOptions, opts ...ClientOption) (*FileUpload, error)
+
+	// file operations
+	FileChecksum(ctx context.Context, fd, count, offset uint64, opts ...ClientOption) (*PFileChecksum, error)
+	FileClose(ctx context.Context, fd uint64, opts ...ClientOption) error
+	FileOpen(ctx context.Context, flags uint64, file FileRef, opts ...ClientOption) (*File, error)
+	FilePRead(ctx context.Context, fd, count, offset uint64, opts ...ClientOption) ([]byte, error)
+	FilePReadIfMod(ctx context.Context, fd, count, offset uint64, checksum T5SHA1OrMD5, opts ...ClientOption) ([]byte, error)
+	FileRead(ctx context.Context, fd, count uint64, opts ...ClientOption) ([]byte, error)
+	FileSeek(ctx context.Context, fd, offset uint64, whenceOpt Whence, opts ...ClientOption) (*FileSeek, error)
+	FileWrite(ctx context.Context, fd uint64, data []byte, opts ...ClientOption) (*FileDataTransfer, error)
+
+	// folders
+	CopyFolder(ctx context.Context, folder FolderRef, toFolder FolderRef, noOverOpt, skipExisting, copyContentOnly bool, opts ...ClientOption) (*FSList, error)
+	CopyFolderTo(ctx context.Context, folder FolderRef, toFolder FolderRef, o CopyFolderOptions, opts ...ClientOption) (*FSList, error)
+	CreateFolder(ctx context.Context, folder FolderRef, opts ...ClientOption) (*FSList, error)
+	CreateFolderIfNotExists(ctx context.Context, folder FolderRef, opts ...ClientOption) (*FSList, error)
+	DeleteFolder(ctx context.Context, folder FolderRef, opts ...ClientOption) (*FSList, error)
+	DeleteFolderRecursive(ctx context.Context, folder FolderRef, opts ...ClientOption) (*DeleteResult, error)
+	List(ctx context.Context, folder FolderRef, o ListOptions, opts ...ClientOption) (*FSList, error)
+	ListFolder(ctx context.Context, folder FolderRef, recursiveOpt, showDeletedOpt, noFilesOpt, noSharesOpt bool, opts ...ClientOption) (*FSList, error)
+	ListFolderFunc(ctx context.Context, folder FolderRef, recursiveOpt, showDeletedOpt, noFilesOpt, noSharesOpt bool, fn func(m *Metadata) error, opts ...ClientOption) error
+	ListFunc(ctx context.Context, folder FolderRef, o ListOptions, fn func(m *Metadata) error, opts ...ClientOption) error
+	RenameFolder(ctx context.Context, folder FolderRef, toFolder FolderRef, opts ...ClientOption) (*FSList, error)
+
+	// general
+	Call(ctx context.Context, method string, params url.Values, out interface{}, opts ...ClientOption) error
+	Changes(ctx context.Context, diffID uint64, o ChangesOptions, opts ...ClientOption) (*DiffResult, error)
+	ChangesFunc(ctx context.Context, diffID uint64, o ChangesOptions, fn func(e *Entry) error, opts ...ClientOption) (uint64, error)
+	CurrentServer(ctx context.Context, opts ...ClientOption) (*CurrentServerResult, error)
+	Diff(ctx context.Context, diffID uint64, after time.Time, last uint64, block bool, limit uint64, opts ...ClientOption) (*DiffResult, error)
+	DiffFunc(ctx context.Context, diffID uint64, after time.Time, last uint64, block bool, limit uint64, fn func(e *Entry) error, opts ...ClientOption) (uint64, error)
+	Feedback(ctx context.Context, mail, reason, message string, opts ...ClientOption) error
+	GetAPIServer(ctx context.Context, opts ...ClientOption) (*APIServerResult, error)
+	GetFileHistory(ctx context.Context, fileID uint64, opts ...ClientOption) (*DiffResult, error)
+	GetIP(ctx context.Context, opts ...ClientOption) (*IPResult, error)
+	SetLanguage(ctx context.Context, language string, opts ...ClientOption) error
+	SupportedLanguages(ctx context.Context, opts ...ClientOption) (*SupportedLanguages, error)
+	UserInfo(ctx context.Context, opts ...ClientOption) (*UserInfo, error)
+
+	// notifications
+	ListNotifications(ctx context.Context, thumbSizeOpt string, opts ...ClientOption) (*NotificationsResult, error)
+	MarkNotificationsRead(ctx context.Context, notificationID uint64, opts ...ClientOption) error
+
+	// pipelines
+	NewPipeline() *Pipeline
+
+	// public links
+	ChangePubLink(ctx context.Context, linkID uint64, expireOpt time.Time, passwordOpt string, opts ...ClientOption) error
+	CreateFilePubLink(ctx context.Context, file FileRef, o PubLinkOptions, opts ...ClientOption) (*PubLinkResult, error)
+	CreateFolderPubLink(ctx context.Context, folder FolderRef, o PubLinkOptions, opts ...ClientOption) (*PubLinkResult, error)
+	DeletePubLink(ctx context.Context, linkID uint64, opts ...ClientOption) error
+	GetFilePubLink(ctx context.Context, file FileRef, expireOpt time.Time, maxDownloadsOpt, maxTrafficOpt uint64, shortLinkOpt bool, opts ...ClientOption) (*PubLinkResult, error)
+	GetFolderPubLink(ctx context.Context, folder FolderRef, expireOpt time.Time, maxDownloadsOpt, maxTrafficOpt uint64, shortLinkOpt bool, opts ...ClientOption) (*PubLinkResult, error)
+	ListPubLinks(ctx context.Context, opts ...ClientOption) (*PubLinksList, error)
+	UpdatePubLink(ctx context.Context, linkID uint64, o UpdatePubLinkOptions, opts ...ClientOption) error
+
+	// revisions
+	ListRevisions(ctx context.Context, file FileRef, opts ...ClientOption) (*RevisionsList, error)
+	RevertRevision(ctx context.Context, file FileRef, revisionID uint64, opts ...ClientOption) (*FileResult, error)
+
+	// sharing
+	AcceptShare(ctx context.Context, shareRequestID uint64, nameOpt string, folderOpt FolderRef, opts ...ClientOption) error
+	CancelShareRequest(ctx context.Context, shareRequestID uint64, opts ...ClientOption) error
+	DeclineShare(ctx context.Context, shareRequestID uint64, opts ...ClientOption) error
+	ListShares(ctx context.Context, opts ...ClientOption) (*SharesList, error)
+	RemoveShare(ctx context.Context, shareID uint64, opts ...ClientOption) error
+	ShareFolder(ctx context.Context, folder FolderRef, mail string, permissions SharePermissions, nameOpt, messageOpt string, opts ...ClientOption) error
+
+	// streaming
+	DownloadLink(ctx context.Context, file FileRef, o DownloadLinkOptions, opts ...ClientOption) (*FileLink, error)
+	GetFileLink(ctx context.Context, file FileRef, forceDownloadOpt bool, contentTypeOpt string, maxSpeedOpt uint64, skipFilenameOpt bool, opts ...ClientOption) (*FileLink, error)
+
+	// thumbnails
+	Preview(ctx context.Context, m *Metadata, width, height int, opts ...ClientOption) (*Preview, error)
+	ThumbLink(ctx context.Context, file FileRef, width, height int, o ThumbLinkOptions, opts ...ClientOption) (*ThumbLink, error)
+
+	// subscriptions
+	Subscribe(ctx context.Context, fromDiffID uint64, opts ...ClientOption) (<-chan Entry, error)
+
+	// trash
+	ListTrash(ctx context.Context, o ListTrashOptions, opts ...ClientOption) (*FSList, error)
+	TrashClear(ctx context.Context, item T6FileIDOrFolderID, opts ...ClientOption) error
+	TrashList(ctx context.Context, folderIDOpt uint64, noFilesOpt, recursiveOpt bool, opts ...ClientOption) (*FSList, error)
+	TrashRestore(ctx context.Context, item T6FileIDOrFolderID, restoreToOpt uint64, opts ...ClientOption) (*FSList, error)
+}
+
+var _ Cloud = (*Client)(nil)
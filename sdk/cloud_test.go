@@ -0,0 +1,20 @@
+package sdk_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+func TestCloud_CoversClient(t *testing.T) {
+	cloud := reflect.TypeOf((*sdk.Cloud)(nil)).Elem()
+	client := reflect.TypeOf(&sdk.Client{})
+
+	for i := 0; i < client.NumMethod(); i++ {
+		_, ok := cloud.MethodByName(client.Method(i).Name)
+		assert.True(t, ok, "sdk.Cloud is missing Client.%s", client.Method(i).Name)
+	}
+}
@@ -0,0 +1,237 @@
+package sdk_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+// newFileOpsServer returns a server that implements enough of the file operations of pCloud
+// to exercise a Client from many goroutines.
+// Like pCloud, it binds each file descriptor to the connection it was opened on.
+func newFileOpsServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	var fd uint64
+
+	// conns maps the file descriptors to the remote address of their connection.
+	var conns sync.Map
+
+	var srv *httptest.Server
+	srv = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.URL.Path != "/file_open" && strings.HasPrefix(r.URL.Path, "/file_") {
+			conn, ok := conns.Load(r.URL.Query().Get("fd"))
+			if !ok || conn != r.RemoteAddr {
+				fmt.Fprint(w, `{"result": 1007, "error": "Invalid or closed file descriptor."}`)
+				return
+			}
+		}
+
+		switch r.URL.Path {
+		case "/getapiserver":
+			fmt.Fprintf(w, `{"result": 0, "api": [%q], "binapi": []}`, strings.TrimPrefix(srv.URL, "https://"))
+		case "/getip":
+			time.Sleep(time.Millisecond)
+			fmt.Fprint(w, `{"result": 0, "ip": "1.2.3.4"}`)
+		case "/login":
+			fmt.Fprint(w, `{"result": 0, "auth": "token"}`)
+		case "/logout":
+			fmt.Fprint(w, `{"result": 0, "auth_deleted": true}`)
+		case "/file_open":
+			n := atomic.AddUint64(&fd, 1)
+			conns.Store(fmt.Sprintf("%d", n), r.RemoteAddr)
+			fmt.Fprintf(w, `{"result": 0, "fd": %d, "fileid": 1}`, n)
+		case "/file_write":
+			n, _ := io.Copy(io.Discard, r.Body)
+			fmt.Fprintf(w, `{"result": 0, "bytes": %d}`, n)
+		case "/file_read":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			fmt.Fprint(w, r.URL.Query().Get("fd"))
+		case "/file_close":
+			conns.Delete(r.URL.Query().Get("fd"))
+			fmt.Fprint(w, `{"result": 0}`)
+		default:
+			fmt.Fprint(w, `{"result": 2000, "error": "Log in failed."}`)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+// TestClient_Concurrency shares one Client across goroutines. It is meant to be run with the
+// race detector.
+func TestClient_Concurrency(t *testing.T) {
+	srv := newFileOpsServer(t)
+
+	var requests int64
+
+	counter := func(next http.RoundTripper) http.RoundTripper {
+		return sdk.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			atomic.AddInt64(&requests, 1)
+			return next.RoundTrip(req)
+		})
+	}
+
+	pcc := sdk.NewClient(
+		srv.Client(),
+		sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")),
+		sdk.WithAPIServerDiscovery(),
+		sdk.WithRateLimit(10_000, 100),
+		sdk.WithInterceptor(counter),
+	)
+
+	ctx := context.Background()
+
+	const workers = 8
+	const rounds = 10
+
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+
+			for i := 0; i < rounds; i++ {
+				f, err := pcc.FileOpen(ctx, sdk.O_CREAT, sdk.ByPath(fmt.Sprintf("/file-%d-%d", w, i)))
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				fdt, err := pcc.FileWrite(ctx, f.FD, []byte("hello"))
+				if assert.NoError(t, err) {
+					assert.EqualValues(t, 5, fdt.Bytes)
+				}
+
+				data, err := pcc.FileRead(ctx, f.FD, 10)
+				if assert.NoError(t, err) {
+					assert.Equal(t, fmt.Sprintf("%d", f.FD), string(data))
+				}
+
+				assert.NoError(t, pcc.FileClose(ctx, f.FD))
+			}
+		}(w)
+	}
+
+	// log in and out while the file operations take place.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		for i := 0; i < rounds; i++ {
+			assert.NoError(t, pcc.Login(ctx, "", sdk.WithGlobalOptionUsername("user"), sdk.WithGlobalOptionPassword("pass")))
+			_, err := pcc.Logout(ctx)
+			assert.NoError(t, err)
+		}
+	}()
+
+	wg.Wait()
+
+	// 1 discovery + 4 file operations per round and per worker + 2 per login round.
+	assert.EqualValues(t, 1+workers*rounds*4+rounds*2, atomic.LoadInt64(&requests))
+}
+
+// TestClient_ConcurrentRequests checks that the requests that goroutines make through one Client
+// are in flight at the same time rather than sent one at a time.
+func TestClient_ConcurrentRequests(t *testing.T) {
+	const requests = 2
+
+	var inFlight int64
+	overlap := make(chan struct{})
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt64(&inFlight, 1) == requests {
+			close(overlap)
+		}
+		defer atomic.AddInt64(&inFlight, -1)
+
+		w.Header().Set("Content-Type", "application/json")
+
+		select {
+		case <-overlap:
+			fmt.Fprint(w, `{"result": 0, "ip": "1.2.3.4"}`)
+		case <-time.After(5 * time.Second):
+			fmt.Fprint(w, `{"result": 2000, "error": "Log in failed."}`)
+		}
+	}))
+	defer srv.Close()
+
+	pcc := sdk.NewClient(srv.Client(), sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")))
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_, err := pcc.GetIP(context.Background())
+			assert.NoError(t, err, "the requests did not overlap")
+		}()
+	}
+
+	wg.Wait()
+}
+
+// TestClient_FileDescriptorConnection checks that the file operations keep using the connection
+// their file descriptor was opened on while other requests are in flight over other connections.
+func TestClient_FileDescriptorConnection(t *testing.T) {
+	srv := newFileOpsServer(t)
+
+	pcc := sdk.NewClient(srv.Client(), sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")))
+
+	ctx := context.Background()
+
+	const workers = 4
+	const rounds = 20
+
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < rounds; i++ {
+				_, err := pcc.GetIP(ctx)
+				assert.NoError(t, err)
+			}
+		}()
+
+		go func(w int) {
+			defer wg.Done()
+
+			for i := 0; i < rounds; i++ {
+				f, err := pcc.FileOpen(ctx, sdk.O_CREAT, sdk.ByPath(fmt.Sprintf("/file-%d-%d", w, i)))
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				_, err = pcc.FileWrite(ctx, f.FD, []byte("hello"))
+				assert.NoError(t, err)
+
+				_, err = pcc.FileRead(ctx, f.FD, 10)
+				assert.NoError(t, err)
+
+				assert.NoError(t, pcc.FileClose(ctx, f.FD))
+			}
+		}(w)
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,166 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// WithCallIfHash makes DeleteFile and RenameFile fail with a *PreconditionError unless the
+// hash of the file they target is hash (see Metadata.Hash).
+// The hash is verified with checksumfile just before the file is changed: this gives sync
+// engines compare-and-swap semantics so that they don't clobber concurrent remote edits. Note
+// that pCloud does not offer an atomic check: a change that takes place between the check and
+// the operation goes undetected.
+func WithCallIfHash(hash uint64) ClientOption {
+	return func(co *callOptions) {
+		co.ifHash = &hash
+	}
+}
+
+// WithCallIfDestinationHash makes RenameFile, CopyFileTo and UploadFiles fail with a
+// *PreconditionError unless the hash of the file they would overwrite is hash (see
+// Metadata.Hash). A hash of 0 requires that there is no file to overwrite.
+// See WithCallIfHash.
+func WithCallIfDestinationHash(hash uint64) ClientOption {
+	return func(co *callOptions) {
+		co.ifDestinationHash = &hash
+	}
+}
+
+// PreconditionError is returned when the precondition of a call is not met.
+// See WithCallIfHash and WithCallIfDestinationHash.
+type PreconditionError struct {
+	// File identifies the file whose hash was verified.
+	File string
+
+	// Expected is the hash the file was expected to have. 0 means the file was expected not to
+	// exist.
+	Expected uint64
+
+	// Actual is the hash of the file. 0 means the file does not exist.
+	Actual uint64
+}
+
+// Error implements Go's error interface.
+func (e *PreconditionError) Error() string {
+	return fmt.Sprintf("precondition failed: %s: expected hash %d, got %d", e.File, e.Expected, e.Actual)
+}
+
+// IsPreconditionFailed returns true when err indicates that the precondition of a call was not
+// met.
+func IsPreconditionFailed(err error) bool {
+	var pe *PreconditionError
+	return errors.As(err, &pe)
+}
+
+// checkIfHash verifies the precondition set by WithCallIfHash, if any, on file.
+func (c *Client) checkIfHash(ctx context.Context, file FileRef) error {
+	want := callOptionsFromContext(ctx).ifHash
+	if want == nil {
+		return nil
+	}
+
+	fc, err := c.ChecksumFile(ctx, file)
+	if err != nil {
+		return errors.WithMessage(err, "precondition")
+	}
+
+	if fc.Metadata.Hash != *want {
+		return errors.WithStack(&PreconditionError{File: describeFile(file), Expected: *want, Actual: fc.Metadata.Hash})
+	}
+
+	return nil
+}
+
+// checkIfDestinationHashOfMove verifies the precondition set by WithCallIfDestinationHash, if
+// any, on the file that moving or copying file to destination would overwrite.
+func (c *Client) checkIfDestinationHashOfMove(ctx context.Context, file FileRef, destination FolderRef) error {
+	if callOptionsFromContext(ctx).ifDestinationHash == nil {
+		return nil
+	}
+
+	q := url.Values{}
+	destination.setFolder(q, "to")
+
+	var folder FolderRef
+	var name string
+
+	if toPath := q.Get("topath"); toPath != "" {
+		dir := toPath
+		if !strings.HasSuffix(toPath, "/") {
+			dir, name = path.Split(toPath)
+		}
+		folder = ByPath(path.Clean(dir))
+	} else {
+		folderID, err := strconv.ParseUint(q.Get("tofolderid"), 10, 64)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		name = q.Get("toname")
+		folder = ByID(folderID)
+	}
+
+	// the file keeps its name when the destination is a folder.
+	if name == "" {
+		fc, err := c.ChecksumFile(ctx, file)
+		if err != nil {
+			return errors.WithMessage(err, "precondition")
+		}
+		name = fc.Metadata.Name
+	}
+
+	return c.checkIfDestinationHash(ctx, folder, name)
+}
+
+// checkIfDestinationHash verifies the precondition set by WithCallIfDestinationHash, if any, on
+// the files called names in folder.
+// The folder is listed, rather than the files checksummed, so that the files may be referenced
+// by name in a folder referenced by folderid.
+func (c *Client) checkIfDestinationHash(ctx context.Context, folder FolderRef, names ...string) error {
+	want := callOptionsFromContext(ctx).ifDestinationHash
+	if want == nil {
+		return nil
+	}
+
+	hashes := map[string]uint64{}
+
+	lf, err := c.List(ctx, folder, ListOptions{}, WithCallCacheBypass())
+	switch {
+	case ErrorCode(err) == ErrDirectoryNotExists:
+		// the files do not exist.
+
+	case err != nil:
+		return errors.WithMessage(err, "precondition")
+
+	default:
+		for _, m := range lf.Metadata.Contents {
+			if !m.IsFolder {
+				hashes[m.Name] = m.Hash
+			}
+		}
+	}
+
+	for _, name := range names {
+		if got := hashes[name]; got != *want {
+			q := url.Values{}
+			folder.setFolder(q, "")
+			q.Set("name", name)
+			return errors.WithStack(&PreconditionError{File: q.Encode(), Expected: *want, Actual: got})
+		}
+	}
+
+	return nil
+}
+
+// describeFile returns a description of the file referenced by file.
+func describeFile(file FileRef) string {
+	q := url.Values{}
+	file.setFile(q)
+	return q.Encode()
+}
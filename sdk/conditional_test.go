@@ -0,0 +1,92 @@
+package sdk_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+func TestPreconditions(t *testing.T) {
+	var lock sync.Mutex
+	var mutations []string
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		q := r.URL.Query()
+
+		switch r.URL.Path {
+		case "/checksumfile":
+			assert.Equal(t, "1", q.Get("fileid"))
+			fmt.Fprint(w, `{"result": 0, "sha1": "aaa", "metadata": {"name": "a.txt", "fileid": 1, "hash": 111}}`)
+		case "/listfolder":
+			if q.Get("path") == "/missing" {
+				fmt.Fprint(w, `{"result": 2005, "error": "Directory does not exist."}`)
+				return
+			}
+			assert.True(t, q.Get("path") == "/dst" || q.Get("folderid") == "5", q.Encode())
+			fmt.Fprint(w, `{"result": 0, "metadata": {"isfolder": true, "folderid": 5, "contents": [{"name": "a.txt", "fileid": 2, "hash": 222}, {"name": "sub", "isfolder": true, "folderid": 6}]}}`)
+		case "/deletefile", "/renamefile", "/copyfile", "/uploadfile":
+			lock.Lock()
+			mutations = append(mutations, r.URL.Path)
+			lock.Unlock()
+			if r.URL.Path == "/uploadfile" {
+				fmt.Fprint(w, `{"result": 0, "fileids": [1], "metadata": [{"name": "a.txt", "fileid": 1}]}`)
+				return
+			}
+			fmt.Fprint(w, `{"result": 0, "metadata": {"name": "a.txt", "fileid": 1}}`)
+		default:
+			t.Errorf("unexpected call to %s", r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	pcc := sdk.NewClient(srv.Client(), sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")))
+	ctx := context.Background()
+
+	_, err := pcc.DeleteFile(ctx, sdk.ByID(1), sdk.WithCallIfHash(999))
+	require.Error(t, err)
+	assert.True(t, sdk.IsPreconditionFailed(err))
+	var pe *sdk.PreconditionError
+	require.ErrorAs(t, err, &pe)
+	assert.EqualValues(t, 999, pe.Expected)
+	assert.EqualValues(t, 111, pe.Actual)
+
+	_, err = pcc.DeleteFile(ctx, sdk.ByID(1), sdk.WithCallIfHash(111))
+	require.NoError(t, err)
+
+	// the destination is a folder: the file keeps its name, a.txt, which exists there.
+	_, err = pcc.RenameFile(ctx, sdk.ByID(1), sdk.ByPath("/dst/"), sdk.WithCallIfDestinationHash(0))
+	assert.True(t, sdk.IsPreconditionFailed(err), err)
+
+	_, err = pcc.RenameFile(ctx, sdk.ByID(1), sdk.ByPath("/dst/"), sdk.WithCallIfHash(111), sdk.WithCallIfDestinationHash(222))
+	require.NoError(t, err)
+
+	_, err = pcc.CopyFileTo(ctx, sdk.ByID(1), sdk.ByIDName(5, "sub"), sdk.CopyFileOptions{}, sdk.WithCallIfDestinationHash(0))
+	require.NoError(t, err)
+
+	_, err = pcc.CopyFileTo(ctx, sdk.ByID(1), sdk.ByPath("/dst/a.txt"), sdk.CopyFileOptions{}, sdk.WithCallIfDestinationHash(111))
+	assert.True(t, sdk.IsPreconditionFailed(err), err)
+
+	f, err := os.Create(filepath.Join(t.TempDir(), "a.txt"))
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+
+	_, err = pcc.UploadFiles(ctx, sdk.ByPath("/missing"), map[string]*os.File{"a.txt": f}, sdk.UploadOptions{}, sdk.WithCallIfDestinationHash(0))
+	require.NoError(t, err)
+
+	_, err = pcc.UploadFiles(ctx, sdk.ByID(5), map[string]*os.File{"a.txt": f}, sdk.UploadOptions{}, sdk.WithCallIfDestinationHash(0))
+	assert.True(t, sdk.IsPreconditionFailed(err), err)
+
+	assert.Equal(t, []string{"/deletefile", "/renamefile", "/copyfile", "/uploadfile"}, mutations)
+}
@@ -0,0 +1,171 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+)
+
+// WithCryptoKey makes CreateFolder create a Crypto folder, and FileOpen create a Crypto file
+// when it creates one (see O_CREAT). key is the symmetric key of the new folder or file,
+// encrypted with the public key of the user in the format of pCloud's apps, which the SDK does
+// not implement.
+func WithCryptoKey(key string) ClientOption {
+	return func(co *callOptions) {
+		co.query.Add("encrypted", "1")
+		co.query.Add("key", key)
+	}
+}
+
+// CryptoUserHint is returned by the SDK CryptoGetUserHint() method.
+type CryptoUserHint struct {
+	result
+	Hint string
+}
+
+// CryptoGetUserHint returns the hint the user has set for their Crypto passphrase.
+// https://docs.pcloud.com/methods/crypto/crypto_getuserhint.html
+func (c *Client) CryptoGetUserHint(ctx context.Context, opts ...ClientOption) (*CryptoUserHint, error) {
+	ctx, q := toQuery(ctx, opts...)
+
+	cuh := &CryptoUserHint{}
+
+	err := c.parseAPIOutput(cuh)(c.get(ctx, "crypto_getuserhint", q))
+	if err != nil {
+		return nil, err
+	}
+
+	return cuh, nil
+}
+
+// CryptoUserKeys is returned by the SDK CryptoGetUserKeys() method.
+// Both keys are base64 encoded. The private key is encrypted with a key derived from the
+// Crypto passphrase of the user, in the format of pCloud's apps, which the SDK does not
+// implement.
+type CryptoUserKeys struct {
+	result
+	PrivateKey string
+	PublicKey  string
+}
+
+// CryptoGetUserKeys returns the Crypto key pair of the current user.
+// This fails when the user has not set up Crypto yet.
+// https://docs.pcloud.com/methods/crypto/crypto_getuserkeys.html
+func (c *Client) CryptoGetUserKeys(ctx context.Context, opts ...ClientOption) (*CryptoUserKeys, error) {
+	ctx, q := toQuery(ctx, opts...)
+
+	cuk := &CryptoUserKeys{}
+
+	err := c.parseAPIOutput(cuk)(c.get(ctx, "crypto_getuserkeys", q))
+	if err != nil {
+		return nil, err
+	}
+
+	return cuk, nil
+}
+
+// CryptoSetUserKeys sets up Crypto for the current user by storing their key pair and the
+// optional hint of the passphrase that protects the private key.
+// Both keys must be base64 encoded, in the format of pCloud's apps.
+// https://docs.pcloud.com/methods/crypto/crypto_setuserkeys.html
+func (c *Client) CryptoSetUserKeys(ctx context.Context, privateKey, publicKey, hintOpt string, opts ...ClientOption) error {
+	ctx, q := toQuery(ctx, opts...)
+
+	q.Add("privatekey", privateKey)
+	q.Add("publickey", publicKey)
+
+	if hintOpt != "" {
+		q.Add("hint", hintOpt)
+	}
+
+	r := &result{}
+
+	err := c.parseAPIOutput(r)(c.get(ctx, "crypto_setuserkeys", q))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CryptoKey contains an encrypted (base64 encoded) symmetric key, as returned by
+// CryptoGetFolderKey and CryptoGetFileKey.
+type CryptoKey struct {
+	result
+	Key string
+}
+
+// CryptoGetFolderKey returns the encrypted key of the Crypto folder identified by folderid.
+// The key is encrypted with the public key of the user.
+// https://docs.pcloud.com/methods/crypto/crypto_getfolderkey.html
+func (c *Client) CryptoGetFolderKey(ctx context.Context, folderID uint64, opts ...ClientOption) (*CryptoKey, error) {
+	ctx, q := toQuery(ctx, opts...)
+
+	q.Add("folderid", fmt.Sprintf("%d", folderID))
+
+	ck := &CryptoKey{}
+
+	err := c.parseAPIOutput(ck)(c.get(ctx, "crypto_getfolderkey", q))
+	if err != nil {
+		return nil, err
+	}
+
+	return ck, nil
+}
+
+// CryptoGetFileKey returns the encrypted key of the Crypto file identified by fileid.
+// The key is encrypted with the public key of the user.
+// https://docs.pcloud.com/methods/crypto/crypto_getfilekey.html
+func (c *Client) CryptoGetFileKey(ctx context.Context, fileID uint64, opts ...ClientOption) (*CryptoKey, error) {
+	ctx, q := toQuery(ctx, opts...)
+
+	q.Add("fileid", fmt.Sprintf("%d", fileID))
+
+	ck := &CryptoKey{}
+
+	err := c.parseAPIOutput(ck)(c.get(ctx, "crypto_getfilekey", q))
+	if err != nil {
+		return nil, err
+	}
+
+	return ck, nil
+}
+
+// CryptoSendChangeUserPrivate sends to the current user an e-mail containing a code that is
+// required by CryptoChangeUserPrivate to change the Crypto passphrase.
+// https://docs.pcloud.com/methods/crypto/crypto_sendchangeuserprivate.html
+func (c *Client) CryptoSendChangeUserPrivate(ctx context.Context, opts ...ClientOption) error {
+	ctx, q := toQuery(ctx, opts...)
+
+	r := &result{}
+
+	err := c.parseAPIOutput(r)(c.get(ctx, "crypto_sendchangeuserprivate", q))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CryptoChangeUserPrivate replaces the private key of the current user with privateKey, which
+// is the same RSA key re-encrypted with a new passphrase.
+// code is received by e-mail from CryptoSendChangeUserPrivate.
+// https://docs.pcloud.com/methods/crypto/crypto_changeuserprivate.html
+func (c *Client) CryptoChangeUserPrivate(ctx context.Context, privateKey, code, hintOpt string, opts ...ClientOption) error {
+	ctx, q := toQuery(ctx, opts...)
+
+	q.Add("privatekey", privateKey)
+	q.Add("code", code)
+
+	if hintOpt != "" {
+		q.Add("hint", hintOpt)
+	}
+
+	r := &result{}
+
+	err := c.parseAPIOutput(r)(c.get(ctx, "crypto_changeuserprivate", q))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package sdk
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseResult_Error(t *testing.T) {
+	err := parseResult([]byte(`{"result": 2009, "error": "File not found."}`), nil, &result{})
+	require.Error(t, err)
+	assert.Equal(t, "error 2009: File not found.", err.Error())
+
+	var e *Error
+	require.True(t, errors.As(err, &e))
+	assert.Equal(t, ErrFileNotFound, e.Code)
+	assert.Equal(t, "File not found.", e.Message)
+}
+
+func TestErrorPredicates(t *testing.T) {
+	apiErr := func(code int) error {
+		return errors.Wrap(&Error{Code: code}, "wrapped")
+	}
+
+	assert.True(t, IsAuthError(apiErr(ErrLoginRequired)))
+	assert.False(t, IsAuthError(apiErr(ErrFileNotFound)))
+
+	assert.True(t, IsNotFound(apiErr(ErrFileNotFound)))
+	assert.True(t, IsNotFound(apiErr(ErrDirectoryNotExists)))
+	assert.False(t, IsNotFound(apiErr(ErrFileOrFolderAlreadyExists)))
+
+	assert.True(t, IsExists(apiErr(ErrFileOrFolderAlreadyExists)))
+	assert.False(t, IsExists(errors.New("not an API error")))
+
+	assert.True(t, IsRateLimited(apiErr(ErrTooManyLoginsForIP)))
+	assert.False(t, IsRateLimited(apiErr(ErrInternalError)))
+
+	assert.True(t, IsRetryable(apiErr(ErrInternalError)))
+	assert.True(t, IsRetryable(apiErr(ErrTooManyLoginsForIP)))
+	assert.True(t, IsRetryable(errors.Wrap(&url.Error{Op: "Get", Err: errors.New("connection reset")}, "http Do")))
+	assert.False(t, IsRetryable(apiErr(ErrFileNotFound)))
+	assert.False(t, IsRetryable(nil))
+
+	assert.Equal(t, 0, ErrorCode(errors.New("not an API error")))
+}
+
+func TestCode(t *testing.T) {
+	err := errors.Wrap(&Error{Code: 2009, Message: "File not found."}, "stat")
+
+	assert.True(t, errors.Is(err, CodeFileNotFound))
+	assert.False(t, errors.Is(err, CodeDirectoryNotExists))
+	assert.False(t, errors.Is(errors.New("not an API error"), CodeFileNotFound))
+	assert.Equal(t, CodeFileNotFound, Code(ErrorCode(err)))
+	assert.Equal(t, ErrFileNotFound, int(CodeFileNotFound))
+
+	assert.Equal(t, "Log in failed.", CodeLoginFailed.Message())
+	assert.Equal(t, "error 2000: Log in failed.", CodeLoginFailed.Error())
+	assert.Empty(t, Code(1).Message())
+}
@@ -1,5 +1,10 @@
 package sdk
 
+import (
+	"encoding/json"
+	"time"
+)
+
 // Event is returned by the SDK method diff.
 // IMPORTANT NOTE:
 // Pay close attention to deletedfileid field set in metadata returned from either modifyfile
@@ -72,3 +77,95 @@ const (
 	// Every user is guaranteed to have one such event in it's full state diff.
 	ModifyUserInfo Event = "modifyuserinfo"
 )
+
+// IsFolderEvent returns true for the events that concern a folder: metadata is provided and
+// describes the folder.
+func (e Event) IsFolderEvent() bool {
+	return e == CreateFolder || e == DeleteFolder || e == ModifyFolder
+}
+
+// IsFileEvent returns true for the events that concern a file: metadata is provided and
+// describes the file.
+func (e Event) IsFileEvent() bool {
+	return e == CreateFile || e == ModifyFile || e == DeleteFile
+}
+
+// IsShareEvent returns true for the events that concern a share: share is provided.
+func (e Event) IsShareEvent() bool {
+	switch e {
+	case RequestShareIn, AcceptedShareIn, DeclinedShareIn, DeclinedShareOut, CancelledShareIn, RemovedShareIn, ModifiedShareIn:
+		return true
+	default:
+		return false
+	}
+}
+
+// EventShare is the share object provided with share events.
+// https://docs.pcloud.com/structures/event.html
+type EventShare struct {
+	ShareID        uint64 `json:"shareid,omitempty"`
+	ShareRequestID uint64 `json:"sharerequestid,omitempty"`
+	FolderID       uint64 `json:"folderid"`
+	ShareName      string `json:"sharename"`
+	FromUserID     uint64 `json:"fromuserid,omitempty"`
+	FromMail       string `json:"frommail,omitempty"`
+	ToUserID       uint64 `json:"touserid,omitempty"`
+	ToMail         string `json:"tomail,omitempty"`
+	Message        string `json:"message,omitempty"`
+	CanRead        bool   `json:"canread"`
+	CanModify      bool   `json:"canmodify"`
+	CanDelete      bool   `json:"candelete"`
+	CanCreate      bool   `json:"cancreate"`
+	Created        time.Time
+	Expires        time.Time
+}
+
+// UnmarshalJSON decodes the EventShare, with its times in any of the formats of APITime.
+func (e *EventShare) UnmarshalJSON(data []byte) error {
+	type eventShare EventShare
+	times := struct {
+		*eventShare
+		Created APITime
+		Expires APITime
+	}{eventShare: (*eventShare)(e)}
+
+	err := json.Unmarshal(data, &times)
+	if err != nil {
+		return err
+	}
+
+	e.Created, e.Expires = times.Created.Time, times.Expires.Time
+
+	return nil
+}
+
+// EventUserInfo is the userinfo object provided with the modifyuserinfo event.
+// https://docs.pcloud.com/structures/event.html
+type EventUserInfo struct {
+	UserID         uint64 `json:"userid"`
+	Premium        bool
+	PremiumExpires time.Time
+	Language       string
+	Email          string
+	EmailVerified  bool
+	Quota          uint64
+	UsedQuota      uint64
+}
+
+// UnmarshalJSON decodes the EventUserInfo, with its times in any of the formats of APITime.
+func (e *EventUserInfo) UnmarshalJSON(data []byte) error {
+	type eventUserInfo EventUserInfo
+	times := struct {
+		*eventUserInfo
+		PremiumExpires APITime
+	}{eventUserInfo: (*eventUserInfo)(e)}
+
+	err := json.Unmarshal(data, &times)
+	if err != nil {
+		return err
+	}
+
+	e.PremiumExpires = times.PremiumExpires.Time
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package sdk_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+const diffResponse = `{
+	"result": 0,
+	"diffid": 4,
+	"entries": [
+		{
+			"event": "createfile",
+			"time": "Thu, 21 Mar 2013 18:31:45 +0000",
+			"diffid": 1,
+			"metadata": {"name": "a.txt", "fileid": 10, "parentfolderid": 0, "isfolder": false}
+		},
+		{
+			"event": "deletefolder",
+			"time": "Thu, 21 Mar 2013 18:32:45 +0000",
+			"diffid": 2,
+			"metadata": {"name": "old", "folderid": 20, "isfolder": true}
+		},
+		{
+			"event": "requestsharein",
+			"time": "Thu, 21 Mar 2013 18:33:45 +0000",
+			"diffid": 3,
+			"share": {"sharerequestid": 30, "folderid": 21, "sharename": "holidays", "frommail": "a@b.c", "canread": true, "canmodify": false, "candelete": false, "cancreate": false, "message": "enjoy"}
+		},
+		{
+			"event": "modifyuserinfo",
+			"time": "Thu, 21 Mar 2013 18:34:45 +0000",
+			"diffid": 4,
+			"userinfo": {"userid": 40, "premium": false, "language": "en", "email": "a@b.c", "emailverified": true, "quota": 10737418240, "usedquota": 1024}
+		}
+	]
+}`
+
+func TestDiff_TypedEntries(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/diff", r.URL.Path)
+		assert.Equal(t, "3", r.URL.Query().Get("diffid"))
+		assert.Equal(t, "1", r.URL.Query().Get("block"))
+		assert.Equal(t, "100", r.URL.Query().Get("limit"))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, diffResponse)
+	}))
+	defer srv.Close()
+
+	pcc := sdk.NewClient(srv.Client(), sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")))
+
+	dr, err := pcc.Changes(context.Background(), 3, sdk.ChangesOptions{Block: true, Limit: 100})
+	require.NoError(t, err)
+	assert.EqualValues(t, 4, dr.DiffID)
+	require.Len(t, dr.Entries, 4)
+
+	e := dr.Entries[0]
+	assert.True(t, e.Event.IsFileEvent())
+	assert.Equal(t, "a.txt", e.Metadata.Name)
+	assert.EqualValues(t, 10, e.Metadata.FileID)
+	assert.Nil(t, e.Share)
+
+	e = dr.Entries[1]
+	assert.Equal(t, sdk.DeleteFolder, e.Event)
+	assert.True(t, e.Event.IsFolderEvent())
+	assert.EqualValues(t, 20, e.Metadata.FolderID)
+
+	e = dr.Entries[2]
+	assert.True(t, e.Event.IsShareEvent())
+	require.NotNil(t, e.Share)
+	assert.EqualValues(t, 30, e.Share.ShareRequestID)
+	assert.Equal(t, "holidays", e.Share.ShareName)
+	assert.True(t, e.Share.CanRead)
+
+	e = dr.Entries[3]
+	assert.Equal(t, sdk.ModifyUserInfo, e.Event)
+	require.NotNil(t, e.UserInfo)
+	assert.EqualValues(t, 1024, e.UserInfo.UsedQuota)
+	assert.True(t, e.UserInfo.EmailVerified)
+}
+
+func TestGetFileHistory_Chronological(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/getfilehistory", r.URL.Path)
+		assert.Equal(t, "10", r.URL.Query().Get("fileid"))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{
+			"result": 0,
+			"entries": [
+				{"event": "deletefile", "time": "Thu, 21 Mar 2013 18:35:45 +0000", "diffid": 9, "metadata": {"name": "a.txt", "fileid": 10}},
+				{"event": "createfile", "time": "Thu, 21 Mar 2013 18:31:45 +0000", "diffid": 1, "metadata": {"name": "a.txt", "fileid": 10}},
+				{"event": "modifyfile", "time": "Thu, 21 Mar 2013 18:33:45 +0000", "diffid": 5, "metadata": {"name": "a.txt", "fileid": 10}}
+			]
+		}`)
+	}))
+	defer srv.Close()
+
+	pcc := sdk.NewClient(srv.Client(), sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")))
+
+	dr, err := pcc.GetFileHistory(context.Background(), 10)
+	require.NoError(t, err)
+	require.Len(t, dr.Entries, 3)
+	assert.Equal(t, sdk.CreateFile, dr.Entries[0].Event)
+	assert.Equal(t, sdk.ModifyFile, dr.Entries[1].Event)
+	assert.Equal(t, sdk.DeleteFile, dr.Entries[2].Event)
+}
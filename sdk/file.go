@@ -23,13 +23,18 @@ type FileResult struct {
 
 // DeleteFile deletes a file identified by fileid or path.
 // https://docs.pcloud.com/methods/file/deletefile.html
-func (c *Client) DeleteFile(ctx context.Context, file T3PathOrFileID, opts ...ClientOption) (*FileResult, error) {
-	q := toQuery(opts...)
-	file(q)
+func (c *Client) DeleteFile(ctx context.Context, file FileRef, opts ...ClientOption) (*FileResult, error) {
+	ctx, q := toQuery(ctx, opts...)
+	file.setFile(q)
+
+	err := c.checkIfHash(ctx, file)
+	if err != nil {
+		return nil, err
+	}
 
 	r := &FileResult{}
 
-	err := parseAPIOutput(r)(c.get(ctx, "deletefile", q))
+	err = c.parseAPIOutput(r)(c.get(ctx, "deletefile", q))
 	if err != nil {
 		return nil, err
 	}
@@ -45,14 +50,24 @@ func (c *Client) DeleteFile(ctx context.Context, file T3PathOrFileID, opts ...Cl
 // in this case the metadata will include deletedfileid with the fileid of the old file at the
 // destination, and the source and destination files revisions will be merged together.
 // https://docs.pcloud.com/methods/file/renamefile.html
-func (c *Client) RenameFile(ctx context.Context, file T3PathOrFileID, destination ToT3PathOrFolderIDName, opts ...ClientOption) (*FileResult, error) {
-	q := toQuery(opts...)
-	file(q)
-	destination(q)
+func (c *Client) RenameFile(ctx context.Context, file FileRef, destination FolderRef, opts ...ClientOption) (*FileResult, error) {
+	ctx, q := toQuery(ctx, opts...)
+	file.setFile(q)
+	destination.setFolder(q, "to")
+
+	err := c.checkIfHash(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.checkIfDestinationHashOfMove(ctx, file, destination)
+	if err != nil {
+		return nil, err
+	}
 
 	r := &FileResult{}
 
-	err := parseAPIOutput(r)(c.get(ctx, "renamefile", q))
+	err = c.parseAPIOutput(r)(c.get(ctx, "renamefile", q))
 	if err != nil {
 		return nil, err
 	}
@@ -63,13 +78,13 @@ func (c *Client) RenameFile(ctx context.Context, file T3PathOrFileID, destinatio
 // Stat returns information about the file pointed to by fileid or path.
 // It's is recomended to use fileid.
 // https://docs.pcloud.com/methods/file/stat.html
-func (c *Client) Stat(ctx context.Context, file T3PathOrFileID, opts ...ClientOption) (*FileResult, error) {
-	q := toQuery(opts...)
-	file(q)
+func (c *Client) Stat(ctx context.Context, file FileRef, opts ...ClientOption) (*FileResult, error) {
+	ctx, q := toQuery(ctx, opts...)
+	file.setFile(q)
 
 	r := &FileResult{}
 
-	err := parseAPIOutput(r)(c.get(ctx, "stat", q))
+	err := c.parseAPIOutput(r)(c.get(ctx, "stat", q))
 	if err != nil {
 		return nil, err
 	}
@@ -77,7 +92,30 @@ func (c *Client) Stat(ctx context.Context, file T3PathOrFileID, opts ...ClientOp
 	return r, nil
 }
 
-// CopyFile takes one file and copies it as another file in the user's filesystem.
+// CopyFileOptions are the optional parameters of CopyFileTo.
+type CopyFileOptions struct {
+	// NoOver if set, the destination file is not overwritten: the call fails with
+	// ErrFileOrFolderAlreadyExists instead.
+	NoOver bool
+
+	// ModifiedTime and CreatedTime, if not zero, set the modification and the creation time of
+	// the destination file. pCloud requires ModifiedTime to set CreatedTime.
+	ModifiedTime time.Time
+	CreatedTime  time.Time
+}
+
+// options returns the ClientOption's of the parameters of o that are set.
+func (o CopyFileOptions) options() []ClientOption {
+	var opts []ClientOption
+
+	if o.NoOver {
+		opts = append(opts, WithNoOver())
+	}
+
+	return append(opts, WithModifiedTime(o.ModifiedTime), WithCreatedTime(o.CreatedTime))
+}
+
+// CopyFileTo takes one file and copies it as another file in the user's filesystem.
 // Expects fileid or path to identify the source file and tofolderid+toname or topath to
 // identify destination filename.
 // If toname is omitted, original filename is used.
@@ -87,28 +125,20 @@ func (c *Client) Stat(ctx context.Context, file T3PathOrFileID, opts ...ClientOp
 // Any future operations on either the source or destination file will not modify the other one.
 // This call is useful when you want to create a public link from somebody else's file (shared
 // with you).
-// If ctime is set, file created time is set. It's required to provide mtime to set ctime.
 // https://docs.pcloud.com/methods/file/copyfile.html
-func (c *Client) CopyFile(ctx context.Context, file T3PathOrFileID, destination ToT3PathOrFolderIDName, noOverOpt bool, mTime, cTime time.Time, opts ...ClientOption) (*FileResult, error) {
-	q := toQuery(opts...)
-	file(q)
-	destination(q)
-
-	if noOverOpt {
-		q.Add("noover", "1")
-	}
-
-	if !mTime.IsZero() {
-		q.Add("mtime", fmt.Sprintf("%d", mTime.UTC().Unix()))
-	}
+func (c *Client) CopyFileTo(ctx context.Context, file FileRef, destination FolderRef, o CopyFileOptions, opts ...ClientOption) (*FileResult, error) {
+	ctx, q := toQuery(ctx, withOptions(opts, o)...)
+	file.setFile(q)
+	destination.setFolder(q, "to")
 
-	if !cTime.IsZero() {
-		q.Add("ctime", fmt.Sprintf("%d", cTime.UTC().Unix()))
+	err := c.checkIfDestinationHashOfMove(ctx, file, destination)
+	if err != nil {
+		return nil, err
 	}
 
 	r := &FileResult{}
 
-	err := parseAPIOutput(r)(c.get(ctx, "copyfile", q))
+	err = c.parseAPIOutput(r)(c.get(ctx, "copyfile", q))
 	if err != nil {
 		return nil, err
 	}
@@ -116,6 +146,15 @@ func (c *Client) CopyFile(ctx context.Context, file T3PathOrFileID, destination
 	return r, nil
 }
 
+// CopyFile is CopyFileTo with its optional parameters passed positionally.
+// If cTime is set, file created time is set. It's required to provide mTime to set cTime.
+// https://docs.pcloud.com/methods/file/copyfile.html
+//
+// Deprecated: use CopyFileTo.
+func (c *Client) CopyFile(ctx context.Context, file FileRef, destination FolderRef, noOverOpt bool, mTime, cTime time.Time, opts ...ClientOption) (*FileResult, error) {
+	return c.CopyFileTo(ctx, file, destination, CopyFileOptions{NoOver: noOverOpt, ModifiedTime: mTime, CreatedTime: cTime}, opts...)
+}
+
 // FileChecksum is returned by the SDK FileChecksum() method.
 type FileChecksum struct {
 	result
@@ -133,13 +172,13 @@ type FileChecksum struct {
 // collions.
 // sha256 is returned in Europe only.
 // https://docs.pcloud.com/methods/file/checksumfile.html
-func (c *Client) ChecksumFile(ctx context.Context, file T3PathOrFileID, opts ...ClientOption) (*FileChecksum, error) {
-	q := toQuery(opts...)
-	file(q)
+func (c *Client) ChecksumFile(ctx context.Context, file FileRef, opts ...ClientOption) (*FileChecksum, error) {
+	ctx, q := toQuery(ctx, opts...)
+	file.setFile(q)
 
 	fc := &FileChecksum{}
 
-	err := parseAPIOutput(fc)(c.get(ctx, "checksumfile", q))
+	err := c.parseAPIOutput(fc)(c.get(ctx, "checksumfile", q))
 	if err != nil {
 		return nil, err
 	}
@@ -147,7 +186,7 @@ func (c *Client) ChecksumFile(ctx context.Context, file T3PathOrFileID, opts ...
 	return fc, nil
 }
 
-// FileUpload is returned by the SDK UploadFile() method.
+// FileUpload is returned by the SDK UploadFiles() method.
 type FileUpload struct {
 	result
 	FileIDs   []uint64
@@ -162,13 +201,48 @@ type ChecksumSet struct {
 	SHA256 string
 }
 
-// UploadFile Upload a file.
+// UploadOptions are the optional parameters of UploadFiles.
+type UploadOptions struct {
+	// NoPartial if set, the partially uploaded files are not saved, that is when the connection
+	// breaks before a file is read in full.
+	NoPartial bool
+
+	// ProgressHash, if not empty, is the hash by which the progress of the upload can be
+	// followed with the uploadprogress method.
+	ProgressHash string
+
+	// RenameIfExists if set, on name conflict, the files are not overwritten but renamed to names
+	// like filename (2).ext.
+	RenameIfExists bool
+
+	// ModifiedTime and CreatedTime, if not zero, set the modification and the creation time of
+	// the uploaded files. pCloud requires ModifiedTime to set CreatedTime.
+	ModifiedTime time.Time
+	CreatedTime  time.Time
+}
+
+// options returns the ClientOption's of the parameters of o that are set.
+func (o UploadOptions) options() []ClientOption {
+	var opts []ClientOption
+
+	if o.NoPartial {
+		opts = append(opts, WithNoPartial())
+	}
+
+	if o.ProgressHash != "" {
+		opts = append(opts, WithProgressHash(o.ProgressHash))
+	}
+
+	if o.RenameIfExists {
+		opts = append(opts, WithRenameIfExists())
+	}
+
+	return append(opts, WithModifiedTime(o.ModifiedTime), WithCreatedTime(o.CreatedTime))
+}
+
+// UploadFiles uploads files.
 // String path or int folderid specify the target directory. If both are omitted the root folder
 // is selected.
-// Parameter string progresshash can be passed. Same should be passed to uploadprogress method.
-// If nopartial is set, partially uploaded files will not be saved (that is when the connection
-// breaks before file is read in full). If renameifexists is set, on name conflict, files will
-// not be overwritten but renamed to name like filename (2).ext.
 // Multiple files can be uploaded, using POST with multipart/form-data encoding. If passed by
 // POST, the parameters must come before files. All files are accepted, the name of the form
 // field is ignored. Multiple files can come one or more HTML file controls.
@@ -184,28 +258,18 @@ type ChecksumSet struct {
 // data (if any) from the current position will be uplaoded!
 //
 // https://docs.pcloud.com/methods/file/uploadfile.html
-func (c *Client) UploadFile(ctx context.Context, folder T1PathOrFolderID, files map[string]*os.File, noPartialOpt bool, progressHashOpt string, renameIfExistsOpt bool, mTimeOpt, cTimeOpt time.Time, opts ...ClientOption) (*FileUpload, error) {
-	q := toQuery(opts...)
-	folder(q)
-
-	if noPartialOpt {
-		q.Add("nopartial", "1")
-	}
-
-	if progressHashOpt != "" {
-		q.Add("progresshash", progressHashOpt)
-	}
+func (c *Client) UploadFiles(ctx context.Context, folder FolderRef, files map[string]*os.File, o UploadOptions, opts ...ClientOption) (*FileUpload, error) {
+	ctx, q := toQuery(ctx, withOptions(opts, o)...)
+	folder.setFolder(q, "")
 
-	if renameIfExistsOpt {
-		q.Add("renameifexists", "1")
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
 	}
 
-	if !mTimeOpt.IsZero() {
-		q.Add("mtime", fmt.Sprintf("%d", mTimeOpt.UTC().Unix()))
-	}
-
-	if !cTimeOpt.IsZero() {
-		q.Add("ctime", fmt.Sprintf("%d", cTimeOpt.UTC().Unix()))
+	err := c.checkIfDestinationHash(ctx, folder, names...)
+	if err != nil {
+		return nil, err
 	}
 
 	fu := &FileUpload{}
@@ -215,7 +279,8 @@ func (c *Client) UploadFile(ctx context.Context, folder T1PathOrFolderID, files
 		return nil, err
 	}
 
-	err = parseAPIOutput(fu)(c.post(ctx, "uploadfile", q, contentType, data))
+	err = c.parseAPIOutput(fu)(c.post(ctx, "uploadfile", q, contentType, data))
+	PutBuffer(data)
 	if err != nil {
 		return nil, err
 	}
@@ -223,14 +288,34 @@ func (c *Client) UploadFile(ctx context.Context, folder T1PathOrFolderID, files
 	return fu, nil
 }
 
+// UploadFile is UploadFiles with its optional parameters passed positionally.
+// https://docs.pcloud.com/methods/file/uploadfile.html
+//
+// Deprecated: use UploadFiles.
+func (c *Client) UploadFile(ctx context.Context, folder FolderRef, files map[string]*os.File, noPartialOpt bool, progressHashOpt string, renameIfExistsOpt bool, mTimeOpt, cTimeOpt time.Time, opts ...ClientOption) (*FileUpload, error) {
+	o := UploadOptions{
+		NoPartial:      noPartialOpt,
+		ProgressHash:   progressHashOpt,
+		RenameIfExists: renameIfExistsOpt,
+		ModifiedTime:   mTimeOpt,
+		CreatedTime:    cTimeOpt,
+	}
+
+	return c.UploadFiles(ctx, folder, files, o, opts...)
+}
+
 // ToT3PathOrFolderIDName is a type of parameters that some of the SDK functions take.
 // It applies when referencing a destination folder.
 // Functions that use it have a dichotomic usage to reference a folder:
 // by path alone or by folderid+name.
+//
+// Deprecated: use FolderRef.
 type ToT3PathOrFolderIDName func(q url.Values)
 
 // ToT3ByPath is a type of ToT3PathOrFolderIDName that references a folder (must end with '/')
 // or afile, by path alone.
+//
+// Deprecated: use ByPath.
 func ToT3ByPath(path string) ToT3PathOrFolderIDName {
 	return func(q url.Values) {
 		q.Set("topath", path)
@@ -239,6 +324,8 @@ func ToT3ByPath(path string) ToT3PathOrFolderIDName {
 
 // ToT3ByIDName is a type of ToT3PathOrFolderIDName that references a file by
 // folderid+name or, if name is empty, a folder.
+//
+// Deprecated: use ByIDName.
 func ToT3ByIDName(folderID uint64, name string) ToT3PathOrFolderIDName {
 	return func(q url.Values) {
 		q.Set("tofolderid", fmt.Sprintf("%d", folderID))
@@ -246,10 +333,19 @@ func ToT3ByIDName(folderID uint64, name string) ToT3PathOrFolderIDName {
 	}
 }
 
+// The form is built in a pooled buffer (see GetBuffer).
 func prepareForm(files map[string]*os.File) (string, []byte, error) {
-	var b bytes.Buffer
+	// headroom for the multipart headers.
+	size := int64(4096)
+	for _, f := range files {
+		if fi, err := f.Stat(); err == nil {
+			size += fi.Size()
+		}
+	}
+
+	b := bytes.NewBuffer(GetBuffer(int(size))[:0])
 
-	w := multipart.NewWriter(&b)
+	w := multipart.NewWriter(b)
 	defer func() { _ = w.Close() }()
 
 	for destName, f := range files {
@@ -2,7 +2,6 @@ package sdk_test
 
 import (
 	"os"
-	"time"
 
 	"github.com/google/uuid"
 
@@ -23,7 +22,7 @@ func (testsuite *IntegrationTestSuite) Test_UploadFile() {
 	}(files)
 
 	progressHash := ""
-	fu, err := testsuite.pcc.UploadFile(testsuite.ctx, sdk.T1FolderByID(testsuite.testFolderID), files, true, progressHash, true, time.Time{}, time.Time{})
+	fu, err := testsuite.pcc.UploadFiles(testsuite.ctx, sdk.ByID(testsuite.testFolderID), files, sdk.UploadOptions{NoPartial: true, ProgressHash: progressHash, RenameIfExists: true})
 	// if this test starts failing for no apparent reason, add a retry loop to ensure pCloud has propagated the upload(s).
 	testsuite.Require().NoError(err)
 	testsuite.Len(fu.FileIDs, len(files))
@@ -37,7 +36,7 @@ func (testsuite *IntegrationTestSuite) Test_UploadFile() {
 }
 
 func (testsuite *IntegrationTestSuite) Test_Stat() {
-	fs, err := testsuite.pcc.Stat(testsuite.ctx, sdk.T3FileByID(testsuite.testFileID))
+	fs, err := testsuite.pcc.Stat(testsuite.ctx, sdk.ByID(testsuite.testFileID))
 	testsuite.Require().NoError(err)
 	testsuite.Equal(testsuite.testFileID, fs.Metadata.FileID)
 	testsuite.Equal("sample.file", fs.Metadata.Name)
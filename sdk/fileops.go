@@ -40,17 +40,34 @@ const (
 	O_APPEND = 0x0400
 )
 
+// fdEndpoints lists the file operations whose file descriptor pCloud binds to the connection
+// it was opened on. The Client sends them over a connection of their own.
+var fdEndpoints = map[string]bool{
+	"file_checksum":    true,
+	"file_close":       true,
+	"file_lock":        true,
+	"file_open":        true,
+	"file_pread":       true,
+	"file_pread_ifmod": true,
+	"file_pwrite":      true,
+	"file_read":        true,
+	"file_seek":        true,
+	"file_size":        true,
+	"file_truncate":    true,
+	"file_write":       true,
+}
+
 // FileOpen opens a file descriptor.
 // https://docs.pcloud.com/methods/fileops/file_open.html
-func (c *Client) FileOpen(ctx context.Context, flags uint64, file T4PathOrFileIDOrFolderIDName, opts ...ClientOption) (*File, error) {
-	q := toQuery(opts...)
-	file(q)
+func (c *Client) FileOpen(ctx context.Context, flags uint64, file FileRef, opts ...ClientOption) (*File, error) {
+	ctx, q := toQuery(ctx, opts...)
+	file.setFile(q)
 
 	q.Add("flags", fmt.Sprintf("%d", flags))
 
 	f := &File{}
 
-	err := parseAPIOutput(f)(c.get(ctx, "file_open", q))
+	err := c.parseAPIOutput(f)(c.get(ctx, "file_open", q))
 	if err != nil {
 		return nil, err
 	}
@@ -70,13 +87,13 @@ type FileDataTransfer struct {
 // You can see how to send data here: https://docs.pcloud.com/methods/fileops/index.html
 // https://docs.pcloud.com/methods/fileops/file_write.html
 func (c *Client) FileWrite(ctx context.Context, fd uint64, data []byte, opts ...ClientOption) (*FileDataTransfer, error) {
-	q := toQuery(opts...)
+	ctx, q := toQuery(ctx, opts...)
 
 	q.Add("fd", fmt.Sprintf("%d", fd))
 
 	fdt := &FileDataTransfer{}
 
-	err := parseAPIOutput(fdt)(c.put(ctx, "file_write", q, data))
+	err := c.parseAPIOutput(fdt)(c.put(ctx, "file_write", q, data))
 	if err != nil {
 		return nil, err
 	}
@@ -88,6 +105,7 @@ func (c *Client) FileWrite(ctx context.Context, fd uint64, data []byte, opts ...
 // If currentofset+count<=filesize this method will satisfy the request and read count bytes,
 // otherwise it will return just the bytes available (this is the only way to discover the EOF
 // condition).
+// The data may be returned to the buffer pool with PutBuffer once done with.
 // You can see how to send data here: https://docs.pcloud.com/methods/fileops/index.html
 // https://docs.pcloud.com/methods/fileops/file_read.html
 func (c *Client) FileRead(ctx context.Context, fd, count uint64, opts ...ClientOption) ([]byte, error) {
@@ -95,7 +113,7 @@ func (c *Client) FileRead(ctx context.Context, fd, count uint64, opts ...ClientO
 	//        AND EOF has been reached. In the current implementation, EOF is returned when some
 	//        data was read but EOF was reached. This means both data and EOF are returned at the
 	//        same time. This is not as per os.File.Read()'s specification which returns "0, EOF".
-	q := toQuery(opts...)
+	ctx, q := toQuery(ctx, opts...)
 
 	q.Add("fd", fmt.Sprintf("%d", fd))
 	q.Add("count", fmt.Sprintf("%d", count))
@@ -109,11 +127,12 @@ func (c *Client) FileRead(ctx context.Context, fd, count uint64, opts ...ClientO
 }
 
 // FilePRead tries to read at most count bytes at the given offset of the file.
+// The data may be returned to the buffer pool with PutBuffer once done with.
 // You can see how to send data here: https://docs.pcloud.com/methods/fileops/index.html
 // offset starts at 0.
 // https://docs.pcloud.com/methods/fileops/file_pread.html
 func (c *Client) FilePRead(ctx context.Context, fd, count, offset uint64, opts ...ClientOption) ([]byte, error) {
-	q := toQuery(opts...)
+	ctx, q := toQuery(ctx, opts...)
 
 	q.Add("fd", fmt.Sprintf("%d", fd))
 	q.Add("count", fmt.Sprintf("%d", count))
@@ -155,7 +174,7 @@ func T5MD5(md5 string) T5SHA1OrMD5 {
 // You can see how to send data here: https://docs.pcloud.com/methods/fileops/index.html
 // https://docs.pcloud.com/methods/fileops/file_pread_ifmod.html
 func (c *Client) FilePReadIfMod(ctx context.Context, fd, count, offset uint64, checksum T5SHA1OrMD5, opts ...ClientOption) ([]byte, error) {
-	q := toQuery(opts...)
+	ctx, q := toQuery(ctx, opts...)
 	checksum(q)
 
 	q.Add("fd", fmt.Sprintf("%d", fd))
@@ -192,7 +211,7 @@ type PFileChecksum struct {
 // You can see how to send data here: https://docs.pcloud.com/methods/fileops/index.html
 // https://docs.pcloud.com/methods/fileops/file_checksum.html
 func (c *Client) FileChecksum(ctx context.Context, fd, count, offset uint64, opts ...ClientOption) (*PFileChecksum, error) {
-	q := toQuery(opts...)
+	ctx, q := toQuery(ctx, opts...)
 
 	q.Add("fd", fmt.Sprintf("%d", fd))
 	q.Add("count", fmt.Sprintf("%d", count))
@@ -200,7 +219,7 @@ func (c *Client) FileChecksum(ctx context.Context, fd, count, offset uint64, opt
 
 	pfc := &PFileChecksum{}
 
-	err := parseAPIOutput(pfc)(c.get(ctx, "file_checksum", q))
+	err := c.parseAPIOutput(pfc)(c.get(ctx, "file_checksum", q))
 	if err != nil {
 		return nil, err
 	}
@@ -236,7 +255,7 @@ type FileSeek struct {
 // 2        after end of the file.
 // https://docs.pcloud.com/methods/fileops/file_seek.html
 func (c *Client) FileSeek(ctx context.Context, fd, offset uint64, whenceOpt Whence, opts ...ClientOption) (*FileSeek, error) {
-	q := toQuery(opts...)
+	ctx, q := toQuery(ctx, opts...)
 
 	q.Add("fd", fmt.Sprintf("%d", fd))
 	q.Add("offset", fmt.Sprintf("%d", offset))
@@ -244,7 +263,7 @@ func (c *Client) FileSeek(ctx context.Context, fd, offset uint64, whenceOpt When
 
 	fs := &FileSeek{}
 
-	err := parseAPIOutput(fs)(c.get(ctx, "file_seek", q))
+	err := c.parseAPIOutput(fs)(c.get(ctx, "file_seek", q))
 	if err != nil {
 		return nil, err
 	}
@@ -255,13 +274,13 @@ func (c *Client) FileSeek(ctx context.Context, fd, offset uint64, whenceOpt When
 // FileClose closes a file descriptor.
 // https://docs.pcloud.com/methods/fileops/file_close.html
 func (c *Client) FileClose(ctx context.Context, fd uint64, opts ...ClientOption) error {
-	q := toQuery(opts...)
+	ctx, q := toQuery(ctx, opts...)
 
 	q.Add("fd", fmt.Sprintf("%d", fd))
 
 	f := &result{}
 
-	err := parseAPIOutput(f)(c.get(ctx, "file_close", q))
+	err := c.parseAPIOutput(f)(c.get(ctx, "file_close", q))
 	if err != nil {
 		return err
 	}
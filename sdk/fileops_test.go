@@ -15,11 +15,11 @@ func (testsuite *IntegrationTestSuite) Test_FileOps_ByPath() {
 	folderPath := testsuite.testFolderPath + "/go_pCloud_" + uuid.New().String()
 	fileName := "go_pCloud_" + uuid.New().String() + ".bin"
 
-	_, err := testsuite.pcc.CreateFolder(testsuite.ctx, sdk.T2FolderByPath(folderPath))
+	_, err := testsuite.pcc.CreateFolder(testsuite.ctx, sdk.ByPath(folderPath))
 	testsuite.Require().NoError(err)
 
 	// File operations by path
-	f, err := testsuite.pcc.FileOpen(testsuite.ctx, sdk.O_CREAT|sdk.O_EXCL, sdk.T4FileByPath(folderPath+"/"+fileName))
+	f, err := testsuite.pcc.FileOpen(testsuite.ctx, sdk.O_CREAT|sdk.O_EXCL, sdk.ByPath(folderPath+"/"+fileName))
 	testsuite.Require().NoError(err)
 
 	// file write
@@ -75,7 +75,7 @@ func (testsuite *IntegrationTestSuite) Test_FileOps_ByPath() {
 
 		sha1sum = fmt.Sprintf("%x", cs.Sum(nil))
 		var fc *sdk.FileChecksum
-		fc, err = testsuite.pcc.ChecksumFile(testsuite.ctx, sdk.T3FileByPath(folderPath+"/"+fileName))
+		fc, err = testsuite.pcc.ChecksumFile(testsuite.ctx, sdk.ByPath(folderPath+"/"+fileName))
 		if err != nil {
 			continue
 		}
@@ -89,39 +89,39 @@ func (testsuite *IntegrationTestSuite) Test_FileOps_ByPath() {
 	}
 
 	// copy original file to "* COPY", for use by "File operations by id", below
-	cf, err := testsuite.pcc.CopyFile(testsuite.ctx, sdk.T3FileByPath(folderPath+"/"+fileName), sdk.ToT3ByPath(folderPath+"/"+fileName+" COPY"), true, time.Time{}, time.Time{})
+	cf, err := testsuite.pcc.CopyFileTo(testsuite.ctx, sdk.ByPath(folderPath+"/"+fileName), sdk.ByPath(folderPath+"/"+fileName+" COPY"), sdk.CopyFileOptions{NoOver: true})
 	testsuite.Require().NoError(err)
 	cFileID := cf.Metadata.FileID
 
 	// copy original file to "* COPY2"
-	cf2, err := testsuite.pcc.CopyFile(testsuite.ctx, sdk.T3FileByPath(folderPath+"/"+fileName), sdk.ToT3ByPath(folderPath+"/"+fileName+" COPY2"), true, time.Time{}, time.Time{})
+	cf2, err := testsuite.pcc.CopyFileTo(testsuite.ctx, sdk.ByPath(folderPath+"/"+fileName), sdk.ByPath(folderPath+"/"+fileName+" COPY2"), sdk.CopyFileOptions{NoOver: true})
 	testsuite.Require().NoError(err)
 	cFileID2 := cf2.Metadata.FileID
 
 	// rename original file to "* COPY2" (i.e. overwrite operation)
-	rf, err := testsuite.pcc.RenameFile(testsuite.ctx, sdk.T3FileByPath(folderPath+"/"+fileName), sdk.ToT3ByPath(folderPath+"/"+fileName+" COPY2"))
+	rf, err := testsuite.pcc.RenameFile(testsuite.ctx, sdk.ByPath(folderPath+"/"+fileName), sdk.ByPath(folderPath+"/"+fileName+" COPY2"))
 	testsuite.Require().NoError(err)
 	testsuite.Equal(cFileID2, rf.Metadata.DeletedFileID)
 
 	// delete "* COPY2" file.
-	df, err := testsuite.pcc.DeleteFile(testsuite.ctx, sdk.T3FileByPath(folderPath+"/"+fileName+" COPY2"))
+	df, err := testsuite.pcc.DeleteFile(testsuite.ctx, sdk.ByPath(folderPath+"/"+fileName+" COPY2"))
 	testsuite.Require().NoError(err)
 	testsuite.True(df.Metadata.IsDeleted)
 
 	// File operations by id
-	f, err = testsuite.pcc.FileOpen(testsuite.ctx, 0, sdk.T4FileByID(cFileID))
+	f, err = testsuite.pcc.FileOpen(testsuite.ctx, 0, sdk.ByID(cFileID))
 	testsuite.Require().NoError(err)
 
 	err = testsuite.pcc.FileClose(testsuite.ctx, f.FD)
 	testsuite.Require().NoError(err)
 
-	_, err = testsuite.pcc.RenameFile(testsuite.ctx, sdk.T3FileByID(cFileID), sdk.ToT3ByIDName(testsuite.testFolderID, fileName+" RENAMED BY ID"))
+	_, err = testsuite.pcc.RenameFile(testsuite.ctx, sdk.ByID(cFileID), sdk.ByIDName(testsuite.testFolderID, fileName+" RENAMED BY ID"))
 	testsuite.Require().NoError(err)
 
-	df, err = testsuite.pcc.DeleteFile(testsuite.ctx, sdk.T3FileByID(cFileID))
+	df, err = testsuite.pcc.DeleteFile(testsuite.ctx, sdk.ByID(cFileID))
 	testsuite.Require().NoError(err)
 	testsuite.True(df.Metadata.IsDeleted)
 
-	_, err = testsuite.pcc.DeleteFolderRecursive(testsuite.ctx, sdk.T1FolderByPath(folderPath))
+	_, err = testsuite.pcc.DeleteFolderRecursive(testsuite.ctx, sdk.ByPath(folderPath))
 	testsuite.Require().NoError(err)
 }
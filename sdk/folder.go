@@ -2,8 +2,12 @@ package sdk
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/url"
+	"strconv"
+	"time"
 )
 
 // RootFolderID is the folderID of the root folder (i.e. '/').
@@ -17,13 +21,57 @@ type FSList struct {
 	Metadata *Metadata
 }
 
+// Category is the category of a file, which pCloud derives from its content type.
+// https://docs.pcloud.com/structures/metadata.html
+type Category int32
+
+const (
+	// CategoryUncategorized is the category of the files that are in none of the categories below.
+	CategoryUncategorized Category = iota
+
+	// CategoryImage is the category of the image files.
+	CategoryImage
+
+	// CategoryVideo is the category of the video files.
+	CategoryVideo
+
+	// CategoryAudio is the category of the audio files.
+	CategoryAudio
+
+	// CategoryDocument is the category of the document files.
+	CategoryDocument
+
+	// CategoryArchive is the category of the archive files.
+	CategoryArchive
+)
+
+// String returns the name of the category, such as "image".
+func (c Category) String() string {
+	switch c {
+	case CategoryUncategorized:
+		return "uncategorized"
+	case CategoryImage:
+		return "image"
+	case CategoryVideo:
+		return "video"
+	case CategoryAudio:
+		return "audio"
+	case CategoryDocument:
+		return "document"
+	case CategoryArchive:
+		return "archive"
+	default:
+		return fmt.Sprintf("category %d", int32(c))
+	}
+}
+
 // Metadata contains properties related to folder and file information.
 type Metadata struct {
 	Path string
 
 	// Generic
 	Name    string
-	Created *APITime
+	Created time.Time
 
 	IsMine bool `json:"ismine"`
 	// BEGIN: if IsMine == false
@@ -33,34 +81,30 @@ type Metadata struct {
 	CanCreate bool `json:"cancreate,omitempty"` // for folders only
 	// END: if IsMine == false
 
-	Thumb          bool
-	Modified       *APITime
-	Comments       uint64
+	Thumb          bool // set when pCloud can create thumbnails of the file
+	Modified       time.Time
+	Comments       uint64 // the number of comments on the folder or file
 	ID             string
-	IsShared       bool `json:"isshared"`
-	Icon           string
+	IsShared       bool   `json:"isshared"`
+	Icon           Icon   // the icon to show for the folder or file, such as "folder" or "image"
 	IsFolder       bool   `json:"isfolder"`
 	ParentFolderID uint64 `json:"parentfolderid"`
 	IsDeleted      bool   `json:"isdeleted"`     // this may be set by DeleteFile, for instance
 	DeletedFileID  uint64 `json:"deletedfileid"` // this may be set by RenameFile, for instance
+	// Encrypted is set on the folders and files of Crypto folders, whose names and contents
+	// are encrypted by the client.
+	Encrypted bool `json:"encrypted,omitempty"`
 
 	// Folder-specific
 	FolderID uint64      `json:"folderid,omitempty"`
 	Contents []*Metadata `json:"contents,omitempty"`
 
 	// File-specific
-	FileID uint64 `json:"fileid,omitempty"`
-	Hash   uint64 `json:"hash,omitempty"`
-	// Category is one of:
-	// 0 - uncategorized
-	// 1 - image
-	// 2 - video
-	// 3 - audio
-	// 4 - document
-	// 5 - archive
-	Category    int32  `json:"category,omitempty"`
-	Size        uint64 `json:"size,omitempty"`
-	ContentType string `json:"contenttype,omitempty"`
+	FileID      uint64   `json:"fileid,omitempty"`
+	Hash        uint64   `json:"hash,omitempty"`
+	Category    Category `json:"category,omitempty"`
+	Size        uint64   `json:"size,omitempty"`
+	ContentType string   `json:"contenttype,omitempty"`
 
 	// optionally, image/video files may have:
 	Width  int `json:"width,omitempty"`
@@ -84,6 +128,35 @@ type Metadata struct {
 	Rotate          int    `json:"rotate,omitempty"`          // indicates that video should be rotated (0, 90, 180 or 270) degrees when playing}
 }
 
+// MediaDuration returns the duration of the video or audio file, or 0 when pCloud did not
+// return it.
+func (m *Metadata) MediaDuration() time.Duration {
+	sec, err := strconv.ParseFloat(m.Duration, 64)
+	if err != nil || sec < 0 {
+		return 0
+	}
+	return time.Duration(sec * float64(time.Second))
+}
+
+// UnmarshalJSON decodes the Metadata, with its times in any of the formats of APITime.
+func (m *Metadata) UnmarshalJSON(data []byte) error {
+	type metadata Metadata
+	times := struct {
+		*metadata
+		Created  APITime
+		Modified APITime
+	}{metadata: (*metadata)(m)}
+
+	err := json.Unmarshal(data, &times)
+	if err != nil {
+		return err
+	}
+
+	m.Created, m.Modified = times.Created.Time, times.Modified.Time
+
+	return nil
+}
+
 // DeleteResult contains the properties returned by DeleteFolderRecursive.
 type DeleteResult struct {
 	result
@@ -91,34 +164,76 @@ type DeleteResult struct {
 	DeletedFolders uint64
 }
 
-// ListFolder receives data for a folder.
-// Expects folderid or path parameter, returns folder's metadata.
-// The metadata will have contents field that is array of metadatas of folder's contents.
-// Recursively listing the root folder is not an expensive operation.
-// https://docs.pcloud.com/methods/folder/listfolder.html
-func (c *Client) ListFolder(ctx context.Context, folder T1PathOrFolderID, recursiveOpt, showDeletedOpt, noFilesOpt, noSharesOpt bool, opts ...ClientOption) (*FSList, error) {
-	q := toQuery(opts...)
-	folder(q)
+// ListOptions are the optional parameters of List and ListFunc.
+type ListOptions struct {
+	// Recursive if set, the full tree of the folder is listed, rather than its direct contents
+	// only.
+	Recursive bool
+
+	// ShowDeleted if set, the deleted files and folders that can be undeleted are listed too.
+	ShowDeleted bool
 
-	if recursiveOpt {
-		q.Add("recursive", "1")
+	// NoFiles if set, the folders only are listed.
+	NoFiles bool
+
+	// NoShares if set, the user's own files and folders only are listed.
+	NoShares bool
+}
+
+// options returns the ClientOption's of the parameters of o that are set.
+func (o ListOptions) options() []ClientOption {
+	var opts []ClientOption
+
+	if o.Recursive {
+		opts = append(opts, WithRecursive())
 	}
 
-	if showDeletedOpt {
-		q.Add("showdeleted", "1")
+	if o.ShowDeleted {
+		opts = append(opts, WithShowDeleted())
 	}
 
-	if noFilesOpt {
-		q.Add("nofiles", "1")
+	if o.NoFiles {
+		opts = append(opts, WithNoFiles())
 	}
 
-	if noSharesOpt {
-		q.Add("noshares", "1")
+	if o.NoShares {
+		opts = append(opts, WithNoShares())
 	}
 
+	return opts
+}
+
+// List receives data for a folder.
+// Expects folderid or path parameter, returns folder's metadata.
+// The metadata will have contents field that is array of metadatas of folder's contents.
+// Recursively listing the root folder is not an expensive operation.
+// The response is decoded as it is received, rather than read whole first. See also ListFunc.
+// https://docs.pcloud.com/methods/folder/listfolder.html
+func (c *Client) List(ctx context.Context, folder FolderRef, o ListOptions, opts ...ClientOption) (*FSList, error) {
+	ctx, q := toQuery(ctx, withOptions(opts, o)...)
+	folder.setFolder(q, "")
+
 	lf := &FSList{}
 
-	err := parseAPIOutput(lf)(c.get(ctx, "listfolder", q))
+	// the metadata cache holds whole responses, and strict decoding checks them.
+	if c.cache != nil || c.strictDecoding {
+		err := c.parseAPIOutput(lf)(c.get(ctx, "listfolder", q))
+		if err != nil {
+			return nil, err
+		}
+
+		return lf, nil
+	}
+
+	err := c.stream(ctx, "listfolder", q, func(r io.Reader) (result, error) {
+		lf = &FSList{} // the call may be attempted more than once
+		return decodeStream(r, map[string]streamField{
+			"metadata": func(dec *json.Decoder) (err error) {
+				lf.Metadata, err = decodeMetadata(dec, true, nil)
+				return err
+			},
+		})
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -126,16 +241,64 @@ func (c *Client) ListFolder(ctx context.Context, folder T1PathOrFolderID, recurs
 	return lf, nil
 }
 
+// ListFunc is like List but rather than returning the metadata of the folder, it calls fn with
+// the metadata of the folder and of each of its contents, as they are received.
+// The Contents of the folders passed to fn are not populated: memory use remains low even for
+// very large trees. A folder is passed to fn after its contents.
+// The response is read while fn runs: fn must not call the Client, which sends its requests
+// one at a time. fn may return an error to abort the listing: ListFunc then returns it.
+// As fn may have been called with part of the response, the call is not retried.
+// https://docs.pcloud.com/methods/folder/listfolder.html
+func (c *Client) ListFunc(ctx context.Context, folder FolderRef, o ListOptions, fn func(m *Metadata) error, opts ...ClientOption) error {
+	ctx, q := toQuery(ctx, withOptions(append(opts, WithCallRetryPolicy(NoRetryPolicy())), o)...)
+	folder.setFolder(q, "")
+
+	return c.stream(ctx, "listfolder", q, func(r io.Reader) (result, error) {
+		return decodeStream(r, map[string]streamField{
+			"metadata": func(dec *json.Decoder) error {
+				_, err := decodeMetadata(dec, false, fn)
+				return err
+			},
+		})
+	})
+}
+
+// ListFolder is List with its optional parameters passed positionally.
+// https://docs.pcloud.com/methods/folder/listfolder.html
+//
+// Deprecated: use List.
+func (c *Client) ListFolder(ctx context.Context, folder FolderRef, recursiveOpt, showDeletedOpt, noFilesOpt, noSharesOpt bool, opts ...ClientOption) (*FSList, error) {
+	return c.List(ctx, folder, listOptions(recursiveOpt, showDeletedOpt, noFilesOpt, noSharesOpt), opts...)
+}
+
+// ListFolderFunc is ListFunc with its optional parameters passed positionally.
+// https://docs.pcloud.com/methods/folder/listfolder.html
+//
+// Deprecated: use ListFunc.
+func (c *Client) ListFolderFunc(ctx context.Context, folder FolderRef, recursiveOpt, showDeletedOpt, noFilesOpt, noSharesOpt bool, fn func(m *Metadata) error, opts ...ClientOption) error {
+	return c.ListFunc(ctx, folder, listOptions(recursiveOpt, showDeletedOpt, noFilesOpt, noSharesOpt), fn, opts...)
+}
+
+// listOptions returns the ListOptions of the positional parameters of ListFolder.
+func listOptions(recursiveOpt, showDeletedOpt, noFilesOpt, noSharesOpt bool) ListOptions {
+	return ListOptions{
+		Recursive:   recursiveOpt,
+		ShowDeleted: showDeletedOpt,
+		NoFiles:     noFilesOpt,
+		NoShares:    noSharesOpt,
+	}
+}
+
 // CreateFolder creates a folder.
 // Expects either path string parameter (discouraged) or int folderid and string name parameters.
 // https://docs.pcloud.com/methods/folder/createfolder.html
-func (c *Client) CreateFolder(ctx context.Context, folder T2PathOrFolderIDName, opts ...ClientOption) (*FSList, error) {
-	q := toQuery(opts...)
-	folder(q)
+func (c *Client) CreateFolder(ctx context.Context, folder FolderRef, opts ...ClientOption) (*FSList, error) {
+	ctx, q := toQuery(ctx, opts...)
+	folder.setFolder(q, "")
 
 	lf := &FSList{}
 
-	err := parseAPIOutput(lf)(c.get(ctx, "createfolder", q))
+	err := c.parseAPIOutput(lf)(c.get(ctx, "createfolder", q))
 	if err != nil {
 		return nil, err
 	}
@@ -147,13 +310,13 @@ func (c *Client) CreateFolder(ctx context.Context, folder T2PathOrFolderIDName,
 // folder's metadata.
 // Expects either path string parameter (discouraged) or int folderid and string name parameters.
 // https://docs.pcloud.com/methods/folder/createfolderifnotexists.html
-func (c *Client) CreateFolderIfNotExists(ctx context.Context, folder T2PathOrFolderIDName, opts ...ClientOption) (*FSList, error) {
-	q := toQuery(opts...)
-	folder(q)
+func (c *Client) CreateFolderIfNotExists(ctx context.Context, folder FolderRef, opts ...ClientOption) (*FSList, error) {
+	ctx, q := toQuery(ctx, opts...)
+	folder.setFolder(q, "")
 
 	lf := &FSList{}
 
-	err := parseAPIOutput(lf)(c.get(ctx, "createfolderifnotexists", q))
+	err := c.parseAPIOutput(lf)(c.get(ctx, "createfolderifnotexists", q))
 	if err != nil {
 		return nil, err
 	}
@@ -165,13 +328,13 @@ func (c *Client) CreateFolderIfNotExists(ctx context.Context, folder T2PathOrFol
 // Expects either path string parameter (discouraged) or int folderid parameter.
 // Note: This function deletes files, directories, and removes sharing. Use with extreme care.
 // https://docs.pcloud.com/methods/folder/deletefolderrecursive.html
-func (c *Client) DeleteFolderRecursive(ctx context.Context, folder T1PathOrFolderID, opts ...ClientOption) (*DeleteResult, error) {
-	q := toQuery(opts...)
-	folder(q)
+func (c *Client) DeleteFolderRecursive(ctx context.Context, folder FolderRef, opts ...ClientOption) (*DeleteResult, error) {
+	ctx, q := toQuery(ctx, opts...)
+	folder.setFolder(q, "")
 
 	dr := &DeleteResult{}
 
-	err := parseAPIOutput(dr)(c.get(ctx, "deletefolderrecursive", q))
+	err := c.parseAPIOutput(dr)(c.get(ctx, "deletefolderrecursive", q))
 	if err != nil {
 		return nil, err
 	}
@@ -183,13 +346,13 @@ func (c *Client) DeleteFolderRecursive(ctx context.Context, folder T1PathOrFolde
 // Expects either path string parameter (discouraged) or int folderid parameter.
 // Note: Folders must be empty before calling deletefolder.
 // https://docs.pcloud.com/methods/folder/deletefolder.html
-func (c *Client) DeleteFolder(ctx context.Context, folder T1PathOrFolderID, opts ...ClientOption) (*FSList, error) {
-	q := toQuery(opts...)
-	folder(q)
+func (c *Client) DeleteFolder(ctx context.Context, folder FolderRef, opts ...ClientOption) (*FSList, error) {
+	ctx, q := toQuery(ctx, opts...)
+	folder.setFolder(q, "")
 
 	lf := &FSList{}
 
-	err := parseAPIOutput(lf)(c.get(ctx, "deletefolder", q))
+	err := c.parseAPIOutput(lf)(c.get(ctx, "deletefolder", q))
 	if err != nil {
 		return nil, err
 	}
@@ -201,14 +364,14 @@ func (c *Client) DeleteFolder(ctx context.Context, folder T1PathOrFolderID, opts
 // topath (if topath is an existing folder, to place the source folder without new name for the
 // folder it MUST end with slash - /newpath/) or tofolderid/toname (one or both can be provided).
 // https://docs.pcloud.com/methods/folder/renamefolder.html
-func (c *Client) RenameFolder(ctx context.Context, folder T1PathOrFolderID, toFolder ToT2PathOrFolderIDOrFolderIDName, opts ...ClientOption) (*FSList, error) {
-	q := toQuery(opts...)
-	folder(q)
-	toFolder(q)
+func (c *Client) RenameFolder(ctx context.Context, folder FolderRef, toFolder FolderRef, opts ...ClientOption) (*FSList, error) {
+	ctx, q := toQuery(ctx, opts...)
+	folder.setFolder(q, "")
+	toFolder.setFolder(q, "to")
 
 	lf := &FSList{}
 
-	err := parseAPIOutput(lf)(c.get(ctx, "renamefolder", q))
+	err := c.parseAPIOutput(lf)(c.get(ctx, "renamefolder", q))
 	if err != nil {
 		return nil, err
 	}
@@ -216,16 +379,50 @@ func (c *Client) RenameFolder(ctx context.Context, folder T1PathOrFolderID, toFo
 	return lf, nil
 }
 
-// CopyFolder copies a folder identified by folderid or path to either topath or tofolderid.
+// CopyFolderOptions are the optional parameters of CopyFolderTo.
+type CopyFolderOptions struct {
+	// NoOver if set, the destination files are not overwritten: the call fails with
+	// ErrFileOrFolderAlreadyExists instead.
+	NoOver bool
+
+	// SkipExisting if set, the files that already exist at the destination are skipped, rather
+	// than overwritten.
+	SkipExisting bool
+
+	// CopyContentOnly if set, the contents of the folder are copied into the destination folder,
+	// rather than the folder itself.
+	CopyContentOnly bool
+}
+
+// options returns the ClientOption's of the parameters of o that are set.
+func (o CopyFolderOptions) options() []ClientOption {
+	var opts []ClientOption
+
+	if o.NoOver {
+		opts = append(opts, WithNoOver())
+	}
+
+	if o.SkipExisting {
+		opts = append(opts, WithSkipExisting())
+	}
+
+	if o.CopyContentOnly {
+		opts = append(opts, WithCopyContentOnly())
+	}
+
+	return opts
+}
+
+// CopyFolderTo copies a folder identified by folderid or path to either topath or tofolderid.
 // https://docs.pcloud.com/methods/folder/copyfolder.html
-func (c *Client) CopyFolder(ctx context.Context, folder T1PathOrFolderID, toFolder ToT1PathOrFolderID, noOverOpt, skipExisting, copyContentOnly bool, opts ...ClientOption) (*FSList, error) {
-	q := toQuery(opts...)
-	folder(q)
-	toFolder(q)
+func (c *Client) CopyFolderTo(ctx context.Context, folder FolderRef, toFolder FolderRef, o CopyFolderOptions, opts ...ClientOption) (*FSList, error) {
+	ctx, q := toQuery(ctx, withOptions(opts, o)...)
+	folder.setFolder(q, "")
+	toFolder.setFolder(q, "to")
 
 	lf := &FSList{}
 
-	err := parseAPIOutput(lf)(c.get(ctx, "copyfolder", q))
+	err := c.parseAPIOutput(lf)(c.get(ctx, "copyfolder", q))
 	if err != nil {
 		return nil, err
 	}
@@ -233,11 +430,23 @@ func (c *Client) CopyFolder(ctx context.Context, folder T1PathOrFolderID, toFold
 	return lf, nil
 }
 
+// CopyFolder is CopyFolderTo with its optional parameters passed positionally.
+// https://docs.pcloud.com/methods/folder/copyfolder.html
+//
+// Deprecated: use CopyFolderTo.
+func (c *Client) CopyFolder(ctx context.Context, folder FolderRef, toFolder FolderRef, noOverOpt, skipExisting, copyContentOnly bool, opts ...ClientOption) (*FSList, error) {
+	return c.CopyFolderTo(ctx, folder, toFolder, CopyFolderOptions{NoOver: noOverOpt, SkipExisting: skipExisting, CopyContentOnly: copyContentOnly}, opts...)
+}
+
 // T1PathOrFolderID is a type of parameters that some of the SDK functions take.
 // Such functions have a dichotomic usage to reference a folder: either by path or by folderid.
+//
+// Deprecated: use FolderRef.
 type T1PathOrFolderID func(q url.Values)
 
 // T1FolderByPath is a type of T1PathOrFolderID that references a folder by path alone.
+//
+// Deprecated: use ByPath.
 func T1FolderByPath(path string) T1PathOrFolderID {
 	return func(q url.Values) {
 		q.Set("path", path)
@@ -245,6 +454,8 @@ func T1FolderByPath(path string) T1PathOrFolderID {
 }
 
 // T1FolderByID is a type of T1PathOrFolderID that references a folder by folderid alone.
+//
+// Deprecated: use ByID.
 func T1FolderByID(folderID uint64) T1PathOrFolderID {
 	return func(q url.Values) {
 		q.Set("folderid", fmt.Sprintf("%d", folderID))
@@ -254,9 +465,13 @@ func T1FolderByID(folderID uint64) T1PathOrFolderID {
 // T2PathOrFolderIDName is a type of parameters that some of the SDK functions take.
 // Such functions have a dichotomic usage to reference a folder:
 // either by path or by folderid+name.
+//
+// Deprecated: use FolderRef.
 type T2PathOrFolderIDName func(q url.Values)
 
 // T2FolderByPath is a type of T2PathOrFolderIDName that references a folder by path alone.
+//
+// Deprecated: use ByPath.
 func T2FolderByPath(path string) T2PathOrFolderIDName {
 	return func(q url.Values) {
 		q.Set("path", path)
@@ -264,6 +479,8 @@ func T2FolderByPath(path string) T2PathOrFolderIDName {
 }
 
 // T2FolderByIDName is a type of T2PathOrFolderIDName that references a folder by folderid+name.
+//
+// Deprecated: use ByIDName.
 func T2FolderByIDName(folderID uint64, name string) T2PathOrFolderIDName {
 	return func(q url.Values) {
 		q.Set("folderid", fmt.Sprintf("%d", folderID))
@@ -273,9 +490,13 @@ func T2FolderByIDName(folderID uint64, name string) T2PathOrFolderIDName {
 
 // ToT1PathOrFolderID is a type of parameters that some of the SDK functions take.
 // It is similar to T1PathOrFolderID but applies when referencing a destination folder.
+//
+// Deprecated: use FolderRef.
 type ToT1PathOrFolderID func(q url.Values)
 
 // ToT1FolderByPath is a type of ToT1PathOrFolderID that references a folder by path alone.
+//
+// Deprecated: use ByPath.
 func ToT1FolderByPath(path string) ToT1PathOrFolderID {
 	return func(q url.Values) {
 		q.Set("topath", path)
@@ -283,6 +504,8 @@ func ToT1FolderByPath(path string) ToT1PathOrFolderID {
 }
 
 // ToT1FolderByID is a type of ToT1PathOrFolderID that references a folder by folderid alone.
+//
+// Deprecated: use ByID.
 func ToT1FolderByID(folderID uint64) ToT1PathOrFolderID {
 	return func(q url.Values) {
 		q.Set("tofolderid", fmt.Sprintf("%d", folderID))
@@ -293,10 +516,14 @@ func ToT1FolderByID(folderID uint64) ToT1PathOrFolderID {
 // It applies when referencing a destination folder.
 // Functions that use it have a trichotomic usage to reference a folder:
 // by path alone, by folderid alone or by folderid+name.
+//
+// Deprecated: use FolderRef.
 type ToT2PathOrFolderIDOrFolderIDName func(q url.Values)
 
 // ToT2FolderByPath is a type of ToT2PathOrFolderIDOrFolderIDName that references a folder by
 // path alone.
+//
+// Deprecated: use ByPath.
 func ToT2FolderByPath(path string) ToT2PathOrFolderIDOrFolderIDName {
 	return func(q url.Values) {
 		q.Set("topath", path)
@@ -305,6 +532,8 @@ func ToT2FolderByPath(path string) ToT2PathOrFolderIDOrFolderIDName {
 
 // ToT2FolderByID is a type of ToT2PathOrFolderIDOrFolderIDName that references a folder by
 // folderid alone.
+//
+// Deprecated: use ByID.
 func ToT2FolderByID(folderID uint64) ToT2PathOrFolderIDOrFolderIDName {
 	return func(q url.Values) {
 		q.Set("tofolderid", fmt.Sprintf("%d", folderID))
@@ -313,6 +542,8 @@ func ToT2FolderByID(folderID uint64) ToT2PathOrFolderIDOrFolderIDName {
 
 // ToT2FolderByIDName is a type of ToT2PathOrFolderIDOrFolderIDName that references a folder by
 // folderid+name.
+//
+// Deprecated: use ByIDName.
 func ToT2FolderByIDName(folderID uint64, name string) ToT2PathOrFolderIDOrFolderIDName {
 	return func(q url.Values) {
 		q.Set("tofolderid", fmt.Sprintf("%d", folderID))
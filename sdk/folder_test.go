@@ -1,9 +1,14 @@
 package sdk_test
 
 import (
+	"encoding/json"
 	"fmt"
+	"testing"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/seborama/pcloud-sdk/sdk"
 )
@@ -11,38 +16,38 @@ import (
 func (testsuite *IntegrationTestSuite) Test_FolderOperations_ByPath() {
 	folderPath := testsuite.testFolderPath + "/go_pCloud_" + uuid.New().String()
 
-	_, err := testsuite.pcc.DeleteFolderRecursive(testsuite.ctx, sdk.T1FolderByPath(folderPath))
+	_, err := testsuite.pcc.DeleteFolderRecursive(testsuite.ctx, sdk.ByPath(folderPath))
 	testsuite.Require().Error(err)
 	testsuite.Require().Contains(err.Error(), fmt.Sprintf("error %d:", sdk.ErrDirectoryNotExists))
 
-	_, err = testsuite.pcc.DeleteFolder(testsuite.ctx, sdk.T1FolderByPath(folderPath))
+	_, err = testsuite.pcc.DeleteFolder(testsuite.ctx, sdk.ByPath(folderPath))
 	testsuite.Require().Error(err)
 	testsuite.Require().Contains(err.Error(), fmt.Sprintf("error %d:", sdk.ErrDirectoryNotExists))
 
-	_, err = testsuite.pcc.CreateFolder(testsuite.ctx, sdk.T2FolderByPath(folderPath))
+	_, err = testsuite.pcc.CreateFolder(testsuite.ctx, sdk.ByPath(folderPath))
 	testsuite.Require().NoError(err)
 
-	_, err = testsuite.pcc.CreateFolderIfNotExists(testsuite.ctx, sdk.T2FolderByPath(folderPath))
+	_, err = testsuite.pcc.CreateFolderIfNotExists(testsuite.ctx, sdk.ByPath(folderPath))
 	testsuite.Require().NoError(err)
 
-	_, err = testsuite.pcc.ListFolder(testsuite.ctx, sdk.T1FolderByPath(folderPath), true, false, false, false)
+	_, err = testsuite.pcc.List(testsuite.ctx, sdk.ByPath(folderPath), sdk.ListOptions{Recursive: true})
 	testsuite.Require().NoError(err)
 
-	_, err = testsuite.pcc.CreateFolder(testsuite.ctx, sdk.T2FolderByPath(folderPath+" COPY"))
+	_, err = testsuite.pcc.CreateFolder(testsuite.ctx, sdk.ByPath(folderPath+" COPY"))
 	testsuite.Require().NoError(err)
 
-	_, err = testsuite.pcc.CopyFolder(testsuite.ctx, sdk.T1FolderByPath(folderPath), sdk.ToT1FolderByPath(folderPath+" COPY"), false, false, false)
+	_, err = testsuite.pcc.CopyFolderTo(testsuite.ctx, sdk.ByPath(folderPath), sdk.ByPath(folderPath+" COPY"), sdk.CopyFolderOptions{})
 	testsuite.Require().NoError(err)
 
-	fr, err := testsuite.pcc.DeleteFolderRecursive(testsuite.ctx, sdk.T1FolderByPath(folderPath))
+	fr, err := testsuite.pcc.DeleteFolderRecursive(testsuite.ctx, sdk.ByPath(folderPath))
 	testsuite.Require().NoError(err)
 	testsuite.EqualValues(1, fr.DeletedFolders)
 	testsuite.EqualValues(0, fr.DeletedFiles)
 
-	_, err = testsuite.pcc.CreateFolderIfNotExists(testsuite.ctx, sdk.T2FolderByPath(folderPath))
+	_, err = testsuite.pcc.CreateFolderIfNotExists(testsuite.ctx, sdk.ByPath(folderPath))
 	testsuite.Require().NoError(err)
 
-	lf, err := testsuite.pcc.DeleteFolder(testsuite.ctx, sdk.T1FolderByPath(folderPath))
+	lf, err := testsuite.pcc.DeleteFolder(testsuite.ctx, sdk.ByPath(folderPath))
 	testsuite.Require().NoError(err)
 	testsuite.Equal(folderPath, lf.Metadata.Path)
 }
@@ -52,41 +57,74 @@ func (testsuite *IntegrationTestSuite) Test_FolderOperations_ByID() {
 
 	folderPathName := testsuite.testFolderPath + "/" + folderName
 
-	_, err := testsuite.pcc.DeleteFolderRecursive(testsuite.ctx, sdk.T1FolderByPath(folderPathName))
+	_, err := testsuite.pcc.DeleteFolderRecursive(testsuite.ctx, sdk.ByPath(folderPathName))
 	testsuite.Require().Error(err)
 	testsuite.Require().Contains(err.Error(), fmt.Sprintf("error %d:", sdk.ErrDirectoryNotExists))
 
-	_, err = testsuite.pcc.DeleteFolder(testsuite.ctx, sdk.T1FolderByPath(folderPathName))
+	_, err = testsuite.pcc.DeleteFolder(testsuite.ctx, sdk.ByPath(folderPathName))
 	testsuite.Require().Error(err)
 	testsuite.Require().Contains(err.Error(), fmt.Sprintf("error %d:", sdk.ErrDirectoryNotExists))
 
-	lf, err := testsuite.pcc.CreateFolder(testsuite.ctx, sdk.T2FolderByIDName(testsuite.testFolderID, folderName))
+	lf, err := testsuite.pcc.CreateFolder(testsuite.ctx, sdk.ByIDName(testsuite.testFolderID, folderName))
 	testsuite.Require().NoError(err)
 	folderID := lf.Metadata.FolderID
 
-	_, err = testsuite.pcc.CreateFolderIfNotExists(testsuite.ctx, sdk.T2FolderByIDName(testsuite.testFolderID, folderName))
+	_, err = testsuite.pcc.CreateFolderIfNotExists(testsuite.ctx, sdk.ByIDName(testsuite.testFolderID, folderName))
 	testsuite.Require().NoError(err)
 
-	_, err = testsuite.pcc.ListFolder(testsuite.ctx, sdk.T1FolderByID(folderID), true, false, false, false)
+	_, err = testsuite.pcc.List(testsuite.ctx, sdk.ByID(folderID), sdk.ListOptions{Recursive: true})
 	testsuite.Require().NoError(err)
 
-	lf, err = testsuite.pcc.CreateFolder(testsuite.ctx, sdk.T2FolderByIDName(testsuite.testFolderID, folderName+" COPY"))
+	lf, err = testsuite.pcc.CreateFolder(testsuite.ctx, sdk.ByIDName(testsuite.testFolderID, folderName+" COPY"))
 	testsuite.Require().NoError(err)
 	copyFolderID := lf.Metadata.FolderID
 
-	_, err = testsuite.pcc.CopyFolder(testsuite.ctx, sdk.T1FolderByID(folderID), sdk.ToT1FolderByID(copyFolderID), false, false, false)
+	_, err = testsuite.pcc.CopyFolderTo(testsuite.ctx, sdk.ByID(folderID), sdk.ByID(copyFolderID), sdk.CopyFolderOptions{})
 	testsuite.Require().NoError(err)
 
-	fr, err := testsuite.pcc.DeleteFolderRecursive(testsuite.ctx, sdk.T1FolderByID(folderID))
+	fr, err := testsuite.pcc.DeleteFolderRecursive(testsuite.ctx, sdk.ByID(folderID))
 	testsuite.Require().NoError(err)
 	testsuite.EqualValues(1, fr.DeletedFolders)
 	testsuite.EqualValues(0, fr.DeletedFiles)
 
-	lf, err = testsuite.pcc.CreateFolderIfNotExists(testsuite.ctx, sdk.T2FolderByIDName(testsuite.testFolderID, folderName))
+	lf, err = testsuite.pcc.CreateFolderIfNotExists(testsuite.ctx, sdk.ByIDName(testsuite.testFolderID, folderName))
 	testsuite.Require().NoError(err)
 	folderID = lf.Metadata.FolderID
 
-	lf, err = testsuite.pcc.DeleteFolder(testsuite.ctx, sdk.T1FolderByID(folderID))
+	lf, err = testsuite.pcc.DeleteFolder(testsuite.ctx, sdk.ByID(folderID))
 	testsuite.Require().NoError(err)
 	testsuite.EqualValues(folderID, lf.Metadata.FolderID)
 }
+
+func TestMetadata_Media(t *testing.T) {
+	var m sdk.Metadata
+	err := json.Unmarshal([]byte(`{
+		"name": "clip.mp4", "fileid": 10, "isfolder": false, "ismine": true, "isshared": true,
+		"icon": "video", "category": 2, "thumb": true, "comments": 3, "contenttype": "video/mp4",
+		"width": 1920, "height": 1080, "duration": "61.5", "fps": "29.97",
+		"videocodec": "h264", "audiocodec": "aac", "videobitrate": 4000, "rotate": 90
+	}`), &m)
+	require.NoError(t, err)
+
+	assert.Equal(t, sdk.CategoryVideo, m.Category)
+	assert.Equal(t, "video", m.Category.String())
+	assert.Equal(t, sdk.IconVideo, m.Icon)
+	assert.True(t, m.Thumb)
+	assert.True(t, m.IsMine)
+	assert.True(t, m.IsShared)
+	assert.EqualValues(t, 3, m.Comments)
+	assert.Equal(t, 1920, m.Width)
+	assert.Equal(t, 1080, m.Height)
+	assert.Equal(t, 61500*time.Millisecond, m.MediaDuration())
+	assert.Equal(t, "h264", m.VideoCodec)
+	assert.Equal(t, 90, m.Rotate)
+
+	err = json.Unmarshal([]byte(`{"name": "song.mp3", "category": 3, "artist": "A", "album": "B", "title": "C", "genre": "D", "trackno": "4"}`), &m)
+	require.NoError(t, err)
+	assert.Equal(t, sdk.CategoryAudio, m.Category)
+	assert.Equal(t, "A", m.Artist)
+	assert.Equal(t, "4", m.TrackNo)
+
+	assert.Equal(t, time.Duration(0), (&sdk.Metadata{}).MediaDuration())
+	assert.Equal(t, "category 9", sdk.Category(9).String())
+}
@@ -2,7 +2,11 @@ package sdk
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/url"
+	"sort"
 	"time"
 )
 
@@ -16,7 +20,7 @@ type UserInfo struct {
 	HasPassword           bool
 	PublicLinkQuota       uint64
 	CryptoLifetime        bool
-	PremiumExpires        APITime
+	PremiumExpires        time.Time
 	Email                 string
 	TrashRevRetentionDays int
 	Auth                  string
@@ -25,14 +29,14 @@ type UserInfo struct {
 	Currency              string
 	AgreedWithPP          bool // pp: privacy policy
 	Quota                 uint64
-	CryptoExpires         APITime
+	CryptoExpires         time.Time
 	Premium               bool
 	PremiumLifetime       bool
 	Business              bool
 	UsedQuota             uint64
 	Language              string
 	HasPaidRelocation     bool
-	Registered            APITime
+	Registered            time.Time
 	RegistrationInfo      RegistrationInfo
 	Journey               Journey
 	APIServer             APIServer
@@ -42,6 +46,26 @@ type UserInfo struct {
 	Token                 string // this is used with two-factor authentication (perhaps OAUTH2 journeys too?)
 }
 
+// UnmarshalJSON decodes the UserInfo, with its times in any of the formats of APITime.
+func (u *UserInfo) UnmarshalJSON(data []byte) error {
+	type userInfo UserInfo
+	times := struct {
+		*userInfo
+		PremiumExpires APITime
+		CryptoExpires  APITime
+		Registered     APITime
+	}{userInfo: (*userInfo)(u)}
+
+	err := json.Unmarshal(data, &times)
+	if err != nil {
+		return err
+	}
+
+	u.PremiumExpires, u.CryptoExpires, u.Registered = times.PremiumExpires.Time, times.CryptoExpires.Time, times.Registered.Time
+
+	return nil
+}
+
 // RegistrationInfo contains registration information about a user account.
 type RegistrationInfo struct {
 	Provider int
@@ -71,6 +95,12 @@ type APIServer struct {
 	API    []string
 }
 
+// APIServerResult is returned by the SDK GetAPIServer() method.
+type APIServerResult struct {
+	result
+	APIServer
+}
+
 // DiffResult is returned by Diff and GetFileHistory.
 type DiffResult struct {
 	result
@@ -79,11 +109,33 @@ type DiffResult struct {
 }
 
 // Entry is a component of DiffResult which is returned by diff.
+// Metadata is provided with folder and file events, Share with share events and UserInfo
+// with the modifyuserinfo event. See Event.
 type Entry struct {
 	Event    Event
-	Time     APITime
+	Time     time.Time
 	DiffID   uint64
 	Metadata Metadata
+	Share    *EventShare
+	UserInfo *EventUserInfo
+}
+
+// UnmarshalJSON decodes the Entry, with its times in any of the formats of APITime.
+func (e *Entry) UnmarshalJSON(data []byte) error {
+	type entry Entry
+	times := struct {
+		*entry
+		Time APITime
+	}{entry: (*entry)(e)}
+
+	err := json.Unmarshal(data, &times)
+	if err != nil {
+		return err
+	}
+
+	e.Time = times.Time.Time
+
+	return nil
 }
 
 // UserInfo returns information about the current user.
@@ -91,7 +143,7 @@ type Entry struct {
 // this is an especially good place for logging in with no particular action in mind.
 // https://docs.pcloud.com/methods/general/userinfo.html
 func (c *Client) UserInfo(ctx context.Context, opts ...ClientOption) (*UserInfo, error) {
-	q := toQuery(opts...)
+	ctx, q := toQuery(ctx, opts...)
 
 	q.Add("getregistrationinfo", "1")
 	q.Add("getapiserver", "1")
@@ -99,7 +151,7 @@ func (c *Client) UserInfo(ctx context.Context, opts ...ClientOption) (*UserInfo,
 
 	e := &UserInfo{}
 
-	err := parseAPIOutput(e)(c.get(ctx, "userinfo", q))
+	err := c.parseAPIOutput(e)(c.get(ctx, "userinfo", q))
 	if err != nil {
 		return nil, err
 	}
@@ -109,39 +161,75 @@ func (c *Client) UserInfo(ctx context.Context, opts ...ClientOption) (*UserInfo,
 
 // GetFileHistory returns the event history of a file identified by fileid.
 // File might be a deleted one. The output format is the same as that of the diff method.
+// The entries are sorted chronologically, oldest first.
 // https://docs.pcloud.com/methods/general/getfilehistory.html
 func (c *Client) GetFileHistory(ctx context.Context, fileID uint64, opts ...ClientOption) (*DiffResult, error) {
-	q := toQuery(opts...)
+	ctx, q := toQuery(ctx, opts...)
 
 	q.Add("fileid", fmt.Sprintf("%d", fileID))
 
 	dr := &DiffResult{}
 
-	err := parseAPIOutput(dr)(c.get(ctx, "getfilehistory", q))
+	err := c.parseAPIOutput(dr)(c.get(ctx, "getfilehistory", q))
 	if err != nil {
 		return nil, err
 	}
 
+	// diffids increase monotonically with time.
+	sort.SliceStable(dr.Entries, func(i, j int) bool {
+		return dr.Entries[i].DiffID < dr.Entries[j].DiffID
+	})
+
 	return dr, nil
 }
 
-// Diff lists updates of the user's folders/files.
+// ChangesOptions are the optional parameters of Changes and ChangesFunc.
+type ChangesOptions struct {
+	// After, if not zero, is an alternative to the diffid: only the events generated after that
+	// time are returned.
+	After time.Time
+
+	// Last, if not 0, is another alternative to the diffid or After: the last number of events
+	// with highest diffids (that is the last events) are returned.
+	Last uint64
+
+	// Block if set and there are no changes since the provided diffid, the connection will block
+	// until an event arrives. Blocking only works when diffid is provided and does not work with
+	// either After or Last.
+	// However, sending any additional data on the blocked connection will unblock the request and
+	// an empty set will be returned. This is useful when you want to monitor for updates when idle
+	// and use connection for other activities when needed.
+	// Just keep in mind that if you send any request on a connection that is blocked, you will
+	// receive two replies - one with empty set of updates and one answering your second request.
+	Block bool
+
+	// Limit, if not 0, is the maximum number of entries returned.
+	Limit uint64
+}
+
+// set sets the parameters of o in q.
+func (o ChangesOptions) set(q url.Values) {
+	if !o.After.IsZero() {
+		q.Set("after", o.After.Format(ctLayout))
+	}
+
+	if o.Last > 0 {
+		q.Set("last", fmt.Sprintf("%d", o.Last))
+	}
+
+	if o.Block {
+		q.Set("block", "1")
+	}
+
+	if o.Limit > 0 {
+		q.Set("limit", fmt.Sprintf("%d", o.Limit))
+	}
+}
+
+// Changes lists updates of the user's folders/files.
 // Optionally, takes the parameter diffid, which if provided returns only changes since that
 // diffid.
-// Alternatively you can provide date/time in after parameter and you will only receive events
-// generated after that time.
-// Another alternative to providing diffid or after is providing last, which will return last
-// number of events with highest diffids (that is the last events).
-// Especially setting last to 0 is optimised to do nothing more than return the last diffid.
-// If the optional parameter block is set and there are no changes since the provided diffid,
-// the connection will block until an event arrives. Blocking only works when diffid is provided
-// and does not work with either after or last.
-// However, sending any additional data on the blocked connection will unblock the request and
-// an empty set will be returned. This is useful when you want to monitor for updates when idle
-// and use connection for other activities when needed.
-// Just keep in mind that if you send any request on a connection that is blocked, you will
-// receive two replies - one with empty set of updates and one answering your second request.
-// If the optional limit parameter is provided, no more than limit entries will be returned.
+// Alternatively, see ChangesOptions.
 // IMPORTANT When a folder/file is created/delete/moved in or out of a folder, you are supposed
 // to update modification time of the parent folder to the timestamp of the event.
 // IMPORTANT If your state is more than 6 months old, you are advised to re-download all your
@@ -151,38 +239,226 @@ func (c *Client) GetFileHistory(ctx context.Context, fileID uint64, opts ...Clie
 // old, it can become createfile and the original createfile will disappear. That is not
 // comprehensive list of compacting activities, so you should generally re-download from zero
 // rather than trying to cope with compacting.
+// The response is decoded as it is received, rather than read whole first. See also
+// ChangesFunc.
+// https://docs.pcloud.com/methods/general/diff.html
+func (c *Client) Changes(ctx context.Context, diffID uint64, o ChangesOptions, opts ...ClientOption) (*DiffResult, error) {
+	ctx, q := toQuery(ctx, opts...)
+	diffQuery(q, diffID, o)
+
+	dr := &DiffResult{}
+
+	var err error
+	if c.strictDecoding {
+		// strict decoding checks whole responses.
+		err = c.parseAPIOutput(dr)(c.get(ctx, "diff", q))
+	} else {
+		err = c.stream(ctx, "diff", q, func(r io.Reader) (result, error) {
+			dr = &DiffResult{} // the call may be attempted more than once
+			return decodeStream(r, map[string]streamField{
+				"diffid": func(dec *json.Decoder) error {
+					return dec.Decode(&dr.DiffID)
+				},
+				"entries": func(dec *json.Decoder) error {
+					return decodeEntries(dec, func(e *Entry) error {
+						dr.Entries = append(dr.Entries, *e)
+						return nil
+					})
+				},
+			})
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c.observeEvents(q, dr.Entries)
+
+	return dr, nil
+}
+
+// ChangesFunc is like Changes but rather than returning the entries, it calls fn with each of them
+// as they are received, so that memory use remains low even for very long lists of events.
+// It returns the diffid of the last event.
+// The response is read while fn runs: fn must not call the Client, which sends its requests
+// one at a time. fn may return an error to abort the diff: ChangesFunc then returns it.
+// As fn may have been called with part of the response, the call is not retried.
+// https://docs.pcloud.com/methods/general/diff.html
+func (c *Client) ChangesFunc(ctx context.Context, diffID uint64, o ChangesOptions, fn func(e *Entry) error, opts ...ClientOption) (uint64, error) {
+	ctx, q := toQuery(ctx, append(opts, WithCallRetryPolicy(NoRetryPolicy()))...)
+	diffQuery(q, diffID, o)
+
+	var lastDiffID uint64
+
+	err := c.stream(ctx, "diff", q, func(r io.Reader) (result, error) {
+		return decodeStream(r, map[string]streamField{
+			"diffid": func(dec *json.Decoder) error {
+				return dec.Decode(&lastDiffID)
+			},
+			"entries": func(dec *json.Decoder) error {
+				return decodeEntries(dec, func(e *Entry) error {
+					c.observeEvents(q, []Entry{*e})
+					return fn(e)
+				})
+			},
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return lastDiffID, nil
+}
+
+// Diff is Changes with its optional parameters passed positionally.
 // https://docs.pcloud.com/methods/general/diff.html
 //
-// TODO: add support for shares.
+// Deprecated: use Changes.
 func (c *Client) Diff(ctx context.Context, diffID uint64, after time.Time, last uint64, block bool, limit uint64, opts ...ClientOption) (*DiffResult, error) {
-	q := toQuery(opts...)
+	return c.Changes(ctx, diffID, ChangesOptions{After: after, Last: last, Block: block, Limit: limit}, opts...)
+}
 
+// DiffFunc is ChangesFunc with its optional parameters passed positionally.
+// https://docs.pcloud.com/methods/general/diff.html
+//
+// Deprecated: use ChangesFunc.
+func (c *Client) DiffFunc(ctx context.Context, diffID uint64, after time.Time, last uint64, block bool, limit uint64, fn func(e *Entry) error, opts ...ClientOption) (uint64, error) {
+	return c.ChangesFunc(ctx, diffID, ChangesOptions{After: after, Last: last, Block: block, Limit: limit}, fn, opts...)
+}
+
+// diffQuery adds the parameters of diff to q.
+func diffQuery(q url.Values, diffID uint64, o ChangesOptions) {
 	if diffID > 0 {
 		q.Add("diffid", fmt.Sprintf("%d", diffID))
 	}
 
-	if !after.IsZero() {
-		q.Add("after", after.Format(ctLayout))
-	}
+	o.set(q)
+}
 
-	if last > 0 {
-		q.Add("last", fmt.Sprintf("%d", last))
+// GetAPIServer returns the API servers closest to the requesting client, i.e. those that
+// should be used for best performance.
+// The servers are sorted by preference: the first one should be used.
+// See WithAPIServerDiscovery to let the Client do this automatically.
+// https://docs.pcloud.com/methods/general/getapiserver.html
+func (c *Client) GetAPIServer(ctx context.Context, opts ...ClientOption) (*APIServerResult, error) {
+	ctx, q := toQuery(ctx, opts...)
+
+	as := &APIServerResult{}
+
+	err := c.parseAPIOutput(as)(c.get(ctx, "getapiserver", q))
+	if err != nil {
+		return nil, err
 	}
 
-	if block {
-		q.Add("block", "1")
+	return as, nil
+}
+
+// CurrentServerResult is returned by the SDK CurrentServer() method.
+type CurrentServerResult struct {
+	result
+	IP       string
+	IPBin    string
+	IPv6     string
+	Hostname string
+}
+
+// CurrentServer returns the IP address and hostname of the API server the Client is connected
+// to. This is useful to diagnose a mismatch between the region of the account and that of the
+// API host.
+// https://docs.pcloud.com/methods/general/currentserver.html
+func (c *Client) CurrentServer(ctx context.Context, opts ...ClientOption) (*CurrentServerResult, error) {
+	ctx, q := toQuery(ctx, opts...)
+
+	cs := &CurrentServerResult{}
+
+	err := c.parseAPIOutput(cs)(c.get(ctx, "currentserver", q))
+	if err != nil {
+		return nil, err
 	}
 
-	if limit > 0 {
-		q.Add("limit", fmt.Sprintf("%d", limit))
+	return cs, nil
+}
+
+// IPResult is returned by the SDK GetIP() method.
+type IPResult struct {
+	result
+	IP      string
+	Country string // lowercase two-letter country code
+}
+
+// GetIP returns the IP address of the caller, as seen by pCloud, and the country it is
+// located in.
+// https://docs.pcloud.com/methods/general/getip.html
+func (c *Client) GetIP(ctx context.Context, opts ...ClientOption) (*IPResult, error) {
+	ctx, q := toQuery(ctx, opts...)
+
+	ipr := &IPResult{}
+
+	err := c.parseAPIOutput(ipr)(c.get(ctx, "getip", q))
+	if err != nil {
+		return nil, err
 	}
 
-	dr := &DiffResult{}
+	return ipr, nil
+}
+
+// SupportedLanguages is returned by the SDK SupportedLanguages() method.
+type SupportedLanguages struct {
+	result
+	Languages map[string]string // language code => language name (in that language)
+}
 
-	err := parseAPIOutput(dr)(c.get(ctx, "diff", q))
+// SupportedLanguages lists the languages supported by pCloud.
+// https://docs.pcloud.com/methods/general/supportedlanguages.html
+func (c *Client) SupportedLanguages(ctx context.Context, opts ...ClientOption) (*SupportedLanguages, error) {
+	ctx, q := toQuery(ctx, opts...)
+
+	sl := &SupportedLanguages{}
+
+	err := c.parseAPIOutput(sl)(c.get(ctx, "supportedlanguages", q))
 	if err != nil {
 		return nil, err
 	}
 
-	return dr, nil
+	return sl, nil
+}
+
+// SetLanguage sets the language of the current user, i.e. the language pCloud uses for its
+// e-mails and notifications.
+// language is one of the codes returned by SupportedLanguages.
+// https://docs.pcloud.com/methods/general/setlanguage.html
+func (c *Client) SetLanguage(ctx context.Context, language string, opts ...ClientOption) error {
+	ctx, q := toQuery(ctx, opts...)
+
+	q.Add("language", language)
+
+	r := &result{}
+
+	err := c.parseAPIOutput(r)(c.get(ctx, "setlanguage", q))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Feedback sends a message to pCloud's user support.
+// mail is the e-mail address pCloud should reply to. reason is the subject of the message.
+// See WithFeedbackName to give the name of the sender.
+// https://docs.pcloud.com/methods/general/feedback.html
+func (c *Client) Feedback(ctx context.Context, mail, reason, message string, opts ...ClientOption) error {
+	ctx, q := toQuery(ctx, opts...)
+
+	q.Add("mail", mail)
+	q.Add("reason", reason)
+	q.Add("message", message)
+
+	r := &result{}
+
+	err := c.parseAPIOutput(r)(c.get(ctx, "feedback", q))
+	if err != nil {
+		return err
+	}
+
+	return nil
 }
@@ -2,6 +2,8 @@ package sdk_test
 
 import (
 	"time"
+
+	"github.com/seborama/pcloud-sdk/sdk"
 )
 
 func (testsuite *IntegrationTestSuite) Test_UserInfo() {
@@ -20,10 +22,35 @@ func (testsuite *IntegrationTestSuite) Test_GetFileHistory() {
 	testsuite.Require().EqualValues(testsuite.testFolderID, dr.Entries[0].Metadata.ParentFolderID)
 }
 
-func (testsuite *IntegrationTestSuite) Test_Diff() {
-	dr, err := testsuite.pcc.Diff(testsuite.ctx, 0, time.Now().Add(-10*time.Minute), 0, false, 0)
+func (testsuite *IntegrationTestSuite) Test_Changes() {
+	dr, err := testsuite.pcc.Changes(testsuite.ctx, 0, sdk.ChangesOptions{After: time.Now().Add(-10 * time.Minute)})
 	testsuite.Require().NoError(err)
 	testsuite.Require().GreaterOrEqual(dr.DiffID, uint64(1))
 	testsuite.Require().GreaterOrEqual(dr.Entries[0].DiffID, uint64(1))
 	testsuite.Require().NotEmpty(dr.Entries[0].Metadata.Name)
 }
+
+func (testsuite *IntegrationTestSuite) Test_GetAPIServer() {
+	as, err := testsuite.pcc.GetAPIServer(testsuite.ctx)
+	testsuite.Require().NoError(err)
+	testsuite.Require().NotEmpty(as.API)
+	testsuite.Require().NotEmpty(as.BinAPI)
+}
+
+func (testsuite *IntegrationTestSuite) Test_CurrentServer() {
+	cs, err := testsuite.pcc.CurrentServer(testsuite.ctx)
+	testsuite.Require().NoError(err)
+	testsuite.Require().NotEmpty(cs.Hostname)
+}
+
+func (testsuite *IntegrationTestSuite) Test_GetIP() {
+	ipr, err := testsuite.pcc.GetIP(testsuite.ctx)
+	testsuite.Require().NoError(err)
+	testsuite.Require().NotEmpty(ipr.IP)
+}
+
+func (testsuite *IntegrationTestSuite) Test_SupportedLanguages() {
+	sl, err := testsuite.pcc.SupportedLanguages(testsuite.ctx)
+	testsuite.Require().NoError(err)
+	testsuite.Require().Contains(sl.Languages, "en")
+}
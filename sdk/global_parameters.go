@@ -1,23 +1,59 @@
 package sdk
 
 import (
+	"context"
 	"fmt"
 	"net/url"
+	"strings"
 	"time"
 )
 
 // ClientOption is a Go functional parameter signature.
 // This is used by most SDK methods to pass global parameters such as username,
-// getauth,id, authexpire, etc.
-type ClientOption func(q *url.Values)
+// getauth,id, authexpire, etc, and the optional parameters of the methods, such as WithNoOver.
+// It is also used to pass settings that apply to a single call, such as WithCallRetryPolicy.
+type ClientOption func(co *callOptions)
+
+// callOptions holds the query parameters and the call settings set by ClientOption's.
+type callOptions struct {
+	query       url.Values
+	retryPolicy *RetryPolicy
+
+	// timeouts, see WithCallTimeout, etc.
+	callTimeout    time.Duration
+	connectTimeout time.Duration
+	headerTimeout  time.Duration
+	bodyTimeout    time.Duration
+
+	// see WithCallCacheBypass.
+	bypassCache bool
+
+	// preconditions, see WithCallIfHash and WithCallIfDestinationHash.
+	ifHash            *uint64
+	ifDestinationHash *uint64
+
+	// see WithCallCaptureResponse.
+	capture *ResponseInfo
+}
+
+type callOptionsKey struct{}
+
+// callOptionsFromContext returns the call settings carried by ctx (see toQuery).
+func callOptionsFromContext(ctx context.Context) *callOptions {
+	co, ok := ctx.Value(callOptionsKey{}).(*callOptions)
+	if !ok {
+		return &callOptions{}
+	}
+	return co
+}
 
 // WithGlobalOptionID if set to anything, you will get it back in the reply (no matter
 // successful or not). This might be useful if you pipeline requests from many places over
 // single connection.
 // https://docs.pcloud.com/methods/intro/global_parameters.html
 func WithGlobalOptionID(id string) ClientOption {
-	return func(q *url.Values) {
-		q.Add("id", id)
+	return func(co *callOptions) {
+		co.query.Add("id", id)
 	}
 }
 
@@ -32,7 +68,7 @@ func WithGlobalOptionID(id string) ClientOption {
 // long.
 // https://docs.pcloud.com/methods/intro/global_parameters.html
 func WithGlobalOptionTimeFormatAsUnixUTCTimestamp() ClientOption {
-	return func(q *url.Values) {
+	return func(co *callOptions) {
 		panic("do not use this option. see comment for `WithGlobalOptionTimeFormatAsUnixUTCTimestamp`")
 	}
 }
@@ -43,8 +79,30 @@ func WithGlobalOptionTimeFormatAsUnixUTCTimestamp() ClientOption {
 // This token is especially good for setting the auth cookie to keep the user logged in.
 // https://docs.pcloud.com/methods/intro/global_parameters.html
 func WithGlobalOptionGetAuth() ClientOption {
-	return func(q *url.Values) {
-		q.Add("getauth", "1")
+	return func(co *callOptions) {
+		co.query.Add("getauth", "1")
+	}
+}
+
+// WithGlobalOptionFilterMeta limits the fields of the metadata returned by the call to fields,
+// such as "name", "size" and "modified", which shrinks the responses of large listings. The
+// other fields of the Metadata are left zero, and "contents" is always returned.
+// The responses of the calls with filtered metadata are not kept in the metadata cache, and
+// they purge it when they report changes: the fields by which the cache is kept coherent may
+// have been filtered out (see WithMetadataCache).
+// https://docs.pcloud.com/methods/intro/global_parameters.html
+func WithGlobalOptionFilterMeta(fields ...string) ClientOption {
+	return func(co *callOptions) {
+		co.query.Set("filtermeta", strings.Join(fields, ","))
+	}
+}
+
+// WithGlobalOptionIconFormat sets the format of the icons of the metadata returned by the call.
+// With IconFormatID, pCloud returns numeric ids, which Icon.ID returns, rather than names.
+// https://docs.pcloud.com/methods/intro/global_parameters.html
+func WithGlobalOptionIconFormat(format string) ClientOption {
+	return func(co *callOptions) {
+		co.query.Set("iconformat", format)
 	}
 }
 
@@ -52,8 +110,8 @@ func WithGlobalOptionGetAuth() ClientOption {
 // Should only be used over SSL connections.
 // https://docs.pcloud.com/methods/intro/global_parameters.html
 func WithGlobalOptionUsername(username string) ClientOption {
-	return func(q *url.Values) {
-		q.Add("username", username)
+	return func(co *callOptions) {
+		co.query.Add("username", username)
 	}
 }
 
@@ -61,8 +119,19 @@ func WithGlobalOptionUsername(username string) ClientOption {
 // Should only be used over SSL connections.
 // https://docs.pcloud.com/methods/intro/global_parameters.html
 func WithGlobalOptionPassword(password string) ClientOption {
-	return func(q *url.Values) {
-		q.Add("password", password)
+	return func(co *callOptions) {
+		co.query.Add("password", password)
+	}
+}
+
+// WithGlobalOptionPasswordDigest authenticates with a digest obtained with GetDigest and the
+// password digest computed from it, rather than with the password in plain text. It is used
+// along with WithGlobalOptionUsername. See LoginDigest.
+// https://docs.pcloud.com/methods/intro/authentication.html
+func WithGlobalOptionPasswordDigest(digest, passwordDigest string) ClientOption {
+	return func(co *callOptions) {
+		co.query.Add("digest", digest)
+		co.query.Add("passworddigest", passwordDigest)
 	}
 }
 
@@ -77,7 +146,7 @@ func WithGlobalOptionAuthExpire(authExpire time.Duration) ClientOption {
 		maxSeconds     = 63072000
 	)
 
-	return func(q *url.Values) {
+	return func(co *callOptions) {
 		e := int64(authExpire.Seconds())
 		if e < 0 {
 			e = defaultSeconds
@@ -85,7 +154,7 @@ func WithGlobalOptionAuthExpire(authExpire time.Duration) ClientOption {
 		if e > maxSeconds {
 			e = maxSeconds
 		}
-		q.Add("authexpire", fmt.Sprintf("%d", e))
+		co.query.Add("authexpire", fmt.Sprintf("%d", e))
 	}
 }
 
@@ -99,7 +168,7 @@ func WithGlobalOptionAuthInactiveExpire(authInactiveExpire time.Duration) Client
 		maxSeconds     = 5356800
 	)
 
-	return func(q *url.Values) {
+	return func(co *callOptions) {
 		e := int64(authInactiveExpire.Seconds())
 		if e < 0 {
 			e = defaultSeconds
@@ -107,6 +176,43 @@ func WithGlobalOptionAuthInactiveExpire(authInactiveExpire time.Duration) Client
 		if e > maxSeconds {
 			e = maxSeconds
 		}
-		q.Add("authinactiveexpire", fmt.Sprintf("%d", e))
+		co.query.Add("authinactiveexpire", fmt.Sprintf("%d", e))
+	}
+}
+
+// WithGlobalOptionDevice sets the name of the device, as displayed in the list of sessions of
+// the account (see ListTokens) and in the security settings of the account.
+// It defaults to a description made of the hostname, OS and architecture of the machine.
+// This is only meaningful when logging in (i.e. Login and Register).
+func WithGlobalOptionDevice(name string) ClientOption {
+	return func(co *callOptions) {
+		co.query.Set("device", name)
+	}
+}
+
+// WithGlobalOptionDeviceID sets the unique identifier of the device.
+// Two-factor authentication remembers trusted devices by this identifier.
+// It defaults to the same value as the default of WithGlobalOptionDevice.
+// This is only meaningful when logging in (i.e. Login and Register).
+func WithGlobalOptionDeviceID(id string) ClientOption {
+	return func(co *callOptions) {
+		co.query.Set("deviceid", id)
+	}
+}
+
+// WithGlobalOptionOSVersion sets the version of the operating system of the device.
+// This is only meaningful when logging in (i.e. Login and Register).
+func WithGlobalOptionOSVersion(version string) ClientOption {
+	return func(co *callOptions) {
+		co.query.Set("osversion", version)
+	}
+}
+
+// WithGlobalOptionClientVersion sets the name and version of the application that uses this SDK.
+// This is only meaningful when logging in (i.e. Login and Register).
+func WithGlobalOptionClientVersion(name, version string) ClientOption {
+	return func(co *callOptions) {
+		co.query.Set("clientname", name)
+		co.query.Set("appversion", version)
 	}
 }
@@ -0,0 +1,70 @@
+package sdk
+
+import (
+	"context"
+	"net/http"
+)
+
+// RoundTripperFunc is an adapter to allow the use of ordinary functions as http.RoundTripper.
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip implements http.RoundTripper.
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Interceptor wraps the http.RoundTripper that sends requests to pCloud.
+// An Interceptor can inspect or modify the request before passing it to next, inspect or
+// modify the response returned by next, or not call next at all and respond on its own.
+// The pCloud method of the request is its URL path (without the leading "/").
+//
+// Interceptors see every attempt of a call, including retries (see RetryPolicy). Use
+// RequestAttempt to tell them apart.
+type Interceptor func(next http.RoundTripper) http.RoundTripper
+
+// WithInterceptor adds interceptors to the chain of the Client. This allows logging, metrics,
+// fault injection, caching, etc without forking the SDK.
+// Interceptors run in the order they are added: the first one sees the request first and the
+// response last.
+// The *http.Client passed to NewClient is not modified.
+func WithInterceptor(interceptors ...Interceptor) Option {
+	return func(c *Client) {
+		c.interceptors = append(c.interceptors, interceptors...)
+	}
+}
+
+// withInterceptors returns a copy of hc with its transport wrapped in the interceptors.
+func withInterceptors(hc *http.Client, interceptors []Interceptor) *http.Client {
+	if len(interceptors) == 0 {
+		return hc
+	}
+
+	rt := hc.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		rt = interceptors[i](rt)
+	}
+
+	hcc := *hc
+	hcc.Transport = rt
+
+	return &hcc
+}
+
+type attemptKey struct{}
+
+// withAttempt records the attempt number of a call in ctx.
+func withAttempt(ctx context.Context, attempt int) context.Context {
+	return context.WithValue(ctx, attemptKey{}, attempt)
+}
+
+// RequestAttempt returns the attempt number, starting at 1, of the call the request whose
+// context is ctx belongs to. Attempts greater than 1 are retries (see RetryPolicy).
+// It returns 0 when ctx is not that of a request made by a Client.
+func RequestAttempt(ctx context.Context) int {
+	attempt, _ := ctx.Value(attemptKey{}).(int)
+	return attempt
+}
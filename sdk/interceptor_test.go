@@ -0,0 +1,82 @@
+package sdk_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+func TestInterceptor(t *testing.T) {
+	srv, calls := newFlakyServer(t, 0, 0)
+
+	var trail []string
+
+	tracer := func(name string) sdk.Interceptor {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return sdk.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				trail = append(trail, name+">"+req.URL.Path)
+				resp, err := next.RoundTrip(req)
+				trail = append(trail, "<"+name)
+				return resp, err
+			})
+		}
+	}
+
+	hc := srv.Client()
+	transport := hc.Transport
+	pcc := sdk.NewClient(hc, sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")), sdk.WithInterceptor(tracer("a"), tracer("b")))
+
+	_, err := pcc.List(context.Background(), sdk.ByID(sdk.RootFolderID), sdk.ListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a>/listfolder", "b>/listfolder", "<b", "<a"}, trail)
+	assert.EqualValues(t, 1, *calls)
+	assert.True(t, transport == hc.Transport, "the caller's http.Client must not be modified")
+}
+
+func TestInterceptor_ShortCircuit(t *testing.T) {
+	srv, calls := newFlakyServer(t, 0, 0)
+
+	chaos := func(next http.RoundTripper) http.RoundTripper {
+		return sdk.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+				Body:       io.NopCloser(strings.NewReader(`{"result": 2009, "error": "File not found."}`)),
+				Request:    req,
+			}, nil
+		})
+	}
+
+	pcc := sdk.NewClient(srv.Client(), sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")), sdk.WithInterceptor(chaos))
+
+	_, err := pcc.List(context.Background(), sdk.ByID(sdk.RootFolderID), sdk.ListOptions{})
+	require.Error(t, err)
+	assert.True(t, sdk.IsNotFound(err))
+	assert.EqualValues(t, 0, *calls)
+}
+
+func TestInterceptor_RequestAttempt(t *testing.T) {
+	srv, _ := newFlakyServer(t, 2, sdk.ErrInternalError)
+
+	var attempts []int
+
+	recorder := func(next http.RoundTripper) http.RoundTripper {
+		return sdk.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			attempts = append(attempts, sdk.RequestAttempt(req.Context()))
+			return next.RoundTrip(req)
+		})
+	}
+
+	pcc := sdk.NewClient(srv.Client(), sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")), sdk.WithRetryPolicy(retryTestPolicy()), sdk.WithInterceptor(recorder))
+
+	_, err := pcc.List(context.Background(), sdk.ByID(sdk.RootFolderID), sdk.ListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, attempts)
+}
@@ -0,0 +1,122 @@
+// Command gencodes generates the result codes of pCloud's API, sdk/codes.go, from the table of
+// codes.txt, which follows pCloud's documentation of its errors.
+// It is run by go generate in package sdk.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// resultCode is a row of codes.txt.
+type resultCode struct {
+	code    int
+	name    string
+	message string
+}
+
+func main() {
+	in := flag.String("in", filepath.Join("internal", "gencodes", "codes.txt"), "the table of the result codes")
+	out := flag.String("out", "codes.go", "the Go file to generate")
+	flag.Parse()
+
+	codes, err := readCodes(*in)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	src, err := generate(codes)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = os.WriteFile(*out, src, 0o644)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// readCodes reads the table of result codes of the file name: a code, its Go name and its
+// message per line, separated by tabs. Empty lines and the lines starting with # are ignored.
+func readCodes(name string) ([]resultCode, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var codes []resultCode
+
+	seen := map[int]bool{}
+	s := bufio.NewScanner(f)
+
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("%s:%d: expected a code, a name and a message", name, n)
+		}
+
+		code, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid code: %w", name, n, err)
+		}
+		if seen[code] {
+			return nil, fmt.Errorf("%s:%d: duplicate code %d", name, n, code)
+		}
+		seen[code] = true
+
+		codes = append(codes, resultCode{code: code, name: fields[1], message: fields[2]})
+	}
+
+	return codes, s.Err()
+}
+
+// generate returns the Go source of the result codes.
+func generate(codes []resultCode) ([]byte, error) {
+	b := &bytes.Buffer{}
+
+	fmt.Fprint(b, "// Code generated by go run ./internal/gencodes; DO NOT EDIT.\n\n")
+	fmt.Fprint(b, "package sdk\n\n")
+
+	fmt.Fprint(b, "// The result codes of pCloud's API.\n")
+	fmt.Fprint(b, "// https://docs.pcloud.com/errors/\n")
+	fmt.Fprint(b, "const (\n")
+	for i, c := range codes {
+		if i > 0 {
+			fmt.Fprint(b, "\n")
+		}
+		fmt.Fprintf(b, "\t// Code%s is the result code %d: %s\n", c.name, c.code, c.message)
+		fmt.Fprintf(b, "\tCode%s Code = %d\n", c.name, c.code)
+	}
+	fmt.Fprint(b, ")\n\n")
+
+	fmt.Fprint(b, "// The result codes of pCloud's API as untyped constants, to compare with Error.Code and\n")
+	fmt.Fprint(b, "// ErrorCode. They are the same as the Code... constants.\n")
+	fmt.Fprint(b, "const (\n")
+	for _, c := range codes {
+		fmt.Fprintf(b, "\tErr%s = %d // see Code%s\n", c.name, c.code, c.name)
+	}
+	fmt.Fprint(b, ")\n\n")
+
+	fmt.Fprint(b, "// codeMessages holds the messages of the result codes, as documented by pCloud.\n")
+	fmt.Fprint(b, "var codeMessages = map[Code]string{\n")
+	for _, c := range codes {
+		fmt.Fprintf(b, "\tCode%s: %q,\n", c.name, c.message)
+	}
+	fmt.Fprint(b, "}\n")
+
+	return format.Source(b.Bytes())
+}
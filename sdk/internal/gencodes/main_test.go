@@ -0,0 +1,23 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate_UpToDate(t *testing.T) {
+	codes, err := readCodes("codes.txt")
+	require.NoError(t, err)
+	require.NotEmpty(t, codes)
+
+	src, err := generate(codes)
+	require.NoError(t, err)
+
+	current, err := os.ReadFile("../../codes.go")
+	require.NoError(t, err)
+
+	assert.Equal(t, string(current), string(src), "sdk/codes.go is out of date: run go generate ./sdk")
+}
@@ -0,0 +1,150 @@
+package sdk
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// redactedParams lists the query parameters whose value is never logged.
+var redactedParams = map[string]bool{
+	"access_token":   true,
+	"auth":           true,
+	"client_secret":  true,
+	"code":           true,
+	"digest":         true,
+	"newpassword":    true,
+	"oldpassword":    true,
+	"password":       true,
+	"passworddigest": true,
+	"privatekey":     true,
+	"token":          true,
+}
+
+// WithLogger sets the logger the Client uses to report its API calls.
+// Successful calls are logged at the debug level, failed calls (including those that pCloud
+// responds to with an error result) at the warn level and retries
+// at the info level. Each record carries the pCloud method, its parameters, the duration of the
+// call, the pCloud result code and the number of attempts.
+// Passwords, auth tokens and other secrets are redacted from the parameters and from the URLs
+// that errors report.
+func WithLogger(l *slog.Logger) Option {
+	return func(c *Client) {
+		c.logger = l
+	}
+}
+
+// logCall logs the outcome of an API call.
+func (c *Client) logCall(ctx context.Context, endpoint string, query url.Values, start time.Time, attempts int, body []byte, err error) {
+	if c.logger == nil {
+		return
+	}
+
+	code := resultCode(body)
+
+	attrs := []slog.Attr{
+		slog.String("method", endpoint),
+		slog.Any("params", redactParams(query)),
+		slog.Duration("duration", time.Since(start)),
+		slog.Int("result", code),
+		slog.Int("attempts", attempts),
+	}
+
+	if err != nil || code != 0 {
+		if err != nil {
+			attrs = append(attrs, slog.String("error", errorString(err)))
+		}
+		c.logger.LogAttrs(ctx, slog.LevelWarn, "pCloud API call failed", attrs...)
+		return
+	}
+
+	c.logger.LogAttrs(ctx, slog.LevelDebug, "pCloud API call", attrs...)
+}
+
+// logRetry logs the decision to retry an API call.
+func (c *Client) logRetry(ctx context.Context, endpoint string, attempt int, delay time.Duration, body []byte, err error) {
+	if c.logger == nil {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", endpoint),
+		slog.Int("attempt", attempt),
+		slog.Duration("delay", delay),
+		slog.Int("result", resultCode(body)),
+	}
+
+	if err != nil {
+		attrs = append(attrs, slog.String("error", errorString(err)))
+	}
+
+	c.logger.LogAttrs(ctx, slog.LevelInfo, "retrying pCloud API call", attrs...)
+}
+
+// logDiscoveryFailure logs the failure of the discovery of the API server (see
+// WithAPIServerDiscovery).
+func (c *Client) logDiscoveryFailure(ctx context.Context, err error) {
+	if c.logger == nil {
+		return
+	}
+
+	c.logger.LogAttrs(ctx, slog.LevelWarn, "pCloud API server discovery failed",
+		slog.String("host", c.apiURL),
+		slog.String("error", errorString(err)),
+	)
+}
+
+// redactParams returns the query parameters of a call, with secrets redacted.
+func redactParams(query url.Values) map[string]string {
+	params := make(map[string]string, len(query))
+
+	for k := range query {
+		if redactedParams[k] {
+			params[k] = "REDACTED"
+			continue
+		}
+		params[k] = query.Get(k)
+	}
+
+	return params
+}
+
+// stripURLQuery removes the query from the URL of the request that err reports, if any, as it
+// carries the auth token and other secrets. err is modified in place.
+func stripURLQuery(err error) {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL = withoutQuery(urlErr.URL)
+	}
+}
+
+// errorString returns the message of err, without the query of the URL of the request it
+// reports, if any.
+func errorString(err error) string {
+	msg := err.Error()
+
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		msg = strings.ReplaceAll(msg, urlErr.URL, withoutQuery(urlErr.URL))
+	}
+
+	return msg
+}
+
+// withoutQuery returns rawURL without its query.
+func withoutQuery(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		// the URL cannot be told apart from its query: drop it all.
+		return ""
+	}
+
+	u.RawQuery = ""
+	u.ForceQuery = false
+
+	return u.String()
+}
@@ -0,0 +1,69 @@
+package sdk_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+func TestLogger(t *testing.T) {
+	srv, _ := newFlakyServer(t, 1, sdk.ErrInternalError)
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	pcc := sdk.NewClient(
+		srv.Client(),
+		sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")),
+		sdk.WithRetryPolicy(retryTestPolicy()),
+		sdk.WithLogger(logger),
+	)
+
+	_, err := pcc.List(context.Background(), sdk.ByID(sdk.RootFolderID), sdk.ListOptions{}, sdk.WithGlobalOptionUsername("user"), sdk.WithGlobalOptionPassword("s3cr3t"))
+	require.NoError(t, err)
+
+	out := buf.String()
+	assert.Contains(t, out, `msg="retrying pCloud API call" method=listfolder attempt=1`)
+	assert.Contains(t, out, "result=5000")
+	assert.Contains(t, out, `msg="pCloud API call" method=listfolder`)
+	assert.Contains(t, out, "attempts=2")
+	assert.Contains(t, out, "username:user")
+	assert.Contains(t, out, "password:REDACTED")
+	assert.NotContains(t, out, "s3cr3t")
+}
+
+func TestLogger_NetworkError(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	// nothing listens on port 1: the calls fail with a *url.Error, whose message has the URL.
+	pcc := sdk.NewClient(
+		nil,
+		sdk.WithBaseHost("127.0.0.1:1"),
+		sdk.WithAuthToken("SECRET-TOKEN"),
+		sdk.WithRetryPolicy(retryTestPolicy()),
+		sdk.WithLogger(logger),
+	)
+
+	_, err := pcc.GetIP(context.Background())
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "SECRET-TOKEN")
+
+	err = pcc.Login(context.Background(), "", sdk.WithGlobalOptionUsername("user"), sdk.WithGlobalOptionPassword("s3cr3t"))
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "s3cr3t")
+
+	out := buf.String()
+	assert.Contains(t, out, `msg="retrying pCloud API call" method=getip attempt=1`)
+	assert.Contains(t, out, `msg="pCloud API call failed" method=getip`)
+	assert.Contains(t, out, "https://127.0.0.1:1/getip")
+	assert.NotContains(t, out, "SECRET-TOKEN")
+	assert.NotContains(t, out, "s3cr3t")
+}
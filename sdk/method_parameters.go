@@ -0,0 +1,239 @@
+package sdk
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// The optional parameters of the methods of pCloud are ClientOption's too: they are passed
+// with the global parameters, so that a method that pCloud extends does not need a new
+// signature. Each option documents the methods that accept it; pCloud ignores the parameters
+// that a method does not accept.
+// The options structs of the methods, such as CopyFileOptions, are a shorthand for these
+// ClientOption's: their fields that are set are passed as ClientOption's after those of the call,
+// and take precedence over them. A field left to its zero value, such as a false NoOver, sets
+// nothing: it does not unset the ClientOption of the same parameter.
+
+// WithNoOver if set, CopyFileTo and CopyFolderTo do not overwrite the destination files: the call
+// fails with ErrFileOrFolderAlreadyExists instead.
+// https://docs.pcloud.com/methods/file/copyfile.html
+// https://docs.pcloud.com/methods/folder/copyfolder.html
+func WithNoOver() ClientOption {
+	return withFlag("noover")
+}
+
+// WithSkipExisting if set, CopyFolderTo skips the files that already exist at the destination,
+// rather than overwriting them.
+// https://docs.pcloud.com/methods/folder/copyfolder.html
+func WithSkipExisting() ClientOption {
+	return withFlag("skipexisting")
+}
+
+// WithCopyContentOnly if set, CopyFolderTo copies the contents of the folder into the
+// destination folder, rather than the folder itself.
+// https://docs.pcloud.com/methods/folder/copyfolder.html
+func WithCopyContentOnly() ClientOption {
+	return withFlag("copycontentonly")
+}
+
+// WithRenameIfExists if set, UploadFiles does not overwrite the files with the same name but
+// renames the uploaded files to names like filename (2).ext.
+// https://docs.pcloud.com/methods/file/uploadfile.html
+func WithRenameIfExists() ClientOption {
+	return withFlag("renameifexists")
+}
+
+// WithNoPartial if set, UploadFiles does not save the files that are partially uploaded, that is
+// when the connection breaks before a file is read in full.
+// https://docs.pcloud.com/methods/file/uploadfile.html
+func WithNoPartial() ClientOption {
+	return withFlag("nopartial")
+}
+
+// WithProgressHash sets the hash by which the progress of UploadFiles can be followed with the
+// uploadprogress method.
+// https://docs.pcloud.com/methods/file/uploadfile.html
+func WithProgressHash(hash string) ClientOption {
+	return func(co *callOptions) {
+		co.query.Set("progresshash", hash)
+	}
+}
+
+// WithModifiedTime sets the modification time of the files written by CopyFileTo and UploadFiles.
+// A zero time is ignored.
+// https://docs.pcloud.com/methods/file/copyfile.html
+// https://docs.pcloud.com/methods/file/uploadfile.html
+func WithModifiedTime(t time.Time) ClientOption {
+	return withTime("mtime", t)
+}
+
+// WithCreatedTime sets the creation time of the files written by CopyFileTo and UploadFiles.
+// pCloud requires the modification time to be set too (see WithModifiedTime).
+// A zero time is ignored.
+// https://docs.pcloud.com/methods/file/copyfile.html
+// https://docs.pcloud.com/methods/file/uploadfile.html
+func WithCreatedTime(t time.Time) ClientOption {
+	return withTime("ctime", t)
+}
+
+// WithRecursive if set, List, ListFunc and ListTrash return the full tree of the
+// folder, rather than its direct contents only.
+// https://docs.pcloud.com/methods/folder/listfolder.html
+// https://docs.pcloud.com/methods/trash/trash_list.html
+func WithRecursive() ClientOption {
+	return withFlag("recursive")
+}
+
+// WithShowDeleted if set, List and ListFunc return the deleted files and folders
+// that can be undeleted too.
+// https://docs.pcloud.com/methods/folder/listfolder.html
+func WithShowDeleted() ClientOption {
+	return withFlag("showdeleted")
+}
+
+// WithNoFiles if set, List, ListFunc and ListTrash return the folders only.
+// https://docs.pcloud.com/methods/folder/listfolder.html
+// https://docs.pcloud.com/methods/trash/trash_list.html
+func WithNoFiles() ClientOption {
+	return withFlag("nofiles")
+}
+
+// WithNoShares if set, List and ListFunc return the user's own files and folders
+// only, and ListShares does not return the active shares.
+// https://docs.pcloud.com/methods/folder/listfolder.html
+// https://docs.pcloud.com/methods/sharing/listshares.html
+func WithNoShares() ClientOption {
+	return withFlag("noshares")
+}
+
+// WithNoRequests if set, ListShares does not return the pending share requests.
+// https://docs.pcloud.com/methods/sharing/listshares.html
+func WithNoRequests() ClientOption {
+	return withFlag("norequests")
+}
+
+// WithNoIncoming if set, ListShares does not return the incoming shares and share requests.
+// https://docs.pcloud.com/methods/sharing/listshares.html
+func WithNoIncoming() ClientOption {
+	return withFlag("noincoming")
+}
+
+// WithNoOutgoing if set, ListShares does not return the outgoing shares and share requests.
+// https://docs.pcloud.com/methods/sharing/listshares.html
+func WithNoOutgoing() ClientOption {
+	return withFlag("nooutgoing")
+}
+
+// WithForceDownload if set, the links returned by DownloadLink serve the file as a download,
+// with the content type application/octet-stream.
+// https://docs.pcloud.com/methods/streaming/getfilelink.html
+func WithForceDownload() ClientOption {
+	return withFlag("forcedownload")
+}
+
+// WithContentType sets the content type with which the links returned by DownloadLink serve the
+// file.
+// https://docs.pcloud.com/methods/streaming/getfilelink.html
+func WithContentType(contentType string) ClientOption {
+	return func(co *callOptions) {
+		co.query.Set("contenttype", contentType)
+	}
+}
+
+// WithMaxSpeed limits the speed, in bytes per second, at which the links returned by
+// DownloadLink serve the file.
+// https://docs.pcloud.com/methods/streaming/getfilelink.html
+func WithMaxSpeed(bytesPerSecond uint64) ClientOption {
+	return func(co *callOptions) {
+		co.query.Set("maxspeed", fmt.Sprintf("%d", bytesPerSecond))
+	}
+}
+
+// WithSkipFilename if set, the links returned by DownloadLink do not end with the name of the
+// file.
+// https://docs.pcloud.com/methods/streaming/getfilelink.html
+func WithSkipFilename() ClientOption {
+	return withFlag("skipfilename")
+}
+
+// WithExpire sets the time at which the public links created by CreateFilePubLink and
+// CreateFolderPubLink, or changed by UpdatePubLink, stop working.
+// A zero time is ignored.
+// https://docs.pcloud.com/methods/public_links/getfilepublink.html
+// https://docs.pcloud.com/methods/public_links/changepublink.html
+func WithExpire(t time.Time) ClientOption {
+	return withTime("expire", t)
+}
+
+// WithMaxDownloads limits the number of downloads that the public links created by
+// CreateFilePubLink and CreateFolderPubLink allow.
+// https://docs.pcloud.com/methods/public_links/getfilepublink.html
+func WithMaxDownloads(n uint64) ClientOption {
+	return func(co *callOptions) {
+		co.query.Set("maxdownloads", fmt.Sprintf("%d", n))
+	}
+}
+
+// WithMaxTraffic limits the traffic, in bytes, that the public links created by CreateFilePubLink
+// and CreateFolderPubLink allow.
+// https://docs.pcloud.com/methods/public_links/getfilepublink.html
+func WithMaxTraffic(bytes uint64) ClientOption {
+	return func(co *callOptions) {
+		co.query.Set("maxtraffic", fmt.Sprintf("%d", bytes))
+	}
+}
+
+// WithShortLink if set, CreateFilePubLink and CreateFolderPubLink create a short link too.
+// https://docs.pcloud.com/methods/public_links/getfilepublink.html
+func WithShortLink() ClientOption {
+	return withFlag("shortlink")
+}
+
+// WithLinkPassword protects the public links changed by UpdatePubLink with a password.
+// https://docs.pcloud.com/methods/public_links/changepublink.html
+func WithLinkPassword(password string) ClientOption {
+	return func(co *callOptions) {
+		co.query.Set("linkpassword", password)
+	}
+}
+
+// WithFeedbackName sets the name of the sender of the messages sent by Feedback.
+// https://docs.pcloud.com/methods/general/feedback.html
+func WithFeedbackName(name string) ClientOption {
+	return func(co *callOptions) {
+		co.query.Set("name", name)
+	}
+}
+
+// methodOptions are the options structs of the methods.
+type methodOptions interface {
+	// options returns the ClientOption's of the fields that are set.
+	options() []ClientOption
+}
+
+// withOptions returns opts followed by the ClientOption's of o (see methodOptions).
+func withOptions(opts []ClientOption, o methodOptions) []ClientOption {
+	return append(append(make([]ClientOption, 0, len(opts)), opts...), o.options()...)
+}
+
+// withFlag returns the ClientOption that sets the flag name.
+func withFlag(name string) ClientOption {
+	return func(co *callOptions) {
+		co.query.Set(name, "1")
+	}
+}
+
+// withTime returns the ClientOption that sets the parameter name to t (see setTime).
+func withTime(name string, t time.Time) ClientOption {
+	return func(co *callOptions) {
+		setTime(co.query, name, t)
+	}
+}
+
+// setTime sets the parameter name to t in q, as a Unix timestamp, unless t is zero.
+func setTime(q url.Values, name string, t time.Time) {
+	if !t.IsZero() {
+		q.Set(name, fmt.Sprintf("%d", t.UTC().Unix()))
+	}
+}
@@ -0,0 +1,114 @@
+package sdk_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+func TestMethodParameters(t *testing.T) {
+	var query url.Values
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"result": 0, "metadata": {"name": "a.txt"}}`)
+	}))
+	defer srv.Close()
+
+	pcc := sdk.NewClient(srv.Client(), sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")))
+
+	mtime := time.Date(2024, 5, 1, 12, 0, 0, 0, time.FixedZone("CEST", 2*3600))
+
+	_, err := pcc.CopyFileTo(context.Background(), sdk.ByID(1), sdk.ByPath("/backup/"), sdk.CopyFileOptions{}, sdk.WithNoOver(), sdk.WithModifiedTime(mtime), sdk.WithCreatedTime(time.Time{}))
+	require.NoError(t, err)
+	assert.Equal(t, "1", query.Get("noover"))
+	assert.Equal(t, fmt.Sprintf("%d", mtime.Unix()), query.Get("mtime"))
+	assert.NotContains(t, query, "ctime")
+
+	// a field of the options of the method left to its zero value does not unset the ClientOption.
+	_, err = pcc.CopyFileTo(context.Background(), sdk.ByID(1), sdk.ByPath("/backup/"), sdk.CopyFileOptions{NoOver: false}, sdk.WithNoOver())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1"}, query["noover"])
+
+	_, err = pcc.List(context.Background(), sdk.ByID(sdk.RootFolderID), sdk.ListOptions{Recursive: false}, sdk.WithRecursive())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1"}, query["recursive"])
+
+	// the parameters passed in the options of the method and as ClientOption's are sent once, with
+	// the value of the options.
+	_, err = pcc.CopyFileTo(context.Background(), sdk.ByID(1), sdk.ByPath("/backup/"), sdk.CopyFileOptions{NoOver: true, ModifiedTime: mtime.Add(time.Hour)}, sdk.WithNoOver(), sdk.WithModifiedTime(mtime))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1"}, query["noover"])
+	assert.Equal(t, []string{fmt.Sprintf("%d", mtime.Add(time.Hour).Unix())}, query["mtime"])
+
+	_, err = pcc.List(context.Background(), sdk.ByID(sdk.RootFolderID), sdk.ListOptions{}, sdk.WithRecursive(), sdk.WithNoShares())
+	require.NoError(t, err)
+	assert.Equal(t, "1", query.Get("recursive"))
+	assert.Equal(t, "1", query.Get("noshares"))
+	assert.NotContains(t, query, "nofiles")
+
+	// the metadata of the response is partially populated.
+	lf, err := pcc.List(context.Background(), sdk.ByID(sdk.RootFolderID), sdk.ListOptions{}, sdk.WithGlobalOptionFilterMeta("name", "size"))
+	require.NoError(t, err)
+	assert.Equal(t, "name,size", query.Get("filtermeta"))
+	assert.Equal(t, "a.txt", lf.Metadata.Name)
+	assert.True(t, lf.Metadata.Modified.IsZero())
+
+	_, err = pcc.ListShares(context.Background(), sdk.WithNoRequests(), sdk.WithNoOutgoing())
+	require.NoError(t, err)
+	assert.Equal(t, "1", query.Get("norequests"))
+	assert.Equal(t, "1", query.Get("nooutgoing"))
+
+	_, err = pcc.DownloadLink(context.Background(), sdk.ByID(1), sdk.DownloadLinkOptions{}, sdk.WithContentType("text/plain"), sdk.WithMaxSpeed(1024))
+	require.NoError(t, err)
+	assert.Equal(t, "text/plain", query.Get("contenttype"))
+	assert.Equal(t, "1024", query.Get("maxspeed"))
+
+	err = pcc.Feedback(context.Background(), "me@example.com", "Question", "Hello", sdk.WithFeedbackName("Me"))
+	require.NoError(t, err)
+	assert.Equal(t, "Me", query.Get("name"))
+	assert.Equal(t, "me@example.com", query.Get("mail"))
+}
+
+func TestDeprecatedPositionalParameters(t *testing.T) {
+	var query url.Values
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"result": 0, "metadata": {"name": "a.txt"}}`)
+	}))
+	defer srv.Close()
+
+	pcc := sdk.NewClient(srv.Client(), sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")))
+
+	mtime := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	_, err := pcc.CopyFile(context.Background(), sdk.ByID(1), sdk.ByPath("/backup/"), true, mtime, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, "1", query.Get("noover"))
+	assert.Equal(t, fmt.Sprintf("%d", mtime.Unix()), query.Get("mtime"))
+	assert.NotContains(t, query, "ctime")
+
+	_, err = pcc.ListFolder(context.Background(), sdk.ByID(sdk.RootFolderID), true, false, true, false)
+	require.NoError(t, err)
+	assert.Equal(t, "1", query.Get("recursive"))
+	assert.Equal(t, "1", query.Get("nofiles"))
+	assert.NotContains(t, query, "showdeleted")
+
+	_, err = pcc.TrashList(context.Background(), 12, false, true)
+	require.NoError(t, err)
+	assert.Equal(t, "12", query.Get("folderid"))
+	assert.Equal(t, "1", query.Get("recursive"))
+}
@@ -0,0 +1,118 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// NotificationAction is the action an application should take when the user opens a
+// Notification.
+// Applications are advised to ignore actions they don't understand.
+type NotificationAction string
+
+const (
+	// NotificationNoAction means the notification is informative only (for instance, a quota
+	// warning).
+	NotificationNoAction NotificationAction = ""
+
+	// NotificationGoToFolder means the application should open the folder in FolderID.
+	NotificationGoToFolder NotificationAction = "gotofolder"
+
+	// NotificationGoToURL means the application should open URL.
+	NotificationGoToURL NotificationAction = "gotourl"
+
+	// NotificationOpenShareRequest means the application should open the share invite in
+	// ShareRequestID.
+	NotificationOpenShareRequest NotificationAction = "opensharerequest"
+)
+
+// Notification is a pCloud user notification, such as a share invite or a quota warning.
+type Notification struct {
+	NotificationID uint64
+	Text           string
+	Thumb          string
+	MTime          time.Time
+	IsNew          bool
+	IconID         int
+	Action         NotificationAction
+	FolderID       uint64
+	ShareRequestID uint64
+	URL            string
+}
+
+// UnmarshalJSON decodes the Notification, with its times in any of the formats of APITime.
+func (n *Notification) UnmarshalJSON(data []byte) error {
+	type notification Notification
+	times := struct {
+		*notification
+		MTime APITime
+	}{notification: (*notification)(n)}
+
+	err := json.Unmarshal(data, &times)
+	if err != nil {
+		return err
+	}
+
+	n.MTime = times.MTime.Time
+
+	return nil
+}
+
+// NotificationsResult contains the notifications returned by ListNotifications.
+type NotificationsResult struct {
+	result
+	Notifications []Notification
+}
+
+// Unread returns the notifications that have not yet been marked as read.
+func (nr *NotificationsResult) Unread() []Notification {
+	var unread []Notification
+	for _, n := range nr.Notifications {
+		if n.IsNew {
+			unread = append(unread, n)
+		}
+	}
+	return unread
+}
+
+// ListNotifications returns the notifications of the user, the most recent first.
+// The optional parameter thumbSizeOpt (e.g. "32x32") requests thumbnails of that size.
+// This method is not part of pCloud's public API documentation: it is the one used by pCloud's
+// own sync clients (getnotifications).
+func (c *Client) ListNotifications(ctx context.Context, thumbSizeOpt string, opts ...ClientOption) (*NotificationsResult, error) {
+	ctx, q := toQuery(ctx, opts...)
+
+	if thumbSizeOpt != "" {
+		q.Add("notificationthumbsize", thumbSizeOpt)
+	}
+
+	r := &NotificationsResult{}
+
+	err := c.parseAPIOutput(r)(c.get(ctx, "getnotifications", q))
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// MarkNotificationsRead marks as read the notification notificationID and all the notifications
+// older than it.
+// This method is not part of pCloud's public API documentation: it is the one used by pCloud's
+// own sync clients (readnotifications).
+func (c *Client) MarkNotificationsRead(ctx context.Context, notificationID uint64, opts ...ClientOption) error {
+	ctx, q := toQuery(ctx, opts...)
+
+	q.Add("notificationid", fmt.Sprintf("%d", notificationID))
+
+	r := &result{}
+
+	err := c.parseAPIOutput(r)(c.get(ctx, "readnotifications", q))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
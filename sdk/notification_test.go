@@ -0,0 +1,79 @@
+package sdk_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+const notificationsResponse = `{
+	"result": 0,
+	"notifications": [
+		{
+			"notificationid": 12,
+			"text": "a@b.c wants to share folder 'holidays' with you",
+			"thumb": "",
+			"mtime": "Thu, 21 Mar 2013 18:33:45 +0000",
+			"isnew": true,
+			"iconid": 3,
+			"action": "opensharerequest",
+			"sharerequestid": 30
+		},
+		{
+			"notificationid": 11,
+			"text": "You have used 95% of your storage",
+			"mtime": "Thu, 21 Mar 2013 18:31:45 +0000",
+			"isnew": false,
+			"iconid": 1
+		}
+	]
+}`
+
+func TestNotifications(t *testing.T) {
+	var read string
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/getnotifications":
+			assert.Equal(t, "32x32", r.URL.Query().Get("notificationthumbsize"))
+			fmt.Fprint(w, notificationsResponse)
+		case "/readnotifications":
+			read = r.URL.Query().Get("notificationid")
+			fmt.Fprint(w, `{"result": 0}`)
+		default:
+			t.Errorf("unexpected call to %s", r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	pcc := sdk.NewClient(srv.Client(), sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")))
+
+	nr, err := pcc.ListNotifications(context.Background(), "32x32")
+	require.NoError(t, err)
+	require.Len(t, nr.Notifications, 2)
+
+	n := nr.Notifications[0]
+	assert.EqualValues(t, 12, n.NotificationID)
+	assert.Equal(t, sdk.NotificationOpenShareRequest, n.Action)
+	assert.EqualValues(t, 30, n.ShareRequestID)
+	assert.Equal(t, 2013, n.MTime.Year())
+
+	assert.Equal(t, sdk.NotificationNoAction, nr.Notifications[1].Action)
+
+	unread := nr.Unread()
+	require.Len(t, unread, 1)
+	assert.EqualValues(t, 12, unread[0].NotificationID)
+
+	err = pcc.MarkNotificationsRead(context.Background(), unread[0].NotificationID)
+	require.NoError(t, err)
+	assert.Equal(t, "12", read)
+}
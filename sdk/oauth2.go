@@ -0,0 +1,73 @@
+package sdk
+
+import (
+	"context"
+	"net/url"
+)
+
+// OAuth2AuthorizeURL returns the URL of the page on which the user grants the application
+// clientID access to their account. pCloud then redirects the user to redirectURIOpt with a
+// code, or displays the code when redirectURIOpt is empty. The code is exchanged for an access
+// token with OAuth2Token.
+// The optional parameter stateOpt is passed back to redirectURIOpt.
+// https://docs.pcloud.com/methods/oauth_2.0/authorize.html
+func OAuth2AuthorizeURL(clientID, redirectURIOpt, stateOpt string) string {
+	q := url.Values{}
+	q.Add("client_id", clientID)
+	q.Add("response_type", "code")
+
+	if redirectURIOpt != "" {
+		q.Add("redirect_uri", redirectURIOpt)
+	}
+
+	if stateOpt != "" {
+		q.Add("state", stateOpt)
+	}
+
+	u := url.URL{
+		Scheme:   "https",
+		Host:     "my.pcloud.com",
+		Path:     "/oauth2/authorize",
+		RawQuery: q.Encode(),
+	}
+
+	return u.String()
+}
+
+// OAuth2AccessToken contains the properties returned from an API call to OAuth2Token.
+type OAuth2AccessToken struct {
+	result
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	UserID      uint64 `json:"uid"`
+	// LocationID is the data region of the account: 1 for the US and 2 for Europe.
+	LocationID int `json:"locationid"`
+}
+
+// Region returns the data region of the account, as indicated by LocationID.
+func (t *OAuth2AccessToken) Region() Region {
+	if t.LocationID == 1 {
+		return RegionUS
+	}
+	return RegionEU
+}
+
+// OAuth2Token exchanges the code obtained by the user on the page of OAuth2AuthorizeURL for an
+// access token, which authenticates the calls of Clients created with WithOAuth2AccessToken.
+// https://docs.pcloud.com/methods/oauth_2.0/oauth2_token.html
+func (c *Client) OAuth2Token(ctx context.Context, clientID, clientSecret, code string, opts ...ClientOption) (*OAuth2AccessToken, error) {
+	ctx, q := toQuery(ctx, opts...)
+
+	q.Add("client_id", clientID)
+	q.Add("client_secret", clientSecret)
+	q.Add("code", code)
+
+	t := &OAuth2AccessToken{}
+
+	err := c.parseAPIOutput(t)(c.get(ctx, "oauth2_token", q))
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
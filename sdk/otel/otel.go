@@ -0,0 +1,246 @@
+// Package otel instruments the pCloud SDK with OpenTelemetry.
+//
+// It is a separate Go module so that programs that do not use OpenTelemetry do not depend on
+// it. Plug it into a Client with sdk.WithInterceptor:
+//
+//	pcc := sdk.NewClient(nil, sdk.WithInterceptor(otel.Interceptor()))
+package otel
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	gotel "go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+// instrumentationName identifies the instrumentation in the telemetry it produces.
+const instrumentationName = "github.com/seborama/pcloud-sdk/sdk/otel"
+
+// Attribute keys.
+const (
+	// MethodKey is the pCloud method of the request, e.g. "listfolder".
+	MethodKey = attribute.Key("pcloud.method")
+
+	// ResultKey is the pCloud result code of the response. 0 means success.
+	ResultKey = attribute.Key("pcloud.result")
+
+	// BytesSentKey is the number of bytes of the body of the request.
+	BytesSentKey = attribute.Key("pcloud.bytes_sent")
+
+	// BytesReceivedKey is the number of bytes of the body of the response.
+	BytesReceivedKey = attribute.Key("pcloud.bytes_received")
+)
+
+// Option is a functional parameter for Interceptor.
+type Option func(*config)
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+}
+
+// WithTracerProvider sets the TracerProvider used to create spans.
+// The default is the global TracerProvider.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracerProvider = tp
+	}
+}
+
+// WithMeterProvider sets the MeterProvider used to create instruments.
+// The default is the global MeterProvider.
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(c *config) {
+		c.meterProvider = mp
+	}
+}
+
+// instrumentation holds the tracer and instruments of an Interceptor.
+type instrumentation struct {
+	tracer        trace.Tracer
+	requests      metric.Int64Counter
+	errors        metric.Int64Counter
+	duration      metric.Float64Histogram
+	bytesSent     metric.Int64Counter
+	bytesReceived metric.Int64Counter
+}
+
+// Interceptor returns an sdk.Interceptor that creates a span for each request made to pCloud
+// and records the following metrics:
+//   - pcloud.client.requests: number of requests
+//   - pcloud.client.errors: number of requests that failed, either at the HTTP level or with a
+//     non-zero pCloud result code
+//   - pcloud.client.duration: duration of the requests, in seconds
+//   - pcloud.client.bytes_sent and pcloud.client.bytes_received: size of the bodies of the
+//     requests and responses
+//
+// Note that each attempt of a call that is retried (see sdk.RetryPolicy) is a separate request.
+func Interceptor(opts ...Option) sdk.Interceptor {
+	cfg := &config{
+		tracerProvider: gotel.GetTracerProvider(),
+		meterProvider:  gotel.GetMeterProvider(),
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	inst := newInstrumentation(cfg)
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return sdk.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return inst.roundTrip(next, req)
+		})
+	}
+}
+
+func newInstrumentation(cfg *config) *instrumentation {
+	meter := cfg.meterProvider.Meter(instrumentationName)
+
+	// errors are ignored: the instruments returned on error are no-op instruments.
+	inst := &instrumentation{tracer: cfg.tracerProvider.Tracer(instrumentationName)}
+	inst.requests, _ = meter.Int64Counter("pcloud.client.requests", metric.WithDescription("Number of requests made to pCloud"))
+	inst.errors, _ = meter.Int64Counter("pcloud.client.errors", metric.WithDescription("Number of requests made to pCloud that failed"))
+	inst.duration, _ = meter.Float64Histogram("pcloud.client.duration", metric.WithDescription("Duration of the requests made to pCloud"), metric.WithUnit("s"))
+	inst.bytesSent, _ = meter.Int64Counter("pcloud.client.bytes_sent", metric.WithDescription("Bytes sent to pCloud"), metric.WithUnit("By"))
+	inst.bytesReceived, _ = meter.Int64Counter("pcloud.client.bytes_received", metric.WithDescription("Bytes received from pCloud"), metric.WithUnit("By"))
+
+	return inst
+}
+
+func (inst *instrumentation) roundTrip(next http.RoundTripper, req *http.Request) (*http.Response, error) {
+	method := strings.TrimPrefix(req.URL.Path, "/")
+	start := time.Now()
+
+	ctx, span := inst.tracer.Start(req.Context(), "pcloud "+method, trace.WithSpanKind(trace.SpanKindClient))
+	span.SetAttributes(MethodKey.String(method))
+
+	resp, err := next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		inst.end(ctx, span, method, start, req.ContentLength, 0, -1, true)
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+
+	resp.Body = &bodyObserver{
+		ReadCloser: resp.Body,
+		header:     resp.Header,
+		onClose: func(received int64, result int) {
+			failed := result != 0 || resp.StatusCode != http.StatusOK
+			inst.end(ctx, span, method, start, req.ContentLength, received, result, failed)
+		},
+	}
+
+	return resp, nil
+}
+
+// end ends the span and records the metrics of a request.
+// result is the pCloud result code, or -1 when no response was received.
+func (inst *instrumentation) end(ctx context.Context, span trace.Span, method string, start time.Time, sent, received int64, result int, failed bool) {
+	if sent < 0 {
+		sent = 0
+	}
+
+	attrs := []attribute.KeyValue{MethodKey.String(method)}
+	if result >= 0 {
+		attrs = append(attrs, ResultKey.Int(result))
+	}
+	set := metric.WithAttributes(attrs...)
+
+	inst.requests.Add(ctx, 1, set)
+	inst.duration.Record(ctx, time.Since(start).Seconds(), set)
+	inst.bytesSent.Add(ctx, sent, set)
+	inst.bytesReceived.Add(ctx, received, set)
+
+	if failed {
+		inst.errors.Add(ctx, 1, set)
+	}
+
+	if result > 0 {
+		span.SetStatus(codes.Error, "pCloud result "+strconv.Itoa(result))
+	}
+
+	span.SetAttributes(BytesSentKey.Int64(sent), BytesReceivedKey.Int64(received))
+	if result >= 0 {
+		span.SetAttributes(ResultKey.Int(result))
+	}
+	span.End()
+}
+
+// resultPattern extracts the pCloud result code from a JSON response.
+// pCloud places "result" first in its responses so it is found in the head of the body.
+var resultPattern = regexp.MustCompile(`"result"\s*:\s*(\d+)`)
+
+// headLen is the size of the head of the body of a JSON response that is inspected for the
+// result code.
+const headLen = 64
+
+// bodyObserver counts the bytes of the body of a response and extracts its pCloud result code.
+type bodyObserver struct {
+	io.ReadCloser
+	header   http.Header
+	head     []byte
+	received int64
+	onClose  func(received int64, result int)
+	closed   bool
+}
+
+func (b *bodyObserver) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.received += int64(n)
+
+	if len(b.head) < headLen {
+		b.head = append(b.head, p[:min(n, headLen-len(b.head))]...)
+	}
+
+	return n, err
+}
+
+func (b *bodyObserver) Close() error {
+	err := b.ReadCloser.Close()
+
+	if !b.closed {
+		b.closed = true
+		b.onClose(b.received, b.result())
+	}
+
+	return err
+}
+
+// result returns the pCloud result code of the response.
+func (b *bodyObserver) result() int {
+	// binary responses report errors with the X-Error header.
+	if xerr := b.header.Get("X-Error"); xerr != "" {
+		if code, err := strconv.Atoi(xerr); err == nil {
+			return code
+		}
+	}
+
+	if !strings.HasPrefix(b.header.Get("Content-Type"), "application/json") {
+		return 0
+	}
+
+	m := resultPattern.FindSubmatch(bytes.TrimSpace(b.head))
+	if m == nil {
+		return 0
+	}
+
+	code, _ := strconv.Atoi(string(m[1]))
+
+	return code
+}
@@ -0,0 +1,73 @@
+package otel_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+	"github.com/seborama/pcloud-sdk/sdk/otel"
+)
+
+func TestInterceptor(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/stat" {
+			fmt.Fprint(w, `{"result": 2009, "error": "File not found."}`)
+			return
+		}
+		fmt.Fprint(w, `{"result": 0, "metadata": {"name": "/", "isfolder": true}}`)
+	}))
+	defer srv.Close()
+
+	spans := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	pcc := sdk.NewClient(
+		srv.Client(),
+		sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")),
+		sdk.WithInterceptor(otel.Interceptor(otel.WithTracerProvider(tp), otel.WithMeterProvider(mp))),
+	)
+
+	_, err := pcc.List(context.Background(), sdk.ByID(sdk.RootFolderID), sdk.ListOptions{})
+	require.NoError(t, err)
+
+	_, err = pcc.Stat(context.Background(), sdk.ByID(1))
+	require.Error(t, err)
+
+	ended := spans.Ended()
+	require.Len(t, ended, 2)
+	assert.Equal(t, "pcloud listfolder", ended[0].Name())
+	assert.Contains(t, ended[0].Attributes(), otel.ResultKey.Int(0))
+	assert.Equal(t, "pcloud stat", ended[1].Name())
+	assert.Contains(t, ended[1].Attributes(), otel.ResultKey.Int(2009))
+	assert.Equal(t, "pCloud result 2009", ended[1].Status().Description)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+
+	counts := map[string]int64{}
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		if sum, ok := m.Data.(metricdata.Sum[int64]); ok {
+			for _, dp := range sum.DataPoints {
+				counts[m.Name] += dp.Value
+			}
+		}
+	}
+	assert.EqualValues(t, 2, counts["pcloud.client.requests"])
+	assert.EqualValues(t, 1, counts["pcloud.client.errors"])
+	assert.Positive(t, counts["pcloud.client.bytes_received"])
+}
@@ -0,0 +1,209 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Pipeline executes a batch of calls.
+// When the Client uses the binary protocol (see BinAPITransport), the calls are pipelined:
+// they are all sent over the connection without waiting for the response to each before
+// sending the next. Each call is tagged with an id (see WithGlobalOptionID) and its response
+// is delivered to the Future of the call. This saves one round trip per call, which is a
+// considerable win for bulk metadata operations.
+// With other transports, the calls are executed one after the other.
+//
+// Like the other calls, the calls of a Pipeline are logged (see WithLogger) and invalidate the
+// metadata cache (see WithMetadataCache). Over the binary protocol however, they are sent
+// together: they are not retried (see WithRetryPolicy), they do not go through the
+// interceptors of the Client (see WithInterceptor) and their responses are not captured (see
+// WithCallCaptureResponse).
+//
+// A Pipeline is not safe for concurrent use.
+type Pipeline struct {
+	c     *Client
+	calls []*Future
+}
+
+// Future is the eventual result of a call added to a Pipeline.
+type Future struct {
+	method string
+	query  url.Values
+	done   chan struct{}
+	strict bool // see WithStrictDecoding
+
+	contentType string
+	body        []byte
+	err         error
+}
+
+// NewPipeline creates a new, empty Pipeline.
+func (c *Client) NewPipeline() *Pipeline {
+	return &Pipeline{c: c}
+}
+
+// Call adds a call of pCloud method `method` with parameters params to the pipeline.
+// The call is only sent by Exec.
+func (p *Pipeline) Call(method string, params url.Values, opts ...ClientOption) *Future {
+	_, q := toQuery(context.Background(), opts...)
+
+	for k, v := range params {
+		q[k] = append(q[k], v...)
+	}
+
+	// the id is the index of the call in the pipeline.
+	q.Set("id", strconv.Itoa(len(p.calls)))
+
+	f := &Future{method: method, query: q, done: make(chan struct{}), strict: p.c.strictDecoding}
+	p.calls = append(p.calls, f)
+
+	return f
+}
+
+// Exec sends the calls of the pipeline and waits for their responses.
+// It returns an error when the pipeline could not be executed, in which case the calls that
+// did not complete fail with the same error. The errors of individual calls are reported by
+// their Future.
+// The pipeline is empty once Exec returns.
+func (p *Pipeline) Exec(ctx context.Context) error {
+	calls := p.calls
+	p.calls = nil
+
+	var err error
+	if p.c.binapi != nil {
+		err = p.execBinAPI(ctx, calls)
+	} else {
+		for _, f := range calls {
+			f.contentType, f.body, f.err = p.c.do(ctx, http.MethodGet, f.method, f.query, "application/json", nil, nil)
+			if f.err == nil {
+				p.c.observeMutation(f.method, f.query, f.body)
+			}
+			close(f.done)
+		}
+	}
+
+	for _, f := range calls {
+		select {
+		case <-f.done:
+		default:
+			f.err = err
+			close(f.done)
+		}
+	}
+
+	return err
+}
+
+func (p *Pipeline) execBinAPI(ctx context.Context, calls []*Future) error {
+	msgs := make([][]byte, len(calls))
+
+	for i, f := range calls {
+		p.c.addAuth(f.query)
+
+		msg, err := encodeBinRequest(f.method, f.query, nil)
+		if err != nil {
+			return err
+		}
+		msgs[i] = msg
+	}
+
+	if p.c.limiter != nil {
+		for range calls {
+			err := p.c.limiter.wait(ctx)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+		}
+	}
+
+	t := p.c.binapi
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	start := time.Now()
+
+	resps, err := t.exchangeAll(ctx, msgs)
+	if err != nil {
+		t.closeConn()
+		return err
+	}
+
+	// a call may only complete once: the responses that do not match a call still pending
+	// mean that the connection is out of step with the calls.
+	completed := make([]bool, len(calls))
+
+	for _, resp := range resps {
+		i, err := strconv.Atoi(resp.id)
+		if err != nil || i < 0 || i >= len(calls) {
+			t.closeConn()
+			return errors.Errorf("binapi: response with unexpected id: '%s'", resp.id)
+		}
+
+		if completed[i] {
+			t.closeConn()
+			return errors.Errorf("binapi: duplicate response for id: '%s'", resp.id)
+		}
+		completed[i] = true
+
+		f := calls[i]
+		f.contentType, f.body = resp.contentType, resp.body
+		p.c.logCall(ctx, f.method, f.query, start, 1, f.body, nil)
+		p.c.observeMutation(f.method, f.query, f.body)
+		close(f.done)
+	}
+
+	return nil
+}
+
+// Done returns a channel that is closed when the call has completed.
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// Decode waits for the call to complete and unmarshals its response into out, which should
+// embed the fields that all pCloud responses share, for instance:
+//
+//	var r struct {
+//		Result   int
+//		Error    string
+//		Metadata *sdk.Metadata
+//	}
+//
+// pCloud errors are returned as an *Error.
+func (f *Future) Decode(out interface{}) error {
+	<-f.done
+
+	if f.err != nil {
+		return f.err
+	}
+
+	if !strings.HasPrefix(f.contentType, "application/json") {
+		return errors.Errorf("call '%s' did not return JSON but '%s'", f.method, f.contentType)
+	}
+
+	r := &result{}
+
+	err := parseResult(f.body, nil, r)
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(f.body, out)
+	if err != nil {
+		return errors.Wrap(err, "unmarshal")
+	}
+
+	if f.strict {
+		return checkUnknownFields(f.body, out)
+	}
+
+	return nil
+}
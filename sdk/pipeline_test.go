@@ -0,0 +1,157 @@
+package sdk_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+type statResult struct {
+	Result   int
+	Error    string
+	Metadata *sdk.Metadata
+}
+
+func TestPipeline_BinAPI(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close() //nolint:errcheck
+
+	const calls = 5
+
+	go func() {
+		defer server.Close() //nolint:errcheck
+
+		rd := bufio.NewReader(server)
+
+		// read all the requests before responding: this only works if they are pipelined.
+		var reqs []binRequest
+		for i := 0; i < calls; i++ {
+			reqs = append(reqs, readBinRequest(t, rd))
+		}
+
+		// respond in reverse order to exercise the demultiplexing of the responses.
+		for i := len(reqs) - 1; i >= 0; i-- {
+			req := reqs[i]
+
+			if req.params["fileid"] == "3" {
+				_, _ = server.Write(binResponse(binHash(
+					binString("result"), binNumber(2009),
+					binString("error"), binString("File not found."),
+					binString("id"), binString(req.params["id"]),
+				)))
+				continue
+			}
+
+			_, _ = server.Write(binResponse(binHash(
+				binString("result"), binNumber(0),
+				binString("id"), binString(req.params["id"]),
+				binString("metadata"), binHash(
+					binString("name"), binString("file-"+req.params["fileid"]),
+				),
+			)))
+		}
+	}()
+
+	transport := sdk.NewBinAPITransport("binapi.test:443")
+	transport.DialContext = func(context.Context, string, string) (net.Conn, error) {
+		return client, nil
+	}
+
+	pcc := sdk.NewClient(&http.Client{Transport: transport})
+
+	p := pcc.NewPipeline()
+
+	var futures []*sdk.Future
+	for i := 0; i < calls; i++ {
+		futures = append(futures, p.Call("stat", url.Values{"fileid": []string{fmt.Sprint(i)}}))
+	}
+
+	require.NoError(t, p.Exec(context.Background()))
+
+	for i, f := range futures {
+		r := statResult{}
+		err := f.Decode(&r)
+
+		if i == 3 {
+			require.Error(t, err)
+			assert.True(t, sdk.IsNotFound(err))
+			continue
+		}
+
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("file-%d", i), r.Metadata.Name)
+	}
+}
+
+func TestPipeline_BinAPI_DuplicateID(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close() //nolint:errcheck
+
+	go func() {
+		defer server.Close() //nolint:errcheck
+
+		rd := bufio.NewReader(server)
+		readBinRequest(t, rd)
+		readBinRequest(t, rd)
+
+		// both responses are tagged with the id of the first call.
+		for i := 0; i < 2; i++ {
+			_, _ = server.Write(binResponse(binHash(
+				binString("result"), binNumber(0),
+				binString("id"), binString("0"),
+				binString("metadata"), binHash(binString("name"), binString("file-0")),
+			)))
+		}
+	}()
+
+	transport := sdk.NewBinAPITransport("binapi.test:443")
+	transport.DialContext = func(context.Context, string, string) (net.Conn, error) {
+		return client, nil
+	}
+
+	pcc := sdk.NewClient(&http.Client{Transport: transport})
+
+	p := pcc.NewPipeline()
+	f0 := p.Call("stat", url.Values{"fileid": []string{"0"}})
+	f1 := p.Call("stat", url.Values{"fileid": []string{"1"}})
+
+	err := p.Exec(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "duplicate response for id: '0'")
+
+	r := statResult{}
+	require.NoError(t, f0.Decode(&r))
+	assert.Equal(t, "file-0", r.Metadata.Name)
+
+	assert.Equal(t, err, f1.Decode(&r))
+}
+
+func TestPipeline_HTTP(t *testing.T) {
+	srv, calls := newFlakyServer(t, 0, 0)
+
+	pcc := sdk.NewClient(srv.Client(), sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")))
+
+	p := pcc.NewPipeline()
+	f1 := p.Call("listfolder", url.Values{"folderid": []string{"0"}})
+	f2 := p.Call("listfolder", url.Values{"folderid": []string{"0"}})
+
+	require.NoError(t, p.Exec(context.Background()))
+
+	for _, f := range []*sdk.Future{f1, f2} {
+		r := statResult{}
+		require.NoError(t, f.Decode(&r))
+		assert.Equal(t, "/", r.Metadata.Name)
+	}
+
+	assert.EqualValues(t, 2, *calls)
+}
@@ -0,0 +1,215 @@
+// Package prometheus exposes metrics about the file transfers made with the pCloud SDK as a
+// prometheus.Collector.
+//
+// It is a separate Go module so that programs that do not use Prometheus do not depend on it.
+// Plug it into a Client with sdk.WithInterceptor and register it with Prometheus:
+//
+//	tm := prometheus.NewTransferMetrics()
+//	prom.MustRegister(tm)
+//	pcc := sdk.NewClient(nil, sdk.WithInterceptor(tm.Interceptor()))
+package prometheus
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+// uploadMethods and downloadMethods are the pCloud methods that transfer file data.
+var (
+	uploadMethods = map[string]bool{
+		"file_write": true,
+		"uploadfile": true,
+	}
+
+	downloadMethods = map[string]bool{
+		"file_read":        true,
+		"file_pread":       true,
+		"file_pread_ifmod": true,
+	}
+)
+
+// TransferMetrics collects metrics about the file transfers made by the Clients it intercepts.
+// It implements prometheus.Collector.
+//
+// A transfer is a request that carries file data, such as file_write or file_read. Each
+// attempt of a call that is retried (see sdk.RetryPolicy) is a separate transfer.
+type TransferMetrics struct {
+	uploadedBytes   atomic.Int64
+	downloadedBytes atomic.Int64
+	active          atomic.Int64
+	retries         atomic.Int64
+
+	failuresLock sync.Mutex
+	failures     map[string]int64
+
+	uploadedDesc   *prom.Desc
+	downloadedDesc *prom.Desc
+	activeDesc     *prom.Desc
+	retriesDesc    *prom.Desc
+	failuresDesc   *prom.Desc
+}
+
+// NewTransferMetrics creates a new initialised TransferMetrics.
+func NewTransferMetrics() *TransferMetrics {
+	return &TransferMetrics{
+		failures: map[string]int64{},
+
+		uploadedDesc:   prom.NewDesc("pcloud_transfer_uploaded_bytes_total", "Bytes of file data uploaded to pCloud.", nil, nil),
+		downloadedDesc: prom.NewDesc("pcloud_transfer_downloaded_bytes_total", "Bytes of file data downloaded from pCloud.", nil, nil),
+		activeDesc:     prom.NewDesc("pcloud_transfers_active", "Number of transfers in progress.", nil, nil),
+		retriesDesc:    prom.NewDesc("pcloud_transfer_retries_total", "Number of transfers that were retries of a failed attempt.", nil, nil),
+		failuresDesc: prom.NewDesc("pcloud_transfer_failures_total",
+			`Number of transfers that failed, by error code: the pCloud result code, "http_<status>" or "network".`,
+			[]string{"code"}, nil),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (tm *TransferMetrics) Describe(ch chan<- *prom.Desc) {
+	ch <- tm.uploadedDesc
+	ch <- tm.downloadedDesc
+	ch <- tm.activeDesc
+	ch <- tm.retriesDesc
+	ch <- tm.failuresDesc
+}
+
+// Collect implements prometheus.Collector.
+func (tm *TransferMetrics) Collect(ch chan<- prom.Metric) {
+	ch <- prom.MustNewConstMetric(tm.uploadedDesc, prom.CounterValue, float64(tm.uploadedBytes.Load()))
+	ch <- prom.MustNewConstMetric(tm.downloadedDesc, prom.CounterValue, float64(tm.downloadedBytes.Load()))
+	ch <- prom.MustNewConstMetric(tm.activeDesc, prom.GaugeValue, float64(tm.active.Load()))
+	ch <- prom.MustNewConstMetric(tm.retriesDesc, prom.CounterValue, float64(tm.retries.Load()))
+
+	tm.failuresLock.Lock()
+	defer tm.failuresLock.Unlock()
+
+	for code, n := range tm.failures {
+		ch <- prom.MustNewConstMetric(tm.failuresDesc, prom.CounterValue, float64(n), code)
+	}
+}
+
+// Interceptor returns an sdk.Interceptor that records the transfers made by a Client.
+// The same TransferMetrics may be used with several Clients.
+func (tm *TransferMetrics) Interceptor() sdk.Interceptor {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return sdk.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return tm.roundTrip(next, req)
+		})
+	}
+}
+
+func (tm *TransferMetrics) roundTrip(next http.RoundTripper, req *http.Request) (*http.Response, error) {
+	method := strings.TrimPrefix(req.URL.Path, "/")
+
+	upload, download := uploadMethods[method], downloadMethods[method]
+	if !upload && !download {
+		return next.RoundTrip(req)
+	}
+
+	tm.active.Add(1)
+
+	if sdk.RequestAttempt(req.Context()) > 1 {
+		tm.retries.Add(1)
+	}
+
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		tm.active.Add(-1)
+		tm.fail("network")
+		return nil, err
+	}
+
+	resp.Body = &bodyObserver{
+		ReadCloser: resp.Body,
+		header:     resp.Header,
+		onClose: func(received int64, result int) {
+			defer tm.active.Add(-1)
+
+			switch {
+			case resp.StatusCode != http.StatusOK:
+				tm.fail("http_" + strconv.Itoa(resp.StatusCode))
+			case result != 0:
+				tm.fail(strconv.Itoa(result))
+			case upload && req.ContentLength > 0:
+				tm.uploadedBytes.Add(req.ContentLength)
+			case download:
+				tm.downloadedBytes.Add(received)
+			}
+		},
+	}
+
+	return resp, nil
+}
+
+func (tm *TransferMetrics) fail(code string) {
+	tm.failuresLock.Lock()
+	defer tm.failuresLock.Unlock()
+
+	tm.failures[code]++
+}
+
+// resultPattern extracts the pCloud result code from a JSON response.
+// pCloud places "result" first in its responses so it is found in the head of the body.
+var resultPattern = regexp.MustCompile(`"result"\s*:\s*(\d+)`)
+
+// headLen is the size of the head of the body of a JSON response that is inspected for the
+// result code.
+const headLen = 64
+
+// bodyObserver counts the bytes of the body of a response and extracts its pCloud result code.
+type bodyObserver struct {
+	io.ReadCloser
+	header   http.Header
+	head     []byte
+	received int64
+	onClose  func(received int64, result int)
+	closed   bool
+}
+
+func (b *bodyObserver) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.received += int64(n)
+
+	if len(b.head) < headLen {
+		b.head = append(b.head, p[:min(n, headLen-len(b.head))]...)
+	}
+
+	return n, err
+}
+
+func (b *bodyObserver) Close() error {
+	err := b.ReadCloser.Close()
+
+	if !b.closed {
+		b.closed = true
+		b.onClose(b.received, b.result())
+	}
+
+	return err
+}
+
+// result returns the pCloud result code of the response.
+func (b *bodyObserver) result() int {
+	if !strings.HasPrefix(b.header.Get("Content-Type"), "application/json") {
+		return 0
+	}
+
+	m := resultPattern.FindSubmatch(bytes.TrimSpace(b.head))
+	if m == nil {
+		return 0
+	}
+
+	code, _ := strconv.Atoi(string(m[1]))
+
+	return code
+}
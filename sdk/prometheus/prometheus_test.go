@@ -0,0 +1,85 @@
+package prometheus_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+	"github.com/seborama/pcloud-sdk/sdk/prometheus"
+)
+
+func TestTransferMetrics(t *testing.T) {
+	var reads int32
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/file_write":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"result": 0, "bytes": 5}`)
+
+		case "/file_read":
+			if atomic.AddInt32(&reads, 1) == 1 {
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(w, `{"result": 5000, "error": "Internal error. Try again later."}`)
+				return
+			}
+			w.Header().Set("Content-Type", "application/octet-stream")
+			fmt.Fprint(w, "0123456789")
+
+		case "/file_close":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"result": 1007, "error": "Invalid or closed file descriptor."}`)
+		}
+	}))
+	defer srv.Close()
+
+	policy := sdk.DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+
+	tm := prometheus.NewTransferMetrics()
+	pcc := sdk.NewClient(
+		srv.Client(),
+		sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")),
+		sdk.WithInterceptor(tm.Interceptor()),
+	)
+
+	ctx := context.Background()
+
+	_, err := pcc.FileWrite(ctx, 1, []byte("hello"))
+	require.NoError(t, err)
+
+	data, err := pcc.FileRead(ctx, 1, 10, sdk.WithCallRetryPolicy(policy))
+	require.NoError(t, err)
+	require.Len(t, data, 10)
+
+	// not a transfer.
+	require.Error(t, pcc.FileClose(ctx, 1))
+
+	expected := `
+# HELP pcloud_transfer_downloaded_bytes_total Bytes of file data downloaded from pCloud.
+# TYPE pcloud_transfer_downloaded_bytes_total counter
+pcloud_transfer_downloaded_bytes_total 10
+# HELP pcloud_transfer_failures_total Number of transfers that failed, by error code: the pCloud result code, "http_<status>" or "network".
+# TYPE pcloud_transfer_failures_total counter
+pcloud_transfer_failures_total{code="5000"} 1
+# HELP pcloud_transfer_retries_total Number of transfers that were retries of a failed attempt.
+# TYPE pcloud_transfer_retries_total counter
+pcloud_transfer_retries_total 1
+# HELP pcloud_transfer_uploaded_bytes_total Bytes of file data uploaded to pCloud.
+# TYPE pcloud_transfer_uploaded_bytes_total counter
+pcloud_transfer_uploaded_bytes_total 5
+# HELP pcloud_transfers_active Number of transfers in progress.
+# TYPE pcloud_transfers_active gauge
+pcloud_transfers_active 0
+`
+	require.NoError(t, testutil.CollectAndCompare(tm, strings.NewReader(expected)))
+}
@@ -0,0 +1,226 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// PubLink is a public link to a file or a folder, as listed by ListPubLinks.
+type PubLink struct {
+	LinkID    uint64
+	Code      string
+	Link      string
+	ShortLink string
+	Created   time.Time
+	Modified  time.Time
+	// Expires is zero when the link does not expire.
+	Expires      time.Time
+	MaxDownloads uint64
+	MaxTraffic   uint64
+	Downloads    uint64
+	Traffic      uint64
+	// HasPassword is set when the link is protected by a password (see UpdatePubLink).
+	HasPassword bool
+	Metadata    *Metadata
+}
+
+// UnmarshalJSON decodes the PubLink, with its times in any of the formats of APITime.
+func (p *PubLink) UnmarshalJSON(data []byte) error {
+	type pubLink PubLink
+	times := struct {
+		*pubLink
+		Created  APITime
+		Modified APITime
+		Expires  APITime
+	}{pubLink: (*pubLink)(p)}
+
+	err := json.Unmarshal(data, &times)
+	if err != nil {
+		return err
+	}
+
+	p.Created, p.Modified, p.Expires = times.Created.Time, times.Modified.Time, times.Expires.Time
+
+	return nil
+}
+
+// PubLinkResult contains the properties returned from an API call to CreateFilePubLink and
+// CreateFolderPubLink.
+type PubLinkResult struct {
+	result
+	LinkID    uint64
+	Code      string
+	Link      string
+	ShortLink string
+}
+
+// PubLinksList contains the public links returned by ListPubLinks.
+type PubLinksList struct {
+	result
+	PubLinks []*PubLink
+}
+
+// PubLinkOptions are the optional parameters of CreateFilePubLink and CreateFolderPubLink,
+// which limit the link.
+type PubLinkOptions struct {
+	// Expire, if not zero, is the time at which the link stops working.
+	Expire time.Time
+
+	// MaxDownloads, if not 0, is the number of downloads that the link allows.
+	MaxDownloads uint64
+
+	// MaxTraffic, if not 0, is the traffic in bytes that the link allows.
+	MaxTraffic uint64
+
+	// ShortLink if set, a short link is created too.
+	ShortLink bool
+}
+
+// options returns the ClientOption's of the parameters of o that are set.
+func (o PubLinkOptions) options() []ClientOption {
+	var opts []ClientOption
+
+	opts = append(opts, WithExpire(o.Expire))
+
+	if o.MaxDownloads > 0 {
+		opts = append(opts, WithMaxDownloads(o.MaxDownloads))
+	}
+
+	if o.MaxTraffic > 0 {
+		opts = append(opts, WithMaxTraffic(o.MaxTraffic))
+	}
+
+	if o.ShortLink {
+		opts = append(opts, WithShortLink())
+	}
+
+	return opts
+}
+
+// CreateFilePubLink creates and returns a public link to a file.
+// https://docs.pcloud.com/methods/public_links/getfilepublink.html
+func (c *Client) CreateFilePubLink(ctx context.Context, file FileRef, o PubLinkOptions, opts ...ClientOption) (*PubLinkResult, error) {
+	ctx, q := toQuery(ctx, withOptions(opts, o)...)
+	file.setFile(q)
+
+	return c.getPubLink(ctx, "getfilepublink", q)
+}
+
+// CreateFolderPubLink creates and returns a public link to a folder.
+// https://docs.pcloud.com/methods/public_links/getfolderpublink.html
+func (c *Client) CreateFolderPubLink(ctx context.Context, folder FolderRef, o PubLinkOptions, opts ...ClientOption) (*PubLinkResult, error) {
+	ctx, q := toQuery(ctx, withOptions(opts, o)...)
+	folder.setFolder(q, "")
+
+	return c.getPubLink(ctx, "getfolderpublink", q)
+}
+
+// GetFilePubLink is CreateFilePubLink with its optional parameters passed positionally.
+// https://docs.pcloud.com/methods/public_links/getfilepublink.html
+//
+// Deprecated: use CreateFilePubLink.
+func (c *Client) GetFilePubLink(ctx context.Context, file FileRef, expireOpt time.Time, maxDownloadsOpt, maxTrafficOpt uint64, shortLinkOpt bool, opts ...ClientOption) (*PubLinkResult, error) {
+	return c.CreateFilePubLink(ctx, file, PubLinkOptions{Expire: expireOpt, MaxDownloads: maxDownloadsOpt, MaxTraffic: maxTrafficOpt, ShortLink: shortLinkOpt}, opts...)
+}
+
+// GetFolderPubLink is CreateFolderPubLink with its optional parameters passed positionally.
+// https://docs.pcloud.com/methods/public_links/getfolderpublink.html
+//
+// Deprecated: use CreateFolderPubLink.
+func (c *Client) GetFolderPubLink(ctx context.Context, folder FolderRef, expireOpt time.Time, maxDownloadsOpt, maxTrafficOpt uint64, shortLinkOpt bool, opts ...ClientOption) (*PubLinkResult, error) {
+	return c.CreateFolderPubLink(ctx, folder, PubLinkOptions{Expire: expireOpt, MaxDownloads: maxDownloadsOpt, MaxTraffic: maxTrafficOpt, ShortLink: shortLinkOpt}, opts...)
+}
+
+func (c *Client) getPubLink(ctx context.Context, method string, q url.Values) (*PubLinkResult, error) {
+	pl := &PubLinkResult{}
+
+	err := c.parseAPIOutput(pl)(c.get(ctx, method, q))
+	if err != nil {
+		return nil, err
+	}
+
+	return pl, nil
+}
+
+// ListPubLinks returns the public links of the user.
+// https://docs.pcloud.com/methods/public_links/listpublinks.html
+func (c *Client) ListPubLinks(ctx context.Context, opts ...ClientOption) (*PubLinksList, error) {
+	ctx, q := toQuery(ctx, opts...)
+
+	pl := &PubLinksList{}
+
+	err := c.parseAPIOutput(pl)(c.get(ctx, "listpublinks", q))
+	if err != nil {
+		return nil, err
+	}
+
+	return pl, nil
+}
+
+// UpdatePubLinkOptions are the changes of UpdatePubLink. The other properties of the link are
+// left as they are.
+type UpdatePubLinkOptions struct {
+	// Expire, if not zero, is the time at which the link stops working.
+	Expire time.Time
+
+	// Password, if not empty, protects the link with a password (a premium feature).
+	Password string
+}
+
+// options returns the ClientOption's of the parameters of o that are set.
+func (o UpdatePubLinkOptions) options() []ClientOption {
+	var opts []ClientOption
+
+	opts = append(opts, WithExpire(o.Expire))
+
+	if o.Password != "" {
+		opts = append(opts, WithLinkPassword(o.Password))
+	}
+
+	return opts
+}
+
+// UpdatePubLink modifies the public link linkID.
+// https://docs.pcloud.com/methods/public_links/changepublink.html
+func (c *Client) UpdatePubLink(ctx context.Context, linkID uint64, o UpdatePubLinkOptions, opts ...ClientOption) error {
+	ctx, q := toQuery(ctx, withOptions(opts, o)...)
+
+	q.Add("linkid", fmt.Sprintf("%d", linkID))
+
+	r := &result{}
+
+	err := c.parseAPIOutput(r)(c.get(ctx, "changepublink", q))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ChangePubLink is UpdatePubLink with its optional parameters passed positionally.
+// https://docs.pcloud.com/methods/public_links/changepublink.html
+//
+// Deprecated: use UpdatePubLink.
+func (c *Client) ChangePubLink(ctx context.Context, linkID uint64, expireOpt time.Time, passwordOpt string, opts ...ClientOption) error {
+	return c.UpdatePubLink(ctx, linkID, UpdatePubLinkOptions{Expire: expireOpt, Password: passwordOpt}, opts...)
+}
+
+// DeletePubLink deletes the public link linkID: the link stops working.
+// https://docs.pcloud.com/methods/public_links/deletepublink.html
+func (c *Client) DeletePubLink(ctx context.Context, linkID uint64, opts ...ClientOption) error {
+	ctx, q := toQuery(ctx, opts...)
+
+	q.Add("linkid", fmt.Sprintf("%d", linkID))
+
+	r := &result{}
+
+	err := c.parseAPIOutput(r)(c.get(ctx, "deletepublink", q))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
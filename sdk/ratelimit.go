@@ -0,0 +1,95 @@
+package sdk
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// WithRateLimit limits the rate of the API calls made by the Client to rps requests per second
+// on average, with bursts of up to burst requests.
+// This prevents bulk operations, such as walking a large tree, from tripping pCloud's abuse
+// protection.
+// When pCloud responds with a throttling error (see IsRateLimited) and the call is retried
+// (see RetryPolicy), all the calls of the Client are held back for the retry delay, which
+// increases progressively with each attempt.
+func WithRateLimit(rps float64, burst int) Option {
+	return func(c *Client) {
+		if rps <= 0 {
+			c.limiter = nil
+			return
+		}
+
+		if burst < 1 {
+			burst = 1
+		}
+
+		c.limiter = &rateLimiter{
+			rate:   rps,
+			burst:  float64(burst),
+			tokens: float64(burst),
+			last:   time.Now(),
+		}
+	}
+}
+
+// rateLimiter is a token bucket rate limiter.
+type rateLimiter struct {
+	lock      sync.Mutex
+	rate      float64 // tokens per second
+	burst     float64
+	tokens    float64
+	last      time.Time
+	notBefore time.Time // see throttle
+}
+
+// wait blocks until a request may be sent, or ctx is done.
+func (rl *rateLimiter) wait(ctx context.Context) error {
+	for {
+		d := rl.reserve()
+		if d == 0 {
+			return nil
+		}
+
+		err := sleep(ctx, d)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// reserve takes a token and returns 0 if one is available. Otherwise, it returns how long to
+// wait before trying again.
+func (rl *rateLimiter) reserve() time.Duration {
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+
+	now := time.Now()
+
+	if now.Before(rl.notBefore) {
+		return rl.notBefore.Sub(now)
+	}
+
+	rl.tokens += now.Sub(rl.last).Seconds() * rl.rate
+	if rl.tokens > rl.burst {
+		rl.tokens = rl.burst
+	}
+	rl.last = now
+
+	if rl.tokens >= 1 {
+		rl.tokens--
+		return 0
+	}
+
+	return time.Duration((1 - rl.tokens) / rl.rate * float64(time.Second))
+}
+
+// throttle holds back all requests for d.
+func (rl *rateLimiter) throttle(d time.Duration) {
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+
+	if nb := time.Now().Add(d); nb.After(rl.notBefore) {
+		rl.notBefore = nb
+	}
+}
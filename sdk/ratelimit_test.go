@@ -0,0 +1,51 @@
+package sdk_test
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+func TestRateLimit(t *testing.T) {
+	srv, calls := newFlakyServer(t, 0, 0)
+
+	pcc := sdk.NewClient(srv.Client(), sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")), sdk.WithRateLimit(50, 2))
+
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		_, err := pcc.List(context.Background(), sdk.ByID(sdk.RootFolderID), sdk.ListOptions{})
+		require.NoError(t, err)
+	}
+
+	// the first 2 calls use the burst, the next 4 are spaced by 20ms each.
+	assert.GreaterOrEqual(t, time.Since(start), 70*time.Millisecond)
+	assert.EqualValues(t, 6, atomic.LoadInt32(calls))
+}
+
+func TestRateLimit_Throttled(t *testing.T) {
+	srv, calls := newFlakyServer(t, 1, sdk.ErrTooManyLoginsForIP)
+
+	policy := retryTestPolicy()
+	policy.BaseDelay = 50 * time.Millisecond
+	policy.MaxDelay = 50 * time.Millisecond
+
+	pcc := sdk.NewClient(
+		srv.Client(),
+		sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")),
+		sdk.WithRateLimit(1000, 10),
+		sdk.WithRetryPolicy(policy),
+	)
+
+	start := time.Now()
+	_, err := pcc.List(context.Background(), sdk.ByID(sdk.RootFolderID), sdk.ListOptions{})
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 25*time.Millisecond)
+	assert.EqualValues(t, 2, atomic.LoadInt32(calls))
+}
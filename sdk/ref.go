@@ -0,0 +1,129 @@
+package sdk
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Ref references a file or a folder: by its path, by its ID, or by the ID of its parent folder
+// and its name. It is the FolderRef and the FileRef that the methods of the SDK take:
+//
+//	c.List(ctx, sdk.ByPath("/Documents"), sdk.ListOptions{})
+//	c.Stat(ctx, sdk.ByID(fileID))
+//	c.RenameFile(ctx, sdk.ByPath("/a.txt"), sdk.ByIDName(folderID, "b.txt"))
+//
+// The methods document the references that pCloud accepts for each of their arguments.
+type Ref struct {
+	by   refKind
+	path string
+	id   uint64
+	name string
+}
+
+type refKind int
+
+const (
+	refByPath refKind = iota
+	refByID
+	refByIDName
+)
+
+// ByPath references a file or a folder by its path.
+func ByPath(path string) Ref {
+	return Ref{by: refByPath, path: path}
+}
+
+// ByID references a folder by its folderid, or a file by its fileid. The destination of a file,
+// which is a folder, is referenced by its folderid.
+func ByID(id uint64) Ref {
+	return Ref{by: refByID, id: id}
+}
+
+// ByIDName references a file or a folder by the folderid of its parent folder and its name. The
+// destination of a file is its folder and its new name, or its folder alone when name is empty.
+func ByIDName(folderID uint64, name string) Ref {
+	return Ref{by: refByIDName, id: folderID, name: name}
+}
+
+// setFolder implements FolderRef.
+func (r Ref) setFolder(q url.Values, prefix string) {
+	switch r.by {
+	case refByID:
+		q.Set(prefix+"folderid", fmt.Sprintf("%d", r.id))
+	case refByIDName:
+		q.Set(prefix+"folderid", fmt.Sprintf("%d", r.id))
+		q.Set(prefix+"name", r.name)
+	default:
+		q.Set(prefix+"path", r.path)
+	}
+}
+
+// setFile implements FileRef.
+func (r Ref) setFile(q url.Values) {
+	switch r.by {
+	case refByID:
+		q.Set("fileid", fmt.Sprintf("%d", r.id))
+	case refByIDName:
+		q.Set("folderid", fmt.Sprintf("%d", r.id))
+		q.Set("name", r.name)
+	default:
+		q.Set("path", r.path)
+	}
+}
+
+// FolderRef references a folder, or the destination of a file, such as ByPath("/Documents") or
+// ByID(folderID). See Ref.
+type FolderRef interface {
+	// setFolder sets the parameters of the folder in q, with their names prefixed with prefix:
+	// "to" for a destination.
+	setFolder(q url.Values, prefix string)
+}
+
+// FileRef references a file, such as ByPath("/Documents/a.txt") or ByID(fileID). See Ref.
+type FileRef interface {
+	// setFile sets the parameters of the file in q.
+	setFile(q url.Values)
+}
+
+// The deprecated builders of the parameters that reference files and folders are references
+// too, in the positions they were built for: their parameters are set with the prefix of the
+// position, in place of their "to" prefix.
+
+// setFolder implements FolderRef.
+func (f T1PathOrFolderID) setFolder(q url.Values, prefix string) { setBuilt(q, prefix, f) }
+
+// setFolder implements FolderRef.
+func (f T2PathOrFolderIDName) setFolder(q url.Values, prefix string) { setBuilt(q, prefix, f) }
+
+// setFolder implements FolderRef.
+func (f ToT1PathOrFolderID) setFolder(q url.Values, prefix string) { setBuilt(q, prefix, f) }
+
+// setFolder implements FolderRef.
+func (f ToT2PathOrFolderIDOrFolderIDName) setFolder(q url.Values, prefix string) {
+	setBuilt(q, prefix, f)
+}
+
+// setFolder implements FolderRef.
+func (f ToT3PathOrFolderIDName) setFolder(q url.Values, prefix string) { setBuilt(q, prefix, f) }
+
+// setFile implements FileRef.
+func (f T3PathOrFileID) setFile(q url.Values) { setBuilt(q, "", f) }
+
+// setFile implements FileRef.
+func (f T4PathOrFileIDOrFolderIDName) setFile(q url.Values) { setBuilt(q, "", f) }
+
+// setBuilt sets in q the parameters set by the deprecated builder set, if any, with their names
+// prefixed with prefix in place of their "to" prefix.
+func setBuilt(q url.Values, prefix string, set func(q url.Values)) {
+	if set == nil {
+		return
+	}
+
+	built := url.Values{}
+	set(built)
+
+	for k, v := range built {
+		q[prefix+strings.TrimPrefix(k, "to")] = v
+	}
+}
@@ -0,0 +1,58 @@
+package sdk
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRef(t *testing.T) {
+	folder := func(ref FolderRef, prefix string) url.Values {
+		q := url.Values{}
+		ref.setFolder(q, prefix)
+		return q
+	}
+	file := func(ref FileRef) url.Values {
+		q := url.Values{}
+		ref.setFile(q)
+		return q
+	}
+
+	assert.Equal(t, url.Values{"path": {"/a"}}, folder(ByPath("/a"), ""))
+	assert.Equal(t, url.Values{"tofolderid": {"12"}}, folder(ByID(12), "to"))
+	assert.Equal(t, url.Values{"tofolderid": {"12"}, "toname": {"b.txt"}}, folder(ByIDName(12, "b.txt"), "to"))
+
+	assert.Equal(t, url.Values{"path": {"/a.txt"}}, file(ByPath("/a.txt")))
+	assert.Equal(t, url.Values{"fileid": {"34"}}, file(ByID(34)))
+	assert.Equal(t, url.Values{"folderid": {"12"}, "name": {"b.txt"}}, file(ByIDName(12, "b.txt")))
+}
+
+func TestRef_DeprecatedBuilders(t *testing.T) {
+	folder := func(ref FolderRef, prefix string) url.Values {
+		q := url.Values{}
+		ref.setFolder(q, prefix)
+		return q
+	}
+	file := func(ref FileRef) url.Values {
+		q := url.Values{}
+		ref.setFile(q)
+		return q
+	}
+
+	// the builders are references in the positions they were built for.
+	assert.Equal(t, url.Values{"folderid": {"12"}}, folder(T1FolderByID(12), ""))
+	assert.Equal(t, url.Values{"folderid": {"12"}, "name": {"new"}}, folder(T2FolderByIDName(12, "new"), ""))
+	assert.Equal(t, url.Values{"topath": {"/b"}}, folder(ToT1FolderByPath("/b"), "to"))
+	assert.Equal(t, url.Values{"tofolderid": {"12"}, "toname": {"c"}}, folder(ToT2FolderByIDName(12, "c"), "to"))
+	assert.Equal(t, url.Values{"tofolderid": {"12"}, "toname": {""}}, folder(ToT3ByIDName(12, ""), "to"))
+	assert.Equal(t, url.Values{"fileid": {"34"}}, file(T3FileByID(34)))
+	assert.Equal(t, url.Values{"folderid": {"12"}, "name": {"d.txt"}}, file(T4FileByFolderIDName(12, "d.txt")))
+
+	// and elsewhere, with the prefix of the position.
+	assert.Equal(t, url.Values{"topath": {"/a"}}, folder(T1FolderByPath("/a"), "to"))
+	assert.Equal(t, url.Values{"path": {"/b"}}, folder(ToT1FolderByPath("/b"), ""))
+
+	// a nil builder sets nothing, like an optional argument.
+	assert.Empty(t, folder(T1PathOrFolderID(nil), ""))
+}
@@ -0,0 +1,190 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// RetryPolicy defines how failed API calls are attempted again.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts of a call, including the first one.
+	// A value of 0 or 1 disables retries.
+	MaxAttempts int
+
+	// BaseDelay is the delay before the first retry. It doubles with every subsequent retry.
+	// A random jitter of up to half the delay is subtracted from it.
+	BaseDelay time.Duration
+
+	// MaxDelay caps the delay between two attempts.
+	MaxDelay time.Duration
+
+	// RetryableCodes are the pCloud result codes that cause a call to be retried.
+	// When nil, the result codes for which IsRetryable returns true are retried.
+	RetryableCodes []int
+
+	// RetryableHTTPStatuses are the HTTP statuses that cause a call to be retried.
+	RetryableHTTPStatuses []int
+
+	// RetryNetworkErrors causes calls that fail with network errors to be retried.
+	RetryNetworkErrors bool
+}
+
+// DefaultRetryPolicy returns the retry policy the Client uses unless WithRetryPolicy is
+// specified.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    10 * time.Second,
+		RetryableHTTPStatuses: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+		RetryNetworkErrors: true,
+	}
+}
+
+// NoRetryPolicy returns a retry policy that disables retries.
+func NoRetryPolicy() RetryPolicy {
+	return RetryPolicy{}
+}
+
+// WithRetryPolicy sets the retry policy the Client applies to idempotent API calls.
+// See also WithCallRetryPolicy.
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(c *Client) {
+		c.retryPolicy = p
+	}
+}
+
+// WithCallRetryPolicy overrides the retry policy of the Client for a single call.
+// Unlike the policy of the Client, it applies even if the call is not idempotent: use with
+// care on calls that modify data.
+func WithCallRetryPolicy(p RetryPolicy) ClientOption {
+	return func(co *callOptions) {
+		co.retryPolicy = &p
+	}
+}
+
+// idempotentEndpoints lists the endpoints that may be called repeatedly with the same
+// outcome. Only these are retried by the retry policy of the Client.
+// Notably, file_read and file_write are not idempotent because they move the file offset.
+var idempotentEndpoints = map[string]bool{
+	"checksumfile":            true,
+	"createfolderifnotexists": true,
+	"currentserver":           true,
+	"diff":                    true,
+	"file_checksum":           true,
+	"file_pread":              true,
+	"file_pread_ifmod":        true,
+	"file_pwrite":             true,
+	"file_size":               true,
+	"getapiserver":            true,
+	"getfilehistory":          true,
+	"getfilelink":             true,
+	"getip":                   true,
+	"listfolder":              true,
+	"listtokens":              true,
+	"stat":                    true,
+	"supportedlanguages":      true,
+	"userinfo":                true,
+	"userinvites":             true,
+}
+
+// retryPolicyFor returns the retry policy that applies to a call to endpoint with the parameters
+// query. A call with getauth is not idempotent, whatever its endpoint: each call issues a new
+// auth token.
+func (c *Client) retryPolicyFor(ctx context.Context, endpoint string, query url.Values) RetryPolicy {
+	if p := callOptionsFromContext(ctx).retryPolicy; p != nil {
+		return *p
+	}
+
+	if idempotentEndpoints[endpoint] && query.Get("getauth") == "" {
+		return c.retryPolicy
+	}
+
+	return NoRetryPolicy()
+}
+
+// shouldRetry returns true when a call that returned err, or a body with a retryable result
+// code, should be attempted again.
+func (p RetryPolicy) shouldRetry(body []byte, err error) bool {
+	if err != nil {
+		var se *statusError
+		if errors.As(err, &se) {
+			return containsInt(p.RetryableHTTPStatuses, se.statusCode)
+		}
+
+		return p.RetryNetworkErrors && IsRetryable(err)
+	}
+
+	return p.shouldRetryCode(resultCode(body))
+}
+
+// shouldRetryCode returns true when a call that returned the result code should be attempted
+// again.
+func (p RetryPolicy) shouldRetryCode(code int) bool {
+	if code == 0 {
+		return false
+	}
+
+	if p.RetryableCodes == nil {
+		return IsRetryable(&Error{Code: code})
+	}
+
+	return containsInt(p.RetryableCodes, code)
+}
+
+// resultCode returns the pCloud result code contained in body, or 0 if there is none.
+func resultCode(body []byte) int {
+	r := result{}
+	if json.Unmarshal(body, &r) != nil {
+		return 0
+	}
+	return r.Result
+}
+
+// delay returns the delay before attempt number attempt (starting at 1 for the first retry).
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	d := p.BaseDelay << (attempt - 1)
+	if d <= 0 || (p.MaxDelay > 0 && d > p.MaxDelay) {
+		d = p.MaxDelay
+	}
+
+	if d <= 0 {
+		return 0
+	}
+
+	// nolint:gosec
+	return d - time.Duration(rand.Int63n(int64(d)/2+1))
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+func containsInt(values []int, v int) bool {
+	for _, e := range values {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package sdk_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+// newFlakyServer returns a server that responds to the first `failures` requests with
+// the pCloud result code `code` and succeeds afterwards.
+func newFlakyServer(t *testing.T, failures int32, code int) (*httptest.Server, *int32) {
+	t.Helper()
+
+	var calls int32
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if atomic.AddInt32(&calls, 1) <= failures {
+			fmt.Fprintf(w, `{"result": %d, "error": "flaky"}`, code)
+			return
+		}
+		fmt.Fprint(w, `{"result": 0, "metadata": {"name": "/", "isfolder": true}}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &calls
+}
+
+func retryTestPolicy() sdk.RetryPolicy {
+	p := sdk.DefaultRetryPolicy()
+	p.BaseDelay = time.Millisecond
+	p.MaxDelay = 5 * time.Millisecond
+	return p
+}
+
+func TestRetry_IdempotentCall(t *testing.T) {
+	srv, calls := newFlakyServer(t, 2, sdk.ErrInternalError)
+
+	pcc := sdk.NewClient(srv.Client(), sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")), sdk.WithRetryPolicy(retryTestPolicy()))
+
+	lf, err := pcc.List(context.Background(), sdk.ByID(sdk.RootFolderID), sdk.ListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "/", lf.Metadata.Name)
+	assert.EqualValues(t, 3, atomic.LoadInt32(calls))
+}
+
+func TestRetry_GivesUp(t *testing.T) {
+	srv, calls := newFlakyServer(t, 10, sdk.ErrInternalError)
+
+	pcc := sdk.NewClient(srv.Client(), sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")), sdk.WithRetryPolicy(retryTestPolicy()))
+
+	_, err := pcc.List(context.Background(), sdk.ByID(sdk.RootFolderID), sdk.ListOptions{})
+	require.Error(t, err)
+	assert.Equal(t, sdk.ErrInternalError, sdk.ErrorCode(err))
+	assert.EqualValues(t, 3, atomic.LoadInt32(calls))
+}
+
+func TestRetry_NonRetryableCode(t *testing.T) {
+	srv, calls := newFlakyServer(t, 1, sdk.ErrDirectoryNotExists)
+
+	pcc := sdk.NewClient(srv.Client(), sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")), sdk.WithRetryPolicy(retryTestPolicy()))
+
+	_, err := pcc.List(context.Background(), sdk.ByID(sdk.RootFolderID), sdk.ListOptions{})
+	require.Error(t, err)
+	assert.True(t, sdk.IsNotFound(err))
+	assert.EqualValues(t, 1, atomic.LoadInt32(calls))
+}
+
+func TestRetry_NonIdempotentCall(t *testing.T) {
+	srv, calls := newFlakyServer(t, 1, sdk.ErrInternalError)
+
+	pcc := sdk.NewClient(srv.Client(), sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")), sdk.WithRetryPolicy(retryTestPolicy()))
+
+	_, err := pcc.DeleteFolder(context.Background(), sdk.ByID(1))
+	require.Error(t, err)
+	assert.EqualValues(t, 1, atomic.LoadInt32(calls))
+
+	// the policy can be forced per call.
+	_, err = pcc.DeleteFolder(context.Background(), sdk.ByID(1), sdk.WithCallRetryPolicy(retryTestPolicy()))
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, atomic.LoadInt32(calls))
+}
+
+func TestRetry_GetAuth(t *testing.T) {
+	srv, calls := newFlakyServer(t, 1, sdk.ErrInternalError)
+
+	pcc := sdk.NewClient(srv.Client(), sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")), sdk.WithRetryPolicy(retryTestPolicy()))
+
+	// a call with getauth issues a new token each time: it is not retried.
+	_, err := pcc.UserInfo(context.Background(), sdk.WithGlobalOptionGetAuth())
+	require.Error(t, err)
+	assert.EqualValues(t, 1, atomic.LoadInt32(calls))
+
+	srv, calls = newFlakyServer(t, 1, sdk.ErrInternalError)
+
+	pcc = sdk.NewClient(srv.Client(), sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")), sdk.WithRetryPolicy(retryTestPolicy()))
+
+	_, err = pcc.UserInfo(context.Background())
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, atomic.LoadInt32(calls))
+}
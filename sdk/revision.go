@@ -0,0 +1,76 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Revision is a previous version of the contents of a file, as listed by ListRevisions.
+type Revision struct {
+	RevisionID uint64
+	Size       uint64
+	Hash       uint64
+	Created    time.Time
+}
+
+// UnmarshalJSON decodes the Revision, with its times in any of the formats of APITime.
+func (r *Revision) UnmarshalJSON(data []byte) error {
+	type revision Revision
+	times := struct {
+		*revision
+		Created APITime
+	}{revision: (*revision)(r)}
+
+	err := json.Unmarshal(data, &times)
+	if err != nil {
+		return err
+	}
+
+	r.Created = times.Created.Time
+
+	return nil
+}
+
+// RevisionsList contains the revisions of a file returned by ListRevisions.
+type RevisionsList struct {
+	result
+	Metadata  *Metadata
+	Revisions []*Revision
+}
+
+// ListRevisions lists the revisions of a file: pCloud keeps the previous versions of the
+// contents of the files that are overwritten, for a time that depends on the plan of the account.
+// https://docs.pcloud.com/methods/revisions/listrevisions.html
+func (c *Client) ListRevisions(ctx context.Context, file FileRef, opts ...ClientOption) (*RevisionsList, error) {
+	ctx, q := toQuery(ctx, opts...)
+	file.setFile(q)
+
+	rl := &RevisionsList{}
+
+	err := c.parseAPIOutput(rl)(c.get(ctx, "listrevisions", q))
+	if err != nil {
+		return nil, err
+	}
+
+	return rl, nil
+}
+
+// RevertRevision replaces the contents of a file with its revision revisionID. The current
+// contents become a revision.
+// https://docs.pcloud.com/methods/revisions/revertrevision.html
+func (c *Client) RevertRevision(ctx context.Context, file FileRef, revisionID uint64, opts ...ClientOption) (*FileResult, error) {
+	ctx, q := toQuery(ctx, opts...)
+	file.setFile(q)
+	q.Add("revisionid", fmt.Sprintf("%d", revisionID))
+
+	r := &FileResult{}
+
+	err := c.parseAPIOutput(r)(c.get(ctx, "revertrevision", q))
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
@@ -0,0 +1,680 @@
+// Package sdkmock provides a mock of sdk.Cloud, based on testify's mock package, so that the
+// code that depends on the SDK may be unit tested without pCloud:
+//
+//	m := &sdkmock.Cloud{}
+//	m.On("Stat", ctx, mock.Anything, []sdk.ClientOption(nil)).Return(&sdk.FileResult{}, nil)
+//
+// As with testify's mocks, the variadic options of the methods are matched as a single slice.
+// Methods may return nil for any result.
+package sdkmock
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+// Cloud is a mock of sdk.Cloud.
+type Cloud struct {
+	mock.Mock
+}
+
+var _ sdk.Cloud = (*Cloud)(nil)
+
+// AuthToken implements sdk.Cloud.
+func (m *Cloud) AuthToken() string {
+	args := m.Called()
+	return args.String(0)
+}
+
+// ChangeMail implements sdk.Cloud.
+func (m *Cloud) ChangeMail(ctx context.Context, password, code string, opts ...sdk.ClientOption) (*sdk.ChangeMailResult, error) {
+	args := m.Called(ctx, password, code, opts)
+	r0, _ := args.Get(0).(*sdk.ChangeMailResult)
+	return r0, args.Error(1)
+}
+
+// ChangePassword implements sdk.Cloud.
+func (m *Cloud) ChangePassword(ctx context.Context, oldPassword, newPassword string, opts ...sdk.ClientOption) error {
+	args := m.Called(ctx, oldPassword, newPassword, opts)
+	return args.Error(0)
+}
+
+// GetDigest implements sdk.Cloud.
+func (m *Cloud) GetDigest(ctx context.Context, opts ...sdk.ClientOption) (*sdk.Digest, error) {
+	args := m.Called(ctx, opts)
+	r0, _ := args.Get(0).(*sdk.Digest)
+	return r0, args.Error(1)
+}
+
+// Invite implements sdk.Cloud.
+func (m *Cloud) Invite(ctx context.Context, mail, messageOpt, nameOpt string, opts ...sdk.ClientOption) error {
+	args := m.Called(ctx, mail, messageOpt, nameOpt, opts)
+	return args.Error(0)
+}
+
+// ListInvites implements sdk.Cloud.
+func (m *Cloud) ListInvites(ctx context.Context, opts ...sdk.ClientOption) (*sdk.InvitesList, error) {
+	args := m.Called(ctx, opts)
+	r0, _ := args.Get(0).(*sdk.InvitesList)
+	return r0, args.Error(1)
+}
+
+// ListTokens implements sdk.Cloud.
+func (m *Cloud) ListTokens(ctx context.Context, opts ...sdk.ClientOption) (*sdk.TokensList, error) {
+	args := m.Called(ctx, opts)
+	r0, _ := args.Get(0).(*sdk.TokensList)
+	return r0, args.Error(1)
+}
+
+// Login implements sdk.Cloud.
+func (m *Cloud) Login(ctx context.Context, otpCodeOpt string, opts ...sdk.ClientOption) error {
+	args := m.Called(ctx, otpCodeOpt, opts)
+	return args.Error(0)
+}
+
+// LoginDigest implements sdk.Cloud.
+func (m *Cloud) LoginDigest(ctx context.Context, otpCodeOpt, username, password string, opts ...sdk.ClientOption) error {
+	args := m.Called(ctx, otpCodeOpt, username, password, opts)
+	return args.Error(0)
+}
+
+// LoginV1 implements sdk.Cloud.
+func (m *Cloud) LoginV1(ctx context.Context, opts ...sdk.ClientOption) error {
+	args := m.Called(ctx, opts)
+	return args.Error(0)
+}
+
+// Logout implements sdk.Cloud.
+func (m *Cloud) Logout(ctx context.Context, opts ...sdk.ClientOption) (*sdk.LogoutResult, error) {
+	args := m.Called(ctx, opts)
+	r0, _ := args.Get(0).(*sdk.LogoutResult)
+	return r0, args.Error(1)
+}
+
+// LostPassword implements sdk.Cloud.
+func (m *Cloud) LostPassword(ctx context.Context, mail string, opts ...sdk.ClientOption) error {
+	args := m.Called(ctx, mail, opts)
+	return args.Error(0)
+}
+
+// OAuth2Token implements sdk.Cloud.
+func (m *Cloud) OAuth2Token(ctx context.Context, clientID, clientSecret, code string, opts ...sdk.ClientOption) (*sdk.OAuth2AccessToken, error) {
+	args := m.Called(ctx, clientID, clientSecret, code, opts)
+	r0, _ := args.Get(0).(*sdk.OAuth2AccessToken)
+	return r0, args.Error(1)
+}
+
+// Register implements sdk.Cloud.
+func (m *Cloud) Register(ctx context.Context, mail, password string, termsAccepted bool, languageOpt string, referrerOpt uint64, opts ...sdk.ClientOption) (*sdk.RegisterResult, error) {
+	args := m.Called(ctx, mail, password, termsAccepted, languageOpt, referrerOpt, opts)
+	r0, _ := args.Get(0).(*sdk.RegisterResult)
+	return r0, args.Error(1)
+}
+
+// ResetPassword implements sdk.Cloud.
+func (m *Cloud) ResetPassword(ctx context.Context, code, newPassword string, opts ...sdk.ClientOption) error {
+	args := m.Called(ctx, code, newPassword, opts)
+	return args.Error(0)
+}
+
+// SendChangeMail implements sdk.Cloud.
+func (m *Cloud) SendChangeMail(ctx context.Context, newMailOpt, codeOpt string, opts ...sdk.ClientOption) error {
+	args := m.Called(ctx, newMailOpt, codeOpt, opts)
+	return args.Error(0)
+}
+
+// SendVerificationEmail implements sdk.Cloud.
+func (m *Cloud) SendVerificationEmail(ctx context.Context, opts ...sdk.ClientOption) error {
+	args := m.Called(ctx, opts)
+	return args.Error(0)
+}
+
+// VerifyEmail implements sdk.Cloud.
+func (m *Cloud) VerifyEmail(ctx context.Context, code string, opts ...sdk.ClientOption) (*sdk.VerifyEmailResult, error) {
+	args := m.Called(ctx, code, opts)
+	r0, _ := args.Get(0).(*sdk.VerifyEmailResult)
+	return r0, args.Error(1)
+}
+
+// BatchCopyFiles implements sdk.Cloud.
+func (m *Cloud) BatchCopyFiles(ctx context.Context, ops []sdk.BatchFileOp, opts ...sdk.BatchOption) []sdk.BatchResult {
+	args := m.Called(ctx, ops, opts)
+	r0, _ := args.Get(0).([]sdk.BatchResult)
+	return r0
+}
+
+// BatchDeleteFiles implements sdk.Cloud.
+func (m *Cloud) BatchDeleteFiles(ctx context.Context, files []sdk.FileRef, opts ...sdk.BatchOption) []sdk.BatchResult {
+	args := m.Called(ctx, files, opts)
+	r0, _ := args.Get(0).([]sdk.BatchResult)
+	return r0
+}
+
+// BatchDeleteFolders implements sdk.Cloud.
+func (m *Cloud) BatchDeleteFolders(ctx context.Context, folders []sdk.FolderRef, opts ...sdk.BatchOption) []sdk.BatchResult {
+	args := m.Called(ctx, folders, opts)
+	r0, _ := args.Get(0).([]sdk.BatchResult)
+	return r0
+}
+
+// BatchMoveFiles implements sdk.Cloud.
+func (m *Cloud) BatchMoveFiles(ctx context.Context, ops []sdk.BatchFileOp, opts ...sdk.BatchOption) []sdk.BatchResult {
+	args := m.Called(ctx, ops, opts)
+	r0, _ := args.Get(0).([]sdk.BatchResult)
+	return r0
+}
+
+// CryptoChangeUserPrivate implements sdk.Cloud.
+func (m *Cloud) CryptoChangeUserPrivate(ctx context.Context, privateKey, code, hintOpt string, opts ...sdk.ClientOption) error {
+	args := m.Called(ctx, privateKey, code, hintOpt, opts)
+	return args.Error(0)
+}
+
+// CryptoGetFileKey implements sdk.Cloud.
+func (m *Cloud) CryptoGetFileKey(ctx context.Context, fileID uint64, opts ...sdk.ClientOption) (*sdk.CryptoKey, error) {
+	args := m.Called(ctx, fileID, opts)
+	r0, _ := args.Get(0).(*sdk.CryptoKey)
+	return r0, args.Error(1)
+}
+
+// CryptoGetFolderKey implements sdk.Cloud.
+func (m *Cloud) CryptoGetFolderKey(ctx context.Context, folderID uint64, opts ...sdk.ClientOption) (*sdk.CryptoKey, error) {
+	args := m.Called(ctx, folderID, opts)
+	r0, _ := args.Get(0).(*sdk.CryptoKey)
+	return r0, args.Error(1)
+}
+
+// CryptoGetUserHint implements sdk.Cloud.
+func (m *Cloud) CryptoGetUserHint(ctx context.Context, opts ...sdk.ClientOption) (*sdk.CryptoUserHint, error) {
+	args := m.Called(ctx, opts)
+	r0, _ := args.Get(0).(*sdk.CryptoUserHint)
+	return r0, args.Error(1)
+}
+
+// CryptoGetUserKeys implements sdk.Cloud.
+func (m *Cloud) CryptoGetUserKeys(ctx context.Context, opts ...sdk.ClientOption) (*sdk.CryptoUserKeys, error) {
+	args := m.Called(ctx, opts)
+	r0, _ := args.Get(0).(*sdk.CryptoUserKeys)
+	return r0, args.Error(1)
+}
+
+// CryptoSendChangeUserPrivate implements sdk.Cloud.
+func (m *Cloud) CryptoSendChangeUserPrivate(ctx context.Context, opts ...sdk.ClientOption) error {
+	args := m.Called(ctx, opts)
+	return args.Error(0)
+}
+
+// CryptoSetUserKeys implements sdk.Cloud.
+func (m *Cloud) CryptoSetUserKeys(ctx context.Context, privateKey, publicKey, hintOpt string, opts ...sdk.ClientOption) error {
+	args := m.Called(ctx, privateKey, publicKey, hintOpt, opts)
+	return args.Error(0)
+}
+
+// ChecksumFile implements sdk.Cloud.
+func (m *Cloud) ChecksumFile(ctx context.Context, file sdk.FileRef, opts ...sdk.ClientOption) (*sdk.FileChecksum, error) {
+	args := m.Called(ctx, file, opts)
+	r0, _ := args.Get(0).(*sdk.FileChecksum)
+	return r0, args.Error(1)
+}
+
+// CopyFile implements sdk.Cloud.
+func (m *Cloud) CopyFile(ctx context.Context, file sdk.FileRef, destination sdk.FolderRef, noOverOpt bool, mTime, cTime time.Time, opts ...sdk.ClientOption) (*sdk.FileResult, error) {
+	args := m.Called(ctx, file, destination, noOverOpt, mTime, cTime, opts)
+	r0, _ := args.Get(0).(*sdk.FileResult)
+	return r0, args.Error(1)
+}
+
+// CopyFileTo implements sdk.Cloud.
+func (m *Cloud) CopyFileTo(ctx context.Context, file sdk.FileRef, destination sdk.FolderRef, o sdk.CopyFileOptions, opts ...sdk.ClientOption) (*sdk.FileResult, error) {
+	args := m.Called(ctx, file, destination, o, opts)
+	r0, _ := args.Get(0).(*sdk.FileResult)
+	return r0, args.Error(1)
+}
+
+// DeleteFile implements sdk.Cloud.
+func (m *Cloud) DeleteFile(ctx context.Context, file sdk.FileRef, opts ...sdk.ClientOption) (*sdk.FileResult, error) {
+	args := m.Called(ctx, file, opts)
+	r0, _ := args.Get(0).(*sdk.FileResult)
+	return r0, args.Error(1)
+}
+
+// RenameFile implements sdk.Cloud.
+func (m *Cloud) RenameFile(ctx context.Context, file sdk.FileRef, destination sdk.FolderRef, opts ...sdk.ClientOption) (*sdk.FileResult, error) {
+	args := m.Called(ctx, file, destination, opts)
+	r0, _ := args.Get(0).(*sdk.FileResult)
+	return r0, args.Error(1)
+}
+
+// Stat implements sdk.Cloud.
+func (m *Cloud) Stat(ctx context.Context, file sdk.FileRef, opts ...sdk.ClientOption) (*sdk.FileResult, error) {
+	args := m.Called(ctx, file, opts)
+	r0, _ := args.Get(0).(*sdk.FileResult)
+	return r0, args.Error(1)
+}
+
+// UploadFile implements sdk.Cloud.
+func (m *Cloud) UploadFile(ctx context.Context, folder sdk.FolderRef, files map[string]*os.File, noPartialOpt bool, progressHashOpt string, renameIfExistsOpt bool, mTimeOpt, cTimeOpt time.Time, opts ...sdk.ClientOption) (*sdk.FileUpload, error) {
+	args := m.Called(ctx, folder, files, noPartialOpt, progressHashOpt, renameIfExistsOpt, mTimeOpt, cTimeOpt, opts)
+	r0, _ := args.Get(0).(*sdk.FileUpload)
+	return r0, args.Error(1)
+}
+
+// UploadFiles implements sdk.Cloud.
+func (m *Cloud) UploadFiles(ctx context.Context, folder sdk.FolderRef, files map[string]*os.File, o sdk.UploadOptions, opts ...sdk.ClientOption) (*sdk.FileUpload, error) {
+	args := m.Called(ctx, folder, files, o, opts)
+	r0, _ := args.Get(0).(*sdk.FileUpload)
+	return r0, args.Error(1)
+}
+
+// FileChecksum implements sdk.Cloud.
+func (m *Cloud) FileChecksum(ctx context.Context, fd, count, offset uint64, opts ...sdk.ClientOption) (*sdk.PFileChecksum, error) {
+	args := m.Called(ctx, fd, count, offset, opts)
+	r0, _ := args.Get(0).(*sdk.PFileChecksum)
+	return r0, args.Error(1)
+}
+
+// FileClose implements sdk.Cloud.
+func (m *Cloud) FileClose(ctx context.Context, fd uint64, opts ...sdk.ClientOption) error {
+	args := m.Called(ctx, fd, opts)
+	return args.Error(0)
+}
+
+// FileOpen implements sdk.Cloud.
+func (m *Cloud) FileOpen(ctx context.Context, flags uint64, file sdk.FileRef, opts ...sdk.ClientOption) (*sdk.File, error) {
+	args := m.Called(ctx, flags, file, opts)
+	r0, _ := args.Get(0).(*sdk.File)
+	return r0, args.Error(1)
+}
+
+// FilePRead implements sdk.Cloud.
+func (m *Cloud) FilePRead(ctx context.Context, fd, count, offset uint64, opts ...sdk.ClientOption) ([]byte, error) {
+	args := m.Called(ctx, fd, count, offset, opts)
+	r0, _ := args.Get(0).([]byte)
+	return r0, args.Error(1)
+}
+
+// FilePReadIfMod implements sdk.Cloud.
+func (m *Cloud) FilePReadIfMod(ctx context.Context, fd, count, offset uint64, checksum sdk.T5SHA1OrMD5, opts ...sdk.ClientOption) ([]byte, error) {
+	args := m.Called(ctx, fd, count, offset, checksum, opts)
+	r0, _ := args.Get(0).([]byte)
+	return r0, args.Error(1)
+}
+
+// FileRead implements sdk.Cloud.
+func (m *Cloud) FileRead(ctx context.Context, fd, count uint64, opts ...sdk.ClientOption) ([]byte, error) {
+	args := m.Called(ctx, fd, count, opts)
+	r0, _ := args.Get(0).([]byte)
+	return r0, args.Error(1)
+}
+
+// FileSeek implements sdk.Cloud.
+func (m *Cloud) FileSeek(ctx context.Context, fd, offset uint64, whenceOpt sdk.Whence, opts ...sdk.ClientOption) (*sdk.FileSeek, error) {
+	args := m.Called(ctx, fd, offset, whenceOpt, opts)
+	r0, _ := args.Get(0).(*sdk.FileSeek)
+	return r0, args.Error(1)
+}
+
+// FileWrite implements sdk.Cloud.
+func (m *Cloud) FileWrite(ctx context.Context, fd uint64, data []byte, opts ...sdk.ClientOption) (*sdk.FileDataTransfer, error) {
+	args := m.Called(ctx, fd, data, opts)
+	r0, _ := args.Get(0).(*sdk.FileDataTransfer)
+	return r0, args.Error(1)
+}
+
+// CopyFolder implements sdk.Cloud.
+func (m *Cloud) CopyFolder(ctx context.Context, folder sdk.FolderRef, toFolder sdk.FolderRef, noOverOpt, skipExisting, copyContentOnly bool, opts ...sdk.ClientOption) (*sdk.FSList, error) {
+	args := m.Called(ctx, folder, toFolder, noOverOpt, skipExisting, copyContentOnly, opts)
+	r0, _ := args.Get(0).(*sdk.FSList)
+	return r0, args.Error(1)
+}
+
+// CopyFolderTo implements sdk.Cloud.
+func (m *Cloud) CopyFolderTo(ctx context.Context, folder sdk.FolderRef, toFolder sdk.FolderRef, o sdk.CopyFolderOptions, opts ...sdk.ClientOption) (*sdk.FSList, error) {
+	args := m.Called(ctx, folder, toFolder, o, opts)
+	r0, _ := args.Get(0).(*sdk.FSList)
+	return r0, args.Error(1)
+}
+
+// CreateFolder implements sdk.Cloud.
+func (m *Cloud) CreateFolder(ctx context.Context, folder sdk.FolderRef, opts ...sdk.ClientOption) (*sdk.FSList, error) {
+	args := m.Called(ctx, folder, opts)
+	r0, _ := args.Get(0).(*sdk.FSList)
+	return r0, args.Error(1)
+}
+
+// CreateFolderIfNotExists implements sdk.Cloud.
+func (m *Cloud) CreateFolderIfNotExists(ctx context.Context, folder sdk.FolderRef, opts ...sdk.ClientOption) (*sdk.FSList, error) {
+	args := m.Called(ctx, folder, opts)
+	r0, _ := args.Get(0).(*sdk.FSList)
+	return r0, args.Error(1)
+}
+
+// DeleteFolder implements sdk.Cloud.
+func (m *Cloud) DeleteFolder(ctx context.Context, folder sdk.FolderRef, opts ...sdk.ClientOption) (*sdk.FSList, error) {
+	args := m.Called(ctx, folder, opts)
+	r0, _ := args.Get(0).(*sdk.FSList)
+	return r0, args.Error(1)
+}
+
+// DeleteFolderRecursive implements sdk.Cloud.
+func (m *Cloud) DeleteFolderRecursive(ctx context.Context, folder sdk.FolderRef, opts ...sdk.ClientOption) (*sdk.DeleteResult, error) {
+	args := m.Called(ctx, folder, opts)
+	r0, _ := args.Get(0).(*sdk.DeleteResult)
+	return r0, args.Error(1)
+}
+
+// List implements sdk.Cloud.
+func (m *Cloud) List(ctx context.Context, folder sdk.FolderRef, o sdk.ListOptions, opts ...sdk.ClientOption) (*sdk.FSList, error) {
+	args := m.Called(ctx, folder, o, opts)
+	r0, _ := args.Get(0).(*sdk.FSList)
+	return r0, args.Error(1)
+}
+
+// ListFolder implements sdk.Cloud.
+func (m *Cloud) ListFolder(ctx context.Context, folder sdk.FolderRef, recursiveOpt, showDeletedOpt, noFilesOpt, noSharesOpt bool, opts ...sdk.ClientOption) (*sdk.FSList, error) {
+	args := m.Called(ctx, folder, recursiveOpt, showDeletedOpt, noFilesOpt, noSharesOpt, opts)
+	r0, _ := args.Get(0).(*sdk.FSList)
+	return r0, args.Error(1)
+}
+
+// ListFolderFunc implements sdk.Cloud.
+func (m *Cloud) ListFolderFunc(ctx context.Context, folder sdk.FolderRef, recursiveOpt, showDeletedOpt, noFilesOpt, noSharesOpt bool, fn func(m *sdk.Metadata) error, opts ...sdk.ClientOption) error {
+	args := m.Called(ctx, folder, recursiveOpt, showDeletedOpt, noFilesOpt, noSharesOpt, fn, opts)
+	return args.Error(0)
+}
+
+// ListFunc implements sdk.Cloud.
+func (m *Cloud) ListFunc(ctx context.Context, folder sdk.FolderRef, o sdk.ListOptions, fn func(m *sdk.Metadata) error, opts ...sdk.ClientOption) error {
+	args := m.Called(ctx, folder, o, fn, opts)
+	return args.Error(0)
+}
+
+// RenameFolder implements sdk.Cloud.
+func (m *Cloud) RenameFolder(ctx context.Context, folder sdk.FolderRef, toFolder sdk.FolderRef, opts ...sdk.ClientOption) (*sdk.FSList, error) {
+	args := m.Called(ctx, folder, toFolder, opts)
+	r0, _ := args.Get(0).(*sdk.FSList)
+	return r0, args.Error(1)
+}
+
+// Call implements sdk.Cloud.
+func (m *Cloud) Call(ctx context.Context, method string, params url.Values, out interface{}, opts ...sdk.ClientOption) error {
+	args := m.Called(ctx, method, params, out, opts)
+	return args.Error(0)
+}
+
+// Changes implements sdk.Cloud.
+func (m *Cloud) Changes(ctx context.Context, diffID uint64, o sdk.ChangesOptions, opts ...sdk.ClientOption) (*sdk.DiffResult, error) {
+	args := m.Called(ctx, diffID, o, opts)
+	r0, _ := args.Get(0).(*sdk.DiffResult)
+	return r0, args.Error(1)
+}
+
+// ChangesFunc implements sdk.Cloud.
+func (m *Cloud) ChangesFunc(ctx context.Context, diffID uint64, o sdk.ChangesOptions, fn func(e *sdk.Entry) error, opts ...sdk.ClientOption) (uint64, error) {
+	args := m.Called(ctx, diffID, o, fn, opts)
+	r0, _ := args.Get(0).(uint64)
+	return r0, args.Error(1)
+}
+
+// CurrentServer implements sdk.Cloud.
+func (m *Cloud) CurrentServer(ctx context.Context, opts ...sdk.ClientOption) (*sdk.CurrentServerResult, error) {
+	args := m.Called(ctx, opts)
+	r0, _ := args.Get(0).(*sdk.CurrentServerResult)
+	return r0, args.Error(1)
+}
+
+// Diff implements sdk.Cloud.
+func (m *Cloud) Diff(ctx context.Context, diffID uint64, after time.Time, last uint64, block bool, limit uint64, opts ...sdk.ClientOption) (*sdk.DiffResult, error) {
+	args := m.Called(ctx, diffID, after, last, block, limit, opts)
+	r0, _ := args.Get(0).(*sdk.DiffResult)
+	return r0, args.Error(1)
+}
+
+// DiffFunc implements sdk.Cloud.
+func (m *Cloud) DiffFunc(ctx context.Context, diffID uint64, after time.Time, last uint64, block bool, limit uint64, fn func(e *sdk.Entry) error, opts ...sdk.ClientOption) (uint64, error) {
+	args := m.Called(ctx, diffID, after, last, block, limit, fn, opts)
+	r0, _ := args.Get(0).(uint64)
+	return r0, args.Error(1)
+}
+
+// Feedback implements sdk.Cloud.
+func (m *Cloud) Feedback(ctx context.Context, mail, reason, message string, opts ...sdk.ClientOption) error {
+	args := m.Called(ctx, mail, reason, message, opts)
+	return args.Error(0)
+}
+
+// GetAPIServer implements sdk.Cloud.
+func (m *Cloud) GetAPIServer(ctx context.Context, opts ...sdk.ClientOption) (*sdk.APIServerResult, error) {
+	args := m.Called(ctx, opts)
+	r0, _ := args.Get(0).(*sdk.APIServerResult)
+	return r0, args.Error(1)
+}
+
+// GetFileHistory implements sdk.Cloud.
+func (m *Cloud) GetFileHistory(ctx context.Context, fileID uint64, opts ...sdk.ClientOption) (*sdk.DiffResult, error) {
+	args := m.Called(ctx, fileID, opts)
+	r0, _ := args.Get(0).(*sdk.DiffResult)
+	return r0, args.Error(1)
+}
+
+// GetIP implements sdk.Cloud.
+func (m *Cloud) GetIP(ctx context.Context, opts ...sdk.ClientOption) (*sdk.IPResult, error) {
+	args := m.Called(ctx, opts)
+	r0, _ := args.Get(0).(*sdk.IPResult)
+	return r0, args.Error(1)
+}
+
+// SetLanguage implements sdk.Cloud.
+func (m *Cloud) SetLanguage(ctx context.Context, language string, opts ...sdk.ClientOption) error {
+	args := m.Called(ctx, language, opts)
+	return args.Error(0)
+}
+
+// SupportedLanguages implements sdk.Cloud.
+func (m *Cloud) SupportedLanguages(ctx context.Context, opts ...sdk.ClientOption) (*sdk.SupportedLanguages, error) {
+	args := m.Called(ctx, opts)
+	r0, _ := args.Get(0).(*sdk.SupportedLanguages)
+	return r0, args.Error(1)
+}
+
+// UserInfo implements sdk.Cloud.
+func (m *Cloud) UserInfo(ctx context.Context, opts ...sdk.ClientOption) (*sdk.UserInfo, error) {
+	args := m.Called(ctx, opts)
+	r0, _ := args.Get(0).(*sdk.UserInfo)
+	return r0, args.Error(1)
+}
+
+// ListNotifications implements sdk.Cloud.
+func (m *Cloud) ListNotifications(ctx context.Context, thumbSizeOpt string, opts ...sdk.ClientOption) (*sdk.NotificationsResult, error) {
+	args := m.Called(ctx, thumbSizeOpt, opts)
+	r0, _ := args.Get(0).(*sdk.NotificationsResult)
+	return r0, args.Error(1)
+}
+
+// MarkNotificationsRead implements sdk.Cloud.
+func (m *Cloud) MarkNotificationsRead(ctx context.Context, notificationID uint64, opts ...sdk.ClientOption) error {
+	args := m.Called(ctx, notificationID, opts)
+	return args.Error(0)
+}
+
+// NewPipeline implements sdk.Cloud.
+func (m *Cloud) NewPipeline() *sdk.Pipeline {
+	args := m.Called()
+	r0, _ := args.Get(0).(*sdk.Pipeline)
+	return r0
+}
+
+// ChangePubLink implements sdk.Cloud.
+func (m *Cloud) ChangePubLink(ctx context.Context, linkID uint64, expireOpt time.Time, passwordOpt string, opts ...sdk.ClientOption) error {
+	args := m.Called(ctx, linkID, expireOpt, passwordOpt, opts)
+	return args.Error(0)
+}
+
+// CreateFilePubLink implements sdk.Cloud.
+func (m *Cloud) CreateFilePubLink(ctx context.Context, file sdk.FileRef, o sdk.PubLinkOptions, opts ...sdk.ClientOption) (*sdk.PubLinkResult, error) {
+	args := m.Called(ctx, file, o, opts)
+	r0, _ := args.Get(0).(*sdk.PubLinkResult)
+	return r0, args.Error(1)
+}
+
+// CreateFolderPubLink implements sdk.Cloud.
+func (m *Cloud) CreateFolderPubLink(ctx context.Context, folder sdk.FolderRef, o sdk.PubLinkOptions, opts ...sdk.ClientOption) (*sdk.PubLinkResult, error) {
+	args := m.Called(ctx, folder, o, opts)
+	r0, _ := args.Get(0).(*sdk.PubLinkResult)
+	return r0, args.Error(1)
+}
+
+// DeletePubLink implements sdk.Cloud.
+func (m *Cloud) DeletePubLink(ctx context.Context, linkID uint64, opts ...sdk.ClientOption) error {
+	args := m.Called(ctx, linkID, opts)
+	return args.Error(0)
+}
+
+// GetFilePubLink implements sdk.Cloud.
+func (m *Cloud) GetFilePubLink(ctx context.Context, file sdk.FileRef, expireOpt time.Time, maxDownloadsOpt, maxTrafficOpt uint64, shortLinkOpt bool, opts ...sdk.ClientOption) (*sdk.PubLinkResult, error) {
+	args := m.Called(ctx, file, expireOpt, maxDownloadsOpt, maxTrafficOpt, shortLinkOpt, opts)
+	r0, _ := args.Get(0).(*sdk.PubLinkResult)
+	return r0, args.Error(1)
+}
+
+// GetFolderPubLink implements sdk.Cloud.
+func (m *Cloud) GetFolderPubLink(ctx context.Context, folder sdk.FolderRef, expireOpt time.Time, maxDownloadsOpt, maxTrafficOpt uint64, shortLinkOpt bool, opts ...sdk.ClientOption) (*sdk.PubLinkResult, error) {
+	args := m.Called(ctx, folder, expireOpt, maxDownloadsOpt, maxTrafficOpt, shortLinkOpt, opts)
+	r0, _ := args.Get(0).(*sdk.PubLinkResult)
+	return r0, args.Error(1)
+}
+
+// ListPubLinks implements sdk.Cloud.
+func (m *Cloud) ListPubLinks(ctx context.Context, opts ...sdk.ClientOption) (*sdk.PubLinksList, error) {
+	args := m.Called(ctx, opts)
+	r0, _ := args.Get(0).(*sdk.PubLinksList)
+	return r0, args.Error(1)
+}
+
+// UpdatePubLink implements sdk.Cloud.
+func (m *Cloud) UpdatePubLink(ctx context.Context, linkID uint64, o sdk.UpdatePubLinkOptions, opts ...sdk.ClientOption) error {
+	args := m.Called(ctx, linkID, o, opts)
+	return args.Error(0)
+}
+
+// ListRevisions implements sdk.Cloud.
+func (m *Cloud) ListRevisions(ctx context.Context, file sdk.FileRef, opts ...sdk.ClientOption) (*sdk.RevisionsList, error) {
+	args := m.Called(ctx, file, opts)
+	r0, _ := args.Get(0).(*sdk.RevisionsList)
+	return r0, args.Error(1)
+}
+
+// RevertRevision implements sdk.Cloud.
+func (m *Cloud) RevertRevision(ctx context.Context, file sdk.FileRef, revisionID uint64, opts ...sdk.ClientOption) (*sdk.FileResult, error) {
+	args := m.Called(ctx, file, revisionID, opts)
+	r0, _ := args.Get(0).(*sdk.FileResult)
+	return r0, args.Error(1)
+}
+
+// AcceptShare implements sdk.Cloud.
+func (m *Cloud) AcceptShare(ctx context.Context, shareRequestID uint64, nameOpt string, folderOpt sdk.FolderRef, opts ...sdk.ClientOption) error {
+	args := m.Called(ctx, shareRequestID, nameOpt, folderOpt, opts)
+	return args.Error(0)
+}
+
+// CancelShareRequest implements sdk.Cloud.
+func (m *Cloud) CancelShareRequest(ctx context.Context, shareRequestID uint64, opts ...sdk.ClientOption) error {
+	args := m.Called(ctx, shareRequestID, opts)
+	return args.Error(0)
+}
+
+// DeclineShare implements sdk.Cloud.
+func (m *Cloud) DeclineShare(ctx context.Context, shareRequestID uint64, opts ...sdk.ClientOption) error {
+	args := m.Called(ctx, shareRequestID, opts)
+	return args.Error(0)
+}
+
+// ListShares implements sdk.Cloud.
+func (m *Cloud) ListShares(ctx context.Context, opts ...sdk.ClientOption) (*sdk.SharesList, error) {
+	args := m.Called(ctx, opts)
+	r0, _ := args.Get(0).(*sdk.SharesList)
+	return r0, args.Error(1)
+}
+
+// RemoveShare implements sdk.Cloud.
+func (m *Cloud) RemoveShare(ctx context.Context, shareID uint64, opts ...sdk.ClientOption) error {
+	args := m.Called(ctx, shareID, opts)
+	return args.Error(0)
+}
+
+// ShareFolder implements sdk.Cloud.
+func (m *Cloud) ShareFolder(ctx context.Context, folder sdk.FolderRef, mail string, permissions sdk.SharePermissions, nameOpt, messageOpt string, opts ...sdk.ClientOption) error {
+	args := m.Called(ctx, folder, mail, permissions, nameOpt, messageOpt, opts)
+	return args.Error(0)
+}
+
+// DownloadLink implements sdk.Cloud.
+func (m *Cloud) DownloadLink(ctx context.Context, file sdk.FileRef, o sdk.DownloadLinkOptions, opts ...sdk.ClientOption) (*sdk.FileLink, error) {
+	args := m.Called(ctx, file, o, opts)
+	r0, _ := args.Get(0).(*sdk.FileLink)
+	return r0, args.Error(1)
+}
+
+// GetFileLink implements sdk.Cloud.
+func (m *Cloud) GetFileLink(ctx context.Context, file sdk.FileRef, forceDownloadOpt bool, contentTypeOpt string, maxSpeedOpt uint64, skipFilenameOpt bool, opts ...sdk.ClientOption) (*sdk.FileLink, error) {
+	args := m.Called(ctx, file, forceDownloadOpt, contentTypeOpt, maxSpeedOpt, skipFilenameOpt, opts)
+	r0, _ := args.Get(0).(*sdk.FileLink)
+	return r0, args.Error(1)
+}
+
+// Preview implements sdk.Cloud.
+func (m *Cloud) Preview(ctx context.Context, md *sdk.Metadata, width, height int, opts ...sdk.ClientOption) (*sdk.Preview, error) {
+	args := m.Called(ctx, md, width, height, opts)
+	r0, _ := args.Get(0).(*sdk.Preview)
+	return r0, args.Error(1)
+}
+
+// ThumbLink implements sdk.Cloud.
+func (m *Cloud) ThumbLink(ctx context.Context, file sdk.FileRef, width, height int, o sdk.ThumbLinkOptions, opts ...sdk.ClientOption) (*sdk.ThumbLink, error) {
+	args := m.Called(ctx, file, width, height, o, opts)
+	r0, _ := args.Get(0).(*sdk.ThumbLink)
+	return r0, args.Error(1)
+}
+
+// Subscribe implements sdk.Cloud.
+func (m *Cloud) Subscribe(ctx context.Context, fromDiffID uint64, opts ...sdk.ClientOption) (<-chan sdk.Entry, error) {
+	args := m.Called(ctx, fromDiffID, opts)
+	if ch, ok := args.Get(0).(chan sdk.Entry); ok {
+		return ch, args.Error(1)
+	}
+	r0, _ := args.Get(0).(<-chan sdk.Entry)
+	return r0, args.Error(1)
+}
+
+// ListTrash implements sdk.Cloud.
+func (m *Cloud) ListTrash(ctx context.Context, o sdk.ListTrashOptions, opts ...sdk.ClientOption) (*sdk.FSList, error) {
+	args := m.Called(ctx, o, opts)
+	r0, _ := args.Get(0).(*sdk.FSList)
+	return r0, args.Error(1)
+}
+
+// TrashClear implements sdk.Cloud.
+func (m *Cloud) TrashClear(ctx context.Context, item sdk.T6FileIDOrFolderID, opts ...sdk.ClientOption) error {
+	args := m.Called(ctx, item, opts)
+	return args.Error(0)
+}
+
+// TrashList implements sdk.Cloud.
+func (m *Cloud) TrashList(ctx context.Context, folderIDOpt uint64, noFilesOpt, recursiveOpt bool, opts ...sdk.ClientOption) (*sdk.FSList, error) {
+	args := m.Called(ctx, folderIDOpt, noFilesOpt, recursiveOpt, opts)
+	r0, _ := args.Get(0).(*sdk.FSList)
+	return r0, args.Error(1)
+}
+
+// TrashRestore implements sdk.Cloud.
+func (m *Cloud) TrashRestore(ctx context.Context, item sdk.T6FileIDOrFolderID, restoreToOpt uint64, opts ...sdk.ClientOption) (*sdk.FSList, error) {
+	args := m.Called(ctx, item, restoreToOpt, opts)
+	r0, _ := args.Get(0).(*sdk.FSList)
+	return r0, args.Error(1)
+}
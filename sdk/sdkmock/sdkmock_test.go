@@ -0,0 +1,60 @@
+package sdkmock_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+	"github.com/seborama/pcloud-sdk/sdk/sdkmock"
+)
+
+// fileSize is an example of code that depends on the SDK.
+func fileSize(ctx context.Context, pcc sdk.Cloud, fileID uint64) (uint64, error) {
+	fr, err := pcc.Stat(ctx, sdk.ByID(fileID))
+	if err != nil {
+		return 0, err
+	}
+	return fr.Metadata.Size, nil
+}
+
+func TestCloud(t *testing.T) {
+	ctx := context.Background()
+
+	m := &sdkmock.Cloud{}
+	defer m.AssertExpectations(t)
+
+	m.
+		On("Stat", ctx, mock.Anything, []sdk.ClientOption(nil)).
+		Return(&sdk.FileResult{Metadata: sdk.Metadata{Size: 42}}, nil).
+		Once().
+		On("Stat", ctx, mock.Anything, []sdk.ClientOption(nil)).
+		Return(nil, errors.New("boom")).
+		Once()
+
+	size, err := fileSize(ctx, m, 1)
+	require.NoError(t, err)
+	assert.EqualValues(t, 42, size)
+
+	_, err = fileSize(ctx, m, 1)
+	assert.EqualError(t, err, "boom")
+}
+
+func TestCloud_Subscribe(t *testing.T) {
+	ctx := context.Background()
+
+	ch := make(chan sdk.Entry, 1)
+	ch <- sdk.Entry{DiffID: 7}
+	close(ch)
+
+	m := &sdkmock.Cloud{}
+	m.On("Subscribe", ctx, uint64(0), []sdk.ClientOption(nil)).Return(ch, nil)
+
+	entries, err := m.Subscribe(ctx, 0)
+	require.NoError(t, err)
+	assert.EqualValues(t, 7, (<-entries).DiffID)
+}
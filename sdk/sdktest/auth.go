@@ -0,0 +1,180 @@
+package sdktest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+// tokenLifetime is the lifetime of the auth tokens, as listed by listtokens. The tokens of the
+// Server do not actually expire.
+const tokenLifetime = 365 * 24 * time.Hour
+
+// authToken is an auth token issued by the Server.
+type authToken struct {
+	id      uint64
+	created time.Time
+}
+
+// authenticate verifies that the call to method is authenticated, when the Server requires it
+// (see WithCredentials).
+func (s *Server) authenticate(method string, q url.Values) error {
+	if s.username == "" {
+		return nil
+	}
+
+	switch {
+	case q.Has("auth"):
+		if s.tokens[q.Get("auth")] == nil {
+			return apiError(sdk.ErrLoginRequired)
+		}
+		return nil
+
+	case q.Has("access_token"):
+		if s.tokens[q.Get("access_token")] == nil {
+			return apiError(sdk.ErrLoginRequired)
+		}
+		return nil
+
+	case method == "getdigest" || method == "oauth2_token":
+		return nil
+
+	case q.Has("digest"):
+		digest := q.Get("digest")
+		ok := s.digests[digest]
+		delete(s.digests, digest)
+
+		if !ok || q.Get("username") != s.username || q.Get("passworddigest") != sdk.PasswordDigest(s.username, s.password, digest) {
+			return apiError(sdk.ErrLoginFailed)
+		}
+		return nil
+
+	case q.Has("username") || method == "login":
+		if q.Get("username") != s.username || q.Get("password") != s.password {
+			return apiError(sdk.ErrLoginFailed)
+		}
+		return nil
+
+	default:
+		return apiError(sdk.ErrLoginRequired)
+	}
+}
+
+// https://docs.pcloud.com/methods/general/userinfo.html
+func (s *Server) userInfo(q url.Values, _ *http.Request) (any, error) {
+	email := s.username
+	if email == "" {
+		email = q.Get("username")
+	}
+
+	o := obj{
+		"userid":        1,
+		"email":         email,
+		"emailverified": true,
+		"quota":         10 << 30,
+		"usedquota":     s.usedQuota(),
+		"language":      "en",
+		"premium":       false,
+		"cryptosetup":   s.cryptoPrivateKey != "",
+	}
+
+	if boolParam(q, "getauth") {
+		o["auth"] = s.newToken()
+	}
+
+	return o, nil
+}
+
+// login is not a documented method.
+// https://docs.pcloud.com/methods/intro/authentication.html
+func (s *Server) login(q url.Values, r *http.Request) (any, error) {
+	return s.userInfo(q, r)
+}
+
+// https://docs.pcloud.com/methods/auth/logout.html
+func (s *Server) logout(q url.Values, _ *http.Request) (any, error) {
+	deleted := s.tokens[q.Get("auth")] != nil
+	delete(s.tokens, q.Get("auth"))
+
+	return obj{"auth_deleted": deleted}, nil
+}
+
+// https://docs.pcloud.com/methods/auth/getdigest.html
+func (s *Server) getDigest(_ url.Values, _ *http.Request) (any, error) {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+
+	digest := hex.EncodeToString(b)
+	s.digests[digest] = true
+
+	return obj{"digest": digest, "expires": s.fs.now().Add(30 * time.Second).Format(time.RFC1123Z)}, nil
+}
+
+// oauth2_token accepts any code: the Server has no authorisation page that would issue them.
+// https://docs.pcloud.com/methods/oauth_2.0/oauth2_token.html
+func (s *Server) oauth2Token(q url.Values, _ *http.Request) (any, error) {
+	if q.Get("client_id") == "" || q.Get("code") == "" {
+		return nil, apiError(sdk.ErrInvalidCodeProvided, "Invalid 'code' provided.")
+	}
+
+	return obj{
+		"access_token": s.newToken(),
+		"token_type":   "bearer",
+		"uid":          1,
+		"locationid":   2,
+	}, nil
+}
+
+// newToken returns a new auth token.
+func (s *Server) newToken() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+
+	token := hex.EncodeToString(b)
+	s.lastTokenID++
+	s.tokens[token] = &authToken{id: s.lastTokenID, created: s.fs.now()}
+
+	return token
+}
+
+// https://docs.pcloud.com/methods/auth/listtokens.html
+func (s *Server) listTokens(q url.Values, _ *http.Request) (any, error) {
+	current := s.tokens[q.Get("auth")]
+	if current == nil {
+		current = s.tokens[q.Get("access_token")]
+	}
+
+	ts := make([]*authToken, 0, len(s.tokens))
+	for _, t := range s.tokens {
+		ts = append(ts, t)
+	}
+	sort.Slice(ts, func(i, j int) bool { return ts[i].id < ts[j].id })
+
+	tokens := make([]obj, 0, len(ts))
+	for _, t := range ts {
+		tokens = append(tokens, obj{
+			"tokenid":         t.id,
+			"device":          "sdktest",
+			"current":         t == current,
+			"created":         t.created.Format(time.RFC1123Z),
+			"expiresinactive": t.created.Add(tokenLifetime).Format(time.RFC1123Z),
+			"expires":         t.created.Add(tokenLifetime).Format(time.RFC1123Z),
+		})
+	}
+
+	return obj{"tokens": tokens}, nil
+}
+
+// usedQuota returns the total size of the files.
+func (s *Server) usedQuota() uint64 {
+	var size uint64
+	for _, n := range s.fs.files {
+		size += uint64(len(n.data))
+	}
+	return size
+}
@@ -0,0 +1,85 @@
+package sdktest
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+// https://docs.pcloud.com/methods/crypto/crypto_setuserkeys.html
+func (s *Server) cryptoSetUserKeys(q url.Values, _ *http.Request) (any, error) {
+	if s.cryptoPrivateKey != "" {
+		return nil, apiError(sdk.ErrAccessDenied, "Crypto is already set up.")
+	}
+
+	if q.Get("privatekey") == "" || q.Get("publickey") == "" {
+		return nil, apiError(sdk.ErrInternalError, "Please provide 'privatekey' and 'publickey'.")
+	}
+
+	s.cryptoPrivateKey = q.Get("privatekey")
+	s.cryptoPublicKey = q.Get("publickey")
+	s.cryptoHint = q.Get("hint")
+
+	return obj{}, nil
+}
+
+// https://docs.pcloud.com/methods/crypto/crypto_getuserkeys.html
+func (s *Server) cryptoGetUserKeys(_ url.Values, _ *http.Request) (any, error) {
+	if s.cryptoPrivateKey == "" {
+		return nil, apiError(sdk.ErrAccessDenied, "Crypto is not set up.")
+	}
+
+	return obj{"privatekey": s.cryptoPrivateKey, "publickey": s.cryptoPublicKey}, nil
+}
+
+// https://docs.pcloud.com/methods/crypto/crypto_getuserhint.html
+func (s *Server) cryptoGetUserHint(_ url.Values, _ *http.Request) (any, error) {
+	if s.cryptoPrivateKey == "" {
+		return nil, apiError(sdk.ErrAccessDenied, "Crypto is not set up.")
+	}
+
+	return obj{"hint": s.cryptoHint}, nil
+}
+
+// https://docs.pcloud.com/methods/crypto/crypto_getfolderkey.html
+func (s *Server) cryptoGetFolderKey(q url.Values, _ *http.Request) (any, error) {
+	n, err := s.folderParam(url.Values{"folderid": q["folderid"]})
+	if err != nil {
+		return nil, err
+	}
+
+	if n.cryptoKey == "" {
+		return nil, apiError(sdk.ErrAccessDenied, "Not a Crypto folder.")
+	}
+
+	return obj{"key": n.cryptoKey}, nil
+}
+
+// https://docs.pcloud.com/methods/crypto/crypto_getfilekey.html
+func (s *Server) cryptoGetFileKey(q url.Values, _ *http.Request) (any, error) {
+	n, err := s.fileParam(url.Values{"fileid": q["fileid"]})
+	if err != nil {
+		return nil, err
+	}
+
+	if n.cryptoKey == "" {
+		return nil, apiError(sdk.ErrAccessDenied, "Not a Crypto file.")
+	}
+
+	return obj{"key": n.cryptoKey}, nil
+}
+
+// cryptoKeyParam returns the key parameter of the Crypto folders and files created with the
+// encrypted parameter, or the empty string for the others.
+func cryptoKeyParam(q url.Values) (string, error) {
+	if !boolParam(q, "encrypted") {
+		return "", nil
+	}
+
+	if q.Get("key") == "" {
+		return "", apiError(sdk.ErrInternalError, "Please provide 'key'.")
+	}
+
+	return q.Get("key"), nil
+}
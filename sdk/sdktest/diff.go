@@ -0,0 +1,182 @@
+package sdktest
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+// diffJournal holds the events of the files and folders, for diff.
+//
+// pCloud records the events as the file system changes. The Server computes them when diff is
+// called instead, from the changes since the previous call: the changes made to a file between
+// two calls make a single event.
+type diffJournal struct {
+	// events holds the events, oldest first: the diffid of an event is its index + 1.
+	events []obj
+	times  []time.Time
+	// seen holds the files and folders as of the last update of the journal, by their "id"
+	// metadata, such as "d12" or "f7".
+	seen map[string]seenNode
+}
+
+// seenNode is a file or a folder, as recorded by the journal.
+type seenNode struct {
+	metadata obj
+	isFolder bool
+	depth    int
+	// state holds the attributes whose changes are events: the location of folders, and the
+	// location and the contents of files.
+	state string
+}
+
+// update records the events of the changes of fs since the last update.
+func (j *diffJournal) update(fs *fileSystem) {
+	current := map[string]seenNode{}
+
+	var walk func(n *node, depth int)
+	walk = func(n *node, depth int) {
+		for _, c := range n.sortedChildren() {
+			m := c.metadata(0, false, false)
+
+			state := fmt.Sprintf("%d/%s", n.id, c.name)
+			if !c.isFolder {
+				state += fmt.Sprintf(" %d %d %d", c.hash(), len(c.data), c.modified.Unix())
+			} else {
+				walk(c, depth+1)
+			}
+
+			current[m["id"].(string)] = seenNode{metadata: m, isFolder: c.isFolder, depth: depth, state: state}
+		}
+	}
+	walk(fs.root, 0)
+
+	type change struct {
+		id    string
+		event sdk.Event
+		seenNode
+	}
+
+	var changes, deletions []change
+
+	for id, n := range current {
+		old, ok := j.seen[id]
+		switch {
+		case !ok && n.isFolder:
+			changes = append(changes, change{id, sdk.CreateFolder, n})
+		case !ok:
+			changes = append(changes, change{id, sdk.CreateFile, n})
+		case old.state != n.state && n.isFolder:
+			changes = append(changes, change{id, sdk.ModifyFolder, n})
+		case old.state != n.state:
+			changes = append(changes, change{id, sdk.ModifyFile, n})
+		}
+	}
+
+	for id, n := range j.seen {
+		if _, ok := current[id]; ok {
+			continue
+		}
+
+		// the metadata is that of an earlier event.
+		m := obj{"isdeleted": true}
+		for k, v := range n.metadata {
+			m[k] = v
+		}
+		n.metadata = m
+
+		if n.isFolder {
+			deletions = append(deletions, change{id, sdk.DeleteFolder, n})
+		} else {
+			deletions = append(deletions, change{id, sdk.DeleteFile, n})
+		}
+	}
+
+	// the folders are created before their contents, and deleted after them.
+	sort.Slice(changes, func(i, k int) bool {
+		if changes[i].depth != changes[k].depth {
+			return changes[i].depth < changes[k].depth
+		}
+		return changes[i].id < changes[k].id
+	})
+	sort.Slice(deletions, func(i, k int) bool {
+		if deletions[i].depth != deletions[k].depth {
+			return deletions[i].depth > deletions[k].depth
+		}
+		return deletions[i].id < deletions[k].id
+	})
+
+	t := fs.now()
+
+	for _, c := range append(changes, deletions...) {
+		j.events = append(j.events, obj{
+			"event":    c.event,
+			"time":     t.Format(time.RFC1123Z),
+			"diffid":   len(j.events) + 1,
+			"metadata": c.metadata,
+		})
+		j.times = append(j.times, t)
+	}
+
+	j.seen = current
+}
+
+// https://docs.pcloud.com/methods/general/diff.html
+// Only the events of the files and folders are reported: not those of the shares, nor those of
+// the user. block is not supported: diff returns at once, with no entries when there is no new
+// event.
+func (s *Server) diff(q url.Values, _ *http.Request) (any, error) {
+	s.diffs.update(s.fs)
+
+	events := s.diffs.events
+
+	switch {
+	case q.Has("diffid"):
+		diffID, err := uintParam(q, "diffid", sdk.ErrInternalError)
+		if err != nil {
+			return nil, err
+		}
+		if diffID > uint64(len(events)) {
+			diffID = uint64(len(events))
+		}
+		events = events[diffID:]
+
+	case q.Has("after"):
+		after, err := time.Parse(time.RFC1123Z, q.Get("after"))
+		if err != nil {
+			return nil, apiError(sdk.ErrInternalError, "invalid after: "+q.Get("after"))
+		}
+		i := sort.Search(len(s.diffs.times), func(i int) bool { return s.diffs.times[i].After(after) })
+		events = events[i:]
+
+	case q.Has("last"):
+		last, err := uintParam(q, "last", sdk.ErrInternalError)
+		if err != nil {
+			return nil, err
+		}
+		if last < uint64(len(events)) {
+			events = events[uint64(len(events))-last:]
+		}
+	}
+
+	if q.Has("limit") {
+		limit, err := uintParam(q, "limit", sdk.ErrInternalError)
+		if err != nil {
+			return nil, err
+		}
+		if limit < uint64(len(events)) {
+			events = events[:limit]
+		}
+	}
+
+	diffID := len(s.diffs.events)
+	if len(events) > 0 {
+		diffID = events[len(events)-1]["diffid"].(int)
+	}
+
+	return obj{"diffid": diffID, "entries": append([]obj{}, events...)}, nil
+}
@@ -0,0 +1,257 @@
+package sdktest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+// downloadPath is the path prefix of the links returned by getfilelink.
+const downloadPath = "/dl/"
+
+// https://docs.pcloud.com/methods/file/uploadfile.html
+func (s *Server) uploadFile(q url.Values, r *http.Request) (any, error) {
+	parent, err := s.folderParam(q)
+	if err != nil {
+		return nil, err
+	}
+
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, apiError(sdk.ErrInternalUploadError, err.Error())
+	}
+
+	mTime := timeParam(q, "mtime")
+	cTime := timeParam(q, "ctime")
+
+	fileIDs := []uint64{}
+	sums := []obj{}
+	metadata := []obj{}
+
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, apiError(sdk.ErrInternalUploadError, err.Error())
+		}
+
+		name := part.FileName()
+		if name == "" {
+			continue
+		}
+
+		data, err := io.ReadAll(part)
+		if err != nil {
+			return nil, apiError(sdk.ErrInternalUploadError, err.Error())
+		}
+
+		if boolParam(q, "renameifexists") {
+			name = freeName(parent, name)
+		}
+
+		n, _, err := s.fs.mkfile(parent, name)
+		if err != nil {
+			return nil, err
+		}
+		n.data = data
+
+		if !mTime.IsZero() {
+			n.modified = mTime
+		}
+		if !cTime.IsZero() {
+			n.created = cTime
+		}
+
+		sha1Sum, md5Sum, sha256Sum := checksums(n.data)
+
+		fileIDs = append(fileIDs, n.id)
+		sums = append(sums, obj{"sha1": sha1Sum, "md5": md5Sum, "sha256": sha256Sum})
+		metadata = append(metadata, n.metadata(0, false, false))
+	}
+
+	return obj{"fileids": fileIDs, "checksums": sums, "metadata": metadata}, nil
+}
+
+// freeName returns name, or a variant of it that does not exist in the folder parent, in the
+// way pCloud renames files: "name (1).ext", "name (2).ext", etc.
+func freeName(parent *node, name string) string {
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+
+	for i := 1; ; i++ {
+		if _, ok := parent.children[name]; !ok {
+			return name
+		}
+		name = fmt.Sprintf("%s (%d)%s", base, i, ext)
+	}
+}
+
+// https://docs.pcloud.com/methods/file/deletefile.html
+func (s *Server) deleteFile(q url.Values, _ *http.Request) (any, error) {
+	n, err := s.fileParam(q)
+	if err != nil {
+		return nil, err
+	}
+
+	m := n.metadata(0, false, false)
+	m["isdeleted"] = true
+
+	s.fs.moveToTrash(n)
+
+	return obj{"metadata": m}, nil
+}
+
+// https://docs.pcloud.com/methods/file/renamefile.html
+func (s *Server) renameFile(q url.Values, _ *http.Request) (any, error) {
+	n, err := s.fileParam(q)
+	if err != nil {
+		return nil, err
+	}
+
+	parent, name, err := s.destinationParam(q)
+	if err != nil {
+		return nil, err
+	}
+
+	if name == "" {
+		name = n.name
+	}
+
+	if !validName(name) {
+		return nil, apiError(sdk.ErrInvalidFileOrFolderName)
+	}
+
+	var deletedFileID uint64
+	if c, ok := parent.children[name]; ok && c != n {
+		if c.isFolder {
+			return nil, apiError(sdk.ErrFileOrFolderAlreadyExists)
+		}
+		deletedFileID = c.id
+		s.fs.remove(c)
+	}
+
+	s.fs.detach(n)
+	n.name = name
+	s.fs.attach(parent, n)
+
+	m := n.metadata(0, false, false)
+	if deletedFileID != 0 {
+		m["deletedfileid"] = deletedFileID
+	}
+
+	return obj{"metadata": m}, nil
+}
+
+// https://docs.pcloud.com/methods/file/copyfile.html
+func (s *Server) copyFile(q url.Values, _ *http.Request) (any, error) {
+	n, err := s.fileParam(q)
+	if err != nil {
+		return nil, err
+	}
+
+	parent, name, err := s.destinationParam(q)
+	if err != nil {
+		return nil, err
+	}
+
+	if name == "" {
+		name = n.name
+	}
+
+	if c, ok := parent.children[name]; ok && c == n {
+		return nil, apiError(sdk.ErrFileOrFolderAlreadyExists)
+	}
+
+	c, err := s.fs.copyNode(n, parent, name, boolParam(q, "noover"), false)
+	if err != nil {
+		return nil, err
+	}
+
+	if t := timeParam(q, "mtime"); !t.IsZero() {
+		c.modified = t
+	}
+	if t := timeParam(q, "ctime"); !t.IsZero() {
+		c.created = t
+	}
+
+	return obj{"metadata": c.metadata(0, false, false)}, nil
+}
+
+// https://docs.pcloud.com/methods/file/stat.html
+func (s *Server) stat(q url.Values, _ *http.Request) (any, error) {
+	n, err := s.fileParam(q)
+	if err != nil {
+		return nil, err
+	}
+
+	return obj{"metadata": n.metadata(0, false, false)}, nil
+}
+
+// https://docs.pcloud.com/methods/file/checksumfile.html
+func (s *Server) checksumFile(q url.Values, _ *http.Request) (any, error) {
+	n, err := s.fileParam(q)
+	if err != nil {
+		return nil, err
+	}
+
+	sha1Sum, md5Sum, sha256Sum := checksums(n.data)
+
+	return obj{"sha1": sha1Sum, "md5": md5Sum, "sha256": sha256Sum, "metadata": n.metadata(0, false, false)}, nil
+}
+
+// https://docs.pcloud.com/methods/streaming/getfilelink.html
+// The link is served by the Server itself (see download).
+func (s *Server) getFileLink(q url.Values, _ *http.Request) (any, error) {
+	n, err := s.fileParam(q)
+	if err != nil {
+		return nil, err
+	}
+
+	p := fmt.Sprintf("%s%d/%s", downloadPath, n.id, url.PathEscape(n.name))
+	if q.Has("contenttype") {
+		p += "?contenttype=" + url.QueryEscape(q.Get("contenttype"))
+	}
+
+	return obj{
+		"path":    p,
+		"expires": s.fs.now().Add(6 * time.Hour).Format(time.RFC1123Z),
+		"hosts":   []string{s.Host()},
+	}, nil
+}
+
+// download serves the contents of the file of a link returned by getfilelink, in full or in
+// part (see http.ServeContent).
+func (s *Server) download(w http.ResponseWriter, r *http.Request) {
+	id, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, downloadPath), "/")
+
+	fileID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	n, ok := s.fs.files[fileID]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	ct := r.URL.Query().Get("contenttype")
+	if ct == "" {
+		ct = n.contentType()
+	}
+
+	// the content servers of pCloud support range requests.
+	w.Header().Set("Content-Type", ct)
+	http.ServeContent(w, r, n.name, n.modified, bytes.NewReader(n.data))
+}
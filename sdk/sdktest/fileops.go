@@ -0,0 +1,273 @@
+package sdktest
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+// fileDescriptor is a file opened with file_open.
+type fileDescriptor struct {
+	file   *node
+	offset uint64
+	append bool
+}
+
+// https://docs.pcloud.com/methods/fileops/file_open.html
+func (s *Server) fileOpen(q url.Values, _ *http.Request) (any, error) {
+	if !q.Has("flags") {
+		return nil, apiError(sdk.ErrFlagsNotProvided)
+	}
+
+	flags, err := uintParam(q, "flags", sdk.ErrFlagsNotProvided)
+	if err != nil {
+		return nil, err
+	}
+
+	var n *node
+
+	switch {
+	case q.Has("fileid"):
+		n, err = s.fileParam(q)
+		if err != nil {
+			return nil, err
+		}
+		if flags&sdk.O_CREAT != 0 && flags&sdk.O_EXCL != 0 {
+			return nil, apiError(sdk.ErrFileOrFolderAlreadyExists)
+		}
+
+	default:
+		parent, name, err := s.parentParam(q)
+		if err != nil {
+			return nil, err
+		}
+
+		c, ok := parent.children[name]
+		switch {
+		case ok && c.isFolder:
+			return nil, apiError(sdk.ErrFileOrFolderAlreadyExists)
+
+		case ok && flags&sdk.O_CREAT != 0 && flags&sdk.O_EXCL != 0:
+			return nil, apiError(sdk.ErrFileOrFolderAlreadyExists)
+
+		case ok:
+			n = c
+
+		case flags&sdk.O_CREAT == 0:
+			return nil, apiError(sdk.ErrFileNotFound)
+
+		default:
+			key, err := cryptoKeyParam(q)
+			if err != nil {
+				return nil, err
+			}
+
+			n, _, err = s.fs.mkfile(parent, name)
+			if err != nil {
+				return nil, err
+			}
+			n.cryptoKey = key
+		}
+	}
+
+	if flags&sdk.O_TRUNC != 0 {
+		s.fs.addRevision(n)
+		n.data = nil
+		n.modified = s.fs.now()
+	}
+
+	s.lastFD++
+	s.fds[s.lastFD] = &fileDescriptor{file: n, append: flags&sdk.O_APPEND != 0}
+
+	return obj{"fd": s.lastFD, "fileid": n.id}, nil
+}
+
+// fdParam returns the file descriptor referenced by the fd parameter.
+func (s *Server) fdParam(q url.Values) (*fileDescriptor, error) {
+	fd, err := uintParam(q, "fd", sdk.ErrInvalidOrClosedFileDescriptor)
+	if err != nil {
+		return nil, err
+	}
+
+	f, ok := s.fds[fd]
+	if !ok {
+		return nil, apiError(sdk.ErrInvalidOrClosedFileDescriptor)
+	}
+
+	// the file may have been deleted since it was opened.
+	if _, ok := s.fs.files[f.file.id]; !ok {
+		return nil, apiError(sdk.ErrFileNotFound)
+	}
+
+	return f, nil
+}
+
+// https://docs.pcloud.com/methods/fileops/file_write.html
+func (s *Server) fileWrite(q url.Values, r *http.Request) (any, error) {
+	f, err := s.fdParam(q)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, apiError(sdk.ErrWriteError, err.Error())
+	}
+
+	n := f.file
+	if f.append {
+		f.offset = uint64(len(n.data))
+	}
+
+	end := f.offset + uint64(len(data))
+	if end > uint64(len(n.data)) {
+		n.data = append(n.data, make([]byte, end-uint64(len(n.data)))...)
+	}
+	copy(n.data[f.offset:], data)
+
+	f.offset = end
+	n.modified = s.fs.now()
+
+	return obj{"bytes": len(data)}, nil
+}
+
+// https://docs.pcloud.com/methods/fileops/file_read.html
+func (s *Server) fileRead(q url.Values, _ *http.Request) (any, error) {
+	f, err := s.fdParam(q)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := readAt(f.file, q, f.offset)
+	if err != nil {
+		return nil, err
+	}
+
+	f.offset += uint64(len(data))
+
+	return data, nil
+}
+
+// https://docs.pcloud.com/methods/fileops/file_pread.html
+func (s *Server) filePRead(q url.Values, _ *http.Request) (any, error) {
+	f, err := s.fdParam(q)
+	if err != nil {
+		return nil, err
+	}
+
+	offset, err := offsetParam(q)
+	if err != nil {
+		return nil, err
+	}
+
+	return readAt(f.file, q, offset)
+}
+
+// https://docs.pcloud.com/methods/fileops/file_pread_ifmod.html
+func (s *Server) filePReadIfMod(q url.Values, r *http.Request) (any, error) {
+	if !q.Has("sha1") && !q.Has("md5") {
+		return nil, apiError(sdk.ErrChecksumNotProvided)
+	}
+
+	resp, err := s.filePRead(q, r)
+	if err != nil {
+		return nil, err
+	}
+
+	sha1Sum, md5Sum, _ := checksums(resp.([]byte))
+	if q.Get("sha1") == sha1Sum || q.Get("md5") == md5Sum {
+		return nil, apiError(sdk.ErrNotModified)
+	}
+
+	return resp, nil
+}
+
+// https://docs.pcloud.com/methods/fileops/file_checksum.html
+func (s *Server) fileChecksum(q url.Values, r *http.Request) (any, error) {
+	resp, err := s.filePRead(q, r)
+	if err != nil {
+		return nil, err
+	}
+
+	data := resp.([]byte)
+	sha1Sum, md5Sum, _ := checksums(data)
+
+	return obj{"sha1": sha1Sum, "md5": md5Sum, "size": len(data)}, nil
+}
+
+// https://docs.pcloud.com/methods/fileops/file_seek.html
+func (s *Server) fileSeek(q url.Values, _ *http.Request) (any, error) {
+	f, err := s.fdParam(q)
+	if err != nil {
+		return nil, err
+	}
+
+	offset, err := offsetParam(q)
+	if err != nil {
+		return nil, err
+	}
+
+	// whence defaults to 0, from the beginning of the file.
+	whence, _ := strconv.ParseInt(q.Get("whence"), 10, 8)
+
+	switch sdk.Whence(whence) {
+	case sdk.WhenceFromCurrent:
+		offset += f.offset
+	case sdk.WhenceFromEnd:
+		offset += uint64(len(f.file.data))
+	}
+
+	f.offset = offset
+
+	return obj{"offset": f.offset}, nil
+}
+
+// https://docs.pcloud.com/methods/fileops/file_close.html
+func (s *Server) fileClose(q url.Values, _ *http.Request) (any, error) {
+	fd, err := uintParam(q, "fd", sdk.ErrInvalidOrClosedFileDescriptor)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := s.fds[fd]; !ok {
+		return nil, apiError(sdk.ErrInvalidOrClosedFileDescriptor)
+	}
+	delete(s.fds, fd)
+
+	return obj{}, nil
+}
+
+// readAt returns at most count bytes (the count parameter) of the file n, from offset.
+func readAt(n *node, q url.Values, offset uint64) ([]byte, error) {
+	if !q.Has("count") {
+		return nil, apiError(sdk.ErrCountNotProvided)
+	}
+
+	count, err := uintParam(q, "count", sdk.ErrCountNotProvided)
+	if err != nil {
+		return nil, err
+	}
+
+	size := uint64(len(n.data))
+	if offset >= size {
+		return []byte{}, nil
+	}
+
+	end := offset + count
+	if end > size {
+		end = size
+	}
+
+	return append([]byte(nil), n.data[offset:end]...), nil
+}
+
+// offsetParam returns the offset parameter.
+func offsetParam(q url.Values) (uint64, error) {
+	if !q.Has("offset") {
+		return 0, apiError(sdk.ErrOffsetNotProvided)
+	}
+	return uintParam(q, "offset", sdk.ErrOffsetNotProvided)
+}
@@ -0,0 +1,189 @@
+package sdktest
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+// https://docs.pcloud.com/methods/folder/listfolder.html
+func (s *Server) listFolder(q url.Values, _ *http.Request) (any, error) {
+	n, err := s.folderParam(q)
+	if err != nil {
+		return nil, err
+	}
+
+	depth := 1
+	if boolParam(q, "recursive") {
+		depth = -1
+	}
+
+	return obj{"metadata": n.metadata(depth, boolParam(q, "nofiles"), q.Has("path"))}, nil
+}
+
+// https://docs.pcloud.com/methods/folder/createfolder.html
+func (s *Server) createFolder(q url.Values, _ *http.Request) (any, error) {
+	parent, name, err := s.parentParam(q)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := cryptoKeyParam(q)
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := s.fs.mkdir(parent, name)
+	if err != nil {
+		return nil, err
+	}
+	n.cryptoKey = key
+
+	return obj{"metadata": n.metadata(0, false, false)}, nil
+}
+
+// https://docs.pcloud.com/methods/folder/createfolderifnotexists.html
+func (s *Server) createFolderIfNotExists(q url.Values, _ *http.Request) (any, error) {
+	parent, name, err := s.parentParam(q)
+	if err != nil {
+		return nil, err
+	}
+
+	n, ok := parent.children[name]
+	if ok && !n.isFolder {
+		return nil, apiError(sdk.ErrFileOrFolderAlreadyExists)
+	}
+
+	if !ok {
+		n, err = s.fs.mkdir(parent, name)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return obj{"created": !ok, "metadata": n.metadata(0, false, false)}, nil
+}
+
+// https://docs.pcloud.com/methods/folder/deletefolder.html
+func (s *Server) deleteFolder(q url.Values, _ *http.Request) (any, error) {
+	n, err := s.folderParam(q)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.parent == nil {
+		return nil, apiError(sdk.ErrCannotDeleteRootFolder)
+	}
+
+	if len(n.children) > 0 {
+		return nil, apiError(sdk.ErrFolderNotEmpty)
+	}
+
+	m := n.metadata(0, false, false)
+	m["isdeleted"] = true
+
+	s.fs.moveToTrash(n)
+
+	return obj{"metadata": m}, nil
+}
+
+// https://docs.pcloud.com/methods/folder/deletefolderrecursive.html
+func (s *Server) deleteFolderRecursive(q url.Values, _ *http.Request) (any, error) {
+	n, err := s.folderParam(q)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.parent == nil {
+		return nil, apiError(sdk.ErrCannotDeleteRootFolder)
+	}
+
+	files, folders := s.fs.moveToTrash(n)
+
+	return obj{"deletedfiles": files, "deletedfolders": folders}, nil
+}
+
+// https://docs.pcloud.com/methods/folder/renamefolder.html
+func (s *Server) renameFolder(q url.Values, _ *http.Request) (any, error) {
+	n, err := s.folderParam(q)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.parent == nil {
+		return nil, apiError(sdk.ErrCannotRenameRootFolder)
+	}
+
+	parent, name, err := s.destinationParam(q)
+	if err != nil {
+		return nil, err
+	}
+
+	if name == "" {
+		name = n.name
+	}
+
+	if !validName(name) {
+		return nil, apiError(sdk.ErrInvalidFileOrFolderName)
+	}
+
+	if n.isAncestorOf(parent) {
+		return nil, apiError(sdk.ErrCannotMoveFolderToSubfolder)
+	}
+
+	if c, ok := parent.children[name]; ok && c != n {
+		return nil, apiError(sdk.ErrFileOrFolderAlreadyExists)
+	}
+
+	s.fs.detach(n)
+	n.name = name
+	s.fs.attach(parent, n)
+
+	return obj{"metadata": n.metadata(0, false, false)}, nil
+}
+
+// https://docs.pcloud.com/methods/folder/copyfolder.html
+func (s *Server) copyFolder(q url.Values, _ *http.Request) (any, error) {
+	n, err := s.folderParam(q)
+	if err != nil {
+		return nil, err
+	}
+
+	var dst *node
+	switch {
+	case q.Has("tofolderid"):
+		dst, err = s.folderParam(url.Values{"folderid": q["tofolderid"]})
+	case q.Has("topath"):
+		dst, err = s.folderParam(url.Values{"path": q["topath"]})
+	default:
+		err = apiError(sdk.ErrFullToPathOrToNameToFolderIDNotProvided)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	noOver := boolParam(q, "noover")
+	skipExisting := boolParam(q, "skipexisting")
+
+	if !boolParam(q, "copycontentonly") {
+		c, err := s.fs.copyNode(n, dst, n.name, noOver, skipExisting)
+		if err != nil {
+			return nil, err
+		}
+		return obj{"metadata": c.metadata(-1, false, false)}, nil
+	}
+
+	if n.isAncestorOf(dst) {
+		return nil, apiError(sdk.ErrCannotMoveFolderToSubfolder)
+	}
+
+	for _, c := range n.sortedChildren() {
+		_, err := s.fs.copyNode(c, dst, c.name, noOver, skipExisting)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return obj{"metadata": dst.metadata(-1, false, false)}, nil
+}
@@ -0,0 +1,445 @@
+package sdktest
+
+import (
+	"crypto/md5"  // nolint: gosec
+	"crypto/sha1" // nolint: gosec
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+// node is a file or a folder of the in-memory file system.
+type node struct {
+	id       uint64
+	name     string
+	isFolder bool
+	parent   *node
+	created  time.Time
+	modified time.Time
+
+	// folder-specific.
+	children map[string]*node
+
+	// file-specific.
+	data      []byte
+	revisions []*revision // oldest first
+
+	// cryptoKey is the encrypted key of the folders and files of Crypto folders.
+	cryptoKey string
+}
+
+// revision is a previous version of the contents of a file.
+type revision struct {
+	id      uint64
+	data    []byte
+	created time.Time
+}
+
+// trashed is a file or a folder of the trash, with the contents it was deleted with.
+type trashed struct {
+	n        *node
+	parentID uint64
+}
+
+// path returns the full path of n.
+func (n *node) path() string {
+	if n.parent == nil {
+		return "/"
+	}
+	return path.Join(n.parent.path(), n.name)
+}
+
+// isAncestorOf returns true when n is m or one of its ancestors.
+func (n *node) isAncestorOf(m *node) bool {
+	for ; m != nil; m = m.parent {
+		if m == n {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedChildren returns the children of the folder n, sorted by name.
+func (n *node) sortedChildren() []*node {
+	children := make([]*node, 0, len(n.children))
+	for _, c := range n.children {
+		children = append(children, c)
+	}
+	sort.Slice(children, func(i, j int) bool { return children[i].name < children[j].name })
+	return children
+}
+
+// hash returns the pCloud-like hash of the contents of the file n.
+func (n *node) hash() uint64 {
+	h := sha1.Sum(n.data) // nolint: gosec
+	return binary.BigEndian.Uint64(h[:8])
+}
+
+// checksums returns the SHA-1, MD5 and SHA-256 checksums of b.
+func checksums(b []byte) (string, string, string) {
+	sha1Sum := sha1.Sum(b) // nolint: gosec
+	md5Sum := md5.Sum(b)   // nolint: gosec
+	sha256Sum := sha256.Sum256(b)
+	return hex.EncodeToString(sha1Sum[:]), hex.EncodeToString(md5Sum[:]), hex.EncodeToString(sha256Sum[:])
+}
+
+// contentType returns the content type of the file n, from its extension.
+func (n *node) contentType() string {
+	ct := mime.TypeByExtension(path.Ext(n.name))
+	if ct == "" {
+		return "application/octet-stream"
+	}
+	return ct
+}
+
+// metadata returns the metadata of n, as pCloud sends it. The contents of folders are included
+// down to depth levels: 0 means none, a negative depth means all levels.
+func (n *node) metadata(depth int, noFiles, withPath bool) obj {
+	m := obj{
+		"name":     n.name,
+		"created":  n.created.Format(time.RFC1123Z),
+		"modified": n.modified.Format(time.RFC1123Z),
+		"ismine":   true,
+		"isshared": false,
+		"thumb":    false,
+		"comments": 0,
+		"isfolder": n.isFolder,
+	}
+
+	if n.parent != nil {
+		m["parentfolderid"] = n.parent.id
+	}
+
+	if withPath {
+		m["path"] = n.path()
+	}
+
+	if n.cryptoKey != "" {
+		m["encrypted"] = true
+	}
+
+	if !n.isFolder {
+		m["id"] = fmt.Sprintf("f%d", n.id)
+		m["fileid"] = n.id
+		m["hash"] = n.hash()
+		m["size"] = len(n.data)
+		m["contenttype"] = n.contentType()
+		m["category"] = 0
+		m["icon"] = "file"
+		return m
+	}
+
+	m["id"] = fmt.Sprintf("d%d", n.id)
+	m["folderid"] = n.id
+	m["icon"] = "folder"
+
+	if depth == 0 {
+		return m
+	}
+
+	contents := []obj{}
+	for _, c := range n.sortedChildren() {
+		if noFiles && !c.isFolder {
+			continue
+		}
+		contents = append(contents, c.metadata(depth-1, noFiles, false))
+	}
+	m["contents"] = contents
+
+	return m
+}
+
+// fileSystem is the in-memory file system of the fake server.
+// It is not safe for concurrent use: the Server serialises its accesses.
+type fileSystem struct {
+	root    *node
+	folders map[uint64]*node
+	files   map[uint64]*node
+	lastID  uint64
+	now     func() time.Time
+
+	trash          []*trashed
+	lastRevisionID uint64
+}
+
+func newFileSystem(now func() time.Time) *fileSystem {
+	t := now()
+
+	root := &node{
+		id:       sdk.RootFolderID,
+		isFolder: true,
+		created:  t,
+		modified: t,
+		children: map[string]*node{},
+	}
+
+	return &fileSystem{
+		root:    root,
+		folders: map[uint64]*node{root.id: root},
+		files:   map[uint64]*node{},
+		now:     now,
+	}
+}
+
+// lookup returns the node at path p, or nil if there is none.
+func (fs *fileSystem) lookup(p string) (*node, error) {
+	if !strings.HasPrefix(p, "/") {
+		return nil, apiError(sdk.ErrInvalidPath)
+	}
+
+	n := fs.root
+	for _, name := range strings.Split(strings.Trim(path.Clean(p), "/"), "/") {
+		if name == "" {
+			continue
+		}
+		if !n.isFolder {
+			return nil, apiError(sdk.ErrComponentOfParentDirectoryNotExists)
+		}
+		c, ok := n.children[name]
+		if !ok {
+			return nil, nil
+		}
+		n = c
+	}
+
+	return n, nil
+}
+
+// parentOf returns the folder that holds path p and the name of p in it.
+func (fs *fileSystem) parentOf(p string) (*node, string, error) {
+	if !strings.HasPrefix(p, "/") {
+		return nil, "", apiError(sdk.ErrInvalidPath)
+	}
+
+	dir, name := path.Split(path.Clean(p))
+	if name == "" {
+		return nil, "", apiError(sdk.ErrInvalidFileOrFolderName)
+	}
+
+	parent, err := fs.lookup(dir)
+	if err != nil {
+		return nil, "", err
+	}
+	if parent == nil || !parent.isFolder {
+		return nil, "", apiError(sdk.ErrComponentOfParentDirectoryNotExists)
+	}
+
+	return parent, name, nil
+}
+
+// mkdir creates the folder called name in parent.
+func (fs *fileSystem) mkdir(parent *node, name string) (*node, error) {
+	if !validName(name) {
+		return nil, apiError(sdk.ErrInvalidFileOrFolderName)
+	}
+	if _, ok := parent.children[name]; ok {
+		return nil, apiError(sdk.ErrFileOrFolderAlreadyExists)
+	}
+
+	fs.lastID++
+	t := fs.now()
+
+	n := &node{
+		id:       fs.lastID,
+		name:     name,
+		isFolder: true,
+		created:  t,
+		modified: t,
+		children: map[string]*node{},
+	}
+	fs.attach(parent, n)
+	fs.folders[n.id] = n
+
+	return n, nil
+}
+
+// mkfile creates the empty file called name in parent. It replaces the file of the same name,
+// if any: the id of the replaced file is returned.
+func (fs *fileSystem) mkfile(parent *node, name string) (*node, uint64, error) {
+	if !validName(name) {
+		return nil, 0, apiError(sdk.ErrInvalidFileOrFolderName)
+	}
+
+	var replaced uint64
+	var revisions []*revision
+	if c, ok := parent.children[name]; ok {
+		if c.isFolder {
+			return nil, 0, apiError(sdk.ErrFileOrFolderAlreadyExists)
+		}
+		// the contents of the replaced file become a revision of the new one.
+		fs.addRevision(c)
+		replaced, revisions = c.id, c.revisions
+		fs.remove(c)
+	}
+
+	fs.lastID++
+	t := fs.now()
+
+	n := &node{
+		id:        fs.lastID,
+		name:      name,
+		created:   t,
+		modified:  t,
+		revisions: revisions,
+	}
+	fs.attach(parent, n)
+	fs.files[n.id] = n
+
+	return n, replaced, nil
+}
+
+// addRevision records the current contents of the file n as a revision, unless it is empty.
+func (fs *fileSystem) addRevision(n *node) {
+	if len(n.data) == 0 {
+		return
+	}
+
+	fs.lastRevisionID++
+	n.revisions = append(n.revisions, &revision{id: fs.lastRevisionID, data: append([]byte(nil), n.data...), created: n.modified})
+}
+
+// attach places n in the folder parent.
+func (fs *fileSystem) attach(parent, n *node) {
+	n.parent = parent
+	parent.children[n.name] = n
+	parent.modified = fs.now()
+}
+
+// detach removes n from its folder, without deleting it.
+func (fs *fileSystem) detach(n *node) {
+	delete(n.parent.children, n.name)
+	n.parent.modified = fs.now()
+	n.parent = nil
+}
+
+// remove deletes n and its contents. It returns the number of files and folders deleted.
+func (fs *fileSystem) remove(n *node) (uint64, uint64) {
+	var files, folders uint64
+
+	var walk func(n *node)
+	walk = func(n *node) {
+		if !n.isFolder {
+			delete(fs.files, n.id)
+			files++
+			return
+		}
+		for _, c := range n.children {
+			walk(c)
+		}
+		delete(fs.folders, n.id)
+		folders++
+	}
+
+	walk(n)
+	fs.detach(n)
+
+	return files, folders
+}
+
+// moveToTrash deletes n and its contents like remove, and keeps them in the trash. It returns
+// the number of files and folders deleted.
+func (fs *fileSystem) moveToTrash(n *node) (uint64, uint64) {
+	t := &trashed{n: n, parentID: n.parent.id}
+
+	files, folders := fs.remove(n)
+	fs.trash = append(fs.trash, t)
+
+	return files, folders
+}
+
+// restore moves the item t of the trash, and its contents, back to the folder parent.
+func (fs *fileSystem) restore(t *trashed, parent *node) error {
+	if _, ok := parent.children[t.n.name]; ok {
+		return apiError(sdk.ErrFileOrFolderAlreadyExists)
+	}
+
+	var walk func(n *node)
+	walk = func(n *node) {
+		if !n.isFolder {
+			fs.files[n.id] = n
+			return
+		}
+		for _, c := range n.children {
+			walk(c)
+		}
+		fs.folders[n.id] = n
+	}
+
+	walk(t.n)
+	fs.attach(parent, t.n)
+
+	for i, u := range fs.trash {
+		if u == t {
+			fs.trash = append(fs.trash[:i], fs.trash[i+1:]...)
+			break
+		}
+	}
+
+	return nil
+}
+
+// copyNode copies n to the folder parent under name.
+// With noOver, the copy fails when a file or folder would be overwritten. With skipExisting, the
+// files that exist are left untouched.
+func (fs *fileSystem) copyNode(n, parent *node, name string, noOver, skipExisting bool) (*node, error) {
+	existing := parent.children[name]
+
+	if !n.isFolder {
+		if existing != nil && (noOver || existing.isFolder) {
+			return nil, apiError(sdk.ErrFileOrFolderAlreadyExists)
+		}
+		if existing != nil && skipExisting {
+			return existing, nil
+		}
+
+		c, _, err := fs.mkfile(parent, name)
+		if err != nil {
+			return nil, err
+		}
+		c.data = append([]byte(nil), n.data...)
+		c.modified = n.modified
+		c.cryptoKey = n.cryptoKey
+
+		return c, nil
+	}
+
+	if n.isAncestorOf(parent) {
+		return nil, apiError(sdk.ErrCannotMoveFolderToSubfolder)
+	}
+
+	dst := existing
+	switch {
+	case dst == nil:
+		var err error
+		dst, err = fs.mkdir(parent, name)
+		if err != nil {
+			return nil, err
+		}
+		dst.cryptoKey = n.cryptoKey
+
+	case !dst.isFolder || noOver:
+		return nil, apiError(sdk.ErrFileOrFolderAlreadyExists)
+	}
+
+	for _, c := range n.sortedChildren() {
+		_, err := fs.copyNode(c, dst, c.name, noOver, skipExisting)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return dst, nil
+}
+
+// validName returns true when name may be used for a file or a folder.
+func validName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, "/\\")
+}
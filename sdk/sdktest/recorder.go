@@ -0,0 +1,302 @@
+package sdktest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// RecordEnvVar is the environment variable that selects the Record mode in ModeFromEnv.
+const RecordEnvVar = "GO_PCLOUD_RECORD"
+
+// redacted replaces the secrets in cassettes.
+const redacted = "REDACTED"
+
+// Mode is the mode of a Recorder.
+type Mode int
+
+const (
+	// Replay serves the requests with the responses recorded in the cassette. No request is sent
+	// to pCloud.
+	Replay Mode = iota
+
+	// Record sends the requests to pCloud and records them, along with their responses, in the
+	// cassette.
+	Record
+)
+
+// ModeFromEnv returns Record when the environment variable GO_PCLOUD_RECORD is set to a
+// non-empty value, and Replay otherwise. This lets CI replay cassettes while developers record
+// them again against a live account.
+func ModeFromEnv() Mode {
+	if os.Getenv(RecordEnvVar) != "" {
+		return Record
+	}
+	return Replay
+}
+
+// scrubbedParams lists the query parameters whose value is replaced in cassettes, either
+// because they are secrets or because they depend on the machine the requests are made from.
+var scrubbedParams = map[string]bool{
+	"auth":           true,
+	"code":           true,
+	"device":         true,
+	"deviceid":       true,
+	"digest":         true,
+	"newpassword":    true,
+	"oldpassword":    true,
+	"os":             true,
+	"password":       true,
+	"passworddigest": true,
+	"privatekey":     true,
+	"token":          true,
+	"username":       true,
+}
+
+// scrubbedFields lists the fields of JSON responses whose value is replaced in cassettes.
+var scrubbedFields = []string{"auth", "email", "privatekey", "token"}
+
+// Interaction is a request and its response, as recorded in a cassette.
+type Interaction struct {
+	// Method is the HTTP method of the request.
+	Method string `json:"method"`
+
+	// Path is the path of the request, that is the pCloud API method.
+	Path string `json:"path"`
+
+	// Query is the query of the request, with its secrets scrubbed.
+	Query string `json:"query"`
+
+	// StatusCode is the HTTP status of the response.
+	StatusCode int `json:"status_code"`
+
+	// ContentType is the content type of the response.
+	ContentType string `json:"content_type"`
+
+	// Body is the body of the response, when it is JSON, with its secrets scrubbed.
+	Body json.RawMessage `json:"body,omitempty"`
+
+	// Data is the body of the response, when it is not JSON, such as file contents.
+	Data []byte `json:"data,omitempty"`
+}
+
+// Recorder is a VCR-style http.RoundTripper: it records the requests made to pCloud, along with
+// their responses, in a cassette (a JSON file) and replays them later. Secrets such as passwords
+// and auth tokens are scrubbed from the cassette.
+// This lets tests validate how requests are constructed and how responses are parsed, without
+// a live account:
+//
+//	rec, err := sdktest.NewRecorder("testdata/listfolder.json", sdktest.ModeFromEnv(), nil)
+//	...
+//	defer func() { _ = rec.Save() }()
+//
+//	pcc := sdk.NewClient(rec.Client())
+//
+// Requests are matched by HTTP method, API method and query, ignoring the scrubbed parameters,
+// in the order they were recorded. Request bodies are neither recorded nor matched.
+type Recorder struct {
+	mode Mode
+	path string
+	next http.RoundTripper
+
+	lock         sync.Mutex
+	interactions []*Interaction
+	replayed     []bool
+}
+
+// NewRecorder creates a Recorder for the cassette at path.
+// In Replay mode, the cassette is loaded. In Record mode, the requests are sent with next, or
+// with http.DefaultTransport when next is nil, and the cassette is written by Save.
+func NewRecorder(path string, mode Mode, next http.RoundTripper) (*Recorder, error) {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	r := &Recorder{
+		mode: mode,
+		path: path,
+		next: next,
+	}
+
+	if mode == Record {
+		return r, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	err = json.Unmarshal(data, &r.interactions)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cassette %s", path)
+	}
+
+	r.replayed = make([]bool, len(r.interactions))
+
+	return r, nil
+}
+
+// Client returns an HTTP client that uses the Recorder, for use with sdk.NewClient.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	if r.mode == Replay {
+		return r.replay(req)
+	}
+
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	i := &Interaction{
+		Method:      req.Method,
+		Path:        req.URL.Path,
+		Query:       scrubQuery(req.URL.Query()),
+		StatusCode:  resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+	}
+
+	if strings.HasPrefix(i.ContentType, "application/json") && json.Valid(body) {
+		i.Body = scrubBody(body)
+	} else {
+		i.Data = body
+	}
+
+	r.lock.Lock()
+	r.interactions = append(r.interactions, i)
+	r.lock.Unlock()
+
+	return resp, nil
+}
+
+// replay returns the recorded response to req.
+func (r *Recorder) replay(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		_, _ = io.Copy(io.Discard, req.Body)
+		_ = req.Body.Close()
+	}
+
+	query := scrubQuery(req.URL.Query())
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for n, i := range r.interactions {
+		if r.replayed[n] || i.Method != req.Method || i.Path != req.URL.Path || i.Query != query {
+			continue
+		}
+		r.replayed[n] = true
+
+		body := i.Data
+		if i.Body != nil {
+			body = i.Body
+		}
+
+		return &http.Response{
+			Status:        strconv.Itoa(i.StatusCode) + " " + http.StatusText(i.StatusCode),
+			StatusCode:    i.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{"Content-Type": {i.ContentType}},
+			Body:          io.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, errors.Errorf("sdktest: no recorded interaction in %s for %s %s?%s", r.path, req.Method, req.URL.Path, query)
+}
+
+// Save writes the cassette in Record mode. It does nothing in Replay mode.
+func (r *Recorder) Save() error {
+	if r.mode != Record {
+		return nil
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	data, err := json.MarshalIndent(r.interactions, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	err = os.MkdirAll(filepath.Dir(r.path), 0750)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return errors.WithStack(os.WriteFile(r.path, append(data, '\n'), 0600))
+}
+
+// Unreplayed returns the recorded interactions that were not replayed. A test may verify that
+// it is empty to ensure that the code under test made all the requests that were recorded.
+func (r *Recorder) Unreplayed() []*Interaction {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var unreplayed []*Interaction
+	for n, i := range r.interactions {
+		if r.mode == Replay && !r.replayed[n] {
+			unreplayed = append(unreplayed, i)
+		}
+	}
+
+	return unreplayed
+}
+
+// scrubQuery returns the encoded query q, with its scrubbed parameters redacted.
+func scrubQuery(q url.Values) string {
+	s := url.Values{}
+	for k, v := range q {
+		if scrubbedParams[k] {
+			s.Set(k, redacted)
+			continue
+		}
+		s[k] = v
+	}
+	return s.Encode()
+}
+
+// scrubBody returns the JSON body with its scrubbed fields redacted.
+func scrubBody(body []byte) json.RawMessage {
+	o := map[string]json.RawMessage{}
+	if json.Unmarshal(body, &o) != nil {
+		return body
+	}
+
+	for _, f := range scrubbedFields {
+		if _, ok := o[f]; ok {
+			o[f] = json.RawMessage(`"` + redacted + `"`)
+		}
+	}
+
+	b, err := json.Marshal(o)
+	if err != nil {
+		return body
+	}
+
+	return b
+}
@@ -0,0 +1,72 @@
+package sdktest_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+	"github.com/seborama/pcloud-sdk/sdk/sdktest"
+)
+
+func TestRecorder(t *testing.T) {
+	cassette := filepath.Join(t.TempDir(), "testdata", "cassette.json")
+	ctx := context.Background()
+
+	// scenario is run against pCloud when recording and against the cassette when replaying.
+	scenario := func(pcc *sdk.Client) (*sdk.FSList, []byte) {
+		err := pcc.Login(ctx, "", sdk.WithGlobalOptionUsername("user@example.com"), sdk.WithGlobalOptionPassword("secret"))
+		require.NoError(t, err)
+
+		lf, err := pcc.List(ctx, sdk.ByPath("/Docs"), sdk.ListOptions{})
+		require.NoError(t, err)
+
+		f, err := pcc.FileOpen(ctx, 0, sdk.ByPath("/Docs/a.txt"))
+		require.NoError(t, err)
+
+		data, err := pcc.FileRead(ctx, f.FD, 100)
+		require.NoError(t, err)
+
+		return lf, data
+	}
+
+	srv := sdktest.NewServer(sdktest.WithCredentials("user@example.com", "secret"))
+	defer srv.Close()
+
+	_, err := srv.WriteFile("/Docs/a.txt", []byte("hello"))
+	require.NoError(t, err)
+
+	rec, err := sdktest.NewRecorder(cassette, sdktest.Record, srv.Client().Transport)
+	require.NoError(t, err)
+
+	recordedList, recordedData := scenario(sdk.NewClient(rec.Client(), sdk.WithBaseHost(srv.Host())))
+	require.NoError(t, rec.Save())
+
+	b, err := os.ReadFile(cassette)
+	require.NoError(t, err)
+	assert.NotContains(t, string(b), "secret")
+	assert.NotContains(t, string(b), "user@example.com")
+	assert.Contains(t, string(b), "REDACTED")
+
+	srv.Close()
+
+	rec, err = sdktest.NewRecorder(cassette, sdktest.Replay, nil)
+	require.NoError(t, err)
+
+	replayedList, replayedData := scenario(sdk.NewClient(rec.Client()))
+	assert.Equal(t, recordedList, replayedList)
+	assert.Equal(t, recordedData, replayedData)
+	assert.Empty(t, rec.Unreplayed())
+
+	_, err = sdk.NewClient(rec.Client()).Stat(ctx, sdk.ByPath("/Docs/a.txt"))
+	assert.ErrorContains(t, err, "no recorded interaction")
+}
+
+func TestNewRecorder_MissingCassette(t *testing.T) {
+	_, err := sdktest.NewRecorder(filepath.Join(t.TempDir(), "missing.json"), sdktest.Replay, nil)
+	assert.Error(t, err)
+}
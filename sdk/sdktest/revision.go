@@ -0,0 +1,59 @@
+package sdktest
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+// https://docs.pcloud.com/methods/revisions/listrevisions.html
+func (s *Server) listRevisions(q url.Values, _ *http.Request) (any, error) {
+	n, err := s.fileParam(q)
+	if err != nil {
+		return nil, err
+	}
+
+	// the most recent revision first, as pCloud lists them.
+	revisions := make([]obj, 0, len(n.revisions))
+	for i := len(n.revisions) - 1; i >= 0; i-- {
+		r := n.revisions[i]
+		revisions = append(revisions, obj{
+			"revisionid": r.id,
+			"size":       len(r.data),
+			"hash":       (&node{data: r.data}).hash(),
+			"created":    r.created.Format(time.RFC1123Z),
+		})
+	}
+
+	return obj{"metadata": n.metadata(0, false, false), "revisions": revisions}, nil
+}
+
+// https://docs.pcloud.com/methods/revisions/revertrevision.html
+func (s *Server) revertRevision(q url.Values, _ *http.Request) (any, error) {
+	n, err := s.fileParam(q)
+	if err != nil {
+		return nil, err
+	}
+
+	revisionID, err := uintParam(q, "revisionid", sdk.ErrRevisionNotFound)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range n.revisions {
+		if r.id != revisionID {
+			continue
+		}
+
+		data := r.data
+		s.fs.addRevision(n)
+		n.data = append([]byte(nil), data...)
+		n.modified = s.fs.now()
+
+		return obj{"metadata": n.metadata(0, false, false)}, nil
+	}
+
+	return nil, apiError(sdk.ErrRevisionNotFound)
+}
@@ -0,0 +1,483 @@
+// Package sdktest provides a fake pCloud API server for tests.
+//
+// The Server implements the subset of the pCloud API that the SDK supports for authentication,
+// folders, files, file operations, links, public links, shares, revisions, the trash, the
+// keys of Crypto folders and the events of diff, over an in-memory file system. It lets the
+// test suites of projects that use the SDK run without credentials or network access:
+//
+//	srv := sdktest.NewServer()
+//	defer srv.Close()
+//
+//	pcc := srv.NewClient()
+//	_, err := pcc.CreateFolder(ctx, sdk.ByPath("/Photos"))
+//
+// The Server mimics the behaviour of pCloud closely enough for the needs of most tests but it
+// is not a complete reimplementation: thumbs, etc are not supported, shares are only recorded
+// (see AddShareRequest), revisions are kept for good, and diff only reports the events of the
+// files and folders, computed when it is called.
+//
+// The package also provides the Recorder, which records the interactions with pCloud in golden
+// files and replays them, for tests that need the exact responses of the real API.
+package sdktest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+// obj is a JSON object of a response.
+type obj map[string]any
+
+// handler serves a call to an API method. It returns the JSON object of the response, or the
+// raw data of the response when it is a []byte.
+type handler func(s *Server, q url.Values, r *http.Request) (any, error)
+
+// Server is a fake pCloud API server. It is safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	// lock serialises the calls to the Server.
+	lock sync.Mutex
+
+	fs *fileSystem
+
+	diffs diffJournal
+
+	// see WithCredentials.
+	username    string
+	password    string
+	tokens      map[string]*authToken
+	lastTokenID uint64
+	digests     map[string]bool
+
+	fds    map[uint64]*fileDescriptor
+	lastFD uint64
+
+	pubLinks   map[uint64]*pubLink
+	lastLinkID uint64
+
+	shares        map[uint64]*share
+	shareRequests map[uint64]*share
+	lastShareID   uint64
+
+	// the Crypto keys of the user, see crypto_setuserkeys.
+	cryptoPrivateKey string
+	cryptoPublicKey  string
+	cryptoHint       string
+
+	handlers map[string]handler
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithCredentials makes the Server require authentication: only username and password are
+// accepted by login and userinfo, and the other methods require the auth token they return.
+// By default, any credentials are accepted and no authentication is required.
+func WithCredentials(username, password string) Option {
+	return func(s *Server) {
+		s.username = username
+		s.password = password
+	}
+}
+
+// WithClock sets the function that gives the current time to the Server, which it uses for
+// the creation and modification times of files and folders. By default, time.Now is used.
+func WithClock(now func() time.Time) Option {
+	return func(s *Server) {
+		s.fs.now = func() time.Time { return now().UTC().Truncate(time.Second) }
+	}
+}
+
+// NewServer starts and returns a new Server, with an empty file system.
+// The caller should call Close when finished, to shut it down.
+func NewServer(opts ...Option) *Server {
+	s := &Server{
+		fs:      newFileSystem(func() time.Time { return time.Now().UTC().Truncate(time.Second) }),
+		tokens:  map[string]*authToken{},
+		digests: map[string]bool{},
+		fds:     map[uint64]*fileDescriptor{},
+
+		pubLinks:      map[uint64]*pubLink{},
+		shares:        map[uint64]*share{},
+		shareRequests: map[uint64]*share{},
+		handlers: map[string]handler{
+			"userinfo": (*Server).userInfo,
+			"login":    (*Server).login,
+			"logout":   (*Server).logout,
+			"diff":     (*Server).diff,
+
+			"listtokens":   (*Server).listTokens,
+			"getdigest":    (*Server).getDigest,
+			"oauth2_token": (*Server).oauth2Token,
+
+			"listfolder":              (*Server).listFolder,
+			"createfolder":            (*Server).createFolder,
+			"createfolderifnotexists": (*Server).createFolderIfNotExists,
+			"deletefolder":            (*Server).deleteFolder,
+			"deletefolderrecursive":   (*Server).deleteFolderRecursive,
+			"renamefolder":            (*Server).renameFolder,
+			"copyfolder":              (*Server).copyFolder,
+
+			"uploadfile":   (*Server).uploadFile,
+			"deletefile":   (*Server).deleteFile,
+			"renamefile":   (*Server).renameFile,
+			"copyfile":     (*Server).copyFile,
+			"stat":         (*Server).stat,
+			"checksumfile": (*Server).checksumFile,
+			"getfilelink":  (*Server).getFileLink,
+
+			"file_open":        (*Server).fileOpen,
+			"file_write":       (*Server).fileWrite,
+			"file_read":        (*Server).fileRead,
+			"file_pread":       (*Server).filePRead,
+			"file_pread_ifmod": (*Server).filePReadIfMod,
+			"file_checksum":    (*Server).fileChecksum,
+			"file_seek":        (*Server).fileSeek,
+			"file_close":       (*Server).fileClose,
+
+			"listrevisions":  (*Server).listRevisions,
+			"revertrevision": (*Server).revertRevision,
+
+			"crypto_setuserkeys":  (*Server).cryptoSetUserKeys,
+			"crypto_getuserkeys":  (*Server).cryptoGetUserKeys,
+			"crypto_getuserhint":  (*Server).cryptoGetUserHint,
+			"crypto_getfolderkey": (*Server).cryptoGetFolderKey,
+			"crypto_getfilekey":   (*Server).cryptoGetFileKey,
+
+			"trash_list":    (*Server).trashList,
+			"trash_restore": (*Server).trashRestore,
+			"trash_clear":   (*Server).trashClear,
+
+			"getfilepublink":   (*Server).getFilePubLink,
+			"getfolderpublink": (*Server).getFolderPubLink,
+			"listpublinks":     (*Server).listPubLinks,
+			"changepublink":    (*Server).changePubLink,
+			"deletepublink":    (*Server).deletePubLink,
+
+			"sharefolder":        (*Server).shareFolder,
+			"listshares":         (*Server).listShares,
+			"acceptshare":        (*Server).acceptShare,
+			"declineshare":       (*Server).declineShare,
+			"cancelsharerequest": (*Server).cancelShareRequest,
+			"removeshare":        (*Server).removeShare,
+		},
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.Server = httptest.NewTLSServer(http.HandlerFunc(s.serveHTTP))
+
+	return s
+}
+
+// Host returns the host (and port) of the Server. See sdk.WithBaseHost.
+func (s *Server) Host() string {
+	return strings.TrimPrefix(s.URL, "https://")
+}
+
+// NewClient returns a new sdk.Client that sends its requests to the Server.
+// opts are applied after the options that point the Client at the Server.
+func (s *Server) NewClient(opts ...sdk.Option) *sdk.Client {
+	return sdk.NewClient(s.Client(), append([]sdk.Option{sdk.WithBaseHost(s.Host())}, opts...)...)
+}
+
+// MkdirAll creates the folder at path p, along with any missing parent folders.
+// It returns the folderid of the folder. This is convenient to prepare the file system of tests.
+func (s *Server) MkdirAll(p string) (uint64, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	n, err := s.mkdirAll(p)
+	if err != nil {
+		return 0, err
+	}
+
+	return n.id, nil
+}
+
+func (s *Server) mkdirAll(p string) (*node, error) {
+	n, err := s.fs.lookup(p)
+	if err != nil {
+		return nil, err
+	}
+	if n != nil {
+		if !n.isFolder {
+			return nil, apiError(sdk.ErrFileOrFolderAlreadyExists)
+		}
+		return n, nil
+	}
+
+	dir, name := splitPath(p)
+
+	parent, err := s.mkdirAll(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.fs.mkdir(parent, name)
+}
+
+// WriteFile creates or replaces the file at path p with data, creating any missing parent
+// folders. It returns the fileid of the file.
+func (s *Server) WriteFile(p string, data []byte) (uint64, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	dir, name := splitPath(p)
+
+	parent, err := s.mkdirAll(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	n, _, err := s.fs.mkfile(parent, name)
+	if err != nil {
+		return 0, err
+	}
+	n.data = append([]byte(nil), data...)
+
+	return n.id, nil
+}
+
+// ReadFile returns the contents of the file at path p.
+func (s *Server) ReadFile(p string) ([]byte, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	n, err := s.fs.lookup(p)
+	if err != nil {
+		return nil, err
+	}
+	if n == nil || n.isFolder {
+		return nil, apiError(sdk.ErrFileNotFound)
+	}
+
+	return append([]byte(nil), n.data...), nil
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if strings.HasPrefix(r.URL.Path, downloadPath) {
+		s.download(w, r)
+		return
+	}
+
+	q := r.URL.Query()
+	method := strings.TrimPrefix(r.URL.Path, "/")
+
+	h, ok := s.handlers[method]
+	if !ok {
+		writeJSON(w, errorResponse(apiError(sdk.ErrInternalError, "method not supported by sdktest: "+method)), q)
+		return
+	}
+
+	var resp any
+	err := s.authenticate(method, q)
+	if err == nil {
+		resp, err = h(s, q, r)
+	}
+	if err != nil {
+		writeJSON(w, errorResponse(err), q)
+		return
+	}
+
+	if b, ok := resp.([]byte); ok {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Length", strconv.Itoa(len(b)))
+		_, _ = w.Write(b)
+		return
+	}
+
+	writeJSON(w, resp.(obj), q)
+}
+
+// writeJSON writes the JSON response o. The id of the request is echoed back, as pCloud does.
+func writeJSON(w http.ResponseWriter, o obj, q url.Values) {
+	if _, ok := o["result"]; !ok {
+		o["result"] = 0
+	}
+	if id := q.Get("id"); id != "" {
+		o["id"] = id
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_ = json.NewEncoder(w).Encode(o)
+}
+
+// resultError is an error result of the API.
+type resultError struct {
+	code    int
+	message string
+}
+
+// Error implements Go's error interface.
+func (e *resultError) Error() string {
+	return fmt.Sprintf("%d: %s", e.code, e.message)
+}
+
+// apiError returns the error result code. The message is optional: the message documented by
+// pCloud for the code is the default.
+func apiError(code int, message ...string) error {
+	msg := sdk.Code(code).Message()
+	if len(message) > 0 {
+		msg = message[0]
+	}
+	return &resultError{code: code, message: msg}
+}
+
+// errorResponse returns the response for err.
+func errorResponse(err error) obj {
+	re, ok := err.(*resultError)
+	if !ok {
+		re = &resultError{code: sdk.ErrInternalError, message: err.Error()}
+	}
+	return obj{"result": re.code, "error": re.message}
+}
+
+// folderParam returns the folder referenced by the folderid or path parameter.
+func (s *Server) folderParam(q url.Values) (*node, error) {
+	switch {
+	case q.Has("folderid"):
+		id, err := uintParam(q, "folderid", sdk.ErrInvalidFolderID)
+		if err != nil {
+			return nil, err
+		}
+		n, ok := s.fs.folders[id]
+		if !ok {
+			return nil, apiError(sdk.ErrDirectoryNotExists)
+		}
+		return n, nil
+
+	case q.Has("path"):
+		n, err := s.fs.lookup(q.Get("path"))
+		if err != nil {
+			return nil, err
+		}
+		if n == nil || !n.isFolder {
+			return nil, apiError(sdk.ErrDirectoryNotExists)
+		}
+		return n, nil
+
+	default:
+		return nil, apiError(sdk.ErrFullPathOrFolderIDNotProvided)
+	}
+}
+
+// fileParam returns the file referenced by the fileid or path parameter.
+func (s *Server) fileParam(q url.Values) (*node, error) {
+	switch {
+	case q.Has("fileid"):
+		id, err := uintParam(q, "fileid", sdk.ErrInvalidFileID)
+		if err != nil {
+			return nil, err
+		}
+		n, ok := s.fs.files[id]
+		if !ok {
+			return nil, apiError(sdk.ErrFileNotFound)
+		}
+		return n, nil
+
+	case q.Has("path"):
+		n, err := s.fs.lookup(q.Get("path"))
+		if err != nil {
+			return nil, err
+		}
+		if n == nil || n.isFolder {
+			return nil, apiError(sdk.ErrFileNotFound)
+		}
+		return n, nil
+
+	default:
+		return nil, apiError(sdk.ErrFileIDOrPathNotProvided)
+	}
+}
+
+// parentParam returns the folder and the name referenced by the path parameter or by the
+// folderid and name parameters.
+func (s *Server) parentParam(q url.Values) (*node, string, error) {
+	if q.Has("path") {
+		return s.fs.parentOf(q.Get("path"))
+	}
+
+	if !q.Has("folderid") || !q.Has("name") {
+		return nil, "", apiError(sdk.ErrFullPathOrNameFolderIDNotProvided)
+	}
+
+	parent, err := s.folderParam(url.Values{"folderid": q["folderid"]})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return parent, q.Get("name"), nil
+}
+
+// destinationParam returns the folder and the name referenced by the topath parameter or by
+// the tofolderid and toname parameters. The name is empty when the destination is a folder,
+// in which case the source keeps its name.
+func (s *Server) destinationParam(q url.Values) (*node, string, error) {
+	if q.Has("topath") {
+		toPath := q.Get("topath")
+		if strings.HasSuffix(toPath, "/") {
+			n, err := s.folderParam(url.Values{"path": {toPath}})
+			return n, "", err
+		}
+		return s.fs.parentOf(toPath)
+	}
+
+	if !q.Has("tofolderid") {
+		return nil, "", apiError(sdk.ErrFullToPathOrToNameToFolderIDNotProvided)
+	}
+
+	parent, err := s.folderParam(url.Values{"folderid": q["tofolderid"]})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return parent, q.Get("toname"), nil
+}
+
+// uintParam returns the parameter called name as an unsigned integer. The result code
+// invalid is returned when it is not one.
+func uintParam(q url.Values, name string, invalid int) (uint64, error) {
+	v, err := strconv.ParseUint(q.Get(name), 10, 64)
+	if err != nil {
+		return 0, apiError(invalid)
+	}
+	return v, nil
+}
+
+// timeParam returns the parameter called name, a Unix timestamp, as a time. It returns the zero
+// time when the parameter is absent.
+func timeParam(q url.Values, name string) time.Time {
+	v, err := strconv.ParseInt(q.Get(name), 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(v, 0).UTC()
+}
+
+// boolParam returns true when the parameter called name is set, as the API does.
+func boolParam(q url.Values, name string) bool {
+	return q.Get(name) != "" && q.Get(name) != "0"
+}
+
+// splitPath splits path p into its folder and its name.
+func splitPath(p string) (string, string) {
+	p = strings.TrimSuffix(p, "/")
+	i := strings.LastIndex(p, "/")
+	return p[:i+1], p[i+1:]
+}
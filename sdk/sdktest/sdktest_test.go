@@ -0,0 +1,548 @@
+package sdktest_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+	"github.com/seborama/pcloud-sdk/sdk/sdktest"
+)
+
+func TestServer_Auth(t *testing.T) {
+	srv := sdktest.NewServer(sdktest.WithCredentials("user@example.com", "secret"))
+	defer srv.Close()
+
+	ctx := context.Background()
+	pcc := srv.NewClient()
+
+	_, err := pcc.List(ctx, sdk.ByID(sdk.RootFolderID), sdk.ListOptions{})
+	assert.Equal(t, sdk.ErrLoginRequired, sdk.ErrorCode(err))
+
+	err = pcc.Login(ctx, "", sdk.WithGlobalOptionUsername("user@example.com"), sdk.WithGlobalOptionPassword("wrong"))
+	assert.Equal(t, sdk.ErrLoginFailed, sdk.ErrorCode(err))
+
+	err = pcc.Login(ctx, "", sdk.WithGlobalOptionUsername("user@example.com"), sdk.WithGlobalOptionPassword("secret"))
+	require.NoError(t, err)
+
+	_, err = pcc.List(ctx, sdk.ByID(sdk.RootFolderID), sdk.ListOptions{})
+	require.NoError(t, err)
+
+	_, err = pcc.Logout(ctx)
+	require.NoError(t, err)
+}
+
+func TestServer_DigestAndOAuth2(t *testing.T) {
+	srv := sdktest.NewServer(sdktest.WithCredentials("User@example.com", "secret"))
+	defer srv.Close()
+
+	ctx := context.Background()
+
+	pcc := srv.NewClient()
+
+	err := pcc.LoginDigest(ctx, "", "User@example.com", "wrong")
+	assert.Equal(t, sdk.ErrLoginFailed, sdk.ErrorCode(err))
+
+	err = pcc.LoginDigest(ctx, "", "User@example.com", "secret")
+	require.NoError(t, err)
+
+	_, err = srv.NewClient(sdk.WithAuthToken(pcc.AuthToken())).List(ctx, sdk.ByID(sdk.RootFolderID), sdk.ListOptions{})
+	require.NoError(t, err)
+
+	_, err = pcc.OAuth2Token(ctx, "client", "secret", "")
+	assert.Equal(t, sdk.ErrInvalidCodeProvided, sdk.ErrorCode(err))
+
+	t2, err := pcc.OAuth2Token(ctx, "client", "secret", "code")
+	require.NoError(t, err)
+	assert.Equal(t, sdk.RegionEU, t2.Region())
+
+	_, err = srv.NewClient(sdk.WithOAuth2AccessToken(t2.AccessToken)).List(ctx, sdk.ByID(sdk.RootFolderID), sdk.ListOptions{})
+	require.NoError(t, err)
+
+	_, err = srv.NewClient(sdk.WithOAuth2AccessToken("invalid")).List(ctx, sdk.ByID(sdk.RootFolderID), sdk.ListOptions{})
+	assert.Equal(t, sdk.ErrLoginRequired, sdk.ErrorCode(err))
+}
+
+func TestServer_Folders(t *testing.T) {
+	srv := sdktest.NewServer()
+	defer srv.Close()
+
+	ctx := context.Background()
+	pcc := srv.NewClient()
+
+	photos, err := pcc.CreateFolder(ctx, sdk.ByPath("/Photos"))
+	require.NoError(t, err)
+	assert.True(t, photos.Metadata.IsFolder)
+	assert.Equal(t, "Photos", photos.Metadata.Name)
+	assert.EqualValues(t, sdk.RootFolderID, photos.Metadata.ParentFolderID)
+
+	_, err = pcc.CreateFolder(ctx, sdk.ByPath("/Photos"))
+	assert.Equal(t, sdk.ErrFileOrFolderAlreadyExists, sdk.ErrorCode(err))
+
+	_, err = pcc.CreateFolder(ctx, sdk.ByPath("/Missing/2024"))
+	assert.Equal(t, sdk.ErrComponentOfParentDirectoryNotExists, sdk.ErrorCode(err))
+
+	y2024, err := pcc.CreateFolderIfNotExists(ctx, sdk.ByIDName(photos.Metadata.FolderID, "2024"))
+	require.NoError(t, err)
+
+	again, err := pcc.CreateFolderIfNotExists(ctx, sdk.ByPath("/Photos/2024"))
+	require.NoError(t, err)
+	assert.Equal(t, y2024.Metadata.FolderID, again.Metadata.FolderID)
+
+	_, err = srv.WriteFile("/Photos/2024/a.jpg", []byte("a"))
+	require.NoError(t, err)
+
+	lf, err := pcc.List(ctx, sdk.ByPath("/"), sdk.ListOptions{Recursive: true})
+	require.NoError(t, err)
+	require.Len(t, lf.Metadata.Contents, 1)
+	require.Len(t, lf.Metadata.Contents[0].Contents, 1)
+	require.Len(t, lf.Metadata.Contents[0].Contents[0].Contents, 1)
+	assert.Equal(t, "a.jpg", lf.Metadata.Contents[0].Contents[0].Contents[0].Name)
+	assert.Equal(t, "image/jpeg", lf.Metadata.Contents[0].Contents[0].Contents[0].ContentType)
+
+	lf, err = pcc.List(ctx, sdk.ByPath("/Photos/2024"), sdk.ListOptions{NoFiles: true})
+	require.NoError(t, err)
+	assert.Empty(t, lf.Metadata.Contents)
+
+	_, err = pcc.DeleteFolder(ctx, sdk.ByPath("/Photos"))
+	assert.Equal(t, sdk.ErrFolderNotEmpty, sdk.ErrorCode(err))
+
+	_, err = pcc.RenameFolder(ctx, sdk.ByPath("/Photos"), sdk.ByPath("/Photos/2024/Photos"))
+	assert.Equal(t, sdk.ErrCannotMoveFolderToSubfolder, sdk.ErrorCode(err))
+
+	_, err = pcc.CreateFolder(ctx, sdk.ByPath("/Archive"))
+	require.NoError(t, err)
+
+	_, err = pcc.RenameFolder(ctx, sdk.ByID(y2024.Metadata.FolderID), sdk.ByPath("/Archive/"))
+	require.NoError(t, err)
+
+	_, err = pcc.CopyFolderTo(ctx, sdk.ByPath("/Archive"), sdk.ByPath("/Photos"), sdk.CopyFolderOptions{})
+	require.NoError(t, err)
+
+	data, err := srv.ReadFile("/Photos/Archive/2024/a.jpg")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("a"), data)
+
+	_, err = pcc.CopyFolderTo(ctx, sdk.ByPath("/Archive"), sdk.ByPath("/Photos"), sdk.CopyFolderOptions{CopyContentOnly: true})
+	require.NoError(t, err)
+
+	data, err = srv.ReadFile("/Photos/2024/a.jpg")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("a"), data)
+
+	dr, err := pcc.DeleteFolderRecursive(ctx, sdk.ByPath("/Photos"))
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, dr.DeletedFiles)
+	assert.EqualValues(t, 4, dr.DeletedFolders)
+
+	_, err = pcc.List(ctx, sdk.ByPath("/Photos"), sdk.ListOptions{})
+	assert.Equal(t, sdk.ErrDirectoryNotExists, sdk.ErrorCode(err))
+
+	_, err = pcc.DeleteFolderRecursive(ctx, sdk.ByID(sdk.RootFolderID))
+	assert.Equal(t, sdk.ErrCannotDeleteRootFolder, sdk.ErrorCode(err))
+}
+
+func TestServer_Files(t *testing.T) {
+	srv := sdktest.NewServer(sdktest.WithClock(func() time.Time { return time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC) }))
+	defer srv.Close()
+
+	ctx := context.Background()
+	pcc := srv.NewClient()
+
+	folderID, err := srv.MkdirAll("/Docs")
+	require.NoError(t, err)
+
+	f, err := os.Create(filepath.Join(t.TempDir(), "a.txt"))
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+	_, err = f.WriteString("hello")
+	require.NoError(t, err)
+	_, err = f.Seek(0, io.SeekStart)
+	require.NoError(t, err)
+
+	fu, err := pcc.UploadFiles(ctx, sdk.ByID(folderID), map[string]*os.File{"a.txt": f}, sdk.UploadOptions{})
+	require.NoError(t, err)
+	require.Len(t, fu.Metadata, 1)
+	assert.EqualValues(t, 5, fu.Metadata[0].Size)
+	assert.Equal(t, "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d", fu.Checksums[0].SHA1)
+	assert.Equal(t, time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), fu.Metadata[0].Modified.UTC())
+	fileID := fu.FileIDs[0]
+
+	_, err = f.Seek(0, io.SeekStart)
+	require.NoError(t, err)
+
+	fu, err = pcc.UploadFiles(ctx, sdk.ByPath("/Docs"), map[string]*os.File{"a.txt": f}, sdk.UploadOptions{RenameIfExists: true})
+	require.NoError(t, err)
+	assert.Equal(t, "a (1).txt", fu.Metadata[0].Name)
+
+	st, err := pcc.Stat(ctx, sdk.ByPath("/Docs/a.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, fileID, st.Metadata.FileID)
+
+	fc, err := pcc.ChecksumFile(ctx, sdk.ByID(fileID))
+	require.NoError(t, err)
+	assert.Equal(t, "5d41402abc4b2a76b9719d911017c592", fc.MD5)
+	assert.NotZero(t, fc.Metadata.Hash)
+
+	fr, err := pcc.RenameFile(ctx, sdk.ByPath("/Docs/a (1).txt"), sdk.ByPath("/Docs/a.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, fileID, fr.Metadata.DeletedFileID)
+
+	_, err = pcc.CopyFileTo(ctx, sdk.ByPath("/Docs/a.txt"), sdk.ByPath("/b.txt"), sdk.CopyFileOptions{})
+	require.NoError(t, err)
+
+	_, err = pcc.CopyFileTo(ctx, sdk.ByPath("/Docs/a.txt"), sdk.ByIDName(sdk.RootFolderID, "b.txt"), sdk.CopyFileOptions{NoOver: true})
+	assert.Equal(t, sdk.ErrFileOrFolderAlreadyExists, sdk.ErrorCode(err))
+
+	fl, err := pcc.DownloadLink(ctx, sdk.ByPath("/b.txt"), sdk.DownloadLinkOptions{})
+	require.NoError(t, err)
+
+	resp, err := srv.Client().Get(fl.Hosts[0] + fl.Path)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+	assert.Equal(t, "text/plain; charset=utf-8", resp.Header.Get("Content-Type"))
+
+	fr, err = pcc.DeleteFile(ctx, sdk.ByPath("/b.txt"))
+	require.NoError(t, err)
+	assert.True(t, fr.Metadata.IsDeleted)
+
+	_, err = pcc.Stat(ctx, sdk.ByPath("/b.txt"))
+	assert.Equal(t, sdk.ErrFileNotFound, sdk.ErrorCode(err))
+}
+
+func TestServer_FileOps(t *testing.T) {
+	srv := sdktest.NewServer()
+	defer srv.Close()
+
+	ctx := context.Background()
+	pcc := srv.NewClient()
+
+	_, err := pcc.FileOpen(ctx, sdk.O_WRITE, sdk.ByPath("/a.txt"))
+	assert.Equal(t, sdk.ErrFileNotFound, sdk.ErrorCode(err))
+
+	f, err := pcc.FileOpen(ctx, sdk.O_CREAT|sdk.O_EXCL, sdk.ByIDName(sdk.RootFolderID, "a.txt"))
+	require.NoError(t, err)
+
+	fdt, err := pcc.FileWrite(ctx, f.FD, []byte("hello world"))
+	require.NoError(t, err)
+	assert.EqualValues(t, 11, fdt.Bytes)
+
+	fs, err := pcc.FileSeek(ctx, f.FD, 6, sdk.WhenceFromBeginning)
+	require.NoError(t, err)
+	assert.EqualValues(t, 6, fs.Offset)
+
+	data, err := pcc.FileRead(ctx, f.FD, 100)
+	require.NoError(t, err)
+	assert.Equal(t, "world", string(data))
+
+	data, err = pcc.FileRead(ctx, f.FD, 100)
+	require.NoError(t, err)
+	assert.Empty(t, data)
+
+	data, err = pcc.FilePRead(ctx, f.FD, 5, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+
+	pfc, err := pcc.FileChecksum(ctx, f.FD, 5, 0)
+	require.NoError(t, err)
+	assert.EqualValues(t, 5, pfc.Size)
+
+	_, err = pcc.FilePReadIfMod(ctx, f.FD, 5, 0, sdk.T5SHA1(pfc.SHA1))
+	assert.Equal(t, sdk.ErrNotModified, sdk.ErrorCode(err))
+
+	err = pcc.FileClose(ctx, f.FD)
+	require.NoError(t, err)
+
+	_, err = pcc.FileRead(ctx, f.FD, 100)
+	assert.Equal(t, sdk.ErrInvalidOrClosedFileDescriptor, sdk.ErrorCode(err))
+
+	_, err = pcc.FileOpen(ctx, sdk.O_CREAT|sdk.O_EXCL, sdk.ByPath("/a.txt"))
+	assert.Equal(t, sdk.ErrFileOrFolderAlreadyExists, sdk.ErrorCode(err))
+
+	f, err = pcc.FileOpen(ctx, sdk.O_WRITE|sdk.O_APPEND, sdk.ByID(f.FileID))
+	require.NoError(t, err)
+
+	_, err = pcc.FileWrite(ctx, f.FD, []byte("!"))
+	require.NoError(t, err)
+
+	data, err = srv.ReadFile("/a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "hello world!", string(data))
+}
+
+func TestServer_PubLinks(t *testing.T) {
+	srv := sdktest.NewServer()
+	defer srv.Close()
+
+	ctx := context.Background()
+	pcc := srv.NewClient()
+
+	_, err := srv.WriteFile("/Docs/a.txt", []byte("a"))
+	require.NoError(t, err)
+
+	expires := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	fl, err := pcc.CreateFilePubLink(ctx, sdk.ByPath("/Docs/a.txt"), sdk.PubLinkOptions{Expire: expires, MaxDownloads: 10, ShortLink: true})
+	require.NoError(t, err)
+	assert.NotEmpty(t, fl.Link)
+	assert.NotEmpty(t, fl.ShortLink)
+
+	_, err = pcc.CreateFolderPubLink(ctx, sdk.ByPath("/Docs"), sdk.PubLinkOptions{})
+	require.NoError(t, err)
+
+	require.NoError(t, pcc.UpdatePubLink(ctx, fl.LinkID, sdk.UpdatePubLinkOptions{Password: "secret"}))
+
+	pl, err := pcc.ListPubLinks(ctx)
+	require.NoError(t, err)
+	require.Len(t, pl.PubLinks, 2)
+	assert.Equal(t, fl.Code, pl.PubLinks[0].Code)
+	assert.True(t, expires.Equal(pl.PubLinks[0].Expires))
+	assert.EqualValues(t, 10, pl.PubLinks[0].MaxDownloads)
+	assert.True(t, pl.PubLinks[0].HasPassword)
+	assert.Equal(t, "a.txt", pl.PubLinks[0].Metadata.Name)
+	assert.True(t, pl.PubLinks[1].Expires.IsZero())
+
+	require.NoError(t, pcc.DeletePubLink(ctx, fl.LinkID))
+	err = pcc.DeletePubLink(ctx, fl.LinkID)
+	assert.Equal(t, sdk.ErrInvalidOrDeletedLink, sdk.ErrorCode(err))
+}
+
+func TestServer_Shares(t *testing.T) {
+	srv := sdktest.NewServer()
+	defer srv.Close()
+
+	ctx := context.Background()
+	pcc := srv.NewClient()
+
+	_, err := srv.MkdirAll("/Team")
+	require.NoError(t, err)
+
+	err = pcc.ShareFolder(ctx, sdk.ByPath("/Team"), "friend@example.com", sdk.ShareCanCreate|sdk.ShareCanModify, "", "hello")
+	require.NoError(t, err)
+	err = pcc.ShareFolder(ctx, sdk.ByPath("/Team"), "friend@example.com", sdk.ShareCanCreate, "", "")
+	assert.Equal(t, sdk.ErrShareRequestAlreadyExists, sdk.ErrorCode(err))
+
+	accepted := srv.AddShareRequest("Holidays", "friend@example.com", sdk.ShareCanDelete)
+	declined := srv.AddShareRequest("Spam", "spam@example.com", 0)
+
+	sl, err := pcc.ListShares(ctx)
+	require.NoError(t, err)
+	require.Len(t, sl.Requests.Outgoing, 1)
+	assert.Equal(t, "Team", sl.Requests.Outgoing[0].ShareName)
+	assert.Equal(t, "friend@example.com", sl.Requests.Outgoing[0].ToMail)
+	assert.Equal(t, sdk.ShareCanCreate|sdk.ShareCanModify, sl.Requests.Outgoing[0].Permissions())
+	require.Len(t, sl.Requests.Incoming, 2)
+
+	require.NoError(t, pcc.AcceptShare(ctx, accepted, "", nil))
+	require.NoError(t, pcc.DeclineShare(ctx, declined))
+	require.NoError(t, pcc.CancelShareRequest(ctx, sl.Requests.Outgoing[0].ShareRequestID))
+	assert.Equal(t, sdk.ErrNonExistingShareRequest, sdk.ErrorCode(pcc.DeclineShare(ctx, declined)))
+
+	sl, err = pcc.ListShares(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, sl.Requests.Incoming)
+	assert.Empty(t, sl.Requests.Outgoing)
+	require.Len(t, sl.Shares.Incoming, 1)
+	assert.Equal(t, "Holidays", sl.Shares.Incoming[0].ShareName)
+	assert.Equal(t, sdk.ShareCanDelete, sl.Shares.Incoming[0].Permissions())
+
+	require.NoError(t, pcc.RemoveShare(ctx, sl.Shares.Incoming[0].ShareID))
+	assert.Equal(t, sdk.ErrInvalidShareID, sdk.ErrorCode(pcc.RemoveShare(ctx, sl.Shares.Incoming[0].ShareID)))
+}
+
+func TestServer_Trash(t *testing.T) {
+	srv := sdktest.NewServer()
+	defer srv.Close()
+
+	ctx := context.Background()
+	pcc := srv.NewClient()
+
+	fileID, err := srv.WriteFile("/Docs/a.txt", []byte("a"))
+	require.NoError(t, err)
+	_, err = srv.WriteFile("/Old/Sub/b.txt", []byte("b"))
+	require.NoError(t, err)
+
+	_, err = pcc.DeleteFile(ctx, sdk.ByID(fileID))
+	require.NoError(t, err)
+	dr, err := pcc.DeleteFolderRecursive(ctx, sdk.ByPath("/Old"))
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, dr.DeletedFiles)
+
+	lf, err := pcc.ListTrash(ctx, sdk.ListTrashOptions{})
+	require.NoError(t, err)
+	require.Len(t, lf.Metadata.Contents, 2)
+	assert.Equal(t, "a.txt", lf.Metadata.Contents[0].Name)
+	old := lf.Metadata.Contents[1]
+	assert.Equal(t, "Old", old.Name)
+	assert.Empty(t, old.Contents)
+
+	lf, err = pcc.ListTrash(ctx, sdk.ListTrashOptions{FolderID: old.FolderID, Recursive: true})
+	require.NoError(t, err)
+	require.Len(t, lf.Metadata.Contents, 1)
+	assert.Equal(t, "b.txt", lf.Metadata.Contents[0].Contents[0].Name)
+
+	// the folder of a.txt is gone: it is restored elsewhere.
+	_, err = pcc.DeleteFolderRecursive(ctx, sdk.ByPath("/Docs"))
+	require.NoError(t, err)
+	_, err = pcc.TrashRestore(ctx, sdk.T6FileByID(fileID), 0)
+	assert.Equal(t, sdk.ErrDirectoryNotExists, sdk.ErrorCode(err))
+	archive, err := srv.MkdirAll("/Archive")
+	require.NoError(t, err)
+	lr, err := pcc.TrashRestore(ctx, sdk.T6FileByID(fileID), archive)
+	require.NoError(t, err)
+	assert.Equal(t, "a.txt", lr.Metadata.Name)
+	assert.Equal(t, archive, lr.Metadata.ParentFolderID)
+
+	_, err = pcc.TrashRestore(ctx, sdk.T6FolderByID(old.FolderID), 0)
+	require.NoError(t, err)
+	data, err := srv.ReadFile("/Old/Sub/b.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "b", string(data))
+
+	require.NoError(t, pcc.TrashClear(ctx, sdk.T6FolderByID(0)))
+	lf, err = pcc.ListTrash(ctx, sdk.ListTrashOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, lf.Metadata.Contents)
+}
+
+func TestServer_Revisions(t *testing.T) {
+	srv := sdktest.NewServer()
+	defer srv.Close()
+
+	ctx := context.Background()
+	pcc := srv.NewClient()
+
+	for _, data := range []string{"one", "two", "three"} {
+		_, err := srv.WriteFile("/a.txt", []byte(data))
+		require.NoError(t, err)
+	}
+
+	rl, err := pcc.ListRevisions(ctx, sdk.ByPath("/a.txt"))
+	require.NoError(t, err)
+	require.Len(t, rl.Revisions, 2)
+	assert.EqualValues(t, 3, rl.Revisions[0].Size) // "two"
+	assert.EqualValues(t, 5, rl.Metadata.Size)
+
+	fr, err := pcc.RevertRevision(ctx, sdk.ByPath("/a.txt"), rl.Revisions[1].RevisionID)
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, fr.Metadata.Size)
+
+	data, err := srv.ReadFile("/a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "one", string(data))
+
+	rl, err = pcc.ListRevisions(ctx, sdk.ByPath("/a.txt"))
+	require.NoError(t, err)
+	assert.Len(t, rl.Revisions, 3)
+
+	_, err = pcc.RevertRevision(ctx, sdk.ByPath("/a.txt"), 999)
+	assert.Equal(t, sdk.ErrRevisionNotFound, sdk.ErrorCode(err))
+}
+
+func TestServer_Crypto(t *testing.T) {
+	srv := sdktest.NewServer()
+	defer srv.Close()
+
+	ctx := context.Background()
+	pcc := srv.NewClient()
+
+	_, err := pcc.CryptoGetUserKeys(ctx)
+	require.Error(t, err)
+
+	require.NoError(t, pcc.CryptoSetUserKeys(ctx, "private", "public", "hint"))
+	assert.Error(t, pcc.CryptoSetUserKeys(ctx, "private", "public", ""))
+
+	ui, err := pcc.UserInfo(ctx)
+	require.NoError(t, err)
+	assert.True(t, ui.CryptoSetup)
+
+	cuk, err := pcc.CryptoGetUserKeys(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "private", cuk.PrivateKey)
+	assert.Equal(t, "public", cuk.PublicKey)
+
+	lf, err := pcc.CreateFolder(ctx, sdk.ByPath("/Crypto"), sdk.WithCryptoKey("folder key"))
+	require.NoError(t, err)
+	assert.True(t, lf.Metadata.Encrypted)
+
+	ck, err := pcc.CryptoGetFolderKey(ctx, lf.Metadata.FolderID)
+	require.NoError(t, err)
+	assert.Equal(t, "folder key", ck.Key)
+
+	f, err := pcc.FileOpen(ctx, sdk.O_CREAT, sdk.ByIDName(lf.Metadata.FolderID, "ENCRYPTED"), sdk.WithCryptoKey("file key"))
+	require.NoError(t, err)
+	require.NoError(t, pcc.FileClose(ctx, f.FD))
+
+	ck, err = pcc.CryptoGetFileKey(ctx, f.FileID)
+	require.NoError(t, err)
+	assert.Equal(t, "file key", ck.Key)
+
+	lf, err = pcc.List(ctx, sdk.ByPath("/Crypto"), sdk.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, lf.Metadata.Contents, 1)
+	assert.True(t, lf.Metadata.Contents[0].Encrypted)
+
+	_, err = pcc.CryptoGetFolderKey(ctx, sdk.RootFolderID)
+	assert.Equal(t, sdk.ErrAccessDenied, sdk.ErrorCode(err))
+}
+
+func TestServer_Diff(t *testing.T) {
+	srv := sdktest.NewServer()
+	defer srv.Close()
+
+	ctx := context.Background()
+	pcc := srv.NewClient()
+
+	fileID, err := srv.WriteFile("/Docs/a.txt", []byte("a"))
+	require.NoError(t, err)
+
+	dr, err := pcc.Changes(ctx, 0, sdk.ChangesOptions{})
+	require.NoError(t, err)
+	require.Len(t, dr.Entries, 2)
+	assert.Equal(t, sdk.CreateFolder, dr.Entries[0].Event)
+	assert.Equal(t, "Docs", dr.Entries[0].Metadata.Name)
+	assert.Equal(t, sdk.CreateFile, dr.Entries[1].Event)
+	assert.Equal(t, fileID, dr.Entries[1].Metadata.FileID)
+	assert.Equal(t, uint64(2), dr.DiffID)
+
+	// no new events.
+	dr, err = pcc.Changes(ctx, dr.DiffID, sdk.ChangesOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, dr.Entries)
+	assert.Equal(t, uint64(2), dr.DiffID)
+
+	_, err = srv.WriteFile("/Docs/a.txt", []byte("a2"))
+	require.NoError(t, err)
+	_, err = pcc.RenameFolder(ctx, sdk.ByPath("/Docs"), sdk.ByPath("/Documents"))
+	require.NoError(t, err)
+
+	dr, err = pcc.Changes(ctx, 2, sdk.ChangesOptions{})
+	require.NoError(t, err)
+	events := []sdk.Event{}
+	for _, e := range dr.Entries {
+		events = append(events, e.Event)
+	}
+	// the file was replaced.
+	assert.Equal(t, []sdk.Event{sdk.ModifyFolder, sdk.CreateFile, sdk.DeleteFile}, events)
+	assert.Equal(t, fileID, dr.Entries[2].Metadata.FileID)
+	assert.True(t, dr.Entries[2].Metadata.IsDeleted)
+
+	dr, err = pcc.Changes(ctx, 0, sdk.ChangesOptions{Last: 2})
+	require.NoError(t, err)
+	assert.Len(t, dr.Entries, 2)
+	assert.Equal(t, uint64(5), dr.DiffID)
+
+	dr, err = pcc.Changes(ctx, 0, sdk.ChangesOptions{Limit: 1})
+	require.NoError(t, err)
+	assert.Len(t, dr.Entries, 1)
+	assert.Equal(t, uint64(1), dr.DiffID)
+}
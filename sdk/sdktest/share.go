@@ -0,0 +1,379 @@
+package sdktest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+// pubLink is a public link of the Server.
+type pubLink struct {
+	id          uint64
+	code        string
+	node        *node
+	created     time.Time
+	expires     time.Time
+	maxDown     uint64
+	maxTraffic  uint64
+	hasPassword bool
+}
+
+// share is a share, or a share request, of the Server. The Server has a single user: the
+// incoming shares and requests are made up by AddShareRequest, and the outgoing ones never
+// get accepted.
+type share struct {
+	id          uint64
+	incoming    bool
+	folderID    uint64
+	name        string
+	mail        string
+	message     string
+	permissions sdk.SharePermissions
+	created     time.Time
+}
+
+// AddShareRequest makes up a request from the user of the email address fromMail to share
+// their folder shareName with the user of the Server. It returns the sharerequestid of the
+// request, which can be accepted or declined.
+// The shared folders are not part of the file system of the Server.
+func (s *Server) AddShareRequest(shareName, fromMail string, permissions sdk.SharePermissions) uint64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.addShare(s.shareRequests, &share{incoming: true, name: shareName, mail: fromMail, permissions: permissions})
+}
+
+// addShare adds sh, a share or a share request, to shares. It returns its id.
+func (s *Server) addShare(shares map[uint64]*share, sh *share) uint64 {
+	s.lastShareID++
+	sh.id = s.lastShareID
+	sh.created = s.fs.now()
+	shares[sh.id] = sh
+
+	return sh.id
+}
+
+// https://docs.pcloud.com/methods/public_links/getfilepublink.html
+func (s *Server) getFilePubLink(q url.Values, _ *http.Request) (any, error) {
+	n, err := s.fileParam(q)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.newPubLink(n, q), nil
+}
+
+// https://docs.pcloud.com/methods/public_links/getfolderpublink.html
+func (s *Server) getFolderPubLink(q url.Values, _ *http.Request) (any, error) {
+	n, err := s.folderParam(q)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.newPubLink(n, q), nil
+}
+
+// newPubLink creates a public link to n, as per the parameters q.
+func (s *Server) newPubLink(n *node, q url.Values) obj {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+
+	s.lastLinkID++
+
+	pl := &pubLink{
+		id:         s.lastLinkID,
+		code:       hex.EncodeToString(b),
+		node:       n,
+		created:    s.fs.now(),
+		expires:    timeParam(q, "expire"),
+		maxDown:    optionalUintParam(q, "maxdownloads"),
+		maxTraffic: optionalUintParam(q, "maxtraffic"),
+	}
+	s.pubLinks[pl.id] = pl
+
+	o := obj{"linkid": pl.id, "code": pl.code, "link": pl.link()}
+	if boolParam(q, "shortlink") {
+		o["shortlink"] = "https://pc.cd/" + pl.code[:8]
+	}
+
+	return o
+}
+
+// link returns the URL of the public link.
+func (pl *pubLink) link() string {
+	return "https://u.pcloud.link/publink/show?code=" + pl.code
+}
+
+// https://docs.pcloud.com/methods/public_links/listpublinks.html
+func (s *Server) listPubLinks(_ url.Values, _ *http.Request) (any, error) {
+	ids := make([]uint64, 0, len(s.pubLinks))
+	for id := range s.pubLinks {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	links := []obj{}
+
+	for _, id := range ids {
+		pl := s.pubLinks[id]
+
+		o := obj{
+			"linkid":       pl.id,
+			"code":         pl.code,
+			"link":         pl.link(),
+			"created":      pl.created.Format(time.RFC1123Z),
+			"modified":     pl.created.Format(time.RFC1123Z),
+			"downloads":    0,
+			"traffic":      0,
+			"maxdownloads": pl.maxDown,
+			"maxtraffic":   pl.maxTraffic,
+			"haspassword":  pl.hasPassword,
+			"metadata":     pl.node.metadata(0, false, false),
+		}
+		if !pl.expires.IsZero() {
+			o["expires"] = pl.expires.Format(time.RFC1123Z)
+		}
+
+		links = append(links, o)
+	}
+
+	return obj{"publinks": links}, nil
+}
+
+// https://docs.pcloud.com/methods/public_links/changepublink.html
+func (s *Server) changePubLink(q url.Values, _ *http.Request) (any, error) {
+	pl, err := s.pubLinkParam(q)
+	if err != nil {
+		return nil, err
+	}
+
+	if q.Has("expire") {
+		pl.expires = timeParam(q, "expire")
+	}
+
+	if q.Has("linkpassword") {
+		pl.hasPassword = true
+	}
+
+	return obj{}, nil
+}
+
+// https://docs.pcloud.com/methods/public_links/deletepublink.html
+func (s *Server) deletePubLink(q url.Values, _ *http.Request) (any, error) {
+	pl, err := s.pubLinkParam(q)
+	if err != nil {
+		return nil, err
+	}
+
+	delete(s.pubLinks, pl.id)
+
+	return obj{}, nil
+}
+
+// pubLinkParam returns the public link referenced by the linkid parameter.
+func (s *Server) pubLinkParam(q url.Values) (*pubLink, error) {
+	if !q.Has("linkid") {
+		return nil, apiError(sdk.ErrLinkIDNotProvided)
+	}
+
+	id, err := uintParam(q, "linkid", sdk.ErrInvalidOrDeletedLink)
+	if err != nil {
+		return nil, err
+	}
+
+	pl, ok := s.pubLinks[id]
+	if !ok {
+		return nil, apiError(sdk.ErrInvalidOrDeletedLink)
+	}
+
+	return pl, nil
+}
+
+// https://docs.pcloud.com/methods/sharing/sharefolder.html
+func (s *Server) shareFolder(q url.Values, _ *http.Request) (any, error) {
+	n, err := s.folderParam(q)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.parent == nil {
+		return nil, apiError(sdk.ErrCannotShareRootFolder)
+	}
+
+	mail := q.Get("mail")
+	if mail == "" {
+		return nil, apiError(sdk.ErrMailNotProvidedForShare)
+	}
+	if mail == s.username {
+		return nil, apiError(sdk.ErrCannotShareWithOneself)
+	}
+
+	if !q.Has("permissions") {
+		return nil, apiError(sdk.ErrPermissionsNotProvidedForShare)
+	}
+
+	permissions, err := strconv.Atoi(q.Get("permissions"))
+	if err != nil {
+		return nil, apiError(sdk.ErrPermissionsNotProvidedForShare)
+	}
+
+	for _, sh := range s.shareRequests {
+		if !sh.incoming && sh.folderID == n.id && sh.mail == mail {
+			return nil, apiError(sdk.ErrShareRequestAlreadyExists)
+		}
+	}
+
+	name := q.Get("name")
+	if name == "" {
+		name = n.name
+	}
+
+	s.addShare(s.shareRequests, &share{
+		folderID:    n.id,
+		name:        name,
+		mail:        mail,
+		message:     q.Get("message"),
+		permissions: sdk.SharePermissions(permissions),
+	})
+
+	return obj{}, nil
+}
+
+// https://docs.pcloud.com/methods/sharing/listshares.html
+func (s *Server) listShares(_ url.Values, _ *http.Request) (any, error) {
+	return obj{
+		"shares":   sharesObj(s.shares, "shareid"),
+		"requests": sharesObj(s.shareRequests, "sharerequestid"),
+	}, nil
+}
+
+// sharesObj returns the incoming and outgoing shares, identified by the property idName.
+func sharesObj(shares map[uint64]*share, idName string) obj {
+	ids := make([]uint64, 0, len(shares))
+	for id := range shares {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	incoming, outgoing := []obj{}, []obj{}
+
+	for _, id := range ids {
+		sh := shares[id]
+
+		o := obj{
+			idName:      sh.id,
+			"folderid":  sh.folderID,
+			"sharename": sh.name,
+			"message":   sh.message,
+			"created":   sh.created.Format(time.RFC1123Z),
+			"canread":   true,
+			"cancreate": sh.permissions&sdk.ShareCanCreate != 0,
+			"canmodify": sh.permissions&sdk.ShareCanModify != 0,
+			"candelete": sh.permissions&sdk.ShareCanDelete != 0,
+		}
+
+		if sh.incoming {
+			o["frommail"] = sh.mail
+			incoming = append(incoming, o)
+		} else {
+			o["tomail"] = sh.mail
+			outgoing = append(outgoing, o)
+		}
+	}
+
+	return obj{"incoming": incoming, "outgoing": outgoing}
+}
+
+// https://docs.pcloud.com/methods/sharing/acceptshare.html
+func (s *Server) acceptShare(q url.Values, _ *http.Request) (any, error) {
+	sh, err := s.shareRequestParam(q, true)
+	if err != nil {
+		return nil, err
+	}
+
+	delete(s.shareRequests, sh.id)
+
+	if name := q.Get("name"); name != "" {
+		sh.name = name
+	}
+	s.addShare(s.shares, sh)
+
+	return obj{}, nil
+}
+
+// https://docs.pcloud.com/methods/sharing/declineshare.html
+func (s *Server) declineShare(q url.Values, _ *http.Request) (any, error) {
+	sh, err := s.shareRequestParam(q, true)
+	if err != nil {
+		return nil, err
+	}
+
+	delete(s.shareRequests, sh.id)
+
+	return obj{}, nil
+}
+
+// https://docs.pcloud.com/methods/sharing/cancelsharerequest.html
+func (s *Server) cancelShareRequest(q url.Values, _ *http.Request) (any, error) {
+	sh, err := s.shareRequestParam(q, false)
+	if err != nil {
+		return nil, err
+	}
+
+	delete(s.shareRequests, sh.id)
+
+	return obj{}, nil
+}
+
+// https://docs.pcloud.com/methods/sharing/removeshare.html
+func (s *Server) removeShare(q url.Values, _ *http.Request) (any, error) {
+	if !q.Has("shareid") {
+		return nil, apiError(sdk.ErrShareIDNotProvided)
+	}
+
+	id, err := uintParam(q, "shareid", sdk.ErrInvalidShareID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := s.shares[id]; !ok {
+		return nil, apiError(sdk.ErrInvalidShareID)
+	}
+
+	delete(s.shares, id)
+
+	return obj{}, nil
+}
+
+// shareRequestParam returns the share request, incoming or not, referenced by the
+// sharerequestid parameter.
+func (s *Server) shareRequestParam(q url.Values, incoming bool) (*share, error) {
+	if !q.Has("sharerequestid") {
+		return nil, apiError(sdk.ErrShareRequestIDNotProvided)
+	}
+
+	id, err := uintParam(q, "sharerequestid", sdk.ErrNonExistingShareRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	sh, ok := s.shareRequests[id]
+	if !ok || sh.incoming != incoming {
+		return nil, apiError(sdk.ErrNonExistingShareRequest)
+	}
+
+	return sh, nil
+}
+
+// optionalUintParam returns the parameter called name as an unsigned integer, or 0 when it is
+// absent or invalid.
+func optionalUintParam(q url.Values, name string) uint64 {
+	v, _ := strconv.ParseUint(q.Get(name), 10, 64)
+	return v
+}
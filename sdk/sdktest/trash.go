@@ -0,0 +1,158 @@
+package sdktest
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+// https://docs.pcloud.com/methods/trash/trash_list.html
+func (s *Server) trashList(q url.Values, _ *http.Request) (any, error) {
+	depth := 1
+	if boolParam(q, "recursive") {
+		depth = -1
+	}
+	noFiles := boolParam(q, "nofiles")
+
+	if folderID := optionalUintParam(q, "folderid"); folderID != 0 {
+		n := s.trashedFolder(folderID)
+		if n == nil {
+			return nil, apiError(sdk.ErrDirectoryNotExists)
+		}
+
+		return obj{"metadata": n.metadata(depth, noFiles, false)}, nil
+	}
+
+	contents := []obj{}
+	for _, t := range s.fs.trash {
+		if noFiles && !t.n.isFolder {
+			continue
+		}
+
+		m := t.n.metadata(depth-1, noFiles, false)
+		m["parentfolderid"] = t.parentID
+		m["isdeleted"] = true
+		contents = append(contents, m)
+	}
+
+	return obj{"metadata": obj{
+		"name":     "Trash",
+		"isfolder": true,
+		"folderid": 0,
+		"id":       "d0",
+		"contents": contents,
+	}}, nil
+}
+
+// https://docs.pcloud.com/methods/trash/trash_restore.html
+func (s *Server) trashRestore(q url.Values, _ *http.Request) (any, error) {
+	t, err := s.trashParam(q)
+	if err != nil {
+		return nil, err
+	}
+
+	parentID := t.parentID
+	if q.Has("restoreto") {
+		parentID, err = uintParam(q, "restoreto", sdk.ErrInvalidFolderID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	parent, ok := s.fs.folders[parentID]
+	if !ok {
+		return nil, apiError(sdk.ErrDirectoryNotExists)
+	}
+
+	err = s.fs.restore(t, parent)
+	if err != nil {
+		return nil, err
+	}
+
+	return obj{"metadata": t.n.metadata(0, false, false)}, nil
+}
+
+// https://docs.pcloud.com/methods/trash/trash_clear.html
+func (s *Server) trashClear(q url.Values, _ *http.Request) (any, error) {
+	if q.Has("folderid") && optionalUintParam(q, "folderid") == 0 {
+		s.fs.trash = nil
+		return obj{}, nil
+	}
+
+	t, err := s.trashParam(q)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, u := range s.fs.trash {
+		if u == t {
+			s.fs.trash = append(s.fs.trash[:i], s.fs.trash[i+1:]...)
+			break
+		}
+	}
+
+	return obj{}, nil
+}
+
+// trashParam returns the item of the trash referenced by the fileid or folderid parameter.
+// Only the files and folders deleted as such can be restored or cleared, not their contents.
+func (s *Server) trashParam(q url.Values) (*trashed, error) {
+	isFolder := q.Has("folderid")
+
+	var (
+		id  uint64
+		err error
+	)
+	switch {
+	case isFolder:
+		id, err = uintParam(q, "folderid", sdk.ErrInvalidFolderID)
+	case q.Has("fileid"):
+		id, err = uintParam(q, "fileid", sdk.ErrInvalidFileID)
+	default:
+		return nil, apiError(sdk.ErrFileIDOrPathNotProvided)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range s.fs.trash {
+		if t.n.id == id && t.n.isFolder == isFolder {
+			return t, nil
+		}
+	}
+
+	if isFolder {
+		return nil, apiError(sdk.ErrDirectoryNotExists)
+	}
+
+	return nil, apiError(sdk.ErrFileNotFound)
+}
+
+// trashedFolder returns the folder folderID of the trash, or one of its subfolders, or nil if
+// there is none.
+func (s *Server) trashedFolder(folderID uint64) *node {
+	var find func(n *node) *node
+	find = func(n *node) *node {
+		if !n.isFolder {
+			return nil
+		}
+		if n.id == folderID {
+			return n
+		}
+		for _, c := range n.children {
+			if f := find(c); f != nil {
+				return f
+			}
+		}
+		return nil
+	}
+
+	for _, t := range s.fs.trash {
+		if n := find(t.n); n != nil {
+			return n
+		}
+	}
+
+	return nil
+}
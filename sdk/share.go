@@ -0,0 +1,219 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+)
+
+// SharePermissions are the permissions granted on a shared folder, in addition to reading it.
+// They combine with a bitwise or.
+type SharePermissions int
+
+// The permissions of a share.
+const (
+	ShareCanCreate SharePermissions = 1
+	ShareCanModify SharePermissions = 2
+	ShareCanDelete SharePermissions = 4
+)
+
+// Share is a share of a folder with another user, or a request (invitation) to share a folder,
+// as listed by ListShares.
+type Share struct {
+	// ShareID identifies an accepted share, ShareRequestID a pending request.
+	ShareID        uint64
+	ShareRequestID uint64
+	FolderID       uint64
+	ShareName      string
+	// FromMail is set for the incoming shares, ToMail for the outgoing shares.
+	FromMail  string
+	ToMail    string
+	Message   string
+	Created   time.Time
+	Expires   time.Time
+	CanRead   bool
+	CanCreate bool
+	CanModify bool
+	CanDelete bool
+}
+
+// UnmarshalJSON decodes the Share, with its times in any of the formats of APITime.
+func (s *Share) UnmarshalJSON(data []byte) error {
+	type share Share
+	times := struct {
+		*share
+		Created APITime
+		Expires APITime
+	}{share: (*share)(s)}
+
+	err := json.Unmarshal(data, &times)
+	if err != nil {
+		return err
+	}
+
+	s.Created, s.Expires = times.Created.Time, times.Expires.Time
+
+	return nil
+}
+
+// Permissions returns the permissions of the share.
+func (s *Share) Permissions() SharePermissions {
+	var p SharePermissions
+
+	if s.CanCreate {
+		p |= ShareCanCreate
+	}
+	if s.CanModify {
+		p |= ShareCanModify
+	}
+	if s.CanDelete {
+		p |= ShareCanDelete
+	}
+
+	return p
+}
+
+// SharesDirections holds the incoming and the outgoing shares, or share requests.
+type SharesDirections struct {
+	Incoming []*Share
+	Outgoing []*Share
+}
+
+// SharesList contains the shares and the share requests returned by ListShares.
+type SharesList struct {
+	result
+	Shares   SharesDirections
+	Requests SharesDirections
+}
+
+// ShareFolder invites the user of the email address mail to share a folder, with the
+// permissions granted.
+// The optional parameters nameOpt and messageOpt set the name under which the folder is
+// shared, and a message sent with the invitation.
+// https://docs.pcloud.com/methods/sharing/sharefolder.html
+func (c *Client) ShareFolder(ctx context.Context, folder FolderRef, mail string, permissions SharePermissions, nameOpt, messageOpt string, opts ...ClientOption) error {
+	ctx, q := toQuery(ctx, opts...)
+	folder.setFolder(q, "")
+
+	q.Add("mail", mail)
+	q.Add("permissions", fmt.Sprintf("%d", permissions))
+
+	if nameOpt != "" {
+		q.Add("name", nameOpt)
+	}
+
+	if messageOpt != "" {
+		q.Add("message", messageOpt)
+	}
+
+	r := &result{}
+
+	err := c.parseAPIOutput(r)(c.get(ctx, "sharefolder", q))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ListShares returns the shares of the user and the pending share requests, incoming and
+// outgoing.
+// The response is decoded share by share as it is received, rather than read whole first.
+// https://docs.pcloud.com/methods/sharing/listshares.html
+func (c *Client) ListShares(ctx context.Context, opts ...ClientOption) (*SharesList, error) {
+	ctx, q := toQuery(ctx, opts...)
+
+	sl := &SharesList{}
+
+	// strict decoding checks whole responses.
+	if c.strictDecoding {
+		err := c.parseAPIOutput(sl)(c.get(ctx, "listshares", q))
+		if err != nil {
+			return nil, err
+		}
+
+		return sl, nil
+	}
+
+	err := c.stream(ctx, "listshares", q, func(r io.Reader) (result, error) {
+		sl = &SharesList{} // the call may be attempted more than once
+		return decodeStream(r, map[string]streamField{
+			"shares": func(dec *json.Decoder) error {
+				return decodeSharesDirections(dec, &sl.Shares)
+			},
+			"requests": func(dec *json.Decoder) error {
+				return decodeSharesDirections(dec, &sl.Requests)
+			},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sl, nil
+}
+
+// AcceptShare accepts the incoming share request shareRequestID.
+// The optional parameter nameOpt renames the shared folder, and folderOpt (which may be nil)
+// sets the folder in which it appears, instead of the root folder.
+// https://docs.pcloud.com/methods/sharing/acceptshare.html
+func (c *Client) AcceptShare(ctx context.Context, shareRequestID uint64, nameOpt string, folderOpt FolderRef, opts ...ClientOption) error {
+	ctx, q := toQuery(ctx, opts...)
+
+	q.Add("sharerequestid", fmt.Sprintf("%d", shareRequestID))
+
+	if nameOpt != "" {
+		q.Add("name", nameOpt)
+	}
+
+	if folderOpt != nil {
+		folderOpt.setFolder(q, "")
+	}
+
+	return c.shareCall(ctx, "acceptshare", q)
+}
+
+// DeclineShare declines the incoming share request shareRequestID.
+// https://docs.pcloud.com/methods/sharing/declineshare.html
+func (c *Client) DeclineShare(ctx context.Context, shareRequestID uint64, opts ...ClientOption) error {
+	ctx, q := toQuery(ctx, opts...)
+
+	q.Add("sharerequestid", fmt.Sprintf("%d", shareRequestID))
+
+	return c.shareCall(ctx, "declineshare", q)
+}
+
+// CancelShareRequest cancels the outgoing share request shareRequestID.
+// https://docs.pcloud.com/methods/sharing/cancelsharerequest.html
+func (c *Client) CancelShareRequest(ctx context.Context, shareRequestID uint64, opts ...ClientOption) error {
+	ctx, q := toQuery(ctx, opts...)
+
+	q.Add("sharerequestid", fmt.Sprintf("%d", shareRequestID))
+
+	return c.shareCall(ctx, "cancelsharerequest", q)
+}
+
+// RemoveShare removes the active share shareID, incoming or outgoing.
+// https://docs.pcloud.com/methods/sharing/removeshare.html
+func (c *Client) RemoveShare(ctx context.Context, shareID uint64, opts ...ClientOption) error {
+	ctx, q := toQuery(ctx, opts...)
+
+	q.Add("shareid", fmt.Sprintf("%d", shareID))
+
+	return c.shareCall(ctx, "removeshare", q)
+}
+
+// shareCall calls the sharing method, which returns no properties.
+func (c *Client) shareCall(ctx context.Context, method string, q url.Values) error {
+	r := &result{}
+
+	err := c.parseAPIOutput(r)(c.get(ctx, method, q))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,259 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+// streamDecoder decodes the body of a response incrementally, instead of reading it whole.
+// It returns the result of the call.
+type streamDecoder func(r io.Reader) (result, error)
+
+// stream executes an HTTPS (enforced) GET to the pCloud API endpoint and decodes the response
+// with decode.
+func (c *Client) stream(ctx context.Context, endpoint string, query url.Values, decode streamDecoder) error {
+	_, body, err := c.do(ctx, http.MethodGet, endpoint, query, "application/json", nil, decode)
+	return parseResult(body, err, &result{})
+}
+
+// streamField decodes the value of a field of the top level object of a response.
+type streamField func(dec *json.Decoder) error
+
+// decodeStream decodes the top level object of a response read from r, token by token.
+// The fields in fields are decoded by their streamField. The other fields are skipped.
+func decodeStream(r io.Reader, fields map[string]streamField) (result, error) {
+	res := result{}
+	dec := json.NewDecoder(r)
+
+	err := expectDelim(dec, '{')
+	if err != nil {
+		return res, err
+	}
+
+	for dec.More() {
+		key, err := decodeKey(dec)
+		if err != nil {
+			return res, err
+		}
+
+		switch {
+		case key == "result":
+			err = dec.Decode(&res.Result)
+		case key == "error":
+			err = dec.Decode(&res.Error)
+		case fields[key] != nil:
+			err = fields[key](dec)
+		default:
+			err = dec.Decode(&json.RawMessage{})
+		}
+		if err != nil {
+			return res, errors.Wrapf(err, "decode '%s'", key)
+		}
+	}
+
+	return res, expectDelim(dec, '}')
+}
+
+// decodeMetadata decodes a Metadata object and its contents, token by token.
+// fn, when not nil, is called with every Metadata decoded. A folder is passed to fn after its
+// contents.
+// When keepContents is false, the Contents of the folders are not populated so that memory
+// use does not grow with the size of the tree.
+func decodeMetadata(dec *json.Decoder, keepContents bool, fn func(m *Metadata) error) (*Metadata, error) {
+	err := expectDelim(dec, '{')
+	if err != nil {
+		return nil, err
+	}
+
+	// the fields other than contents are small: they are gathered and unmarshalled at once.
+	fields := bytes.Buffer{}
+	fields.WriteByte('{')
+
+	var contents []*Metadata
+
+	for dec.More() {
+		key, err := decodeKey(dec)
+		if err != nil {
+			return nil, err
+		}
+
+		if key == "contents" {
+			contents, err = decodeContents(dec, keepContents, fn)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		var raw json.RawMessage
+		err = dec.Decode(&raw)
+		if err != nil {
+			return nil, errors.Wrapf(err, "decode '%s'", key)
+		}
+
+		if fields.Len() > 1 {
+			fields.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		fields.Write(k)
+		fields.WriteByte(':')
+		fields.Write(raw)
+	}
+
+	err = expectDelim(dec, '}')
+	if err != nil {
+		return nil, err
+	}
+
+	fields.WriteByte('}')
+
+	m := &Metadata{}
+	err = json.Unmarshal(fields.Bytes(), m)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshal metadata")
+	}
+	m.Contents = contents
+
+	if fn != nil {
+		err = fn(m)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// decodeContents decodes the contents array of a folder Metadata. See decodeMetadata.
+func decodeContents(dec *json.Decoder, keepContents bool, fn func(m *Metadata) error) ([]*Metadata, error) {
+	err := expectDelim(dec, '[')
+	if err != nil {
+		return nil, err
+	}
+
+	var contents []*Metadata
+
+	for dec.More() {
+		m, err := decodeMetadata(dec, keepContents, fn)
+		if err != nil {
+			return nil, err
+		}
+
+		if keepContents {
+			contents = append(contents, m)
+		}
+	}
+
+	return contents, expectDelim(dec, ']')
+}
+
+// decodeEntries decodes the entries array of a diff, token by token, and calls fn with every
+// Entry.
+func decodeEntries(dec *json.Decoder, fn func(e *Entry) error) error {
+	err := expectDelim(dec, '[')
+	if err != nil {
+		return err
+	}
+
+	for dec.More() {
+		e := &Entry{}
+		err = dec.Decode(e)
+		if err != nil {
+			return errors.Wrap(err, "decode entry")
+		}
+
+		err = fn(e)
+		if err != nil {
+			return err
+		}
+	}
+
+	return expectDelim(dec, ']')
+}
+
+// decodeSharesDirections decodes the incoming and the outgoing shares, or share requests, of a
+// listshares response into d, share by share.
+func decodeSharesDirections(dec *json.Decoder, d *SharesDirections) error {
+	err := expectDelim(dec, '{')
+	if err != nil {
+		return err
+	}
+
+	for dec.More() {
+		key, err := decodeKey(dec)
+		if err != nil {
+			return err
+		}
+
+		switch key {
+		case "incoming":
+			d.Incoming, err = decodeShares(dec)
+		case "outgoing":
+			d.Outgoing, err = decodeShares(dec)
+		default:
+			err = dec.Decode(&json.RawMessage{})
+		}
+		if err != nil {
+			return errors.Wrapf(err, "decode '%s'", key)
+		}
+	}
+
+	return expectDelim(dec, '}')
+}
+
+// decodeShares decodes an array of shares, share by share.
+func decodeShares(dec *json.Decoder) ([]*Share, error) {
+	err := expectDelim(dec, '[')
+	if err != nil {
+		return nil, err
+	}
+
+	var shares []*Share
+
+	for dec.More() {
+		s := &Share{}
+		err = dec.Decode(s)
+		if err != nil {
+			return nil, errors.Wrap(err, "decode share")
+		}
+
+		shares = append(shares, s)
+	}
+
+	return shares, expectDelim(dec, ']')
+}
+
+// decodeKey decodes the key of an object field.
+func decodeKey(dec *json.Decoder) (string, error) {
+	t, err := dec.Token()
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	key, ok := t.(string)
+	if !ok {
+		return "", errors.Errorf("unexpected JSON token '%v': expected an object key", t)
+	}
+
+	return key, nil
+}
+
+// expectDelim decodes the delimiter delim.
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	t, err := dec.Token()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if d, ok := t.(json.Delim); !ok || d != delim {
+		return errors.Errorf("unexpected JSON token '%v': expected '%v'", t, delim)
+	}
+
+	return nil
+}
@@ -0,0 +1,161 @@
+package sdk_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+// the contents come before the other fields of the folders to check that they are decoded in
+// any order.
+const listFolderResponse = `{
+	"result": 0,
+	"metadata": {
+		"contents": [
+			{"name": "a.txt", "fileid": 10, "parentfolderid": 1, "isfolder": false, "size": 3},
+			{"contents": [{"name": "b.txt", "fileid": 20, "parentfolderid": 2, "isfolder": false}], "name": "sub", "folderid": 2, "parentfolderid": 1, "isfolder": true},
+			{"name": "c.txt", "fileid": 30, "parentfolderid": 1, "isfolder": false, "unknown": {"nested": [1, 2]}}
+		],
+		"name": "top",
+		"folderid": 1,
+		"isfolder": true,
+		"created": "Thu, 21 Mar 2013 18:31:45 +0000"
+	}
+}`
+
+func newStreamServer(t *testing.T) *httptest.Server {
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/listfolder":
+			if r.URL.Query().Get("folderid") != "1" {
+				fmt.Fprint(w, `{"result": 2005, "error": "Directory does not exist."}`)
+				return
+			}
+			assert.Equal(t, "1", r.URL.Query().Get("recursive"))
+			fmt.Fprint(w, listFolderResponse)
+		case "/diff":
+			fmt.Fprint(w, diffResponse)
+		case "/listshares":
+			fmt.Fprint(w, listSharesResponse)
+		default:
+			t.Errorf("unexpected call to %s", r.URL.Path)
+		}
+	}))
+}
+
+func TestListFolder_Stream(t *testing.T) {
+	srv := newStreamServer(t)
+	defer srv.Close()
+
+	pcc := sdk.NewClient(srv.Client(), sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")))
+
+	lf, err := pcc.List(context.Background(), sdk.ByID(1), sdk.ListOptions{Recursive: true})
+	require.NoError(t, err)
+	require.NotNil(t, lf.Metadata)
+	assert.Equal(t, "top", lf.Metadata.Name)
+	assert.Equal(t, 2013, lf.Metadata.Created.Year())
+	require.Len(t, lf.Metadata.Contents, 3)
+	assert.EqualValues(t, 3, lf.Metadata.Contents[0].Size)
+	require.Len(t, lf.Metadata.Contents[1].Contents, 1)
+	assert.Equal(t, "b.txt", lf.Metadata.Contents[1].Contents[0].Name)
+	assert.Equal(t, "c.txt", lf.Metadata.Contents[2].Name)
+
+	_, err = pcc.List(context.Background(), sdk.ByID(99), sdk.ListOptions{Recursive: true})
+	require.Error(t, err)
+	assert.Equal(t, sdk.ErrDirectoryNotExists, sdk.ErrorCode(err))
+}
+
+func TestListFolderFunc(t *testing.T) {
+	srv := newStreamServer(t)
+	defer srv.Close()
+
+	pcc := sdk.NewClient(srv.Client(), sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")))
+
+	var names []string
+	err := pcc.ListFunc(context.Background(), sdk.ByID(1), sdk.ListOptions{Recursive: true}, func(m *sdk.Metadata) error {
+		assert.Empty(t, m.Contents)
+		names = append(names, m.Name)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.txt", "b.txt", "sub", "c.txt", "top"}, names)
+
+	errStop := errors.New("stop")
+	names = nil
+	err = pcc.ListFunc(context.Background(), sdk.ByID(1), sdk.ListOptions{Recursive: true}, func(m *sdk.Metadata) error {
+		names = append(names, m.Name)
+		if len(names) == 2 {
+			return errStop
+		}
+		return nil
+	})
+	require.ErrorIs(t, err, errStop)
+	assert.Equal(t, []string{"a.txt", "b.txt"}, names)
+
+	err = pcc.ListFunc(context.Background(), sdk.ByID(99), sdk.ListOptions{Recursive: true}, func(m *sdk.Metadata) error {
+		t.Error("unexpected call")
+		return nil
+	})
+	assert.Equal(t, sdk.ErrDirectoryNotExists, sdk.ErrorCode(err))
+}
+
+func TestDiffFunc(t *testing.T) {
+	srv := newStreamServer(t)
+	defer srv.Close()
+
+	pcc := sdk.NewClient(srv.Client(), sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")))
+
+	var events []sdk.Event
+	diffID, err := pcc.ChangesFunc(context.Background(), 3, sdk.ChangesOptions{}, func(e *sdk.Entry) error {
+		events = append(events, e.Event)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.EqualValues(t, 4, diffID)
+	assert.Equal(t, []sdk.Event{sdk.CreateFile, sdk.DeleteFolder, sdk.RequestShareIn, sdk.ModifyUserInfo}, events)
+}
+
+const listSharesResponse = `{
+	"result": 0,
+	"requests": {
+		"incoming": [{"sharerequestid": 7, "frommail": "a@example.com", "canread": true, "created": "Thu, 21 Mar 2013 18:31:45 +0000"}],
+		"outgoing": []
+	},
+	"shares": {
+		"outgoing": [
+			{"shareid": 1, "folderid": 10, "tomail": "b@example.com", "cancreate": true, "unknown": {"nested": [1]}},
+			{"shareid": 2, "folderid": 20, "tomail": "c@example.com"}
+		],
+		"incoming": []
+	}
+}`
+
+func TestListShares_Stream(t *testing.T) {
+	srv := newStreamServer(t)
+	defer srv.Close()
+
+	pcc := sdk.NewClient(srv.Client(), sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")))
+
+	sl, err := pcc.ListShares(context.Background())
+	require.NoError(t, err)
+	require.Len(t, sl.Shares.Outgoing, 2)
+	assert.EqualValues(t, 1, sl.Shares.Outgoing[0].ShareID)
+	assert.Equal(t, "b@example.com", sl.Shares.Outgoing[0].ToMail)
+	assert.Equal(t, sdk.ShareCanCreate, sl.Shares.Outgoing[0].Permissions())
+	assert.EqualValues(t, 20, sl.Shares.Outgoing[1].FolderID)
+	assert.Empty(t, sl.Shares.Incoming)
+	require.Len(t, sl.Requests.Incoming, 1)
+	assert.EqualValues(t, 7, sl.Requests.Incoming[0].ShareRequestID)
+	assert.Equal(t, 2013, sl.Requests.Incoming[0].Created.Year())
+	assert.Empty(t, sl.Requests.Outgoing)
+}
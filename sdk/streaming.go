@@ -2,53 +2,88 @@ package sdk
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/url"
+	"time"
 )
 
-// FileLink contains the details of a file link, as provided by GetFileLink.
+// FileLink contains the details of a file link, as provided by DownloadLink.
 type FileLink struct {
 	result
 	Path    string
-	Expires APITime
+	Expires time.Time
 	Hosts   []string
 }
 
-// GetFileLink gets a download link for file Takes fileid (or path) as parameter and provides
-// links from which the file can be downloaded.
-// If the optional parameter forcedownload is set, the file will be served by the content server
-// with content type application/octet-stream, which typically forces user agents to save the
-// file.
-// Alternatively you can provide parameter contenttype with the Content-Type you wish the
-// content server to send. If these parameters are not set, the content type will depend on the
-// extension of the file.
-// Parameter maxspeed may be used if you wish to limit the download speed (in bytes per second)
-// for this download.
-// Finally you can set skipfilename so the link generated will not include the name of the file.
-// https://docs.pcloud.com/methods/streaming/getfilelink.html
-func (c *Client) GetFileLink(ctx context.Context, file T3PathOrFileID, forceDownloadOpt bool, contentTypeOpt string, maxSpeedOpt uint64, skipFilenameOpt bool, opts ...ClientOption) (*FileLink, error) {
-	q := toQuery(opts...)
-	file(q)
+// UnmarshalJSON decodes the FileLink, with its times in any of the formats of APITime.
+func (f *FileLink) UnmarshalJSON(data []byte) error {
+	type fileLink FileLink
+	times := struct {
+		*fileLink
+		Expires APITime
+	}{fileLink: (*fileLink)(f)}
+
+	err := json.Unmarshal(data, &times)
+	if err != nil {
+		return err
+	}
+
+	f.Expires = times.Expires.Time
+
+	return nil
+}
+
+// DownloadLinkOptions are the optional parameters of DownloadLink.
+type DownloadLinkOptions struct {
+	// ForceDownload if set, the file is served by the content server with content type
+	// application/octet-stream, which typically forces user agents to save the file.
+	ForceDownload bool
+
+	// ContentType, if not empty, is the Content-Type that the content server sends. If neither it
+	// nor ForceDownload is set, the content type depends on the extension of the file.
+	ContentType string
+
+	// MaxSpeed, if not 0, limits the download speed, in bytes per second.
+	MaxSpeed uint64
+
+	// SkipFilename if set, the link does not include the name of the file.
+	SkipFilename bool
+}
+
+// options returns the ClientOption's of the parameters of o that are set.
+func (o DownloadLinkOptions) options() []ClientOption {
+	var opts []ClientOption
 
-	if forceDownloadOpt {
-		q.Add("forcedownload", "1")
+	if o.ForceDownload {
+		opts = append(opts, WithForceDownload())
 	}
 
-	if contentTypeOpt != "" {
-		q.Add("contenttype", contentTypeOpt)
+	if o.ContentType != "" {
+		opts = append(opts, WithContentType(o.ContentType))
 	}
 
-	if maxSpeedOpt > 0 {
-		q.Add("maxspeed", fmt.Sprintf("%d", maxSpeedOpt))
+	if o.MaxSpeed > 0 {
+		opts = append(opts, WithMaxSpeed(o.MaxSpeed))
 	}
 
-	if skipFilenameOpt {
-		q.Add("skipfilename", "1")
+	if o.SkipFilename {
+		opts = append(opts, WithSkipFilename())
 	}
 
+	return opts
+}
+
+// DownloadLink gets a download link for file Takes fileid (or path) as parameter and provides
+// links from which the file can be downloaded.
+// https://docs.pcloud.com/methods/streaming/getfilelink.html
+func (c *Client) DownloadLink(ctx context.Context, file FileRef, o DownloadLinkOptions, opts ...ClientOption) (*FileLink, error) {
+	ctx, q := toQuery(ctx, withOptions(opts, o)...)
+	file.setFile(q)
+
 	fl := &FileLink{}
 
-	err := parseAPIOutput(fl)(c.get(ctx, "getfilelink", q))
+	err := c.parseAPIOutput(fl)(c.get(ctx, "getfilelink", q))
 	if err != nil {
 		return nil, err
 	}
@@ -60,11 +95,30 @@ func (c *Client) GetFileLink(ctx context.Context, file T3PathOrFileID, forceDown
 	return fl, nil
 }
 
+// GetFileLink is DownloadLink with its optional parameters passed positionally.
+// https://docs.pcloud.com/methods/streaming/getfilelink.html
+//
+// Deprecated: use DownloadLink.
+func (c *Client) GetFileLink(ctx context.Context, file FileRef, forceDownloadOpt bool, contentTypeOpt string, maxSpeedOpt uint64, skipFilenameOpt bool, opts ...ClientOption) (*FileLink, error) {
+	o := DownloadLinkOptions{
+		ForceDownload: forceDownloadOpt,
+		ContentType:   contentTypeOpt,
+		MaxSpeed:      maxSpeedOpt,
+		SkipFilename:  skipFilenameOpt,
+	}
+
+	return c.DownloadLink(ctx, file, o, opts...)
+}
+
 // T3PathOrFileID is a type of parameters that some of the SDK functions take.
 // Such functions have a dichotomic usage to reference a file: either by path or by fileid.
+//
+// Deprecated: use FileRef.
 type T3PathOrFileID func(q url.Values)
 
 // T3FileByPath is a type of T3PathOrFileID that references a file by path alone.
+//
+// Deprecated: use ByPath.
 func T3FileByPath(path string) T3PathOrFileID {
 	return func(q url.Values) {
 		q.Set("path", path)
@@ -72,6 +126,8 @@ func T3FileByPath(path string) T3PathOrFileID {
 }
 
 // T3FileByID is a type of T3PathOrFileID that references a file by path alone.
+//
+// Deprecated: use ByID.
 func T3FileByID(fileID uint64) T3PathOrFileID {
 	return func(q url.Values) {
 		q.Set("fileid", fmt.Sprintf("%d", fileID))
@@ -81,9 +137,13 @@ func T3FileByID(fileID uint64) T3PathOrFileID {
 // T4PathOrFileIDOrFolderIDName is a type of parameters that some of the SDK functions take.
 // Such functions have a trichotomic usage to reference a file:
 // by path, by fileid or by folderid and (file) name.
+//
+// Deprecated: use FileRef.
 type T4PathOrFileIDOrFolderIDName func(q url.Values)
 
 // T4FileByPath is a type of T4PathOrFileIDOrFolderIDName that references a file by path alone.
+//
+// Deprecated: use ByPath.
 func T4FileByPath(path string) T4PathOrFileIDOrFolderIDName {
 	return func(q url.Values) {
 		q.Set("path", path)
@@ -91,6 +151,8 @@ func T4FileByPath(path string) T4PathOrFileIDOrFolderIDName {
 }
 
 // T4FileByID is a type of T4PathOrFileIDOrFolderIDName that references a file by path alone.
+//
+// Deprecated: use ByID.
 func T4FileByID(fileID uint64) T4PathOrFileIDOrFolderIDName {
 	return func(q url.Values) {
 		q.Set("fileid", fmt.Sprintf("%d", fileID))
@@ -99,6 +161,8 @@ func T4FileByID(fileID uint64) T4PathOrFileIDOrFolderIDName {
 
 // T4FileByFolderIDName is a type of T4PathOrFileIDOrFolderIDName that references a file
 // by folderid and (file) name (within the folder).
+//
+// Deprecated: use ByIDName.
 func T4FileByFolderIDName(folderID uint64, name string) T4PathOrFileIDOrFolderIDName {
 	return func(q url.Values) {
 		q.Set("folderid", fmt.Sprintf("%d", folderID))
@@ -11,7 +11,7 @@ import (
 func (testsuite *IntegrationTestSuite) Test_GetFileLink() {
 	fileName := "go_pCloud_" + uuid.New().String() + ".txt"
 
-	f, err := testsuite.pcc.FileOpen(testsuite.ctx, sdk.O_CREAT|sdk.O_EXCL, sdk.T4FileByPath(testsuite.testFolderPath+"/"+fileName))
+	f, err := testsuite.pcc.FileOpen(testsuite.ctx, sdk.O_CREAT|sdk.O_EXCL, sdk.ByPath(testsuite.testFolderPath+"/"+fileName))
 	testsuite.Require().NoError(err)
 
 	fdt, err := testsuite.pcc.FileWrite(testsuite.ctx, f.FD, []byte(Lipsum))
@@ -21,7 +21,7 @@ func (testsuite *IntegrationTestSuite) Test_GetFileLink() {
 	err = testsuite.pcc.FileClose(testsuite.ctx, f.FD)
 	testsuite.Require().NoError(err)
 
-	fl, err := testsuite.pcc.GetFileLink(testsuite.ctx, sdk.T3FileByPath(testsuite.testFolderPath+"/"+fileName), true, "", 0, false)
+	fl, err := testsuite.pcc.DownloadLink(testsuite.ctx, sdk.ByPath(testsuite.testFolderPath+"/"+fileName), sdk.DownloadLinkOptions{ForceDownload: true})
 	testsuite.Require().NoError(err)
 	testsuite.Require().Equal(0, fl.Result)
 	testsuite.Require().GreaterOrEqual(len(fl.Path), 10)
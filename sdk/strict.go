@@ -0,0 +1,197 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// WithStrictDecoding makes the decoding of the responses of pCloud strict, in the manner of
+// json.Decoder.DisallowUnknownFields: the calls whose response has fields that the result
+// structs of the SDK do not capture fail with an *UnknownFieldsError, which lists them.
+// This lets the developers of the SDK, and its users, detect the fields that pCloud adds to its
+// responses. It is not meant for production use.
+// List and Changes read their responses whole rather than decode them as they are received.
+// The responses of ListFunc and ChangesFunc are not checked.
+func WithStrictDecoding() Option {
+	return func(c *Client) {
+		c.strictDecoding = true
+	}
+}
+
+// UnknownFieldsError is returned in strict decoding mode when a response has fields that the
+// SDK does not decode. See WithStrictDecoding.
+type UnknownFieldsError struct {
+	// Type is the Go type that the response is decoded into, such as "sdk.FSList".
+	Type string
+
+	// Fields are the paths of the unknown fields in the response, sorted, such as
+	// "metadata.contents[].newfield".
+	Fields []string
+}
+
+// Error implements Go's error interface.
+func (e *UnknownFieldsError) Error() string {
+	return fmt.Sprintf("%s: unknown fields: %s", e.Type, strings.Join(e.Fields, ", "))
+}
+
+// strictGlobalFields are the fields of the responses that are not part of their result but
+// of the protocol, such as the "id" global parameter.
+var strictGlobalFields = map[string]bool{
+	"result": true,
+	"error":  true,
+	"id":     true,
+}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	apiTimeType = reflect.TypeOf(APITime{})
+	rawType     = reflect.TypeOf(json.RawMessage{})
+)
+
+// checkUnknownFields returns an *UnknownFieldsError when body, the JSON response to a call, has
+// fields that v, the value it is decoded into, does not have.
+func checkUnknownFields(body []byte, v interface{}) error {
+	var raw interface{}
+
+	err := json.Unmarshal(body, &raw)
+	if err != nil {
+		return errors.Wrap(err, "unmarshal")
+	}
+
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil {
+		return nil
+	}
+
+	unknown := map[string]bool{}
+
+	if obj, ok := raw.(map[string]interface{}); ok {
+		for k := range strictGlobalFields {
+			delete(obj, k)
+		}
+	}
+
+	collectUnknownFields("", raw, t, unknown)
+
+	if len(unknown) == 0 {
+		return nil
+	}
+
+	fields := make([]string, 0, len(unknown))
+	for f := range unknown {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+
+	return errors.WithStack(&UnknownFieldsError{Type: t.String(), Fields: fields})
+}
+
+// collectUnknownFields adds the paths of the fields of raw that t does not have to unknown.
+// The paths of the elements of arrays end with "[]" so that the unknown fields of the many
+// elements of a list are reported once.
+func collectUnknownFields(path string, raw interface{}, t reflect.Type, unknown map[string]bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == timeType || t == apiTimeType || t == rawType {
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := raw.(map[string]interface{})
+		if !ok {
+			return
+		}
+
+		fields := jsonFields(t)
+
+		for k, v := range obj {
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+
+			ft, ok := fields[strings.ToLower(k)]
+			if !ok {
+				unknown[p] = true
+				continue
+			}
+
+			collectUnknownFields(p, v, ft, unknown)
+		}
+
+	case reflect.Slice, reflect.Array:
+		arr, ok := raw.([]interface{})
+		if !ok {
+			return
+		}
+
+		for _, v := range arr {
+			collectUnknownFields(path+"[]", v, t.Elem(), unknown)
+		}
+
+	case reflect.Map:
+		obj, ok := raw.(map[string]interface{})
+		if !ok {
+			return
+		}
+
+		for _, v := range obj {
+			collectUnknownFields(path+"[]", v, t.Elem(), unknown)
+		}
+	}
+}
+
+// jsonFields returns the types of the fields of the struct type t by their lower-cased JSON
+// name, as encoding/json matches them, including the fields promoted from its embedded structs.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, _, _ := strings.Cut(tag, ",")
+
+		ft := f.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			for k, v := range jsonFields(ft) {
+				if _, ok := fields[k]; !ok {
+					fields[k] = v
+				}
+			}
+			continue
+		}
+
+		if !f.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+
+		fields[strings.ToLower(name)] = f.Type
+	}
+
+	return fields
+}
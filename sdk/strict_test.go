@@ -0,0 +1,72 @@
+package sdk_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+func TestWithStrictDecoding(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.Path {
+		case "/getip":
+			fmt.Fprint(w, `{"result": 0, "id": "1", "ip": "1.2.3.4", "country": "fr"}`)
+		case "/listfolder":
+			fmt.Fprint(w, `{"result": 0, "metadata": {"name": "/", "folderid": 0, "isfolder": true, "created": "Thu, 21 Mar 2013 18:31:45 +0000", "contents": [
+				{"name": "a.txt", "fileid": 1, "category": 4, "newfield": 1},
+				{"name": "b.txt", "fileid": 2, "newfield": 2, "other": {"x": 1}}
+			]}, "cursor": "abc"}`)
+		case "/diff":
+			fmt.Fprint(w, `{"result": 0, "diffid": 1, "entries": [{"event": "createfile", "time": 1363890705, "diffid": 1, "metadata": {"name": "a.txt", "origin": "web"}}]}`)
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	host := strings.TrimPrefix(srv.URL, "https://")
+	ctx := context.Background()
+
+	pcc := sdk.NewClient(srv.Client(), sdk.WithBaseHost(host), sdk.WithStrictDecoding())
+
+	_, err := pcc.GetIP(ctx)
+	require.NoError(t, err)
+
+	_, err = pcc.List(ctx, sdk.ByID(sdk.RootFolderID), sdk.ListOptions{})
+	require.Error(t, err)
+
+	var ufe *sdk.UnknownFieldsError
+	require.True(t, errors.As(err, &ufe))
+	assert.Equal(t, "sdk.FSList", ufe.Type)
+	assert.Equal(t, []string{"cursor", "metadata.contents[].newfield", "metadata.contents[].other"}, ufe.Fields)
+	assert.Equal(t, "sdk.FSList: unknown fields: cursor, metadata.contents[].newfield, metadata.contents[].other", ufe.Error())
+
+	_, err = pcc.Changes(ctx, 0, sdk.ChangesOptions{})
+	require.True(t, errors.As(err, &ufe))
+	assert.Equal(t, []string{"entries[].metadata.origin"}, ufe.Fields)
+
+	var ip struct {
+		IP string
+	}
+	err = pcc.Call(ctx, "getip", nil, &ip)
+	require.True(t, errors.As(err, &ufe))
+	assert.Equal(t, []string{"country"}, ufe.Fields)
+
+	// without strict decoding, the unknown fields are ignored.
+	pcc = sdk.NewClient(srv.Client(), sdk.WithBaseHost(host))
+
+	lf, err := pcc.List(ctx, sdk.ByID(sdk.RootFolderID), sdk.ListOptions{})
+	require.NoError(t, err)
+	assert.Len(t, lf.Metadata.Contents, 2)
+}
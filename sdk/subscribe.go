@@ -0,0 +1,111 @@
+package sdk
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// subscribePollTimeout bounds each long poll of Subscribe. It is shorter than the default
+// response header timeout of the HTTP client (see DefaultTransportConfig) so that an idle poll
+// ends as a timeout of the call rather than as an error of the transport.
+const subscribePollTimeout = 15 * time.Second
+
+// Subscribe watches the account for changes and delivers them over the returned channel.
+// The first entries delivered are those that follow fromDiffID (see Changes: if fromDiffID is 0,
+// this is the full state of the account). Subscribe then long-polls pCloud continuously for
+// new entries. It keeps track of the diffid and recovers from network and server errors by
+// polling again after a delay that increases with each consecutive failure (see
+// RetryPolicy.BaseDelay and RetryPolicy.MaxDelay).
+//
+// The channel is closed when ctx is cancelled, or when pCloud returns an error that cannot be
+// recovered from, such as an authentication error. Such errors are logged (see WithLogger).
+// opts are applied to every call of diff.
+//
+// While a long poll is pending, it holds the Client's connection to pCloud. It is advisable to
+// use a Client dedicated to the subscription.
+func (c *Client) Subscribe(ctx context.Context, fromDiffID uint64, opts ...ClientOption) (<-chan Entry, error) {
+	dr, err := c.Changes(ctx, fromDiffID, ChangesOptions{}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan Entry)
+
+	// opts come last so that the caller can override the poll timeout.
+	pollOpts := append([]ClientOption{WithCallTimeout(subscribePollTimeout)}, opts...)
+
+	go c.subscribe(ctx, dr, pollOpts, ch)
+
+	return ch, nil
+}
+
+func (c *Client) subscribe(ctx context.Context, dr *DiffResult, opts []ClientOption, ch chan<- Entry) {
+	defer close(ch)
+
+	backoff := c.retryPolicy
+	if backoff.BaseDelay <= 0 {
+		backoff = DefaultRetryPolicy()
+	}
+
+	diffID := dr.DiffID
+	entries := dr.Entries
+	failures := 0
+
+	for {
+		for _, e := range entries {
+			select {
+			case ch <- e:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		dr, err := c.Changes(ctx, diffID, ChangesOptions{Block: true}, opts...)
+
+		switch {
+		case err == nil:
+			failures = 0
+			entries = dr.Entries
+			if dr.DiffID > diffID {
+				diffID = dr.DiffID
+			}
+
+		case ctx.Err() != nil:
+			return
+
+		case isPollTimeout(err):
+			// no changes.
+			failures = 0
+			entries = nil
+
+		case IsRetryable(err):
+			failures++
+			entries = nil
+
+			if sleep(ctx, backoff.delay(failures)) != nil {
+				return
+			}
+
+		default:
+			if c.logger != nil {
+				c.logger.LogAttrs(ctx, slog.LevelError, "pCloud subscription ended", slog.Uint64("diffid", diffID), slog.String("error", err.Error()))
+			}
+			return
+		}
+	}
+}
+
+// isPollTimeout returns true if err is the timeout of a long poll.
+func isPollTimeout(err error) bool {
+	var te *TimeoutError
+	if errors.As(err, &te) {
+		return true
+	}
+
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
@@ -0,0 +1,96 @@
+package sdk_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+func TestSubscribe(t *testing.T) {
+	var calls int32
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		q := r.URL.Query()
+		n := atomic.AddInt32(&calls, 1)
+
+		switch n {
+		case 1:
+			assert.Equal(t, "5", q.Get("diffid"))
+			assert.Empty(t, q.Get("block"))
+			fmt.Fprint(w, `{"result": 0, "diffid": 6, "entries": [{"event": "createfolder", "diffid": 6, "metadata": {"name": "a"}}]}`)
+		case 2:
+			// idle poll: no changes before the poll times out.
+			assert.Equal(t, "6", q.Get("diffid"))
+			assert.Equal(t, "1", q.Get("block"))
+			time.Sleep(200 * time.Millisecond)
+			fmt.Fprint(w, `{"result": 0, "diffid": 6, "entries": []}`)
+		case 3:
+			fmt.Fprint(w, `{"result": 5000, "error": "Internal error. Try again later."}`)
+		case 4:
+			assert.Equal(t, "6", q.Get("diffid"))
+			fmt.Fprint(w, `{"result": 0, "diffid": 8, "entries": [{"event": "createfile", "diffid": 7, "metadata": {"name": "b"}}, {"event": "deletefile", "diffid": 8, "metadata": {"name": "b"}}]}`)
+		default:
+			assert.Equal(t, "8", q.Get("diffid"))
+			fmt.Fprint(w, `{"result": 0, "diffid": 8, "entries": []}`)
+		}
+	}))
+	defer srv.Close()
+
+	pcc := sdk.NewClient(
+		srv.Client(),
+		sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")),
+		sdk.WithRetryPolicy(sdk.RetryPolicy{MaxAttempts: 1, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch, err := pcc.Subscribe(ctx, 5, sdk.WithCallTimeout(50*time.Millisecond))
+	require.NoError(t, err)
+
+	var names []string
+	for i := 0; i < 3; i++ {
+		select {
+		case e := <-ch:
+			names = append(names, string(e.Event)+":"+e.Metadata.Name)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for entries")
+		}
+	}
+	assert.Equal(t, []string{"createfolder:a", "createfile:b", "deletefile:b"}, names)
+
+	cancel()
+
+	select {
+	case _, ok := <-ch:
+		assert.False(t, ok, "channel must be closed once the context is cancelled")
+	case <-time.After(5 * time.Second):
+		t.Fatal("channel was not closed")
+	}
+}
+
+func TestSubscribe_AuthError(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"result": 1000, "error": "Log in required."}`)
+	}))
+	defer srv.Close()
+
+	pcc := sdk.NewClient(srv.Client(), sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")))
+
+	_, err := pcc.Subscribe(context.Background(), 0)
+	require.Error(t, err)
+	assert.True(t, sdk.IsAuthError(err))
+}
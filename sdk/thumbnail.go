@@ -0,0 +1,201 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// IconFormatID is the format of WithGlobalOptionIconFormat by which pCloud returns the icons of
+// the metadata as numeric ids rather than names.
+const IconFormatID = "id"
+
+// Icon is the icon to show for a folder or file, as returned by pCloud in its metadata: a name,
+// such as "folder" or "image", or a numeric id when the call is made with
+// WithGlobalOptionIconFormat(IconFormatID).
+// https://docs.pcloud.com/structures/metadata.html
+type Icon string
+
+// The names of the main icons of pCloud.
+const (
+	IconFolder   Icon = "folder"
+	IconFile     Icon = "file"
+	IconImage    Icon = "image"
+	IconVideo    Icon = "video"
+	IconAudio    Icon = "audio"
+	IconDocument Icon = "document"
+	IconArchive  Icon = "archive"
+)
+
+// UnmarshalJSON decodes the Icon from a name or from a numeric id, which is kept in decimal.
+func (i *Icon) UnmarshalJSON(data []byte) error {
+	var id json.Number
+
+	if json.Unmarshal(data, &id) == nil {
+		*i = Icon(id.String())
+		return nil
+	}
+
+	var name string
+
+	err := json.Unmarshal(data, &name)
+	if err != nil {
+		return err
+	}
+
+	*i = Icon(name)
+
+	return nil
+}
+
+// ID returns the numeric id of the icon, and false when the icon is a name.
+func (i Icon) ID() (int, bool) {
+	id, err := strconv.Atoi(string(i))
+	if err != nil {
+		return 0, false
+	}
+	return id, true
+}
+
+// Icon returns the name of the icon that pCloud shows for the files of the category.
+func (c Category) Icon() Icon {
+	switch c {
+	case CategoryImage:
+		return IconImage
+	case CategoryVideo:
+		return IconVideo
+	case CategoryAudio:
+		return IconAudio
+	case CategoryDocument:
+		return IconDocument
+	case CategoryArchive:
+		return IconArchive
+	default:
+		return IconFile
+	}
+}
+
+// ThumbLink contains the details of a thumbnail link, as provided by ThumbLink.
+type ThumbLink struct {
+	result
+	Path    string
+	Expires time.Time
+	Hosts   []string
+	Size    string // the actual size of the thumbnail, as WIDTHxHEIGHT
+}
+
+// UnmarshalJSON decodes the ThumbLink, with its times in any of the formats of APITime.
+func (t *ThumbLink) UnmarshalJSON(data []byte) error {
+	type thumbLink ThumbLink
+	times := struct {
+		*thumbLink
+		Expires APITime
+	}{thumbLink: (*thumbLink)(t)}
+
+	err := json.Unmarshal(data, &times)
+	if err != nil {
+		return err
+	}
+
+	t.Expires = times.Expires.Time
+
+	return nil
+}
+
+// URL returns the URL of the thumbnail on the first of its hosts, or "" when there is none.
+func (t *ThumbLink) URL() string {
+	if len(t.Hosts) == 0 {
+		return ""
+	}
+	return t.Hosts[0] + t.Path
+}
+
+// ThumbLinkOptions are the optional parameters of ThumbLink.
+type ThumbLinkOptions struct {
+	// Crop if set, the thumbnail is cropped to the exact size, rather than fit in it with its
+	// aspect ratio preserved.
+	Crop bool
+
+	// Type, if not empty, is the format of the thumbnail: "png" or "jpeg". It defaults to png
+	// for the images with transparency and to jpeg otherwise.
+	Type string
+}
+
+// set sets the parameters of o in q.
+func (o ThumbLinkOptions) set(q url.Values) {
+	if o.Crop {
+		q.Set("crop", "1")
+	}
+
+	if o.Type != "" {
+		q.Set("type", o.Type)
+	}
+}
+
+// ThumbLink gets a link to a thumbnail of file, of at most width x height pixels.
+// The width is between 16 and 2048 and divisible by 4 or 5, the height between 16 and 1024 and
+// divisible by 4 or 5. Only the files whose Metadata have Thumb set have thumbnails.
+// https://docs.pcloud.com/methods/thumbnails/getthumblink.html
+func (c *Client) ThumbLink(ctx context.Context, file FileRef, width, height int, o ThumbLinkOptions, opts ...ClientOption) (*ThumbLink, error) {
+	ctx, q := toQuery(ctx, opts...)
+	file.setFile(q)
+	q.Set("size", fmt.Sprintf("%dx%d", width, height))
+	o.set(q)
+
+	tl := &ThumbLink{}
+
+	err := c.parseAPIOutput(tl)(c.get(ctx, "getthumblink", q))
+	if err != nil {
+		return nil, err
+	}
+
+	for i, host := range tl.Hosts {
+		tl.Hosts[i] = "https://" + host
+	}
+
+	return tl, nil
+}
+
+// Preview is the representation of a folder or file in a file browser, as provided by Preview.
+type Preview struct {
+	// Icon is the icon of the folder or file.
+	Icon Icon
+
+	// Thumb is the link to the thumbnail of the file, or nil when it has none.
+	Thumb *ThumbLink
+}
+
+// Preview returns the representation of the folder or file of m: its icon and, when pCloud has
+// thumbnails of the file, a link to its thumbnail of at most width x height pixels (see
+// ThumbLink). When m has no icon, such as when its metadata are filtered (see
+// WithGlobalOptionFilterMeta), the icon is that of its category.
+func (c *Client) Preview(ctx context.Context, m *Metadata, width, height int, opts ...ClientOption) (*Preview, error) {
+	p := &Preview{Icon: m.Icon}
+
+	if m.IsFolder {
+		if p.Icon == "" {
+			p.Icon = IconFolder
+		}
+		return p, nil
+	}
+
+	if p.Icon == "" {
+		p.Icon = m.Category.Icon()
+	}
+
+	if !m.Thumb {
+		return p, nil
+	}
+
+	tl, err := c.ThumbLink(ctx, ByID(m.FileID), width, height, ThumbLinkOptions{}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	p.Thumb = tl
+
+	return p, nil
+}
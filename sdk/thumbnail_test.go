@@ -0,0 +1,81 @@
+package sdk_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+func TestThumbnails(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.Path {
+		case "/getthumblink":
+			assert.Equal(t, "10", q.Get("fileid"))
+			assert.Equal(t, "120x80", q.Get("size"))
+			if q.Get("crop") != "" {
+				assert.Equal(t, "1", q.Get("crop"))
+				assert.Equal(t, "png", q.Get("type"))
+			}
+			fmt.Fprint(w, `{"result": 0, "path": "/thumb.jpg", "hosts": ["c1.pcloud.com", "c2.pcloud.com"], "size": "120x68", "expires": "Thu, 21 Mar 2013 18:31:45 +0000"}`)
+		case "/listfolder":
+			assert.Equal(t, "id", q.Get("iconformat"))
+			fmt.Fprint(w, `{"result": 0, "metadata": {"name": "/", "isfolder": true, "icon": 20, "contents": [{"name": "a.jpg", "fileid": 10, "icon": "3"}]}}`)
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	pcc := sdk.NewClient(srv.Client(), sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")))
+
+	tl, err := pcc.ThumbLink(ctx, sdk.ByID(10), 120, 80, sdk.ThumbLinkOptions{Crop: true, Type: "png"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://c1.pcloud.com/thumb.jpg", tl.URL())
+	assert.Equal(t, "120x68", tl.Size)
+	assert.False(t, tl.Expires.IsZero())
+	assert.Equal(t, "", (&sdk.ThumbLink{}).URL())
+
+	p, err := pcc.Preview(ctx, &sdk.Metadata{IsFolder: true, FolderID: 1}, 120, 80)
+	require.NoError(t, err)
+	assert.Equal(t, &sdk.Preview{Icon: sdk.IconFolder}, p)
+
+	// without thumbnails nor icon, the icon is that of the category of the file.
+	p, err = pcc.Preview(ctx, &sdk.Metadata{FileID: 11, Category: sdk.CategoryArchive}, 120, 80)
+	require.NoError(t, err)
+	assert.Equal(t, &sdk.Preview{Icon: sdk.IconArchive}, p)
+
+	p, err = pcc.Preview(ctx, &sdk.Metadata{FileID: 10, Icon: sdk.IconImage, Category: sdk.CategoryImage, Thumb: true}, 120, 80)
+	require.NoError(t, err)
+	assert.Equal(t, sdk.IconImage, p.Icon)
+	require.NotNil(t, p.Thumb)
+	assert.Equal(t, "https://c1.pcloud.com/thumb.jpg", p.Thumb.URL())
+
+	lf, err := pcc.List(ctx, sdk.ByID(sdk.RootFolderID), sdk.ListOptions{}, sdk.WithGlobalOptionIconFormat(sdk.IconFormatID))
+	require.NoError(t, err)
+
+	id, ok := lf.Metadata.Icon.ID()
+	assert.True(t, ok)
+	assert.Equal(t, 20, id)
+
+	id, ok = lf.Metadata.Contents[0].Icon.ID()
+	assert.True(t, ok)
+	assert.Equal(t, 3, id)
+
+	_, ok = sdk.IconFolder.ID()
+	assert.False(t, ok)
+
+	assert.Equal(t, sdk.IconFile, sdk.CategoryUncategorized.Icon())
+	assert.Equal(t, sdk.IconDocument, sdk.CategoryDocument.Icon())
+}
@@ -0,0 +1,164 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// TimeoutError is returned when a call exceeds one of the timeouts set with WithCallTimeout,
+// WithCallConnectTimeout, WithCallResponseHeaderTimeout or WithCallBodyTimeout.
+type TimeoutError struct {
+	// Phase is the phase of the call that timed out: "call", "connect", "response header"
+	// or "body".
+	Phase   string
+	Timeout time.Duration
+}
+
+// Error implements Go's error interface.
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("%s timeout after %s", e.Phase, e.Timeout)
+}
+
+// WithCallTimeout limits the total duration of a call, including retries.
+// This is equivalent to setting a deadline on the context of the call, except that the
+// error returned is a *TimeoutError.
+func WithCallTimeout(d time.Duration) ClientOption {
+	return func(co *callOptions) {
+		co.callTimeout = d
+	}
+}
+
+// WithCallConnectTimeout limits the time taken to establish a new connection to pCloud, for
+// each attempt of a call. It has no effect when a pooled connection is re-used.
+func WithCallConnectTimeout(d time.Duration) ClientOption {
+	return func(co *callOptions) {
+		co.connectTimeout = d
+	}
+}
+
+// WithCallResponseHeaderTimeout limits the time taken by pCloud to respond after the request
+// was sent, for each attempt of a call. This includes the time taken to send the body of the
+// request, i.e. the data of an upload.
+func WithCallResponseHeaderTimeout(d time.Duration) ClientOption {
+	return func(co *callOptions) {
+		co.headerTimeout = d
+	}
+}
+
+// WithCallBodyTimeout limits the time taken to receive the body of the response, for each
+// attempt of a call. This is useful to bound the duration of large downloads separately from
+// the latency of pCloud.
+func WithCallBodyTimeout(d time.Duration) ClientOption {
+	return func(co *callOptions) {
+		co.bodyTimeout = d
+	}
+}
+
+// withCallTimeout applies the timeout of WithCallTimeout, if any, to ctx.
+func withCallTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	d := callOptionsFromContext(ctx).callTimeout
+	if d <= 0 {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeoutCause(ctx, d, &TimeoutError{Phase: "call", Timeout: d})
+}
+
+// attemptTimers enforces the per-phase timeouts of an attempt of a call.
+// The connect phase is traced from the goroutine that dials the connection, while the other
+// phases are started by the goroutine of the call: each phase has a timer of its own, and the
+// timers are guarded by mu.
+type attemptTimers struct {
+	co     *callOptions
+	cancel context.CancelCauseFunc
+
+	mu      sync.Mutex
+	timers  map[string]*time.Timer // by phase
+	stopped bool
+}
+
+// newAttemptTimers returns a context for an attempt of a call that is cancelled when a phase
+// exceeds its timeout. The returned context also traces the establishment of connections.
+// stop must be called when the attempt is complete.
+func newAttemptTimers(ctx context.Context) (context.Context, *attemptTimers) {
+	co := callOptionsFromContext(ctx)
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	at := &attemptTimers{co: co, cancel: cancel, timers: map[string]*time.Timer{}}
+
+	if co.connectTimeout > 0 {
+		ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+			ConnectStart: func(string, string) { at.start("connect", co.connectTimeout) },
+			ConnectDone:  func(string, string, error) { at.stopTimer("connect") },
+		})
+	}
+
+	return ctx, at
+}
+
+// startHeader starts the response header phase. It runs alongside the connect phase, if a
+// connection is dialled.
+func (at *attemptTimers) startHeader() {
+	at.start("response header", at.co.headerTimeout)
+}
+
+// startBody starts the body phase, which ends the response header phase.
+func (at *attemptTimers) startBody() {
+	at.stopTimer("response header")
+	at.start("body", at.co.bodyTimeout)
+}
+
+// start starts the timer of a phase, replacing that of the same phase, if any.
+func (at *attemptTimers) start(phase string, d time.Duration) {
+	at.mu.Lock()
+	defer at.mu.Unlock()
+
+	if t := at.timers[phase]; t != nil {
+		t.Stop()
+		delete(at.timers, phase)
+	}
+
+	// the connection may still be dialled after the attempt is complete.
+	if d <= 0 || at.stopped {
+		return
+	}
+
+	at.timers[phase] = time.AfterFunc(d, func() {
+		at.cancel(&TimeoutError{Phase: phase, Timeout: d})
+	})
+}
+
+// stopTimer stops the timer of a phase, if any.
+func (at *attemptTimers) stopTimer(phase string) {
+	at.mu.Lock()
+	defer at.mu.Unlock()
+
+	if t := at.timers[phase]; t != nil {
+		t.Stop()
+		delete(at.timers, phase)
+	}
+}
+
+// err returns the *TimeoutError that cancelled ctx, if any, or err.
+func (at *attemptTimers) err(ctx context.Context, err error) error {
+	if te, ok := context.Cause(ctx).(*TimeoutError); ok {
+		return te
+	}
+	return err
+}
+
+// stop releases the resources of the timers.
+func (at *attemptTimers) stop() {
+	at.mu.Lock()
+	for phase, t := range at.timers {
+		t.Stop()
+		delete(at.timers, phase)
+	}
+	at.stopped = true
+	at.mu.Unlock()
+
+	at.cancel(nil)
+}
@@ -0,0 +1,131 @@
+package sdk_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+// newSlowServer returns a server that waits for headerDelay before responding, then for
+// bodyDelay before sending the body of the response.
+func newSlowServer(t *testing.T, headerDelay, bodyDelay time.Duration) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(headerDelay)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		time.Sleep(bodyDelay)
+		fmt.Fprint(w, `{"result": 0, "metadata": {"name": "/", "isfolder": true}}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+// slowDialClient returns a client of srv that waits for delay once it starts to dial a
+// connection, as a slow network would.
+func slowDialClient(srv *httptest.Server, delay time.Duration) *http.Client {
+	hc := srv.Client()
+	tr := hc.Transport.(*http.Transport).Clone()
+
+	tr.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if trace := httptrace.ContextClientTrace(ctx); trace != nil && trace.ConnectStart != nil {
+			trace.ConnectStart(network, addr)
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		return (&net.Dialer{}).DialContext(ctx, network, addr)
+	}
+	hc.Transport = tr
+
+	return hc
+}
+
+func TestTimeout(t *testing.T) {
+	tt := map[string]struct {
+		dialDelay   time.Duration
+		headerDelay time.Duration
+		bodyDelay   time.Duration
+		opts        []sdk.ClientOption
+		wantPhase   string
+	}{
+		"call": {
+			headerDelay: 200 * time.Millisecond,
+			opts:        []sdk.ClientOption{sdk.WithCallTimeout(50 * time.Millisecond)},
+			wantPhase:   "call",
+		},
+		"connect": {
+			dialDelay: 200 * time.Millisecond,
+			opts:      []sdk.ClientOption{sdk.WithCallConnectTimeout(50 * time.Millisecond)},
+			wantPhase: "connect",
+		},
+		"response header": {
+			headerDelay: 200 * time.Millisecond,
+			opts:        []sdk.ClientOption{sdk.WithCallResponseHeaderTimeout(50 * time.Millisecond)},
+			wantPhase:   "response header",
+		},
+		"connect then response header": {
+			dialDelay:   10 * time.Millisecond,
+			headerDelay: 200 * time.Millisecond,
+			opts: []sdk.ClientOption{
+				sdk.WithCallConnectTimeout(time.Second),
+				sdk.WithCallResponseHeaderTimeout(100 * time.Millisecond),
+			},
+			wantPhase: "response header",
+		},
+		"body": {
+			bodyDelay: 200 * time.Millisecond,
+			opts:      []sdk.ClientOption{sdk.WithCallBodyTimeout(50 * time.Millisecond)},
+			wantPhase: "body",
+		},
+		"within timeouts": {
+			dialDelay: 10 * time.Millisecond,
+			bodyDelay: 10 * time.Millisecond,
+			opts: []sdk.ClientOption{
+				sdk.WithCallConnectTimeout(time.Second),
+				sdk.WithCallResponseHeaderTimeout(time.Second),
+				sdk.WithCallBodyTimeout(time.Second),
+			},
+		},
+	}
+
+	for name, tc := range tt {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := newSlowServer(t, tc.headerDelay, tc.bodyDelay)
+			hc := slowDialClient(srv, tc.dialDelay)
+			pcc := sdk.NewClient(hc, sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")), sdk.WithRetryPolicy(sdk.NoRetryPolicy()))
+
+			_, err := pcc.List(context.Background(), sdk.ByID(sdk.RootFolderID), sdk.ListOptions{}, tc.opts...)
+			if tc.wantPhase == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			var te *sdk.TimeoutError
+			require.True(t, errors.As(err, &te), "unexpected error: %v", err)
+			assert.Equal(t, tc.wantPhase, te.Phase)
+		})
+	}
+}
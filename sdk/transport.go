@@ -0,0 +1,141 @@
+package sdk
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// TransportConfig holds the settings of the HTTP client created by NewHTTPClient.
+// Callers that need full control can instead supply their own *http.Client to NewClient.
+type TransportConfig struct {
+	// Proxy returns the proxy to use for a request. See http.ProxyFromEnvironment and
+	// http.ProxyURL. nil means no proxy.
+	Proxy func(*http.Request) (*url.URL, error)
+
+	// TLSClientConfig is the TLS configuration, for instance to add custom root CAs.
+	// nil means Go's default configuration.
+	TLSClientConfig *tls.Config
+
+	// DialTimeout is the maximum time to establish a TCP connection.
+	DialTimeout time.Duration
+
+	// KeepAlive is the interval between TCP keep-alive probes.
+	KeepAlive time.Duration
+
+	// TLSHandshakeTimeout is the maximum time to perform the TLS handshake.
+	TLSHandshakeTimeout time.Duration
+
+	// ResponseHeaderTimeout is the maximum time to wait for the headers of a response after
+	// the request was sent. It does not limit the time to read the body of the response.
+	ResponseHeaderTimeout time.Duration
+
+	// IdleConnTimeout is the maximum time an idle connection is kept in the pool.
+	IdleConnTimeout time.Duration
+
+	// MaxIdleConnsPerHost is the maximum number of idle connections kept in the pool, per host.
+	MaxIdleConnsPerHost int
+
+	// MaxConnsPerHost is the maximum number of connections per host. 0 means no limit.
+	// It bounds the number of requests that are sent concurrently over HTTP/1.1 through the HTTP
+	// client, for instance to upload files in parallel: the other requests wait for a connection.
+	// The file operations that use a file descriptor are not bound by it: a Client sends them
+	// over a connection of their own.
+	MaxConnsPerHost int
+
+	// ForceAttemptHTTP2 enables HTTP/2 when the server supports it. This is needed because
+	// NewHTTPClient customises the dialer, which otherwise disables HTTP/2 in Go's transport.
+	// With HTTP/2, the concurrent requests made through the HTTP client are multiplexed over a
+	// single connection.
+	ForceAttemptHTTP2 bool
+
+	// ExpectContinueTimeout is the maximum time to wait for the server to accept a request that
+	// has an "Expect: 100-continue" header before its body is sent anyway. See
+	// WithExpectContinue.
+	ExpectContinueTimeout time.Duration
+}
+
+// DefaultTransportConfig returns the default settings of NewHTTPClient.
+func DefaultTransportConfig() TransportConfig {
+	return TransportConfig{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialTimeout:           30 * time.Second,
+		KeepAlive:             30 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: 20 * time.Second,
+		IdleConnTimeout:       90 * time.Second,
+		MaxIdleConnsPerHost:   4,
+		MaxConnsPerHost:       4,
+		ForceAttemptHTTP2:     true,
+		ExpectContinueTimeout: time.Second,
+	}
+}
+
+// NewHTTPClient creates an *http.Client suitable for use with NewClient.
+// The client has no overall timeout: use the context of the SDK methods to limit the
+// duration of calls.
+func NewHTTPClient(cfg TransportConfig) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   cfg.DialTimeout,
+		KeepAlive: cfg.KeepAlive,
+	}
+
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:                 cfg.Proxy,
+			DialContext:           dialer.DialContext,
+			TLSClientConfig:       cfg.TLSClientConfig,
+			TLSHandshakeTimeout:   cfg.TLSHandshakeTimeout,
+			ResponseHeaderTimeout: cfg.ResponseHeaderTimeout,
+			IdleConnTimeout:       cfg.IdleConnTimeout,
+			MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+			MaxConnsPerHost:       cfg.MaxConnsPerHost,
+			ForceAttemptHTTP2:     cfg.ForceAttemptHTTP2,
+			ExpectContinueTimeout: cfg.ExpectContinueTimeout,
+		},
+		Timeout: 0,
+	}
+}
+
+// singleConnClient returns a copy of hc that sends its requests over at most one connection per
+// host, which is kept open for as long as pCloud allows. The Client sends the file operations
+// that use a file descriptor through it, as pCloud binds file descriptors to the connection they
+// were opened on.
+// hc is returned as is when its transport is not an *http.Transport: such a transport, like
+// BinAPITransport, must itself send the requests of the Client over a single connection for
+// the file descriptors to remain valid.
+func singleConnClient(hc *http.Client) *http.Client {
+	rt := hc.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+
+	t, ok := rt.(*http.Transport)
+	if !ok {
+		return hc
+	}
+
+	t = t.Clone()
+	t.MaxConnsPerHost = 1
+	t.MaxIdleConnsPerHost = 1
+	t.IdleConnTimeout = 0
+
+	hcc := *hc
+	hcc.Transport = t
+
+	return &hcc
+}
+
+// WithExpectContinue makes the Client send an "Expect: 100-continue" header with the requests
+// whose body is at least minSize bytes long, such as uploads. pCloud can then reject a request
+// (authentication, quota, etc) before its body is sent, which saves bandwidth.
+// The body is sent regardless once TransportConfig.ExpectContinueTimeout has elapsed: servers
+// that don't honour the header delay each such request by that much.
+// By default, the header is not sent.
+func WithExpectContinue(minSize int) Option {
+	return func(c *Client) {
+		c.expectContinue = minSize
+	}
+}
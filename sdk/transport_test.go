@@ -0,0 +1,183 @@
+package sdk_test
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+func TestNewHTTPClient(t *testing.T) {
+	cfg := sdk.DefaultTransportConfig()
+	cfg.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS13}
+	cfg.ResponseHeaderTimeout = time.Minute
+	cfg.MaxConnsPerHost = 8
+
+	c := sdk.NewHTTPClient(cfg)
+
+	tr, ok := c.Transport.(*http.Transport)
+	require.True(t, ok)
+	assert.Same(t, cfg.TLSClientConfig, tr.TLSClientConfig)
+	assert.Equal(t, time.Minute, tr.ResponseHeaderTimeout)
+	assert.Equal(t, 8, tr.MaxConnsPerHost)
+	assert.NotNil(t, tr.Proxy)
+	assert.Zero(t, c.Timeout)
+}
+
+func TestNewHTTPClient_HTTP2(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, 2, r.ProtoMajor)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"result": 0, "ip": "1.2.3.4", "country": "fr"}`)
+	}))
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	defer srv.Close()
+
+	pcc := sdk.NewClient(newTestHTTPClient(srv, sdk.DefaultTransportConfig()), sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")))
+
+	_, err := pcc.GetIP(context.Background())
+	require.NoError(t, err)
+}
+
+func TestWithExpectContinue(t *testing.T) {
+	var expects []string
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		expects = append(expects, r.Header.Get("Expect"))
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"result": 0, "fileids": [1], "metadata": [{"name": "a.txt", "fileid": 1}]}`)
+	}))
+	defer srv.Close()
+
+	pcc := sdk.NewClient(newTestHTTPClient(srv, sdk.DefaultTransportConfig()), sdk.WithBaseHost(strings.TrimPrefix(srv.URL, "https://")), sdk.WithExpectContinue(10_000))
+
+	_, err := pcc.UploadFiles(context.Background(), sdk.ByID(0), map[string]*os.File{"a.txt": newTempFile(t, 100)}, sdk.UploadOptions{})
+	require.NoError(t, err)
+
+	_, err = pcc.UploadFiles(context.Background(), sdk.ByID(0), map[string]*os.File{"a.txt": newTempFile(t, 20_000)}, sdk.UploadOptions{})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"", "100-continue"}, expects)
+}
+
+// BenchmarkParallelUploads measures the throughput of small-file uploads made in parallel, for
+// various transport settings, by workers that share one Client ("shared_client") and by workers
+// that each have their own Client on the same HTTP client ("client_per_worker").
+func BenchmarkParallelUploads(b *testing.B) {
+	const workers = 4
+	const fileSize = 4096
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		// simulate the latency of pCloud.
+		time.Sleep(time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"result": 0, "fileids": [1], "metadata": [{"name": "a.txt", "fileid": 1}]}`)
+	}))
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	defer srv.Close()
+
+	host := strings.TrimPrefix(srv.URL, "https://")
+
+	cfgs := map[string]func(*sdk.TransportConfig){
+		"http1_1conn": func(cfg *sdk.TransportConfig) {
+			cfg.ForceAttemptHTTP2 = false
+			cfg.MaxIdleConnsPerHost = 1
+			cfg.MaxConnsPerHost = 1
+		},
+		"http1_no_keepalive": func(cfg *sdk.TransportConfig) {
+			cfg.ForceAttemptHTTP2 = false
+			cfg.MaxIdleConnsPerHost = -1
+			cfg.MaxConnsPerHost = workers
+		},
+		"http1_conns": func(cfg *sdk.TransportConfig) {
+			cfg.ForceAttemptHTTP2 = false
+			cfg.MaxIdleConnsPerHost = workers
+			cfg.MaxConnsPerHost = workers
+		},
+		"http2": func(cfg *sdk.TransportConfig) {},
+	}
+
+	for _, mode := range []string{"shared_client", "client_per_worker"} {
+		for _, name := range []string{"http1_1conn", "http1_no_keepalive", "http1_conns", "http2"} {
+			b.Run(mode+"/"+name, func(b *testing.B) {
+				cfg := sdk.DefaultTransportConfig()
+				cfgs[name](&cfg)
+				hc := newTestHTTPClient(srv, cfg)
+				shared := sdk.NewClient(hc, sdk.WithBaseHost(host))
+
+				// RunParallel starts workers goroutines per GOMAXPROCS: each has its own file.
+				goroutines := workers * runtime.GOMAXPROCS(0)
+				files := make(chan *os.File, goroutines)
+				for i := 0; i < goroutines; i++ {
+					files <- newTempFile(b, fileSize)
+				}
+
+				b.SetBytes(fileSize)
+				b.SetParallelism(workers)
+				b.ResetTimer()
+
+				b.RunParallel(func(pb *testing.PB) {
+					pcc := shared
+					if mode == "client_per_worker" {
+						pcc = sdk.NewClient(hc, sdk.WithBaseHost(host))
+					}
+
+					f := <-files
+
+					for pb.Next() {
+						_, err := f.Seek(0, io.SeekStart)
+						if err != nil {
+							b.Error(err)
+							return
+						}
+
+						_, err = pcc.UploadFiles(context.Background(), sdk.ByID(0), map[string]*os.File{"a.txt": f}, sdk.UploadOptions{})
+						if err != nil {
+							b.Error(err)
+							return
+						}
+					}
+				})
+			})
+		}
+	}
+}
+
+// newTestHTTPClient creates an HTTP client with NewHTTPClient that trusts the certificate of srv.
+func newTestHTTPClient(srv *httptest.Server, cfg sdk.TransportConfig) *http.Client {
+	cfg.TLSClientConfig = srv.Client().Transport.(*http.Transport).TLSClientConfig.Clone()
+	return sdk.NewHTTPClient(cfg)
+}
+
+// newTempFile creates a temporary file of size bytes.
+func newTempFile(tb testing.TB, size int) *os.File {
+	tb.Helper()
+
+	f, err := os.Create(filepath.Join(tb.TempDir(), "a.txt"))
+	require.NoError(tb, err)
+	tb.Cleanup(func() { _ = f.Close() })
+
+	_, err = f.Write(make([]byte, size))
+	require.NoError(tb, err)
+	_, err = f.Seek(0, io.SeekStart)
+	require.NoError(tb, err)
+
+	return f
+}
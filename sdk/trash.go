@@ -0,0 +1,123 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// ListTrashOptions are the optional parameters of ListTrash.
+type ListTrashOptions struct {
+	// FolderID, if not 0, lists a deleted folder of the trash rather than its root.
+	FolderID uint64
+
+	// NoFiles if set, only folders are listed.
+	NoFiles bool
+
+	// Recursive if set, the contents of the folders are listed too.
+	Recursive bool
+}
+
+// options returns the ClientOption's of the parameters of o that are set.
+func (o ListTrashOptions) options() []ClientOption {
+	var opts []ClientOption
+
+	if o.FolderID > 0 {
+		opts = append(opts, func(co *callOptions) {
+			co.query.Set("folderid", fmt.Sprintf("%d", o.FolderID))
+		})
+	}
+
+	if o.NoFiles {
+		opts = append(opts, WithNoFiles())
+	}
+
+	if o.Recursive {
+		opts = append(opts, WithRecursive())
+	}
+
+	return opts
+}
+
+// ListTrash lists the contents of the trash: the files and folders deleted from the account,
+// which can be restored until they are cleared or expire.
+// https://docs.pcloud.com/methods/trash/trash_list.html
+func (c *Client) ListTrash(ctx context.Context, o ListTrashOptions, opts ...ClientOption) (*FSList, error) {
+	ctx, q := toQuery(ctx, withOptions(opts, o)...)
+
+	lf := &FSList{}
+
+	err := c.parseAPIOutput(lf)(c.get(ctx, "trash_list", q))
+	if err != nil {
+		return nil, err
+	}
+
+	return lf, nil
+}
+
+// TrashList is ListTrash with its optional parameters passed positionally.
+// https://docs.pcloud.com/methods/trash/trash_list.html
+//
+// Deprecated: use ListTrash.
+func (c *Client) TrashList(ctx context.Context, folderIDOpt uint64, noFilesOpt, recursiveOpt bool, opts ...ClientOption) (*FSList, error) {
+	return c.ListTrash(ctx, ListTrashOptions{FolderID: folderIDOpt, NoFiles: noFilesOpt, Recursive: recursiveOpt}, opts...)
+}
+
+// TrashRestore restores a file or a folder from the trash, into the folder it was deleted from,
+// or into the folder restoreToOpt when it is not 0 (so the root folder can only be restored
+// into when the file or folder was deleted from it).
+// https://docs.pcloud.com/methods/trash/trash_restore.html
+func (c *Client) TrashRestore(ctx context.Context, item T6FileIDOrFolderID, restoreToOpt uint64, opts ...ClientOption) (*FSList, error) {
+	ctx, q := toQuery(ctx, opts...)
+	item(q)
+
+	if restoreToOpt > 0 {
+		q.Add("restoreto", fmt.Sprintf("%d", restoreToOpt))
+	}
+
+	q.Add("metadata", "1")
+
+	lf := &FSList{}
+
+	err := c.parseAPIOutput(lf)(c.get(ctx, "trash_restore", q))
+	if err != nil {
+		return nil, err
+	}
+
+	return lf, nil
+}
+
+// TrashClear deletes a file or a folder from the trash for good. T6FolderByID(0) empties the
+// trash.
+// https://docs.pcloud.com/methods/trash/trash_clear.html
+func (c *Client) TrashClear(ctx context.Context, item T6FileIDOrFolderID, opts ...ClientOption) error {
+	ctx, q := toQuery(ctx, opts...)
+	item(q)
+
+	r := &result{}
+
+	err := c.parseAPIOutput(r)(c.get(ctx, "trash_clear", q))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// T6FileIDOrFolderID is a type of parameters that some of the SDK functions take.
+// Such functions reference either a file by fileid or a folder by folderid.
+type T6FileIDOrFolderID func(q url.Values)
+
+// T6FileByID is a type of T6FileIDOrFolderID that references a file by fileid.
+func T6FileByID(fileID uint64) T6FileIDOrFolderID {
+	return func(q url.Values) {
+		q.Set("fileid", fmt.Sprintf("%d", fileID))
+	}
+}
+
+// T6FolderByID is a type of T6FileIDOrFolderID that references a folder by folderid.
+func T6FolderByID(folderID uint64) T6FileIDOrFolderID {
+	return func(q url.Values) {
+		q.Set("folderid", fmt.Sprintf("%d", folderID))
+	}
+}
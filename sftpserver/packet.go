@@ -0,0 +1,240 @@
+package sftpserver
+
+// This file defines the messages of version 3 of the SFTP protocol, as described in
+// draft-ietf-secsh-filexfer-02, and their encoding.
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/fs"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// protoVersion is the version of the protocol that Server implements.
+const protoVersion = 3
+
+// maxPacketSize is the size of the largest packet that Server accepts, as for OpenSSH.
+const maxPacketSize = 256 * 1024
+
+// maxReadSize is the size of the largest data that Server sends in reply to a read.
+const maxReadSize = maxPacketSize - 1024
+
+// types of packets.
+const (
+	fxpInit     = 1
+	fxpVersion  = 2
+	fxpOpen     = 3
+	fxpClose    = 4
+	fxpRead     = 5
+	fxpWrite    = 6
+	fxpLstat    = 7
+	fxpFstat    = 8
+	fxpSetstat  = 9
+	fxpFsetstat = 10
+	fxpOpendir  = 11
+	fxpReaddir  = 12
+	fxpRemove   = 13
+	fxpMkdir    = 14
+	fxpRmdir    = 15
+	fxpRealpath = 16
+	fxpStat     = 17
+	fxpRename   = 18
+	fxpReadlink = 19
+	fxpSymlink  = 20
+	fxpStatus   = 101
+	fxpHandle   = 102
+	fxpData     = 103
+	fxpName     = 104
+	fxpAttrs    = 105
+	fxpExtended = 200
+)
+
+// flags of fxpOpen.
+const (
+	fxfRead   = 0x01
+	fxfWrite  = 0x02
+	fxfAppend = 0x04
+	fxfCreat  = 0x08
+	fxfTrunc  = 0x10
+	fxfExcl   = 0x20
+)
+
+// flags of attributes.
+const (
+	attrSize        = 0x01
+	attrUIDGID      = 0x02
+	attrPermissions = 0x04
+	attrACModTime   = 0x08
+	attrExtended    = 0x80000000
+)
+
+// status codes.
+const (
+	fxOK               = 0
+	fxEOF              = 1
+	fxNoSuchFile       = 2
+	fxPermissionDenied = 3
+	fxFailure          = 4
+	fxBadMessage       = 5
+	fxOpUnsupported    = 8
+)
+
+// the file types of the permissions of attributes.
+const (
+	modeDir  = 0o040000
+	modeFile = 0o100000
+)
+
+// errBadMessage is returned when a packet cannot be decoded.
+var errBadMessage = errors.New("bad message")
+
+// attrs are the attributes of a file, as sent by a client. Only its size is used.
+type attrs struct {
+	flags uint32
+	size  uint64
+}
+
+// decoder decodes the fields of a packet.
+type decoder struct {
+	b   []byte
+	err error
+}
+
+func (d *decoder) uint32() uint32 {
+	if len(d.b) < 4 {
+		d.err = errBadMessage
+		return 0
+	}
+
+	v := binary.BigEndian.Uint32(d.b)
+	d.b = d.b[4:]
+
+	return v
+}
+
+func (d *decoder) uint64() uint64 {
+	if len(d.b) < 8 {
+		d.err = errBadMessage
+		return 0
+	}
+
+	v := binary.BigEndian.Uint64(d.b)
+	d.b = d.b[8:]
+
+	return v
+}
+
+func (d *decoder) bytes() []byte {
+	n := d.uint32()
+	if uint32(len(d.b)) < n {
+		d.err = errBadMessage
+		return nil
+	}
+
+	v := d.b[:n]
+	d.b = d.b[n:]
+
+	return v
+}
+
+func (d *decoder) string() string {
+	return string(d.bytes())
+}
+
+func (d *decoder) attrs() attrs {
+	a := attrs{flags: d.uint32()}
+
+	if a.flags&attrSize != 0 {
+		a.size = d.uint64()
+	}
+	if a.flags&attrUIDGID != 0 {
+		d.uint32()
+		d.uint32()
+	}
+	if a.flags&attrPermissions != 0 {
+		d.uint32()
+	}
+	if a.flags&attrACModTime != 0 {
+		d.uint32()
+		d.uint32()
+	}
+	if a.flags&attrExtended != 0 {
+		for n := d.uint32(); n > 0 && d.err == nil; n-- {
+			d.string()
+			d.string()
+		}
+	}
+
+	return a
+}
+
+// encoder encodes a packet. Its first 4 bytes are the length of the packet, set by packet.
+type encoder struct {
+	b []byte
+}
+
+func newEncoder(typ byte) *encoder {
+	return &encoder{b: []byte{0, 0, 0, 0, typ}}
+}
+
+func (e *encoder) uint32(v uint32) *encoder {
+	e.b = binary.BigEndian.AppendUint32(e.b, v)
+	return e
+}
+
+func (e *encoder) uint64(v uint64) *encoder {
+	e.b = binary.BigEndian.AppendUint64(e.b, v)
+	return e
+}
+
+func (e *encoder) bytes(v []byte) *encoder {
+	e.uint32(uint32(len(v)))
+	e.b = append(e.b, v...)
+	return e
+}
+
+func (e *encoder) string(v string) *encoder {
+	e.uint32(uint32(len(v)))
+	e.b = append(e.b, v...)
+	return e
+}
+
+// fileInfo encodes the attributes of info: pCloud has no notion of owners, nor of permissions.
+func (e *encoder) fileInfo(info fs.FileInfo) *encoder {
+	mode := uint32(modeFile | 0o644)
+	if info.IsDir() {
+		mode = modeDir | 0o755
+	}
+
+	mtime := uint32(info.ModTime().Unix())
+
+	return e.uint32(attrSize | attrPermissions | attrACModTime).
+		uint64(uint64(info.Size())).
+		uint32(mode).
+		uint32(mtime).
+		uint32(mtime)
+}
+
+// packet returns the encoded packet.
+func (e *encoder) packet() []byte {
+	binary.BigEndian.PutUint32(e.b, uint32(len(e.b)-4))
+	return e.b
+}
+
+// longName returns the description of info in the format of ls -l, which clients display.
+func longName(info fs.FileInfo, now time.Time) string {
+	perms := "-rw-r--r--"
+	if info.IsDir() {
+		perms = "drwxr-xr-x"
+	}
+
+	// as with ls, the year replaces the time of day for dates that are not recent.
+	layout := "Jan _2 15:04"
+	if mt := info.ModTime(); mt.Before(now.AddDate(0, -6, 0)) || mt.After(now.Add(time.Hour)) {
+		layout = "Jan _2  2006"
+	}
+
+	return fmt.Sprintf("%s    1 %-8s %-8s %8d %s %s", perms, "pcloud", "pcloud", info.Size(), info.ModTime().Format(layout), info.Name())
+}
@@ -0,0 +1,522 @@
+// Package sftpserver is an experimental SFTP server backed by pCloud. It lets the backup
+// appliances and scripts that only speak SFTP store their files in pCloud.
+//
+// Server implements version 3 of the SFTP protocol, which all clients support, over the file
+// system of pcloudfs. As OpenSSH's sftp-server, it serves a single session over a stream,
+// typically its standard input and output, and leaves the SSH transport and authentication to
+// an SSH server: see the sftp-server command of the CLI.
+package sftpserver
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/seborama/pcloud-sdk/pcloudfs"
+)
+
+// posixRename is the extension that renames files over existing files, which plain renames do
+// not do with SFTP. OpenSSH's client uses it when the server supports it.
+const posixRename = "posix-rename@openssh.com"
+
+// readDirCount is the number of entries sent in reply to each read of a folder.
+const readDirCount = 100
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithLogger sets the logger of the requests that fail.
+func WithLogger(l *slog.Logger) Option {
+	return func(s *Server) {
+		s.logger = l
+	}
+}
+
+// WithReadOnly makes the server refuse the requests that change files or folders.
+func WithReadOnly() Option {
+	return func(s *Server) {
+		s.readOnly = true
+	}
+}
+
+// Server serves a pcloudfs.FS over SFTP.
+type Server struct {
+	fsys     *pcloudfs.FS
+	logger   *slog.Logger
+	readOnly bool
+}
+
+// New creates a Server for fsys. The paths of the clients are relative to the root of fsys.
+func New(fsys *pcloudfs.FS, opts ...Option) *Server {
+	s := &Server{
+		fsys: fsys,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// session is the state of an SFTP session: its open files and folders.
+type session struct {
+	fsys       *pcloudfs.FS
+	logger     *slog.Logger
+	readOnly   bool
+	w          io.Writer
+	handles    map[string]*handle
+	lastHandle uint64
+}
+
+// handle is an open file or folder.
+type handle struct {
+	f *pcloudfs.File
+
+	// pos is the offset of the next write to f, which needs no seek.
+	pos int64
+}
+
+// Serve serves the SFTP session of the client at the other end of rw, until the client ends
+// it or ctx is cancelled. The calls made to pCloud use ctx.
+// The requests are served in order: SFTP clients send several requests ahead of their replies
+// to make up for the latency.
+func (s *Server) Serve(ctx context.Context, rw io.ReadWriter) error {
+	ss := &session{
+		fsys:     s.fsys.WithContext(ctx),
+		logger:   s.logger,
+		readOnly: s.readOnly,
+		w:        rw,
+		handles:  map[string]*handle{},
+	}
+	defer ss.closeAll()
+
+	r := bufio.NewReaderSize(rw, maxPacketSize)
+	header := make([]byte, 4)
+
+	for {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		_, err := io.ReadFull(r, header)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "reading packet")
+		}
+
+		length := binary.BigEndian.Uint32(header)
+		if length == 0 || length > maxPacketSize {
+			return errors.Errorf("packet of invalid length %d", length)
+		}
+
+		p := make([]byte, length)
+
+		_, err = io.ReadFull(r, p)
+		if err != nil {
+			return errors.Wrap(err, "reading packet")
+		}
+
+		err = ss.request(p[0], &decoder{b: p[1:]})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// request serves the request of type typ. The error it returns ends the session.
+func (ss *session) request(typ byte, d *decoder) error {
+	if typ == fxpInit {
+		// the version of the client is ignored: older versions are long obsolete.
+		return ss.send(newEncoder(fxpVersion).uint32(protoVersion).string(posixRename).string("1"))
+	}
+
+	id := d.uint32()
+	if d.err != nil {
+		return d.err
+	}
+
+	reply, err := ss.serve(typ, id, d)
+	if d.err != nil {
+		reply = status(id, fxBadMessage, d.err.Error())
+	} else if err != nil {
+		if ss.logger != nil {
+			ss.logger.Error("sftpserver: request failed", "type", typ, "error", err)
+		}
+		reply = errorStatus(id, err)
+	}
+
+	return ss.send(reply)
+}
+
+// serve serves the request id of type typ. It returns the reply, or the error to reply with.
+func (ss *session) serve(typ byte, id uint32, d *decoder) (*encoder, error) {
+	if ss.readOnly {
+		switch typ {
+		case fxpWrite, fxpSetstat, fxpFsetstat, fxpRemove, fxpRmdir, fxpMkdir, fxpRename, fxpExtended:
+			return nil, errReadOnly
+		}
+	}
+
+	switch typ {
+	case fxpOpen:
+		return ss.open(id, name(d.string()), d.uint32(), d.attrs())
+
+	case fxpClose:
+		return ss.close(id, d.string())
+
+	case fxpRead:
+		return ss.read(id, d.string(), d.uint64(), d.uint32())
+
+	case fxpWrite:
+		return ss.write(id, d.string(), d.uint64(), d.bytes())
+
+	case fxpLstat, fxpStat:
+		info, err := ss.fsys.Stat(name(d.string()))
+		if err != nil {
+			return nil, err
+		}
+		return newEncoder(fxpAttrs).uint32(id).fileInfo(info), nil
+
+	case fxpFstat:
+		h, err := ss.handle(d.string())
+		if err != nil {
+			return nil, err
+		}
+		info, err := h.f.Stat()
+		if err != nil {
+			return nil, err
+		}
+		return newEncoder(fxpAttrs).uint32(id).fileInfo(info), nil
+
+	case fxpSetstat:
+		return ss.setstat(id, name(d.string()), d.attrs())
+
+	case fxpFsetstat:
+		h, err := ss.handle(d.string())
+		if err != nil {
+			return nil, err
+		}
+		return ss.setstat(id, h.f.Name(), d.attrs())
+
+	case fxpOpendir:
+		return ss.opendir(id, name(d.string()))
+
+	case fxpReaddir:
+		return ss.readdir(id, d.string())
+
+	case fxpRemove:
+		return ss.remove(id, name(d.string()), false)
+
+	case fxpRmdir:
+		return ss.remove(id, name(d.string()), true)
+
+	case fxpMkdir:
+		err := ss.fsys.Mkdir(name(d.string()), 0)
+		if err != nil {
+			return nil, err
+		}
+		return status(id, fxOK, ""), nil
+
+	case fxpRealpath:
+		// the root of the file system is the home folder of the client.
+		p := path.Join("/", d.string())
+		return newEncoder(fxpName).uint32(id).uint32(1).string(p).string(p).uint32(0), nil
+
+	case fxpRename:
+		return ss.rename(id, name(d.string()), name(d.string()), false)
+
+	case fxpExtended:
+		if d.string() == posixRename {
+			return ss.rename(id, name(d.string()), name(d.string()), true)
+		}
+	}
+
+	// fxpReadlink and fxpSymlink: pCloud has no links.
+	return status(id, fxOpUnsupported, "operation not supported"), nil
+}
+
+// open opens the file name with the flags of fxpOpen.
+func (ss *session) open(id uint32, name string, pflags uint32, _ attrs) (*encoder, error) {
+	if ss.readOnly && pflags&(fxfWrite|fxfAppend|fxfCreat|fxfTrunc) != 0 {
+		return nil, errReadOnly
+	}
+
+	var flag int
+
+	switch {
+	case pflags&fxfRead != 0 && pflags&fxfWrite != 0:
+		flag = os.O_RDWR
+	case pflags&fxfWrite != 0:
+		flag = os.O_WRONLY
+	}
+
+	if pflags&fxfAppend != 0 {
+		flag |= os.O_APPEND
+	}
+	if pflags&fxfCreat != 0 {
+		flag |= os.O_CREATE
+	}
+	if pflags&fxfTrunc != 0 {
+		flag |= os.O_TRUNC
+	}
+	if pflags&fxfExcl != 0 {
+		flag |= os.O_EXCL
+	}
+
+	f, err := ss.fsys.OpenFile(name, flag, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return ss.newHandle(id, f), nil
+}
+
+// opendir opens the folder name.
+func (ss *session) opendir(id uint32, name string) (*encoder, error) {
+	f, err := ss.fsys.OpenFile(name, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := f.Stat()
+	if err == nil && !info.IsDir() {
+		err = errNotDir
+	}
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	return ss.newHandle(id, f), nil
+}
+
+// newHandle registers the handle of f and returns the reply that holds it.
+func (ss *session) newHandle(id uint32, f *pcloudfs.File) *encoder {
+	ss.lastHandle++
+	hid := strconv.FormatUint(ss.lastHandle, 10)
+	ss.handles[hid] = &handle{f: f}
+
+	return newEncoder(fxpHandle).uint32(id).string(hid)
+}
+
+// handle returns the handle hid.
+func (ss *session) handle(hid string) (*handle, error) {
+	h, ok := ss.handles[hid]
+	if !ok {
+		return nil, errBadHandle
+	}
+	return h, nil
+}
+
+// close closes the handle hid.
+func (ss *session) close(id uint32, hid string) (*encoder, error) {
+	h, err := ss.handle(hid)
+	if err != nil {
+		return nil, err
+	}
+
+	delete(ss.handles, hid)
+
+	err = h.f.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return status(id, fxOK, ""), nil
+}
+
+// closeAll closes the handles that the client left open.
+func (ss *session) closeAll() {
+	for hid, h := range ss.handles {
+		_ = h.f.Close()
+		delete(ss.handles, hid)
+	}
+}
+
+// read reads up to length bytes at offset off of the file hid.
+func (ss *session) read(id uint32, hid string, off uint64, length uint32) (*encoder, error) {
+	h, err := ss.handle(hid)
+	if err != nil {
+		return nil, err
+	}
+
+	if length > maxReadSize {
+		length = maxReadSize
+	}
+
+	data := make([]byte, length)
+
+	n, err := h.f.ReadAt(data, int64(off))
+	if n == 0 && errors.Is(err, io.EOF) {
+		return status(id, fxEOF, "end of file"), nil
+	}
+	if n == 0 && err != nil {
+		return nil, err
+	}
+
+	return newEncoder(fxpData).uint32(id).bytes(data[:n]), nil
+}
+
+// write writes data at offset off of the file hid. Writes that follow each other, as clients
+// send them, need no seek.
+func (ss *session) write(id uint32, hid string, off uint64, data []byte) (*encoder, error) {
+	h, err := ss.handle(hid)
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(off) != h.pos {
+		h.pos, err = h.f.Seek(int64(off), io.SeekStart)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	n, err := h.f.Write(data)
+	h.pos += int64(n)
+	if err != nil {
+		return nil, err
+	}
+
+	return status(id, fxOK, ""), nil
+}
+
+// readdir reads the next entries of the folder hid.
+func (ss *session) readdir(id uint32, hid string) (*encoder, error) {
+	h, err := ss.handle(hid)
+	if err != nil {
+		return nil, err
+	}
+
+	infos, err := h.f.Readdir(readDirCount)
+	if len(infos) == 0 && errors.Is(err, io.EOF) {
+		return status(id, fxEOF, "end of directory"), nil
+	}
+	if len(infos) == 0 && err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	e := newEncoder(fxpName).uint32(id).uint32(uint32(len(infos)))
+	for _, info := range infos {
+		e.string(info.Name()).string(longName(info, now)).fileInfo(info)
+	}
+
+	return e, nil
+}
+
+// setstat changes the attributes of the file name. pCloud has no owners, permissions or times
+// to change, and truncates files only when they are opened.
+func (ss *session) setstat(id uint32, name string, a attrs) (*encoder, error) {
+	if a.flags&attrSize != 0 {
+		info, err := ss.fsys.Stat(name)
+		if err != nil {
+			return nil, err
+		}
+
+		if uint64(info.Size()) != a.size {
+			return status(id, fxOpUnsupported, "truncating files is not supported"), nil
+		}
+	}
+
+	return status(id, fxOK, ""), nil
+}
+
+// remove removes the file, or the empty folder, name.
+func (ss *session) remove(id uint32, name string, isFolder bool) (*encoder, error) {
+	info, err := ss.fsys.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case isFolder && !info.IsDir():
+		return status(id, fxFailure, "not a directory"), nil
+	case !isFolder && info.IsDir():
+		return status(id, fxFailure, "is a directory"), nil
+	}
+
+	err = ss.fsys.Remove(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return status(id, fxOK, ""), nil
+}
+
+// rename renames oldname to newname. The files that exist at newname are replaced only with
+// the posix-rename extension.
+func (ss *session) rename(id uint32, oldname, newname string, replace bool) (*encoder, error) {
+	if !replace {
+		_, err := ss.fsys.Stat(newname)
+		if err == nil {
+			return status(id, fxFailure, "file already exists"), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	err := ss.fsys.Rename(oldname, newname)
+	if err != nil {
+		return nil, err
+	}
+
+	return status(id, fxOK, ""), nil
+}
+
+// send sends the reply e.
+func (ss *session) send(e *encoder) error {
+	_, err := ss.w.Write(e.packet())
+	return errors.Wrap(err, "sending reply")
+}
+
+var (
+	// errBadHandle is returned for requests that reference a handle that is not open.
+	errBadHandle = errors.New("invalid handle")
+
+	// errNotDir is returned when opening a file as a folder.
+	errNotDir = errors.New("not a directory")
+
+	// errReadOnly is returned for the requests that change files or folders, when the server is
+	// read-only.
+	errReadOnly = errors.WithMessage(fs.ErrPermission, "read-only server")
+)
+
+// name converts the path p of a client to a name of pcloudfs.FS, which is relative to its root.
+func name(p string) string {
+	p = path.Clean("/" + p)
+	if p == "/" {
+		return "."
+	}
+	return p[1:]
+}
+
+// status returns the status reply to the request id.
+func status(id, code uint32, msg string) *encoder {
+	return newEncoder(fxpStatus).uint32(id).uint32(code).string(msg).string("")
+}
+
+// errorStatus returns the status reply to the request id that failed with err.
+func errorStatus(id uint32, err error) *encoder {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return status(id, fxNoSuchFile, err.Error())
+	case errors.Is(err, fs.ErrPermission):
+		return status(id, fxPermissionDenied, err.Error())
+	}
+	return status(id, fxFailure, err.Error())
+}
@@ -0,0 +1,231 @@
+package sftpserver
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/pcloudfs"
+	"github.com/seborama/pcloud-sdk/sdk/sdktest"
+)
+
+// client is a minimal SFTP client.
+type client struct {
+	t    *testing.T
+	conn net.Conn
+	id   uint32
+}
+
+func newTestClient(t *testing.T, opts ...Option) (*sdktest.Server, *client) {
+	t.Helper()
+
+	srv := sdktest.NewServer()
+	t.Cleanup(srv.Close)
+
+	_, err := srv.WriteFile("/Backups/Docs/a.txt", []byte("0123456789"))
+	require.NoError(t, err)
+	_, err = srv.WriteFile("/Backups/b.txt", []byte("b"))
+	require.NoError(t, err)
+
+	s := New(pcloudfs.New(srv.NewClient(), "/Backups"), opts...)
+
+	conn, serverConn := net.Pipe()
+	done := make(chan error, 1)
+
+	go func() { done <- s.Serve(context.Background(), serverConn) }()
+
+	t.Cleanup(func() {
+		_ = conn.Close()
+		assert.NoError(t, <-done)
+	})
+
+	c := &client{t: t, conn: conn}
+
+	typ, d := c.send(newEncoder(fxpInit).uint32(protoVersion))
+	require.EqualValues(t, fxpVersion, typ)
+	assert.EqualValues(t, protoVersion, d.uint32())
+	assert.Equal(t, posixRename, d.string())
+
+	return srv, c
+}
+
+// send sends the request e and returns the reply.
+func (c *client) send(e *encoder) (byte, *decoder) {
+	c.t.Helper()
+
+	_, err := c.conn.Write(e.packet())
+	require.NoError(c.t, err)
+
+	header := make([]byte, 4)
+	_, err = io.ReadFull(c.conn, header)
+	require.NoError(c.t, err)
+
+	p := make([]byte, binary.BigEndian.Uint32(header))
+	_, err = io.ReadFull(c.conn, p)
+	require.NoError(c.t, err)
+
+	return p[0], &decoder{b: p[1:]}
+}
+
+// request returns a request of type typ, with the next id.
+func (c *client) request(typ byte) *encoder {
+	c.id++
+	return newEncoder(typ).uint32(c.id)
+}
+
+// call sends the request e and checks the id of the reply.
+func (c *client) call(e *encoder) (byte, *decoder) {
+	c.t.Helper()
+
+	typ, d := c.send(e)
+	require.Equal(c.t, c.id, d.uint32())
+
+	return typ, d
+}
+
+// status sends the request e and returns the code of its status reply.
+func (c *client) status(e *encoder) uint32 {
+	c.t.Helper()
+
+	typ, d := c.call(e)
+	require.EqualValues(c.t, fxpStatus, typ)
+
+	return d.uint32()
+}
+
+// open opens the file p and returns its handle.
+func (c *client) open(p string, pflags uint32) string {
+	c.t.Helper()
+
+	typ, d := c.call(c.request(fxpOpen).string(p).uint32(pflags).uint32(0))
+	require.EqualValues(c.t, fxpHandle, typ)
+
+	return d.string()
+}
+
+func TestServer_ReadWrite(t *testing.T) {
+	srv, c := newTestClient(t)
+
+	h := c.open("/Docs/a.txt", fxfRead)
+
+	typ, d := c.call(c.request(fxpRead).string(h).uint64(2).uint32(3))
+	require.EqualValues(t, fxpData, typ)
+	assert.Equal(t, "234", d.string())
+
+	typ, d = c.call(c.request(fxpRead).string(h).uint64(8).uint32(100))
+	require.EqualValues(t, fxpData, typ)
+	assert.Equal(t, "89", d.string())
+
+	assert.EqualValues(t, fxEOF, c.status(c.request(fxpRead).string(h).uint64(10).uint32(100)))
+
+	typ, d = c.call(c.request(fxpFstat).string(h))
+	require.EqualValues(t, fxpAttrs, typ)
+	assert.EqualValues(t, attrSize|attrPermissions|attrACModTime, d.uint32())
+	assert.EqualValues(t, 10, d.uint64())
+	assert.EqualValues(t, modeFile|0o644, d.uint32())
+
+	assert.EqualValues(t, fxOK, c.status(c.request(fxpClose).string(h)))
+	assert.EqualValues(t, fxFailure, c.status(c.request(fxpClose).string(h)))
+
+	h = c.open("new.txt", fxfWrite|fxfCreat|fxfTrunc)
+
+	for i, chunk := range []string{"hello", " ", "world"} {
+		off := uint64(len(strings.Join([]string{"hello", " ", "world"}[:i], "")))
+		assert.EqualValues(t, fxOK, c.status(c.request(fxpWrite).string(h).uint64(off).string(chunk)))
+	}
+	assert.EqualValues(t, fxOK, c.status(c.request(fxpWrite).string(h).uint64(0).string("H")))
+
+	// setting the permissions or times of files is accepted, but not truncating them.
+	assert.EqualValues(t, fxOK, c.status(c.request(fxpFsetstat).string(h).uint32(attrPermissions).uint32(0o600)))
+	assert.EqualValues(t, fxOpUnsupported, c.status(c.request(fxpFsetstat).string(h).uint32(attrSize).uint64(1)))
+
+	assert.EqualValues(t, fxOK, c.status(c.request(fxpClose).string(h)))
+
+	data, err := srv.ReadFile("/Backups/new.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "Hello world", string(data))
+
+	typ, _ = c.call(c.request(fxpOpen).string("new.txt").uint32(fxfWrite | fxfCreat | fxfExcl).uint32(0))
+	assert.EqualValues(t, fxpStatus, typ)
+
+	assert.EqualValues(t, fxNoSuchFile, c.status(c.request(fxpOpen).string("missing.txt").uint32(fxfRead).uint32(0)))
+}
+
+func TestServer_Folders(t *testing.T) {
+	srv, c := newTestClient(t)
+
+	typ, d := c.call(c.request(fxpRealpath).string("."))
+	require.EqualValues(t, fxpName, typ)
+	assert.EqualValues(t, 1, d.uint32())
+	assert.Equal(t, "/", d.string())
+
+	typ, d = c.call(c.request(fxpOpendir).string("/"))
+	require.EqualValues(t, fxpHandle, typ)
+	h := d.string()
+
+	typ, d = c.call(c.request(fxpReaddir).string(h))
+	require.EqualValues(t, fxpName, typ)
+	require.EqualValues(t, 2, d.uint32())
+	assert.Equal(t, "Docs", d.string())
+	assert.True(t, strings.HasPrefix(d.string(), "drwxr-xr-x"))
+	d.attrs()
+	assert.Equal(t, "b.txt", d.string())
+	assert.True(t, strings.HasSuffix(d.string(), " b.txt"))
+
+	assert.EqualValues(t, fxEOF, c.status(c.request(fxpReaddir).string(h)))
+	assert.EqualValues(t, fxOK, c.status(c.request(fxpClose).string(h)))
+
+	assert.EqualValues(t, fxFailure, c.status(c.request(fxpOpendir).string("b.txt")))
+
+	assert.EqualValues(t, fxOK, c.status(c.request(fxpMkdir).string("New").uint32(0)))
+	assert.EqualValues(t, fxFailure, c.status(c.request(fxpMkdir).string("New").uint32(0)))
+
+	// plain renames do not replace files, unlike posix renames.
+	assert.EqualValues(t, fxOK, c.status(c.request(fxpRename).string("b.txt").string("New/c.txt")))
+	assert.EqualValues(t, fxFailure, c.status(c.request(fxpRename).string("Docs/a.txt").string("New/c.txt")))
+	assert.EqualValues(t, fxOK, c.status(c.request(fxpExtended).string(posixRename).string("Docs/a.txt").string("New/c.txt")))
+
+	data, err := srv.ReadFile("/Backups/New/c.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789", string(data))
+
+	assert.EqualValues(t, fxFailure, c.status(c.request(fxpRmdir).string("New/c.txt")))
+	assert.EqualValues(t, fxFailure, c.status(c.request(fxpRemove).string("New")))
+	assert.EqualValues(t, fxFailure, c.status(c.request(fxpRmdir).string("New")))
+	assert.EqualValues(t, fxOK, c.status(c.request(fxpRemove).string("New/c.txt")))
+	assert.EqualValues(t, fxOK, c.status(c.request(fxpRmdir).string("New")))
+	assert.EqualValues(t, fxNoSuchFile, c.status(c.request(fxpStat).string("New")))
+
+	assert.EqualValues(t, fxOpUnsupported, c.status(c.request(fxpSymlink).string("a").string("b")))
+	assert.EqualValues(t, fxBadMessage, c.status(c.request(fxpStat)))
+}
+
+func TestServer_ReadOnly(t *testing.T) {
+	srv, c := newTestClient(t, WithReadOnly())
+
+	h := c.open("Docs/a.txt", fxfRead)
+	typ, d := c.call(c.request(fxpRead).string(h).uint64(2).uint32(3))
+	require.EqualValues(t, fxpData, typ)
+	assert.Equal(t, "234", string(d.bytes()))
+	assert.EqualValues(t, fxOK, c.status(c.request(fxpClose).string(h)))
+
+	for _, pflags := range []uint32{fxfWrite, fxfRead | fxfAppend, fxfWrite | fxfCreat | fxfTrunc} {
+		assert.EqualValues(t, fxPermissionDenied, c.status(c.request(fxpOpen).string("Docs/a.txt").uint32(pflags).uint32(0)))
+	}
+
+	assert.EqualValues(t, fxPermissionDenied, c.status(c.request(fxpMkdir).string("New").uint32(0)))
+	assert.EqualValues(t, fxPermissionDenied, c.status(c.request(fxpRename).string("b.txt").string("c.txt")))
+	assert.EqualValues(t, fxPermissionDenied, c.status(c.request(fxpExtended).string(posixRename).string("b.txt").string("c.txt")))
+	assert.EqualValues(t, fxPermissionDenied, c.status(c.request(fxpRemove).string("b.txt")))
+	assert.EqualValues(t, fxPermissionDenied, c.status(c.request(fxpRmdir).string("Docs")))
+
+	data, err := srv.ReadFile("/Backups/b.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "b", string(data))
+}
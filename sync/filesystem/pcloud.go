@@ -2,7 +2,9 @@ package filesystem
 
 import (
 	"context"
+	"fmt"
 	"io"
+	"path/filepath"
 
 	"github.com/pkg/errors"
 
@@ -12,10 +14,40 @@ import (
 
 // pCloudSDK defines the SDK methods used to perform operations on the PCloud file system.
 type pCloudSDK interface {
-	FileOpen(ctx context.Context, flags uint64, file sdk.T4PathOrFileIDOrFolderIDName, opts ...sdk.ClientOption) (*sdk.File, error)
+	FileOpen(ctx context.Context, flags uint64, file sdk.FileRef, opts ...sdk.ClientOption) (*sdk.File, error)
 	FileClose(ctx context.Context, fd uint64, opts ...sdk.ClientOption) error
 	FileRead(ctx context.Context, fd, count uint64, opts ...sdk.ClientOption) ([]byte, error)
 	FileWrite(ctx context.Context, fd uint64, data []byte, opts ...sdk.ClientOption) (*sdk.FileDataTransfer, error)
+	RenameFile(ctx context.Context, file sdk.FileRef, destination sdk.FolderRef, opts ...sdk.ClientOption) (*sdk.FileResult, error)
+	DeleteFile(ctx context.Context, file sdk.FileRef, opts ...sdk.ClientOption) (*sdk.FileResult, error)
+}
+
+// partialSuffix is appended to the path of a file while MkFile writes it.
+// The file is renamed to its final path once complete.
+const partialSuffix = ".pcloud-partial"
+
+// ErrCancelled is returned when a transfer is aborted because its context was cancelled.
+// It reports the progress made so that the transfer can be resumed later.
+type ErrCancelled struct {
+	// Path is the path of the file being transferred.
+	Path string
+
+	// Transferred is the number of bytes of the file transferred before the cancellation.
+	// For a download, this is the offset to resume from.
+	Transferred uint64
+
+	// Err is the error of the context.
+	Err error
+}
+
+// Error implements Go's error interface.
+func (e *ErrCancelled) Error() string {
+	return fmt.Sprintf("transfer of '%s' cancelled after %d bytes: %v", e.Path, e.Transferred, e.Err)
+}
+
+// Unwrap returns the error of the context so that errors.Is(err, context.Canceled) holds.
+func (e *ErrCancelled) Unwrap() error {
+	return e.Err
 }
 
 // PCloud is a file system abstraction for the PCloud file system.
@@ -32,6 +64,8 @@ func NewPCloud(sdk pCloudSDK) *PCloud {
 
 // StreamFileData reads the contents of the file pointed to by fsEntry and streams it to the
 // channel the method returns.
+// When ctx is cancelled, the transfer is aborted and an *ErrCancelled is sent to the error
+// channel.
 // TODO: wrap the dataCh into a io.ReadWriter so to keep the code simple and offer a familiar Go feel.
 func (fs *PCloud) StreamFileData(ctx context.Context, fsEntry db.FSEntry) (<-chan []byte, <-chan error) {
 	dataCh := make(chan []byte, 100)
@@ -41,32 +75,48 @@ func (fs *PCloud) StreamFileData(ctx context.Context, fsEntry db.FSEntry) (<-cha
 		defer close(errCh)
 		defer close(dataCh)
 
-		f, err := fs.sdk.FileOpen(ctx, 0, sdk.T4FileByID(fsEntry.EntryID))
+		path := filepath.Join(fsEntry.Path, fsEntry.Name)
+
+		f, err := fs.sdk.FileOpen(ctx, 0, sdk.ByID(fsEntry.EntryID))
 		if err != nil {
-			errCh <- err
+			errCh <- cancelledOr(ctx, err, path, 0)
 			return
 		}
 		defer func() {
-			e := fs.sdk.FileClose(ctx, f.FD)
+			// the file descriptor must be closed even when ctx is cancelled.
+			e := fs.sdk.FileClose(context.WithoutCancel(ctx), f.FD)
 			if e != nil && err == nil {
 				errCh <- e
 				return
 			}
 		}()
 
+		var transferred uint64
+
 		eof := false
 		for !eof {
-			data, err := fs.sdk.FileRead(ctx, f.FD, 1_048_576)
+			var data []byte
+			data, err = fs.sdk.FileRead(ctx, f.FD, 1_048_576)
 			if err != nil {
 				if !errors.Is(err, io.EOF) {
+					err = cancelledOr(ctx, err, path, transferred)
 					errCh <- err
 					return
 				}
 				eof = true
 			}
 
-			dataCh <- data
+			select {
+			case dataCh <- data:
+				transferred += uint64(len(data))
+			case <-ctx.Done():
+				err = &ErrCancelled{Path: path, Transferred: transferred, Err: ctx.Err()}
+				errCh <- err
+				return
+			}
 		}
+
+		err = nil
 	}()
 
 	return dataCh, errCh
@@ -78,30 +128,89 @@ func (fs *PCloud) MkDir(ctx context.Context, path string) error {
 }
 
 // MkFile creates a file with the contents streamed through dataCh.
+// The contents are written to a temporary file that is renamed to path once complete. The
+// temporary file is deleted if the transfer fails.
+// The slices received from dataCh are returned to the SDK buffer pool once written (see sdk.PutBuffer).
+// When ctx is cancelled, the transfer is aborted and an *ErrCancelled is returned.
 // TODO: wrap the dataCh into a io.ReadWriter so to keep the code simple and offer a familiar Go feel.
 func (fs *PCloud) MkFile(ctx context.Context, path string, dataCh <-chan []byte) (err error) {
-	f, err := fs.sdk.FileOpen(ctx, sdk.O_CREAT|sdk.O_TRUNC, sdk.T4FileByPath(path))
+	partialPath := path + partialSuffix
+
+	f, err := fs.sdk.FileOpen(ctx, sdk.O_CREAT|sdk.O_TRUNC, sdk.ByPath(partialPath))
 	if err != nil {
-		return errors.WithStack(err)
+		return cancelledOr(ctx, errors.WithStack(err), path, 0)
 	}
+
+	// clean up must take place even when ctx is cancelled.
+	cleanupCtx := context.WithoutCancel(ctx)
+
+	fdOpen := true
 	defer func() {
-		e := fs.sdk.FileClose(ctx, f.FD)
-		if e != nil && err == nil {
-			err = e
-			return
+		if fdOpen {
+			e := fs.sdk.FileClose(cleanupCtx, f.FD)
+			if e != nil && err == nil {
+				err = e
+			}
 		}
-	}()
 
-	for data := range dataCh {
-		_, err = fs.sdk.FileWrite(ctx, f.FD, data)
 		if err != nil {
-			return
+			_, _ = fs.sdk.DeleteFile(cleanupCtx, sdk.ByPath(partialPath))
+		}
+	}()
+
+	var transferred uint64
+
+	for done := false; !done; {
+		if ctx.Err() != nil {
+			return &ErrCancelled{Path: path, Transferred: transferred, Err: ctx.Err()}
+		}
+
+		select {
+		case data, ok := <-dataCh:
+			if !ok {
+				done = true
+				break
+			}
+
+			fdt, err := fs.sdk.FileWrite(ctx, f.FD, data)
+			sdk.PutBuffer(data)
+			if err != nil {
+				return cancelledOr(ctx, err, path, transferred)
+			}
+			transferred += fdt.Bytes
+
+		case <-ctx.Done():
+			return &ErrCancelled{Path: path, Transferred: transferred, Err: ctx.Err()}
 		}
 	}
 
+	// dataCh is also closed when the reader is cancelled: the file must not be committed then.
+	if ctx.Err() != nil {
+		return &ErrCancelled{Path: path, Transferred: transferred, Err: ctx.Err()}
+	}
+
+	fdOpen = false
+	err = fs.sdk.FileClose(ctx, f.FD)
+	if err != nil {
+		return cancelledOr(ctx, err, path, transferred)
+	}
+
+	_, err = fs.sdk.RenameFile(ctx, sdk.ByPath(partialPath), sdk.ByPath(path))
+	if err != nil {
+		return cancelledOr(ctx, err, path, transferred)
+	}
+
 	return nil
 }
 
+// cancelledOr returns an *ErrCancelled if ctx was cancelled, or err otherwise.
+func cancelledOr(ctx context.Context, err error, path string, transferred uint64) error {
+	if ctx.Err() != nil {
+		return &ErrCancelled{Path: path, Transferred: transferred, Err: ctx.Err()}
+	}
+	return err
+}
+
 // RmDir removes a directory.
 func (fs *PCloud) RmDir(ctx context.Context, path string) error {
 	panic("not implemented")
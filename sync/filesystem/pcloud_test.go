@@ -3,7 +3,6 @@ package filesystem_test
 import (
 	"context"
 	"io"
-	"net/url"
 	"testing"
 	"time"
 
@@ -21,10 +20,8 @@ func TestPCloud_StreamFileData_MkFile(t *testing.T) {
 
 	data := []byte("Hello")
 
-	fileIDMatcher := func(f func(q url.Values)) bool {
-		q := url.Values{}
-		f(q)
-		return assert.Equal(t, "123", q.Get("fileid"))
+	fileIDMatcher := func(f sdk.Ref) bool {
+		return assert.Equal(t, sdk.ByID(123), f)
 	}
 
 	pCloudSDK1 := &mockPCloudSDK{}
@@ -36,14 +33,16 @@ func TestPCloud_StreamFileData_MkFile(t *testing.T) {
 		On("FileRead", ctx, uint64(124816), uint64(1_048_576), []sdk.ClientOption(nil)).
 		Return(data, io.EOF).
 		Once().
-		On("FileClose", ctx, uint64(124816), []sdk.ClientOption(nil)).
+		On("FileClose", mock.Anything, uint64(124816), []sdk.ClientOption(nil)).
 		Return(nil).
 		Once()
 
-	fileByPathMatcher := func(f func(q url.Values)) bool {
-		q := url.Values{}
-		f(q)
-		return assert.Equal(t, "somewhere", q.Get("path"))
+	fileByPathMatcher := func(f sdk.Ref) bool {
+		return assert.Equal(t, sdk.ByPath("somewhere.pcloud-partial"), f)
+	}
+
+	renameMatcher := func(f sdk.Ref) bool {
+		return assert.Equal(t, sdk.ByPath("somewhere"), f)
 	}
 
 	pCloudSDK2 := &mockPCloudSDK{}
@@ -57,6 +56,9 @@ func TestPCloud_StreamFileData_MkFile(t *testing.T) {
 		Once().
 		On("FileClose", ctx, uint64(321684), []sdk.ClientOption(nil)).
 		Return(nil).
+		Once().
+		On("RenameFile", ctx, mock.MatchedBy(fileByPathMatcher), mock.MatchedBy(renameMatcher), []sdk.ClientOption(nil)).
+		Return(&sdk.FileResult{}, nil).
 		Once()
 
 	u1 := filesystem.NewPCloud(pCloudSDK1)
@@ -84,11 +86,47 @@ func TestPCloud_StreamFileData_MkFile(t *testing.T) {
 	require.NoError(t, <-errCh)
 }
 
+func TestPCloud_MkFile_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	data := []byte("Hello")
+
+	pCloudSDK := &mockPCloudSDK{}
+	defer func() { _ = pCloudSDK.AssertExpectations(t) }()
+	pCloudSDK.
+		On("FileOpen", ctx, uint64(sdk.O_CREAT|sdk.O_TRUNC), mock.Anything, []sdk.ClientOption(nil)).
+		Return(&sdk.File{FD: 321684}, nil).
+		Once().
+		On("FileWrite", ctx, uint64(321684), data, []sdk.ClientOption(nil)).
+		Run(func(mock.Arguments) { cancel() }).
+		Return(&sdk.FileDataTransfer{Bytes: uint64(len(data))}, nil).
+		Once().
+		On("FileClose", mock.Anything, uint64(321684), []sdk.ClientOption(nil)).
+		Return(nil).
+		Once().
+		On("DeleteFile", mock.Anything, mock.Anything, []sdk.ClientOption(nil)).
+		Return(&sdk.FileResult{}, nil).
+		Once()
+
+	dataCh := make(chan []byte, 2)
+	dataCh <- data
+	dataCh <- data
+
+	err := filesystem.NewPCloud(pCloudSDK).MkFile(ctx, "somewhere", dataCh)
+	require.Error(t, err)
+
+	var errCancelled *filesystem.ErrCancelled
+	require.ErrorAs(t, err, &errCancelled)
+	assert.Equal(t, "somewhere", errCancelled.Path)
+	assert.EqualValues(t, len(data), errCancelled.Transferred)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
 type mockPCloudSDK struct {
 	mock.Mock
 }
 
-func (m *mockPCloudSDK) FileOpen(ctx context.Context, flags uint64, file sdk.T4PathOrFileIDOrFolderIDName, opts ...sdk.ClientOption) (*sdk.File, error) {
+func (m *mockPCloudSDK) FileOpen(ctx context.Context, flags uint64, file sdk.FileRef, opts ...sdk.ClientOption) (*sdk.File, error) {
 	args := m.Called(ctx, flags, file, opts)
 	return args.Get(0).(*sdk.File), args.Error(1)
 }
@@ -107,3 +145,13 @@ func (m *mockPCloudSDK) FileWrite(ctx context.Context, fd uint64, data []byte, o
 	args := m.Called(ctx, fd, data, opts)
 	return args.Get(0).(*sdk.FileDataTransfer), args.Error(1)
 }
+
+func (m *mockPCloudSDK) RenameFile(ctx context.Context, file sdk.FileRef, destination sdk.FolderRef, opts ...sdk.ClientOption) (*sdk.FileResult, error) {
+	args := m.Called(ctx, file, destination, opts)
+	return args.Get(0).(*sdk.FileResult), args.Error(1)
+}
+
+func (m *mockPCloudSDK) DeleteFile(ctx context.Context, file sdk.FileRef, opts ...sdk.ClientOption) (*sdk.FileResult, error) {
+	args := m.Called(ctx, file, opts)
+	return args.Get(0).(*sdk.FileResult), args.Error(1)
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/pkg/errors"
 
+	"github.com/seborama/pcloud-sdk/sdk"
 	"github.com/seborama/pcloud-sdk/tracker/db"
 )
 
@@ -56,11 +57,13 @@ func (fs *Unix) StreamFileData(ctx context.Context, fsEntry db.FSEntry) (<-chan
 		}()
 
 		br := bufio.NewReader(f)
-		data := make([]byte, 1_048_576)
 
 		for {
+			// each chunk gets its own buffer: the receiver owns it and returns it to the pool.
+			data := sdk.GetBuffer(1_048_576)
 			n, err := br.Read(data)
 			if err != nil {
+				sdk.PutBuffer(data)
 				if !errors.Is(err, io.EOF) {
 					errCh <- errors.WithStack(err)
 					return
@@ -81,6 +84,7 @@ func (fs *Unix) MkDir(ctx context.Context, path string) error {
 }
 
 // MkFile creates a file with the contents streamed through dataCh.
+// The slices received from dataCh are returned to the SDK buffer pool once written (see sdk.PutBuffer).
 // TODO: wrap the dataCh into a io.ReadWriter so to keep the code simple and offer a familiar Go feel.
 func (fs *Unix) MkFile(ctx context.Context, path string, dataCh <-chan []byte) (err error) {
 	f, err := fs.fsOps.OpenFile(path, os.O_CREATE|os.O_TRUNC, 0640)
@@ -99,6 +103,7 @@ func (fs *Unix) MkFile(ctx context.Context, path string, dataCh <-chan []byte) (
 
 	for data := range dataCh {
 		_, err = bw.Write(data)
+		sdk.PutBuffer(data)
 		if err != nil {
 			return
 		}
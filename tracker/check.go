@@ -0,0 +1,325 @@
+package tracker
+
+import (
+	"context"
+	"os"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+	"github.com/seborama/pcloud-sdk/tracker/db"
+	"github.com/seborama/pcloud-sdk/tracker/filesystem"
+)
+
+// CheckIssueKind is the kind of a divergence that Check found between the store and the pair.
+type CheckIssueKind string
+
+const (
+	// IssueOrphaned is the state of a path that is on neither side of the pair.
+	IssueOrphaned CheckIssueKind = "orphaned"
+	// IssueStaleHash is the state of a file whose hash differs from that of one of its sides,
+	// although both sides have the same contents: the next sync would transfer it again.
+	IssueStaleHash CheckIssueKind = "stale-hash"
+	// IssueUntracked is a file or folder that is on both sides with the same contents, but that
+	// has no state.
+	IssueUntracked CheckIssueKind = "untracked"
+	// IssueLocalHash is a hash of a local file, held for its size and modification time, that
+	// differs from the hash of its contents.
+	IssueLocalHash CheckIssueKind = "local-hash"
+	// IssueRemoteTree is a file or folder of the saved tree of the pCloud folder that differs
+	// from its listing.
+	IssueRemoteTree CheckIssueKind = "remote-tree"
+)
+
+// CheckIssue is a divergence that Check found between the store and the pair.
+type CheckIssue struct {
+	Kind CheckIssueKind `json:"kind"`
+	// Path is the slash-separated path of the file or folder, relative to the roots of the pair.
+	Path   string `json:"path"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// CheckReport is the outcome of Check.
+type CheckReport struct {
+	// Checked is the number of paths of the state, of the local folder and of the pCloud folder
+	// that were compared.
+	Checked  int          `json:"checked"`
+	Issues   []CheckIssue `json:"issues"`
+	Repaired bool         `json:"repaired"`
+}
+
+// Check validates what the store holds of the pair against both sides: its state, against a scan
+// of the local folder that hashes every file again and a listing of the pCloud folder, the hashes
+// of the local files, and the saved tree of the pCloud folder. The changes of either side since
+// the last sync are not divergences: they are left to the next sync.
+//
+// With repair, the store is rebuilt from both sides where they diverge: the files that have the
+// same contents on both sides are recorded as synced, so that the next sync does not transfer
+// them again, the state of the paths that are on neither side is dropped, and the wrong hashes
+// and tree are replaced. The files are left as they are.
+// nolint: gocyclo
+func (s *TwoWay) Check(ctx context.Context, repair bool) (*CheckReport, error) {
+	info, err := s.readState(ctx)
+	if err != nil {
+		return nil, err
+	}
+	emptyIfMissing := info.entries == 0
+
+	s.ignore, err = s.loadIgnorer(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.order = s.pathOrder()
+
+	report := &CheckReport{Issues: []CheckIssue{}}
+
+	local, err := scan(ctx, filesystem.NewLocal(s.localOptions()...), localFSName, s.localRoot)
+	switch {
+	case err != nil && emptyIfMissing && errors.Is(err, os.ErrNotExist):
+		local = map[string]db.FSEntry{}
+	case err != nil:
+		return nil, errors.WithMessage(err, "scanning the local folder")
+	}
+
+	var wrongHashes []db.LocalHash
+	err = s.store.EachLocalHash(ctx, s.pairName, db.ByPath, func(h db.LocalHash) error {
+		e, ok := local[h.Path]
+		if ok && !e.IsFolder && int64(e.Size) == h.Size && e.Modified.Equal(h.Modified) && e.Hash != h.Hash {
+			report.Issues = append(report.Issues, CheckIssue{Kind: IssueLocalHash, Path: h.Path, Detail: "the file was changed without its modification time"})
+			wrongHashes = append(wrongHashes, h)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	remoteEntries, listing, err := s.checkRemote(ctx, emptyIfMissing, report)
+	if err != nil {
+		return nil, err
+	}
+
+	report.Checked = info.entries + len(local) + len(remoteEntries)
+
+	// the hashes and the tree are those of the names of the files, and the state that of their
+	// canonical paths.
+	merge := s.merge(s.eachState(ctx, info, s.order), mapEntries(s.order, local), mapEntries(s.order, remoteEntries))
+
+	// base holds the repaired state of the paths of touched. The files whose contents are to be
+	// compared are compared once the state is streamed: stale, those of the state, and
+	// untracked, those that have none.
+	base := map[string]db.SyncStateEntry{}
+	var (
+		touched          []string
+		stale, untracked []action
+	)
+
+	err = merge(func(p string, e *db.SyncStateEntry, l, r *db.FSEntry) error {
+		a := action{path: p, local: l, remote: r}
+
+		switch {
+		case e == nil:
+			if l != nil && r != nil && l.IsFolder == r.IsFolder {
+				untracked = append(untracked, a)
+			}
+
+		case s.ignore.ignored(p, e.IsFolder):
+			// the sync forgets the state of the ignored paths.
+
+		case l == nil && r == nil:
+			report.Issues = append(report.Issues, CheckIssue{Kind: IssueOrphaned, Path: p, Detail: "the path is on neither side"})
+			touched = append(touched, p)
+
+		case l == nil || r == nil:
+			// deleted from one side since the last sync.
+
+		case l.IsFolder || r.IsFolder:
+			if l.IsFolder && r.IsFolder && !e.IsFolder {
+				report.Issues = append(report.Issues, CheckIssue{Kind: IssueStaleHash, Path: p, Detail: "the folder is recorded as a file"})
+				s.record(a, base)
+				touched = append(touched, p)
+			}
+
+		case l.Hash != e.LocalHash || r.Hash != e.RemoteHash:
+			stale = append(stale, a)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, a := range stale {
+		same, err := s.sameContents(ctx, a)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "path: %s", a.path)
+		}
+		if same {
+			report.Issues = append(report.Issues, CheckIssue{Kind: IssueStaleHash, Path: a.path, Detail: "both sides have the same contents"})
+			s.record(a, base)
+			touched = append(touched, a.path)
+		}
+	}
+
+	for _, a := range untracked {
+		if !a.local.IsFolder {
+			same, err := s.sameContents(ctx, a)
+			if err != nil {
+				return nil, errors.WithMessagef(err, "path: %s", a.path)
+			}
+			if !same {
+				// a conflict, which is left to the next sync.
+				continue
+			}
+		}
+
+		report.Issues = append(report.Issues, CheckIssue{Kind: IssueUntracked, Path: a.path, Detail: "both sides have the same contents"})
+		s.record(a, base)
+		touched = append(touched, a.path)
+	}
+
+	sort.SliceStable(report.Issues, func(i, j int) bool { return report.Issues[i].Path < report.Issues[j].Path })
+
+	if !repair || len(report.Issues) == 0 {
+		return report, nil
+	}
+
+	entries, removed := stateChanges(info, base, touched)
+
+	err = s.store.UpdateSyncState(ctx, s.pairName, entries, removed)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(wrongHashes) > 0 {
+		err = s.store.DeleteLocalHashes(ctx, s.pairName, wrongHashes)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if listing != nil {
+		err = s.store.DeleteSyncRemote(ctx, s.pairName)
+		if err != nil {
+			return nil, err
+		}
+
+		err = s.store.UpdateSyncRemote(ctx, s.pairName, listing.remote, listing.entries, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	report.Repaired = true
+
+	return report, nil
+}
+
+// remoteListing is a listing of the pCloud folder, with its root folder.
+type remoteListing struct {
+	remote  db.SyncRemote
+	entries []db.FSEntry
+}
+
+// checkRemote lists the pCloud folder, and compares it with its saved tree. It returns the listed
+// entries, and the listing when the saved tree diverges from it.
+func (s *TwoWay) checkRemote(ctx context.Context, emptyIfMissing bool, report *CheckReport) (map[string]db.FSEntry, *remoteListing, error) {
+	saved, err := s.store.GetSyncRemote(ctx, s.pairName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if saved != nil {
+		// the saved tree is brought up to date with the diff of the pCloud account, like by the
+		// syncs.
+		err = s.scanRemote(ctx)
+		if err != nil {
+			return nil, nil, errors.WithMessage(err, "reading the saved tree of the pCloud folder")
+		}
+	}
+
+	listing := &remoteListing{}
+	entries := map[string]db.FSEntry{}
+
+	remote, err := s.listRemote(ctx, func(p string, e db.FSEntry) error {
+		listing.entries = append(listing.entries, e)
+		if p != "" && !unsynced(p) && !s.ignore.ignored(p, e.IsFolder) {
+			entries[p] = e
+		}
+		return nil
+	})
+	switch {
+	case err != nil && emptyIfMissing && sdk.IsNotFound(err):
+		return map[string]db.FSEntry{}, nil, nil
+	case err != nil:
+		return nil, nil, errors.WithMessage(err, "listing the pCloud folder")
+	}
+	listing.remote = *remote
+
+	if saved == nil {
+		return entries, nil, nil
+	}
+
+	// the saved tree is streamed, and compared with the listing.
+	var issues []CheckIssue
+	seen := make(map[string]bool, len(entries))
+
+	err = s.store.EachSyncRemote(ctx, s.pairName, db.ByPath, func(p string, se db.FSEntry) error {
+		if unsynced(p) {
+			return nil
+		}
+
+		e, ok := entries[p]
+		switch {
+		case !ok && s.ignore.ignored(p, se.IsFolder):
+		case !ok:
+			issues = append(issues, CheckIssue{Kind: IssueRemoteTree, Path: p, Detail: "not in pCloud"})
+		case se.IsFolder != e.IsFolder || se.Hash != e.Hash:
+			issues = append(issues, CheckIssue{Kind: IssueRemoteTree, Path: p, Detail: "differs from pCloud"})
+		}
+		seen[p] = true
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "reading the saved tree of the pCloud folder")
+	}
+
+	for p := range entries {
+		if !seen[p] {
+			issues = append(issues, CheckIssue{Kind: IssueRemoteTree, Path: p, Detail: "missing from the saved tree"})
+		}
+	}
+
+	if len(issues) == 0 {
+		return entries, nil, nil
+	}
+
+	sort.Slice(issues, func(i, j int) bool { return issues[i].Path < issues[j].Path })
+	report.Issues = append(report.Issues, issues...)
+
+	return entries, listing, nil
+}
+
+// mapEntries returns the stream of entries, by path, in the order order.
+func mapEntries(order db.PathOrder, entries map[string]db.FSEntry) entryFunc {
+	return func(fn func(p string, e db.FSEntry) error) error {
+		paths := make([]string, 0, len(entries))
+		for p := range entries {
+			paths = append(paths, p)
+		}
+		sort.Slice(paths, func(i, j int) bool { return comparePaths(order, paths[i], paths[j]) < 0 })
+
+		for _, p := range paths {
+			err := fn(p, entries[p])
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,114 @@
+package tracker_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/tracker"
+	"github.com/seborama/pcloud-sdk/tracker/db"
+)
+
+func TestTwoWay_Check(t *testing.T) {
+	ctx := context.Background()
+
+	srv, store, local, s := newTestTwoWay(t)
+
+	writeLocalFile(t, local, "a.txt", "a")
+	writeLocalFile(t, local, "Sub/b.txt", "bb")
+	writeLocalFile(t, local, "c.txt", "c")
+
+	_, err := s.Sync(ctx)
+	require.NoError(t, err)
+
+	report, err := s.Check(ctx, false)
+	require.NoError(t, err)
+	assert.Empty(t, report.Issues)
+	assert.False(t, report.Repaired)
+	assert.Positive(t, report.Checked)
+
+	// the changes since the last sync are not divergences.
+	writeLocalFile(t, local, "new.txt", "new")
+	_, err = srv.WriteFile("/Sync/c.txt", []byte("c2"))
+	require.NoError(t, err)
+
+	report, err = s.Check(ctx, false)
+	require.NoError(t, err)
+	assert.Empty(t, report.Issues)
+
+	_, err = s.Sync(ctx)
+	require.NoError(t, err)
+
+	// the store is damaged.
+	entries, err := store.GetSyncState(ctx, "test")
+	require.NoError(t, err)
+
+	damaged := []db.SyncStateEntry{{Path: "gone.txt", Size: 1, LocalHash: "x", RemoteHash: "y"}}
+	for _, e := range entries {
+		switch e.Path {
+		case "a.txt":
+			continue
+		case "Sub/b.txt":
+			e.LocalHash = "bad"
+		}
+		damaged = append(damaged, e)
+	}
+	require.NoError(t, store.ReplaceSyncState(ctx, "test", damaged))
+
+	info, err := os.Stat(filepath.Join(local, "c.txt"))
+	require.NoError(t, err)
+	require.NoError(t, store.AddLocalHashes(ctx, "test", []db.LocalHash{{Path: "c.txt", Size: info.Size(), Modified: info.ModTime(), Hash: "bad"}}))
+
+	remote, err := store.GetSyncRemote(ctx, "test")
+	require.NoError(t, err)
+	require.NotNil(t, remote)
+	var removed []db.FSEntry
+	err = store.EachSyncRemote(ctx, "test", db.ByPath, func(p string, e db.FSEntry) error {
+		if p == "Sub/b.txt" {
+			removed = append(removed, e)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.NoError(t, store.UpdateSyncRemote(ctx, "test", *remote, nil, removed))
+
+	want := []tracker.CheckIssue{
+		{Kind: tracker.IssueRemoteTree, Path: "Sub/b.txt", Detail: "missing from the saved tree"},
+		{Kind: tracker.IssueStaleHash, Path: "Sub/b.txt", Detail: "both sides have the same contents"},
+		{Kind: tracker.IssueUntracked, Path: "a.txt", Detail: "both sides have the same contents"},
+		{Kind: tracker.IssueLocalHash, Path: "c.txt", Detail: "the file was changed without its modification time"},
+		{Kind: tracker.IssueOrphaned, Path: "gone.txt", Detail: "the path is on neither side"},
+	}
+
+	report, err = s.Check(ctx, false)
+	require.NoError(t, err)
+	assert.Equal(t, want, report.Issues)
+	assert.False(t, report.Repaired)
+
+	// nothing was repaired.
+	state, err := store.GetSyncState(ctx, "test")
+	require.NoError(t, err)
+	assert.Len(t, state, len(damaged))
+
+	report, err = s.Check(ctx, true)
+	require.NoError(t, err)
+	assert.Equal(t, want, report.Issues)
+	assert.True(t, report.Repaired)
+
+	report, err = s.Check(ctx, false)
+	require.NoError(t, err)
+	assert.Empty(t, report.Issues)
+
+	// the repaired pair is in sync: no file is transferred again.
+	actions, err := s.Plan(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, actions)
+
+	stats, err := s.Sync(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, stats.Uploaded+stats.Downloaded)
+}
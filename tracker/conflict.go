@@ -0,0 +1,208 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/seborama/pcloud-sdk/tracker/db"
+)
+
+// ConflictPolicy is how TwoWay resolves the conflicts: the files that changed on both sides
+// since the last sync.
+type ConflictPolicy string
+
+const (
+	// ConflictSkip leaves the conflicts untouched: they are reported until both sides are made
+	// identical. It is the default.
+	ConflictSkip ConflictPolicy = "skip"
+	// ConflictKeepNewest keeps the file modified last, which replaces the other one.
+	ConflictKeepNewest ConflictPolicy = "keep-newest"
+	// ConflictKeepBoth keeps both files: the local file is renamed with a conflict suffix and
+	// uploaded, and the pCloud file is downloaded.
+	ConflictKeepBoth ConflictPolicy = "keep-both"
+	// ConflictPreferLocal keeps the local file, which replaces the pCloud file.
+	ConflictPreferLocal ConflictPolicy = "prefer-local"
+	// ConflictPreferRemote keeps the pCloud file, which replaces the local file.
+	ConflictPreferRemote ConflictPolicy = "prefer-remote"
+	// ConflictAsk asks which of the other policies resolves each conflict (see
+	// WithConflictAsker).
+	ConflictAsk ConflictPolicy = "ask"
+)
+
+// ParseConflictPolicy parses the name of a conflict policy, such as "keep-newest".
+func ParseConflictPolicy(s string) (ConflictPolicy, error) {
+	switch p := ConflictPolicy(s); p {
+	case ConflictSkip, ConflictKeepNewest, ConflictKeepBoth, ConflictPreferLocal, ConflictPreferRemote, ConflictAsk:
+		return p, nil
+	default:
+		return "", errors.Errorf("unknown conflict policy '%s': use skip, keep-newest, keep-both, prefer-local, prefer-remote or ask", s)
+	}
+}
+
+// Conflict is a file that changed on both sides since the last sync.
+type Conflict struct {
+	// Path is the slash-separated path of the file, relative to the roots of the pair.
+	Path           string
+	LocalSize      uint64
+	LocalModified  time.Time
+	RemoteSize     uint64
+	RemoteModified time.Time
+}
+
+// ConflictAsker chooses the policy that resolves the conflict c. It must not return ConflictAsk.
+type ConflictAsker func(ctx context.Context, c Conflict) (ConflictPolicy, error)
+
+// WithConflictPolicy sets how the conflicts are resolved. It defaults to ConflictSkip.
+func WithConflictPolicy(p ConflictPolicy) TwoWayOption {
+	return func(s *TwoWay) {
+		s.conflictPolicy = p
+	}
+}
+
+// WithConflictAsker sets the function that chooses the resolution of each conflict with the
+// ConflictAsk policy. The conflicts are skipped when there is none.
+func WithConflictAsker(ask ConflictAsker) TwoWayOption {
+	return func(s *TwoWay) {
+		s.askConflict = ask
+	}
+}
+
+// resolveConflict resolves the conflict of a with the conflict policy, and records the decision.
+// The conflicts between a file and a folder are always skipped.
+func (s *TwoWay) resolveConflict(ctx context.Context, a action, base map[string]db.SyncStateEntry, stats *SyncStats) error {
+	resolution := s.conflictPolicy
+
+	switch {
+	case a.local.IsFolder || a.remote.IsFolder:
+		resolution = ConflictSkip
+
+	case resolution == ConflictAsk && s.askConflict == nil:
+		resolution = ConflictSkip
+
+	case resolution == ConflictAsk:
+		var err error
+		resolution, err = s.askConflict(ctx, Conflict{
+			Path:           a.path,
+			LocalSize:      a.local.Size,
+			LocalModified:  a.local.Modified,
+			RemoteSize:     a.remote.Size,
+			RemoteModified: a.remote.Modified,
+		})
+		if err != nil {
+			return err
+		}
+		if resolution == ConflictAsk {
+			return errors.New("the conflict policy chosen for the conflict must not be 'ask'")
+		}
+	}
+
+	if resolution == ConflictKeepNewest {
+		resolution = keepNewest(a)
+	}
+
+	now := time.Now()
+	copyPath := ""
+
+	switch resolution {
+	case ConflictSkip:
+		s.logger.Warn("sync conflict: the file changed on both sides, it is left untouched", zap.String("path", a.path))
+		stats.Conflicts = append(stats.Conflicts, a.path)
+
+	case ConflictPreferLocal:
+		entry, err := s.upload(ctx, a.path)
+		if err != nil {
+			return err
+		}
+		base[a.path] = *entry
+		stats.Uploaded++
+
+	case ConflictPreferRemote:
+		entry, err := s.download(ctx, a.path, a.remote)
+		if err != nil {
+			return err
+		}
+		base[a.path] = *entry
+		stats.Downloaded++
+
+	case ConflictKeepBoth:
+		copyPath = conflictCopyPath(a.path, now)
+
+		err := os.Rename(s.localPath(a.path), s.localPath(copyPath))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		entry, err := s.upload(ctx, copyPath)
+		if err != nil {
+			return err
+		}
+		base[copyPath] = *entry
+		stats.Uploaded++
+
+		entry, err = s.download(ctx, a.path, a.remote)
+		if err != nil {
+			return err
+		}
+		base[a.path] = *entry
+		stats.Downloaded++
+
+	default:
+		return errors.Errorf("unknown conflict policy '%s'", resolution)
+	}
+
+	if resolution != ConflictSkip {
+		s.logger.Info("sync conflict resolved", zap.String("path", a.path), zap.String("resolution", string(resolution)))
+		stats.ResolvedConflicts++
+	}
+
+	return s.store.AddSyncConflict(ctx, s.pairName, db.SyncConflict{
+		Path:       a.path,
+		Detected:   now,
+		Policy:     string(s.conflictPolicy),
+		Resolution: string(resolution),
+		CopyPath:   copyPath,
+	})
+}
+
+// keepNewest returns the resolution of the conflict of a by the ConflictKeepNewest policy.
+func keepNewest(a action) ConflictPolicy {
+	if a.local.Modified.After(a.remote.Modified) {
+		return ConflictPreferLocal
+	}
+
+	return ConflictPreferRemote
+}
+
+// plannedResolution returns the resolution of the conflict of a that the sync would apply, without
+// asking it: ConflictAsk when it would be asked.
+func (s *TwoWay) plannedResolution(a action) ConflictPolicy {
+	switch {
+	case a.local.IsFolder || a.remote.IsFolder:
+		return ConflictSkip
+	case s.conflictPolicy == ConflictAsk && s.askConflict == nil:
+		return ConflictSkip
+	case s.conflictPolicy == ConflictKeepNewest:
+		return keepNewest(a)
+	default:
+		return s.conflictPolicy
+	}
+}
+
+// conflictCopyPath returns the path that the local file p is renamed to by the keep-both
+// resolution of its conflict, at time t: "Notes/todo (local conflict 2024-01-02 150405).txt".
+func conflictCopyPath(p string, t time.Time) string {
+	ext := path.Ext(p)
+	if ext == path.Base(p) {
+		// a dot file, such as ".profile", has no extension.
+		ext = ""
+	}
+
+	return fmt.Sprintf("%s (local conflict %s)%s", strings.TrimSuffix(p, ext), t.Format("2006-01-02 150405"), ext)
+}
@@ -0,0 +1,387 @@
+package tracker
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/seborama/pcloud-sdk/tracker/db"
+	"github.com/seborama/pcloud-sdk/tracker/filesystem"
+)
+
+// DefaultShutdownGrace is the default shutdown grace period of the daemon (see WithShutdownGrace).
+const DefaultShutdownGrace = 30 * time.Second
+
+// Duration is a time.Duration that is encoded in JSON as a string, such as "1h30m".
+type Duration time.Duration
+
+// MarshalText implements encoding.TextMarshaler.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	*d = Duration(parsed)
+
+	return nil
+}
+
+// DaemonConfig is the configuration of the daemon, which watches sync pairs until it is stopped.
+// It is read from a JSON file (see LoadDaemonConfig), such as:
+//
+//	{
+//	  "local": "/home/me/pcloud",
+//	  "remote": "/Backup",
+//	  "conflict": "keep-both",
+//	  "sync_interval": "5m",
+//	  "quiet_hours": ["09:00-12:00", "23:00-07:00"],
+//	  "limits": {"upload_concurrency": 2, "upload_rate": 1048576},
+//	  "notify": [{"type": "desktop"}]
+//	}
+//
+// The daemon watches the pair of the top-level settings, or the pairs of "pairs", each with its
+// own settings:
+//
+//	{
+//	  "pairs": [
+//	    {"pair": "photos", "local": "/home/me/Pictures", "remote": "/Photos", "direction": "upload"},
+//	    {"pair": "docs", "local": "/home/me/Documents", "remote": "/Documents", "sync_interval": "1h"}
+//	  ],
+//	  "limits": {"upload_concurrency": 2}
+//	}
+type DaemonConfig struct {
+	PairConfig
+	// Pairs are the sync pairs of the daemon, when it watches several.
+	Pairs []PairConfig `json:"pairs,omitempty"`
+	// Limits are the limits of the transfers, which the daemon may change while it runs. They are
+	// shared by all the pairs.
+	Limits TransferLimits `json:"limits,omitempty"`
+	// Notify are the notifiers of the events of the syncs of all the pairs.
+	Notify []NotifierConfig `json:"notify,omitempty"`
+}
+
+// PairConfig is the configuration of a sync pair of the daemon.
+type PairConfig struct {
+	// Pair is the name under which the state of the sync is saved. The commands derive it from
+	// the folders when it is empty.
+	Pair   string `json:"pair,omitempty"`
+	Local  string `json:"local,omitempty"`
+	Remote string `json:"remote,omitempty"`
+	// Direction is the direction of the syncs (see WithDirection). It defaults to both.
+	Direction SyncDirection `json:"direction,omitempty"`
+	// Conflict is the conflict policy. It cannot be ConflictAsk: the daemon is not interactive.
+	Conflict   ConflictPolicy `json:"conflict,omitempty"`
+	IgnoreFile string         `json:"ignore_file,omitempty"`
+	// Symlinks and SpecialFiles are the policies of the symbolic links and the special files of
+	// the local folder. They default to skip.
+	Symlinks     filesystem.SymlinkPolicy     `json:"symlinks,omitempty"`
+	SpecialFiles filesystem.SpecialFilePolicy `json:"special_files,omitempty"`
+	// DeleteThreshold is the percentage of the files that a sync may delete (see
+	// WithDeleteThreshold). It defaults to DefaultDeleteThreshold.
+	DeleteThreshold int `json:"delete_threshold,omitempty"`
+	// PermanentDeletes clears the files deleted from pCloud from its trash (see
+	// WithPermanentDeletes).
+	PermanentDeletes bool `json:"permanent_deletes,omitempty"`
+	// FileModes records and restores the modes of the files (see WithFileModes).
+	FileModes bool `json:"file_modes,omitempty"`
+	// SyncDelay, SyncInterval and FullScanInterval are those of Watch: see WithSyncDelay,
+	// WithSyncInterval and WithFullScanInterval.
+	SyncDelay        *Duration `json:"sync_delay,omitempty"`
+	SyncInterval     Duration  `json:"sync_interval,omitempty"`
+	FullScanInterval *Duration `json:"full_scan_interval,omitempty"`
+	// QuietHours are the periods of the day when the daemon does not sync.
+	QuietHours []QuietHours `json:"quiet_hours,omitempty"`
+	// HashWorkers is the number of local files hashed concurrently (see WithHashWorkers).
+	HashWorkers int `json:"hash_workers,omitempty"`
+	// Selection is the folders of the pair that are synced, relative to its roots (see
+	// ParseSelection). The whole pair is synced when it is empty.
+	Selection []string `json:"selection,omitempty"`
+	// ShutdownGrace is the time given to the change in progress to complete when the daemon is
+	// stopped. It defaults to DefaultShutdownGrace.
+	ShutdownGrace *Duration `json:"shutdown_grace,omitempty"`
+}
+
+// The types of the notifiers of NotifierConfig.
+const (
+	notifierCommand = "command"
+	notifierWebhook = "webhook"
+	notifierDesktop = "desktop"
+)
+
+// NotifierConfig is the configuration of a notifier of the daemon, such as:
+//
+//	{"type": "webhook", "url": "https://example.com/hook", "events": ["failed"]}
+type NotifierConfig struct {
+	// Type is "command" (see CommandNotifier), "webhook" (see WebhookNotifier) or "desktop" (see
+	// DesktopNotifier).
+	Type string `json:"type"`
+	// Command is the command of the "command" notifiers, and URL the URL of the "webhook" ones.
+	Command []string `json:"command,omitempty"`
+	URL     string   `json:"url,omitempty"`
+	// Events are the events that the notifier is notified of. They default to failed and
+	// conflicts.
+	Events []NotifyEvent `json:"events,omitempty"`
+}
+
+// validate checks the configuration of the notifier.
+func (nc *NotifierConfig) validate() error {
+	switch nc.Type {
+	case notifierCommand:
+		if len(nc.Command) == 0 {
+			return errors.New("the command of the command notifier is missing")
+		}
+	case notifierWebhook:
+		u, err := url.Parse(nc.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.Errorf("invalid URL of the webhook notifier: '%s'", nc.URL)
+		}
+	case notifierDesktop:
+	default:
+		return errors.Errorf("unknown notifier type '%s': use command, webhook or desktop", nc.Type)
+	}
+
+	for _, e := range nc.Events {
+		_, err := ParseNotifyEvent(string(e))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// notifier returns the Notifier of the configuration.
+func (nc *NotifierConfig) notifier() Notifier {
+	switch nc.Type {
+	case notifierCommand:
+		return &CommandNotifier{Command: nc.Command}
+	case notifierWebhook:
+		return &WebhookNotifier{URL: nc.URL}
+	default:
+		return DesktopNotifier{}
+	}
+}
+
+// LoadDaemonConfig reads and validates the configuration of the daemon from the JSON file name.
+func LoadDaemonConfig(name string) (*DaemonConfig, error) {
+	data, err := os.ReadFile(name) // nolint: gosec
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	cfg := &DaemonConfig{}
+
+	err = json.Unmarshal(data, cfg)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading %s", name)
+	}
+
+	err = cfg.Validate()
+	if err != nil {
+		return nil, errors.WithMessagef(err, "invalid configuration %s", name)
+	}
+
+	return cfg, nil
+}
+
+// Validate checks the configuration, and sets the defaults of its unset fields.
+func (cfg *DaemonConfig) Validate() error {
+	if len(cfg.Pairs) > 0 {
+		if cfg.PairConfig.Local != "" || cfg.PairConfig.Remote != "" {
+			return errors.New("the top-level local and pCloud folders cannot be used with pairs")
+		}
+
+		names := make(map[string]bool)
+		folders := make(map[[2]string]bool)
+
+		for i := range cfg.Pairs {
+			pc := &cfg.Pairs[i]
+
+			err := pc.Validate()
+			if err != nil {
+				return errors.WithMessagef(err, "pair %d", i+1)
+			}
+
+			if pc.Pair != "" {
+				if names[pc.Pair] {
+					return errors.Errorf("duplicate pair '%s'", pc.Pair)
+				}
+				names[pc.Pair] = true
+			}
+
+			key := [2]string{pc.Local, pc.Remote}
+			if folders[key] {
+				return errors.Errorf("duplicate pair of '%s' and '%s'", pc.Local, pc.Remote)
+			}
+			folders[key] = true
+		}
+	} else {
+		err := cfg.PairConfig.Validate()
+		if err != nil {
+			return err
+		}
+	}
+
+	err := cfg.Limits.Validate()
+	if err != nil {
+		return err
+	}
+
+	for i := range cfg.Notify {
+		err = cfg.Notify[i].validate()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PairConfigs returns the configurations of the sync pairs of the daemon.
+func (cfg *DaemonConfig) PairConfigs() []PairConfig {
+	if len(cfg.Pairs) > 0 {
+		return cfg.Pairs
+	}
+
+	return []PairConfig{cfg.PairConfig}
+}
+
+// Validate checks the configuration of the pair, and sets the defaults of its unset fields.
+func (pc *PairConfig) Validate() error {
+	if pc.Local == "" {
+		return errors.New("the local folder is missing")
+	}
+	if pc.Remote == "" {
+		return errors.New("the pCloud folder is missing")
+	}
+
+	if pc.Direction == "" {
+		pc.Direction = DirectionBoth
+	}
+	_, err := ParseSyncDirection(string(pc.Direction))
+	if err != nil {
+		return err
+	}
+
+	if pc.Conflict == "" {
+		pc.Conflict = ConflictSkip
+	}
+	policy, err := ParseConflictPolicy(string(pc.Conflict))
+	if err != nil {
+		return err
+	}
+	if policy == ConflictAsk {
+		return errors.New("the daemon cannot ask how to resolve the conflicts: use another conflict policy")
+	}
+
+	if pc.Symlinks == "" {
+		pc.Symlinks = filesystem.SymlinkSkip
+	}
+	_, err = filesystem.ParseSymlinkPolicy(string(pc.Symlinks))
+	if err != nil {
+		return err
+	}
+
+	if pc.SpecialFiles == "" {
+		pc.SpecialFiles = filesystem.SpecialFileSkip
+	}
+	_, err = filesystem.ParseSpecialFilePolicy(string(pc.SpecialFiles))
+	if err != nil {
+		return err
+	}
+
+	for _, d := range []*Duration{pc.SyncDelay, &pc.SyncInterval, pc.FullScanInterval, pc.ShutdownGrace} {
+		if d != nil && *d < 0 {
+			return errors.Errorf("negative duration: %s", time.Duration(*d))
+		}
+	}
+
+	if pc.HashWorkers < 0 {
+		return errors.Errorf("negative number of hash workers: %d", pc.HashWorkers)
+	}
+
+	if pc.DeleteThreshold == 0 {
+		pc.DeleteThreshold = DefaultDeleteThreshold
+	}
+	if pc.DeleteThreshold < 0 || pc.DeleteThreshold > 100 {
+		return errors.Errorf("invalid delete threshold: %d%%", pc.DeleteThreshold)
+	}
+
+	if len(pc.Selection) > 0 {
+		pc.Selection, err = ParseSelection(pc.Selection)
+		if err != nil {
+			return err
+		}
+	}
+
+	if pc.ShutdownGrace == nil {
+		grace := Duration(DefaultShutdownGrace)
+		pc.ShutdownGrace = &grace
+	}
+
+	return nil
+}
+
+// TwoWayOptions returns the options of the TwoWay of the pair, but those of its clients, its
+// Limiter and its Progress.
+func (pc *PairConfig) TwoWayOptions() []TwoWayOption {
+	opts := []TwoWayOption{
+		WithConflictPolicy(pc.Conflict),
+		WithIgnoreFile(pc.IgnoreFile),
+		WithSymlinks(pc.Symlinks),
+		WithSpecialFiles(pc.SpecialFiles),
+		WithFileModes(pc.FileModes),
+		WithDeleteThreshold(pc.DeleteThreshold),
+		WithPermanentDeletes(pc.PermanentDeletes),
+	}
+
+	if pc.Direction != "" {
+		opts = append(opts, WithDirection(pc.Direction))
+	}
+	if pc.HashWorkers > 0 {
+		opts = append(opts, WithHashWorkers(pc.HashWorkers))
+	}
+	if pc.ShutdownGrace != nil {
+		opts = append(opts, WithShutdownGrace(time.Duration(*pc.ShutdownGrace)))
+	}
+
+	return opts
+}
+
+// Notifications returns the Notifications of the notifiers of the configuration, for the syncs of
+// the pair pairName.
+func (cfg *DaemonConfig) Notifications(logger *zap.Logger, pairName db.PairName) *Notifications {
+	ns := NewNotifications(logger, pairName)
+
+	for i := range cfg.Notify {
+		ns.Add(cfg.Notify[i].notifier(), cfg.Notify[i].Events...)
+	}
+
+	return ns
+}
+
+// WatchOptions returns the options of Watch of the pair, but its sync report.
+func (pc *PairConfig) WatchOptions() []WatchOption {
+	opts := []WatchOption{
+		WithSyncInterval(time.Duration(pc.SyncInterval)),
+		WithQuietHours(pc.QuietHours...),
+	}
+
+	if pc.SyncDelay != nil {
+		opts = append(opts, WithSyncDelay(time.Duration(*pc.SyncDelay)))
+	}
+	if pc.FullScanInterval != nil {
+		opts = append(opts, WithFullScanInterval(time.Duration(*pc.FullScanInterval)))
+	}
+
+	return opts
+}
@@ -0,0 +1,121 @@
+package tracker_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/tracker"
+	"github.com/seborama/pcloud-sdk/tracker/filesystem"
+)
+
+func TestLoadDaemonConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	write := func(data string) string {
+		t.Helper()
+
+		name := filepath.Join(dir, "daemon.json")
+		require.NoError(t, os.WriteFile(name, []byte(data), 0o600))
+
+		return name
+	}
+
+	cfg, err := tracker.LoadDaemonConfig(write(`{
+		"local": "/home/me/pcloud",
+		"remote": "/Backup",
+		"conflict": "keep-both",
+		"symlinks": "placeholder",
+		"file_modes": true,
+		"delete_threshold": 90,
+		"sync_interval": "5m",
+		"full_scan_interval": "0s",
+		"quiet_hours": ["23:00-07:00"],
+		"selection": ["/Photos/", "Work", "Work/Projects"],
+		"limits": {"upload_concurrency": 2, "download_rate": 1048576},
+		"notify": [{"type": "webhook", "url": "https://example.com/hook", "events": ["completed"]}, {"type": "desktop"}]
+	}`))
+	require.NoError(t, err)
+	assert.Equal(t, "/home/me/pcloud", cfg.Local)
+	assert.Equal(t, "/Backup", cfg.Remote)
+	assert.Equal(t, tracker.ConflictKeepBoth, cfg.Conflict)
+	assert.Equal(t, filesystem.SymlinkPlaceholder, cfg.Symlinks)
+	assert.Equal(t, filesystem.SpecialFileSkip, cfg.SpecialFiles)
+	assert.True(t, cfg.FileModes)
+	assert.Equal(t, 90, cfg.DeleteThreshold)
+	assert.Equal(t, tracker.Duration(5*time.Minute), cfg.SyncInterval)
+	require.NotNil(t, cfg.FullScanInterval)
+	assert.Zero(t, *cfg.FullScanInterval)
+	assert.Nil(t, cfg.SyncDelay)
+	assert.Equal(t, []tracker.QuietHours{{Start: 23 * 60, End: 7 * 60}}, cfg.QuietHours)
+	assert.Equal(t, []string{"Photos", "Work"}, cfg.Selection)
+	assert.Equal(t, tracker.TransferLimits{UploadConcurrency: 2, DownloadRate: 1 << 20}, cfg.Limits)
+	require.NotNil(t, cfg.ShutdownGrace)
+	assert.Equal(t, tracker.Duration(tracker.DefaultShutdownGrace), *cfg.ShutdownGrace)
+	require.Len(t, cfg.Notify, 2)
+	assert.Equal(t, []tracker.NotifyEvent{tracker.EventCompleted}, cfg.Notify[0].Events)
+
+	cfg, err = tracker.LoadDaemonConfig(write(`{"local": "a", "remote": "/b"}`))
+	require.NoError(t, err)
+	assert.Equal(t, tracker.ConflictSkip, cfg.Conflict)
+	assert.Equal(t, filesystem.SymlinkSkip, cfg.Symlinks)
+	assert.Equal(t, tracker.DefaultDeleteThreshold, cfg.DeleteThreshold)
+	assert.Equal(t, tracker.DirectionBoth, cfg.Direction)
+	assert.Equal(t, []tracker.PairConfig{cfg.PairConfig}, cfg.PairConfigs())
+
+	cfg, err = tracker.LoadDaemonConfig(write(`{
+		"pairs": [
+			{"pair": "photos", "local": "/home/me/Pictures", "remote": "/Photos", "direction": "upload"},
+			{"local": "/home/me/Documents", "remote": "/Documents", "sync_interval": "1h", "conflict": "keep-newest"}
+		],
+		"limits": {"upload_concurrency": 2}
+	}`))
+	require.NoError(t, err)
+	pairs := cfg.PairConfigs()
+	require.Len(t, pairs, 2)
+	assert.Equal(t, "photos", pairs[0].Pair)
+	assert.Equal(t, tracker.DirectionUpload, pairs[0].Direction)
+	assert.Equal(t, tracker.ConflictSkip, pairs[0].Conflict)
+	assert.Zero(t, pairs[0].SyncInterval)
+	assert.Equal(t, "/home/me/Documents", pairs[1].Local)
+	assert.Equal(t, tracker.DirectionBoth, pairs[1].Direction)
+	assert.Equal(t, tracker.ConflictKeepNewest, pairs[1].Conflict)
+	assert.Equal(t, tracker.Duration(time.Hour), pairs[1].SyncInterval)
+	require.NotNil(t, pairs[1].ShutdownGrace)
+	assert.Equal(t, tracker.TransferLimits{UploadConcurrency: 2}, cfg.Limits)
+
+	for _, data := range []string{
+		`{"remote": "/b"}`,
+		`{"local": "a"}`,
+		`{"local": "a", "remote": "/b", "conflict": "ask"}`,
+		`{"local": "a", "remote": "/b", "conflict": "whatever"}`,
+		`{"local": "a", "remote": "/b", "symlinks": "whatever"}`,
+		`{"local": "a", "remote": "/b", "delete_threshold": 101}`,
+		`{"local": "a", "remote": "/b", "special_files": "whatever"}`,
+		`{"local": "a", "remote": "/b", "sync_interval": "soon"}`,
+		`{"local": "a", "remote": "/b", "sync_delay": "-1s"}`,
+		`{"local": "a", "remote": "/b", "quiet_hours": ["night"]}`,
+		`{"local": "a", "remote": "/b", "selection": ["../c"]}`,
+		`{"local": "a", "remote": "/b", "limits": {"download_concurrency": -1}}`,
+		`{"local": "a", "remote": "/b", "notify": [{"type": "email"}]}`,
+		`{"local": "a", "remote": "/b", "notify": [{"type": "webhook", "url": "example.com"}]}`,
+		`{"local": "a", "remote": "/b", "notify": [{"type": "command"}]}`,
+		`{"local": "a", "remote": "/b", "notify": [{"type": "desktop", "events": ["done"]}]}`,
+		`{"local": "a", "remote": "/b", "direction": "sideways"}`,
+		`{"local": "a", "remote": "/b", "pairs": [{"local": "c", "remote": "/d"}]}`,
+		`{"pairs": [{"local": "a", "remote": "/b"}, {"local": "a", "remote": "/b"}]}`,
+		`{"pairs": [{"pair": "p", "local": "a", "remote": "/b"}, {"pair": "p", "local": "c", "remote": "/d"}]}`,
+		`{"pairs": [{"local": "a", "remote": "/b"}, {"local": "c"}]}`,
+		`{"local": "a"`,
+	} {
+		_, err = tracker.LoadDaemonConfig(write(data))
+		assert.Error(t, err, data)
+	}
+
+	_, err = tracker.LoadDaemonConfig(filepath.Join(dir, "missing.json"))
+	require.Error(t, err)
+}
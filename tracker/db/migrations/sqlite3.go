@@ -1,11 +1,13 @@
 package migrations
 
-// SQLite3 holds the migrations for the sqlite3-based schema.
+// SQLite3 holds the migrations for the sqlite3-based schema, in order: the schema version of a
+// database is the number of migrations that it applied. A released migration must not change what
+// it does: the changes of the schema are new migrations, appended to the list.
+//
+// The migrator applies each migration in a transaction, which the migration must not begin nor
+// commit.
 var SQLite3 = []string{
-	`	BEGIN;
-
-		PRAGMA foreign_keys = ON;
-
+	`
 		CREATE TABLE IF NOT EXISTS "filesystem" (
 			"fs_name"           VARCHAR,
 			"version"           VARCHAR,
@@ -61,6 +63,175 @@ var SQLite3 = []string{
 		);
 
 		CREATE INDEX IF NOT EXISTS staging_fs_mutations_fsname_version_device_entry ON staging_fs_mutations (fs_name, version, device_id, entry_id);
+	`,
+
+	`
+		-- the state of the files and folders of the sync pairs, as of their last two-way sync.
+		CREATE TABLE IF NOT EXISTS "sync_state" (
+			"pair_name"    VARCHAR,
+			"path"         VARCHAR,
+			"is_folder"    BOOL DEFAULT FALSE,
+			"size"         INTEGER NULL, -- only valid for files
+			"local_hash"   VARCHAR NULL, -- only valid for files
+			"remote_hash"  VARCHAR NULL, -- only valid for files
+
+			PRIMARY KEY (pair_name, path)
+		);
+	`,
+
+	`
+		-- the decisions taken on the files changed on both sides of the sync pairs.
+		CREATE TABLE IF NOT EXISTS "sync_conflicts" (
+			"pair_name"   VARCHAR NOT NULL,
+			"path"        VARCHAR NOT NULL,
+			"detected"    DATETIME NOT NULL,
+			"policy"      VARCHAR NOT NULL,
+			"resolution"  VARCHAR NOT NULL,
+			"copy_path"   VARCHAR NULL -- only valid for the "keep-both" resolution
+		);
+
+		CREATE INDEX IF NOT EXISTS sync_conflicts_pair_name ON sync_conflicts (pair_name, detected);
+	`,
+
+	`
+		-- the diff of the pCloud account that the trees of the pCloud folders of the sync pairs
+		-- are up to date with.
+		CREATE TABLE IF NOT EXISTS "sync_remote" (
+			"pair_name"       VARCHAR,
+			"diff_id"         INTEGER NOT NULL,
+			"root_folder_id"  INTEGER NOT NULL,
+
+			PRIMARY KEY (pair_name)
+		);
+
+		-- the files and folders of the trees of the pCloud folders of the sync pairs.
+		CREATE TABLE IF NOT EXISTS "sync_remote_entries" (
+			"pair_name"         VARCHAR,
+			"entry_id"          INTEGER,
+			"is_folder"         BOOL DEFAULT FALSE,
+			"parent_folder_id"  INTEGER NOT NULL,
+			"name"              VARCHAR NOT NULL,
+			"created"           DATETIME NOT NULL,
+			"modified"          DATETIME NOT NULL,
+			"size"              INTEGER NULL, -- only valid for files
+			"hash"              VARCHAR NULL, -- only valid for files
+
+			PRIMARY KEY (pair_name, is_folder, entry_id)
+		);
+	`,
+	`
+		-- the hashes of the local files of the sync pairs, by their size and modification time,
+		-- so that the unchanged files are not hashed again.
+		CREATE TABLE IF NOT EXISTS "local_hashes" (
+			"pair_name"  VARCHAR,
+			"path"       VARCHAR,
+			"size"       INTEGER NOT NULL,
+			"modified"   INTEGER NOT NULL, -- in nanoseconds since the Unix epoch
+			"hash"       VARCHAR NOT NULL,
+
+			PRIMARY KEY (pair_name, path)
+		);
+	`,
+	`
+		-- the folders of the sync pairs that are synced, when not all of them are.
+		CREATE TABLE IF NOT EXISTS "sync_selection" (
+			"pair_name"  VARCHAR,
+			"path"       VARCHAR,
+
+			PRIMARY KEY (pair_name, path)
+		);
+	`,
+	`
+		-- the transfers of the sync pairs in progress, which an interrupted sync resumes.
+		CREATE TABLE IF NOT EXISTS "sync_transfers" (
+			"pair_name"    VARCHAR,
+			"path"         VARCHAR,
+			"upload"       BOOL DEFAULT FALSE,
+			"size"         INTEGER NULL, -- only valid for uploads
+			"modified"     INTEGER NULL, -- only valid for uploads, in nanoseconds since the Unix epoch
+			"file_id"      INTEGER NULL, -- only valid for uploads
+			"remote_hash"  VARCHAR NULL, -- only valid for downloads
+
+			PRIMARY KEY (pair_name, path, upload)
+		);
+	`,
+	`
+		-- the history of the syncs of the sync pairs.
+		CREATE TABLE IF NOT EXISTS "sync_runs" (
+			"pair_name"           VARCHAR NOT NULL,
+			"started"             DATETIME NOT NULL,
+			"duration"            INTEGER NOT NULL, -- in nanoseconds
+			"scanned"             INTEGER NOT NULL,
+			"uploaded"            INTEGER NOT NULL,
+			"uploaded_bytes"      INTEGER NOT NULL,
+			"downloaded"          INTEGER NOT NULL,
+			"downloaded_bytes"    INTEGER NOT NULL,
+			"deleted_local"       INTEGER NOT NULL,
+			"deleted_remote"      INTEGER NOT NULL,
+			"moved_local"         INTEGER NOT NULL,
+			"moved_remote"        INTEGER NOT NULL,
+			"conflicts"           INTEGER NOT NULL,
+			"resolved_conflicts"  INTEGER NOT NULL,
+			"errors"              INTEGER NOT NULL,
+			"error"               VARCHAR NULL -- only valid for the failed syncs
+		);
 
-		COMMIT;`,
+		CREATE INDEX IF NOT EXISTS sync_runs_pair_name ON sync_runs (pair_name, started);
+	`,
+	`
+		-- the snapshots of the pairs of the snapshot backups, with their files.
+		CREATE TABLE IF NOT EXISTS "snapshots" (
+			"pair_name"  VARCHAR,
+			"name"       VARCHAR,
+			"started"    DATETIME NOT NULL,
+
+			PRIMARY KEY (pair_name, name)
+		);
+
+		CREATE TABLE IF NOT EXISTS "snapshot_files" (
+			"pair_name"  VARCHAR,
+			"snapshot"   VARCHAR,
+			"path"       VARCHAR,
+			"size"       INTEGER NOT NULL,
+			"hash"       VARCHAR NOT NULL, -- SHA-1
+			"file_id"    INTEGER NOT NULL,
+
+			PRIMARY KEY (pair_name, snapshot, path)
+		);
+	`,
+	`
+		-- the files of the pCloud folders of the sync pairs as of their most recent syncs, which
+		-- they can be restored from.
+		CREATE TABLE IF NOT EXISTS "sync_run_files" (
+			"pair_name"    VARCHAR,
+			"started"      DATETIME,
+			"path"         VARCHAR,
+			"size"         INTEGER NOT NULL,
+			"remote_hash"  VARCHAR NOT NULL,
+
+			PRIMARY KEY (pair_name, started, path)
+		);
+	`,
+	`
+		-- the contents of the folders of the trees of the pCloud folders, which the trees are
+		-- streamed by.
+		CREATE INDEX IF NOT EXISTS sync_remote_entries_parent ON sync_remote_entries (pair_name, parent_folder_id);
+
+		-- the files and folders of the local folders of the sync pairs as of their last syncs,
+		-- while they are watched.
+		CREATE TABLE IF NOT EXISTS "sync_local_entries" (
+			"pair_name"         VARCHAR,
+			"path"              VARCHAR, -- slash-separated, relative to the local root
+			"device_id"         VARCHAR NOT NULL,
+			"entry_id"          INTEGER NOT NULL,
+			"is_folder"         BOOL DEFAULT FALSE,
+			"parent_folder_id"  INTEGER NOT NULL,
+			"created"           DATETIME NOT NULL,
+			"modified"          DATETIME NOT NULL,
+			"size"              INTEGER NULL, -- only valid for files
+			"hash"              VARCHAR NULL, -- only valid for files
+
+			PRIMARY KEY (pair_name, path)
+		);
+	`,
 }
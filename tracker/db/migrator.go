@@ -3,7 +3,6 @@ package db
 import (
 	"context"
 	"database/sql"
-	"fmt"
 
 	// sqllite3 sql driver.
 	_ "github.com/mattn/go-sqlite3"
@@ -12,103 +11,88 @@ import (
 	"github.com/seborama/pcloud-sdk/tracker/db/migrations"
 )
 
+// Migrator upgrades the schema of a database to the latest version, with the migrations that it
+// has not applied yet: the data of the database is kept, such as the state of the sync pairs.
 type Migrator struct {
-	db *sql.DB
+	db         *sql.DB
+	migrations []string
 }
 
 // NewMigrator creates a new initialised Migrator struct.
 func NewMigrator(db *sql.DB) *Migrator {
 	return &Migrator{
-		db: db,
+		db:         db,
+		migrations: migrations.SQLite3,
 	}
 }
 
-func (m *Migrator) MigrateUp(ctx context.Context) (err error) {
-	stmt := `
-		BEGIN;
-		CREATE TABLE IF NOT EXISTS "schema_migrations" ( "version" INTEGER PRIMARY KEY, "status" VARCHAR );
-		COMMIT;`
-
-	_, err = m.db.ExecContext(ctx, stmt)
+// MigrateUp applies the migrations that the database has not applied yet, in order. Each
+// migration is applied in a transaction with the record of the new schema version: a migration
+// that fails leaves the database as it was before it.
+func (m *Migrator) MigrateUp(ctx context.Context) error {
+	_, err := m.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS "schema_version" ( "version" INTEGER NOT NULL )`)
 	if err != nil {
 		return errors.WithStack(err)
 	}
 
-	version := 0
-	var status migrationStatus
-
-	err = m.db.QueryRowContext(ctx, `SELECT version, status FROM "schema_migrations"`).Scan(&version, &status)
-	if err != nil && !errors.Is(err, sql.ErrNoRows) {
-		return errors.WithStack(err)
-	}
-
-	if status == migrationStatusInProgress {
-		return errors.Errorf("unable to apply database migration: migration version %d is recorded 'in progress', probably as the result of a previous failure", version)
-	}
-
-	if version == 0 {
-		version = -1
+	version, err := m.Version(ctx)
+	if err != nil {
+		return err
 	}
-	fmt.Printf("current migrations version: %d\n", version)
 
-	if version == len(migrations.SQLite3) {
-		fmt.Printf("migrations up-to-date - version: %d\n", version)
-		return nil
+	if version > len(m.migrations) {
+		return errors.Errorf("the database was upgraded by a newer release: its schema version is %d, while the latest known one is %d", version, len(m.migrations))
 	}
 
-	if version > len(migrations.SQLite3) {
-		return errors.Errorf("migrations corruption - last executed version: %d - highest available migration version: %d\n", version, len(migrations.SQLite3))
+	for ; version < len(m.migrations); version++ {
+		err = m.migrate(ctx, version)
+		if err != nil {
+			return errors.WithMessagef(err, "applying database migration %d", version+1)
+		}
 	}
 
-	for version, stmt := range migrations.SQLite3[version+1:] {
-		fmt.Printf("applying migrations version: %d\n", version)
+	// the databases of the earlier releases recorded their version in this table.
+	_, err = m.db.ExecContext(ctx, `DROP TABLE IF EXISTS "schema_migrations"`)
 
-		err = m.recordMigrationVersion(ctx, version, migrationStatusInProgress)
-		if err != nil {
-			return err
-		}
+	return errors.WithStack(err)
+}
 
-		_, err = m.db.ExecContext(ctx, stmt)
-		if err != nil {
-			return errors.WithStack(err)
-		}
+// Version returns the schema version of the database: the number of migrations that it applied.
+func (m *Migrator) Version(ctx context.Context) (int, error) {
+	version := 0
 
-		err = m.recordMigrationVersion(ctx, version, migrationStatusApplied)
-		if err != nil {
-			return err
-		}
+	err := m.db.QueryRowContext(ctx, `SELECT version FROM "schema_version"`).Scan(&version)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, errors.WithStack(err)
 	}
 
-	return nil
+	// the databases of the earlier releases, which recorded their version in the
+	// "schema_migrations" table, apply all the migrations again: the migrations that they could
+	// have applied are idempotent.
+	return version, nil
 }
 
-type migrationStatus string
-
-const (
-	migrationStatusInProgress migrationStatus = "in progress"
-	migrationStatusApplied    migrationStatus = "applied"
-)
-
-func (m *Migrator) recordMigrationVersion(ctx context.Context, version int, status migrationStatus) error {
+// migrate applies the migration of index i, and records the version i+1.
+func (m *Migrator) migrate(ctx context.Context, i int) error {
 	tx, err := m.db.BeginTx(ctx, nil)
 	if err != nil {
 		return errors.WithStack(err)
 	}
 
-	_, err = tx.ExecContext(ctx, `DELETE FROM "schema_migrations"`)
+	_, err = tx.ExecContext(ctx, m.migrations[i])
 	if err != nil {
 		return doRollback(tx, err)
 	}
 
-	_, err = tx.ExecContext(ctx, `INSERT INTO "schema_migrations" VALUES (?, ?)`, version, status)
+	_, err = tx.ExecContext(ctx, `DELETE FROM "schema_version"`)
 	if err != nil {
 		return doRollback(tx, err)
 	}
 
-	err = tx.Commit()
+	_, err = tx.ExecContext(ctx, `INSERT INTO "schema_version" VALUES (?)`, i+1)
 	if err != nil {
 		return doRollback(tx, err)
 	}
 
-	return nil
+	return errors.WithStack(tx.Commit())
 }
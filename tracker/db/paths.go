@@ -0,0 +1,47 @@
+package db
+
+import (
+	"fmt"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// PathOrder is an order of the slash-separated paths of the files and folders of a sync pair, in
+// which the store streams them: the paths are sorted by the bytes of their keys, then by their
+// own bytes.
+type PathOrder int
+
+const (
+	// ByPath sorts the paths by their bytes: the key of a path is the path.
+	ByPath PathOrder = iota
+	// ByCanonicalPath sorts the paths by their NFC normal form of Unicode, so that the paths that
+	// differ by their Unicode form only are next to each other.
+	ByCanonicalPath
+	// ByFoldedPath sorts the paths by the case folding of their NFC normal form, so that the paths
+	// that differ by case only are next to each other too.
+	ByFoldedPath
+)
+
+// Key returns the key of the path p. The key of a path is the key of its parent folder, a slash
+// and the key of its name: the contents of a folder are sorted after it.
+func (o PathOrder) Key(p string) string {
+	switch o {
+	case ByCanonicalPath:
+		return norm.NFC.String(p)
+	case ByFoldedPath:
+		return cases.Fold().String(norm.NFC.String(p))
+	default:
+		return p
+	}
+}
+
+// orderBy returns the ORDER BY clause that sorts the paths of column in the order o, with the
+// path_key SQL function of the connections of the store.
+func orderBy(column string, o PathOrder) string {
+	if o == ByPath {
+		return column
+	}
+
+	return fmt.Sprintf("path_key(%d, %s), %s", o, column, column)
+}
@@ -0,0 +1,170 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Snapshot is a snapshot of a pair of the snapshot backups: a copy of its local folder at a point
+// in time, in a folder of the pCloud folder of the pair named after it.
+type Snapshot struct {
+	Name    string    `json:"name"`
+	Started time.Time `json:"started"`
+	Files   int       `json:"files"`
+	Size    uint64    `json:"size"`
+}
+
+// SnapshotFile is a file of a snapshot.
+type SnapshotFile struct {
+	// Path is the slash-separated path of the file, relative to the folder of the snapshot.
+	Path string
+	Size uint64
+	// Hash is the SHA-1 hash of the contents of the file, which the next snapshots copy rather
+	// than upload.
+	Hash   string
+	FileID uint64
+}
+
+// AddSnapshot records the snapshot snap of the pair pairName, with its files.
+func (s *SQLite3) AddSnapshot(ctx context.Context, pairName PairName, snap Snapshot, files []SnapshotFile) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT OR REPLACE INTO "snapshots" (pair_name, name, started) VALUES (?, ?, ?)`,
+		pairName,
+		snap.Name,
+		snap.Started,
+	)
+	if err != nil {
+		return doRollback(tx, err)
+	}
+
+	stmt, err := tx.PrepareContext(
+		ctx,
+		`INSERT OR REPLACE INTO "snapshot_files" (pair_name, snapshot, path, size, hash, file_id) VALUES (?, ?, ?, ?, ?, ?)`,
+	)
+	if err != nil {
+		return doRollback(tx, err)
+	}
+	defer func() { _ = stmt.Close() }()
+
+	for _, f := range files {
+		_, err = stmt.ExecContext(ctx, pairName, snap.Name, f.Path, f.Size, f.Hash, f.FileID)
+		if err != nil {
+			return doRollback(tx, err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return doRollback(tx, err)
+	}
+
+	return nil
+}
+
+// GetSnapshots returns the snapshots of the pair pairName, the oldest first, with the number and
+// the total size of their files.
+func (s *SQLite3) GetSnapshots(ctx context.Context, pairName PairName) ([]Snapshot, error) {
+	// nolint: rowserrcheck
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT s.name, s.started, COUNT(f.path), COALESCE(SUM(f.size), 0)
+		 FROM "snapshots" s
+		 LEFT JOIN "snapshot_files" f ON f.pair_name = s.pair_name AND f.snapshot = s.name
+		 WHERE s.pair_name = :pair_name
+		 GROUP BY s.name, s.started
+		 ORDER BY s.started, s.name`,
+		sql.Named("pair_name", pairName),
+	)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	snaps := []Snapshot{}
+
+	for rows.Next() {
+		snap := Snapshot{}
+		err = rows.Scan(&snap.Name, &snap.Started, &snap.Files, &snap.Size)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		snaps = append(snaps, snap)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return snaps, nil
+}
+
+// GetSnapshotFiles returns the files of the snapshot name of the pair pairName, sorted by path.
+func (s *SQLite3) GetSnapshotFiles(ctx context.Context, pairName PairName, name string) ([]SnapshotFile, error) {
+	// nolint: rowserrcheck
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT path, size, hash, file_id
+		 FROM "snapshot_files"
+		 WHERE pair_name = :pair_name AND snapshot = :snapshot
+		 ORDER BY path`,
+		sql.Named("pair_name", pairName),
+		sql.Named("snapshot", name),
+	)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	files := []SnapshotFile{}
+
+	for rows.Next() {
+		f := SnapshotFile{}
+		err = rows.Scan(&f.Path, &f.Size, &f.Hash, &f.FileID)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		files = append(files, f)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return files, nil
+}
+
+// DeleteSnapshot deletes the snapshot name of the pair pairName, with its files.
+func (s *SQLite3) DeleteSnapshot(ctx context.Context, pairName PairName, name string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM "snapshot_files" WHERE pair_name = ? AND snapshot = ?`, pairName, name)
+	if err != nil {
+		return doRollback(tx, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM "snapshots" WHERE pair_name = ? AND name = ?`, pairName, name)
+	if err != nil {
+		return doRollback(tx, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return doRollback(tx, err)
+	}
+
+	return nil
+}
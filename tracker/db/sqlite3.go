@@ -4,14 +4,28 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"os"
 	"path/filepath"
 	"time"
 
-	// sqllite3 sql driver.
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 	"github.com/pkg/errors"
 )
 
+// driverName is the name of the sqlite3 sql driver of the store, whose connections have the SQL
+// function path_key(order, path), which returns the key of path in the PathOrder order.
+const driverName = "sqlite3_tracker"
+
+func init() {
+	sql.Register(driverName, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			return conn.RegisterFunc("path_key", func(order int64, p string) string {
+				return PathOrder(order).Key(p)
+			}, true)
+		},
+	})
+}
+
 // SQLite3 is a sqlite3 database store.
 type SQLite3 struct {
 	dbPathFilename string
@@ -27,16 +41,25 @@ const (
 
 // NewSQLite3 creates a new initialised SQLite3.
 func NewSQLite3(ctx context.Context, dbPath string) (*SQLite3, error) {
+	err := os.MkdirAll(dbPath, 0o700)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
 	dbPathFilename := filepath.Join(dbPath, "tracker.db")
 
-	db, err := sql.Open("sqlite3", dbPathFilename)
+	// the write-ahead log lets the readers run alongside a writer, such as a command that reads
+	// the state of the pairs during a sync, and the busy timeout makes the writers wait for each
+	// other rather than fail.
+	db, err := sql.Open(driverName, dbPathFilename+"?_journal_mode=WAL&_busy_timeout=10000&_synchronous=NORMAL")
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
 
 	err = NewMigrator(db).MigrateUp(ctx)
 	if err != nil {
-		return nil, errors.WithStack(err)
+		_ = db.Close()
+		return nil, err
 	}
 
 	return &SQLite3{
@@ -651,6 +674,12 @@ func (s *SQLite3) refreshFSMutationsStagingTable(ctx context.Context, fsName FSN
 // FSName is a descriptive name for the tracked file system.
 type FSName string
 
+// The names of the file systems of a sync pair.
+const (
+	LocalFileSystem  FSName = "local"
+	PCloudFileSystem FSName = "pcloud"
+)
+
 // SyncStatus defines the status of the sync. It is used to prevent refreshing data in the
 // filesystem table when it has not yet been completely sync'ed.
 // In particular, VersionPrevious should not be replaced with new data until the sync has
@@ -2,13 +2,20 @@ package db_test
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"os"
+	"path"
+	"path/filepath"
 	"testing"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/seborama/pcloud-sdk/tracker/db"
+	"github.com/seborama/pcloud-sdk/tracker/db/migrations"
 )
 
 func TestSQLite3_MigrationsSuccess(t *testing.T) {
@@ -25,3 +32,224 @@ func TestSQLite3_MigrationsSuccess(t *testing.T) {
 	_, err = db.NewSQLite3(ctx, dbPath)
 	require.NoError(t, err)
 }
+
+func TestMigrator_MigrateUp(t *testing.T) {
+	ctx := context.Background()
+	dbPath := filepath.Join(t.TempDir(), "state")
+
+	store, err := db.NewSQLite3(ctx, dbPath)
+	require.NoError(t, err)
+
+	state := []db.SyncStateEntry{{Path: "a.txt", Size: 1, LocalHash: "l", RemoteHash: "r"}}
+	require.NoError(t, store.ReplaceSyncState(ctx, "pair", state))
+	require.NoError(t, store.Close())
+
+	sqlDB, err := sql.Open("sqlite3", filepath.Join(dbPath, "tracker.db"))
+	require.NoError(t, err)
+	defer func() { _ = sqlDB.Close() }()
+
+	m := db.NewMigrator(sqlDB)
+
+	version, err := m.Version(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, len(migrations.SQLite3), version)
+
+	// the databases of the earlier releases recorded their version in "schema_migrations": they
+	// apply the migrations again, and keep their data.
+	_, err = sqlDB.ExecContext(ctx, `DELETE FROM "schema_version"`)
+	require.NoError(t, err)
+	_, err = sqlDB.ExecContext(ctx, `CREATE TABLE "schema_migrations" ( "version" INTEGER PRIMARY KEY, "status" VARCHAR )`)
+	require.NoError(t, err)
+	_, err = sqlDB.ExecContext(ctx, `INSERT INTO "schema_migrations" VALUES (1, 'applied')`)
+	require.NoError(t, err)
+
+	require.NoError(t, m.MigrateUp(ctx))
+
+	version, err = m.Version(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, len(migrations.SQLite3), version)
+
+	var n int
+	require.NoError(t, sqlDB.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE name = 'schema_migrations'`).Scan(&n))
+	assert.Zero(t, n)
+
+	store, err = db.NewSQLite3(ctx, dbPath)
+	require.NoError(t, err)
+	got, err := store.GetSyncState(ctx, "pair")
+	require.NoError(t, err)
+	assert.Equal(t, state, got)
+	require.NoError(t, store.Close())
+
+	// a database upgraded by a newer release is not downgraded.
+	_, err = sqlDB.ExecContext(ctx, `UPDATE "schema_version" SET version = ?`, len(migrations.SQLite3)+1)
+	require.NoError(t, err)
+
+	_, err = db.NewSQLite3(ctx, dbPath)
+	require.Error(t, err)
+}
+
+func TestSQLite3_UpdateSyncState(t *testing.T) {
+	ctx := context.Background()
+
+	store, err := db.NewSQLite3(ctx, t.TempDir())
+	require.NoError(t, err)
+	defer func() { _ = store.Close() }()
+
+	state := []db.SyncStateEntry{
+		{Path: "b.txt", Size: 2, LocalHash: "lb", RemoteHash: "rb"},
+		{Path: "a", IsFolder: true},
+		{Path: "a/c.txt", Size: 3, LocalHash: "lc", RemoteHash: "rc"},
+	}
+	require.NoError(t, store.ReplaceSyncState(ctx, "pair", state))
+	require.NoError(t, store.ReplaceSyncState(ctx, "other", state[:1]))
+
+	updated := []db.SyncStateEntry{
+		{Path: "a/c.txt", Size: 4, LocalHash: "lc2", RemoteHash: "rc2"},
+		{Path: "d.txt", Size: 5, LocalHash: "ld", RemoteHash: "rd"},
+	}
+	require.NoError(t, store.UpdateSyncState(ctx, "pair", updated, []string{"b.txt", "missing.txt"}))
+
+	// the state is streamed in the order of the paths.
+	var got []db.SyncStateEntry
+	err = store.EachSyncState(ctx, "pair", db.ByPath, func(e db.SyncStateEntry) error {
+		got = append(got, e)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []db.SyncStateEntry{state[1], updated[0], updated[1]}, got)
+
+	other, err := store.GetSyncState(ctx, "other")
+	require.NoError(t, err)
+	assert.Equal(t, state[:1], other)
+
+	// the stream stops at the first error.
+	errStop := errors.New("stop")
+	n := 0
+	err = store.EachSyncState(ctx, "pair", db.ByPath, func(db.SyncStateEntry) error {
+		n++
+		return errStop
+	})
+	require.ErrorIs(t, err, errStop)
+	assert.Equal(t, 1, n)
+
+	// the files of a sync are those of the state, without its folders.
+	started := time.Now().Truncate(time.Millisecond)
+	require.NoError(t, store.AddSyncRunFiles(ctx, "pair", started))
+
+	files, err := store.GetSyncRunFiles(ctx, "pair", started)
+	require.NoError(t, err)
+	assert.Equal(t, []db.SyncStateEntry{
+		{Path: "a/c.txt", Size: 4, RemoteHash: "rc2"},
+		{Path: "d.txt", Size: 5, RemoteHash: "rd"},
+	}, files)
+}
+
+func TestSQLite3_UpdateSyncRemote(t *testing.T) {
+	ctx := context.Background()
+
+	store, err := db.NewSQLite3(ctx, t.TempDir())
+	require.NoError(t, err)
+	defer func() { _ = store.Close() }()
+
+	remote, err := store.GetSyncRemote(ctx, "pair")
+	require.NoError(t, err)
+	assert.Nil(t, remote)
+
+	folder := func(id, parent uint64, name string) db.FSEntry {
+		return db.FSEntry{EntryID: id, IsFolder: true, ParentFolderID: parent, Name: name}
+	}
+	file := func(id, parent uint64, name string) db.FSEntry {
+		return db.FSEntry{EntryID: id, ParentFolderID: parent, Name: name, Hash: name}
+	}
+
+	// the tree is listed by batches, and only read once its diff is saved. The IDs of the files
+	// and of the folders are distinct.
+	require.NoError(t, store.DeleteSyncRemote(ctx, "pair"))
+	require.NoError(t, store.AddSyncRemoteEntries(ctx, "pair", []db.FSEntry{folder(1, 0, "root"), folder(2, 1, "b"), file(2, 2, "x.txt")}))
+	require.NoError(t, store.UpdateSyncRemote(ctx, "pair", db.SyncRemote{DiffID: 10, RootFolderID: 1}, []db.FSEntry{folder(3, 2, "sub"), file(3, 3, "y.txt"), file(4, 1, "B.txt"), file(5, 1, "a.txt")}, nil))
+
+	paths := func(order db.PathOrder) []string {
+		var got []string
+		err := store.EachSyncRemote(ctx, "pair", order, func(p string, e db.FSEntry) error {
+			assert.Equal(t, e.Name, path.Base(p))
+			got = append(got, p)
+			return nil
+		})
+		require.NoError(t, err)
+		return got
+	}
+
+	assert.Equal(t, []string{"B.txt", "a.txt", "b", "b/sub", "b/sub/y.txt", "b/x.txt"}, paths(db.ByPath))
+	assert.Equal(t, []string{"a.txt", "b", "B.txt", "b/sub", "b/sub/y.txt", "b/x.txt"}, paths(db.ByFoldedPath))
+
+	entry, err := store.GetSyncRemoteEntry(ctx, "pair", false, 2)
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+	assert.Equal(t, "x.txt", entry.Name)
+
+	// a removed folder is removed with its contents, but those moved out of it.
+	require.NoError(t, store.UpdateSyncRemote(ctx, "pair", db.SyncRemote{DiffID: 11, RootFolderID: 1}, []db.FSEntry{file(2, 1, "x.txt")}, []db.FSEntry{folder(2, 1, "b")}))
+	assert.Equal(t, []string{"B.txt", "a.txt", "x.txt"}, paths(db.ByPath))
+
+	entry, err = store.GetSyncRemoteEntry(ctx, "pair", false, 3)
+	require.NoError(t, err)
+	assert.Nil(t, entry)
+
+	remote, err = store.GetSyncRemote(ctx, "pair")
+	require.NoError(t, err)
+	assert.Equal(t, &db.SyncRemote{DiffID: 11, RootFolderID: 1}, remote)
+}
+
+func TestSQLite3_SyncLocal(t *testing.T) {
+	ctx := context.Background()
+
+	store, err := db.NewSQLite3(ctx, t.TempDir())
+	require.NoError(t, err)
+	defer func() { _ = store.Close() }()
+
+	entries := map[string]db.FSEntry{"a": {IsFolder: true}, "a/x.txt": {Size: 1}, "a0.txt": {Size: 2}, "ab": {IsFolder: true}}
+	require.NoError(t, store.AddSyncLocal(ctx, "pair", entries))
+
+	// the contents of a folder are deleted with it, but not the paths that start with its name.
+	require.NoError(t, store.DeleteSyncLocal(ctx, "pair", []string{"a"}))
+
+	var got []string
+	err = store.EachSyncLocal(ctx, "pair", db.ByPath, func(p string, e db.FSEntry) error {
+		got = append(got, p)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a0.txt", "ab"}, got)
+
+	entry, err := store.GetSyncLocalEntry(ctx, "pair", "a0.txt")
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+	assert.Equal(t, uint64(2), entry.Size)
+
+	require.NoError(t, store.DeleteSyncLocal(ctx, "pair", nil))
+	entry, err = store.GetSyncLocalEntry(ctx, "pair", "a0.txt")
+	require.NoError(t, err)
+	assert.Nil(t, entry)
+}
+
+func TestSQLite3_DeleteLocalHashes(t *testing.T) {
+	ctx := context.Background()
+
+	store, err := db.NewSQLite3(ctx, t.TempDir())
+	require.NoError(t, err)
+	defer func() { _ = store.Close() }()
+
+	modified := time.Unix(0, 1)
+	require.NoError(t, store.AddLocalHashes(ctx, "pair", []db.LocalHash{{Path: "a.txt", Modified: modified, Hash: "a"}, {Path: "b.txt", Modified: modified, Hash: "b"}}))
+
+	// the hash replaced since it was read is kept.
+	require.NoError(t, store.DeleteLocalHashes(ctx, "pair", []db.LocalHash{{Path: "a.txt", Hash: "a"}, {Path: "b.txt", Hash: "old"}}))
+
+	h, err := store.GetLocalHash(ctx, "pair", "a.txt")
+	require.NoError(t, err)
+	assert.Nil(t, h)
+
+	h, err = store.GetLocalHash(ctx, "pair", "b.txt")
+	require.NoError(t, err)
+	assert.Equal(t, &db.LocalHash{Path: "b.txt", Modified: modified, Hash: "b"}, h)
+}
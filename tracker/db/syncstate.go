@@ -0,0 +1,845 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// SyncStateEntry is the state of a file or folder of a sync pair, as of the last sync: the
+// two-way sync compares both sides against it to tell which side changed.
+type SyncStateEntry struct {
+	// Path is the slash-separated path of the entry, relative to the roots of the pair.
+	Path     string
+	IsFolder bool
+	Size     uint64
+	// LocalHash and RemoteHash are the hashes of the contents of the file on either side: they
+	// are not comparable with each other since the local and pCloud hashes differ.
+	LocalHash  string
+	RemoteHash string
+}
+
+// GetSyncState returns the state of the entries of the sync pair pairName, as of its last sync,
+// sorted by path. It is empty when the pair has never been synced.
+func (s *SQLite3) GetSyncState(ctx context.Context, pairName PairName) ([]SyncStateEntry, error) {
+	entries := []SyncStateEntry{}
+
+	err := s.EachSyncState(ctx, pairName, ByPath, func(e SyncStateEntry) error {
+		entries = append(entries, e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// EachSyncState calls fn with the state of each entry of the sync pair pairName, as of its last
+// sync, in the order of their paths, as it reads them, so that the state is compared with both
+// sides without being held in memory. It stops at the first error of fn, which it returns.
+func (s *SQLite3) EachSyncState(ctx context.Context, pairName PairName, order PathOrder, fn func(e SyncStateEntry) error) error {
+	// nolint: rowserrcheck
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT path, is_folder, size, local_hash, remote_hash
+		 FROM "sync_state"
+		 WHERE pair_name = :pair_name
+		 ORDER BY `+orderBy("path", order),
+		sql.Named("pair_name", pairName),
+	)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		entry := SyncStateEntry{}
+		err = rows.Scan(
+			&entry.Path,
+			&entry.IsFolder,
+			&entry.Size,
+			&entry.LocalHash,
+			&entry.RemoteHash,
+		)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		err = fn(entry)
+		if err != nil {
+			return err
+		}
+	}
+
+	return errors.WithStack(rows.Err())
+}
+
+// ReplaceSyncState replaces the state of the entries of the sync pair pairName with entries.
+func (s *SQLite3) ReplaceSyncState(ctx context.Context, pairName PairName, entries []SyncStateEntry) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`DELETE FROM "sync_state" WHERE pair_name = ?`,
+		pairName,
+	)
+	if err != nil {
+		return doRollback(tx, err)
+	}
+
+	for _, entry := range entries {
+		_, err = tx.ExecContext(
+			ctx,
+			`INSERT INTO "sync_state"
+			(pair_name, path, is_folder, size, local_hash, remote_hash)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			pairName,
+			entry.Path,
+			entry.IsFolder,
+			entry.Size,
+			entry.LocalHash,
+			entry.RemoteHash,
+		)
+		if err != nil {
+			return doRollback(tx, errors.WithMessagef(err, "path: %s", entry.Path))
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return doRollback(tx, err)
+	}
+
+	return nil
+}
+
+// UpdateSyncState updates the state of the sync pair pairName with the state of the paths that a
+// sync changed: entries replace the state of their paths, and the state of the paths of removed
+// is deleted. The state of the other paths is left as it is.
+func (s *SQLite3) UpdateSyncState(ctx context.Context, pairName PairName, entries []SyncStateEntry, removed []string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, p := range removed {
+		_, err = tx.ExecContext(
+			ctx,
+			`DELETE FROM "sync_state" WHERE pair_name = ? AND path = ?`,
+			pairName,
+			p,
+		)
+		if err != nil {
+			return doRollback(tx, errors.WithMessagef(err, "path: %s", p))
+		}
+	}
+
+	for _, entry := range entries {
+		_, err = tx.ExecContext(
+			ctx,
+			`INSERT OR REPLACE INTO "sync_state"
+			(pair_name, path, is_folder, size, local_hash, remote_hash)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			pairName,
+			entry.Path,
+			entry.IsFolder,
+			entry.Size,
+			entry.LocalHash,
+			entry.RemoteHash,
+		)
+		if err != nil {
+			return doRollback(tx, errors.WithMessagef(err, "path: %s", entry.Path))
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return doRollback(tx, err)
+	}
+
+	return nil
+}
+
+// SyncConflict is the decision taken on a file that changed on both sides of a sync pair.
+type SyncConflict struct {
+	Path     string
+	Detected time.Time
+	// Policy is the conflict policy of the sync, and Resolution the one it resolved to, such as
+	// "prefer-local" for the "keep-newest" policy when the local file is the most recent one.
+	Policy     string
+	Resolution string
+	// CopyPath is the path of the copy of the local file, for the "keep-both" resolution.
+	CopyPath string
+}
+
+// AddSyncConflict records the decision taken on a conflict of the sync pair pairName.
+func (s *SQLite3) AddSyncConflict(ctx context.Context, pairName PairName, conflict SyncConflict) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		`INSERT INTO "sync_conflicts"
+		(pair_name, path, detected, policy, resolution, copy_path)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		pairName,
+		conflict.Path,
+		conflict.Detected,
+		conflict.Policy,
+		conflict.Resolution,
+		conflict.CopyPath,
+	)
+
+	return errors.WithStack(err)
+}
+
+// GetSyncConflicts returns the decisions taken on the conflicts of the sync pair pairName, the
+// oldest first.
+func (s *SQLite3) GetSyncConflicts(ctx context.Context, pairName PairName) ([]SyncConflict, error) {
+	// nolint: rowserrcheck
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT path, detected, policy, resolution, copy_path
+		 FROM "sync_conflicts"
+		 WHERE pair_name = :pair_name
+		 ORDER BY detected, rowid`,
+		sql.Named("pair_name", pairName),
+	)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	conflicts := []SyncConflict{}
+
+	for rows.Next() {
+		conflict := SyncConflict{}
+		err = rows.Scan(
+			&conflict.Path,
+			&conflict.Detected,
+			&conflict.Policy,
+			&conflict.Resolution,
+			&conflict.CopyPath,
+		)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		conflicts = append(conflicts, conflict)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return conflicts, nil
+}
+
+// LocalHash is the hash of a local file of a sync pair, as of its size and modification time.
+type LocalHash struct {
+	// Path is the slash-separated path of the file, relative to the local root of the pair.
+	Path     string
+	Size     int64
+	Modified time.Time
+	Hash     string
+}
+
+// GetLocalHashes returns the hashes of the local files of the sync pair pairName.
+func (s *SQLite3) GetLocalHashes(ctx context.Context, pairName PairName) ([]LocalHash, error) {
+	hashes := []LocalHash{}
+
+	err := s.EachLocalHash(ctx, pairName, ByPath, func(h LocalHash) error {
+		hashes = append(hashes, h)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return hashes, nil
+}
+
+// GetLocalHash returns the hash of the local file p of the sync pair pairName. It returns nil
+// when there is none.
+func (s *SQLite3) GetLocalHash(ctx context.Context, pairName PairName, p string) (*LocalHash, error) {
+	h := &LocalHash{Path: p}
+	var modified int64
+
+	err := s.db.QueryRowContext(
+		ctx,
+		`SELECT size, modified, hash
+		 FROM "local_hashes"
+		 WHERE pair_name = :pair_name AND path = :path`,
+		sql.Named("pair_name", pairName),
+		sql.Named("path", p),
+	).Scan(&h.Size, &modified, &h.Hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	h.Modified = time.Unix(0, modified)
+
+	return h, nil
+}
+
+// EachLocalHash calls fn with the hash of each local file of the sync pair pairName, in the order
+// of the paths, as it reads them. It stops at the first error of fn, which it returns.
+func (s *SQLite3) EachLocalHash(ctx context.Context, pairName PairName, order PathOrder, fn func(h LocalHash) error) error {
+	// nolint: rowserrcheck
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT path, size, modified, hash
+		 FROM "local_hashes"
+		 WHERE pair_name = :pair_name
+		 ORDER BY `+orderBy("path", order),
+		sql.Named("pair_name", pairName),
+	)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		h := LocalHash{}
+		var modified int64
+		err = rows.Scan(
+			&h.Path,
+			&h.Size,
+			&modified,
+			&h.Hash,
+		)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		h.Modified = time.Unix(0, modified)
+
+		err = fn(h)
+		if err != nil {
+			return err
+		}
+	}
+
+	return errors.WithStack(rows.Err())
+}
+
+// AddLocalHashes records the hashes of local files of the sync pair pairName, which replace
+// those of the same paths.
+func (s *SQLite3) AddLocalHashes(ctx context.Context, pairName PairName, hashes []LocalHash) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	err = insertLocalHashes(ctx, tx, pairName, hashes)
+	if err != nil {
+		return doRollback(tx, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return doRollback(tx, err)
+	}
+
+	return nil
+}
+
+// ReplaceLocalHashes replaces the hashes of the local files of the sync pair pairName with
+// hashes.
+func (s *SQLite3) ReplaceLocalHashes(ctx context.Context, pairName PairName, hashes []LocalHash) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`DELETE FROM "local_hashes" WHERE pair_name = ?`,
+		pairName,
+	)
+	if err != nil {
+		return doRollback(tx, err)
+	}
+
+	err = insertLocalHashes(ctx, tx, pairName, hashes)
+	if err != nil {
+		return doRollback(tx, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return doRollback(tx, err)
+	}
+
+	return nil
+}
+
+// DeleteLocalHashes deletes the hashes of local files of the sync pair pairName, unless they were
+// replaced since they were read: a hash is only deleted when it is still that of its path.
+func (s *SQLite3) DeleteLocalHashes(ctx context.Context, pairName PairName, hashes []LocalHash) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, h := range hashes {
+		_, err = tx.ExecContext(
+			ctx,
+			`DELETE FROM "local_hashes" WHERE pair_name = ? AND path = ? AND hash = ?`,
+			pairName,
+			h.Path,
+			h.Hash,
+		)
+		if err != nil {
+			return doRollback(tx, errors.WithMessagef(err, "path: %s", h.Path))
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return doRollback(tx, err)
+	}
+
+	return nil
+}
+
+func insertLocalHashes(ctx context.Context, tx *sql.Tx, pairName PairName, hashes []LocalHash) error {
+	for _, h := range hashes {
+		_, err := tx.ExecContext(
+			ctx,
+			`INSERT OR REPLACE INTO "local_hashes"
+			(pair_name, path, size, modified, hash)
+			VALUES (?, ?, ?, ?, ?)`,
+			pairName,
+			h.Path,
+			h.Size,
+			h.Modified.UnixNano(),
+			h.Hash,
+		)
+		if err != nil {
+			return errors.WithMessagef(err, "path: %s", h.Path)
+		}
+	}
+
+	return nil
+}
+
+// GetSyncSelection returns the slash-separated paths of the folders of the sync pair pairName
+// that are synced, relative to its roots. It is empty when the whole pair is synced.
+func (s *SQLite3) GetSyncSelection(ctx context.Context, pairName PairName) ([]string, error) {
+	// nolint: rowserrcheck
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT path
+		 FROM "sync_selection"
+		 WHERE pair_name = :pair_name
+		 ORDER BY path`,
+		sql.Named("pair_name", pairName),
+	)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	paths := []string{}
+
+	for rows.Next() {
+		var p string
+		err = rows.Scan(&p)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		paths = append(paths, p)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return paths, nil
+}
+
+// ReplaceSyncSelection replaces the folders of the sync pair pairName that are synced with paths:
+// the whole pair is synced when it is empty. The tree of the pCloud folder of the pair is dropped
+// when the selection changes: the folders that are added to it are unknown to the tree.
+func (s *SQLite3) ReplaceSyncSelection(ctx context.Context, pairName PairName, paths []string) error {
+	current, err := s.GetSyncSelection(ctx, pairName)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`DELETE FROM "sync_selection" WHERE pair_name = ?`,
+		pairName,
+	)
+	if err != nil {
+		return doRollback(tx, err)
+	}
+
+	changed := len(current) != len(paths)
+	selected := map[string]bool{}
+	for _, p := range current {
+		selected[p] = true
+	}
+
+	for _, p := range paths {
+		changed = changed || !selected[p]
+
+		_, err = tx.ExecContext(
+			ctx,
+			`INSERT OR REPLACE INTO "sync_selection"
+			(pair_name, path)
+			VALUES (?, ?)`,
+			pairName,
+			p,
+		)
+		if err != nil {
+			return doRollback(tx, errors.WithMessagef(err, "path: %s", p))
+		}
+	}
+
+	if changed {
+		for _, table := range []string{"sync_remote", "sync_remote_entries"} {
+			_, err = tx.ExecContext(ctx, `DELETE FROM "`+table+`" WHERE pair_name = ?`, pairName) // nolint: gosec
+			if err != nil {
+				return doRollback(tx, err)
+			}
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return doRollback(tx, err)
+	}
+
+	return nil
+}
+
+// SyncTransfer is a transfer of a file of a sync pair in progress. The contents transferred so far
+// are those of the partial file of the destination, which the transfer of an interrupted sync
+// resumes at its end.
+type SyncTransfer struct {
+	// Path is the slash-separated path of the file, relative to the roots of the pair.
+	Path string
+	// Upload is whether the file is uploaded, rather than downloaded.
+	Upload bool
+	// Size and Modified are those of the local file of an upload: it is uploaded from the start
+	// again when they changed.
+	Size     int64
+	Modified time.Time
+	// FileID is the ID of the partial pCloud file of an upload.
+	FileID uint64
+	// RemoteHash is the pCloud hash of the file of a download: it is downloaded from the start
+	// again when it changed.
+	RemoteHash string
+}
+
+// GetSyncTransfer returns the transfer in progress of the file path of the sync pair pairName, in
+// the direction of upload. It returns nil when there is none.
+func (s *SQLite3) GetSyncTransfer(ctx context.Context, pairName PairName, path string, upload bool) (*SyncTransfer, error) {
+	t := &SyncTransfer{Path: path, Upload: upload}
+
+	var (
+		size, modified sql.NullInt64
+		fileID         sql.NullInt64
+		remoteHash     sql.NullString
+	)
+
+	err := s.db.QueryRowContext(
+		ctx,
+		`SELECT size, modified, file_id, remote_hash
+		 FROM "sync_transfers"
+		 WHERE pair_name = :pair_name AND path = :path AND upload = :upload`,
+		sql.Named("pair_name", pairName),
+		sql.Named("path", path),
+		sql.Named("upload", upload),
+	).Scan(&size, &modified, &fileID, &remoteHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	t.Size = size.Int64
+	if modified.Valid {
+		t.Modified = time.Unix(0, modified.Int64)
+	}
+	t.FileID = uint64(fileID.Int64)
+	t.RemoteHash = remoteHash.String
+
+	return t, nil
+}
+
+// ReplaceSyncTransfer records the transfer in progress t of the sync pair pairName, which replaces
+// the one of the same path and direction.
+func (s *SQLite3) ReplaceSyncTransfer(ctx context.Context, pairName PairName, t SyncTransfer) error {
+	var modified sql.NullInt64
+	if !t.Modified.IsZero() {
+		modified = sql.NullInt64{Int64: t.Modified.UnixNano(), Valid: true}
+	}
+
+	_, err := s.db.ExecContext(
+		ctx,
+		`INSERT OR REPLACE INTO "sync_transfers"
+		(pair_name, path, upload, size, modified, file_id, remote_hash)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		pairName,
+		t.Path,
+		t.Upload,
+		t.Size,
+		modified,
+		t.FileID,
+		t.RemoteHash,
+	)
+
+	return errors.WithStack(err)
+}
+
+// DeleteSyncTransfer deletes the transfer in progress of the file path of the sync pair pairName,
+// in the direction of upload, once it completed.
+func (s *SQLite3) DeleteSyncTransfer(ctx context.Context, pairName PairName, path string, upload bool) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		`DELETE FROM "sync_transfers" WHERE pair_name = ? AND path = ? AND upload = ?`,
+		pairName,
+		path,
+		upload,
+	)
+
+	return errors.WithStack(err)
+}
+
+// MaxSyncRuns is the number of syncs of each sync pair kept in its history (see AddSyncRun).
+const MaxSyncRuns = 1000
+
+// SyncRun is the report of a sync of a sync pair, as recorded in its history.
+type SyncRun struct {
+	Started  time.Time     `json:"started"`
+	Duration time.Duration `json:"duration"`
+	// Scanned is the number of files and folders of both sides that the sync compared.
+	Scanned           int   `json:"scanned"`
+	Uploaded          int   `json:"uploaded"`
+	UploadedBytes     int64 `json:"uploaded_bytes"`
+	Downloaded        int   `json:"downloaded"`
+	DownloadedBytes   int64 `json:"downloaded_bytes"`
+	DeletedLocal      int   `json:"deleted_local"`
+	DeletedRemote     int   `json:"deleted_remote"`
+	MovedLocal        int   `json:"moved_local"`
+	MovedRemote       int   `json:"moved_remote"`
+	Conflicts         int   `json:"conflicts"`
+	ResolvedConflicts int   `json:"resolved_conflicts"`
+	Errors            int   `json:"errors"`
+	// Error is the error of the sync, which is empty when it succeeded.
+	Error string `json:"error,omitempty"`
+}
+
+// AddSyncRun records the report of a sync of the sync pair pairName in its history, which keeps
+// the MaxSyncRuns most recent ones.
+func (s *SQLite3) AddSyncRun(ctx context.Context, pairName PairName, run SyncRun) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO "sync_runs"
+		(pair_name, started, duration, scanned, uploaded, uploaded_bytes, downloaded, downloaded_bytes,
+		 deleted_local, deleted_remote, moved_local, moved_remote, conflicts, resolved_conflicts, errors, error)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		pairName,
+		run.Started,
+		run.Duration,
+		run.Scanned,
+		run.Uploaded,
+		run.UploadedBytes,
+		run.Downloaded,
+		run.DownloadedBytes,
+		run.DeletedLocal,
+		run.DeletedRemote,
+		run.MovedLocal,
+		run.MovedRemote,
+		run.Conflicts,
+		run.ResolvedConflicts,
+		run.Errors,
+		run.Error,
+	)
+	if err != nil {
+		return doRollback(tx, err)
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`DELETE FROM "sync_runs"
+		 WHERE pair_name = ? AND rowid NOT IN (
+			SELECT rowid FROM "sync_runs" WHERE pair_name = ? ORDER BY started DESC, rowid DESC LIMIT ?
+		 )`,
+		pairName,
+		pairName,
+		MaxSyncRuns,
+	)
+	if err != nil {
+		return doRollback(tx, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return doRollback(tx, err)
+	}
+
+	return nil
+}
+
+// GetSyncRuns returns the limit most recent syncs of the history of the sync pair pairName, the
+// most recent first.
+func (s *SQLite3) GetSyncRuns(ctx context.Context, pairName PairName, limit int) ([]SyncRun, error) {
+	// nolint: rowserrcheck
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT started, duration, scanned, uploaded, uploaded_bytes, downloaded, downloaded_bytes,
+		        deleted_local, deleted_remote, moved_local, moved_remote, conflicts, resolved_conflicts, errors, error
+		 FROM "sync_runs"
+		 WHERE pair_name = :pair_name
+		 ORDER BY started DESC, rowid DESC
+		 LIMIT :limit`,
+		sql.Named("pair_name", pairName),
+		sql.Named("limit", limit),
+	)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	runs := []SyncRun{}
+
+	for rows.Next() {
+		run := SyncRun{}
+		var runErr sql.NullString
+		err = rows.Scan(
+			&run.Started,
+			&run.Duration,
+			&run.Scanned,
+			&run.Uploaded,
+			&run.UploadedBytes,
+			&run.Downloaded,
+			&run.DownloadedBytes,
+			&run.DeletedLocal,
+			&run.DeletedRemote,
+			&run.MovedLocal,
+			&run.MovedRemote,
+			&run.Conflicts,
+			&run.ResolvedConflicts,
+			&run.Errors,
+			&runErr,
+		)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		run.Error = runErr.String
+		runs = append(runs, run)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return runs, nil
+}
+
+// MaxSyncRunTrees is the number of syncs of each sync pair whose files are kept (see
+// AddSyncRunFiles).
+const MaxSyncRunTrees = 10
+
+// AddSyncRunFiles records the files of the sync pair pairName as of the end of its sync that
+// started at started: the files of its state then, which are copied by the store rather than read.
+// The files of the MaxSyncRunTrees most recent syncs are kept.
+func (s *SQLite3) AddSyncRunFiles(ctx context.Context, pairName PairName, started time.Time) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT OR REPLACE INTO "sync_run_files" (pair_name, started, path, size, remote_hash)
+		 SELECT pair_name, ?, path, size, remote_hash
+		 FROM "sync_state"
+		 WHERE pair_name = ? AND NOT is_folder`,
+		started,
+		pairName,
+	)
+	if err != nil {
+		return doRollback(tx, err)
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`DELETE FROM "sync_run_files"
+		 WHERE pair_name = ? AND started NOT IN (
+			SELECT DISTINCT started FROM "sync_run_files" WHERE pair_name = ? ORDER BY started DESC LIMIT ?
+		 )`,
+		pairName,
+		pairName,
+		MaxSyncRunTrees,
+	)
+	if err != nil {
+		return doRollback(tx, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return doRollback(tx, err)
+	}
+
+	return nil
+}
+
+// GetSyncRunFiles returns the files of the sync pair pairName as of the end of its sync that
+// started at started, sorted by path. It is empty when they are not kept.
+func (s *SQLite3) GetSyncRunFiles(ctx context.Context, pairName PairName, started time.Time) ([]SyncStateEntry, error) {
+	// nolint: rowserrcheck
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT path, size, remote_hash
+		 FROM "sync_run_files"
+		 WHERE pair_name = :pair_name AND started = :started
+		 ORDER BY path`,
+		sql.Named("pair_name", pairName),
+		sql.Named("started", started),
+	)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	entries := []SyncStateEntry{}
+
+	for rows.Next() {
+		entry := SyncStateEntry{}
+		err = rows.Scan(&entry.Path, &entry.Size, &entry.RemoteHash)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		entries = append(entries, entry)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,455 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"path"
+
+	"github.com/pkg/errors"
+)
+
+// SyncRemote is the tree of the pCloud folder of a sync pair, as of a diff of the pCloud account:
+// the two-way sync updates it with the events that followed, rather than listing the folder. Its
+// files and folders are held by the store, by their IDs and those of their parent folders.
+type SyncRemote struct {
+	DiffID       uint64
+	RootFolderID uint64
+}
+
+// GetSyncRemote returns the tree of the pCloud folder of the sync pair pairName, as saved by its
+// last sync. It returns nil when none was saved.
+func (s *SQLite3) GetSyncRemote(ctx context.Context, pairName PairName) (*SyncRemote, error) {
+	remote := &SyncRemote{}
+
+	err := s.db.QueryRowContext(
+		ctx,
+		`SELECT diff_id, root_folder_id
+		 FROM "sync_remote"
+		 WHERE pair_name = :pair_name`,
+		sql.Named("pair_name", pairName),
+	).Scan(&remote.DiffID, &remote.RootFolderID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return remote, nil
+}
+
+// GetSyncRemoteEntry returns the file or folder id of the tree of the pCloud folder of the sync
+// pair pairName, without its path. It returns nil when the tree does not hold it.
+func (s *SQLite3) GetSyncRemoteEntry(ctx context.Context, pairName PairName, isFolder bool, id uint64) (*FSEntry, error) {
+	entry := &FSEntry{EntryID: id, IsFolder: isFolder}
+
+	err := s.db.QueryRowContext(
+		ctx,
+		`SELECT parent_folder_id, name, created, modified, size, hash
+		 FROM "sync_remote_entries"
+		 WHERE pair_name = :pair_name AND is_folder = :is_folder AND entry_id = :entry_id`,
+		sql.Named("pair_name", pairName),
+		sql.Named("is_folder", isFolder),
+		sql.Named("entry_id", id),
+	).Scan(
+		&entry.ParentFolderID,
+		&entry.Name,
+		&entry.Created,
+		&entry.Modified,
+		&entry.Size,
+		&entry.Hash,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return entry, nil
+}
+
+// EachSyncRemote calls fn with each file and folder of the tree of the pCloud folder of the sync
+// pair pairName, but its root, with its slash-separated path relative to the root, in the order of
+// the paths, as it reads them. The entries do not have their paths. The files and folders whose
+// parent folders are not in the tree are left out. It stops at the first error of fn, which it
+// returns.
+func (s *SQLite3) EachSyncRemote(ctx context.Context, pairName PairName, order PathOrder, fn func(p string, e FSEntry) error) error {
+	remote, err := s.GetSyncRemote(ctx, pairName)
+	if err != nil || remote == nil {
+		return err
+	}
+
+	// the paths are those of the folders reached from the root: the root is not reached again
+	// from its contents.
+	// nolint: rowserrcheck
+	rows, err := s.db.QueryContext(
+		ctx,
+		`WITH RECURSIVE "tree" (entry_id, is_folder, parent_folder_id, name, created, modified, size, hash, path) AS (
+			SELECT entry_id, is_folder, parent_folder_id, name, created, modified, size, hash, name
+			FROM "sync_remote_entries"
+			WHERE pair_name = :pair_name AND parent_folder_id = :root_folder_id
+			  AND NOT (is_folder AND entry_id = :root_folder_id)
+			UNION ALL
+			SELECT e.entry_id, e.is_folder, e.parent_folder_id, e.name, e.created, e.modified, e.size, e.hash, t.path || '/' || e.name
+			FROM "sync_remote_entries" e
+			JOIN "tree" t ON t.is_folder AND e.parent_folder_id = t.entry_id
+			WHERE e.pair_name = :pair_name
+			  AND NOT (e.is_folder AND e.entry_id = :root_folder_id)
+		 )
+		 SELECT path, entry_id, is_folder, parent_folder_id, name, created, modified, size, hash
+		 FROM "tree"
+		 ORDER BY `+orderBy("path", order),
+		sql.Named("pair_name", pairName),
+		sql.Named("root_folder_id", remote.RootFolderID),
+	)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var p string
+		entry := FSEntry{}
+		err = rows.Scan(
+			&p,
+			&entry.EntryID,
+			&entry.IsFolder,
+			&entry.ParentFolderID,
+			&entry.Name,
+			&entry.Created,
+			&entry.Modified,
+			&entry.Size,
+			&entry.Hash,
+		)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		err = fn(p, entry)
+		if err != nil {
+			return err
+		}
+	}
+
+	return errors.WithStack(rows.Err())
+}
+
+// UpdateSyncRemote updates the tree of the pCloud folder of the sync pair pairName with the
+// changes of a diff, which bring it up to date with remote: entries replace the files and folders
+// of the same IDs, and the files and folders of removed are deleted, with the contents of the
+// folders.
+func (s *SQLite3) UpdateSyncRemote(ctx context.Context, pairName PairName, remote SyncRemote, entries []FSEntry, removed []FSEntry) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	err = replaceSyncRemoteInfo(ctx, tx, pairName, remote)
+	if err != nil {
+		return doRollback(tx, err)
+	}
+
+	for _, entry := range entries {
+		err = insertSyncRemoteEntry(ctx, tx, pairName, entry)
+		if err != nil {
+			return doRollback(tx, err)
+		}
+	}
+
+	for _, entry := range removed {
+		if entry.IsFolder {
+			_, err = tx.ExecContext(
+				ctx,
+				`WITH RECURSIVE "contents" (entry_id, is_folder) AS (
+					SELECT entry_id, is_folder
+					FROM "sync_remote_entries"
+					WHERE pair_name = :pair_name AND parent_folder_id = :entry_id
+					UNION
+					SELECT e.entry_id, e.is_folder
+					FROM "sync_remote_entries" e
+					JOIN "contents" c ON c.is_folder AND e.parent_folder_id = c.entry_id
+					WHERE e.pair_name = :pair_name
+				 )
+				 DELETE FROM "sync_remote_entries"
+				 WHERE pair_name = :pair_name AND (is_folder, entry_id) IN (SELECT is_folder, entry_id FROM "contents")`,
+				sql.Named("pair_name", pairName),
+				sql.Named("entry_id", entry.EntryID),
+			)
+			if err != nil {
+				return doRollback(tx, errors.WithMessagef(err, "contents of folder: %d", entry.EntryID))
+			}
+		}
+
+		_, err = tx.ExecContext(
+			ctx,
+			`DELETE FROM "sync_remote_entries" WHERE pair_name = ? AND is_folder = ? AND entry_id = ?`,
+			pairName,
+			entry.IsFolder,
+			entry.EntryID,
+		)
+		if err != nil {
+			return doRollback(tx, errors.WithMessagef(err, "entry: %d", entry.EntryID))
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return doRollback(tx, err)
+	}
+
+	return nil
+}
+
+// DeleteSyncRemote deletes the tree of the pCloud folder of the sync pair pairName, such as before
+// the folder is listed again (see AddSyncRemoteEntries).
+func (s *SQLite3) DeleteSyncRemote(ctx context.Context, pairName PairName) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`DELETE FROM "sync_remote" WHERE pair_name = ?`,
+		pairName,
+	)
+	if err != nil {
+		return doRollback(tx, err)
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`DELETE FROM "sync_remote_entries" WHERE pair_name = ?`,
+		pairName,
+	)
+	if err != nil {
+		return doRollback(tx, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return doRollback(tx, err)
+	}
+
+	return nil
+}
+
+// AddSyncRemoteEntries adds files and folders to the tree of the pCloud folder of the sync pair
+// pairName, which replace those of the same IDs, such as by batches as the folder is listed. The
+// tree is only read once it is complete: when UpdateSyncRemote saves the diff it is as of.
+func (s *SQLite3) AddSyncRemoteEntries(ctx context.Context, pairName PairName, entries []FSEntry) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, entry := range entries {
+		err = insertSyncRemoteEntry(ctx, tx, pairName, entry)
+		if err != nil {
+			return doRollback(tx, err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return doRollback(tx, err)
+	}
+
+	return nil
+}
+
+func replaceSyncRemoteInfo(ctx context.Context, tx *sql.Tx, pairName PairName, remote SyncRemote) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT OR REPLACE INTO "sync_remote"
+		(pair_name, diff_id, root_folder_id)
+		VALUES (?, ?, ?)`,
+		pairName,
+		remote.DiffID,
+		remote.RootFolderID,
+	)
+
+	return errors.WithStack(err)
+}
+
+func insertSyncRemoteEntry(ctx context.Context, tx *sql.Tx, pairName PairName, entry FSEntry) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT OR REPLACE INTO "sync_remote_entries"
+		(pair_name, entry_id, is_folder, parent_folder_id, name, created, modified, size, hash)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		pairName,
+		entry.EntryID,
+		entry.IsFolder,
+		entry.ParentFolderID,
+		entry.Name,
+		entry.Created,
+		entry.Modified,
+		entry.Size,
+		entry.Hash,
+	)
+
+	return errors.WithMessagef(err, "entry: %d", entry.EntryID)
+}
+
+// GetSyncLocalEntry returns the file or folder p of the local folder of the sync pair pairName, as
+// of its last sync, without its path. It returns nil when there is none.
+func (s *SQLite3) GetSyncLocalEntry(ctx context.Context, pairName PairName, p string) (*FSEntry, error) {
+	entry := &FSEntry{Name: path.Base(p)}
+
+	err := s.db.QueryRowContext(
+		ctx,
+		`SELECT device_id, entry_id, is_folder, parent_folder_id, created, modified, size, hash
+		 FROM "sync_local_entries"
+		 WHERE pair_name = :pair_name AND path = :path`,
+		sql.Named("pair_name", pairName),
+		sql.Named("path", p),
+	).Scan(
+		&entry.DeviceID,
+		&entry.EntryID,
+		&entry.IsFolder,
+		&entry.ParentFolderID,
+		&entry.Created,
+		&entry.Modified,
+		&entry.Size,
+		&entry.Hash,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return entry, nil
+}
+
+// EachSyncLocal calls fn with each file and folder of the local folder of the sync pair pairName,
+// as of its last sync, with its slash-separated path relative to the local root, in the order of
+// the paths, as it reads them. The entries do not have their paths. It stops at the first error
+// of fn, which it returns.
+func (s *SQLite3) EachSyncLocal(ctx context.Context, pairName PairName, order PathOrder, fn func(p string, e FSEntry) error) error {
+	// nolint: rowserrcheck
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT path, device_id, entry_id, is_folder, parent_folder_id, created, modified, size, hash
+		 FROM "sync_local_entries"
+		 WHERE pair_name = :pair_name
+		 ORDER BY `+orderBy("path", order),
+		sql.Named("pair_name", pairName),
+	)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var p string
+		entry := FSEntry{}
+		err = rows.Scan(
+			&p,
+			&entry.DeviceID,
+			&entry.EntryID,
+			&entry.IsFolder,
+			&entry.ParentFolderID,
+			&entry.Created,
+			&entry.Modified,
+			&entry.Size,
+			&entry.Hash,
+		)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		entry.Name = path.Base(p)
+
+		err = fn(p, entry)
+		if err != nil {
+			return err
+		}
+	}
+
+	return errors.WithStack(rows.Err())
+}
+
+// DeleteSyncLocal deletes the files and folders of paths of the local folder of the sync pair
+// pairName, with their contents, such as before they are scanned again (see AddSyncLocal). All of
+// them are deleted when paths is nil.
+func (s *SQLite3) DeleteSyncLocal(ctx context.Context, pairName PairName, paths []string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if paths == nil {
+		_, err = tx.ExecContext(
+			ctx,
+			`DELETE FROM "sync_local_entries" WHERE pair_name = ?`,
+			pairName,
+		)
+		if err != nil {
+			return doRollback(tx, err)
+		}
+	}
+
+	for _, p := range paths {
+		// the contents of p are the paths that start with "p/", which sort before "p0".
+		_, err = tx.ExecContext(
+			ctx,
+			`DELETE FROM "sync_local_entries"
+			 WHERE pair_name = ? AND (path = ? OR path > ? AND path < ?)`,
+			pairName,
+			p,
+			p+"/",
+			p+"0",
+		)
+		if err != nil {
+			return doRollback(tx, errors.WithMessagef(err, "path: %s", p))
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return doRollback(tx, err)
+	}
+
+	return nil
+}
+
+// AddSyncLocal records the files and folders of the local folder of the sync pair pairName, by
+// their slash-separated paths relative to the local root, which replace those of the same paths.
+func (s *SQLite3) AddSyncLocal(ctx context.Context, pairName PairName, entries map[string]FSEntry) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for p, entry := range entries {
+		_, err = tx.ExecContext(
+			ctx,
+			`INSERT OR REPLACE INTO "sync_local_entries"
+			(pair_name, path, device_id, entry_id, is_folder, parent_folder_id, created, modified, size, hash)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			pairName,
+			p,
+			entry.DeviceID,
+			entry.EntryID,
+			entry.IsFolder,
+			entry.ParentFolderID,
+			entry.Created,
+			entry.Modified,
+			entry.Size,
+			entry.Hash,
+		)
+		if err != nil {
+			return doRollback(tx, errors.WithMessagef(err, "path: %s", p))
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return doRollback(tx, err)
+	}
+
+	return nil
+}
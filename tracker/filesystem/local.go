@@ -10,6 +10,11 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -18,17 +23,185 @@ import (
 )
 
 // Local is a file system abstraction for a local file system.
-type Local struct{}
+type Local struct {
+	skip         SkipFunc
+	hashWorkers  int
+	hashes       HashCache
+	symlinks     SymlinkPolicy
+	specialFiles SpecialFilePolicy
+	sortKey      SortKeyFunc
+}
+
+// SymlinkPolicy is how Walk and Stat handle the symbolic links.
+type SymlinkPolicy string
+
+const (
+	// SymlinkSkip leaves the symbolic links out. It is the default.
+	SymlinkSkip SymlinkPolicy = "skip"
+	// SymlinkFollow replaces the symbolic links with their targets: the contents of the file, or
+	// of the folder. The broken links are left out, as are the folders that were already walked,
+	// such as a link to a parent folder.
+	SymlinkFollow SymlinkPolicy = "follow"
+	// SymlinkPlaceholder replaces the symbolic links with placeholder files, whose contents are
+	// their targets.
+	SymlinkPlaceholder SymlinkPolicy = "placeholder"
+)
+
+// ParseSymlinkPolicy parses the name of a symbolic link policy, such as "follow".
+func ParseSymlinkPolicy(s string) (SymlinkPolicy, error) {
+	switch p := SymlinkPolicy(s); p {
+	case SymlinkSkip, SymlinkFollow, SymlinkPlaceholder:
+		return p, nil
+	default:
+		return "", errors.Errorf("unknown symbolic link policy '%s': use skip, follow or placeholder", s)
+	}
+}
+
+// SpecialFilePolicy is how Walk and Stat handle the special files: the named pipes, the sockets
+// and the devices, whose contents cannot be synced.
+type SpecialFilePolicy string
+
+const (
+	// SpecialFileSkip leaves the special files out. It is the default.
+	SpecialFileSkip SpecialFilePolicy = "skip"
+	// SpecialFileFail fails the walk at the first special file.
+	SpecialFileFail SpecialFilePolicy = "fail"
+)
+
+// ParseSpecialFilePolicy parses the name of a special file policy, such as "fail".
+func ParseSpecialFilePolicy(s string) (SpecialFilePolicy, error) {
+	switch p := SpecialFilePolicy(s); p {
+	case SpecialFileSkip, SpecialFileFail:
+		return p, nil
+	default:
+		return "", errors.Errorf("unknown special file policy '%s': use skip or fail", s)
+	}
+}
+
+// ErrSkipped is the error of Stat for the symbolic links and the special files that Walk leaves
+// out.
+var ErrSkipped = errors.New("the file is left out by the policy of its type")
+
+// SkipFunc returns whether Walk leaves out the file or folder at path, with the contents of the
+// folder.
+type SkipFunc func(path string, info os.FileInfo) bool
+
+// HashCache holds the hashes of the local files by their size and modification time: Walk and
+// Stat do not hash again the files whose size and modification time did not change. It must be
+// safe for concurrent use.
+type HashCache interface {
+	// Hash returns the hash of the file at path, when it is known for its size and modification
+	// time.
+	Hash(path string, size int64, modified time.Time) (string, bool)
+	// StoreHash records the hash of the file at path, of the given size and modification time.
+	StoreHash(path string, size int64, modified time.Time, hash string)
+}
+
+// LocalOption configures a Local.
+type LocalOption func(*Local)
+
+// WithSkip sets the function that chooses the files and folders that Walk leaves out. The root
+// of the walk is never left out.
+func WithSkip(skip SkipFunc) LocalOption {
+	return func(fs *Local) {
+		fs.skip = skip
+	}
+}
+
+// WithHashWorkers sets the number of files that Walk hashes concurrently. It defaults to the
+// number of CPUs.
+func WithHashWorkers(n int) LocalOption {
+	return func(fs *Local) {
+		fs.hashWorkers = n
+	}
+}
+
+// WithHashCache sets the cache of the hashes of the files. By default, all the files are hashed.
+func WithHashCache(c HashCache) LocalOption {
+	return func(fs *Local) {
+		fs.hashes = c
+	}
+}
+
+// WithSymlinks sets the policy of the symbolic links. It defaults to SymlinkSkip.
+func WithSymlinks(p SymlinkPolicy) LocalOption {
+	return func(fs *Local) {
+		fs.symlinks = p
+	}
+}
+
+// WithSpecialFiles sets the policy of the special files. It defaults to SpecialFileSkip.
+func WithSpecialFiles(p SpecialFilePolicy) LocalOption {
+	return func(fs *Local) {
+		fs.specialFiles = p
+	}
+}
+
+// SortKeyFunc returns the key of the name of a file or folder, by which Walk sorts the entries of
+// each folder, and its rank among the names of the same key. The key of a name followed by a slash
+// must sort after the keys of the other names that it starts with, like the names do.
+type SortKeyFunc func(name string) (key string, rank int)
+
+// WithSortKey sets the keys by which Walk sorts the entries of each folder. By default, the keys
+// are the names.
+func WithSortKey(fn SortKeyFunc) LocalOption {
+	return func(fs *Local) {
+		fs.sortKey = fn
+	}
+}
 
 // NewLocal creates a new initialised Local structure.
-func NewLocal() *Local {
-	return &Local{}
+func NewLocal(opts ...LocalOption) *Local {
+	fs := &Local{
+		hashWorkers:  runtime.NumCPU(),
+		symlinks:     SymlinkSkip,
+		specialFiles: SpecialFileSkip,
+	}
+
+	for _, opt := range opts {
+		opt(fs)
+	}
+
+	if fs.hashWorkers < 1 {
+		fs.hashWorkers = 1
+	}
+
+	return fs
+}
+
+// walkedEntry is an entry found by Walk, whose file is hashed by the hash workers before it is
+// written.
+type walkedEntry struct {
+	path  string
+	info  os.FileInfo
+	entry db.FSEntry
+	// hashed is closed once the file is hashed, or once the walk is cancelled.
+	hashed chan struct{}
+}
+
+// walkedName is the name of a file or folder of a walked folder, or of the contents of a
+// subfolder, which Walk sorts by their keys.
+type walkedName struct {
+	key      string
+	rank     int
+	name     string
+	path     string
+	info     os.FileInfo
+	hash     string
+	contents bool
 }
 
 // Walk traverses the file system entries and writes each entry to fsEntriesCh.
 // It must check for an error in errCh (which indicates the receiver of fsEntriesCh encountered
 // a problem and terminate if one is present.
 // Walk is the PRODUCER on fsEntriesCh and IS RESPONSIBLE FOR CLOSING IT!!
+//
+// The entries are written in the order of the keys of their paths, relative to path, the root
+// first: the key of a path is the key of its parent folder, a slash and the key of its name (see
+// WithSortKey), and the entries of the same key are sorted by their ranks, then by their names.
+// Of the folders of the same key, only the contents of the first one are walked, since the
+// contents of the others would not be in order. The files are hashed ahead of the entries that
+// are written, by a pool of workers (see WithHashWorkers).
 // nolint: gocognit, gocyclo
 func (fs *Local) Walk(ctx context.Context, fsName db.FSName, path string, fsEntriesCh chan<- db.FSEntry, errCh <-chan error) error {
 	fi, err := os.Stat(path)
@@ -41,76 +214,402 @@ func (fs *Local) Walk(ctx context.Context, fsName db.FSName, path string, fsEntr
 	}
 
 	deviceID := archos.Device(fi)
+	root := filepath.Clean(path)
 
-	folderIDs := map[string]uint64{}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
-	err = func() error {
-		isFSEntriesChOpened := true
+	var (
+		mu       sync.Mutex
+		firstErr error
+	)
+	fail := func(err error) {
+		mu.Lock()
+		if firstErr == nil {
+			firstErr = err
+		}
+		mu.Unlock()
+		cancel()
+	}
 
-		err = filepath.Walk(path,
-			func(path string, info os.FileInfo, err error) error {
-				if err != nil {
-					return errors.WithStack(err)
-				}
+	files := make(chan *walkedEntry, fs.hashWorkers)
+	// entries holds the entries in the order they are written, ahead of the files that are
+	// hashed.
+	entries := make(chan *walkedEntry, 4*fs.hashWorkers)
 
-				if archos.Device(info) != deviceID {
-					return filepath.SkipDir
-				}
+	var workers sync.WaitGroup
+	workers.Add(fs.hashWorkers)
+
+	for i := 0; i < fs.hashWorkers; i++ {
+		go func() {
+			defer workers.Done()
 
-				hash := ""
-				dir := filepath.Dir(path) // NOTE: this also calls filepath.Clean
-				if info.IsDir() {
-					dir = filepath.Clean(path)
-					folderIDs[dir] = archos.Inode(info)
-				} else {
-					hash, err = hashFileData(path)
+			for f := range files {
+				if ctx.Err() == nil {
+					hash, err := fs.hash(f.path, f.info)
 					if err != nil {
-						return err
+						fail(errors.WithMessagef(err, "hashing %s", f.path))
 					}
+					f.entry.Hash = hash
 				}
+				close(f.hashed)
+			}
+		}()
+	}
+
+	go func() {
+		defer close(entries)
 
-				createdTime := archos.CreatedTime(info)
+		// walked holds the inodes of the folders walked, which the followed symbolic links do not
+		// walk again.
+		walked := map[uint64]bool{}
 
-				parentFolderID, ok := folderIDs[dir]
-				if !ok {
-					return errors.Errorf("unable to determine parent folder ID for '%s' using key='%s'", path, dir)
+		// emit queues the entry of the file or folder at path, whose parent folder is parentID.
+		emit := func(path string, info os.FileInfo, parentID uint64, hash string) {
+			e := &walkedEntry{
+				path:   path,
+				info:   info,
+				entry:  newFSEntry(fsName, fmt.Sprintf("%d", deviceID), path, info, parentID, hash),
+				hashed: make(chan struct{}),
+			}
+
+			if info.IsDir() || hash != "" {
+				close(e.hashed)
+			} else {
+				select {
+				case files <- e:
+				case <-ctx.Done():
+					return
 				}
+			}
 
-				// tips for Windows support:
-				// - go/src/os/types_windows.go
-				// - https://stackoverflow.com/questions/7162164/does-windows-have-inode-numbers-like-linux
-				fsEntry := db.FSEntry{
-					FSName:         fsName,
-					DeviceID:       fmt.Sprintf("%d", deviceID),
-					EntryID:        archos.Inode(info),
-					IsFolder:       info.IsDir(),
-					Path:           filepath.Dir(path),
-					Name:           info.Name(),
-					ParentFolderID: parentFolderID,
-					Created:        createdTime,
-					Modified:       info.ModTime(),
-					Size:           uint64(info.Size()),
-					Hash:           hash,
+			select {
+			case entries <- e:
+			case <-ctx.Done():
+			}
+		}
+
+		var walkDir func(dir string, id uint64) error
+		walkDir = func(dir string, id uint64) error {
+			names, err := fs.readDir(dir, deviceID)
+			if err != nil {
+				return err
+			}
+
+			// emitted holds the names of the entries written, and contentsKey is the key of the
+			// contents of the last folder walked.
+			emitted := map[string]bool{}
+			contentsKey := ""
+
+			for _, n := range names {
+				if ctx.Err() != nil {
+					return errors.WithStack(ctx.Err())
 				}
 
-				select {
-				case err := <-errCh:
-					close(fsEntriesCh)
-					isFSEntriesChOpened = false
-					return errors.WithStack(err)
-				case fsEntriesCh <- fsEntry:
-					return nil
+				switch {
+				case !n.contents:
+					if n.info.IsDir() {
+						if walked[archos.Inode(n.info)] {
+							continue
+						}
+						walked[archos.Inode(n.info)] = true
+					}
+					emitted[n.name] = true
+					emit(n.path, n.info, id, n.hash)
+
+				case emitted[n.name] && n.key != contentsKey:
+					contentsKey = n.key
+					err = walkDir(n.path, archos.Inode(n.info))
+					if err != nil {
+						return err
+					}
 				}
-			})
-		if isFSEntriesChOpened {
-			close(fsEntriesCh)
-			isFSEntriesChOpened = false
-			return errors.WithStack(<-errCh)
+			}
+
+			return nil
+		}
+
+		walked[archos.Inode(fi)] = true
+		emit(root, fi, archos.Inode(fi), "")
+
+		err := walkDir(root, archos.Inode(fi))
+
+		close(files)
+		workers.Wait()
+
+		if err == nil {
+			// the workers skip the files once ctx is done.
+			err = errors.WithStack(ctx.Err())
+		}
+		if err != nil {
+			fail(err)
 		}
-		return err
 	}()
 
-	return err
+	stopped := false
+	for e := range entries {
+		if stopped {
+			// the remaining entries are dropped.
+			continue
+		}
+
+		select {
+		case <-e.hashed:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			stopped = true
+			continue
+		}
+
+		select {
+		case err := <-errCh:
+			// the receiver stopped: the workers are stopped, and the remaining entries dropped.
+			cancel()
+			close(fsEntriesCh)
+			for range entries { // nolint: revive
+			}
+			return errors.WithStack(err)
+		case <-ctx.Done():
+			stopped = true
+		case fsEntriesCh <- e.entry:
+		}
+	}
+
+	close(fsEntriesCh)
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if firstErr != nil {
+		return firstErr
+	}
+
+	return errors.WithStack(err)
+}
+
+// readDir returns the files and folders of the folder dir that Walk writes, with the contents of
+// its subfolders, sorted by their keys, their ranks and their names. The files and folders of
+// other devices than deviceID are left out.
+// nolint: gocognit, gocyclo
+func (fs *Local) readDir(dir string, deviceID uint64) ([]walkedName, error) {
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	names := make([]walkedName, 0, len(dirEntries))
+
+	for _, d := range dirEntries {
+		path := filepath.Join(dir, d.Name())
+
+		info, err := os.Lstat(path)
+		if errors.Is(err, os.ErrNotExist) {
+			// deleted since it was listed.
+			continue
+		}
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		if archos.Device(info) != deviceID {
+			continue
+		}
+
+		if fs.skip != nil && fs.skip(path, info) {
+			continue
+		}
+
+		hash := ""
+
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			target, err := fs.resolveSymlink(path)
+			switch {
+			case err != nil:
+				return nil, err
+			case target == nil:
+				continue
+			case fs.symlinks == SymlinkFollow:
+				// the target folder is walked through the path of the link.
+				info = target
+			default:
+				hash, err = placeholderHash(path)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+		case !info.IsDir() && !info.Mode().IsRegular():
+			if fs.specialFiles == SpecialFileFail {
+				return nil, errors.Errorf("special file: %s", path)
+			}
+			continue
+		}
+
+		key, rank := d.Name(), 0
+		if fs.sortKey != nil {
+			key, rank = fs.sortKey(d.Name())
+		}
+
+		n := walkedName{key: key, rank: rank, name: d.Name(), path: path, info: info, hash: hash}
+		names = append(names, n)
+
+		if info.IsDir() {
+			n.key += "/"
+			n.contents = true
+			names = append(names, n)
+		}
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		a, b := names[i], names[j]
+		switch {
+		case a.key != b.key:
+			return a.key < b.key
+		case a.rank != b.rank:
+			return a.rank < b.rank
+		default:
+			return a.name < b.name
+		}
+	})
+
+	return names, nil
+}
+
+// Stat returns the entry of the file or folder at path, as Walk would write it. The contents of
+// a file are hashed.
+func (fs *Local) Stat(fsName db.FSName, path string) (*db.FSEntry, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	parent, err := os.Stat(filepath.Dir(path))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	hash := ""
+
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		target, err := fs.resolveSymlink(path)
+		switch {
+		case err != nil:
+			return nil, err
+		case target == nil:
+			return nil, errors.WithMessage(ErrSkipped, path)
+		case fs.symlinks == SymlinkFollow:
+			info = target
+		default:
+			hash, err = placeholderHash(path)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+	case !info.IsDir() && !info.Mode().IsRegular():
+		if fs.specialFiles == SpecialFileFail {
+			return nil, errors.Errorf("special file: %s", path)
+		}
+		return nil, errors.WithMessage(ErrSkipped, path)
+	}
+
+	if !info.IsDir() && hash == "" {
+		hash, err = fs.hash(path, info)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	fsEntry := newFSEntry(fsName, fmt.Sprintf("%d", archos.Device(info)), path, info, archos.Inode(parent), hash)
+
+	return &fsEntry, nil
+}
+
+// resolveSymlink returns the file info of the target of the symbolic link at path when it is
+// followed, or the file info of the link when it is replaced by a placeholder. It returns nil when
+// the link is left out: with SymlinkSkip, and when the followed link is broken, or points to a
+// special file.
+func (fs *Local) resolveSymlink(path string) (os.FileInfo, error) {
+	switch fs.symlinks {
+	case SymlinkFollow:
+		target, err := os.Stat(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if !target.IsDir() && !target.Mode().IsRegular() {
+			return nil, nil
+		}
+		return target, nil
+
+	case SymlinkPlaceholder:
+		info, err := os.Lstat(path)
+		return info, errors.WithStack(err)
+
+	default:
+		return nil, nil
+	}
+}
+
+// placeholderHash returns the hash of the placeholder of the symbolic link at path: that of its
+// target.
+func placeholderHash(path string) (string, error) {
+	target, err := os.Readlink(path)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return hashData(strings.NewReader(target))
+}
+
+// hash returns the hash of the file at path, from the hash cache when the file did not change.
+func (fs *Local) hash(path string, info os.FileInfo) (string, error) {
+	if fs.hashes != nil {
+		if hash, ok := fs.hashes.Hash(path, info.Size(), info.ModTime()); ok {
+			return hash, nil
+		}
+	}
+
+	hash, err := hashFileData(path)
+	if err != nil {
+		return "", err
+	}
+
+	if fs.hashes != nil {
+		fs.hashes.StoreHash(path, info.Size(), info.ModTime(), hash)
+	}
+
+	return hash, nil
+}
+
+// newFSEntry returns the entry of the file or folder at path, of the device deviceID.
+func newFSEntry(fsName db.FSName, deviceID string, path string, info os.FileInfo, parentFolderID uint64, hash string) db.FSEntry {
+	// tips for Windows support:
+	// - go/src/os/types_windows.go
+	// - https://stackoverflow.com/questions/7162164/does-windows-have-inode-numbers-like-linux
+	return db.FSEntry{
+		FSName:         fsName,
+		DeviceID:       deviceID,
+		EntryID:        archos.Inode(info),
+		IsFolder:       info.IsDir(),
+		Path:           filepath.Dir(path),
+		Name:           info.Name(),
+		ParentFolderID: parentFolderID,
+		Created:        archos.CreatedTime(info),
+		Modified:       info.ModTime(),
+		Size:           uint64(info.Size()),
+		Hash:           hash,
+	}
 }
 
 func hashFileData(path string) (string, error) {
@@ -2,9 +2,13 @@ package filesystem_test
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
+	"sync"
+	"syscall"
 	"testing"
 	"time"
 
@@ -168,3 +172,205 @@ func (testsuite *LocalIntegrationTestSuite) TestLocal_Walk() {
 		testsuite.EqualValues(e.Hash, actualE.Hash)
 	}
 }
+
+// fakeHashCache is a filesystem.HashCache that records its use.
+type fakeHashCache struct {
+	mu     sync.Mutex
+	hashes map[string]string
+	stored []string
+}
+
+func (c *fakeHashCache) Hash(path string, _ int64, _ time.Time) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	h, ok := c.hashes[path]
+	return h, ok
+}
+
+func (c *fakeHashCache) StoreHash(path string, _ int64, _ time.Time, hash string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.hashes[path] = hash
+	c.stored = append(c.stored, path)
+}
+
+func (testsuite *LocalIntegrationTestSuite) TestLocal_Walk_HashWorkers() {
+	root := filepath.Join(testsuite.localTestPath, "hashed")
+
+	for i := 0; i < 20; i++ {
+		err := os.MkdirAll(filepath.Join(root, fmt.Sprintf("Folder%d", i%4)), 0700)
+		testsuite.Require().NoError(err)
+		err = os.WriteFile(filepath.Join(root, fmt.Sprintf("Folder%d", i%4), fmt.Sprintf("File%d", i)), []byte("This is File000"), 0600)
+		testsuite.Require().NoError(err)
+	}
+
+	// the hash of a cached file is not computed again.
+	cached := filepath.Join(root, "Folder0", "File0")
+	cache := &fakeHashCache{hashes: map[string]string{cached: "cached"}}
+	localFS := filesystem.NewLocal(filesystem.WithHashWorkers(3), filesystem.WithHashCache(cache))
+
+	fsEntriesCh := make(chan db.FSEntry)
+	errCh := make(chan error)
+	seen := map[string]db.FSEntry{}
+
+	go func() {
+		for fse := range fsEntriesCh {
+			p := filepath.Join(fse.Path, fse.Name)
+			if !fse.IsFolder {
+				// the folders come before their contents.
+				_, ok := seen[fse.Path]
+				testsuite.True(ok, p)
+			}
+			seen[p] = fse
+		}
+
+		errCh <- nil
+	}()
+
+	err := localFS.Walk(testsuite.ctx, "local_fs", root, fsEntriesCh, errCh)
+	testsuite.Require().NoError(err)
+
+	testsuite.Len(seen, 1+4+20)
+	testsuite.Equal("cached", seen[cached].Hash)
+	testsuite.Equal("01ce643e7c1ca98f6fb21e61b5d03f547813edae", seen[filepath.Join(root, "Folder3", "File19")].Hash)
+	testsuite.Len(cache.stored, 19)
+}
+
+func (testsuite *LocalIntegrationTestSuite) TestLocal_Walk_Symlinks() {
+	root := filepath.Join(testsuite.localTestPath, "links")
+
+	testsuite.Require().NoError(os.MkdirAll(filepath.Join(root, "Folder"), 0700))
+	testsuite.Require().NoError(os.WriteFile(filepath.Join(root, "File"), []byte("This is File000"), 0600))
+	testsuite.Require().NoError(os.WriteFile(filepath.Join(root, "Folder", "File1"), []byte("This is File1"), 0600))
+	testsuite.Require().NoError(os.Symlink("File", filepath.Join(root, "LinkToFile")))
+	testsuite.Require().NoError(os.Symlink("Folder", filepath.Join(root, "LinkToFolder")))
+	testsuite.Require().NoError(os.Symlink("Missing", filepath.Join(root, "BrokenLink")))
+	testsuite.Require().NoError(os.Symlink("..", filepath.Join(root, "Folder", "LinkToRoot")))
+	testsuite.Require().NoError(syscall.Mkfifo(filepath.Join(root, "Pipe"), 0600))
+
+	walk := func(opts ...filesystem.LocalOption) (map[string]db.FSEntry, error) {
+		fsEntriesCh := make(chan db.FSEntry)
+		errCh := make(chan error)
+		seen := map[string]db.FSEntry{}
+
+		go func() {
+			for fse := range fsEntriesCh {
+				rel, err := filepath.Rel(root, filepath.Join(fse.Path, fse.Name))
+				testsuite.Require().NoError(err)
+				seen[filepath.ToSlash(rel)] = fse
+			}
+
+			errCh <- nil
+		}()
+
+		err := filesystem.NewLocal(opts...).Walk(testsuite.ctx, "local_fs", root, fsEntriesCh, errCh)
+
+		return seen, err
+	}
+
+	paths := func(seen map[string]db.FSEntry) []string {
+		ps := []string{}
+		for p := range seen {
+			ps = append(ps, p)
+		}
+		sort.Strings(ps)
+
+		return ps
+	}
+
+	// the links and the special files are left out by default.
+	seen, err := walk()
+	testsuite.Require().NoError(err)
+	testsuite.Equal([]string{".", "File", "Folder", "Folder/File1"}, paths(seen))
+
+	// the links are replaced by their targets, but the broken links and the folders already
+	// walked.
+	seen, err = walk(filesystem.WithSymlinks(filesystem.SymlinkFollow))
+	testsuite.Require().NoError(err)
+	testsuite.Equal([]string{".", "File", "Folder", "Folder/File1", "LinkToFile"}, paths(seen))
+	testsuite.Equal(seen["File"].Hash, seen["LinkToFile"].Hash)
+
+	// or by placeholders holding their targets.
+	seen, err = walk(filesystem.WithSymlinks(filesystem.SymlinkPlaceholder))
+	testsuite.Require().NoError(err)
+	testsuite.Equal([]string{".", "BrokenLink", "File", "Folder", "Folder/File1", "Folder/LinkToRoot", "LinkToFile", "LinkToFolder"}, paths(seen))
+	testsuite.False(seen["LinkToFolder"].IsFolder)
+	testsuite.EqualValues(len("Folder"), seen["LinkToFolder"].Size)
+	testsuite.Equal("30baa24967e08965d1594408031f0324ae11ccac", seen["LinkToFolder"].Hash)
+
+	_, err = walk(filesystem.WithSpecialFiles(filesystem.SpecialFileFail))
+	testsuite.Require().Error(err)
+
+	_, err = filesystem.NewLocal().Stat("local_fs", filepath.Join(root, "Pipe"))
+	testsuite.Require().ErrorIs(err, filesystem.ErrSkipped)
+}
+
+func (testsuite *LocalIntegrationTestSuite) TestLocal_Walk_FollowedFolder() {
+	root := filepath.Join(testsuite.localTestPath, "followed")
+	target := filepath.Join(testsuite.localTestPath, "target")
+
+	testsuite.Require().NoError(os.MkdirAll(root, 0700))
+	testsuite.Require().NoError(os.MkdirAll(filepath.Join(target, "Sub"), 0700))
+	testsuite.Require().NoError(os.WriteFile(filepath.Join(target, "Sub", "File"), []byte("This is File000"), 0600))
+	testsuite.Require().NoError(os.Symlink(target, filepath.Join(root, "Link")))
+
+	fsEntriesCh := make(chan db.FSEntry)
+	errCh := make(chan error)
+	seen := map[string]db.FSEntry{}
+
+	go func() {
+		for fse := range fsEntriesCh {
+			rel, err := filepath.Rel(root, filepath.Join(fse.Path, fse.Name))
+			testsuite.Require().NoError(err)
+			seen[filepath.ToSlash(rel)] = fse
+		}
+
+		errCh <- nil
+	}()
+
+	err := filesystem.NewLocal(filesystem.WithSymlinks(filesystem.SymlinkFollow)).Walk(testsuite.ctx, "local_fs", root, fsEntriesCh, errCh)
+	testsuite.Require().NoError(err)
+
+	testsuite.Len(seen, 4)
+	testsuite.True(seen["Link"].IsFolder)
+	testsuite.True(seen["Link/Sub"].IsFolder)
+	testsuite.Equal("01ce643e7c1ca98f6fb21e61b5d03f547813edae", seen["Link/Sub/File"].Hash)
+}
+
+func (testsuite *LocalIntegrationTestSuite) TestLocal_Walk_Order() {
+	root := filepath.Join(testsuite.localTestPath, "ordered")
+
+	for _, p := range []string{"a/x", "a.txt", "B/y", "b2/z", "c"} {
+		testsuite.Require().NoError(os.MkdirAll(filepath.Dir(filepath.Join(root, p)), 0700))
+		testsuite.Require().NoError(os.WriteFile(filepath.Join(root, p), []byte(p), 0600))
+	}
+
+	// "b2" has the key of "B", of a lower rank: only the contents of "B" are walked.
+	key := func(name string) (string, int) {
+		if name == "b2" {
+			return "b", 1
+		}
+		return strings.ToLower(name), 0
+	}
+
+	fsEntriesCh := make(chan db.FSEntry)
+	errCh := make(chan error)
+	var paths []string
+
+	go func() {
+		for fse := range fsEntriesCh {
+			rel, err := filepath.Rel(root, filepath.Join(fse.Path, fse.Name))
+			testsuite.Require().NoError(err)
+			paths = append(paths, filepath.ToSlash(rel))
+		}
+
+		errCh <- nil
+	}()
+
+	err := filesystem.NewLocal(filesystem.WithHashWorkers(2), filesystem.WithSortKey(key)).Walk(testsuite.ctx, "local_fs", root, fsEntriesCh, errCh)
+	testsuite.Require().NoError(err)
+
+	testsuite.Equal([]string{".", "a", "a.txt", "a/x", "B", "b2", "B/y", "c"}, paths)
+}
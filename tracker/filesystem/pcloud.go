@@ -13,7 +13,7 @@ import (
 
 // pCloudSDK defines the SDK methods used to perform operations on the PCloud file system.
 type pCloudSDK interface {
-	ListFolder(ctx context.Context, folder sdk.T1PathOrFolderID, recursiveOpt, showDeletedOpt, noFilesOpt, noSharesOpt bool, opts ...sdk.ClientOption) (*sdk.FSList, error)
+	List(ctx context.Context, folder sdk.FolderRef, o sdk.ListOptions, opts ...sdk.ClientOption) (*sdk.FSList, error)
 }
 
 // PCloud is a file system abstraction for the PCloud file system.
@@ -34,11 +34,15 @@ func NewPCloud(sdk pCloudSDK) *PCloud {
 // Walk is the PRODUCER on fsEntriesCh and IS RESPONSIBLE FOR CLOSING IT!!
 // nolint: gocognit
 func (fs *PCloud) Walk(ctx context.Context, fsName db.FSName, path string, fsEntriesCh chan<- db.FSEntry, errCh <-chan error) error {
-	lf, err := fs.sdk.ListFolder(ctx, sdk.T1FolderByPath(path), true, false, false, false)
+	lf, err := fs.sdk.List(ctx, sdk.ByPath(path), sdk.ListOptions{Recursive: true})
 	if err != nil {
 		return err
 	}
 
+	// like Local.Walk, the path of the root entry is that of its parent folder, so that the
+	// paths of its contents, which pCloud does not return, are rebuilt from it.
+	lf.Metadata.Path = filepath.Dir(filepath.Clean(path))
+
 	err = func() error {
 		var entries stack
 		entries.add(lf.Metadata)
@@ -70,8 +74,8 @@ func (fs *PCloud) Walk(ctx context.Context, fsName db.FSName, path string, fsEnt
 				Path:           entry.Path,
 				Name:           entry.Name,
 				ParentFolderID: entry.ParentFolderID,
-				Created:        entry.Created.Time,
-				Modified:       entry.Modified.Time,
+				Created:        entry.Created,
+				Modified:       entry.Modified,
 				Size:           entry.Size,
 				Hash:           hash,
 			}
@@ -80,12 +84,20 @@ func (fs *PCloud) Walk(ctx context.Context, fsName db.FSName, path string, fsEnt
 			case err = <-errCh:
 				close(fsEntriesCh)
 				return errors.WithStack(err)
+			case <-ctx.Done():
+				close(fsEntriesCh)
+				return errors.WithStack(ctx.Err())
 			case fsEntriesCh <- fsEntry:
 			}
 		}
 		close(fsEntriesCh)
 
-		return errors.WithStack(<-errCh)
+		select {
+		case err = <-errCh:
+			return errors.WithStack(err)
+		case <-ctx.Done():
+			return errors.WithStack(ctx.Err())
+		}
 	}()
 
 	return err
@@ -75,7 +75,7 @@ func (testsuite *PCloudIntegrationTestSuite) TestPCloud_Walk() {
 	lf := pCloudFolderTreeSample1(time1, time2, time3, time4, time5, time6, time7)
 
 	testsuite.pCloudClient.
-		On("ListFolder", testsuite.ctx, mock.AnythingOfType("sdk.T1PathOrFolderID"), true, false, false, false, []sdk.ClientOption(nil)).
+		On("List", testsuite.ctx, sdk.ByPath("/"), sdk.ListOptions{Recursive: true}, []sdk.ClientOption(nil)).
 		Return(lf, nil).
 		Once()
 
@@ -117,16 +117,12 @@ func (testsuite *PCloudIntegrationTestSuite) TestPCloud_Walk() {
 func pCloudFolderTreeSample1(time1, time2, time3, time4, time5, time6, time7 time.Time) *sdk.FSList {
 	return &sdk.FSList{
 		Metadata: &sdk.Metadata{
-			Path: "/",
-			Name: "/",
-			Created: &sdk.APITime{
-				Time: time1,
-			},
-			IsMine: true,
-			Thumb:  false,
-			Modified: &sdk.APITime{
-				Time: time1,
-			},
+			Path:           "/",
+			Name:           "/",
+			Created:        time1,
+			IsMine:         true,
+			Thumb:          false,
+			Modified:       time1,
 			Comments:       0,
 			ID:             "d0",
 			IsShared:       false,
@@ -138,15 +134,11 @@ func pCloudFolderTreeSample1(time1, time2, time3, time4, time5, time6, time7 tim
 			FolderID:       0,
 			Contents: []*sdk.Metadata{
 				{
-					Name: "Folder1",
-					Created: &sdk.APITime{
-						Time: time2,
-					},
-					IsMine: true,
-					Thumb:  false,
-					Modified: &sdk.APITime{
-						Time: time2,
-					},
+					Name:           "Folder1",
+					Created:        time2,
+					IsMine:         true,
+					Thumb:          false,
+					Modified:       time2,
 					Comments:       0,
 					ID:             "d10001",
 					IsShared:       false,
@@ -157,15 +149,11 @@ func pCloudFolderTreeSample1(time1, time2, time3, time4, time5, time6, time7 tim
 					FolderID:       10001,
 					Contents: []*sdk.Metadata{
 						{
-							Name: "File1",
-							Created: &sdk.APITime{
-								Time: time3,
-							},
-							IsMine: true,
-							Thumb:  false,
-							Modified: &sdk.APITime{
-								Time: time3,
-							},
+							Name:           "File1",
+							Created:        time3,
+							IsMine:         true,
+							Thumb:          false,
+							Modified:       time3,
 							Comments:       0,
 							ID:             "f10002",
 							IsShared:       false,
@@ -181,15 +169,11 @@ func pCloudFolderTreeSample1(time1, time2, time3, time4, time5, time6, time7 tim
 					},
 				},
 				{
-					Name: "Folder2",
-					Created: &sdk.APITime{
-						Time: time4,
-					},
-					IsMine: true,
-					Thumb:  false,
-					Modified: &sdk.APITime{
-						Time: time4,
-					},
+					Name:           "Folder2",
+					Created:        time4,
+					IsMine:         true,
+					Thumb:          false,
+					Modified:       time4,
 					Comments:       0,
 					ID:             "d20001",
 					IsShared:       false,
@@ -200,15 +184,11 @@ func pCloudFolderTreeSample1(time1, time2, time3, time4, time5, time6, time7 tim
 					FolderID:       20001,
 					Contents: []*sdk.Metadata{
 						{
-							Name: "File2",
-							Created: &sdk.APITime{
-								Time: time5,
-							},
-							IsMine: true,
-							Thumb:  false,
-							Modified: &sdk.APITime{
-								Time: time5,
-							},
+							Name:           "File2",
+							Created:        time5,
+							IsMine:         true,
+							Thumb:          false,
+							Modified:       time5,
 							Comments:       0,
 							ID:             "f20002",
 							IsShared:       false,
@@ -224,15 +204,11 @@ func pCloudFolderTreeSample1(time1, time2, time3, time4, time5, time6, time7 tim
 					},
 				},
 				{
-					Name: "Folder3",
-					Created: &sdk.APITime{
-						Time: time6,
-					},
-					IsMine: true,
-					Thumb:  false,
-					Modified: &sdk.APITime{
-						Time: time6,
-					},
+					Name:           "Folder3",
+					Created:        time6,
+					IsMine:         true,
+					Thumb:          false,
+					Modified:       time6,
 					Comments:       0,
 					ID:             "d30001",
 					IsShared:       false,
@@ -244,15 +220,11 @@ func pCloudFolderTreeSample1(time1, time2, time3, time4, time5, time6, time7 tim
 					Contents:       []*sdk.Metadata{},
 				},
 				{
-					Name: "File000",
-					Created: &sdk.APITime{
-						Time: time7,
-					},
-					IsMine: true,
-					Thumb:  false,
-					Modified: &sdk.APITime{
-						Time: time7,
-					},
+					Name:           "File000",
+					Created:        time7,
+					IsMine:         true,
+					Thumb:          false,
+					Modified:       time7,
 					Comments:       0,
 					ID:             "f1000003",
 					IsShared:       false,
@@ -274,8 +246,8 @@ type pCloudClientMock struct {
 	mock.Mock
 }
 
-func (m *pCloudClientMock) ListFolder(ctx context.Context, folder sdk.T1PathOrFolderID, recursiveOpt, showDeletedOpt, noFilesOpt, noSharesOpt bool, opts ...sdk.ClientOption) (*sdk.FSList, error) {
-	args := m.Called(ctx, folder, recursiveOpt, showDeletedOpt, noFilesOpt, noSharesOpt, opts)
+func (m *pCloudClientMock) List(ctx context.Context, folder sdk.FolderRef, o sdk.ListOptions, opts ...sdk.ClientOption) (*sdk.FSList, error) {
+	args := m.Called(ctx, folder, o, opts)
 	return args.Get(0).(*sdk.FSList), args.Error(1)
 }
 
@@ -0,0 +1,243 @@
+package tracker
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/seborama/pcloud-sdk/tracker/db"
+)
+
+// The new hashes are recorded in the store by batches of hashFlushSize, or every
+// hashFlushInterval: an interrupted scan only hashes again the files of the last batch.
+const (
+	hashFlushSize     = 256
+	hashFlushInterval = 5 * time.Second
+)
+
+// localHashStorer defines the store methods used by hashCache.
+type localHashStorer interface {
+	GetLocalHash(ctx context.Context, pairName db.PairName, p string) (*db.LocalHash, error)
+	EachLocalHash(ctx context.Context, pairName db.PairName, order db.PathOrder, fn func(h db.LocalHash) error) error
+	AddLocalHashes(ctx context.Context, pairName db.PairName, hashes []db.LocalHash) error
+	DeleteLocalHashes(ctx context.Context, pairName db.PairName, hashes []db.LocalHash) error
+}
+
+// hashCache is the filesystem.HashCache of the local folder of a pair, held in the store with the
+// size and the modification time of the files, where each file is looked up as it is scanned. The
+// hashes are recorded as the files are hashed, so that a scan that is interrupted does not hash
+// them again.
+type hashCache struct {
+	logger    *zap.Logger
+	store     localHashStorer
+	pairName  db.PairName
+	localRoot string
+
+	mu sync.Mutex
+	// pending holds the hashes that are not recorded yet, by the slash-separated paths of the
+	// files, relative to the local root.
+	pending   map[string]db.LocalHash
+	lastFlush time.Time
+}
+
+func newHashCache(logger *zap.Logger, store localHashStorer, pairName db.PairName, localRoot string) *hashCache {
+	return &hashCache{
+		logger:    logger,
+		store:     store,
+		pairName:  pairName,
+		localRoot: localRoot,
+		pending:   map[string]db.LocalHash{},
+		lastFlush: time.Now(),
+	}
+}
+
+// Hash implements filesystem.HashCache.
+func (c *hashCache) Hash(path string, size int64, modified time.Time) (string, bool) {
+	rel, ok := c.rel(path)
+	if !ok {
+		return "", false
+	}
+
+	c.mu.Lock()
+	h, ok := c.pending[rel]
+	c.mu.Unlock()
+
+	if !ok {
+		stored, err := c.store.GetLocalHash(context.Background(), c.pairName, rel)
+		if err != nil {
+			// the file is hashed again.
+			c.logger.Warn("reading the hash of a local file failed", zap.String("path", rel), zap.Error(err))
+			return "", false
+		}
+		if stored == nil {
+			return "", false
+		}
+		h = *stored
+	}
+
+	if h.Size != size || !h.Modified.Equal(modified) {
+		return "", false
+	}
+
+	return h.Hash, true
+}
+
+// StoreHash implements filesystem.HashCache.
+func (c *hashCache) StoreHash(path string, size int64, modified time.Time, hash string) {
+	rel, ok := c.rel(path)
+	if !ok {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.pending[rel] = db.LocalHash{Path: rel, Size: size, Modified: modified, Hash: hash}
+
+	if len(c.pending) >= hashFlushSize || time.Since(c.lastFlush) >= hashFlushInterval {
+		c.flushLocked()
+	}
+}
+
+// flush records the new hashes in the store.
+func (c *hashCache) flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.flushLocked()
+}
+
+func (c *hashCache) flushLocked() {
+	c.lastFlush = time.Now()
+
+	if len(c.pending) == 0 {
+		return
+	}
+
+	hashes := make([]db.LocalHash, 0, len(c.pending))
+	for _, h := range c.pending {
+		hashes = append(hashes, h)
+	}
+
+	// the hashes are recorded even when the scan is cancelled: they are those of its progress.
+	err := c.store.AddLocalHashes(context.Background(), c.pairName, hashes)
+	if err != nil {
+		// the hashes are only a cache: they are recorded by the next flush.
+		c.logger.Warn("recording the hashes of the local files failed", zap.Error(err))
+		return
+	}
+	c.pending = map[string]db.LocalHash{}
+}
+
+// pruner returns the hashPruner of a full scan of the local folder, whose entries are streamed in
+// the order order.
+func (c *hashCache) pruner(ctx context.Context, order db.PathOrder) *hashPruner {
+	return &hashPruner{
+		cache: c,
+		order: order,
+		hashes: newCursor(func(fn func(h db.LocalHash) error) error {
+			return c.store.EachLocalHash(ctx, c.pairName, order, fn)
+		}),
+		ctx: ctx,
+	}
+}
+
+// hashPruner drops the hashes of the files that no longer exist, as the entries of a full scan
+// of the local folder are streamed: the hashes are streamed from the store in the same order, and
+// those that are not of the files of the scan are deleted, by batches. A hash that is recorded
+// again meanwhile is kept (see db.SQLite3.DeleteLocalHashes). The hashes are only a cache: the
+// pruner stops at its first error, which it logs.
+type hashPruner struct {
+	cache  *hashCache
+	order  db.PathOrder
+	hashes *cursor[db.LocalHash]
+	ctx    context.Context
+
+	// key is the key of the paths of the last entries of the scan, and group holds the hashes of
+	// their files, by path.
+	key     string
+	group   map[string]string
+	removed []db.LocalHash
+	failed  bool
+}
+
+// add adds the entry e of the path p of the scan, which follows the entries added before.
+func (p *hashPruner) add(path string, e db.FSEntry) {
+	if key := p.order.Key(path); key != p.key {
+		p.prune(&key)
+		p.key = key
+		p.group = map[string]string{}
+	}
+
+	if !e.IsFolder {
+		p.group[path] = e.Hash
+	}
+}
+
+// done deletes the hashes left, once the scan is complete, and stops the pruner.
+func (p *hashPruner) done() {
+	p.prune(nil)
+	p.deleteRemoved()
+	p.close()
+}
+
+// close stops the pruner.
+func (p *hashPruner) close() {
+	p.hashes.close()
+}
+
+// prune removes the hashes before the key next, or all of them when it is nil, but those of the
+// files of the group.
+func (p *hashPruner) prune(next *string) {
+	for !p.failed && p.hashes.ok {
+		h := p.hashes.cur
+		key := p.order.Key(h.Path)
+		if next != nil && key >= *next {
+			break
+		}
+
+		if hash, ok := p.group[h.Path]; key != p.key || !ok || hash != h.Hash {
+			p.removed = append(p.removed, h)
+		}
+		p.hashes.next()
+	}
+
+	if p.hashes.err != nil {
+		p.fail(p.hashes.err)
+	}
+	if len(p.removed) >= hashFlushSize {
+		p.deleteRemoved()
+	}
+}
+
+func (p *hashPruner) deleteRemoved() {
+	if p.failed || len(p.removed) == 0 {
+		return
+	}
+
+	err := p.cache.store.DeleteLocalHashes(p.ctx, p.cache.pairName, p.removed)
+	if err != nil {
+		p.fail(err)
+	}
+	p.removed = nil
+}
+
+func (p *hashPruner) fail(err error) {
+	if !p.failed {
+		p.cache.logger.Warn("pruning the hashes of the local files failed", zap.Error(err))
+	}
+	p.failed = true
+}
+
+// rel returns the slash-separated path of the local file path, relative to the local root.
+func (c *hashCache) rel(path string) (string, bool) {
+	rel, err := filepath.Rel(c.localRoot, path)
+	if err != nil || rel == "." {
+		return "", false
+	}
+
+	return filepath.ToSlash(rel), true
+}
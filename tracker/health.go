@@ -0,0 +1,109 @@
+package tracker
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Health records the results of the syncs of Watch, through its Report method, and serves them
+// as the health of the sync over HTTP. It is safe for concurrent use.
+type Health struct {
+	mu                  sync.Mutex
+	started             time.Time
+	syncs               int
+	failures            int
+	fileErrors          int
+	lastSync            time.Time
+	lastSuccess         time.Time
+	lastError           error
+	consecutiveFailures int
+	lastStats           *SyncStats
+}
+
+// NewHealth creates a new Health.
+func NewHealth() *Health {
+	return &Health{started: time.Now()}
+}
+
+// Report records the result of a sync. It is meant for WithSyncReport.
+func (h *Health) Report(stats *SyncStats, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.syncs++
+	h.lastSync = time.Now()
+	h.lastStats = stats
+	h.lastError = err
+	if stats != nil {
+		h.fileErrors += stats.Errors
+	}
+
+	if err != nil {
+		h.failures++
+		h.consecutiveFailures++
+		return
+	}
+	h.consecutiveFailures = 0
+	h.lastSuccess = h.lastSync
+}
+
+// HealthStatus is the health of the sync, as served by Health.
+type HealthStatus struct {
+	// Status is "ok" until a sync fails, then "failing" until a sync succeeds again.
+	Status              string     `json:"status"`
+	Started             time.Time  `json:"started"`
+	Syncs               int        `json:"syncs"`
+	Failures            int        `json:"failures"`
+	FileErrors          int        `json:"file_errors"`
+	LastSync            *time.Time `json:"last_sync,omitempty"`
+	LastSuccess         *time.Time `json:"last_success,omitempty"`
+	LastError           string     `json:"last_error,omitempty"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LastStats           *SyncStats `json:"last_stats,omitempty"`
+}
+
+// Status returns the health of the sync.
+func (h *Health) Status() HealthStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	st := HealthStatus{
+		Status:              "ok",
+		Started:             h.started,
+		Syncs:               h.syncs,
+		Failures:            h.failures,
+		FileErrors:          h.fileErrors,
+		ConsecutiveFailures: h.consecutiveFailures,
+		LastStats:           h.lastStats,
+	}
+
+	if !h.lastSync.IsZero() {
+		t := h.lastSync
+		st.LastSync = &t
+	}
+	if !h.lastSuccess.IsZero() {
+		t := h.lastSuccess
+		st.LastSuccess = &t
+	}
+	if h.lastError != nil {
+		st.Status = "failing"
+		st.LastError = h.lastError.Error()
+	}
+
+	return st
+}
+
+// ServeHTTP serves the health of the sync as JSON, with the status 503 Service Unavailable when
+// the last sync failed.
+func (h *Health) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	st := h.Status()
+
+	w.Header().Set("Content-Type", "application/json")
+	if st.LastError != "" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	_ = json.NewEncoder(w).Encode(st)
+}
@@ -0,0 +1,61 @@
+package tracker_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/tracker"
+)
+
+func TestHealth(t *testing.T) {
+	h := tracker.NewHealth()
+
+	get := func() (int, tracker.HealthStatus) {
+		t.Helper()
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+		var st tracker.HealthStatus
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &st))
+
+		return rec.Code, st
+	}
+
+	code, st := get()
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", st.Status)
+	assert.Zero(t, st.Syncs)
+	assert.Nil(t, st.LastSync)
+
+	h.Report(&tracker.SyncStats{Uploaded: 2}, nil)
+
+	code, st = get()
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 1, st.Syncs)
+	require.NotNil(t, st.LastSuccess)
+	assert.Equal(t, 2, st.LastStats.Uploaded)
+
+	h.Report(nil, errors.New("boom"))
+	h.Report(nil, errors.New("boom again"))
+
+	code, st = get()
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "failing", st.Status)
+	assert.Equal(t, "boom again", st.LastError)
+	assert.Equal(t, 2, st.ConsecutiveFailures)
+	assert.NotNil(t, st.LastSuccess)
+
+	h.Report(&tracker.SyncStats{}, nil)
+
+	code, st = get()
+	assert.Equal(t, http.StatusOK, code)
+	assert.Zero(t, st.ConsecutiveFailures)
+	assert.Empty(t, st.LastError)
+}
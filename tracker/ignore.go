@@ -0,0 +1,286 @@
+package tracker
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// IgnoreFileName is the name of the files of the local folder that list the files and folders
+// left out of the two-way sync, with the patterns of .gitignore files. The patterns of a file
+// apply to the contents of its folder.
+const IgnoreFileName = ".pcloudignore"
+
+// WithIgnoreFile sets a global ignore file, whose patterns apply to the whole sync pair, before
+// those of the ignore files of the local folder. It is read by each sync.
+func WithIgnoreFile(name string) TwoWayOption {
+	return func(s *TwoWay) {
+		s.ignoreFile = name
+	}
+}
+
+// loadIgnorer creates the ignorer of a sync, with the selection of the pair saved in the store.
+func (s *TwoWay) loadIgnorer(ctx context.Context) (*ignorer, error) {
+	sel, err := s.store.GetSyncSelection(ctx, s.pairName)
+	if err != nil {
+		return nil, err
+	}
+
+	return newIgnorer(s.localRoot, s.ignoreFile, sel)
+}
+
+// ignoreRule is a pattern of an ignore file.
+type ignoreRule struct {
+	// dir is the slash-separated folder of the ignore file, relative to the root of the pair,
+	// "" for the root folder and the global ignore file.
+	dir    string
+	negate bool
+	// dirOnly rules only match folders: their pattern ends with a slash.
+	dirOnly bool
+	// anchored rules match the path relative to dir: their pattern contains a slash. The others
+	// match the names of the files and folders at any depth.
+	anchored bool
+	re       *regexp.Regexp
+}
+
+func (r ignoreRule) match(p string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+
+	if r.dir != "" {
+		if !strings.HasPrefix(p, r.dir+"/") {
+			return false
+		}
+		p = strings.TrimPrefix(p, r.dir+"/")
+	}
+
+	if !r.anchored {
+		p = path.Base(p)
+	}
+
+	return r.re.MatchString(p)
+}
+
+// ignorer tells which paths of a sync pair are left out of the sync by the ignore files. It is
+// safe for concurrent use, such as by the scans of both sides, which run alongside each other.
+type ignorer struct {
+	mu        sync.Mutex
+	localRoot string
+	// selection holds the selected folders of the pair: the other paths are ignored.
+	selection selection
+	global    []ignoreRule
+	// dirs holds the rules of the ignore files of the local folders, as they are read.
+	dirs map[string][]ignoreRule
+	// ignoredDirs holds whether the folders are ignored, as they are checked.
+	ignoredDirs map[string]bool
+}
+
+// newIgnorer creates an ignorer for the local folder localRoot, with the rules of the global
+// ignore file globalFile, if not empty, and the selected folders sel.
+func newIgnorer(localRoot, globalFile string, sel selection) (*ignorer, error) {
+	ig := &ignorer{
+		localRoot:   localRoot,
+		selection:   sel,
+		dirs:        map[string][]ignoreRule{},
+		ignoredDirs: map[string]bool{},
+	}
+
+	if globalFile != "" {
+		var err error
+		ig.global, err = readIgnoreFile(globalFile, "")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ig, nil
+}
+
+// ignored returns whether the file or folder p, a slash-separated path relative to the roots of
+// the pair, is left out of the sync. The contents of an ignored folder are ignored.
+func (ig *ignorer) ignored(p string, isDir bool) bool {
+	ig.mu.Lock()
+	defer ig.mu.Unlock()
+
+	return ig.ignoredLocked(p, isDir)
+}
+
+// ignoredLocked is ignored, with ig.mu held.
+func (ig *ignorer) ignoredLocked(p string, isDir bool) bool {
+	if !ig.selection.selected(p, isDir) {
+		return true
+	}
+
+	if dir := path.Dir(p); dir != "." && ig.ignoredDir(dir) {
+		return true
+	}
+
+	return ig.match(p, isDir)
+}
+
+func (ig *ignorer) ignoredDir(dir string) bool {
+	ignored, ok := ig.ignoredDirs[dir]
+	if !ok {
+		ignored = ig.ignoredLocked(dir, true)
+		ig.ignoredDirs[dir] = ignored
+	}
+
+	return ignored
+}
+
+// match returns whether the last rule that matches p ignores it: the rules of the global ignore
+// file come first, then those of the ignore files of the folders of p, from the root down.
+func (ig *ignorer) match(p string, isDir bool) bool {
+	ignored := false
+
+	for _, rules := range ig.rules(p) {
+		for _, r := range rules {
+			if r.match(p, isDir) {
+				ignored = !r.negate
+			}
+		}
+	}
+
+	return ignored
+}
+
+// rules returns the rules that apply to p, by ignore file.
+func (ig *ignorer) rules(p string) [][]ignoreRule {
+	rules := [][]ignoreRule{ig.global, ig.dirRules("")}
+
+	dir := ""
+	for _, name := range strings.Split(path.Dir(p), "/") {
+		if name == "." {
+			break
+		}
+		dir = path.Join(dir, name)
+		rules = append(rules, ig.dirRules(dir))
+	}
+
+	return rules
+}
+
+func (ig *ignorer) dirRules(dir string) []ignoreRule {
+	rules, ok := ig.dirs[dir]
+	if ok {
+		return rules
+	}
+
+	// an ignore file that cannot be read is ignored, like a file that does not exist.
+	rules, _ = readIgnoreFile(filepath.Join(ig.localRoot, filepath.FromSlash(dir), IgnoreFileName), dir)
+	ig.dirs[dir] = rules
+
+	return rules
+}
+
+// readIgnoreFile reads the rules of the ignore file name, which applies to the folder dir.
+func readIgnoreFile(name, dir string) ([]ignoreRule, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var rules []ignoreRule
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		r, ok := parseIgnoreRule(sc.Text(), dir)
+		if ok {
+			rules = append(rules, r)
+		}
+	}
+
+	return rules, errors.Wrapf(sc.Err(), "reading %s", name)
+}
+
+// parseIgnoreRule parses a line of an ignore file of the folder dir. It returns false for the
+// blank lines, the comments and the invalid patterns.
+func parseIgnoreRule(line, dir string) (ignoreRule, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	r := ignoreRule{dir: dir}
+
+	if strings.HasPrefix(line, "!") {
+		r.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+
+	if strings.Contains(line, "/") {
+		r.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+
+	if line == "" {
+		return ignoreRule{}, false
+	}
+
+	re, err := regexp.Compile("^" + globToRegexp(line) + "$")
+	if err != nil {
+		// such as a character class of an invalid range: the line matches nothing.
+		return ignoreRule{}, false
+	}
+	r.re = re
+
+	return r, true
+}
+
+// globToRegexp translates the glob pattern of an ignore file to a regular expression: "*" and
+// "?" do not match slashes, and "**" matches any number of folders.
+func globToRegexp(pattern string) string {
+	var sb strings.Builder
+
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/") && (i == 0 || pattern[i-1] == '/'):
+			sb.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**") && i+2 == len(pattern) && (i == 0 || pattern[i-1] == '/'):
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			sb.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		case c == '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,68 @@
+package tracker
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIgnorer(t *testing.T) {
+	root := t.TempDir()
+
+	global := filepath.Join(t.TempDir(), "ignore")
+	require.NoError(t, os.WriteFile(global, []byte("*.tmp\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(root, IgnoreFileName), []byte(`
+# comments and blank lines are skipped
+node_modules/
+/build
+docs/**/*.pdf
+!keep.tmp
+cache?
+[Tt]humbs.db
+\#notes
+`), 0o600))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "src"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "src", IgnoreFileName), []byte("*.log\n!important.tmp\n"), 0o600))
+
+	ig, err := newIgnorer(root, global, nil)
+	require.NoError(t, err)
+
+	tests := []struct {
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{"a.txt", false, false},
+		{"a.tmp", false, true},
+		{"keep.tmp", false, false},
+		{"src/b.tmp", false, true},
+		{"src/important.tmp", false, false},
+		{"src/b.log", false, true},
+		{"b.log", false, false},
+		{"node_modules", true, true},
+		{"node_modules/x/y.js", false, true},
+		{"src/node_modules", true, true},
+		{"node_modules", false, false},
+		{"build", true, true},
+		{"build/out.bin", false, true},
+		{"src/build", true, false},
+		{"docs/a.pdf", false, true},
+		{"docs/2024/jan/a.pdf", false, true},
+		{"src/docs/a.pdf", false, false},
+		{"cache1", true, true},
+		{"cache12", true, false},
+		{"thumbs.db", false, true},
+		{"Thumbs.db", false, true},
+		{"#notes", false, true},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.ignored, ig.ignored(tt.path, tt.isDir), tt.path)
+	}
+
+	_, err = newIgnorer(root, filepath.Join(root, "missing"), nil)
+	assert.Error(t, err)
+}
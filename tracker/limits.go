@@ -0,0 +1,245 @@
+package tracker
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// maxLimitedRead is the largest read of the readers of a Limiter, so that a large buffer does not
+// hold the bandwidth of its direction for long at once.
+const maxLimitedRead = 32 << 10
+
+// TransferLimits are the limits of the transfers of the syncs, independently for the uploads and
+// the downloads.
+type TransferLimits struct {
+	// UploadConcurrency and DownloadConcurrency are the numbers of files transferred at the same
+	// time in each direction. 0 transfers one file at a time.
+	UploadConcurrency   int `json:"upload_concurrency,omitempty"`
+	DownloadConcurrency int `json:"download_concurrency,omitempty"`
+	// UploadRate and DownloadRate are the bandwidths of each direction, in bytes per second,
+	// shared by the files transferred at the same time. 0 is no limit.
+	UploadRate   int64 `json:"upload_rate,omitempty"`
+	DownloadRate int64 `json:"download_rate,omitempty"`
+}
+
+// Validate checks the limits.
+func (tl TransferLimits) Validate() error {
+	if tl.UploadConcurrency < 0 || tl.DownloadConcurrency < 0 {
+		return errors.Errorf("negative transfer concurrency: %d uploads, %d downloads", tl.UploadConcurrency, tl.DownloadConcurrency)
+	}
+	if tl.UploadRate < 0 || tl.DownloadRate < 0 {
+		return errors.Errorf("negative transfer rate: %d uploads, %d downloads", tl.UploadRate, tl.DownloadRate)
+	}
+
+	return nil
+}
+
+// direction is the direction of a transfer.
+type direction int
+
+const (
+	directionUpload direction = iota
+	directionDownload
+)
+
+// directionState is the state of the transfers of a direction.
+type directionState struct {
+	active int
+	// next is the time at which the bytes transferred so far are within the rate.
+	next time.Time
+}
+
+// Limiter applies TransferLimits to the transfers of the syncs (see WithLimiter). Its limits can
+// be changed while the syncs run, including over HTTP (see ServeHTTP). It is safe for concurrent
+// use.
+type Limiter struct {
+	mu     sync.Mutex
+	limits TransferLimits
+	state  [2]directionState
+	// released is closed, and replaced, when a transfer slot may have become available.
+	released chan struct{}
+}
+
+// NewLimiter creates a new Limiter of the limits tl, which must be valid.
+func NewLimiter(tl TransferLimits) *Limiter {
+	return &Limiter{
+		limits:   tl,
+		released: make(chan struct{}),
+	}
+}
+
+// Limits returns the current limits.
+func (l *Limiter) Limits() TransferLimits {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.limits
+}
+
+// SetLimits replaces the limits. The transfers in progress are not stopped by a lower
+// concurrency: the next ones wait for them to complete.
+func (l *Limiter) SetLimits(tl TransferLimits) error {
+	err := tl.Validate()
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.limits = tl
+	l.wakeUp()
+
+	return nil
+}
+
+// ServeHTTP serves the current limits as JSON, and replaces them with those of the JSON body of
+// a PUT request.
+func (l *Limiter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+
+	case http.MethodPut:
+		var tl TransferLimits
+
+		err := json.NewDecoder(r.Body).Decode(&tl)
+		if err == nil {
+			err = l.SetLimits(tl)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(l.Limits())
+}
+
+// concurrency returns the number of transfers allowed at the same time in the direction dir.
+// l.mu must be held.
+func (l *Limiter) concurrency(dir direction) int {
+	n := l.limits.UploadConcurrency
+	if dir == directionDownload {
+		n = l.limits.DownloadConcurrency
+	}
+	if n < 1 {
+		return 1
+	}
+
+	return n
+}
+
+// rate returns the bandwidth of the direction dir. l.mu must be held.
+func (l *Limiter) rate(dir direction) int64 {
+	if dir == directionDownload {
+		return l.limits.DownloadRate
+	}
+
+	return l.limits.UploadRate
+}
+
+// wakeUp wakes up the transfers that wait for a slot. l.mu must be held.
+func (l *Limiter) wakeUp() {
+	close(l.released)
+	l.released = make(chan struct{})
+}
+
+// acquire waits for a transfer slot in the direction dir, or until ctx is done. It returns the
+// function that releases the slot.
+func (l *Limiter) acquire(ctx context.Context, dir direction) (func(), error) {
+	for {
+		l.mu.Lock()
+		if l.state[dir].active < l.concurrency(dir) {
+			l.state[dir].active++
+			l.mu.Unlock()
+
+			return func() { l.release(dir) }, nil
+		}
+		released := l.released
+		l.mu.Unlock()
+
+		select {
+		case <-released:
+		case <-ctx.Done():
+			return nil, errors.WithStack(ctx.Err())
+		}
+	}
+}
+
+func (l *Limiter) release(dir direction) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.state[dir].active--
+	l.wakeUp()
+}
+
+// reader returns a reader of r whose reads are limited by the bandwidth of the direction dir.
+func (l *Limiter) reader(ctx context.Context, dir direction, r io.Reader) io.Reader {
+	return &limitedReader{ctx: ctx, r: r, l: l, dir: dir}
+}
+
+// wait waits until the n bytes just transferred in the direction dir are within its rate.
+func (l *Limiter) wait(ctx context.Context, dir direction, n int) error {
+	l.mu.Lock()
+	rate := l.rate(dir)
+	if rate <= 0 {
+		l.mu.Unlock()
+		return nil
+	}
+
+	st := &l.state[dir]
+	now := time.Now()
+	if st.next.Before(now) {
+		st.next = now
+	}
+	st.next = st.next.Add(time.Duration(n) * time.Second / time.Duration(rate))
+	d := st.next.Sub(now)
+	l.mu.Unlock()
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return errors.WithStack(ctx.Err())
+	}
+}
+
+// limitedReader is a reader limited by the bandwidth of a direction of a Limiter. The rate is
+// read at each read, so that a change applies to the transfers in progress.
+type limitedReader struct {
+	ctx context.Context
+	r   io.Reader
+	l   *Limiter
+	dir direction
+}
+
+func (lr *limitedReader) Read(p []byte) (int, error) {
+	if len(p) > maxLimitedRead {
+		p = p[:maxLimitedRead]
+	}
+
+	n, err := lr.r.Read(p)
+	if n > 0 {
+		if waitErr := lr.l.wait(lr.ctx, lr.dir, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+
+	return n, err
+}
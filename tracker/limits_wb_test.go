@@ -0,0 +1,114 @@
+package tracker
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter_Acquire(t *testing.T) {
+	ctx := context.Background()
+	l := NewLimiter(TransferLimits{UploadConcurrency: 2})
+
+	release1, err := l.acquire(ctx, directionUpload)
+	require.NoError(t, err)
+	release2, err := l.acquire(ctx, directionUpload)
+	require.NoError(t, err)
+
+	// the downloads are limited independently of the uploads.
+	releaseDownload, err := l.acquire(ctx, directionDownload)
+	require.NoError(t, err)
+	defer releaseDownload()
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	_, err = l.acquire(timeoutCtx, directionUpload)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	acquired := make(chan func())
+	go func() {
+		release, err := l.acquire(ctx, directionUpload)
+		assert.NoError(t, err)
+		acquired <- release
+	}()
+
+	release1()
+	(<-acquired)()
+
+	// a higher concurrency lets the waiting transfers start at once.
+	go func() {
+		release, err := l.acquire(ctx, directionUpload)
+		assert.NoError(t, err)
+		acquired <- release
+	}()
+	go func() {
+		release, err := l.acquire(ctx, directionUpload)
+		assert.NoError(t, err)
+		acquired <- release
+	}()
+	require.NoError(t, l.SetLimits(TransferLimits{UploadConcurrency: 3}))
+	(<-acquired)()
+	(<-acquired)()
+	release2()
+}
+
+func TestLimiter_Reader(t *testing.T) {
+	ctx := context.Background()
+	l := NewLimiter(TransferLimits{DownloadRate: 100 << 10})
+
+	start := time.Now()
+	data, err := io.ReadAll(l.reader(ctx, directionDownload, strings.NewReader(strings.Repeat("x", 20<<10))))
+	require.NoError(t, err)
+	assert.Len(t, data, 20<<10)
+	assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+
+	// the uploads are not limited.
+	start = time.Now()
+	_, err = io.ReadAll(l.reader(ctx, directionUpload, strings.NewReader(strings.Repeat("x", 20<<10))))
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+}
+
+func TestLimiter_ServeHTTP(t *testing.T) {
+	l := NewLimiter(TransferLimits{UploadConcurrency: 2})
+
+	serve := func(method, body string) (int, string) {
+		t.Helper()
+
+		rec := httptest.NewRecorder()
+		l.ServeHTTP(rec, httptest.NewRequest(method, "/limits", strings.NewReader(body)))
+
+		return rec.Code, rec.Body.String()
+	}
+
+	code, body := serve(http.MethodGet, "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, `{"upload_concurrency": 2}`, body)
+
+	code, body = serve(http.MethodPut, `{"download_concurrency": 4, "upload_rate": 1024}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, `{"download_concurrency": 4, "upload_rate": 1024}`, body)
+	assert.Equal(t, TransferLimits{DownloadConcurrency: 4, UploadRate: 1024}, l.Limits())
+
+	for _, body := range []string{`{"upload_rate": -1}`, `{"upload_concurrency": "2"}`, `{`} {
+		code, _ = serve(http.MethodPut, body)
+		assert.Equal(t, http.StatusBadRequest, code, body)
+	}
+	assert.Equal(t, TransferLimits{DownloadConcurrency: 4, UploadRate: 1024}, l.Limits())
+
+	code, _ = serve(http.MethodDelete, "")
+	assert.Equal(t, http.StatusMethodNotAllowed, code)
+
+	var tl TransferLimits
+	_, body = serve(http.MethodGet, "")
+	require.NoError(t, json.Unmarshal([]byte(body), &tl))
+	assert.Equal(t, l.Limits(), tl)
+}
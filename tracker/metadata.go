@@ -0,0 +1,147 @@
+package tracker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// MetadataFileName is the name of the file, at the root of the pCloud folder of a pair, that
+// holds the metadata of the files that pCloud does not keep, such as their modes (see
+// WithFileModes). It is not synced.
+const MetadataFileName = ".pcloud-metadata"
+
+// fileMetadata is the contents of the metadata file.
+type fileMetadata struct {
+	// Modes are the permission bits of the files, in octal, by their slash-separated paths
+	// relative to the roots of the pair.
+	Modes map[string]string `json:"modes"`
+}
+
+// fileModes holds the modes of the files of a pair during a sync, as recorded in the metadata
+// file. It is safe for concurrent use.
+type fileModes struct {
+	mu      sync.Mutex
+	modes   map[string]os.FileMode
+	changed bool
+}
+
+// loadFileModes reads the modes of the files from the metadata file of the pCloud folder, which
+// may not exist yet.
+func (s *TwoWay) loadFileModes(ctx context.Context) (*fileModes, error) {
+	fm := &fileModes{modes: map[string]os.FileMode{}}
+
+	rc, err := s.remote.Get(ctx, MetadataFileName, 0)
+	if errors.Is(err, os.ErrNotExist) {
+		return fm, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rc.Close() }()
+
+	var md fileMetadata
+
+	err = json.NewDecoder(rc).Decode(&md)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading the metadata file")
+	}
+
+	for p, mode := range md.Modes {
+		m, err := strconv.ParseUint(mode, 8, 32)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading the mode of %s in the metadata file", p)
+		}
+		fm.modes[p] = os.FileMode(m).Perm()
+	}
+
+	return fm, nil
+}
+
+// saveFileModes writes the modes of the files to the metadata file, when they changed.
+func (s *TwoWay) saveFileModes(ctx context.Context, fm *fileModes) error {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+
+	if !fm.changed {
+		return nil
+	}
+
+	md := fileMetadata{Modes: make(map[string]string, len(fm.modes))}
+	for p, mode := range fm.modes {
+		md.Modes[p] = "0" + strconv.FormatUint(uint64(mode), 8)
+	}
+
+	data, err := json.MarshalIndent(md, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	_, err = s.remote.Put(ctx, MetadataFileName, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	fm.changed = false
+
+	return nil
+}
+
+// get returns the mode of the file p, and whether it is known.
+func (fm *fileModes) get(p string) (os.FileMode, bool) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+
+	mode, ok := fm.modes[p]
+
+	return mode, ok
+}
+
+// set records mode as the mode of the file p.
+func (fm *fileModes) set(p string, mode os.FileMode) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+
+	if m, ok := fm.modes[p]; ok && m == mode.Perm() {
+		return
+	}
+	fm.modes[p] = mode.Perm()
+	fm.changed = true
+}
+
+// move moves the modes of the file or folder from, and of its contents, to the path to.
+func (fm *fileModes) move(from, to string) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+
+	moved := map[string]os.FileMode{}
+	for p, mode := range fm.modes {
+		if p == from || strings.HasPrefix(p, from+"/") {
+			moved[to+strings.TrimPrefix(p, from)] = mode
+			delete(fm.modes, p)
+		}
+	}
+
+	for p, mode := range moved {
+		fm.modes[p] = mode
+		fm.changed = true
+	}
+}
+
+// remove removes the modes of the file or folder p, and of its contents.
+func (fm *fileModes) remove(p string) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+
+	for q := range fm.modes {
+		if q == p || strings.HasPrefix(q, p+"/") {
+			delete(fm.modes, q)
+			fm.changed = true
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package tracker
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Monitor holds the Health and the Progress of the syncs of several pairs, such as those of a
+// daemon, and serves them over HTTP: their health (see ServeHealth), their progress (see
+// ServeStatus) and their metrics for Prometheus (see ServeMetrics). It is safe for concurrent use.
+type Monitor struct {
+	mu    sync.Mutex
+	pairs map[string]*monitoredPair
+}
+
+// monitoredPair is a pair of a Monitor.
+type monitoredPair struct {
+	health   *Health
+	progress *Progress
+}
+
+// NewMonitor creates a new Monitor, without pairs.
+func NewMonitor() *Monitor {
+	return &Monitor{pairs: map[string]*monitoredPair{}}
+}
+
+// Pair returns the Health and the Progress of the pair pairName, which are created on the first
+// call: the syncs of the pair report to them (see WithSyncReport and WithProgress).
+func (m *Monitor) Pair(pairName string) (*Health, *Progress) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	mp, ok := m.pairs[pairName]
+	if !ok {
+		mp = &monitoredPair{health: NewHealth(), progress: NewProgress()}
+		m.pairs[pairName] = mp
+	}
+
+	return mp.health, mp.progress
+}
+
+// Retain removes the pairs but those of pairNames, such as those that are no longer synced.
+func (m *Monitor) Retain(pairNames ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	kept := make(map[string]*monitoredPair, len(pairNames))
+	for _, name := range pairNames {
+		if mp, ok := m.pairs[name]; ok {
+			kept[name] = mp
+		}
+	}
+	m.pairs = kept
+}
+
+// names returns the names of the pairs, sorted, with the pairs by name.
+func (m *Monitor) names() ([]string, map[string]monitoredPair) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	names := make([]string, 0, len(m.pairs))
+	pairs := make(map[string]monitoredPair, len(m.pairs))
+	for name, mp := range m.pairs {
+		names = append(names, name)
+		pairs[name] = *mp
+	}
+	sort.Strings(names)
+
+	return names, pairs
+}
+
+// MonitorHealth is the health of the pairs of a Monitor, as served by ServeHealth.
+type MonitorHealth struct {
+	// Status is "failing" when the last sync of one of the pairs failed, and "ok" otherwise.
+	Status string                  `json:"status"`
+	Pairs  map[string]HealthStatus `json:"pairs"`
+}
+
+// ServeHealth serves the health of the pairs as JSON, with the status 503 Service Unavailable
+// when the last sync of one of them failed.
+func (m *Monitor) ServeHealth(w http.ResponseWriter, _ *http.Request) {
+	names, pairs := m.names()
+
+	mh := MonitorHealth{Status: "ok", Pairs: make(map[string]HealthStatus, len(names))}
+	for _, name := range names {
+		st := pairs[name].health.Status()
+		if st.Status != "ok" {
+			mh.Status = st.Status
+		}
+		mh.Pairs[name] = st
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if mh.Status != "ok" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	_ = json.NewEncoder(w).Encode(mh)
+}
+
+// ServeStatus serves the progress of the syncs of the pairs as JSON, by pair name.
+func (m *Monitor) ServeStatus(w http.ResponseWriter, _ *http.Request) {
+	names, pairs := m.names()
+
+	status := make(map[string]SyncStatus, len(names))
+	for _, name := range names {
+		status[name] = pairs[name].progress.Status()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(status)
+}
+
+// metric is a metric of ServeMetrics, with its samples.
+type metric struct {
+	name, typ, help string
+	samples         []sample
+}
+
+// sample is a sample of a metric.
+type sample struct {
+	// labels are the labels of the sample, such as `pair="notes",direction="upload"`.
+	labels string
+	value  float64
+}
+
+// labelEscaper escapes the values of the labels of the metrics.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// ServeMetrics serves the health and the progress of the syncs of the pairs as metrics in the
+// text exposition format of Prometheus, with the label pair, so that they can be scraped and
+// alerted on, such as when the last successful sync of a pair is too old.
+func (m *Monitor) ServeMetrics(w http.ResponseWriter, _ *http.Request) {
+	metrics := []*metric{
+		{name: "pcloud_sync_runs_total", typ: "counter", help: "Number of syncs."},
+		{name: "pcloud_sync_failures_total", typ: "counter", help: "Number of syncs that failed."},
+		{name: "pcloud_sync_consecutive_failures", typ: "gauge", help: "Number of syncs that failed since the last successful one."},
+		{name: "pcloud_sync_file_errors_total", typ: "counter", help: "Number of changes of files that failed."},
+		{name: "pcloud_sync_last_run_timestamp_seconds", typ: "gauge", help: "Time of the end of the last sync, 0 before the first one."},
+		{name: "pcloud_sync_last_success_timestamp_seconds", typ: "gauge", help: "Time of the end of the last successful sync, 0 before the first one."},
+		{name: "pcloud_sync_applying", typ: "gauge", help: "Whether a sync is applying its changes."},
+		{name: "pcloud_sync_files_pending", typ: "gauge", help: "Number of changes of the running sync that are not applied yet."},
+		{name: "pcloud_sync_changes_queued", typ: "gauge", help: "Number of changed local paths waiting for the next sync."},
+		{name: "pcloud_sync_transferred_bytes_total", typ: "counter", help: "Number of bytes transferred by the syncs."},
+	}
+
+	names, pairs := m.names()
+
+	for _, name := range names {
+		h := pairs[name].health.Status()
+		p := pairs[name].progress.Status()
+
+		var lastSync, lastSuccess, applying float64
+		if h.LastSync != nil {
+			lastSync = float64(h.LastSync.Unix())
+		}
+		if h.LastSuccess != nil {
+			lastSuccess = float64(h.LastSuccess.Unix())
+		}
+		if p.Operation == OperationApplying {
+			applying = 1
+		}
+
+		labels := `pair="` + labelEscaper.Replace(name) + `"`
+
+		for i, v := range []float64{
+			float64(h.Syncs),
+			float64(h.Failures),
+			float64(h.ConsecutiveFailures),
+			float64(h.FileErrors),
+			lastSync,
+			lastSuccess,
+			applying,
+			float64(p.Pending),
+			float64(p.Queued),
+		} {
+			metrics[i].samples = append(metrics[i].samples, sample{labels: labels, value: v})
+		}
+
+		transferred := metrics[len(metrics)-1]
+		transferred.samples = append(transferred.samples,
+			sample{labels: labels + `,direction="upload"`, value: float64(p.UploadedBytes)},
+			sample{labels: labels + `,direction="download"`, value: float64(p.DownloadedBytes)},
+		)
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	writeMetrics(w, metrics)
+}
+
+// writeMetrics writes the metrics to w, each with its HELP and TYPE followed by its samples.
+func writeMetrics(w io.Writer, metrics []*metric) {
+	var b strings.Builder
+
+	for _, m := range metrics {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", m.name, m.help, m.name, m.typ)
+
+		for _, s := range m.samples {
+			fmt.Fprintf(&b, "%s{%s} %s\n", m.name, s.labels, strconv.FormatFloat(s.value, 'f', -1, 64))
+		}
+	}
+
+	_, _ = io.WriteString(w, b.String())
+}
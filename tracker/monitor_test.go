@@ -0,0 +1,72 @@
+package tracker_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/tracker"
+)
+
+func TestMonitor(t *testing.T) {
+	ctx := context.Background()
+	m := tracker.NewMonitor()
+
+	health, progress := m.Pair("test")
+	srv, _, local, s := newTestTwoWay(t, tracker.WithProgress(progress))
+
+	writeLocalFile(t, local, "a.txt", "aaa")
+	_, err := srv.WriteFile("/Sync/b.txt", []byte("bb"))
+	require.NoError(t, err)
+
+	health.Report(s.Sync(ctx))
+
+	other, _ := m.Pair(`other "pair"`)
+	other.Report(nil, errors.New("boom"))
+
+	get := func(serve http.HandlerFunc) *httptest.ResponseRecorder {
+		t.Helper()
+
+		rec := httptest.NewRecorder()
+		serve(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		return rec
+	}
+
+	rec := get(m.ServeStatus)
+	var status map[string]tracker.SyncStatus
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+	require.Contains(t, status, "test")
+	assert.Equal(t, tracker.OperationIdle, status["test"].Operation)
+	assert.Zero(t, status["test"].Pending)
+	assert.EqualValues(t, 3, status["test"].UploadedBytes)
+	assert.EqualValues(t, 2, status["test"].DownloadedBytes)
+
+	rec = get(m.ServeHealth)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	var mh tracker.MonitorHealth
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &mh))
+	assert.Equal(t, "failing", mh.Status)
+	assert.Equal(t, "ok", mh.Pairs["test"].Status)
+	assert.Equal(t, "boom", mh.Pairs[`other "pair"`].LastError)
+
+	body := get(m.ServeMetrics).Body.String()
+	assert.Contains(t, body, "# TYPE pcloud_sync_runs_total counter\npcloud_sync_runs_total{pair=\"other \\\"pair\\\"\"} 1\npcloud_sync_runs_total{pair=\"test\"} 1\n")
+	assert.Contains(t, body, "\npcloud_sync_failures_total{pair=\"test\"} 0\n")
+	assert.Contains(t, body, "\npcloud_sync_consecutive_failures{pair=\"other \\\"pair\\\"\"} 1\n")
+	assert.Contains(t, body, "\npcloud_sync_files_pending{pair=\"test\"} 0\n")
+	assert.Contains(t, body, "\npcloud_sync_transferred_bytes_total{pair=\"test\",direction=\"upload\"} 3\npcloud_sync_transferred_bytes_total{pair=\"test\",direction=\"download\"} 2\n")
+	assert.Regexp(t, `\npcloud_sync_last_success_timestamp_seconds\{pair="test"\} [1-9][0-9]+\n`, body)
+
+	// the pairs that are no longer synced are removed.
+	m.Retain("test")
+
+	rec = get(m.ServeHealth)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
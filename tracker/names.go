@@ -0,0 +1,308 @@
+package tracker
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/seborama/pcloud-sdk/tracker/db"
+)
+
+// The syncs compare the files of both sides by their canonical paths, rather than by their names:
+//   - the names are in the NFC normal form of Unicode, so that a name decomposed by macOS (NFD)
+//     is the same file as its composed form in pCloud, rather than a new file on each side.
+//   - when the local folder is case-insensitive, such as on macOS and Windows, a local path is
+//     that of the pCloud file or folder that differs from it by case only, since the local folder
+//     cannot hold both.
+//
+// The state of the pair is held by canonical path, while the files are read and written under
+// their names on each side (see localPath and remotePath).
+
+// canonicalPath returns the path p in the NFC normal form of Unicode.
+func canonicalPath(p string) string {
+	return norm.NFC.String(p)
+}
+
+// pathOrder returns the order of the paths of the syncs, in which the paths that may be the same
+// file or folder have the same key: those that differ by their Unicode form only, and by case only
+// too when the local folder is case-insensitive.
+func (s *TwoWay) pathOrder() db.PathOrder {
+	if s.localCaseInsensitive() {
+		return db.ByFoldedPath
+	}
+
+	return db.ByCanonicalPath
+}
+
+// localSortKey is the filesystem.SortKeyFunc of the scans of the local folder, which walk it in
+// the order of the paths of the running sync. Of the names of the same key, the canonical one
+// comes first.
+func (s *TwoWay) localSortKey(name string) (string, int) {
+	rank := 0
+	if canonicalPath(name) != name {
+		rank = 1
+	}
+
+	return s.order.Key(name), rank
+}
+
+// merge returns the merge of the state streamed by each with the entries of both sides streamed by
+// local and remote, by canonical path (see canonicalGroup). It sets the names of the entries whose
+// canonical paths differ from their paths, the paths of the entries that are left out of the sync
+// since the local folder cannot hold them along other ones, and the number of entries scanned.
+func (s *TwoWay) merge(each stateFunc, local, remote entryFunc) mergeFunc {
+	return func(fn func(p string, b *db.SyncStateEntry, l, r *db.FSEntry) error) error {
+		s.localNames = map[string]string{}
+		s.remoteNames = map[string]string{}
+		s.nameConflicts = nil
+		s.scanned = 0
+
+		err := mergeState(s.order, each, local, remote, func(g *pathGroup) error {
+			s.scanned += len(g.local) + len(g.remote)
+			return s.canonicalGroup(g, fn)
+		})
+
+		sort.Strings(s.nameConflicts)
+
+		return err
+	}
+}
+
+// canonicalEntry is an entry of a side, by its path and its canonical path.
+type canonicalEntry struct {
+	canonical string
+	path      string
+	entry     db.FSEntry
+}
+
+// canonicalGroup calls fn with each canonical path of the group g, sorted, with its state and its
+// entries on both sides. The canonical path of an entry is its path in the NFC normal form of
+// Unicode, while a local path takes the canonical path of the pCloud entry, or else of the state,
+// that differs from it by case only when the local folder is case-insensitive. The paths left out
+// of the sync are the files of a side whose names differ by their Unicode form only from another
+// one, and the pCloud files and folders that differ by case only from another one when the local
+// folder is case-insensitive.
+func (s *TwoWay) canonicalGroup(g *pathGroup, fn func(p string, b *db.SyncStateEntry, l, r *db.FSEntry) error) error {
+	insensitive := s.order == db.ByFoldedPath
+
+	remote := s.canonicalSide(g.remote, "")
+	if insensitive && len(remote) > 1 {
+		remote = s.foldRemote(g, remote)
+	}
+
+	folded := ""
+	if insensitive {
+		for _, b := range g.state {
+			if !hasLocalPath(g, b.Path) {
+				folded = b.Path
+			}
+		}
+		for _, e := range remote {
+			folded = e.canonical
+		}
+	}
+
+	local := s.canonicalSide(g.local, folded)
+
+	paths := make([]string, 0, len(g.state)+len(local)+len(remote))
+	for _, b := range g.state {
+		paths = append(paths, b.Path)
+	}
+	for _, e := range local {
+		setName(s.localNames, e)
+		paths = append(paths, e.canonical)
+	}
+	for _, e := range remote {
+		setName(s.remoteNames, e)
+		paths = append(paths, e.canonical)
+	}
+	sort.Strings(paths)
+
+	for i, p := range paths {
+		if i > 0 && paths[i-1] == p {
+			continue
+		}
+
+		var (
+			b    *db.SyncStateEntry
+			l, r *db.FSEntry
+		)
+		for i := range g.state {
+			if g.state[i].Path == p {
+				b = &g.state[i]
+				break
+			}
+		}
+		l = findCanonical(local, p)
+		r = findCanonical(remote, p)
+
+		err := fn(p, b, l, r)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// canonicalSide returns the entries of a side by their canonical paths, which are folded when it
+// is not empty. Of the entries whose canonical paths are the same, the canonical one is kept, or
+// else the first one in the order of the paths, and the others are name conflicts.
+func (s *TwoWay) canonicalSide(entries []pathEntry, folded string) []canonicalEntry {
+	sorted := append([]pathEntry{}, entries...)
+	sort.Slice(sorted, func(i, j int) bool {
+		ci, cj := canonicalPath(sorted[i].path) == sorted[i].path, canonicalPath(sorted[j].path) == sorted[j].path
+		if ci != cj {
+			return ci
+		}
+		return sorted[i].path < sorted[j].path
+	})
+
+	canonical := make([]canonicalEntry, 0, len(sorted))
+
+	for _, e := range sorted {
+		c := canonicalPath(e.path)
+		if folded != "" {
+			c = folded
+		}
+
+		if findCanonical(canonical, c) != nil {
+			s.nameConflicts = append(s.nameConflicts, e.path)
+			continue
+		}
+
+		canonical = append(canonical, canonicalEntry{canonical: c, path: e.path, entry: e.entry})
+	}
+
+	return canonical
+}
+
+// foldRemote returns the one of the remote entries, which differ by case only, that is kept: the
+// one of the state of the pair, or else the one of the local path, or else the first one in the
+// order of the paths. The others are name conflicts.
+func (s *TwoWay) foldRemote(g *pathGroup, remote []canonicalEntry) []canonicalEntry {
+	sort.Slice(remote, func(i, j int) bool { return remote[i].canonical < remote[j].canonical })
+
+	keep := 0
+	for i, e := range remote {
+		tracked := false
+		for _, b := range g.state {
+			tracked = tracked || b.Path == e.canonical
+		}
+		if tracked {
+			keep = i
+			break
+		}
+
+		for _, l := range g.local {
+			if canonicalPath(l.path) == e.canonical {
+				keep = i
+			}
+		}
+	}
+
+	for i, e := range remote {
+		if i != keep {
+			s.nameConflicts = append(s.nameConflicts, e.path)
+		}
+	}
+
+	return remote[keep : keep+1]
+}
+
+// hasLocalPath returns whether the group g has a local entry of the path p.
+func hasLocalPath(g *pathGroup, p string) bool {
+	for _, e := range g.local {
+		if e.path == p {
+			return true
+		}
+	}
+
+	return false
+}
+
+// findCanonical returns the entry of the canonical path p of entries, or nil.
+func findCanonical(entries []canonicalEntry, p string) *db.FSEntry {
+	for i := range entries {
+		if entries[i].canonical == p {
+			return &entries[i].entry
+		}
+	}
+
+	return nil
+}
+
+// setName records the name of the entry e in names, when its path is not that of its canonical
+// path under the names of its parent folders, which are recorded first.
+func setName(names map[string]string, e canonicalEntry) {
+	if actualPath(names, e.canonical) != e.path {
+		names[e.canonical] = e.path
+	}
+}
+
+// actualPath returns the name of the canonical path p on the side of names, where the canonical
+// paths of p and its parent folders are replaced by their names.
+func actualPath(names map[string]string, p string) string {
+	if len(names) == 0 {
+		return p
+	}
+	if n, ok := names[p]; ok {
+		return n
+	}
+
+	dir := path.Dir(p)
+	if dir == "." || dir == "/" {
+		return p
+	}
+
+	return path.Join(actualPath(names, dir), path.Base(p))
+}
+
+// localCaseInsensitive returns whether the local folder is case-insensitive. It is detected once
+// the local root, or the nearest of its parent folders that exists, has a name with letters: it
+// is case-insensitive when that name in another case is the same folder.
+func (s *TwoWay) localCaseInsensitive() bool {
+	if s.caseInsensitive != nil {
+		return *s.caseInsensitive
+	}
+
+	for dir := s.localRoot; ; dir = filepath.Dir(dir) {
+		info, err := os.Stat(dir)
+		if err != nil {
+			if filepath.Dir(dir) == dir {
+				return false
+			}
+			continue
+		}
+
+		name := filepath.Base(dir)
+		swapped := swapCase(name)
+		if swapped != name {
+			other, err := os.Stat(filepath.Join(filepath.Dir(dir), swapped))
+			ci := err == nil && os.SameFile(info, other)
+			if dir == s.localRoot {
+				s.caseInsensitive = &ci
+			}
+			return ci
+		}
+
+		if filepath.Dir(dir) == dir {
+			return false
+		}
+	}
+}
+
+// swapCase returns s with its upper case letters in lower case, and the other way round.
+func swapCase(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsUpper(r) {
+			return unicode.ToLower(r)
+		}
+		return unicode.ToUpper(r)
+	}, s)
+}
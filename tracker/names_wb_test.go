@@ -0,0 +1,102 @@
+package tracker
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/tracker/db"
+)
+
+func TestTwoWay_Merge_Names(t *testing.T) {
+	entries := func(paths ...string) map[string]db.FSEntry {
+		m := map[string]db.FSEntry{}
+		for _, p := range paths {
+			m[p] = db.FSEntry{Name: p}
+		}
+		return m
+	}
+
+	base := sliceState(db.SyncStateEntry{Path: "Docs", IsFolder: true}, db.SyncStateEntry{Path: "Docs/a.txt"}, db.SyncStateEntry{Path: "gone.txt"})
+
+	const nfc, nfd = "\u00e9.txt", "e\u0301.txt"
+	const resumeNFC, resumeNFD = "R\u00e9sum\u00e9", "Re\u0301sume\u0301"
+
+	local := entries("docs", "docs/a.txt", "b.TXT", "Gone.txt", nfd, nfc)
+	remote := entries("Docs", "Docs/a.txt", "B.txt", "readme.md", "README.md", resumeNFD)
+
+	// merge returns the local and remote entries of the merge by their canonical paths.
+	merge := func(s *TwoWay) (map[string]db.FSEntry, map[string]db.FSEntry) {
+		l, r := map[string]db.FSEntry{}, map[string]db.FSEntry{}
+
+		err := s.merge(base, mapEntries(s.order, local), mapEntries(s.order, remote))(func(p string, _ *db.SyncStateEntry, le, re *db.FSEntry) error {
+			if le != nil {
+				l[p] = *le
+			}
+			if re != nil {
+				r[p] = *re
+			}
+			return nil
+		})
+		require.NoError(t, err)
+
+		return l, r
+	}
+
+	// a case-sensitive local folder only has the names of the same Unicode form in common, and
+	// the canonical one of the names that differ by their Unicode form only.
+	sensitive := false
+	s := &TwoWay{caseInsensitive: &sensitive, order: db.ByCanonicalPath}
+
+	l, r := merge(s)
+	assert.Equal(t, entries("docs", "docs/a.txt", "b.TXT", "Gone.txt", nfc), keyedNames(l))
+	assert.Empty(t, s.localNames)
+	assert.Equal(t, []string{nfd}, s.nameConflicts)
+	assert.Contains(t, r, resumeNFC)
+	assert.Contains(t, r, "readme.md")
+	assert.Equal(t, map[string]string{resumeNFC: resumeNFD}, s.remoteNames)
+	assert.Equal(t, resumeNFD+"/cv.txt", s.remoteName(resumeNFC+"/cv.txt"))
+	assert.Equal(t, 12, s.scanned)
+
+	// a case-insensitive local folder has the paths of pCloud, or else of the state, that differ
+	// by case only, and cannot hold the pCloud paths that differ by case only. The names of the
+	// contents of a folder follow that of the folder.
+	insensitive := true
+	s = &TwoWay{caseInsensitive: &insensitive, order: db.ByFoldedPath}
+
+	l, r = merge(s)
+	assert.Equal(t, []string{"B.txt", "Docs", "Docs/a.txt", "gone.txt", nfc}, mapKeys(l))
+	assert.Equal(t, map[string]string{"Docs": "docs", "B.txt": "b.TXT", "gone.txt": "Gone.txt"}, s.localNames)
+	assert.Equal(t, "docs/a.txt", actualPath(s.localNames, "Docs/a.txt"))
+	assert.Equal(t, []string{"B.txt", "Docs", "Docs/a.txt", "README.md", resumeNFC}, mapKeys(r))
+	assert.Equal(t, []string{nfd, "readme.md"}, s.nameConflicts)
+	assert.Equal(t, "docs/new.txt", actualPath(s.localNames, "Docs/new.txt"))
+}
+
+func TestSwapCase(t *testing.T) {
+	assert.Equal(t, "pCLOUD \u00c9t\u00c9 1", swapCase("Pcloud \u00e9T\u00e9 1"))
+	assert.Equal(t, "123", swapCase("123"))
+}
+
+// keyedNames returns the entries of m by their names, which the tests set to their paths.
+func keyedNames(m map[string]db.FSEntry) map[string]db.FSEntry {
+	named := map[string]db.FSEntry{}
+	for _, e := range m {
+		named[e.Name] = e
+	}
+
+	return named
+}
+
+// mapKeys returns the keys of m, sorted.
+func mapKeys(m map[string]db.FSEntry) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
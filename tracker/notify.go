@@ -0,0 +1,265 @@
+package tracker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/seborama/pcloud-sdk/tracker/db"
+)
+
+// notifyTimeout is the time given to a notifier to deliver a notification.
+const notifyTimeout = 30 * time.Second
+
+// NotifyEvent is an event of the syncs that the notifiers are notified of.
+type NotifyEvent string
+
+const (
+	// EventCompleted is a successful sync that applied changes: the syncs that found nothing to
+	// sync are not notified.
+	EventCompleted NotifyEvent = "completed"
+	// EventFailed is a failed sync that follows a successful one: the syncs that keep failing
+	// are only notified once, until one succeeds.
+	EventFailed NotifyEvent = "failed"
+	// EventConflicts is a sync that left conflicts untouched.
+	EventConflicts NotifyEvent = "conflicts"
+)
+
+// ParseNotifyEvent parses the name of an event, such as "failed".
+func ParseNotifyEvent(s string) (NotifyEvent, error) {
+	switch e := NotifyEvent(s); e {
+	case EventCompleted, EventFailed, EventConflicts:
+		return e, nil
+	default:
+		return "", errors.Errorf("unknown event '%s': use completed, failed or conflicts", s)
+	}
+}
+
+// Notification is the notification of an event of a sync.
+type Notification struct {
+	Event NotifyEvent `json:"event"`
+	Pair  string      `json:"pair"`
+	Time  time.Time   `json:"time"`
+	// Stats are those of the sync, which are nil when it failed before it applied any change.
+	Stats *SyncStats `json:"stats,omitempty"`
+	// Error is the error of the failed sync.
+	Error string `json:"error,omitempty"`
+}
+
+// Message returns a short description of the notification, for humans.
+func (n Notification) Message() string {
+	switch n.Event {
+	case EventFailed:
+		return "the sync failed: " + n.Error
+	case EventConflicts:
+		return fmt.Sprintf("%d files changed on both sides: %s", len(n.Stats.Conflicts), strings.Join(n.Stats.Conflicts, ", "))
+	default:
+		return fmt.Sprintf("%d files uploaded, %d downloaded, %d deleted, %d moved",
+			n.Stats.Uploaded, n.Stats.Downloaded, n.Stats.DeletedLocal+n.Stats.DeletedRemote, n.Stats.MovedLocal+n.Stats.MovedRemote)
+	}
+}
+
+// Notifier delivers the notifications of the events of the syncs.
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// CommandNotifier notifies by running a command, with the notification as JSON on its standard
+// input. The event, the pair and the message of the notification are also set in the environment
+// variables PCLOUD_EVENT, PCLOUD_PAIR and PCLOUD_MESSAGE of the command.
+type CommandNotifier struct {
+	// Command is the name of the program to run, followed by its arguments.
+	Command []string
+}
+
+// Notify runs the command.
+func (cn *CommandNotifier) Notify(ctx context.Context, n Notification) error {
+	if len(cn.Command) == 0 {
+		return errors.New("the command is missing")
+	}
+
+	data, err := json.Marshal(n)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	cmd := exec.CommandContext(ctx, cn.Command[0], cn.Command[1:]...) // nolint: gosec
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Env = append(os.Environ(),
+		"PCLOUD_EVENT="+string(n.Event),
+		"PCLOUD_PAIR="+n.Pair,
+		"PCLOUD_MESSAGE="+n.Message(),
+	)
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return errors.Wrapf(err, "running %s: %s", cn.Command[0], bytes.TrimSpace(out))
+	}
+
+	return nil
+}
+
+// WebhookNotifier notifies by a POST request of the notification as JSON to a URL.
+type WebhookNotifier struct {
+	URL string
+	// Client is the HTTP client of the requests. It defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+// Notify posts the notification.
+func (wn *WebhookNotifier) Notify(ctx context.Context, n Notification) error {
+	data, err := json.Marshal(n)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wn.URL, bytes.NewReader(data))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := wn.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.Errorf("posting to %s: %s", wn.URL, resp.Status)
+	}
+
+	return nil
+}
+
+// DesktopNotifier notifies by a notification of the desktop: with notify-send on Linux, and
+// osascript on macOS.
+type DesktopNotifier struct{}
+
+// Notify shows the notification on the desktop.
+func (DesktopNotifier) Notify(ctx context.Context, n Notification) error {
+	name, args := desktopCommand("pCloud sync: "+string(n.Event), n.Message())
+
+	out, err := exec.CommandContext(ctx, name, args...).CombinedOutput() // nolint: gosec
+	if err != nil {
+		return errors.Wrapf(err, "running %s: %s", name, bytes.TrimSpace(out))
+	}
+
+	return nil
+}
+
+// Notifications notifies the notifiers of the events of the syncs of a pair, which it derives
+// from the results of the syncs, through its Report method. The errors of the notifiers are
+// logged. It is safe for concurrent use.
+type Notifications struct {
+	logger    *zap.Logger
+	pairName  db.PairName
+	notifiers []eventNotifier
+
+	mu     sync.Mutex
+	failed bool
+}
+
+// eventNotifier is a Notifier with the events it is notified of.
+type eventNotifier struct {
+	Notifier
+	events []NotifyEvent
+}
+
+// NewNotifications creates a new Notifications of the syncs of the pair pairName, with no
+// notifiers.
+func NewNotifications(logger *zap.Logger, pairName db.PairName) *Notifications {
+	return &Notifications{logger: logger, pairName: pairName}
+}
+
+// Add adds the notifier n, which is notified of the events. It is notified of EventFailed and
+// EventConflicts when events is empty.
+func (ns *Notifications) Add(n Notifier, events ...NotifyEvent) {
+	if len(events) == 0 {
+		events = []NotifyEvent{EventFailed, EventConflicts}
+	}
+
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+
+	ns.notifiers = append(ns.notifiers, eventNotifier{Notifier: n, events: events})
+}
+
+// Report notifies the events of the result of a sync. It is meant for WithSyncReport.
+func (ns *Notifications) Report(stats *SyncStats, err error) {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+
+	for _, n := range ns.events(stats, err) {
+		for _, en := range ns.notifiers {
+			if !en.notified(n.Event) {
+				continue
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+			errNotify := en.Notify(ctx, n)
+			cancel()
+
+			if errNotify != nil {
+				ns.logger.Error("notifying the sync event failed", zap.String("event", string(n.Event)), zap.Error(errNotify))
+			}
+		}
+	}
+}
+
+// events returns the notifications of the result of a sync. ns.mu must be held.
+func (ns *Notifications) events(stats *SyncStats, err error) []Notification {
+	now := time.Now()
+	var notifications []Notification
+
+	if err != nil {
+		if !ns.failed {
+			notifications = append(notifications, Notification{Event: EventFailed, Pair: string(ns.pairName), Time: now, Stats: stats, Error: err.Error()})
+		}
+		ns.failed = true
+	} else {
+		ns.failed = false
+		if stats != nil && stats.changed() {
+			notifications = append(notifications, Notification{Event: EventCompleted, Pair: string(ns.pairName), Time: now, Stats: stats})
+		}
+	}
+
+	if stats != nil && len(stats.Conflicts) > 0 {
+		notifications = append(notifications, Notification{Event: EventConflicts, Pair: string(ns.pairName), Time: now, Stats: stats})
+	}
+
+	return notifications
+}
+
+// notified returns whether the notifier is notified of the event e.
+func (en eventNotifier) notified(e NotifyEvent) bool {
+	for _, event := range en.events {
+		if event == e {
+			return true
+		}
+	}
+
+	return false
+}
+
+// changed returns whether the sync of the stats applied changes.
+func (st *SyncStats) changed() bool {
+	return st.Uploaded+st.Downloaded+st.DeletedLocal+st.DeletedRemote+st.MovedLocal+st.MovedRemote+st.ResolvedConflicts > 0
+}
@@ -0,0 +1,8 @@
+package tracker
+
+import "strconv"
+
+// desktopCommand returns the command that shows a notification of the desktop.
+func desktopCommand(title, body string) (string, []string) {
+	return "osascript", []string{"-e", "display notification " + strconv.Quote(body) + " with title " + strconv.Quote(title)}
+}
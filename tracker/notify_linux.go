@@ -0,0 +1,6 @@
+package tracker
+
+// desktopCommand returns the command that shows a notification of the desktop.
+func desktopCommand(title, body string) (string, []string) {
+	return "notify-send", []string{"--app-name=pcloud", title, body}
+}
@@ -0,0 +1,87 @@
+package tracker_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/seborama/pcloud-sdk/tracker"
+)
+
+// recordingNotifier records the events of its notifications.
+type recordingNotifier struct {
+	events []tracker.NotifyEvent
+}
+
+func (rn *recordingNotifier) Notify(_ context.Context, n tracker.Notification) error {
+	rn.events = append(rn.events, n.Event)
+	return nil
+}
+
+func TestNotifications_Report(t *testing.T) {
+	all := &recordingNotifier{}
+	defaults := &recordingNotifier{}
+
+	ns := tracker.NewNotifications(zap.NewNop(), "test")
+	ns.Add(all, tracker.EventCompleted, tracker.EventFailed, tracker.EventConflicts)
+	ns.Add(defaults)
+
+	ns.Report(&tracker.SyncStats{}, nil)
+	ns.Report(&tracker.SyncStats{Uploaded: 1}, nil)
+	ns.Report(nil, errors.New("boom"))
+	ns.Report(nil, errors.New("boom again"))
+	ns.Report(&tracker.SyncStats{Conflicts: []string{"a.txt"}}, nil)
+	ns.Report(nil, errors.New("boom"))
+
+	assert.Equal(t, []tracker.NotifyEvent{tracker.EventCompleted, tracker.EventFailed, tracker.EventConflicts, tracker.EventFailed}, all.events)
+	assert.Equal(t, []tracker.NotifyEvent{tracker.EventFailed, tracker.EventConflicts, tracker.EventFailed}, defaults.events)
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var got tracker.Notification
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		if got.Event == tracker.EventCompleted {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	wn := &tracker.WebhookNotifier{URL: srv.URL}
+
+	err := wn.Notify(context.Background(), tracker.Notification{Event: tracker.EventFailed, Pair: "test", Error: "boom"})
+	require.NoError(t, err)
+	assert.Equal(t, "test", got.Pair)
+	assert.Equal(t, "boom", got.Error)
+
+	err = wn.Notify(context.Background(), tracker.Notification{Event: tracker.EventCompleted, Stats: &tracker.SyncStats{}})
+	assert.Error(t, err)
+}
+
+func TestCommandNotifier(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out")
+
+	cn := &tracker.CommandNotifier{Command: []string{"sh", "-c", `echo "$PCLOUD_EVENT $PCLOUD_PAIR: $PCLOUD_MESSAGE" > "$0"; cat >> "$0"`, out}}
+
+	err := cn.Notify(context.Background(), tracker.Notification{Event: tracker.EventFailed, Pair: "test", Error: "boom"})
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "failed test: the sync failed: boom\n")
+	assert.Contains(t, string(data), `"error":"boom"`)
+
+	cn = &tracker.CommandNotifier{Command: []string{"sh", "-c", "exit 1"}}
+	assert.Error(t, cn.Notify(context.Background(), tracker.Notification{Event: tracker.EventFailed}))
+}
@@ -0,0 +1,83 @@
+package tracker
+
+import (
+	"context"
+)
+
+// PlannedAction is a change that a sync of the pair would apply (see Plan).
+type PlannedAction struct {
+	// Action is the type of the change: "upload", "download", "mkdir-local", "mkdir-remote",
+	// "delete-local", "delete-remote", "move-local", "move-remote" or "conflict".
+	Action string `json:"action"`
+	// Path is the slash-separated path of the file or folder, relative to the roots of the pair.
+	Path string `json:"path"`
+	// From is the path that a moved file is moved from.
+	From string `json:"from,omitempty"`
+	// IsFolder tells whether the change applies to a folder.
+	IsFolder bool `json:"is_folder,omitempty"`
+	// Size is the size of the file that is transferred.
+	Size uint64 `json:"size,omitempty"`
+	// Resolution is the resolution of a conflict, by the conflict policy: ConflictAsk when it
+	// would be asked.
+	Resolution ConflictPolicy `json:"resolution,omitempty"`
+}
+
+// Plan returns the changes that Sync would apply, in the order that it would apply them, without
+// applying them: the pair and its saved state are left as they are. The roots of a pair that has
+// never been synced, which the first sync creates, are empty when they are missing.
+func (s *TwoWay) Plan(ctx context.Context) ([]PlannedAction, error) {
+	info, err := s.readState(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	actions, base, err := s.scanAndPlan(ctx, info, nil, info.entries == 0)
+	if err != nil {
+		return nil, err
+	}
+
+	planned := []PlannedAction{}
+
+	for _, a := range orderActions(actions) {
+		if a.typ == actionRecord {
+			continue
+		}
+
+		if a.typ == actionConflict {
+			// the sync records the files of the same contents rather than resolving a conflict.
+			same, err := s.sameContents(ctx, a)
+			if err != nil {
+				return nil, err
+			}
+			if same {
+				continue
+			}
+		}
+
+		pa := PlannedAction{Action: string(a.typ), Path: a.path, From: a.from}
+
+		switch a.typ {
+		case actionUpload:
+			pa.Size = a.local.Size
+		case actionDownload:
+			pa.Size = a.remote.Size
+		case actionConflict:
+			pa.Resolution = s.plannedResolution(a)
+		case actionMkdirLocal, actionMkdirRemote:
+			pa.IsFolder = true
+		case actionDeleteLocal, actionDeleteRemote:
+			if b, ok := base[a.path]; ok {
+				pa.IsFolder = b.IsFolder
+			}
+		}
+
+		planned = append(planned, pa)
+	}
+
+	// the paths that the local folder cannot hold along other ones are left untouched.
+	for _, p := range s.nameConflicts {
+		planned = append(planned, PlannedAction{Action: string(actionConflict), Path: p, Resolution: ConflictSkip})
+	}
+
+	return planned, nil
+}
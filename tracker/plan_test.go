@@ -0,0 +1,75 @@
+package tracker_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/tracker"
+)
+
+func TestTwoWay_Plan(t *testing.T) {
+	ctx := context.Background()
+
+	srv, _, local, s := newTestTwoWay(t, tracker.WithConflictPolicy(tracker.ConflictKeepBoth))
+
+	writeLocalFile(t, local, "a.txt", "a")
+	writeLocalFile(t, local, "Sub/b.txt", "bb")
+	writeLocalFile(t, local, "c.txt", "c")
+	writeLocalFile(t, local, "d.txt", "d")
+
+	// the pCloud folder of a new pair is created by the first sync.
+	actions, err := s.Plan(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []tracker.PlannedAction{
+		{Action: "mkdir-remote", Path: "Sub", IsFolder: true},
+		{Action: "upload", Path: "Sub/b.txt", Size: 2},
+		{Action: "upload", Path: "a.txt", Size: 1},
+		{Action: "upload", Path: "c.txt", Size: 1},
+		{Action: "upload", Path: "d.txt", Size: 1},
+	}, actions)
+
+	_, err = srv.ReadFile("/Sync/a.txt")
+	require.Error(t, err)
+
+	_, err = s.Sync(ctx)
+	require.NoError(t, err)
+
+	writeLocalFile(t, local, "a.txt", "local")
+	_, err = srv.WriteFile("/Sync/a.txt", []byte("remote"))
+	require.NoError(t, err)
+	_, err = srv.WriteFile("/Sync/new.txt", []byte("new"))
+	require.NoError(t, err)
+	require.NoError(t, os.RemoveAll(filepath.Join(local, "Sub")))
+	require.NoError(t, os.Rename(filepath.Join(local, "d.txt"), filepath.Join(local, "e.txt")))
+
+	actions, err = s.Plan(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []tracker.PlannedAction{
+		{Action: "conflict", Path: "a.txt", Resolution: tracker.ConflictKeepBoth},
+		{Action: "move-remote", Path: "e.txt", From: "d.txt"},
+		{Action: "download", Path: "new.txt", Size: 3},
+		{Action: "delete-remote", Path: "Sub/b.txt"},
+		{Action: "delete-remote", Path: "Sub", IsFolder: true},
+	}, actions)
+
+	// nothing was applied.
+	data, err := srv.ReadFile("/Sync/Sub/b.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "bb", string(data))
+	assert.NoFileExists(t, filepath.Join(local, "new.txt"))
+
+	stats, err := s.Sync(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.ResolvedConflicts)
+	assert.Equal(t, 1, stats.MovedRemote)
+	assert.Equal(t, 2, stats.DeletedRemote)
+
+	actions, err = s.Plan(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, actions)
+}
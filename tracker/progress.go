@@ -0,0 +1,110 @@
+package tracker
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SyncOperation is the operation that the syncs of a TwoWay are running.
+type SyncOperation string
+
+// The operations of the syncs.
+const (
+	// OperationIdle is between the syncs.
+	OperationIdle SyncOperation = "idle"
+	// OperationScanning is the scan of both sides and the plan of the changes to apply.
+	OperationScanning SyncOperation = "scanning"
+	// OperationApplying is the application of the planned changes.
+	OperationApplying SyncOperation = "applying"
+)
+
+// Progress records the progress of the syncs of a TwoWay (see WithProgress): the operation in
+// progress, the changes waiting to be applied and the bytes transferred. It may be shared by the
+// successive TwoWay of a daemon, whose transferred bytes then add up. It is safe for concurrent
+// use.
+type Progress struct {
+	mu        sync.Mutex
+	operation SyncOperation
+	since     time.Time
+	pending   int
+	queued    int
+
+	// transferred is the number of bytes transferred by all the syncs, by direction.
+	transferred [2]atomic.Int64
+}
+
+// NewProgress creates a new Progress.
+func NewProgress() *Progress {
+	return &Progress{operation: OperationIdle, since: time.Now()}
+}
+
+// SyncStatus is the progress of the syncs, as served by Progress.
+type SyncStatus struct {
+	Operation SyncOperation `json:"operation"`
+	// Since is when the operation started.
+	Since time.Time `json:"since"`
+	// Pending is the number of changes of the running sync that are not applied yet, including
+	// those in progress.
+	Pending int `json:"pending"`
+	// Queued is the number of changed local paths waiting for the next sync of Watch.
+	Queued          int   `json:"queued"`
+	UploadedBytes   int64 `json:"uploaded_bytes"`
+	DownloadedBytes int64 `json:"downloaded_bytes"`
+}
+
+// Status returns the progress of the syncs.
+func (p *Progress) Status() SyncStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return SyncStatus{
+		Operation:       p.operation,
+		Since:           p.since,
+		Pending:         p.pending,
+		Queued:          p.queued,
+		UploadedBytes:   p.transferred[directionUpload].Load(),
+		DownloadedBytes: p.transferred[directionDownload].Load(),
+	}
+}
+
+// ServeHTTP serves the progress of the syncs as JSON.
+func (p *Progress) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(p.Status())
+}
+
+// start records the start of the operation op, with pending changes to apply.
+func (p *Progress) start(op SyncOperation, pending int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.operation = op
+	p.since = time.Now()
+	p.pending = pending
+}
+
+// applied records that one of the pending changes was applied, or failed.
+func (p *Progress) applied() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.pending > 0 {
+		p.pending--
+	}
+}
+
+// setQueued records the number of changed local paths waiting for the next sync.
+func (p *Progress) setQueued(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.queued = n
+}
+
+// addTransferred records n more bytes transferred in the direction dir.
+func (p *Progress) addTransferred(dir direction, n int64) {
+	p.transferred[dir].Add(n)
+}
@@ -0,0 +1,351 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+	"github.com/seborama/pcloud-sdk/tracker/db"
+)
+
+// remoteTreeStorer defines the store methods used by remoteTree.
+type remoteTreeStorer interface {
+	GetSyncRemoteEntry(ctx context.Context, pairName db.PairName, isFolder bool, id uint64) (*db.FSEntry, error)
+	UpdateSyncRemote(ctx context.Context, pairName db.PairName, remote db.SyncRemote, entries []db.FSEntry, removed []db.FSEntry) error
+}
+
+// remoteBatchSize is the number of the entries of a listing of the pCloud folder that are recorded
+// in the store at once.
+const remoteBatchSize = 1000
+
+// remoteTree is the tree of the pCloud folder of a pair held by the store, by the IDs of its files
+// and folders, as of the diff diffID of the pCloud account, with the changes of the events of the
+// diffs that follow: they are applied to the tree in the store by save, so that the folder is only
+// listed by the first sync. Only the changes are held in memory.
+type remoteTree struct {
+	ctx      context.Context
+	store    remoteTreeStorer
+	pairName db.PairName
+
+	diffID uint64
+	rootID uint64
+	// changed holds the files and folders changed by the events, and removed those that left the
+	// tree, with the contents of the folders.
+	changed map[remoteID]db.FSEntry
+	removed map[remoteID]db.FSEntry
+	// stale is set by the events that the tree cannot follow, such as a folder moved into the
+	// tree, whose contents are unknown: the folder must be listed again.
+	stale bool
+	// selection holds the selected folders of the pair: the entries outside of it are left out
+	// of the tree, and their events are ignored.
+	selection selection
+}
+
+// remoteID is the ID of a file or a folder of the tree: the IDs of the files and of the folders
+// are distinct.
+type remoteID struct {
+	isFolder bool
+	id       uint64
+}
+
+func newRemoteTree(ctx context.Context, store remoteTreeStorer, pairName db.PairName, saved *db.SyncRemote, sel selection) *remoteTree {
+	return &remoteTree{
+		ctx:       ctx,
+		store:     store,
+		pairName:  pairName,
+		diffID:    saved.DiffID,
+		rootID:    saved.RootFolderID,
+		changed:   map[remoteID]db.FSEntry{},
+		removed:   map[remoteID]db.FSEntry{},
+		selection: sel,
+	}
+}
+
+// get returns the file or folder id of the tree, and whether the tree holds it.
+func (t *remoteTree) get(isFolder bool, id uint64) (db.FSEntry, bool, error) {
+	key := remoteID{isFolder: isFolder, id: id}
+
+	if _, ok := t.removed[key]; ok {
+		return db.FSEntry{}, false, nil
+	}
+	if e, ok := t.changed[key]; ok {
+		return e, true, nil
+	}
+
+	e, err := t.store.GetSyncRemoteEntry(t.ctx, t.pairName, isFolder, id)
+	if err != nil || e == nil {
+		return db.FSEntry{}, false, err
+	}
+
+	return *e, true, nil
+}
+
+func (t *remoteTree) set(e db.FSEntry) {
+	key := remoteID{isFolder: e.IsFolder, id: e.EntryID}
+	delete(t.removed, key)
+	t.changed[key] = e
+}
+
+func (t *remoteTree) remove(e db.FSEntry) {
+	key := remoteID{isFolder: e.IsFolder, id: e.EntryID}
+	delete(t.changed, key)
+	t.removed[key] = e
+}
+
+// apply applies the diff event e to the tree.
+func (t *remoteTree) apply(e *sdk.Entry) error {
+	if e.DiffID > t.diffID {
+		t.diffID = e.DiffID
+	}
+
+	switch {
+	case e.Event == sdk.Reset:
+		t.stale = true
+		return nil
+
+	case !e.Event.IsFolderEvent() && !e.Event.IsFileEvent():
+		return nil
+	}
+
+	entry := remoteEntry(e.Metadata)
+
+	old, known, err := t.get(entry.IsFolder, entry.EntryID)
+	if err != nil {
+		return err
+	}
+
+	if entry.IsFolder && entry.EntryID == t.rootID {
+		// the root folder was deleted, moved or renamed: the pair no longer holds its contents.
+		if e.Event == sdk.DeleteFolder || entry.ParentFolderID != old.ParentFolderID || entry.Name != old.Name {
+			t.stale = true
+		}
+		return nil
+	}
+
+	_, inTree, err := t.get(true, entry.ParentFolderID)
+	if err != nil {
+		return err
+	}
+
+	selected := true
+	if inTree {
+		selected, err = t.selected(entry)
+		if err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case e.Event == sdk.DeleteFolder, e.Event == sdk.DeleteFile, !inTree:
+		// the contents of a folder that is deleted, or moved out of the tree, are left out of
+		// the tree with it.
+		t.remove(entry)
+
+	case !selected:
+		// such as a folder moved out of the selection, whose contents are left out with it.
+		t.remove(entry)
+
+	case e.Event == sdk.ModifyFolder && !known:
+		// a folder moved into the tree.
+		t.stale = true
+
+	default:
+		t.set(entry)
+	}
+
+	return nil
+}
+
+// selected returns whether the entry, whose parent folder is in the tree, is in the selection.
+func (t *remoteTree) selected(e db.FSEntry) (bool, error) {
+	if len(t.selection) == 0 {
+		return true, nil
+	}
+
+	parent, ok, err := t.folderPath(e.ParentFolderID)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	return t.selection.selected(path.Join(parent, e.Name), e.IsFolder), nil
+}
+
+// maxFolderDepth bounds the lookups of the paths of the folders of the tree: a cycle, which the
+// events cannot make, ends the lookup.
+const maxFolderDepth = 4096
+
+// folderPath returns the path of the folder id relative to the root of the tree.
+func (t *remoteTree) folderPath(id uint64) (string, bool, error) {
+	var names []string
+
+	for i := 0; i <= maxFolderDepth; i++ {
+		if id == t.rootID {
+			for l, r := 0, len(names)-1; l < r; l, r = l+1, r-1 {
+				names[l], names[r] = names[r], names[l]
+			}
+			return path.Join(names...), true, nil
+		}
+
+		f, ok, err := t.get(true, id)
+		if err != nil || !ok {
+			return "", false, err
+		}
+		names = append(names, f.Name)
+		id = f.ParentFolderID
+	}
+
+	return "", false, nil
+}
+
+// save applies the changes of the events to the tree in the store.
+func (t *remoteTree) save() error {
+	changed := make([]db.FSEntry, 0, len(t.changed))
+	for _, e := range t.changed {
+		changed = append(changed, e)
+	}
+
+	removed := make([]db.FSEntry, 0, len(t.removed))
+	for _, e := range t.removed {
+		removed = append(removed, e)
+	}
+
+	return t.store.UpdateSyncRemote(t.ctx, t.pairName, db.SyncRemote{DiffID: t.diffID, RootFolderID: t.rootID}, changed, removed)
+}
+
+// remoteEntry returns the entry of the metadata m of a file or folder, without its path.
+func remoteEntry(m sdk.Metadata) db.FSEntry {
+	e := db.FSEntry{
+		FSName:         remoteFSName,
+		EntryID:        m.FileID,
+		IsFolder:       m.IsFolder,
+		Name:           m.Name,
+		ParentFolderID: m.ParentFolderID,
+		Created:        m.Created,
+		Modified:       m.Modified,
+		Size:           m.Size,
+		Hash:           fmt.Sprintf("%d", m.Hash),
+	}
+
+	if m.IsFolder {
+		e.EntryID = m.FolderID
+		e.Hash = ""
+	}
+
+	return e
+}
+
+// scanRemote brings the tree of the pCloud folder held by the store up to date: the tree saved by
+// the last sync is updated with the diff of the pCloud account since then, rather than the folder
+// being listed again.
+func (s *TwoWay) scanRemote(ctx context.Context) error {
+	saved, err := s.store.GetSyncRemote(ctx, s.pairName)
+	if err != nil {
+		return err
+	}
+
+	if saved != nil {
+		tree := newRemoteTree(ctx, s.store, s.pairName, saved, s.ignore.selection)
+
+		// the errors of the store are not those of the diff: they fail the scan.
+		var errStore error
+		_, err = s.pcc.ChangesFunc(ctx, saved.DiffID, sdk.ChangesOptions{}, func(e *sdk.Entry) error {
+			errStore = tree.apply(e)
+			return errStore
+		})
+
+		switch {
+		case errStore != nil:
+			return errStore
+		case err != nil && ctx.Err() != nil:
+			return err
+		case err != nil:
+			s.logger.Warn("reading the diff of the pCloud account failed, listing the pCloud folder", zap.Error(err))
+		case tree.stale:
+			s.logger.Info("the diff of the pCloud account changed the pCloud folder in ways that require it to be listed")
+		default:
+			return tree.save()
+		}
+	}
+
+	// the tree is replaced by batches, and only read once it is saved with its diff: an
+	// interrupted listing is listed again by the next sync.
+	err = s.store.DeleteSyncRemote(ctx, s.pairName)
+	if err != nil {
+		return err
+	}
+
+	batch := make([]db.FSEntry, 0, remoteBatchSize)
+
+	remote, err := s.listRemote(ctx, func(_ string, e db.FSEntry) error {
+		batch = append(batch, e)
+		if len(batch) < remoteBatchSize {
+			return nil
+		}
+
+		err := s.store.AddSyncRemoteEntries(ctx, s.pairName, batch)
+		batch = batch[:0]
+
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.store.UpdateSyncRemote(ctx, s.pairName, *remote, batch, nil)
+}
+
+// eachRemote returns the stream of the entries of the tree of the pCloud folder held by the
+// store, in the order of the paths of the running sync, but the ignored ones.
+func (s *TwoWay) eachRemote(ctx context.Context) entryFunc {
+	return func(fn func(p string, e db.FSEntry) error) error {
+		return s.store.EachSyncRemote(ctx, s.pairName, s.order, func(p string, e db.FSEntry) error {
+			if unsynced(p) || s.ignore.ignored(p, e.IsFolder) {
+				return nil
+			}
+
+			e.FSName = remoteFSName
+			e.Path = path.Join(s.remoteRoot, path.Dir(p))
+
+			return fn(p, e)
+		})
+	}
+}
+
+// listRemote lists the pCloud folder in full, and calls fn with each of its selected files and
+// folders, by their slash-separated paths relative to the root, the root folder included, whose
+// path is empty. It returns the tree that they make, without its entries.
+func (s *TwoWay) listRemote(ctx context.Context, fn func(p string, e db.FSEntry) error) (*db.SyncRemote, error) {
+	// the tree is up to date with the last diff before the listing, at least: the events that
+	// follow it are applied again by the next sync, which the tree follows.
+	dr, err := s.pcc.Changes(ctx, 0, sdk.ChangesOptions{Last: 1})
+	if err != nil {
+		return nil, err
+	}
+
+	remote := &db.SyncRemote{DiffID: dr.DiffID}
+
+	err = walk(ctx, s.remoteFS, remoteFSName, s.remoteRoot, func(e db.FSEntry) error {
+		p := filepath.ToSlash(filepath.Join(e.Path, e.Name))
+		if e.IsFolder && p == s.remoteRoot {
+			remote.RootFolderID = e.EntryID
+			return fn("", e)
+		}
+
+		rel := strings.TrimPrefix(p, strings.TrimSuffix(s.remoteRoot, "/")+"/")
+		if !s.ignore.selection.selected(rel, e.IsFolder) {
+			return nil
+		}
+
+		return fn(rel, e)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return remote, nil
+}
@@ -0,0 +1,401 @@
+package tracker
+
+import (
+	"context"
+
+	// nolint:gosec
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/seborama/pcloud-sdk/remote"
+	"github.com/seborama/pcloud-sdk/sdk"
+	"github.com/seborama/pcloud-sdk/tracker/db"
+)
+
+var (
+	// ErrChecksumMismatch is the error of the restored files whose downloaded contents do not have
+	// the SHA-1 hash of the pCloud file.
+	ErrChecksumMismatch = errors.New("the downloaded contents do not match the checksum of the pCloud file")
+
+	// ErrChangedSinceRecorded is the error of the restored files whose contents on pCloud are no
+	// longer those recorded by the sync or the snapshot that is restored.
+	ErrChangedSinceRecorded = errors.New("the pCloud file changed since it was recorded")
+)
+
+// restoreStorer defines the store methods used by Restorer.
+type restoreStorer interface {
+	GetSyncRunFiles(ctx context.Context, pairName db.PairName, started time.Time) ([]db.SyncStateEntry, error)
+	GetSnapshots(ctx context.Context, pairName db.PairName) ([]db.Snapshot, error)
+	GetSnapshotFiles(ctx context.Context, pairName db.PairName, name string) ([]db.SnapshotFile, error)
+}
+
+// Restorer rebuilds a local folder from the pCloud folder of a pair: as it is, as recorded at the
+// end of one of the syncs of the pair, or from one of its snapshots. The downloaded files are
+// checked against the SHA-1 hashes of pCloud.
+type Restorer struct {
+	logger     *zap.Logger
+	store      restoreStorer
+	pcc        pCloudSDK
+	httpClient *http.Client
+	pairName   db.PairName
+	remoteRoot string
+
+	syncRun  time.Time
+	snapshot string
+	include  []ignoreRule
+	exclude  []ignoreRule
+}
+
+// RestoreOption configures a Restorer.
+type RestoreOption func(*Restorer)
+
+// WithRestoreHTTPClient sets the HTTP client that downloads the contents of files from the
+// content servers of pCloud. It defaults to http.DefaultClient.
+func WithRestoreHTTPClient(c *http.Client) RestoreOption {
+	return func(r *Restorer) {
+		r.httpClient = c
+	}
+}
+
+// WithRestoreSyncRun sets the sync of the pair whose files are restored, by the time it started
+// (see db.SyncRun): the files are those of the pair at the end of the sync, which the store keeps
+// for its db.MaxSyncRunTrees most recent syncs. The files that changed on pCloud since then fail
+// with ErrChangedSinceRecorded. By default, the pCloud folder is restored as it is.
+func WithRestoreSyncRun(started time.Time) RestoreOption {
+	return func(r *Restorer) {
+		r.syncRun = started
+	}
+}
+
+// WithRestoreSnapshot sets the snapshot of the pair that is restored, by name (see
+// SnapshotNameLayout), or "latest" for the most recent one.
+func WithRestoreSnapshot(name string) RestoreOption {
+	return func(r *Restorer) {
+		r.snapshot = name
+	}
+}
+
+// WithRestoreFilter sets the patterns of the files that are restored, with the syntax of the
+// ignore files: when include is not empty, only the files that match one of its patterns, or
+// whose folders do, are restored. The files that match a pattern of exclude, or whose folders do,
+// are not restored.
+func WithRestoreFilter(include, exclude []string) RestoreOption {
+	return func(r *Restorer) {
+		r.include = parsePatterns(include)
+		r.exclude = parsePatterns(exclude)
+	}
+}
+
+// NewRestorer creates a new Restorer of the pCloud folder remoteRoot of the pair pairName.
+func NewRestorer(logger *zap.Logger, store restoreStorer, pcc pCloudSDK, pairName db.PairName, remoteRoot string, opts ...RestoreOption) *Restorer {
+	r := &Restorer{
+		logger:     logger,
+		store:      store,
+		pcc:        pcc,
+		httpClient: http.DefaultClient,
+		pairName:   pairName,
+		remoteRoot: path.Clean("/" + remoteRoot),
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// RestoreStats counts the files of a restore.
+type RestoreStats struct {
+	Restored      int   `json:"restored"`
+	RestoredBytes int64 `json:"restored_bytes"`
+	// Unchanged is the number of local files that had the contents to restore already.
+	Unchanged int `json:"unchanged"`
+	Errors    int `json:"errors"`
+}
+
+// restoredFile is a file to restore.
+type restoredFile struct {
+	// path is the slash-separated path of the file, relative to the pCloud folder of the restore.
+	path string
+	size uint64
+	// sha1 and remoteHash are the SHA-1 hash and the pCloud hash of the file as recorded, when
+	// it was.
+	sha1       string
+	remoteHash string
+}
+
+// Restore restores the files into the local folder localRoot, which is created as needed. The
+// local files that are not restored are left as they are. The restore continues after the files
+// that fail, which it reports with the first error.
+func (r *Restorer) Restore(ctx context.Context, localRoot string) (*RestoreStats, error) {
+	root, files, err := r.source(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rem := remote.New(r.pcc, root, remote.WithHTTPClient(r.httpClient))
+
+	stats := &RestoreStats{}
+	var firstErr error
+
+	for _, f := range files {
+		if ctx.Err() != nil {
+			return stats, errors.WithStack(ctx.Err())
+		}
+
+		if unsynced(f.path) || r.filtered(f.path) {
+			continue
+		}
+
+		restored, err := r.restore(ctx, rem, root, localRoot, f)
+		switch {
+		case err != nil:
+			r.logger.Error("restoring the file failed", zap.String("path", f.path), zap.Error(err))
+			stats.Errors++
+			if firstErr == nil {
+				firstErr = errors.WithMessagef(err, "restoring %s", f.path)
+			}
+		case restored:
+			stats.Restored++
+			stats.RestoredBytes += int64(f.size)
+		default:
+			stats.Unchanged++
+		}
+	}
+
+	if firstErr != nil {
+		return stats, errors.WithMessagef(firstErr, "%d files failed, the first one", stats.Errors)
+	}
+
+	return stats, nil
+}
+
+// source returns the pCloud folder of the restore, with its files.
+func (r *Restorer) source(ctx context.Context) (string, []restoredFile, error) {
+	switch {
+	case !r.syncRun.IsZero() && r.snapshot != "":
+		return "", nil, errors.New("a restore is either of a sync or of a snapshot")
+
+	case !r.syncRun.IsZero():
+		entries, err := r.store.GetSyncRunFiles(ctx, r.pairName, r.syncRun)
+		if err != nil {
+			return "", nil, err
+		}
+		if len(entries) == 0 {
+			return "", nil, errors.Errorf("the files of the sync of %s of the pair %s are not recorded", r.syncRun.Format(time.RFC3339), r.pairName)
+		}
+
+		files := make([]restoredFile, 0, len(entries))
+		for _, e := range entries {
+			files = append(files, restoredFile{path: e.Path, size: e.Size, remoteHash: e.RemoteHash})
+		}
+
+		return r.remoteRoot, files, nil
+
+	case r.snapshot != "":
+		return r.snapshotSource(ctx)
+	}
+
+	var files []restoredFile
+
+	err := r.list(ctx, remote.New(r.pcc, r.remoteRoot), "", func(o remote.Object) {
+		files = append(files, restoredFile{path: o.Path, size: uint64(o.Size)})
+	})
+	if err != nil {
+		return "", nil, errors.WithMessage(err, "listing the pCloud folder")
+	}
+
+	return r.remoteRoot, files, nil
+}
+
+// snapshotSource returns the pCloud folder of the snapshot of the restore, with its files.
+func (r *Restorer) snapshotSource(ctx context.Context) (string, []restoredFile, error) {
+	name := r.snapshot
+	if name == "latest" {
+		snaps, err := r.store.GetSnapshots(ctx, r.pairName)
+		if err != nil {
+			return "", nil, err
+		}
+		if len(snaps) == 0 {
+			return "", nil, errors.Errorf("the pair %s has no snapshots", r.pairName)
+		}
+		name = snaps[len(snaps)-1].Name
+	}
+
+	snapFiles, err := r.store.GetSnapshotFiles(ctx, r.pairName, name)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(snapFiles) == 0 {
+		return "", nil, errors.Errorf("unknown snapshot %s of the pair %s", name, r.pairName)
+	}
+
+	files := make([]restoredFile, 0, len(snapFiles))
+	for _, f := range snapFiles {
+		files = append(files, restoredFile{path: f.Path, size: f.Size, sha1: f.Hash})
+	}
+
+	return path.Join(r.remoteRoot, name), files, nil
+}
+
+// list calls fn with the files of the folder dir of rem, recursively. The excluded folders are
+// not listed.
+func (r *Restorer) list(ctx context.Context, rem *remote.PCloud, dir string, fn func(o remote.Object)) error {
+	objects, err := rem.List(ctx, dir)
+	if err != nil {
+		return err
+	}
+
+	for _, o := range objects {
+		if !o.IsDir {
+			fn(o)
+			continue
+		}
+
+		if unsynced(o.Path) || matchPatterns(r.exclude, o.Path, true) {
+			continue
+		}
+
+		err = r.list(ctx, rem, o.Path, fn)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// filtered returns whether the file p is left out by the filters.
+func (r *Restorer) filtered(p string) bool {
+	if len(r.include) > 0 && !matchPatterns(r.include, p, false) {
+		return true
+	}
+
+	return matchPatterns(r.exclude, p, false)
+}
+
+// restore restores the file f of the pCloud folder root, of Remote rem, into the local folder
+// localRoot. It returns false when the local file has its contents already.
+func (r *Restorer) restore(ctx context.Context, rem *remote.PCloud, root, localRoot string, f restoredFile) (bool, error) {
+	fc, err := r.pcc.ChecksumFile(ctx, sdk.ByPath(path.Join(root, f.path)))
+	if err != nil {
+		return false, err
+	}
+
+	if f.remoteHash != "" && fmt.Sprintf("%d", fc.Metadata.Hash) != f.remoteHash ||
+		f.sha1 != "" && !strings.EqualFold(fc.SHA1, f.sha1) {
+		return false, ErrChangedSinceRecorded
+	}
+
+	to := filepath.Join(localRoot, filepath.FromSlash(f.path))
+
+	if info, err := os.Stat(to); err == nil && uint64(info.Size()) == fc.Metadata.Size {
+		local, err := hashFile(to)
+		if err == nil && strings.EqualFold(local, fc.SHA1) {
+			return false, nil
+		}
+	}
+
+	err = os.MkdirAll(filepath.Dir(to), 0755)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	rc, err := rem.Get(ctx, f.path, 0)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = rc.Close() }()
+
+	out, err := os.OpenFile(to+partialSuffix, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	// nolint: gosec
+	h := sha1.New()
+
+	// the contents are checked before they replace the local file, which is left as it is
+	// otherwise.
+	_, err = io.Copy(io.MultiWriter(out, h), rc)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && !strings.EqualFold(fmt.Sprintf("%x", h.Sum(nil)), fc.SHA1) {
+		err = ErrChecksumMismatch
+	}
+	if err == nil {
+		err = os.Rename(to+partialSuffix, to)
+	}
+	if err == nil && !fc.Metadata.Modified.IsZero() {
+		err = os.Chtimes(to, fc.Metadata.Modified, fc.Metadata.Modified)
+	}
+	if err != nil {
+		_ = os.Remove(to + partialSuffix)
+		return false, errors.WithStack(err)
+	}
+
+	return true, nil
+}
+
+// hashFile returns the SHA-1 hash of the contents of the file name.
+func hashFile(name string) (string, error) {
+	f, err := os.Open(name) // nolint: gosec
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	// nolint: gosec
+	h := sha1.New()
+
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// parsePatterns parses the patterns of a filter, with the syntax of the ignore files.
+func parsePatterns(patterns []string) []ignoreRule {
+	var rules []ignoreRule
+
+	for _, p := range patterns {
+		r, ok := parseIgnoreRule(p, "")
+		if ok {
+			rules = append(rules, r)
+		}
+	}
+
+	return rules
+}
+
+// matchPatterns returns whether the path p, or one of its folders, matches one of the rules. A
+// negated rule that matches p excludes it from the match.
+func matchPatterns(rules []ignoreRule, p string, isDir bool) bool {
+	matched := false
+
+	for dir, dirIsDir := p, isDir; dir != "." && dir != ""; dir, dirIsDir = path.Dir(dir), true {
+		for _, rule := range rules {
+			if rule.match(dir, dirIsDir) {
+				matched = !rule.negate
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,68 @@
+package tracker_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/seborama/pcloud-sdk/tracker"
+)
+
+func TestRestorer_Restore(t *testing.T) {
+	ctx := context.Background()
+	srv, store, _, s := newTestTwoWay(t)
+
+	for p, data := range map[string]string{"/Sync/a.txt": "a", "/Sync/Sub/b.txt": "bb", "/Sync/Other/c.txt": "c"} {
+		_, err := srv.WriteFile(p, []byte(data))
+		require.NoError(t, err)
+	}
+
+	_, err := s.Sync(ctx)
+	require.NoError(t, err)
+
+	_, err = srv.WriteFile("/Sync/a.txt", []byte("a2"))
+	require.NoError(t, err)
+
+	_, err = s.Sync(ctx)
+	require.NoError(t, err)
+
+	runs, err := store.GetSyncRuns(ctx, "test", 2)
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+
+	// the pCloud folder as it is, filtered.
+	dir := t.TempDir()
+	r := tracker.NewRestorer(zap.NewNop(), store, srv.NewClient(), "test", "/Sync",
+		tracker.WithRestoreHTTPClient(srv.Client()),
+		tracker.WithRestoreFilter([]string{"*.txt"}, []string{"Other/"}),
+	)
+
+	stats, err := r.Restore(ctx, dir)
+	require.NoError(t, err)
+	assert.Equal(t, &tracker.RestoreStats{Restored: 2, RestoredBytes: 4}, stats)
+	assert.Equal(t, "a2", readLocalFile(t, dir, "a.txt"))
+	assert.Equal(t, "bb", readLocalFile(t, dir, "Sub/b.txt"))
+	assert.NoFileExists(t, filepath.Join(dir, "Other", "c.txt"))
+
+	// the files that have their contents already are not downloaded again.
+	stats, err = r.Restore(ctx, dir)
+	require.NoError(t, err)
+	assert.Equal(t, &tracker.RestoreStats{Unchanged: 2}, stats)
+
+	// the files as of the first sync: those that changed since then fail.
+	dir = t.TempDir()
+	r = tracker.NewRestorer(zap.NewNop(), store, srv.NewClient(), "test", "/Sync",
+		tracker.WithRestoreHTTPClient(srv.Client()),
+		tracker.WithRestoreSyncRun(runs[1].Started),
+	)
+
+	stats, err = r.Restore(ctx, dir)
+	require.ErrorIs(t, err, tracker.ErrChangedSinceRecorded)
+	assert.Equal(t, &tracker.RestoreStats{Restored: 2, RestoredBytes: 3, Errors: 1}, stats)
+	assert.Equal(t, "c", readLocalFile(t, dir, "Other/c.txt"))
+	assert.NoFileExists(t, filepath.Join(dir, "a.txt"))
+}
@@ -0,0 +1,110 @@
+package tracker
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// QuietHours is a period of the day, in local time, when Watch does not sync, such as
+// "23:00-07:00". A period that ends before it starts spans midnight.
+type QuietHours struct {
+	// Start and End are the minutes since midnight of the start and the end of the period.
+	Start int
+	End   int
+}
+
+// ParseQuietHours parses a period of the day in the form "HH:MM-HH:MM".
+func ParseQuietHours(s string) (QuietHours, error) {
+	start, end, ok := strings.Cut(s, "-")
+	if !ok {
+		return QuietHours{}, errors.Errorf("invalid quiet hours %q: expected HH:MM-HH:MM", s)
+	}
+
+	var q QuietHours
+	var err error
+
+	q.Start, err = parseClock(strings.TrimSpace(start))
+	if err != nil {
+		return QuietHours{}, errors.WithMessagef(err, "invalid quiet hours %q", s)
+	}
+	q.End, err = parseClock(strings.TrimSpace(end))
+	if err != nil {
+		return QuietHours{}, errors.WithMessagef(err, "invalid quiet hours %q", s)
+	}
+
+	return q, nil
+}
+
+// parseClock parses a time of the day in the form "HH:MM" to the minutes since midnight.
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, errors.Errorf("invalid time of the day %q", s)
+	}
+
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// String returns the period in the form "HH:MM-HH:MM".
+func (q QuietHours) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", q.Start/60, q.Start%60, q.End/60, q.End%60)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (q QuietHours) MarshalText() ([]byte, error) {
+	return []byte(q.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (q *QuietHours) UnmarshalText(text []byte) error {
+	parsed, err := ParseQuietHours(string(text))
+	if err != nil {
+		return err
+	}
+	*q = parsed
+
+	return nil
+}
+
+// until returns the end of the period when t is in it. A period that starts and ends at the same
+// time is empty.
+func (q QuietHours) until(t time.Time) (time.Time, bool) {
+	minute := t.Hour()*60 + t.Minute()
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+
+	switch {
+	case q.Start < q.End && minute >= q.Start && minute < q.End:
+		return clock(midnight, q.End), true
+	case q.Start > q.End && minute >= q.Start:
+		return clock(midnight.AddDate(0, 0, 1), q.End), true
+	case q.Start > q.End && minute < q.End:
+		return clock(midnight, q.End), true
+	}
+
+	return time.Time{}, false
+}
+
+// clock returns the time of the day of midnight at the given minutes since midnight. The hours
+// are those of the clock, across daylight saving time changes.
+func clock(midnight time.Time, minutes int) time.Time {
+	return time.Date(midnight.Year(), midnight.Month(), midnight.Day(), minutes/60, minutes%60, 0, 0, midnight.Location())
+}
+
+// quietUntil returns the end of the quiet hours that t is in, if any: the latest end when periods
+// overlap.
+func quietUntil(hours []QuietHours, t time.Time) (time.Time, bool) {
+	var end time.Time
+	quiet := false
+
+	for _, q := range hours {
+		if until, ok := q.until(t); ok && until.After(end) {
+			end = until
+			quiet = true
+		}
+	}
+
+	return end, quiet
+}
@@ -0,0 +1,64 @@
+package tracker
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQuietHours(t *testing.T) {
+	q, err := ParseQuietHours("23:00-07:30")
+	require.NoError(t, err)
+	assert.Equal(t, QuietHours{Start: 23 * 60, End: 7*60 + 30}, q)
+	assert.Equal(t, "23:00-07:30", q.String())
+
+	for _, s := range []string{"", "23:00", "25:00-07:00", "23:00-7h", "23:00-07:00-08:00"} {
+		_, err := ParseQuietHours(s)
+		assert.Error(t, err, s)
+	}
+
+	var hours []QuietHours
+	require.NoError(t, json.Unmarshal([]byte(`["09:00-12:00", "23:00-07:00"]`), &hours))
+	assert.Equal(t, []QuietHours{{Start: 9 * 60, End: 12 * 60}, {Start: 23 * 60, End: 7 * 60}}, hours)
+
+	data, err := json.Marshal(hours)
+	require.NoError(t, err)
+	assert.JSONEq(t, `["09:00-12:00", "23:00-07:00"]`, string(data))
+}
+
+func TestQuietUntil(t *testing.T) {
+	day := func(d, h, m int) time.Time {
+		return time.Date(2024, time.March, d, h, m, 0, 0, time.UTC)
+	}
+
+	hours := []QuietHours{
+		{Start: 9 * 60, End: 12 * 60},
+		{Start: 23 * 60, End: 7 * 60},
+		{Start: 15 * 60, End: 15 * 60},
+	}
+
+	tcs := []struct {
+		t     time.Time
+		until time.Time
+		quiet bool
+	}{
+		{t: day(10, 8, 59)},
+		{t: day(10, 9, 0), until: day(10, 12, 0), quiet: true},
+		{t: day(10, 11, 59), until: day(10, 12, 0), quiet: true},
+		{t: day(10, 12, 0)},
+		{t: day(10, 15, 0)},
+		{t: day(10, 22, 59)},
+		{t: day(10, 23, 0), until: day(11, 7, 0), quiet: true},
+		{t: day(11, 3, 0), until: day(11, 7, 0), quiet: true},
+		{t: day(11, 7, 0)},
+	}
+
+	for _, tc := range tcs {
+		until, quiet := quietUntil(hours, tc.t)
+		assert.Equal(t, tc.quiet, quiet, tc.t)
+		assert.Equal(t, tc.until, until, tc.t)
+	}
+}
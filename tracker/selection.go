@@ -0,0 +1,84 @@
+package tracker
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// selection is the set of the folders of a pair that are synced, by their slash-separated paths
+// relative to the roots of the pair. An empty selection selects the whole pair.
+//
+// The selection of a pair is saved in the store (see db.SQLite3.ReplaceSyncSelection): the paths
+// outside of it are left out of the sync on both sides, like the ignored paths.
+type selection []string
+
+// ParseSelection checks and cleans the paths of a selection of folders, such as "Photos" or
+// "Work/Projects", relative to the roots of a pair. The paths within another path of the
+// selection are dropped.
+func ParseSelection(paths []string) ([]string, error) {
+	cleaned := make([]string, 0, len(paths))
+
+	for _, p := range paths {
+		c := strings.Trim(path.Clean("/"+p), "/")
+		if c == "" || containsDotDot(p) {
+			return nil, errors.Errorf("invalid selected folder '%s': it must be a folder of the pair, relative to its roots", p)
+		}
+		cleaned = append(cleaned, c)
+	}
+
+	sort.Strings(cleaned)
+
+	sel := selection{}
+	for _, p := range cleaned {
+		// the parents come first.
+		if !sel.within(p) {
+			sel = append(sel, p)
+		}
+	}
+
+	return sel, nil
+}
+
+// within returns whether p is one of the folders of the selection, or within one.
+func (sel selection) within(p string) bool {
+	for _, s := range sel {
+		if p == s || strings.HasPrefix(p, s+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsDotDot(p string) bool {
+	for _, name := range strings.Split(p, "/") {
+		if name == ".." {
+			return true
+		}
+	}
+
+	return false
+}
+
+// selected returns whether the file or folder p is synced: it is within a selected folder, or it
+// is a folder that holds one.
+func (sel selection) selected(p string, isFolder bool) bool {
+	if len(sel) == 0 {
+		return true
+	}
+
+	if sel.within(p) {
+		return true
+	}
+
+	for _, s := range sel {
+		if isFolder && strings.HasPrefix(s, p+"/") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,46 @@
+package tracker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSelection(t *testing.T) {
+	sel, err := ParseSelection([]string{"/Work/Projects/", "Photos", "Work/Projects/Old", "A-b", "A/B", "A"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"A", "A-b", "Photos", "Work/Projects"}, sel)
+
+	for _, p := range []string{"", "/", "..", "a/../../b"} {
+		_, err := ParseSelection([]string{p})
+		assert.Error(t, err, p)
+	}
+}
+
+func TestSelection_Selected(t *testing.T) {
+	sel := selection{"A/Deep", "B"}
+
+	tcs := []struct {
+		path     string
+		isFolder bool
+		selected bool
+	}{
+		{path: "A", isFolder: true, selected: true},
+		{path: "A/Deep", isFolder: true, selected: true},
+		{path: "A/Deep/x.txt", selected: true},
+		{path: "A/Deep/Sub", isFolder: true, selected: true},
+		{path: "A/y.txt"},
+		{path: "A/Other", isFolder: true},
+		{path: "A/Deeper", isFolder: true},
+		{path: "B/s.txt", selected: true},
+		{path: "C", isFolder: true},
+		{path: "top.txt"},
+	}
+
+	for _, tc := range tcs {
+		assert.Equal(t, tc.selected, sel.selected(tc.path, tc.isFolder), tc.path)
+	}
+
+	assert.True(t, selection{}.selected("anything", false))
+}
@@ -0,0 +1,300 @@
+package tracker
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/seborama/pcloud-sdk/remote"
+	"github.com/seborama/pcloud-sdk/sdk"
+	"github.com/seborama/pcloud-sdk/tracker/db"
+	"github.com/seborama/pcloud-sdk/tracker/filesystem"
+)
+
+// SnapshotNameLayout is the time layout of the names of the snapshots, in UTC, which sort them
+// by time.
+const SnapshotNameLayout = "2006-01-02T15-04-05Z"
+
+// snapshotStorer defines the store methods used by Snapshotter.
+type snapshotStorer interface {
+	localHashStorer
+	AddSnapshot(ctx context.Context, pairName db.PairName, snap db.Snapshot, files []db.SnapshotFile) error
+	GetSnapshots(ctx context.Context, pairName db.PairName) ([]db.Snapshot, error)
+	GetSnapshotFiles(ctx context.Context, pairName db.PairName, name string) ([]db.SnapshotFile, error)
+	DeleteSnapshot(ctx context.Context, pairName db.PairName, name string) error
+}
+
+// Snapshotter backs up a local folder to a pCloud folder by snapshots: each one is a complete copy
+// of the local folder in a folder of the pCloud folder named after its time (see
+// SnapshotNameLayout), which can be restored as it was then.
+//
+// The files whose contents are those of a file of the last snapshot are copied by pCloud from it
+// rather than uploaded, so that a snapshot only uploads the files that changed since the last one.
+type Snapshotter struct {
+	logger     *zap.Logger
+	store      snapshotStorer
+	pcc        pCloudSDK
+	localFS    *filesystem.Local
+	hashes     *hashCache
+	pairName   db.PairName
+	localRoot  string
+	remoteRoot string
+
+	ignoreFile string
+	keep       int
+	ignore     *ignorer
+	now        func() time.Time
+}
+
+// SnapshotOption configures a Snapshotter.
+type SnapshotOption func(*Snapshotter)
+
+// WithSnapshotIgnoreFile sets the global ignore file of the snapshots, like WithIgnoreFile. The
+// .pcloudignore files of the local folder apply to the snapshots too.
+func WithSnapshotIgnoreFile(name string) SnapshotOption {
+	return func(s *Snapshotter) {
+		s.ignoreFile = name
+	}
+}
+
+// WithSnapshotRetention sets the number of snapshots that are kept: the oldest ones are deleted
+// once a new one is complete, to the trash of pCloud. It defaults to 0: all of them are kept.
+func WithSnapshotRetention(keep int) SnapshotOption {
+	return func(s *Snapshotter) {
+		s.keep = keep
+	}
+}
+
+// NewSnapshotter creates a new Snapshotter for the pair pairName, which backs up the local folder
+// localRoot to the pCloud folder remoteRoot.
+func NewSnapshotter(logger *zap.Logger, store snapshotStorer, pcc pCloudSDK, pairName db.PairName, localRoot, remoteRoot string, opts ...SnapshotOption) *Snapshotter {
+	s := &Snapshotter{
+		logger:     logger,
+		store:      store,
+		pcc:        pcc,
+		pairName:   pairName,
+		localRoot:  filepath.Clean(localRoot),
+		remoteRoot: path.Clean("/" + remoteRoot),
+		now:        time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.hashes = newHashCache(logger, store, pairName, s.localRoot)
+	s.localFS = filesystem.NewLocal(filesystem.WithSkip(s.skipLocal), filesystem.WithHashCache(s.hashes))
+
+	return s
+}
+
+// SnapshotStats counts the files of a snapshot.
+type SnapshotStats struct {
+	Name          string `json:"name"`
+	Files         int    `json:"files"`
+	Uploaded      int    `json:"uploaded"`
+	UploadedBytes int64  `json:"uploaded_bytes"`
+	Copied        int    `json:"copied"`
+	Pruned        int    `json:"pruned"`
+}
+
+// Snapshot creates a new snapshot of the local folder. The snapshot is created in a partial folder
+// which is renamed once it is complete: a failed snapshot leaves a partial folder, which is not a
+// snapshot.
+func (s *Snapshotter) Snapshot(ctx context.Context) (*SnapshotStats, error) {
+	started := s.now()
+	stats := &SnapshotStats{Name: started.UTC().Format(SnapshotNameLayout)}
+
+	snaps, err := s.store.GetSnapshots(ctx, s.pairName)
+	if err != nil {
+		return nil, err
+	}
+
+	// the contents of the files of the last snapshot, by hash.
+	previous := map[string]db.SnapshotFile{}
+	if len(snaps) > 0 {
+		files, err := s.store.GetSnapshotFiles(ctx, s.pairName, snaps[len(snaps)-1].Name)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			previous[f.Hash] = f
+		}
+	}
+
+	entries, err := s.scan(ctx)
+	if err != nil {
+		return nil, errors.WithMessage(err, "scanning the local folder")
+	}
+
+	partial := path.Join(s.remoteRoot, stats.Name+partialSuffix)
+	for _, dir := range []string{s.remoteRoot, partial} {
+		_, err = s.pcc.CreateFolderIfNotExists(ctx, sdk.ByPath(dir))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	r := remote.New(s.pcc, partial)
+
+	paths := make([]string, 0, len(entries))
+	for p := range entries {
+		paths = append(paths, p)
+	}
+	// the folders are created before their contents.
+	sort.Strings(paths)
+
+	files := make([]db.SnapshotFile, 0, len(paths))
+
+	for _, p := range paths {
+		if ctx.Err() != nil {
+			return stats, errors.WithStack(ctx.Err())
+		}
+
+		e := entries[p]
+		if e.IsFolder {
+			_, err = s.pcc.CreateFolderIfNotExists(ctx, sdk.ByPath(path.Join(partial, p)))
+			if err != nil {
+				return stats, err
+			}
+			continue
+		}
+
+		f, copied, err := s.copy(ctx, partial, p, e, previous)
+		if err == nil && !copied {
+			f, err = s.upload(ctx, r, partial, p, e)
+		}
+		if err != nil {
+			return stats, errors.WithMessagef(err, "snapshot of %s", p)
+		}
+
+		if copied {
+			stats.Copied++
+		} else {
+			stats.Uploaded++
+			stats.UploadedBytes += int64(e.Size)
+		}
+
+		files = append(files, *f)
+		previous[f.Hash] = *f
+	}
+	stats.Files = len(files)
+
+	_, err = s.pcc.RenameFolder(ctx, sdk.ByPath(partial), sdk.ByPath(path.Join(s.remoteRoot, stats.Name)))
+	if err != nil {
+		return stats, err
+	}
+
+	err = s.store.AddSnapshot(ctx, s.pairName, db.Snapshot{Name: stats.Name, Started: started}, files)
+	if err != nil {
+		return stats, err
+	}
+
+	s.logger.Info("snapshot created", zap.String("name", stats.Name), zap.Int("files", stats.Files), zap.Int("uploaded", stats.Uploaded), zap.Int("copied", stats.Copied))
+
+	stats.Pruned, err = s.prune(ctx, append(snaps, db.Snapshot{Name: stats.Name}))
+
+	return stats, err
+}
+
+// scan returns the entries of the local folder, with their hashes.
+func (s *Snapshotter) scan(ctx context.Context) (map[string]db.FSEntry, error) {
+	var err error
+
+	s.ignore, err = newIgnorer(s.localRoot, s.ignoreFile, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer s.hashes.flush()
+
+	return scan(ctx, s.localFS, localFSName, s.localRoot)
+}
+
+// skipLocal is the filesystem.SkipFunc of the scans, which skips the ignored files and folders.
+func (s *Snapshotter) skipLocal(p string, info os.FileInfo) bool {
+	rel, err := filepath.Rel(s.localRoot, p)
+	if err != nil || s.ignore == nil {
+		return false
+	}
+
+	return s.ignore.ignored(filepath.ToSlash(rel), info.IsDir())
+}
+
+// copy copies the file of a previous snapshot with the contents of the local file p, of entry e,
+// to the snapshot folder dir. It returns false when there is none, or when it no longer exists.
+func (s *Snapshotter) copy(ctx context.Context, dir, p string, e db.FSEntry, previous map[string]db.SnapshotFile) (*db.SnapshotFile, bool, error) {
+	prev, ok := previous[e.Hash]
+	if !ok || prev.Size != e.Size {
+		return nil, false, nil
+	}
+
+	fr, err := s.pcc.CopyFileTo(ctx, sdk.ByID(prev.FileID), sdk.ByPath(path.Join(dir, p)), sdk.CopyFileOptions{ModifiedTime: e.Modified})
+	if sdk.IsNotFound(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &db.SnapshotFile{Path: p, Size: e.Size, Hash: e.Hash, FileID: fr.Metadata.FileID}, true, nil
+}
+
+// upload uploads the local file p, of entry e, to the snapshot folder dir, of Remote r.
+func (s *Snapshotter) upload(ctx context.Context, r *remote.PCloud, dir, p string, e db.FSEntry) (*db.SnapshotFile, error) {
+	f, err := os.Open(filepath.Join(s.localRoot, filepath.FromSlash(p)))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	_, err = r.Put(ctx, p, f)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = r.SetModTime(ctx, p, e.Modified)
+	if err != nil {
+		return nil, err
+	}
+
+	fr, err := s.pcc.Stat(ctx, sdk.ByPath(path.Join(dir, p)))
+	if err != nil {
+		return nil, err
+	}
+
+	return &db.SnapshotFile{Path: p, Size: e.Size, Hash: e.Hash, FileID: fr.Metadata.FileID}, nil
+}
+
+// prune deletes the oldest of the snapshots snaps, the oldest first, beyond the retention. It
+// returns the number of deleted snapshots.
+func (s *Snapshotter) prune(ctx context.Context, snaps []db.Snapshot) (int, error) {
+	if s.keep <= 0 || len(snaps) <= s.keep {
+		return 0, nil
+	}
+
+	var pruned int
+
+	for _, snap := range snaps[:len(snaps)-s.keep] {
+		_, err := s.pcc.DeleteFolderRecursive(ctx, sdk.ByPath(path.Join(s.remoteRoot, snap.Name)))
+		if err != nil && !sdk.IsNotFound(err) {
+			return pruned, err
+		}
+
+		err = s.store.DeleteSnapshot(ctx, s.pairName, snap.Name)
+		if err != nil {
+			return pruned, err
+		}
+		pruned++
+
+		s.logger.Info("snapshot deleted", zap.String("name", snap.Name))
+	}
+
+	return pruned, nil
+}
@@ -0,0 +1,87 @@
+package tracker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+	"github.com/seborama/pcloud-sdk/sdk/sdktest"
+	"github.com/seborama/pcloud-sdk/tracker/db"
+)
+
+func TestSnapshotter_Snapshot(t *testing.T) {
+	ctx := context.Background()
+
+	srv := sdktest.NewServer()
+	t.Cleanup(srv.Close)
+	pcc := srv.NewClient()
+
+	store, err := db.NewSQLite3(ctx, t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+
+	local := t.TempDir()
+	for p, data := range map[string]string{"a.txt": "a", "Sub/b.txt": "b", "Sub/c.txt": "c"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(local, filepath.Dir(p)), 0o700))
+		require.NoError(t, os.WriteFile(filepath.Join(local, p), []byte(data), 0o600))
+	}
+
+	now := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	s := NewSnapshotter(zap.NewNop(), store, pcc, "snap", local, "/Backup", WithSnapshotRetention(2))
+	s.now = func() time.Time { return now }
+
+	stats, err := s.Snapshot(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &SnapshotStats{Name: "2024-05-06T07-08-09Z", Files: 3, Uploaded: 3, UploadedBytes: 3}, stats)
+
+	data, err := srv.ReadFile("/Backup/2024-05-06T07-08-09Z/Sub/b.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "b", string(data))
+
+	// the next snapshot copies the files that did not change from the last one.
+	require.NoError(t, os.WriteFile(filepath.Join(local, "Sub/b.txt"), []byte("b2"), 0o600))
+	require.NoError(t, os.Remove(filepath.Join(local, "Sub/c.txt")))
+	now = now.Add(time.Hour)
+
+	stats, err = s.Snapshot(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &SnapshotStats{Name: "2024-05-06T08-08-09Z", Files: 2, Uploaded: 1, UploadedBytes: 2, Copied: 1}, stats)
+
+	data, err = srv.ReadFile("/Backup/2024-05-06T08-08-09Z/a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "a", string(data))
+	data, err = srv.ReadFile("/Backup/2024-05-06T07-08-09Z/Sub/b.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "b", string(data))
+
+	// the modification times of the local files are kept, including by the copies.
+	info, err := os.Stat(filepath.Join(local, "a.txt"))
+	require.NoError(t, err)
+	fr, err := pcc.Stat(ctx, sdk.ByPath("/Backup/2024-05-06T08-08-09Z/a.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, info.ModTime().Unix(), fr.Metadata.Modified.Unix())
+
+	// the oldest snapshots are deleted beyond the retention.
+	now = now.Add(time.Hour)
+
+	stats, err = s.Snapshot(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &SnapshotStats{Name: "2024-05-06T09-08-09Z", Files: 2, Copied: 2, Pruned: 1}, stats)
+
+	_, err = srv.ReadFile("/Backup/2024-05-06T07-08-09Z/a.txt")
+	assert.Error(t, err)
+
+	snaps, err := store.GetSnapshots(ctx, "snap")
+	require.NoError(t, err)
+	require.Len(t, snaps, 2)
+	assert.Equal(t, "2024-05-06T08-08-09Z", snaps[0].Name)
+	assert.Equal(t, 2, snaps[0].Files)
+	assert.EqualValues(t, 3, snaps[0].Size)
+}
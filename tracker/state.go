@@ -0,0 +1,340 @@
+package tracker
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/seborama/pcloud-sdk/tracker/db"
+)
+
+// The state of the pair and the entries of both sides are compared as they are streamed, in the
+// order of the keys of their paths (see db.PathOrder), rather than loaded: the local folder is
+// walked in that order, and the state and the tree of the pCloud folder are read from the store in
+// that order too (see mergeState). Only the state of the paths that the sync changes is held in
+// memory, and only it is saved (see saveState).
+
+// stateFunc streams the state of the pair to fn, in the order of the keys of the paths, and stops
+// at the first error of fn, which it returns.
+type stateFunc func(fn func(e db.SyncStateEntry) error) error
+
+// entryFunc streams the entries of a side to fn, by their slash-separated paths relative to its
+// root, in the order of the keys of the paths, and stops at the first error of fn, which it
+// returns.
+type entryFunc func(fn func(p string, e db.FSEntry) error) error
+
+// mergeFunc calls fn with each canonical path of the state and of the entries of both sides, with
+// its state and its entries on both sides, which are nil when it has none, and stops at the first
+// error of fn, which it returns.
+type mergeFunc func(fn func(p string, b *db.SyncStateEntry, l, r *db.FSEntry) error) error
+
+// stateInfo is what a sync needs to know of the state of the pair as a whole, read from the store
+// before the state is compared with both sides.
+type stateInfo struct {
+	// entries is the number of files and folders of the state, and files the number of files.
+	entries int
+	files   int
+	// renamed holds the state of the paths saved before the paths were canonical, in another
+	// Unicode form, by their canonical paths. legacy holds their saved paths.
+	renamed []db.SyncStateEntry
+	legacy  []string
+}
+
+// readState reads the state of the pair through, without holding it in memory.
+func (s *TwoWay) readState(ctx context.Context) (*stateInfo, error) {
+	info := &stateInfo{}
+
+	err := s.store.EachSyncState(ctx, s.pairName, db.ByPath, func(e db.SyncStateEntry) error {
+		info.entries++
+		if !e.IsFolder {
+			info.files++
+		}
+
+		if c := canonicalPath(e.Path); c != e.Path {
+			info.legacy = append(info.legacy, e.Path)
+			e.Path = c
+			info.renamed = append(info.renamed, e)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
+
+// eachState returns the stream of the state of the pair by canonical paths, in the order order:
+// the state saved under other paths is streamed by its canonical paths, in their order, in place
+// of the state saved under them, if any.
+func (s *TwoWay) eachState(ctx context.Context, info *stateInfo, order db.PathOrder) stateFunc {
+	return func(fn func(e db.SyncStateEntry) error) error {
+		renamed := append([]db.SyncStateEntry{}, info.renamed...)
+		sort.Slice(renamed, func(i, j int) bool { return comparePaths(order, renamed[i].Path, renamed[j].Path) < 0 })
+
+		err := s.store.EachSyncState(ctx, s.pairName, order, func(e db.SyncStateEntry) error {
+			if canonicalPath(e.Path) != e.Path {
+				return nil
+			}
+
+			replaced := false
+			for ; len(renamed) > 0 && comparePaths(order, renamed[0].Path, e.Path) <= 0; renamed = renamed[1:] {
+				replaced = renamed[0].Path == e.Path
+				err := fn(renamed[0])
+				if err != nil {
+					return err
+				}
+			}
+			if replaced {
+				return nil
+			}
+
+			return fn(e)
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, e := range renamed {
+			err = fn(e)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// comparePaths compares the paths a and b in the order order, like strings.Compare.
+func comparePaths(order db.PathOrder, a, b string) int {
+	ka, kb := order.Key(a), order.Key(b)
+
+	switch {
+	case ka < kb:
+		return -1
+	case ka > kb:
+		return 1
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// pathEntry is an entry of a side, by its path.
+type pathEntry struct {
+	path  string
+	entry db.FSEntry
+}
+
+// pathGroup holds the state and the entries of both sides whose paths have the same key.
+type pathGroup struct {
+	key    string
+	state  []db.SyncStateEntry
+	local  []pathEntry
+	remote []pathEntry
+}
+
+// mergeState calls fn with the state streamed by each and the entries of both sides streamed by
+// local and remote, by group of the same key of their paths, in the order of the keys of order.
+// The streams are read alongside each other, and only the group being merged is held in memory.
+func mergeState(order db.PathOrder, each stateFunc, local, remote entryFunc, fn func(g *pathGroup) error) error {
+	states := newCursor(each)
+	defer states.close()
+
+	locals := newCursor(func(fn func(e pathEntry) error) error {
+		return local(func(p string, e db.FSEntry) error { return fn(pathEntry{path: p, entry: e}) })
+	})
+	defer locals.close()
+
+	remotes := newCursor(func(fn func(e pathEntry) error) error {
+		return remote(func(p string, e db.FSEntry) error { return fn(pathEntry{path: p, entry: e}) })
+	})
+	defer remotes.close()
+
+	for {
+		// the next group is that of the smallest key of the streams.
+		var keys []string
+		if states.ok {
+			keys = append(keys, order.Key(states.cur.Path))
+		}
+		if locals.ok {
+			keys = append(keys, order.Key(locals.cur.path))
+		}
+		if remotes.ok {
+			keys = append(keys, order.Key(remotes.cur.path))
+		}
+		if len(keys) == 0 {
+			break
+		}
+		sort.Strings(keys)
+
+		g := &pathGroup{key: keys[0]}
+		for states.ok && order.Key(states.cur.Path) == g.key {
+			g.state = append(g.state, states.cur)
+			states.next()
+		}
+		for locals.ok && order.Key(locals.cur.path) == g.key {
+			g.local = append(g.local, locals.cur)
+			locals.next()
+		}
+		for remotes.ok && order.Key(remotes.cur.path) == g.key {
+			g.remote = append(g.remote, remotes.cur)
+			remotes.next()
+		}
+
+		// the group is incomplete when a stream failed.
+		err := firstError(states.err, locals.err, remotes.err)
+		if err != nil {
+			return err
+		}
+
+		err = fn(g)
+		if err != nil {
+			return err
+		}
+	}
+
+	return firstError(states.err, locals.err, remotes.err)
+}
+
+// firstError returns the first error of errs that is not nil, if any.
+func firstError(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// errStopped is returned to the streams of the cursors that are closed before their ends.
+var errStopped = errors.New("the stream was stopped")
+
+// cursor reads a stream one item at a time: the stream runs in a goroutine of its own, and is
+// stopped when the cursor is closed.
+type cursor[T any] struct {
+	items chan T
+	errCh chan error
+	stop  chan struct{}
+	once  sync.Once
+
+	// cur is the current item, when ok. err is the error of the stream, once it ended.
+	cur T
+	ok  bool
+	err error
+}
+
+// newCursor starts the stream, and returns its cursor on its first item.
+func newCursor[T any](stream func(fn func(v T) error) error) *cursor[T] {
+	c := &cursor[T]{
+		items: make(chan T, 64),
+		errCh: make(chan error, 1),
+		stop:  make(chan struct{}),
+	}
+
+	go func() {
+		defer close(c.items)
+
+		c.errCh <- stream(func(v T) error {
+			select {
+			case c.items <- v:
+				return nil
+			case <-c.stop:
+				return errStopped
+			}
+		})
+	}()
+
+	c.ok = true
+	c.next()
+
+	return c
+}
+
+// next moves the cursor to the next item of the stream.
+func (c *cursor[T]) next() {
+	if !c.ok {
+		return
+	}
+
+	c.cur, c.ok = <-c.items
+	if !c.ok {
+		c.err = <-c.errCh
+	}
+}
+
+// close stops the stream, and waits for it to end. It may be called more than once.
+func (c *cursor[T]) close() {
+	c.once.Do(func() { close(c.stop) })
+	for range c.items { // nolint: revive
+	}
+}
+
+// saveState saves the state base of the paths changed by the actions of the sync that started at
+// started, and records the state of the pair as the files of the sync, which the pair can be
+// restored from.
+func (s *TwoWay) saveState(started time.Time, info *stateInfo, actions []action, base map[string]db.SyncStateEntry) error {
+	touched := make([]string, 0, len(actions))
+	for _, a := range actions {
+		touched = append(touched, a.path)
+		if a.from != "" {
+			touched = append(touched, a.from)
+		}
+	}
+
+	entries, removed := stateChanges(info, base, touched)
+
+	// the state is saved with a context of its own: it must be saved even when the sync was
+	// interrupted.
+	err := s.store.UpdateSyncState(context.Background(), s.pairName, entries, removed)
+	if err != nil {
+		return err
+	}
+
+	return s.store.AddSyncRunFiles(context.Background(), s.pairName, started)
+}
+
+// stateChanges returns the state to save of the paths of base, and the paths whose state is
+// removed: those of touched that are not in base. The state saved under other paths than their
+// canonical paths is moved to them.
+func stateChanges(info *stateInfo, base map[string]db.SyncStateEntry, touched []string) ([]db.SyncStateEntry, []string) {
+	removed := append([]string{}, info.legacy...)
+	seen := make(map[string]bool, len(touched))
+
+	for _, p := range touched {
+		seen[p] = true
+		if _, ok := base[p]; !ok {
+			removed = append(removed, p)
+		}
+	}
+
+	entries := stateEntries(base)
+	for _, e := range info.renamed {
+		if _, ok := base[e.Path]; !ok && !seen[e.Path] {
+			entries = append(entries, e)
+		}
+	}
+
+	return entries, removed
+}
+
+// stateEntries returns the entries of the state base, sorted by path.
+func stateEntries(base map[string]db.SyncStateEntry) []db.SyncStateEntry {
+	entries := make([]db.SyncStateEntry, 0, len(base))
+	for _, e := range base {
+		entries = append(entries, e)
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+
+	return entries
+}
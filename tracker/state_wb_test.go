@@ -0,0 +1,108 @@
+package tracker
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/tracker/db"
+)
+
+func TestMergeState(t *testing.T) {
+	const nfc, nfd = "\u00e9.txt", "e\u0301.txt"
+
+	state := sliceState(
+		db.SyncStateEntry{Path: "a.txt"},
+		db.SyncStateEntry{Path: "b", IsFolder: true},
+		db.SyncStateEntry{Path: "b/c.txt"},
+		db.SyncStateEntry{Path: "gone.txt"},
+	)
+	local := sliceEntries("a.txt", "b", "b.txt", "z.txt", nfd)
+	remote := sliceEntries("a.txt", "b.txt", "b/c.txt", "new.txt", nfc)
+
+	type merged struct {
+		key                  string
+		state, local, remote []string
+	}
+
+	var got []merged
+	err := mergeState(db.ByCanonicalPath, state, local, remote, func(g *pathGroup) error {
+		m := merged{key: g.key}
+		for _, b := range g.state {
+			m.state = append(m.state, b.Path)
+		}
+		for _, e := range g.local {
+			m.local = append(m.local, e.path)
+		}
+		for _, e := range g.remote {
+			m.remote = append(m.remote, e.path)
+		}
+		got = append(got, m)
+		return nil
+	})
+	require.NoError(t, err)
+
+	// the paths that differ by their Unicode form only are in the same group.
+	want := []merged{
+		{key: "a.txt", state: []string{"a.txt"}, local: []string{"a.txt"}, remote: []string{"a.txt"}},
+		{key: "b", state: []string{"b"}, local: []string{"b"}},
+		{key: "b.txt", local: []string{"b.txt"}, remote: []string{"b.txt"}},
+		{key: "b/c.txt", state: []string{"b/c.txt"}, remote: []string{"b/c.txt"}},
+		{key: "gone.txt", state: []string{"gone.txt"}},
+		{key: "new.txt", remote: []string{"new.txt"}},
+		{key: "z.txt", local: []string{"z.txt"}},
+		{key: nfc, local: []string{nfd}, remote: []string{nfc}},
+	}
+	assert.Equal(t, want, got)
+
+	// the streams are stopped at the first error.
+	errStop := errors.New("stop")
+	err = mergeState(db.ByCanonicalPath, state, local, remote, func(*pathGroup) error { return errStop })
+	require.ErrorIs(t, err, errStop)
+}
+
+func TestStateChanges(t *testing.T) {
+	const nfc, nfd = "\u00e9.txt", "e\u0301.txt"
+	const summerNFC, summerNFD = "\u00e9t\u00e9.txt", "e\u0301te\u0301.txt"
+
+	// the state saved in another Unicode form is moved to its canonical path, including when the
+	// sync did not change it.
+	info := &stateInfo{
+		renamed: []db.SyncStateEntry{{Path: nfc, Size: 1}, {Path: summerNFC, Size: 2}},
+		legacy:  []string{nfd, summerNFD},
+	}
+	base := map[string]db.SyncStateEntry{"a.txt": {Path: "a.txt", Size: 3}, nfc: {Path: nfc, Size: 4}}
+
+	entries, removed := stateChanges(info, base, []string{"a.txt", nfc, "gone.txt"})
+	assert.Equal(t, []db.SyncStateEntry{{Path: "a.txt", Size: 3}, {Path: nfc, Size: 4}, {Path: summerNFC, Size: 2}}, entries)
+	assert.Equal(t, []string{nfd, summerNFD, "gone.txt"}, removed)
+}
+
+// sliceEntries returns the stream of the entries of paths, which must be sorted in the order of the
+// stream. The entries are named after their paths.
+func sliceEntries(paths ...string) entryFunc {
+	return func(fn func(p string, e db.FSEntry) error) error {
+		for _, p := range paths {
+			err := fn(p, db.FSEntry{Name: p})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// sliceState returns the stream of the state entries, which must be sorted by path.
+func sliceState(entries ...db.SyncStateEntry) stateFunc {
+	return func(fn func(e db.SyncStateEntry) error) error {
+		for _, e := range entries {
+			err := fn(e)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
@@ -3,8 +3,10 @@ package tracker
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/seborama/pcloud-sdk/tracker/db"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 )
@@ -76,3 +78,11 @@ func TestTracker_rotateFileSystemVersions_FSChanged(t *testing.T) {
 	err := tr.rotateFileSystemVersions(ctx)
 	require.NoError(t, err)
 }
+
+func TestConflictCopyPath(t *testing.T) {
+	tm := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	assert.Equal(t, "Notes/todo (local conflict 2024-01-02 150405).txt", conflictCopyPath("Notes/todo.txt", tm))
+	assert.Equal(t, ".profile (local conflict 2024-01-02 150405)", conflictCopyPath(".profile", tm))
+	assert.Equal(t, "Makefile (local conflict 2024-01-02 150405)", conflictCopyPath("Makefile", tm))
+}
@@ -0,0 +1,1596 @@
+package tracker
+
+import (
+	"context"
+
+	// nolint:gosec
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/seborama/pcloud-sdk/remote"
+	"github.com/seborama/pcloud-sdk/sdk"
+	"github.com/seborama/pcloud-sdk/tracker/db"
+	"github.com/seborama/pcloud-sdk/tracker/filesystem"
+)
+
+// syncStateStorer defines the store methods used by TwoWay.
+type syncStateStorer interface {
+	remoteTreeStorer
+	localHashStorer
+	EachSyncState(ctx context.Context, pairName db.PairName, order db.PathOrder, fn func(e db.SyncStateEntry) error) error
+	UpdateSyncState(ctx context.Context, pairName db.PairName, entries []db.SyncStateEntry, removed []string) error
+	AddSyncConflict(ctx context.Context, pairName db.PairName, conflict db.SyncConflict) error
+	GetSyncRemote(ctx context.Context, pairName db.PairName) (*db.SyncRemote, error)
+	EachSyncRemote(ctx context.Context, pairName db.PairName, order db.PathOrder, fn func(p string, e db.FSEntry) error) error
+	DeleteSyncRemote(ctx context.Context, pairName db.PairName) error
+	AddSyncRemoteEntries(ctx context.Context, pairName db.PairName, entries []db.FSEntry) error
+	GetSyncLocalEntry(ctx context.Context, pairName db.PairName, p string) (*db.FSEntry, error)
+	EachSyncLocal(ctx context.Context, pairName db.PairName, order db.PathOrder, fn func(p string, e db.FSEntry) error) error
+	DeleteSyncLocal(ctx context.Context, pairName db.PairName, paths []string) error
+	AddSyncLocal(ctx context.Context, pairName db.PairName, entries map[string]db.FSEntry) error
+	GetSyncSelection(ctx context.Context, pairName db.PairName) ([]string, error)
+	AddSyncRun(ctx context.Context, pairName db.PairName, run db.SyncRun) error
+	AddSyncRunFiles(ctx context.Context, pairName db.PairName, started time.Time) error
+	GetSyncTransfer(ctx context.Context, pairName db.PairName, path string, upload bool) (*db.SyncTransfer, error)
+	ReplaceSyncTransfer(ctx context.Context, pairName db.PairName, t db.SyncTransfer) error
+	DeleteSyncTransfer(ctx context.Context, pairName db.PairName, path string, upload bool) error
+}
+
+// pCloudSDK defines the SDK methods used by TwoWay and Snapshotter to scan and change the pCloud
+// side.
+type pCloudSDK interface {
+	List(ctx context.Context, folder sdk.FolderRef, o sdk.ListOptions, opts ...sdk.ClientOption) (*sdk.FSList, error)
+	Changes(ctx context.Context, diffID uint64, o sdk.ChangesOptions, opts ...sdk.ClientOption) (*sdk.DiffResult, error)
+	ChangesFunc(ctx context.Context, diffID uint64, o sdk.ChangesOptions, fn func(e *sdk.Entry) error, opts ...sdk.ClientOption) (uint64, error)
+	CreateFolderIfNotExists(ctx context.Context, folder sdk.FolderRef, opts ...sdk.ClientOption) (*sdk.FSList, error)
+	DeleteFolder(ctx context.Context, folder sdk.FolderRef, opts ...sdk.ClientOption) (*sdk.FSList, error)
+	DeleteFolderRecursive(ctx context.Context, folder sdk.FolderRef, opts ...sdk.ClientOption) (*sdk.DeleteResult, error)
+	RenameFolder(ctx context.Context, folder sdk.FolderRef, toFolder sdk.FolderRef, opts ...sdk.ClientOption) (*sdk.FSList, error)
+	Stat(ctx context.Context, file sdk.FileRef, opts ...sdk.ClientOption) (*sdk.FileResult, error)
+	ChecksumFile(ctx context.Context, file sdk.FileRef, opts ...sdk.ClientOption) (*sdk.FileChecksum, error)
+	DeleteFile(ctx context.Context, file sdk.FileRef, opts ...sdk.ClientOption) (*sdk.FileResult, error)
+	TrashClear(ctx context.Context, item sdk.T6FileIDOrFolderID, opts ...sdk.ClientOption) error
+	RenameFile(ctx context.Context, file sdk.FileRef, destination sdk.FolderRef, opts ...sdk.ClientOption) (*sdk.FileResult, error)
+	CopyFileTo(ctx context.Context, file sdk.FileRef, destination sdk.FolderRef, o sdk.CopyFileOptions, opts ...sdk.ClientOption) (*sdk.FileResult, error)
+	DownloadLink(ctx context.Context, file sdk.FileRef, o sdk.DownloadLinkOptions, opts ...sdk.ClientOption) (*sdk.FileLink, error)
+	FileOpen(ctx context.Context, flags uint64, file sdk.FileRef, opts ...sdk.ClientOption) (*sdk.File, error)
+	FileWrite(ctx context.Context, fd uint64, data []byte, opts ...sdk.ClientOption) (*sdk.FileDataTransfer, error)
+	FileClose(ctx context.Context, fd uint64, opts ...sdk.ClientOption) error
+}
+
+// DefaultDeleteThreshold is the default percentage of the files of a pair that a sync may delete
+// (see WithDeleteThreshold).
+const DefaultDeleteThreshold = 50
+
+// minGuardedDeletes is the number of deletions up to which a sync is not checked against the
+// delete threshold, so that the small pairs can be emptied.
+const minGuardedDeletes = 10
+
+// ErrTooManyDeletes is the error of the syncs that would delete more files than the delete
+// threshold allows, which apply none of their changes.
+var ErrTooManyDeletes = errors.New("too many files would be deleted")
+
+// partialSuffix is appended to the names of the local files while they are downloaded, and to
+// those of the pCloud files while they are uploaded by resumable uploads. Such files are not
+// synced.
+const partialSuffix = ".pcloud-partial"
+
+// The names of the file systems of the pair, as recorded on the entries of their scans.
+const (
+	localFSName  = db.LocalFileSystem
+	remoteFSName = db.PCloudFileSystem
+)
+
+// TwoWay synchronises a local folder and a pCloud folder in both directions.
+//
+// Each side is scanned and compared with the state of the pair as of its last sync, which is
+// held in the store: the changes of either side since then are applied to the other side, and
+// the changes of both sides to the same file are conflicts, resolved by the conflict policy.
+type TwoWay struct {
+	logger     *zap.Logger
+	store      syncStateStorer
+	pcc        pCloudSDK
+	httpClient *http.Client
+	remote     *remote.PCloud
+	localFS    *filesystem.Local
+	remoteFS   FSDriver
+	pairName   db.PairName
+	localRoot  string
+	remoteRoot string
+
+	direction      SyncDirection
+	conflictPolicy ConflictPolicy
+	askConflict    ConflictAsker
+
+	ignoreFile    string
+	shutdownGrace time.Duration
+	hashWorkers   int
+	hashes        *hashCache
+	limiter       *Limiter
+	symlinks      filesystem.SymlinkPolicy
+	specialFiles  filesystem.SpecialFilePolicy
+	recordModes   bool
+
+	deleteThreshold  int
+	permanentDeletes bool
+
+	resumableUploadSize int64
+
+	// ignore is the ignorer of the running sync, and order the order of its paths.
+	ignore *ignorer
+	order  db.PathOrder
+	// keepLocal tells whether the local entries of the syncs are kept in the store, by path, and
+	// localKept whether those of the last sync are: Watch updates them with the changed paths of
+	// the local folder rather than scanning the folder again.
+	keepLocal bool
+	localKept bool
+	// localNames and remoteNames are the names of the files and folders of the running sync on
+	// either side, by their canonical paths, where they differ from the canonical paths under the
+	// names of their parent folders (see canonicalGroup). nameConflicts are the paths that are
+	// left out of the running sync since the local folder cannot hold them along other ones.
+	localNames    map[string]string
+	remoteNames   map[string]string
+	nameConflicts []string
+	// caseInsensitive tells whether the local folder is case-insensitive, once detected.
+	caseInsensitive *bool
+	// modes holds the modes of the files during the running sync, when they are recorded.
+	modes *fileModes
+	// scanned is the number of local and remote entries compared by the running sync, and
+	// transferred the bytes that it transferred, by direction.
+	scanned     int
+	transferred [2]atomic.Int64
+	progress    *Progress
+}
+
+// TwoWayOption configures a TwoWay.
+type TwoWayOption func(*TwoWay)
+
+// WithHTTPClient sets the HTTP client that downloads the contents of files from the content
+// servers of pCloud. It defaults to http.DefaultClient.
+func WithHTTPClient(c *http.Client) TwoWayOption {
+	return func(s *TwoWay) {
+		s.httpClient = c
+	}
+}
+
+// WithShutdownGrace sets how long the change in progress when the context of a sync is done may
+// take to complete, such as the transfer of a file, before it is cancelled too. It defaults to 0:
+// the change is cancelled at once.
+func WithShutdownGrace(d time.Duration) TwoWayOption {
+	return func(s *TwoWay) {
+		s.shutdownGrace = d
+	}
+}
+
+// WithHashWorkers sets the number of local files that the scans hash concurrently. It defaults to
+// the number of CPUs.
+func WithHashWorkers(n int) TwoWayOption {
+	return func(s *TwoWay) {
+		s.hashWorkers = n
+	}
+}
+
+// WithSymlinks sets how the symbolic links of the local folder are synced (see
+// filesystem.SymlinkPolicy). It defaults to filesystem.SymlinkSkip. With
+// filesystem.SymlinkPlaceholder, a link is uploaded as a file holding its target, and the changes
+// of that file are downloaded as a link to its new target.
+func WithSymlinks(p filesystem.SymlinkPolicy) TwoWayOption {
+	return func(s *TwoWay) {
+		s.symlinks = p
+	}
+}
+
+// WithSpecialFiles sets how the special files of the local folder are handled, such as the named
+// pipes (see filesystem.SpecialFilePolicy). It defaults to filesystem.SpecialFileSkip.
+func WithSpecialFiles(p filesystem.SpecialFilePolicy) TwoWayOption {
+	return func(s *TwoWay) {
+		s.specialFiles = p
+	}
+}
+
+// WithFileModes sets whether the modes of the files, such as their executable bit, are recorded
+// in the metadata file of the pCloud folder as they are uploaded, and restored as they are
+// downloaded (see MetadataFileName). It defaults to false: the downloaded files get the default
+// mode.
+func WithFileModes(record bool) TwoWayOption {
+	return func(s *TwoWay) {
+		s.recordModes = record
+	}
+}
+
+// WithDeleteThreshold sets the percentage of the files of the pair, as of its last sync, that a
+// sync may delete on either side: a sync that would delete more of them fails with
+// ErrTooManyDeletes, such as when the local folder is an empty mount point. The syncs that delete
+// up to 10 files are not checked. 100 disables the check. It defaults to DefaultDeleteThreshold.
+func WithDeleteThreshold(percent int) TwoWayOption {
+	return func(s *TwoWay) {
+		s.deleteThreshold = percent
+	}
+}
+
+// WithPermanentDeletes sets whether the files and folders deleted from the pCloud folder are
+// cleared from the trash of pCloud too. It defaults to false: they can be restored from the trash
+// until it expires.
+func WithPermanentDeletes(permanent bool) TwoWayOption {
+	return func(s *TwoWay) {
+		s.permanentDeletes = permanent
+	}
+}
+
+// DefaultResumableUploadSize is the default size of the files whose uploads are resumable (see
+// WithResumableUploadSize).
+const DefaultResumableUploadSize = 64 << 20
+
+// WithResumableUploadSize sets the size of the local files from which their uploads are resumable:
+// they are uploaded to a partial pCloud file that replaces the destination once complete, so that
+// the upload of an interrupted sync resumes where it left off. It defaults to
+// DefaultResumableUploadSize, and 0 disables them. The downloads are always resumable.
+func WithResumableUploadSize(size int64) TwoWayOption {
+	return func(s *TwoWay) {
+		s.resumableUploadSize = size
+	}
+}
+
+// WithLimiter sets the Limiter of the transfers of the syncs, whose limits may be changed while
+// they run. It defaults to a Limiter without limits, which transfers one file at a time in each
+// direction.
+func WithLimiter(l *Limiter) TwoWayOption {
+	return func(s *TwoWay) {
+		s.limiter = l
+	}
+}
+
+// SyncDirection is the direction of the changes that the syncs of a pair apply.
+type SyncDirection string
+
+const (
+	// DirectionBoth applies the changes of either side to the other side. It is the default.
+	DirectionBoth SyncDirection = "both"
+	// DirectionUpload only applies the changes of the local folder to the pCloud folder.
+	DirectionUpload SyncDirection = "upload"
+	// DirectionDownload only applies the changes of the pCloud folder to the local folder.
+	DirectionDownload SyncDirection = "download"
+)
+
+// ParseSyncDirection parses the name of a sync direction, such as "upload".
+func ParseSyncDirection(s string) (SyncDirection, error) {
+	switch d := SyncDirection(s); d {
+	case DirectionBoth, DirectionUpload, DirectionDownload:
+		return d, nil
+	default:
+		return "", errors.Errorf("unknown sync direction '%s': use both, upload or download", s)
+	}
+}
+
+// WithDirection sets the direction of the changes that the syncs apply. It defaults to
+// DirectionBoth. The one-way syncs leave the changes of their destination as they are, and resolve
+// the conflicts in favour of their source, whatever the conflict policy.
+func WithDirection(d SyncDirection) TwoWayOption {
+	return func(s *TwoWay) {
+		s.direction = d
+	}
+}
+
+// WithProgress sets the Progress that records the progress of the syncs, such as to serve it over
+// HTTP. It defaults to a Progress of its own.
+func WithProgress(p *Progress) TwoWayOption {
+	return func(s *TwoWay) {
+		s.progress = p
+	}
+}
+
+// NewTwoWay creates a new initialised TwoWay for the sync pair pairName, made of the local folder
+// localRoot and the pCloud folder remoteRoot.
+func NewTwoWay(logger *zap.Logger, store syncStateStorer, pcc pCloudSDK, pairName db.PairName, localRoot, remoteRoot string, opts ...TwoWayOption) *TwoWay {
+	s := &TwoWay{
+		logger:     logger,
+		store:      store,
+		pcc:        pcc,
+		httpClient: http.DefaultClient,
+		limiter:    NewLimiter(TransferLimits{}),
+		progress:   NewProgress(),
+		remoteFS:   filesystem.NewPCloud(pcc),
+		pairName:   pairName,
+		localRoot:  filepath.Clean(localRoot),
+		remoteRoot: path.Clean("/" + remoteRoot),
+
+		direction:           DirectionBoth,
+		conflictPolicy:      ConflictSkip,
+		symlinks:            filesystem.SymlinkSkip,
+		specialFiles:        filesystem.SpecialFileSkip,
+		deleteThreshold:     DefaultDeleteThreshold,
+		resumableUploadSize: DefaultResumableUploadSize,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	// the one-way syncs resolve the conflicts in favour of their source.
+	switch s.direction {
+	case DirectionUpload:
+		s.conflictPolicy = ConflictPreferLocal
+	case DirectionDownload:
+		s.conflictPolicy = ConflictPreferRemote
+	}
+
+	// the local files whose size and modification time did not change since they were last
+	// hashed are not hashed again.
+	s.hashes = newHashCache(logger, store, pairName, s.localRoot)
+
+	s.localFS = filesystem.NewLocal(append(s.localOptions(), filesystem.WithHashCache(s.hashes))...)
+	s.remote = remote.New(pcc, s.remoteRoot, remote.WithHTTPClient(s.httpClient))
+
+	return s
+}
+
+// localOptions returns the options of the scans of the local folder, but its hash cache.
+func (s *TwoWay) localOptions() []filesystem.LocalOption {
+	opts := []filesystem.LocalOption{
+		filesystem.WithSkip(s.skipLocal),
+		filesystem.WithSymlinks(s.symlinks),
+		filesystem.WithSpecialFiles(s.specialFiles),
+		filesystem.WithSortKey(s.localSortKey),
+	}
+	if s.hashWorkers > 0 {
+		opts = append(opts, filesystem.WithHashWorkers(s.hashWorkers))
+	}
+
+	return opts
+}
+
+// SyncStats counts the changes that Sync applied to the files of either side.
+type SyncStats struct {
+	Uploaded      int `json:"uploaded"`
+	Downloaded    int `json:"downloaded"`
+	DeletedLocal  int `json:"deleted_local"`
+	DeletedRemote int `json:"deleted_remote"`
+	MovedLocal    int `json:"moved_local"`
+	MovedRemote   int `json:"moved_remote"`
+	// Conflicts lists the paths of the files that changed on both sides and were left untouched.
+	Conflicts         []string `json:"conflicts"`
+	ResolvedConflicts int      `json:"resolved_conflicts"`
+	Errors            int      `json:"errors"`
+}
+
+// Sync performs a two-way sync of the pair. The state of the pair is saved with the changes
+// that were applied, even when some failed: they are not applied again by the next sync.
+func (s *TwoWay) Sync(ctx context.Context) (*SyncStats, error) {
+	return s.sync(ctx, nil)
+}
+
+// sync performs a two-way sync of the pair, with a full scan of the local folder when changed
+// is nil, or else with the local entries of the last sync updated with the paths of changed. The
+// report of the sync is recorded in the history of the pair, including when it failed.
+func (s *TwoWay) sync(ctx context.Context, changed []string) (*SyncStats, error) {
+	started := time.Now()
+	s.scanned = 0
+	for i := range s.transferred {
+		s.transferred[i].Store(0)
+	}
+
+	s.progress.start(OperationScanning, 0)
+	defer s.progress.start(OperationIdle, 0)
+
+	stats, err := s.syncChanges(ctx, started, changed)
+
+	errRun := s.store.AddSyncRun(context.Background(), s.pairName, s.syncRun(started, stats, err))
+	if err == nil {
+		err = errRun
+	}
+
+	return stats, err
+}
+
+// syncRun returns the report of the sync that started at started, and returned stats and err.
+func (s *TwoWay) syncRun(started time.Time, stats *SyncStats, err error) db.SyncRun {
+	run := db.SyncRun{
+		Started:         started,
+		Duration:        time.Since(started),
+		Scanned:         s.scanned,
+		UploadedBytes:   s.transferred[directionUpload].Load(),
+		DownloadedBytes: s.transferred[directionDownload].Load(),
+	}
+
+	if stats != nil {
+		run.Uploaded = stats.Uploaded
+		run.Downloaded = stats.Downloaded
+		run.DeletedLocal = stats.DeletedLocal
+		run.DeletedRemote = stats.DeletedRemote
+		run.MovedLocal = stats.MovedLocal
+		run.MovedRemote = stats.MovedRemote
+		run.Conflicts = len(stats.Conflicts)
+		run.ResolvedConflicts = stats.ResolvedConflicts
+		run.Errors = stats.Errors
+	}
+	if err != nil {
+		run.Error = err.Error()
+	}
+
+	return run
+}
+
+// syncChanges performs the sync of sync, which started at started.
+func (s *TwoWay) syncChanges(ctx context.Context, started time.Time, changed []string) (*SyncStats, error) {
+	info, err := s.readState(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.entries == 0 {
+		// the roots are only created by the first sync: a missing root afterwards is more
+		// likely an unmounted drive than a deletion of all the files to propagate.
+		err = s.makeRoots(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	s.modes = nil
+	if s.recordModes {
+		s.modes, err = s.loadFileModes(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	actions, base, err := s.scanAndPlan(ctx, info, changed, false)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.checkDeletes(actions, base, info.files)
+	if err != nil {
+		return nil, err
+	}
+
+	s.progress.start(OperationApplying, len(actions))
+
+	stats, err := s.apply(ctx, actions, base)
+	if stats != nil {
+		stats.Conflicts = append(stats.Conflicts, s.nameConflicts...)
+	}
+
+	errSave := s.saveState(started, info, actions, base)
+	if err == nil {
+		err = errSave
+	}
+
+	if s.modes != nil {
+		errSave = s.saveFileModes(context.Background(), s.modes)
+		if err == nil {
+			err = errSave
+		}
+	}
+
+	return stats, err
+}
+
+// directed returns the actions that apply the changes in the direction of the syncs.
+func (s *TwoWay) directed(actions []action) []action {
+	if s.direction != DirectionUpload && s.direction != DirectionDownload {
+		return actions
+	}
+
+	directed := actions[:0]
+
+	for _, a := range actions {
+		switch a.typ {
+		case actionDownload, actionMkdirLocal, actionDeleteLocal, actionMoveLocal:
+			if s.direction == DirectionUpload {
+				continue
+			}
+		case actionUpload, actionMkdirRemote, actionDeleteRemote, actionMoveRemote:
+			if s.direction == DirectionDownload {
+				continue
+			}
+		}
+		directed = append(directed, a)
+	}
+
+	return directed
+}
+
+// addTransferred records n more bytes transferred in the direction dir by the running sync.
+func (s *TwoWay) addTransferred(dir direction, n int64) {
+	s.transferred[dir].Add(n)
+	s.progress.addTransferred(dir, n)
+}
+
+// checkDeletes returns ErrTooManyDeletes when the actions delete more files than the delete
+// threshold allows, out of the files of the state of the pair, where base is the state of the
+// paths of the actions.
+func (s *TwoWay) checkDeletes(actions []action, base map[string]db.SyncStateEntry, files int) error {
+	if s.deleteThreshold >= 100 {
+		return nil
+	}
+
+	var deletes int
+	for _, a := range actions {
+		if a.isDelete() && !base[a.path].IsFolder {
+			deletes++
+		}
+	}
+
+	if deletes <= minGuardedDeletes || deletes*100 <= files*s.deleteThreshold {
+		return nil
+	}
+
+	return errors.WithMessagef(ErrTooManyDeletes, "%d of the %d files of the pair, more than %d%%", deletes, files, s.deleteThreshold)
+}
+
+// scanAndPlan scans both sides of the pair, and returns the actions that sync them against the
+// state of the pair, with the state of their paths. The local folder is scanned like by sync. A
+// missing root is empty with emptyIfMissing, when the pair has never been synced: it would be
+// created by the sync.
+func (s *TwoWay) scanAndPlan(ctx context.Context, info *stateInfo, changed []string, emptyIfMissing bool) ([]action, map[string]db.SyncStateEntry, error) {
+	var err error
+
+	s.ignore, err = s.loadIgnorer(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.order = s.pathOrder()
+
+	// the tree of the pCloud folder is brought up to date first, from the diff of the account or
+	// listed. It is then streamed from the store alongside the state and the scan of the local
+	// folder, which skips the ignored files and folders.
+	remoteEntries := s.eachRemote(ctx)
+
+	err = s.scanRemote(ctx)
+	switch {
+	case err != nil && emptyIfMissing && sdk.IsNotFound(err):
+		remoteEntries = noEntries
+	case err != nil:
+		return nil, nil, errors.WithMessage(err, "scanning the pCloud folder")
+	}
+
+	local, err := s.scanLocal(ctx, changed)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "scanning the local folder")
+	}
+	if _, err = os.Stat(s.localRoot); emptyIfMissing && errors.Is(err, os.ErrNotExist) {
+		local = noEntries
+	}
+
+	merge := s.merge(s.eachState(ctx, info, s.order), local, remoteEntries)
+
+	actions, base, err := plan(merge)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, p := range s.nameConflicts {
+		s.logger.Warn("the path differs from another one by its case or its Unicode form only, and is not synced", zap.String("path", p))
+	}
+
+	return s.directed(actions), base, nil
+}
+
+// noEntries is the stream of the side of the pair whose root is missing.
+func noEntries(func(p string, e db.FSEntry) error) error {
+	return nil
+}
+
+// skipLocal is the filesystem.SkipFunc of the local scan, which skips the ignored files and
+// folders.
+func (s *TwoWay) skipLocal(p string, info os.FileInfo) bool {
+	rel, err := filepath.Rel(s.localRoot, p)
+	if err != nil || s.ignore == nil {
+		return false
+	}
+
+	return s.ignore.ignored(filepath.ToSlash(rel), info.IsDir())
+}
+
+func (s *TwoWay) makeRoots(ctx context.Context) error {
+	err := os.MkdirAll(s.localRoot, 0755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if s.remoteRoot == "/" {
+		return nil
+	}
+
+	_, err = s.pcc.CreateFolderIfNotExists(ctx, sdk.ByPath(s.remoteRoot))
+
+	return err
+}
+
+// scan returns the entries of the file system under root, by their slash-separated paths relative
+// to root. The root itself is not included.
+func scan(ctx context.Context, fsDriver FSDriver, fsName db.FSName, root string) (map[string]db.FSEntry, error) {
+	entries := map[string]db.FSEntry{}
+
+	err := walkEntries(ctx, fsDriver, fsName, root, func(p string, e db.FSEntry) error {
+		entries[p] = e
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// walkEntries calls fn with each entry of the file system under root, by its slash-separated path
+// relative to root, in the order of the walk. The root itself is not included. It stops at the
+// first error of fn, which it returns.
+func walkEntries(ctx context.Context, fsDriver FSDriver, fsName db.FSName, root string, fn func(p string, e db.FSEntry) error) error {
+	return walk(ctx, fsDriver, fsName, root, func(e db.FSEntry) error {
+		rel, err := filepath.Rel(root, filepath.Join(e.Path, e.Name))
+		if err != nil || rel == "." || unsynced(filepath.ToSlash(rel)) {
+			return nil
+		}
+
+		return fn(filepath.ToSlash(rel), e)
+	})
+}
+
+// unsynced returns whether the path p, relative to the roots of the pair, is never synced: the
+// partial files of the transfers, the copies of pCloud files that get a new modification time,
+// and the metadata file.
+func unsynced(p string) bool {
+	return strings.HasSuffix(p, partialSuffix) || strings.HasSuffix(p, remote.ModTimeSuffix) || p == MetadataFileName
+}
+
+// walk calls fn with each entry of the file system under root, the root included. It stops at the
+// first error of fn, which it returns.
+func walk(ctx context.Context, fsDriver FSDriver, fsName db.FSName, root string, fn func(e db.FSEntry) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	fsEntriesCh := make(chan db.FSEntry, 100)
+	errCh := make(chan error)
+
+	var fnErr error
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for {
+			select {
+			case <-ctx.Done():
+				// Walk failed before it consumed errCh.
+				return
+			case e, ok := <-fsEntriesCh:
+				if !ok {
+					select {
+					case errCh <- nil:
+					case <-ctx.Done():
+					}
+					return
+				}
+
+				fnErr = fn(e)
+				if fnErr != nil {
+					// Walk stops once it reads the error.
+					select {
+					case errCh <- fnErr:
+					case <-ctx.Done():
+					}
+					return
+				}
+			}
+		}
+	}()
+
+	err := fsDriver.Walk(ctx, fsName, root, fsEntriesCh, errCh)
+
+	cancel()
+	<-done
+
+	if fnErr != nil {
+		return fnErr
+	}
+
+	return err
+}
+
+// actionType is the type of change that a sync applies to a path.
+type actionType string
+
+const (
+	actionUpload       actionType = "upload"
+	actionDownload     actionType = "download"
+	actionMkdirLocal   actionType = "mkdir-local"
+	actionMkdirRemote  actionType = "mkdir-remote"
+	actionDeleteLocal  actionType = "delete-local"
+	actionDeleteRemote actionType = "delete-remote"
+	// actionMoveLocal and actionMoveRemote move the file of one side that was moved on the other
+	// side, from the path from of the action to its path.
+	actionMoveLocal  actionType = "move-local"
+	actionMoveRemote actionType = "move-remote"
+	// actionRecord records the state of both sides, which agree, without changing them.
+	actionRecord actionType = "record"
+	// actionConflict is a file that changed on both sides.
+	actionConflict actionType = "conflict"
+)
+
+type action struct {
+	typ    actionType
+	path   string
+	from   string
+	local  *db.FSEntry
+	remote *db.FSEntry
+}
+
+func (a action) isDelete() bool {
+	return a.typ == actionDeleteLocal || a.typ == actionDeleteRemote
+}
+
+// removes returns whether the path of a is absent from both sides after the sync.
+func (a action) removes() bool {
+	return a.isDelete() || a.typ == actionRecord && a.local == nil
+}
+
+// plan computes the actions of the three-way merge of the local and remote entries against the
+// state of the pair, streamed by merge, in its order. It returns them with the state of their
+// paths.
+// nolint: gocyclo
+func plan(merge mergeFunc) ([]action, map[string]db.SyncStateEntry, error) {
+	actions := []action{}
+	base := map[string]db.SyncStateEntry{}
+
+	err := merge(func(p string, state *db.SyncStateEntry, l, r *db.FSEntry) error {
+		var b db.SyncStateEntry
+		hasBase := state != nil
+		if hasBase {
+			b = *state
+		}
+		a := action{path: p, local: l, remote: r}
+
+		localChanged := changed(b, hasBase, a.local, b.LocalHash)
+		remoteChanged := changed(b, hasBase, a.remote, b.RemoteHash)
+
+		switch {
+		case !localChanged && !remoteChanged:
+			return nil
+
+		case a.local != nil && a.remote != nil && a.local.IsFolder != a.remote.IsFolder:
+			a.typ = actionConflict
+
+		case !remoteChanged, remoteChanged && localChanged && a.remote == nil && a.local != nil:
+			// the local change wins over an unchanged, or deleted, remote entry.
+			switch {
+			case a.local == nil:
+				a.typ = actionDeleteRemote
+			case a.local.IsFolder:
+				a.typ = actionMkdirRemote
+			default:
+				a.typ = actionUpload
+			}
+
+		case !localChanged, a.local == nil && a.remote != nil:
+			// the remote change wins over an unchanged, or deleted, local entry.
+			switch {
+			case a.remote == nil:
+				a.typ = actionDeleteLocal
+			case a.remote.IsFolder:
+				a.typ = actionMkdirLocal
+			default:
+				a.typ = actionDownload
+			}
+
+		case a.local == nil || a.local.IsFolder:
+			// deleted, or created as folders, on both sides.
+			a.typ = actionRecord
+
+		default:
+			a.typ = actionConflict
+		}
+
+		if a.typ == actionMkdirRemote && a.remote != nil || a.typ == actionMkdirLocal && a.local != nil {
+			a.typ = actionRecord
+		}
+
+		if hasBase {
+			base[p] = b
+		}
+		actions = append(actions, a)
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return keepNonEmptyFolders(detectMoves(actions, base)), base, nil
+}
+
+// changed returns whether the entry e of a side differs from the state b of the last sync, where
+// hash is the hash of the contents of the file on that side.
+func changed(b db.SyncStateEntry, hasBase bool, e *db.FSEntry, hash string) bool {
+	switch {
+	case !hasBase:
+		return e != nil
+	case e == nil:
+		return true
+	case e.IsFolder != b.IsFolder:
+		return true
+	default:
+		return !e.IsFolder && e.Hash != hash
+	}
+}
+
+// detectMoves pairs the files deleted from a side with the new files of the same contents on
+// that side: they were moved, and are moved on the other side rather than deleted and copied
+// again. The files of the same contents are interchangeable, so any of them can be paired.
+func detectMoves(actions []action, base map[string]db.SyncStateEntry) []action {
+	// the indexes of the deletions, by the hash of the deleted file on the side it was deleted
+	// from.
+	deletedLocally := map[string][]int{}
+	deletedRemotely := map[string][]int{}
+
+	for i, a := range actions {
+		b, ok := base[a.path]
+		if !ok || b.IsFolder {
+			continue
+		}
+
+		switch a.typ {
+		case actionDeleteRemote:
+			deletedLocally[b.LocalHash] = append(deletedLocally[b.LocalHash], i)
+		case actionDeleteLocal:
+			deletedRemotely[b.RemoteHash] = append(deletedRemotely[b.RemoteHash], i)
+		}
+	}
+
+	moved := map[int]bool{}
+
+	for i, a := range actions {
+		if _, ok := base[a.path]; ok {
+			// only the new files are the destinations of moves.
+			continue
+		}
+
+		var (
+			deleted map[string][]int
+			hash    string
+			typ     actionType
+		)
+
+		switch a.typ {
+		case actionUpload:
+			deleted, hash, typ = deletedLocally, a.local.Hash, actionMoveRemote
+		case actionDownload:
+			deleted, hash, typ = deletedRemotely, a.remote.Hash, actionMoveLocal
+		default:
+			continue
+		}
+
+		if len(deleted[hash]) == 0 {
+			continue
+		}
+
+		j := deleted[hash][0]
+		deleted[hash] = deleted[hash][1:]
+		moved[j] = true
+
+		actions[i].typ = typ
+		actions[i].from = actions[j].path
+	}
+
+	if len(moved) == 0 {
+		return actions
+	}
+
+	kept := make([]action, 0, len(actions)-len(moved))
+	for i, a := range actions {
+		if !moved[i] {
+			kept = append(kept, a)
+		}
+	}
+
+	return kept
+}
+
+// keepNonEmptyFolders turns the deletions of the folders that still have contents after the
+// sync back into creations on the side that deleted them.
+func keepNonEmptyFolders(actions []action) []action {
+	for i, a := range actions {
+		if !a.isDelete() {
+			continue
+		}
+
+		prefix := a.path + "/"
+		for _, other := range actions[i+1:] {
+			if !strings.HasPrefix(other.path, prefix) {
+				// the contents of the folder are sorted right after it.
+				continue
+			}
+			if other.removes() {
+				continue
+			}
+
+			if a.typ == actionDeleteRemote {
+				actions[i].typ = actionMkdirLocal
+			} else {
+				actions[i].typ = actionMkdirRemote
+			}
+			break
+		}
+	}
+
+	return actions
+}
+
+// apply applies the actions to both sides and updates base with the new state of their paths.
+// The deletions are applied last, with the contents of the folders before the folders. The
+// uploads and downloads run concurrently with the other actions, within the limits of the
+// limiter of s.
+// nolint: gocognit
+func (s *TwoWay) apply(ctx context.Context, actions []action, base map[string]db.SyncStateEntry) (*SyncStats, error) {
+	stats := &SyncStats{}
+	ordered := orderActions(actions)
+
+	// no action starts once ctx is done, while the action in progress is given the shutdown
+	// grace period to complete.
+	actionCtx := ctx
+	if s.shutdownGrace > 0 {
+		var cancel context.CancelFunc
+		actionCtx, cancel = context.WithCancel(context.WithoutCancel(ctx))
+		defer cancel()
+
+		stop := context.AfterFunc(ctx, func() {
+			time.AfterFunc(s.shutdownGrace, cancel)
+		})
+		defer stop()
+	}
+
+	var (
+		// mu guards base, stats and firstErr, which the transfers update as they complete.
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+
+	// done records the result of the action a. mu must be held.
+	done := func(a action, err error) {
+		s.progress.applied()
+
+		if err != nil {
+			s.logger.Error("sync action failed", zap.String("action", string(a.typ)), zap.String("path", a.path), zap.Error(err))
+			stats.Errors++
+			if firstErr == nil {
+				firstErr = errors.WithMessagef(err, "%s %s", a.typ, a.path)
+			}
+			return
+		}
+
+		s.logger.Debug("sync action applied", zap.String("action", string(a.typ)), zap.String("path", a.path))
+	}
+
+	var ctxErr error
+
+	for i, a := range ordered {
+		if ctx.Err() != nil {
+			ctxErr = errors.WithStack(ctx.Err())
+			break
+		}
+
+		if a.isDelete() && (i == 0 || !ordered[i-1].isDelete()) {
+			// the deletions wait for the transfers, like they wait for the other actions.
+			wg.Wait()
+		}
+
+		if dir, ok := a.transferDirection(); ok {
+			release, err := s.limiter.acquire(ctx, dir)
+			if err != nil {
+				ctxErr = err
+				break
+			}
+
+			wg.Add(1)
+			go func(a action) {
+				defer wg.Done()
+				defer release()
+
+				entry, err := s.transfer(actionCtx, a)
+
+				mu.Lock()
+				defer mu.Unlock()
+
+				if err == nil {
+					base[a.path] = *entry
+					if dir == directionUpload {
+						stats.Uploaded++
+					} else {
+						stats.Downloaded++
+					}
+				}
+				done(a, err)
+			}(a)
+
+			continue
+		}
+
+		mu.Lock()
+		done(a, s.applyAction(actionCtx, a, base, stats))
+		mu.Unlock()
+	}
+
+	wg.Wait()
+
+	switch {
+	case ctxErr != nil:
+		return stats, ctxErr
+	case firstErr != nil:
+		return stats, errors.WithMessagef(firstErr, "%d of %d sync actions failed, the first one", stats.Errors, len(actions))
+	default:
+		return stats, nil
+	}
+}
+
+// transferDirection returns the direction of the transfer of a, and whether a is a transfer.
+func (a action) transferDirection() (direction, bool) {
+	switch a.typ {
+	case actionUpload:
+		return directionUpload, true
+	case actionDownload:
+		return directionDownload, true
+	default:
+		return 0, false
+	}
+}
+
+// transfer applies the upload or download a, and returns the new state of its path.
+func (s *TwoWay) transfer(ctx context.Context, a action) (*db.SyncStateEntry, error) {
+	if a.typ == actionUpload {
+		return s.upload(ctx, a.path)
+	}
+
+	return s.download(ctx, a.path, a.remote)
+}
+
+// orderActions returns the actions in the order of apply: the deletions last, with the contents
+// of the folders before the folders.
+func orderActions(actions []action) []action {
+	var deletes []action
+	for _, a := range actions {
+		if a.isDelete() {
+			deletes = append(deletes, a)
+		}
+	}
+	ordered := make([]action, 0, len(actions))
+	for _, a := range actions {
+		if !a.isDelete() {
+			ordered = append(ordered, a)
+		}
+	}
+	for i := len(deletes) - 1; i >= 0; i-- {
+		ordered = append(ordered, deletes[i])
+	}
+
+	return ordered
+}
+
+// applyAction applies the action a, but a transfer (see transfer).
+// nolint: gocyclo
+func (s *TwoWay) applyAction(ctx context.Context, a action, base map[string]db.SyncStateEntry, stats *SyncStats) error {
+	switch a.typ {
+	case actionMkdirLocal:
+		err := os.MkdirAll(s.localPath(a.path), 0755)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		base[a.path] = db.SyncStateEntry{Path: a.path, IsFolder: true}
+
+	case actionMkdirRemote:
+		_, err := s.pcc.CreateFolderIfNotExists(ctx, sdk.ByPath(s.remotePath(a.path)))
+		if err != nil {
+			return err
+		}
+		base[a.path] = db.SyncStateEntry{Path: a.path, IsFolder: true}
+
+	case actionMoveLocal:
+		err := os.MkdirAll(filepath.Dir(s.localPath(a.path)), 0755)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		err = os.Rename(s.localPath(a.from), s.localPath(a.path))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		s.move(a, base)
+		stats.MovedLocal++
+
+	case actionMoveRemote:
+		_, err := s.remote.Move(ctx, s.remoteName(a.from), s.remoteName(a.path))
+		if err != nil {
+			return err
+		}
+		s.move(a, base)
+		stats.MovedRemote++
+
+	case actionDeleteLocal:
+		err := os.Remove(s.localPath(a.path))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return errors.WithStack(err)
+		}
+		s.forget(a.path, base)
+		stats.DeletedLocal++
+
+	case actionDeleteRemote:
+		err := s.remote.Remove(ctx, s.remoteName(a.path))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		if err == nil && s.permanentDeletes && a.remote != nil {
+			err = s.clearTrash(ctx, a.remote)
+			if err != nil {
+				return err
+			}
+		}
+		s.forget(a.path, base)
+		stats.DeletedRemote++
+
+	case actionRecord:
+		s.record(a, base)
+
+	case actionConflict:
+		same, err := s.sameContents(ctx, a)
+		if err != nil {
+			return err
+		}
+		if same {
+			s.record(a, base)
+			return nil
+		}
+
+		return s.resolveConflict(ctx, a, base, stats)
+
+	default:
+		return errors.Errorf("unknown sync action '%s'", a.typ)
+	}
+
+	return nil
+}
+
+// move moves the state of the file moved by a to its new path: the hashes of its contents did
+// not change.
+func (s *TwoWay) move(a action, base map[string]db.SyncStateEntry) {
+	if s.modes != nil {
+		s.modes.move(a.from, a.path)
+	}
+
+	entry := base[a.from]
+	entry.Path = a.path
+	base[a.path] = entry
+	delete(base, a.from)
+}
+
+// clearTrash clears the deleted remote file or folder e from the trash.
+func (s *TwoWay) clearTrash(ctx context.Context, e *db.FSEntry) error {
+	item := sdk.T6FileByID(e.EntryID)
+	if e.IsFolder {
+		item = sdk.T6FolderByID(e.EntryID)
+	}
+
+	return s.pcc.TrashClear(ctx, item)
+}
+
+// forget forgets the state of the path p, which was deleted.
+func (s *TwoWay) forget(p string, base map[string]db.SyncStateEntry) {
+	if s.modes != nil {
+		s.modes.remove(p)
+	}
+
+	delete(base, p)
+}
+
+// record records the current state of both sides of the path of a, which agree.
+func (s *TwoWay) record(a action, base map[string]db.SyncStateEntry) {
+	if a.local == nil || a.remote == nil {
+		delete(base, a.path)
+		return
+	}
+
+	base[a.path] = db.SyncStateEntry{
+		Path:       a.path,
+		IsFolder:   a.local.IsFolder,
+		Size:       a.local.Size,
+		LocalHash:  a.local.Hash,
+		RemoteHash: a.remote.Hash,
+	}
+}
+
+// sameContents returns whether the local and remote files of a have the same contents, such as
+// when the same file was added to both sides before their first sync.
+func (s *TwoWay) sameContents(ctx context.Context, a action) (bool, error) {
+	if a.local == nil || a.remote == nil || a.local.IsFolder || a.remote.IsFolder || a.local.Size != a.remote.Size {
+		return false, nil
+	}
+
+	hashes, err := s.remote.Hashes(ctx, s.remoteName(a.path))
+	if err != nil {
+		return false, err
+	}
+
+	return strings.EqualFold(hashes[remote.SHA1], a.local.Hash), nil
+}
+
+// upload uploads the local file p and returns its new state. The files of at least the resumable
+// upload size are uploaded by uploadResumable.
+func (s *TwoWay) upload(ctx context.Context, p string) (*db.SyncStateEntry, error) {
+	if target, ok := s.placeholder(p); ok {
+		info, err := os.Lstat(s.localPath(p))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		return s.put(ctx, p, strings.NewReader(target), info)
+	}
+
+	f, err := os.Open(s.localPath(p))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if s.resumableUploadSize > 0 && info.Size() >= s.resumableUploadSize {
+		return s.uploadResumable(ctx, p, f, info)
+	}
+
+	return s.put(ctx, p, f, info)
+}
+
+// put uploads the contents r of the local file p, of info, and returns its new state.
+func (s *TwoWay) put(ctx context.Context, p string, r io.Reader, info os.FileInfo) (*db.SyncStateEntry, error) {
+	// the local hash is that of the uploaded contents, which may have changed since the scan.
+	// nolint: gosec
+	h := sha1.New()
+
+	o, err := s.remote.Put(ctx, s.remoteName(p), s.limiter.reader(ctx, directionUpload, io.TeeReader(r, h)))
+	if err != nil {
+		return nil, err
+	}
+	s.addTransferred(directionUpload, o.Size)
+
+	err = s.uploadMetadata(ctx, p, info)
+	if err != nil {
+		return nil, err
+	}
+
+	fr, err := s.pcc.Stat(ctx, sdk.ByPath(s.remotePath(p)))
+	if err != nil {
+		return nil, err
+	}
+
+	return &db.SyncStateEntry{
+		Path:       p,
+		Size:       uint64(o.Size),
+		LocalHash:  fmt.Sprintf("%x", h.Sum(nil)),
+		RemoteHash: fmt.Sprintf("%d", fr.Metadata.Hash),
+	}, nil
+}
+
+// uploadResumable uploads the local file p, open as f, to a partial pCloud file next to its
+// destination, which it replaces once complete. The partial file is recorded in the store with
+// the size and modification time info of the local file: the upload of an interrupted sync
+// resumes at the end of the partial file, unless the local file changed since.
+func (s *TwoWay) uploadResumable(ctx context.Context, p string, f *os.File, info os.FileInfo) (*db.SyncStateEntry, error) {
+	partial := s.remoteName(p) + partialSuffix
+
+	t, offset, err := s.resumedUpload(ctx, p, info)
+	if err != nil {
+		return nil, err
+	}
+
+	if t == nil {
+		_, err = s.remote.Put(ctx, partial, strings.NewReader(""))
+		if err != nil {
+			return nil, err
+		}
+
+		fr, err := s.pcc.Stat(ctx, sdk.ByPath(path.Join(s.remoteRoot, partial)))
+		if err != nil {
+			return nil, err
+		}
+
+		t = &db.SyncTransfer{Path: p, Upload: true, Size: info.Size(), Modified: info.ModTime(), FileID: fr.Metadata.FileID}
+
+		err = s.store.ReplaceSyncTransfer(ctx, s.pairName, *t)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// the contents uploaded by the interrupted sync are only hashed.
+	// nolint: gosec
+	h := sha1.New()
+
+	_, err = io.CopyN(h, f, offset)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading the uploaded contents of the file")
+	}
+
+	o, err := s.remote.Append(ctx, partial, s.limiter.reader(ctx, directionUpload, io.TeeReader(f, h)))
+	if err != nil {
+		return nil, err
+	}
+	s.addTransferred(directionUpload, o.Size-offset)
+
+	localHash := fmt.Sprintf("%x", h.Sum(nil))
+
+	if offset > 0 {
+		hashes, err := s.remote.Hashes(ctx, partial)
+		if err != nil {
+			return nil, err
+		}
+
+		if !strings.EqualFold(hashes[remote.SHA1], localHash) {
+			// the next sync uploads the file from the start.
+			_ = s.remote.Remove(ctx, partial)
+			_ = s.store.DeleteSyncTransfer(ctx, s.pairName, p, true)
+			return nil, errors.New("the contents of the resumed upload differ from the local file")
+		}
+	}
+
+	o, err = s.remote.Move(ctx, partial, s.remoteName(p))
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.uploadMetadata(ctx, p, info)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.store.DeleteSyncTransfer(ctx, s.pairName, p, true)
+	if err != nil {
+		return nil, err
+	}
+
+	fr, err := s.pcc.Stat(ctx, sdk.ByPath(s.remotePath(p)))
+	if err != nil {
+		return nil, err
+	}
+
+	return &db.SyncStateEntry{
+		Path:       p,
+		Size:       uint64(o.Size),
+		LocalHash:  localHash,
+		RemoteHash: fmt.Sprintf("%d", fr.Metadata.Hash),
+	}, nil
+}
+
+// uploadMetadata applies the metadata of the local file p, of info, to its uploaded copy: its
+// modification time, and its mode when the modes are recorded.
+func (s *TwoWay) uploadMetadata(ctx context.Context, p string, info os.FileInfo) error {
+	_, err := s.remote.SetModTime(ctx, s.remoteName(p), info.ModTime())
+	if err != nil {
+		return err
+	}
+
+	if s.modes != nil && info.Mode().IsRegular() {
+		s.modes.set(p, info.Mode())
+	}
+
+	return nil
+}
+
+// resumedUpload returns the upload of the local file p, of info, that an interrupted sync left in
+// progress and the size of its partial pCloud file. It returns nil when there is none, or when
+// it cannot be resumed.
+func (s *TwoWay) resumedUpload(ctx context.Context, p string, info os.FileInfo) (*db.SyncTransfer, int64, error) {
+	t, err := s.store.GetSyncTransfer(ctx, s.pairName, p, true)
+	if err != nil || t == nil {
+		return nil, 0, err
+	}
+
+	if t.Size != info.Size() || !t.Modified.Equal(info.ModTime()) {
+		s.logger.Info("the local file changed since its upload was interrupted, it is uploaded from the start", zap.String("path", p))
+		return nil, 0, nil
+	}
+
+	fr, err := s.pcc.Stat(ctx, sdk.ByID(t.FileID))
+	switch {
+	case sdk.IsNotFound(err):
+		return nil, 0, nil
+	case err != nil:
+		return nil, 0, err
+	case fr.Metadata.Size > uint64(info.Size()):
+		return nil, 0, nil
+	}
+
+	s.logger.Info("resuming the upload", zap.String("path", p), zap.Uint64("offset", fr.Metadata.Size))
+
+	return t, int64(fr.Metadata.Size), nil
+}
+
+// download downloads the remote file p, whose scanned entry is e, and returns its new state.
+// The file is written next to its destination first, which it replaces once complete. The
+// download is recorded in the store with the hash of the remote file: the download of an
+// interrupted sync resumes at the end of the partial file, unless the remote file changed since.
+func (s *TwoWay) download(ctx context.Context, p string, e *db.FSEntry) (*db.SyncStateEntry, error) {
+	to := s.localPath(p)
+
+	err := os.MkdirAll(filepath.Dir(to), 0755)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	offset, err := s.resumedDownload(ctx, p, e)
+	if err != nil {
+		return nil, err
+	}
+
+	if offset == 0 {
+		err = s.store.ReplaceSyncTransfer(ctx, s.pairName, db.SyncTransfer{Path: p, RemoteHash: e.Hash})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rc, err := s.remote.Get(ctx, s.remoteName(p), offset)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rc.Close() }()
+
+	f, err := os.OpenFile(to+partialSuffix, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// nolint: gosec
+	h := sha1.New()
+
+	// the contents downloaded by the interrupted sync are only hashed, and the next ones written
+	// after them.
+	_, err = io.CopyN(h, f, offset)
+	if err == nil {
+		err = f.Truncate(offset)
+	}
+
+	var n int64
+	if err == nil {
+		n, err = io.Copy(io.MultiWriter(f, h), s.limiter.reader(ctx, directionDownload, rc))
+		s.addTransferred(directionDownload, n)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = s.replaceLocal(p)
+	}
+	if err == nil {
+		err = s.downloadMetadata(p, e)
+	}
+	if err != nil {
+		// the partial file is kept for the next sync to resume the download.
+		return nil, errors.Wrap(err, "downloading the file from pCloud")
+	}
+
+	err = s.store.DeleteSyncTransfer(ctx, s.pairName, p, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &db.SyncStateEntry{
+		Path:       p,
+		Size:       uint64(offset + n),
+		LocalHash:  fmt.Sprintf("%x", h.Sum(nil)),
+		RemoteHash: e.Hash,
+	}, nil
+}
+
+// resumedDownload returns the size of the partial local file of the download of the remote file
+// p, whose scanned entry is e, that an interrupted sync left in progress. It returns 0 when there
+// is none, or when it cannot be resumed.
+func (s *TwoWay) resumedDownload(ctx context.Context, p string, e *db.FSEntry) (int64, error) {
+	t, err := s.store.GetSyncTransfer(ctx, s.pairName, p, false)
+	if err != nil || t == nil || t.RemoteHash != e.Hash {
+		return 0, err
+	}
+
+	info, err := os.Stat(s.localPath(p) + partialSuffix)
+	if err != nil || uint64(info.Size()) > e.Size {
+		return 0, nil
+	}
+
+	if info.Size() > 0 {
+		s.logger.Info("resuming the download", zap.String("path", p), zap.Int64("offset", info.Size()))
+	}
+
+	return info.Size(), nil
+}
+
+// downloadMetadata applies the metadata of the remote file p, of scanned entry e, to its
+// downloaded copy: its modification time, and its recorded mode. The links synced as placeholders
+// are left as they are.
+func (s *TwoWay) downloadMetadata(p string, e *db.FSEntry) error {
+	if _, ok := s.placeholder(p); ok {
+		return nil
+	}
+
+	to := s.localPath(p)
+
+	if s.modes != nil {
+		if mode, ok := s.modes.get(p); ok {
+			err := os.Chmod(to, mode)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+		}
+	}
+
+	if e.Modified.IsZero() {
+		return nil
+	}
+
+	return errors.WithStack(os.Chtimes(to, e.Modified, e.Modified))
+}
+
+// placeholder returns the target of the local file p when it is a symbolic link synced as a
+// placeholder file, which holds its target.
+func (s *TwoWay) placeholder(p string) (string, bool) {
+	if s.symlinks != filesystem.SymlinkPlaceholder {
+		return "", false
+	}
+
+	target, err := os.Readlink(s.localPath(p))
+
+	return target, err == nil
+}
+
+// replaceLocal replaces the local file p with its downloaded partial file. A symbolic link synced
+// as a placeholder is replaced by a link to the target held by the partial file.
+func (s *TwoWay) replaceLocal(p string) error {
+	to := s.localPath(p)
+
+	if _, ok := s.placeholder(p); !ok {
+		return errors.WithStack(os.Rename(to+partialSuffix, to))
+	}
+
+	target, err := os.ReadFile(to + partialSuffix) // nolint: gosec
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	err = os.Remove(to)
+	if err == nil {
+		err = os.Symlink(string(target), to)
+	}
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return errors.WithStack(os.Remove(to + partialSuffix))
+}
+
+// localPath returns the path of the local file or folder of the canonical path p.
+func (s *TwoWay) localPath(p string) string {
+	return filepath.Join(s.localRoot, filepath.FromSlash(actualPath(s.localNames, p)))
+}
+
+// remotePath returns the path of the pCloud file or folder of the canonical path p.
+func (s *TwoWay) remotePath(p string) string {
+	return path.Join(s.remoteRoot, s.remoteName(p))
+}
+
+// remoteName returns the path of the pCloud file or folder of the canonical path p, relative to
+// the pCloud root.
+func (s *TwoWay) remoteName(p string) string {
+	return actualPath(s.remoteNames, p)
+}
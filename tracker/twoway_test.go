@@ -0,0 +1,894 @@
+package tracker_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/seborama/pcloud-sdk/sdk"
+	"github.com/seborama/pcloud-sdk/sdk/sdktest"
+	"github.com/seborama/pcloud-sdk/tracker"
+	"github.com/seborama/pcloud-sdk/tracker/db"
+	"github.com/seborama/pcloud-sdk/tracker/filesystem"
+)
+
+func newTestTwoWay(t *testing.T, opts ...tracker.TwoWayOption) (*sdktest.Server, *db.SQLite3, string, *tracker.TwoWay) {
+	t.Helper()
+
+	srv := sdktest.NewServer()
+	t.Cleanup(srv.Close)
+
+	store, err := db.NewSQLite3(context.Background(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+
+	local := t.TempDir()
+
+	opts = append([]tracker.TwoWayOption{tracker.WithHTTPClient(srv.Client())}, opts...)
+
+	return srv, store, local, tracker.NewTwoWay(zap.NewNop(), store, srv.NewClient(), "test", local, "/Sync", opts...)
+}
+
+func writeLocalFile(t *testing.T, root, p, data string) {
+	t.Helper()
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(root, p)), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(root, p), []byte(data), 0o600))
+}
+
+func readLocalFile(t *testing.T, root, p string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join(root, p))
+	require.NoError(t, err)
+
+	return string(data)
+}
+
+func TestTwoWay_Sync(t *testing.T) {
+	ctx := context.Background()
+	srv, _, local, s := newTestTwoWay(t)
+	pcc := srv.NewClient()
+
+	// the first sync merges both sides: the files that only exist on one side are copied to the
+	// other, the identical files are left as they are and the different ones are conflicts.
+	writeLocalFile(t, local, "a.txt", "a")
+	writeLocalFile(t, local, "same.txt", "same")
+	writeLocalFile(t, local, "diff.txt", "local")
+	for p, data := range map[string]string{"b.txt": "b", "same.txt": "same", "diff.txt": "remote!", "Sub/c.txt": "c"} {
+		_, err := srv.WriteFile("/Sync/"+p, []byte(data))
+		require.NoError(t, err)
+	}
+
+	stats, err := s.Sync(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &tracker.SyncStats{Uploaded: 1, Downloaded: 2, Conflicts: []string{"diff.txt"}}, stats)
+
+	data, err := srv.ReadFile("/Sync/a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "a", string(data))
+	assert.Equal(t, "b", readLocalFile(t, local, "b.txt"))
+	assert.Equal(t, "c", readLocalFile(t, local, "Sub/c.txt"))
+	assert.Equal(t, "local", readLocalFile(t, local, "diff.txt"))
+
+	// nothing changed since: only the conflict remains.
+	stats, err = s.Sync(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &tracker.SyncStats{Conflicts: []string{"diff.txt"}}, stats)
+
+	// the changes of either side are applied to the other one.
+	writeLocalFile(t, local, "a.txt", "a2")
+	require.NoError(t, os.Remove(filepath.Join(local, "b.txt")))
+	_, err = srv.WriteFile("/Sync/Sub/c.txt", []byte("c2"))
+	require.NoError(t, err)
+	_, err = pcc.DeleteFile(ctx, sdk.ByPath("/Sync/same.txt"))
+	require.NoError(t, err)
+	_, err = srv.MkdirAll("/Sync/Empty")
+	require.NoError(t, err)
+
+	stats, err = s.Sync(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &tracker.SyncStats{Uploaded: 1, Downloaded: 1, DeletedLocal: 1, DeletedRemote: 1, Conflicts: []string{"diff.txt"}}, stats)
+
+	data, err = srv.ReadFile("/Sync/a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "a2", string(data))
+	_, err = srv.ReadFile("/Sync/b.txt")
+	assert.Error(t, err)
+	assert.Equal(t, "c2", readLocalFile(t, local, "Sub/c.txt"))
+	assert.NoFileExists(t, filepath.Join(local, "same.txt"))
+	assert.DirExists(t, filepath.Join(local, "Empty"))
+
+	// a folder deleted on one side is kept when the other side added contents to it.
+	require.NoError(t, os.RemoveAll(filepath.Join(local, "Sub")))
+	_, err = srv.WriteFile("/Sync/Sub/d.txt", []byte("d"))
+	require.NoError(t, err)
+
+	stats, err = s.Sync(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &tracker.SyncStats{Downloaded: 1, DeletedRemote: 1, Conflicts: []string{"diff.txt"}}, stats)
+
+	assert.Equal(t, "d", readLocalFile(t, local, "Sub/d.txt"))
+	_, err = srv.ReadFile("/Sync/Sub/c.txt")
+	assert.Error(t, err)
+
+	// the resolved conflict is synced like any other file.
+	writeLocalFile(t, local, "diff.txt", "remote!")
+
+	stats, err = s.Sync(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &tracker.SyncStats{}, stats)
+
+	writeLocalFile(t, local, "diff.txt", "resolved")
+
+	stats, err = s.Sync(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &tracker.SyncStats{Uploaded: 1}, stats)
+
+	data, err = srv.ReadFile("/Sync/diff.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "resolved", string(data))
+}
+
+func TestTwoWay_Sync_Limits(t *testing.T) {
+	ctx := context.Background()
+	limiter := tracker.NewLimiter(tracker.TransferLimits{UploadConcurrency: 4, DownloadConcurrency: 3})
+	srv, _, local, s := newTestTwoWay(t, tracker.WithLimiter(limiter))
+
+	// the files are transferred concurrently, within the folders created before them.
+	for i := 0; i < 10; i++ {
+		writeLocalFile(t, local, fmt.Sprintf("Up/%d.txt", i), fmt.Sprintf("up %d", i))
+		_, err := srv.WriteFile(fmt.Sprintf("/Sync/Down/%d.txt", i), []byte(fmt.Sprintf("down %d", i)))
+		require.NoError(t, err)
+	}
+
+	stats, err := s.Sync(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &tracker.SyncStats{Uploaded: 10, Downloaded: 10}, stats)
+
+	for i := 0; i < 10; i++ {
+		data, err := srv.ReadFile(fmt.Sprintf("/Sync/Up/%d.txt", i))
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("up %d", i), string(data))
+		assert.Equal(t, fmt.Sprintf("down %d", i), readLocalFile(t, local, fmt.Sprintf("Down/%d.txt", i)))
+	}
+
+	// the deletions wait for the transfers.
+	require.NoError(t, os.RemoveAll(filepath.Join(local, "Up")))
+	writeLocalFile(t, local, "Down/new.txt", "new")
+
+	stats, err = s.Sync(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &tracker.SyncStats{Uploaded: 1, DeletedRemote: 11}, stats)
+
+	stats, err = s.Sync(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &tracker.SyncStats{}, stats)
+}
+
+func TestTwoWay_Sync_ConflictPolicies(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		policy     tracker.ConflictPolicy
+		ask        tracker.ConflictAsker
+		localMTime time.Time
+		resolution tracker.ConflictPolicy
+		stats      *tracker.SyncStats
+		local      map[string]string
+		remote     map[string]string
+	}{
+		{
+			policy:     tracker.ConflictSkip,
+			resolution: tracker.ConflictSkip,
+			stats:      &tracker.SyncStats{Conflicts: []string{"a.txt"}},
+			local:      map[string]string{"a.txt": "local"},
+			remote:     map[string]string{"a.txt": "remote!"},
+		},
+		{
+			policy:     tracker.ConflictPreferLocal,
+			resolution: tracker.ConflictPreferLocal,
+			stats:      &tracker.SyncStats{Uploaded: 1, ResolvedConflicts: 1},
+			local:      map[string]string{"a.txt": "local"},
+			remote:     map[string]string{"a.txt": "local"},
+		},
+		{
+			policy:     tracker.ConflictPreferRemote,
+			resolution: tracker.ConflictPreferRemote,
+			stats:      &tracker.SyncStats{Downloaded: 1, ResolvedConflicts: 1},
+			local:      map[string]string{"a.txt": "remote!"},
+			remote:     map[string]string{"a.txt": "remote!"},
+		},
+		{
+			policy:     tracker.ConflictKeepNewest,
+			localMTime: time.Now().Add(time.Hour),
+			resolution: tracker.ConflictPreferLocal,
+			stats:      &tracker.SyncStats{Uploaded: 1, ResolvedConflicts: 1},
+			local:      map[string]string{"a.txt": "local"},
+			remote:     map[string]string{"a.txt": "local"},
+		},
+		{
+			policy:     tracker.ConflictKeepNewest,
+			localMTime: time.Now().Add(-time.Hour),
+			resolution: tracker.ConflictPreferRemote,
+			stats:      &tracker.SyncStats{Downloaded: 1, ResolvedConflicts: 1},
+			local:      map[string]string{"a.txt": "remote!"},
+			remote:     map[string]string{"a.txt": "remote!"},
+		},
+		{
+			policy: tracker.ConflictAsk,
+			ask: func(_ context.Context, c tracker.Conflict) (tracker.ConflictPolicy, error) {
+				if c.Path != "a.txt" || c.LocalSize != 5 || c.RemoteSize != 7 {
+					return "", errors.Errorf("unexpected conflict: %+v", c)
+				}
+				return tracker.ConflictKeepBoth, nil
+			},
+			resolution: tracker.ConflictKeepBoth,
+			stats:      &tracker.SyncStats{Uploaded: 1, Downloaded: 1, ResolvedConflicts: 1},
+			local:      map[string]string{"a.txt": "remote!", "a (local conflict *).txt": "local"},
+			remote:     map[string]string{"a.txt": "remote!", "a (local conflict *).txt": "local"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(string(tt.policy)+"_"+string(tt.resolution), func(t *testing.T) {
+			srv, store, local, s := newTestTwoWay(t, tracker.WithConflictPolicy(tt.policy), tracker.WithConflictAsker(tt.ask))
+
+			writeLocalFile(t, local, "a.txt", "local")
+			if !tt.localMTime.IsZero() {
+				require.NoError(t, os.Chtimes(filepath.Join(local, "a.txt"), tt.localMTime, tt.localMTime))
+			}
+			_, err := srv.WriteFile("/Sync/a.txt", []byte("remote!"))
+			require.NoError(t, err)
+
+			stats, err := s.Sync(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, tt.stats, stats)
+
+			// the copies of keep-both are named after the time of the sync.
+			localFiles := map[string]string{}
+			remoteFiles := map[string]string{}
+			entries, err := os.ReadDir(local)
+			require.NoError(t, err)
+			for _, e := range entries {
+				data, err := srv.ReadFile("/Sync/" + e.Name())
+				require.NoError(t, err)
+
+				name := e.Name()
+				if strings.Contains(name, "(local conflict ") {
+					name = "a (local conflict *).txt"
+				}
+				localFiles[name] = readLocalFile(t, local, e.Name())
+				remoteFiles[name] = string(data)
+			}
+			assert.Equal(t, tt.local, localFiles)
+			assert.Equal(t, tt.remote, remoteFiles)
+
+			conflicts, err := store.GetSyncConflicts(ctx, "test")
+			require.NoError(t, err)
+			require.Len(t, conflicts, 1)
+			assert.Equal(t, "a.txt", conflicts[0].Path)
+			assert.Equal(t, string(tt.policy), conflicts[0].Policy)
+			assert.Equal(t, string(tt.resolution), conflicts[0].Resolution)
+
+			// resolved conflicts are in sync afterwards.
+			stats, err = s.Sync(ctx)
+			require.NoError(t, err)
+			if tt.resolution != tracker.ConflictSkip {
+				assert.Equal(t, &tracker.SyncStats{}, stats)
+			}
+		})
+	}
+}
+
+func TestTwoWay_Sync_Moves(t *testing.T) {
+	ctx := context.Background()
+	srv, _, local, s := newTestTwoWay(t)
+	pcc := srv.NewClient()
+
+	writeLocalFile(t, local, "a.txt", "a")
+	writeLocalFile(t, local, "b.txt", "b")
+	writeLocalFile(t, local, "c.txt", "c")
+
+	_, err := s.Sync(ctx)
+	require.NoError(t, err)
+
+	fa, err := pcc.Stat(ctx, sdk.ByPath("/Sync/a.txt"))
+	require.NoError(t, err)
+
+	// the files moved on one side are moved on the other side, rather than copied again.
+	require.NoError(t, os.MkdirAll(filepath.Join(local, "Moved"), 0o700))
+	require.NoError(t, os.Rename(filepath.Join(local, "a.txt"), filepath.Join(local, "Moved", "a2.txt")))
+	_, err = srv.MkdirAll("/Sync/Sub")
+	require.NoError(t, err)
+	_, err = pcc.RenameFile(ctx, sdk.ByPath("/Sync/b.txt"), sdk.ByPath("/Sync/Sub/b2.txt"))
+	require.NoError(t, err)
+	// a file moved and changed is not a move.
+	require.NoError(t, os.Remove(filepath.Join(local, "c.txt")))
+	writeLocalFile(t, local, "c2.txt", "c2")
+
+	stats, err := s.Sync(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &tracker.SyncStats{Uploaded: 1, DeletedRemote: 1, MovedLocal: 1, MovedRemote: 1}, stats)
+
+	fa2, err := pcc.Stat(ctx, sdk.ByPath("/Sync/Moved/a2.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, fa.Metadata.FileID, fa2.Metadata.FileID)
+	assert.Equal(t, "b", readLocalFile(t, local, "Sub/b2.txt"))
+	assert.NoFileExists(t, filepath.Join(local, "b.txt"))
+	_, err = srv.ReadFile("/Sync/c.txt")
+	assert.Error(t, err)
+
+	stats, err = s.Sync(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &tracker.SyncStats{}, stats)
+}
+
+func TestTwoWay_Sync_Direction(t *testing.T) {
+	ctx := context.Background()
+	srv, _, local, s := newTestTwoWay(t, tracker.WithDirection(tracker.DirectionUpload))
+
+	writeLocalFile(t, local, "a.txt", "a")
+	writeLocalFile(t, local, "c.txt", "c")
+	_, err := srv.WriteFile("/Sync/b.txt", []byte("b"))
+	require.NoError(t, err)
+
+	// the upload syncs only apply the local changes.
+	stats, err := s.Sync(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &tracker.SyncStats{Uploaded: 2}, stats)
+	assert.NoFileExists(t, filepath.Join(local, "b.txt"))
+
+	// the conflicts are resolved in favour of the local files.
+	writeLocalFile(t, local, "a.txt", "a2")
+	_, err = srv.WriteFile("/Sync/a.txt", []byte("a3"))
+	require.NoError(t, err)
+	_, err = srv.WriteFile("/Sync/c.txt", []byte("c2"))
+	require.NoError(t, err)
+
+	stats, err = s.Sync(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &tracker.SyncStats{Uploaded: 1, ResolvedConflicts: 1}, stats)
+
+	data, err := srv.ReadFile("/Sync/a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "a2", string(data))
+	assert.Equal(t, "c", readLocalFile(t, local, "c.txt"))
+}
+
+func TestTwoWay_Sync_Unicode(t *testing.T) {
+	ctx := context.Background()
+	srv, _, local, s := newTestTwoWay(t)
+
+	// the names decomposed by macOS (NFD) are the same files as their composed forms in pCloud
+	// (NFC).
+	const nfd, nfc = "Re\u0301sume\u0301", "R\u00e9sum\u00e9"
+
+	writeLocalFile(t, local, nfd+"/cv.txt", "cv")
+	writeLocalFile(t, local, nfd+"/local.txt", "local")
+	for p, data := range map[string]string{"/Sync/" + nfc + "/cv.txt": "cv", "/Sync/" + nfc + "/remote.txt": "remote"} {
+		_, err := srv.WriteFile(p, []byte(data))
+		require.NoError(t, err)
+	}
+
+	stats, err := s.Sync(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &tracker.SyncStats{Uploaded: 1, Downloaded: 1}, stats)
+
+	// the files are written under the names of either side.
+	assert.Equal(t, "remote", readLocalFile(t, local, nfd+"/remote.txt"))
+	assert.NoDirExists(t, filepath.Join(local, nfc))
+	data, err := srv.ReadFile("/Sync/" + nfc + "/local.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "local", string(data))
+	_, err = srv.ReadFile("/Sync/" + nfd + "/cv.txt")
+	require.Error(t, err)
+
+	actions, err := s.Plan(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, actions)
+
+	writeLocalFile(t, local, nfd+"/cv.txt", "cv2")
+
+	stats, err = s.Sync(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &tracker.SyncStats{Uploaded: 1}, stats)
+	data, err = srv.ReadFile("/Sync/" + nfc + "/cv.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "cv2", string(data))
+}
+
+func TestTwoWay_Sync_Ignore(t *testing.T) {
+	ctx := context.Background()
+
+	global := filepath.Join(t.TempDir(), "ignore")
+	require.NoError(t, os.WriteFile(global, []byte("*.tmp\n"), 0o600))
+
+	srv, _, local, s := newTestTwoWay(t, tracker.WithIgnoreFile(global))
+
+	writeLocalFile(t, local, tracker.IgnoreFileName, "node_modules/\n")
+	writeLocalFile(t, local, "a.txt", "a")
+	writeLocalFile(t, local, "a.tmp", "tmp")
+	writeLocalFile(t, local, "node_modules/x/y.js", "js")
+	_, err := srv.WriteFile("/Sync/b.tmp", []byte("tmp"))
+	require.NoError(t, err)
+	_, err = srv.WriteFile("/Sync/node_modules/z.js", []byte("js"))
+	require.NoError(t, err)
+
+	stats, err := s.Sync(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &tracker.SyncStats{Uploaded: 2}, stats)
+
+	_, err = srv.ReadFile("/Sync/" + tracker.IgnoreFileName)
+	require.NoError(t, err)
+	_, err = srv.ReadFile("/Sync/a.tmp")
+	assert.Error(t, err)
+	_, err = srv.ReadFile("/Sync/node_modules/x/y.js")
+	assert.Error(t, err)
+	assert.NoFileExists(t, filepath.Join(local, "b.tmp"))
+	assert.NoFileExists(t, filepath.Join(local, "node_modules", "z.js"))
+
+	// the files that become ignored are left as they are on both sides.
+	writeLocalFile(t, local, tracker.IgnoreFileName, "node_modules/\na.txt\n")
+
+	stats, err = s.Sync(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &tracker.SyncStats{Uploaded: 1}, stats)
+
+	assert.FileExists(t, filepath.Join(local, "a.txt"))
+	_, err = srv.ReadFile("/Sync/a.txt")
+	require.NoError(t, err)
+}
+
+// listCounter counts the recursive listings of the pCloud folders.
+type listCounter struct {
+	*sdk.Client
+	listings int
+}
+
+func (c *listCounter) List(ctx context.Context, folder sdk.FolderRef, o sdk.ListOptions, opts ...sdk.ClientOption) (*sdk.FSList, error) {
+	if o.Recursive {
+		c.listings++
+	}
+
+	return c.Client.List(ctx, folder, o, opts...)
+}
+
+func TestTwoWay_Sync_RemoteDiff(t *testing.T) {
+	ctx := context.Background()
+	srv, store, local, _ := newTestTwoWay(t)
+	pcc := srv.NewClient()
+	lc := &listCounter{Client: srv.NewClient()}
+	s := tracker.NewTwoWay(zap.NewNop(), store, lc, "test", local, "/Sync", tracker.WithHTTPClient(srv.Client()))
+
+	writeLocalFile(t, local, "c.txt", "c")
+	for p, data := range map[string]string{"/Sync/a.txt": "a", "/Sync/Dir/b.txt": "b", "/Other/x.txt": "x"} {
+		_, err := srv.WriteFile(p, []byte(data))
+		require.NoError(t, err)
+	}
+
+	_, err := s.Sync(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, lc.listings)
+
+	remote, err := store.GetSyncRemote(ctx, "test")
+	require.NoError(t, err)
+	require.NotNil(t, remote)
+
+	// c.txt was uploaded after the listing.
+	var saved []string
+	err = store.EachSyncRemote(ctx, "test", db.ByPath, func(p string, _ db.FSEntry) error {
+		saved = append(saved, p)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Dir", "Dir/b.txt", "a.txt"}, saved)
+
+	// the changes of the pCloud folder are read from the diff of the account.
+	_, err = srv.WriteFile("/Sync/a.txt", []byte("a2"))
+	require.NoError(t, err)
+	_, err = pcc.RenameFolder(ctx, sdk.ByPath("/Sync/Dir"), sdk.ByPath("/Sync/Dir2"))
+	require.NoError(t, err)
+	_, err = pcc.DeleteFile(ctx, sdk.ByPath("/Sync/c.txt"))
+	require.NoError(t, err)
+	_, err = srv.WriteFile("/Other/y.txt", []byte("y"))
+	require.NoError(t, err)
+
+	_, err = s.Sync(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, lc.listings)
+
+	assert.Equal(t, "a2", readLocalFile(t, local, "a.txt"))
+	assert.Equal(t, "b", readLocalFile(t, local, "Dir2/b.txt"))
+	assert.NoDirExists(t, filepath.Join(local, "Dir"))
+	assert.NoFileExists(t, filepath.Join(local, "c.txt"))
+	assert.NoFileExists(t, filepath.Join(local, "y.txt"))
+
+	// the contents of a folder moved into the pCloud folder are unknown: the folder is listed.
+	_, err = srv.WriteFile("/Other/In/z.txt", []byte("z"))
+	require.NoError(t, err)
+	// sdktest records the events when diff is called: the folder is moved rather than created.
+	_, err = pcc.Changes(ctx, 0, sdk.ChangesOptions{Last: 1})
+	require.NoError(t, err)
+	_, err = pcc.RenameFolder(ctx, sdk.ByPath("/Other/In"), sdk.ByPath("/Sync/In"))
+	require.NoError(t, err)
+
+	_, err = s.Sync(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, lc.listings)
+	assert.Equal(t, "z", readLocalFile(t, local, "In/z.txt"))
+
+	// and the pCloud folder moved out of the pair is not synced as deleted.
+	_, err = pcc.RenameFolder(ctx, sdk.ByPath("/Sync"), sdk.ByPath("/Moved"))
+	require.NoError(t, err)
+
+	_, err = s.Sync(ctx)
+	require.Error(t, err)
+	assert.FileExists(t, filepath.Join(local, "a.txt"))
+}
+
+func TestTwoWay_Sync_History(t *testing.T) {
+	ctx := context.Background()
+	srv, store, local, s := newTestTwoWay(t)
+
+	writeLocalFile(t, local, "a.txt", "aaa")
+	_, err := srv.WriteFile("/Sync/Sub/b.txt", []byte("bb"))
+	require.NoError(t, err)
+
+	_, err = s.Sync(ctx)
+	require.NoError(t, err)
+
+	runs, err := store.GetSyncRuns(ctx, "test", 10)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.False(t, runs[0].Started.IsZero())
+	assert.Positive(t, runs[0].Duration)
+	runs[0].Started, runs[0].Duration = time.Time{}, 0
+	assert.Equal(t, db.SyncRun{Scanned: 3, Uploaded: 1, UploadedBytes: 3, Downloaded: 1, DownloadedBytes: 2}, runs[0])
+
+	// the failed syncs are recorded too, the most recent first.
+	require.NoError(t, os.RemoveAll(local))
+
+	_, err = s.Sync(ctx)
+	require.Error(t, err)
+
+	runs, err = store.GetSyncRuns(ctx, "test", 10)
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	assert.NotEmpty(t, runs[0].Error)
+	assert.Empty(t, runs[1].Error)
+
+	runs, err = store.GetSyncRuns(ctx, "test", 1)
+	require.NoError(t, err)
+	assert.Len(t, runs, 1)
+}
+
+func TestTwoWay_Sync_ResumeDownload(t *testing.T) {
+	ctx := context.Background()
+	srv, store, local, s := newTestTwoWay(t)
+	pcc := srv.NewClient()
+
+	fileID, err := srv.WriteFile("/Sync/big.bin", []byte("0123456789"))
+	require.NoError(t, err)
+	fr, err := pcc.Stat(ctx, sdk.ByID(fileID))
+	require.NoError(t, err)
+
+	// an interrupted sync left the first bytes of the file, which are not downloaded again: the
+	// partial file is made different from them to tell.
+	require.NoError(t, store.ReplaceSyncTransfer(ctx, "test", db.SyncTransfer{Path: "big.bin", RemoteHash: fmt.Sprint(fr.Metadata.Hash)}))
+	writeLocalFile(t, local, "big.bin.pcloud-partial", "abcd")
+
+	stats, err := s.Sync(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &tracker.SyncStats{Downloaded: 1}, stats)
+	assert.Equal(t, "abcd456789", readLocalFile(t, local, "big.bin"))
+	assert.NoFileExists(t, filepath.Join(local, "big.bin.pcloud-partial"))
+
+	transfer, err := store.GetSyncTransfer(ctx, "test", "big.bin", false)
+	require.NoError(t, err)
+	assert.Nil(t, transfer)
+
+	// the partial file of another version of the pCloud file is downloaded again.
+	_, err = srv.WriteFile("/Sync/other.bin", []byte("0123456789"))
+	require.NoError(t, err)
+	require.NoError(t, store.ReplaceSyncTransfer(ctx, "test", db.SyncTransfer{Path: "other.bin", RemoteHash: "123"}))
+	writeLocalFile(t, local, "other.bin.pcloud-partial", "abcd")
+
+	stats, err = s.Sync(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &tracker.SyncStats{Downloaded: 1}, stats)
+	assert.Equal(t, "0123456789", readLocalFile(t, local, "other.bin"))
+}
+
+func TestTwoWay_Sync_ResumeUpload(t *testing.T) {
+	ctx := context.Background()
+	srv, store, local, s := newTestTwoWay(t, tracker.WithResumableUploadSize(1))
+
+	writeLocalFile(t, local, "big.bin", "0123456789")
+	info, err := os.Stat(filepath.Join(local, "big.bin"))
+	require.NoError(t, err)
+
+	// an interrupted sync uploaded the first bytes of the file to its partial pCloud file.
+	partialID, err := srv.WriteFile("/Sync/big.bin.pcloud-partial", []byte("0123"))
+	require.NoError(t, err)
+	transfer := db.SyncTransfer{Path: "big.bin", Upload: true, Size: info.Size(), Modified: info.ModTime(), FileID: partialID}
+	require.NoError(t, store.ReplaceSyncTransfer(ctx, "test", transfer))
+
+	stats, err := s.Sync(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &tracker.SyncStats{Uploaded: 1}, stats)
+
+	data, err := srv.ReadFile("/Sync/big.bin")
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789", string(data))
+	_, err = srv.ReadFile("/Sync/big.bin.pcloud-partial")
+	assert.Error(t, err)
+
+	got, err := store.GetSyncTransfer(ctx, "test", "big.bin", true)
+	require.NoError(t, err)
+	assert.Nil(t, got)
+
+	// a partial file that does not match the local file fails the upload, which the next sync
+	// starts again.
+	writeLocalFile(t, local, "other.bin", "0123456789")
+	info, err = os.Stat(filepath.Join(local, "other.bin"))
+	require.NoError(t, err)
+	partialID, err = srv.WriteFile("/Sync/other.bin.pcloud-partial", []byte("abcd"))
+	require.NoError(t, err)
+	transfer = db.SyncTransfer{Path: "other.bin", Upload: true, Size: info.Size(), Modified: info.ModTime(), FileID: partialID}
+	require.NoError(t, store.ReplaceSyncTransfer(ctx, "test", transfer))
+
+	stats, err = s.Sync(ctx)
+	require.Error(t, err)
+	assert.Equal(t, 1, stats.Errors)
+
+	stats, err = s.Sync(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &tracker.SyncStats{Uploaded: 1}, stats)
+
+	data, err = srv.ReadFile("/Sync/other.bin")
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789", string(data))
+}
+
+func TestTwoWay_Sync_Metadata(t *testing.T) {
+	ctx := context.Background()
+	srv, _, local, s := newTestTwoWay(t, tracker.WithFileModes(true))
+	pcc := srv.NewClient()
+
+	mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	writeLocalFile(t, local, "bin/run.sh", "#!/bin/sh")
+	require.NoError(t, os.Chmod(filepath.Join(local, "bin/run.sh"), 0o750))
+	require.NoError(t, os.Chtimes(filepath.Join(local, "bin/run.sh"), mtime, mtime))
+
+	stats, err := s.Sync(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &tracker.SyncStats{Uploaded: 1}, stats)
+
+	// the modification time is that of the local file, and the mode is in the metadata file.
+	fr, err := pcc.Stat(ctx, sdk.ByPath("/Sync/bin/run.sh"))
+	require.NoError(t, err)
+	assert.True(t, mtime.Equal(fr.Metadata.Modified), fr.Metadata.Modified)
+
+	data, err := srv.ReadFile("/Sync/" + tracker.MetadataFileName)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"modes": {"bin/run.sh": "0750"}}`, string(data))
+
+	// a restore of the pCloud folder to another local folder looks like the original.
+	store, err := db.NewSQLite3(ctx, t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+
+	restored := t.TempDir()
+	s = tracker.NewTwoWay(zap.NewNop(), store, pcc, "restore", restored, "/Sync", tracker.WithHTTPClient(srv.Client()), tracker.WithFileModes(true))
+
+	stats, err = s.Sync(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &tracker.SyncStats{Downloaded: 1}, stats)
+
+	info, err := os.Stat(filepath.Join(restored, "bin/run.sh"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o750), info.Mode().Perm())
+	assert.True(t, mtime.Equal(info.ModTime()), info.ModTime())
+	assert.NoFileExists(t, filepath.Join(restored, tracker.MetadataFileName))
+
+	// the modes follow the files that are moved and deleted.
+	require.NoError(t, os.Rename(filepath.Join(restored, "bin"), filepath.Join(restored, "scripts")))
+
+	stats, err = s.Sync(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.MovedRemote)
+
+	data, err = srv.ReadFile("/Sync/" + tracker.MetadataFileName)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"modes": {"scripts/run.sh": "0750"}}`, string(data))
+}
+
+func TestTwoWay_Sync_Deletes(t *testing.T) {
+	ctx := context.Background()
+	srv, store, local, s := newTestTwoWay(t)
+	pcc := srv.NewClient()
+
+	for i := 0; i < 20; i++ {
+		writeLocalFile(t, local, fmt.Sprintf("Docs/%02d.txt", i), "data")
+	}
+
+	_, err := s.Sync(ctx)
+	require.NoError(t, err)
+
+	// the sync that would delete most files, such as from an empty mount point, applies nothing.
+	for i := 0; i < 15; i++ {
+		require.NoError(t, os.Remove(filepath.Join(local, fmt.Sprintf("Docs/%02d.txt", i))))
+	}
+
+	_, err = s.Sync(ctx)
+	require.ErrorIs(t, err, tracker.ErrTooManyDeletes)
+
+	_, err = srv.ReadFile("/Sync/Docs/00.txt")
+	require.NoError(t, err)
+
+	// with a higher threshold, the deleted files go to the trash.
+	s = tracker.NewTwoWay(zap.NewNop(), store, pcc, "test", local, "/Sync", tracker.WithHTTPClient(srv.Client()), tracker.WithDeleteThreshold(80))
+
+	stats, err := s.Sync(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &tracker.SyncStats{DeletedRemote: 15}, stats)
+
+	lf, err := pcc.ListTrash(ctx, sdk.ListTrashOptions{})
+	require.NoError(t, err)
+	assert.Len(t, lf.Metadata.Contents, 15)
+
+	// the permanent deletions do not.
+	s = tracker.NewTwoWay(zap.NewNop(), store, pcc, "test", local, "/Sync", tracker.WithHTTPClient(srv.Client()), tracker.WithPermanentDeletes(true))
+
+	require.NoError(t, os.Remove(filepath.Join(local, "Docs/15.txt")))
+
+	stats, err = s.Sync(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &tracker.SyncStats{DeletedRemote: 1}, stats)
+
+	lf, err = pcc.ListTrash(ctx, sdk.ListTrashOptions{})
+	require.NoError(t, err)
+	assert.Len(t, lf.Metadata.Contents, 15)
+}
+
+func TestTwoWay_Sync_Symlinks(t *testing.T) {
+	ctx := context.Background()
+	srv, _, local, s := newTestTwoWay(t, tracker.WithSymlinks(filesystem.SymlinkPlaceholder))
+
+	// a link is uploaded as a placeholder file, which holds its target.
+	writeLocalFile(t, local, "a.txt", "a")
+	require.NoError(t, os.Symlink("a.txt", filepath.Join(local, "link")))
+
+	stats, err := s.Sync(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &tracker.SyncStats{Uploaded: 2}, stats)
+
+	data, err := srv.ReadFile("/Sync/link")
+	require.NoError(t, err)
+	assert.Equal(t, "a.txt", string(data))
+
+	// a changed placeholder file changes the target of the link.
+	_, err = srv.WriteFile("/Sync/link", []byte("b.txt"))
+	require.NoError(t, err)
+
+	stats, err = s.Sync(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &tracker.SyncStats{Downloaded: 1}, stats)
+
+	target, err := os.Readlink(filepath.Join(local, "link"))
+	require.NoError(t, err)
+	assert.Equal(t, "b.txt", target)
+
+	stats, err = s.Sync(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &tracker.SyncStats{}, stats)
+}
+
+func TestTwoWay_Sync_HashCache(t *testing.T) {
+	ctx := context.Background()
+
+	srv, store, local, s := newTestTwoWay(t, tracker.WithHashWorkers(2))
+
+	writeLocalFile(t, local, "a.txt", "a")
+	writeLocalFile(t, local, "Sub/b.txt", "b")
+
+	stats, err := s.Sync(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &tracker.SyncStats{Uploaded: 2}, stats)
+
+	hashes, err := store.GetLocalHashes(ctx, "test")
+	require.NoError(t, err)
+	assert.Len(t, hashes, 2)
+
+	// a file of the same size and modification time is not hashed again.
+	info, err := os.Stat(filepath.Join(local, "a.txt"))
+	require.NoError(t, err)
+	writeLocalFile(t, local, "a.txt", "A")
+	require.NoError(t, os.Chtimes(filepath.Join(local, "a.txt"), info.ModTime(), info.ModTime()))
+
+	stats, err = s.Sync(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &tracker.SyncStats{}, stats)
+
+	require.NoError(t, os.Chtimes(filepath.Join(local, "a.txt"), info.ModTime(), info.ModTime().Add(time.Second)))
+	require.NoError(t, os.Remove(filepath.Join(local, "Sub", "b.txt")))
+
+	stats, err = s.Sync(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &tracker.SyncStats{Uploaded: 1, DeletedRemote: 1}, stats)
+
+	data, err := srv.ReadFile("/Sync/a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "A", string(data))
+
+	// the hashes of the deleted files are dropped.
+	hashes, err = store.GetLocalHashes(ctx, "test")
+	require.NoError(t, err)
+	require.Len(t, hashes, 1)
+	assert.Equal(t, "a.txt", hashes[0].Path)
+}
+
+func TestTwoWay_Sync_Selection(t *testing.T) {
+	ctx := context.Background()
+	srv, store, local, _ := newTestTwoWay(t)
+	pcc := srv.NewClient()
+	lc := &listCounter{Client: srv.NewClient()}
+	s := tracker.NewTwoWay(zap.NewNop(), store, lc, "test", local, "/Sync", tracker.WithHTTPClient(srv.Client()))
+
+	require.NoError(t, store.ReplaceSyncSelection(ctx, "test", []string{"A/Deep"}))
+
+	writeLocalFile(t, local, "top.txt", "top")
+	writeLocalFile(t, local, "A/Deep/x.txt", "x")
+	writeLocalFile(t, local, "A/y.txt", "y")
+	for p, data := range map[string]string{"/Sync/A/Deep/r.txt": "r", "/Sync/B/s.txt": "s", "/Other/In/z.txt": "z"} {
+		_, err := srv.WriteFile(p, []byte(data))
+		require.NoError(t, err)
+	}
+
+	stats, err := s.Sync(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &tracker.SyncStats{Uploaded: 1, Downloaded: 1}, stats)
+	assert.Equal(t, 1, lc.listings)
+
+	assert.Equal(t, "r", readLocalFile(t, local, "A/Deep/r.txt"))
+	assert.NoDirExists(t, filepath.Join(local, "B"))
+	_, err = srv.ReadFile("/Sync/A/Deep/x.txt")
+	require.NoError(t, err)
+	_, err = srv.ReadFile("/Sync/A/y.txt")
+	require.Error(t, err)
+
+	// the events outside of the selection are ignored: a folder moved there is not listed.
+	_, err = pcc.Changes(ctx, 0, sdk.ChangesOptions{Last: 1})
+	require.NoError(t, err)
+	_, err = pcc.RenameFolder(ctx, sdk.ByPath("/Other/In"), sdk.ByPath("/Sync/B/In"))
+	require.NoError(t, err)
+	_, err = srv.WriteFile("/Sync/A/Deep/r.txt", []byte("r2"))
+	require.NoError(t, err)
+
+	stats, err = s.Sync(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &tracker.SyncStats{Downloaded: 1}, stats)
+	assert.Equal(t, 1, lc.listings)
+	assert.Equal(t, "r2", readLocalFile(t, local, "A/Deep/r.txt"))
+
+	// the folders added to the selection are listed.
+	require.NoError(t, store.ReplaceSyncSelection(ctx, "test", []string{"A/Deep", "B"}))
+
+	stats, err = s.Sync(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &tracker.SyncStats{Downloaded: 2}, stats)
+	assert.Equal(t, 2, lc.listings)
+	assert.Equal(t, "z", readLocalFile(t, local, "B/In/z.txt"))
+}
@@ -0,0 +1,464 @@
+package tracker
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/seborama/pcloud-sdk/tracker/db"
+	"github.com/seborama/pcloud-sdk/tracker/filesystem"
+)
+
+// DefaultFullScanInterval is the default interval of the syncs of Watch that scan the local folder
+// in full.
+const DefaultFullScanInterval = time.Hour
+
+// watchConfig holds the configuration of Watch.
+type watchConfig struct {
+	delay            time.Duration
+	syncInterval     time.Duration
+	fullScanInterval time.Duration
+	quietHours       []QuietHours
+	report           func(stats *SyncStats, err error)
+}
+
+// WatchOption configures Watch.
+type WatchOption func(*watchConfig)
+
+// WithSyncDelay sets how long Watch waits for the changes of the local folder to settle before
+// it syncs them: each change delays the sync again. It defaults to 2 seconds.
+func WithSyncDelay(d time.Duration) WatchOption {
+	return func(cfg *watchConfig) {
+		cfg.delay = d
+	}
+}
+
+// WithSyncInterval sets the interval of the syncs that run without local changes, which sync the
+// changes of the pCloud folder. It defaults to 0, which disables them.
+func WithSyncInterval(d time.Duration) WatchOption {
+	return func(cfg *watchConfig) {
+		cfg.syncInterval = d
+	}
+}
+
+// WithFullScanInterval sets the interval of the syncs that scan the local folder in full, which
+// catch the changes that were not notified. It defaults to DefaultFullScanInterval. 0 disables
+// them.
+func WithFullScanInterval(d time.Duration) WatchOption {
+	return func(cfg *watchConfig) {
+		cfg.fullScanInterval = d
+	}
+}
+
+// WithQuietHours sets the periods of the day when Watch does not sync: the syncs due then are
+// postponed to the end of the period.
+func WithQuietHours(hours ...QuietHours) WatchOption {
+	return func(cfg *watchConfig) {
+		cfg.quietHours = hours
+	}
+}
+
+// WithSyncReport sets the function that Watch calls with the result of each sync. By default, the
+// errors of the syncs are logged.
+func WithSyncReport(report func(stats *SyncStats, err error)) WatchOption {
+	return func(cfg *watchConfig) {
+		cfg.report = report
+	}
+}
+
+// Watch syncs the pair, then watches the local folder and syncs its changes as they happen,
+// until ctx is done. The syncs that follow a change only scan the changed local paths again,
+// while the changes of the pCloud folder are read from the diff of the account by each sync:
+// they are synced by the next sync, at the latest by the next periodic sync (see
+// WithSyncInterval and WithFullScanInterval).
+//
+// The errors of the syncs do not stop Watch (see WithSyncReport): it only returns the errors of
+// the watch itself.
+// nolint: gocognit, gocyclo
+func (s *TwoWay) Watch(ctx context.Context, opts ...WatchOption) error {
+	cfg := watchConfig{
+		delay:            2 * time.Second,
+		fullScanInterval: DefaultFullScanInterval,
+		report: func(_ *SyncStats, err error) {
+			if err != nil {
+				s.logger.Error("sync failed", zap.Error(err))
+			}
+		},
+	}
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	// the local entries of the syncs are kept in the store while the local folder is watched.
+	s.keepLocal = true
+	defer s.dropLocal()
+
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() { _ = w.Close() }()
+
+	s.ignore, err = s.loadIgnorer(ctx)
+	if err != nil {
+		return err
+	}
+
+	// the local folder is watched before the first sync, so that the changes made during the sync
+	// are synced next. It is only created by the first sync of the pair: it is watched after it.
+	err = s.watchFolder(w, s.localRoot)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	var syncIntervalC, fullScanC <-chan time.Time
+	if cfg.syncInterval > 0 {
+		ticker := time.NewTicker(cfg.syncInterval)
+		defer ticker.Stop()
+		syncIntervalC = ticker.C
+	}
+	if cfg.fullScanInterval > 0 {
+		ticker := time.NewTicker(cfg.fullScanInterval)
+		defer ticker.Stop()
+		fullScanC = ticker.C
+	}
+
+	// changed holds the changed local paths since the last sync. It is nil when the next sync
+	// must scan the local folder in full, starting with the first sync.
+	var changed map[string]struct{}
+	syncC := time.After(0)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event, ok := <-w.Events:
+			if !ok {
+				return nil
+			}
+			if s.watchEvent(w, event, changed) {
+				s.progress.setQueued(len(changed))
+				syncC = time.After(cfg.delay)
+			}
+
+		case err, ok := <-w.Errors:
+			if !ok {
+				return nil
+			}
+			// such as fsnotify.ErrEventOverflow: changes may have been missed.
+			s.logger.Warn("watching the local folder failed, the next sync scans it in full", zap.Error(err))
+			changed = nil
+			syncC = time.After(cfg.delay)
+
+		case <-syncC:
+			if end, ok := quietUntil(cfg.quietHours, time.Now()); ok {
+				s.logger.Info("quiet hours: the sync is postponed", zap.Time("until", end))
+				syncC = time.After(time.Until(end))
+				continue
+			}
+
+			var paths []string
+			if changed != nil {
+				paths = make([]string, 0, len(changed))
+				for p := range changed {
+					paths = append(paths, p)
+				}
+			}
+
+			cfg.report(s.sync(ctx, paths))
+
+			if paths == nil || ignoreFileChanged(paths) {
+				s.rewatch(w)
+			}
+			changed = map[string]struct{}{}
+			s.progress.setQueued(0)
+			syncC = nil
+
+		case <-syncIntervalC:
+			if syncC == nil {
+				syncC = time.After(0)
+			}
+
+		case <-fullScanC:
+			changed = nil
+			syncC = time.After(0)
+		}
+	}
+}
+
+// dropLocal stops keeping the local entries of the syncs in the store, and deletes them.
+func (s *TwoWay) dropLocal() {
+	s.keepLocal = false
+	s.localKept = false
+
+	err := s.store.DeleteSyncLocal(context.Background(), s.pairName, nil)
+	if err != nil {
+		s.logger.Warn("deleting the local entries of the syncs failed", zap.Error(err))
+	}
+}
+
+// watchEvent records the path of the event in changed, when it is a change to sync, and watches
+// the new folders. It returns whether the event is a change to sync.
+func (s *TwoWay) watchEvent(w *fsnotify.Watcher, event fsnotify.Event, changed map[string]struct{}) bool {
+	rel, err := filepath.Rel(s.localRoot, event.Name)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return false
+	}
+
+	if event.Op == fsnotify.Chmod || strings.HasSuffix(event.Name, partialSuffix) {
+		return false
+	}
+
+	if changed != nil {
+		changed[filepath.ToSlash(rel)] = struct{}{}
+	}
+
+	if event.Has(fsnotify.Create) {
+		info, err := os.Lstat(event.Name)
+		if err == nil && info.IsDir() {
+			err = s.watchFolder(w, event.Name)
+			if err != nil {
+				s.logger.Warn("watching a new local folder failed", zap.String("path", event.Name), zap.Error(err))
+			}
+		}
+	}
+
+	return true
+}
+
+// rewatch watches the local folders again after a full sync, such as those that are no longer
+// ignored.
+func (s *TwoWay) rewatch(w *fsnotify.Watcher) {
+	err := s.watchFolder(w, s.localRoot)
+	if err != nil {
+		s.logger.Warn("watching the local folder failed", zap.Error(err))
+	}
+}
+
+// watchFolder watches the local folder dir and its subfolders, except the ignored ones: fsnotify
+// does not watch the subfolders of a folder.
+func (s *TwoWay) watchFolder(w *fsnotify.Watcher, dir string) error {
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p != dir && errors.Is(err, os.ErrNotExist) {
+				// deleted since it was listed.
+				return nil
+			}
+			return errors.WithStack(err)
+		}
+
+		if !d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.localRoot, p)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if rel != "." && s.ignore.ignored(filepath.ToSlash(rel), true) {
+			return filepath.SkipDir
+		}
+
+		return errors.WithStack(w.Add(p))
+	})
+}
+
+// localBatchSize is the number of the local entries that Watch records in the store at once.
+const localBatchSize = 1000
+
+// scanLocal returns the stream of the local entries of the sync. The local folder is scanned in
+// full when changed is nil, when the local entries of the last sync are not kept, or when an
+// ignore file changed. Otherwise, only the paths of changed are scanned again, and the local
+// entries kept in the store are streamed.
+func (s *TwoWay) scanLocal(ctx context.Context, changed []string) (entryFunc, error) {
+	if changed != nil && s.localKept && !ignoreFileChanged(changed) {
+		err := s.rescanLocal(ctx, changed)
+		s.hashes.flush()
+		if err == nil {
+			return s.eachLocal(ctx), nil
+		}
+		s.logger.Warn("scanning the changed local paths failed, scanning the local folder in full", zap.Error(err))
+	}
+
+	s.localKept = false
+
+	if s.keepLocal {
+		err := s.store.DeleteSyncLocal(ctx, s.pairName, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s.walkLocal(ctx), nil
+}
+
+// walkLocal returns the stream of a full scan of the local folder. The hashes of the files that
+// no longer exist are dropped as it is streamed, and the entries are recorded in the store when
+// they are kept.
+func (s *TwoWay) walkLocal(ctx context.Context) entryFunc {
+	return func(fn func(p string, e db.FSEntry) error) error {
+		defer s.hashes.flush()
+
+		pruner := s.hashes.pruner(ctx, s.order)
+		defer pruner.close()
+
+		var kept map[string]db.FSEntry
+		if s.keepLocal {
+			kept = map[string]db.FSEntry{}
+		}
+
+		err := walkEntries(ctx, s.localFS, localFSName, s.localRoot, func(p string, e db.FSEntry) error {
+			pruner.add(p, e)
+
+			if kept != nil {
+				kept[p] = e
+				if len(kept) >= localBatchSize {
+					err := s.store.AddSyncLocal(ctx, s.pairName, kept)
+					if err != nil {
+						return err
+					}
+					kept = map[string]db.FSEntry{}
+				}
+			}
+
+			return fn(p, e)
+		})
+		if err != nil {
+			return err
+		}
+
+		pruner.done()
+
+		if kept != nil {
+			err = s.store.AddSyncLocal(ctx, s.pairName, kept)
+			if err != nil {
+				return err
+			}
+			s.localKept = true
+		}
+
+		return nil
+	}
+}
+
+// eachLocal returns the stream of the local entries kept in the store.
+func (s *TwoWay) eachLocal(ctx context.Context) entryFunc {
+	return func(fn func(p string, e db.FSEntry) error) error {
+		return s.store.EachSyncLocal(ctx, s.pairName, s.order, func(p string, e db.FSEntry) error {
+			e.FSName = localFSName
+			e.Path = filepath.Join(s.localRoot, filepath.FromSlash(path.Dir(p)))
+
+			return fn(p, e)
+		})
+	}
+}
+
+// ignoreFileChanged returns whether one of the changed paths is an ignore file.
+func ignoreFileChanged(changed []string) bool {
+	for _, p := range changed {
+		if path.Base(p) == IgnoreFileName {
+			return true
+		}
+	}
+
+	return false
+}
+
+// rescanLocal updates the local entries kept in the store with the current state of the paths of
+// changed, and of their contents. The local entries are no longer kept when it fails.
+func (s *TwoWay) rescanLocal(ctx context.Context, changed []string) error {
+	s.localKept = false
+
+	// the parents come before their contents, which they rescan.
+	sort.Strings(changed)
+	rescanned := map[string]struct{}{}
+
+	for _, p := range changed {
+		// a path whose parent folder is unknown, such as a file created with its folder, is
+		// rescanned with the parent folder.
+		for dir := path.Dir(p); dir != "."; dir = path.Dir(dir) {
+			e, err := s.store.GetSyncLocalEntry(ctx, s.pairName, dir)
+			if err != nil {
+				return err
+			}
+			if e != nil {
+				break
+			}
+			p = dir
+		}
+
+		if rescannedWith(p, rescanned) {
+			continue
+		}
+		rescanned[p] = struct{}{}
+
+		err := s.store.DeleteSyncLocal(ctx, s.pairName, []string{p})
+		if err != nil {
+			return err
+		}
+
+		// the changed paths are those of the local names, rather than canonical paths.
+		name := filepath.Join(s.localRoot, filepath.FromSlash(p))
+
+		e, err := s.localFS.Stat(localFSName, name)
+		if errors.Is(err, os.ErrNotExist) || errors.Is(err, filesystem.ErrSkipped) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if s.ignore.ignored(p, e.IsFolder) {
+			continue
+		}
+		entries := map[string]db.FSEntry{p: *e}
+
+		if e.IsFolder {
+			err = walkEntries(ctx, s.localFS, localFSName, name, func(rel string, e db.FSEntry) error {
+				entries[p+"/"+rel] = e
+				if len(entries) < localBatchSize {
+					return nil
+				}
+
+				err := s.store.AddSyncLocal(ctx, s.pairName, entries)
+				entries = map[string]db.FSEntry{}
+
+				return err
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		err = s.store.AddSyncLocal(ctx, s.pairName, entries)
+		if err != nil {
+			return err
+		}
+	}
+
+	s.localKept = true
+
+	return nil
+}
+
+// rescannedWith returns whether p or one of its parent folders is in rescanned.
+func rescannedWith(p string, rescanned map[string]struct{}) bool {
+	for ; p != "."; p = path.Dir(p) {
+		if _, ok := rescanned[p]; ok {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,153 @@
+package tracker_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/tracker"
+)
+
+func TestTwoWay_Watch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	srv, _, local, s := newTestTwoWay(t)
+
+	writeLocalFile(t, local, "a.txt", "a")
+
+	reports := make(chan error, 100)
+	done := make(chan error)
+	go func() {
+		done <- s.Watch(ctx,
+			tracker.WithSyncDelay(20*time.Millisecond),
+			tracker.WithFullScanInterval(0),
+			tracker.WithSyncReport(func(_ *tracker.SyncStats, err error) { reports <- err }),
+		)
+	}()
+
+	// waitSync waits for the syncs until the remote file p has the contents data, or is deleted
+	// when data is empty.
+	waitSync := func(p, data string) {
+		t.Helper()
+
+		for {
+			got, err := srv.ReadFile("/Sync/" + p)
+			if data == "" && err != nil || data != "" && string(got) == data {
+				return
+			}
+
+			select {
+			case err := <-reports:
+				require.NoError(t, err)
+			case <-time.After(5 * time.Second):
+				t.Fatalf("timed out waiting for the sync of %s", p)
+			}
+		}
+	}
+
+	require.NoError(t, <-reports)
+	waitSync("a.txt", "a")
+
+	writeLocalFile(t, local, "Sub/Deep/b.txt", "b")
+	waitSync("Sub/Deep/b.txt", "b")
+
+	writeLocalFile(t, local, "a.txt", "a2")
+	waitSync("a.txt", "a2")
+
+	require.NoError(t, os.Rename(filepath.Join(local, "Sub", "Deep", "b.txt"), filepath.Join(local, "Sub", "c.txt")))
+	waitSync("Sub/c.txt", "b")
+	waitSync("Sub/Deep/b.txt", "")
+
+	// the changes of pCloud are synced with those of the local folder.
+	_, err := srv.WriteFile("/Sync/remote.txt", []byte("r"))
+	require.NoError(t, err)
+	require.NoError(t, os.RemoveAll(filepath.Join(local, "Sub")))
+	waitSync("Sub/c.txt", "")
+	assert.Equal(t, "r", readLocalFile(t, local, "remote.txt"))
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestTwoWay_Watch_SyncInterval(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	srv, _, local, s := newTestTwoWay(t)
+
+	reports := make(chan error, 100)
+	done := make(chan error)
+	go func() {
+		done <- s.Watch(ctx,
+			tracker.WithSyncInterval(50*time.Millisecond),
+			tracker.WithFullScanInterval(0),
+			tracker.WithSyncReport(func(_ *tracker.SyncStats, err error) { reports <- err }),
+		)
+	}()
+	require.NoError(t, <-reports)
+
+	// the change of pCloud is synced without a local change.
+	_, err := srv.WriteFile("/Sync/remote.txt", []byte("r"))
+	require.NoError(t, err)
+
+	for !fileExists(filepath.Join(local, "remote.txt")) {
+		select {
+		case err := <-reports:
+			require.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the sync of remote.txt")
+		}
+	}
+	assert.Equal(t, "r", readLocalFile(t, local, "remote.txt"))
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestTwoWay_Watch_QuietHours(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	srv, _, local, s := newTestTwoWay(t)
+
+	writeLocalFile(t, local, "a.txt", "a")
+
+	now := time.Now()
+	minute := now.Hour()*60 + now.Minute()
+	quiet := tracker.QuietHours{Start: (minute + 1439) % 1440, End: (minute + 2) % 1440}
+
+	reports := make(chan error, 100)
+	done := make(chan error)
+	go func() {
+		done <- s.Watch(ctx,
+			tracker.WithSyncDelay(10*time.Millisecond),
+			tracker.WithSyncInterval(10*time.Millisecond),
+			tracker.WithQuietHours(quiet),
+			tracker.WithSyncReport(func(_ *tracker.SyncStats, err error) { reports <- err }),
+		)
+	}()
+
+	// the first sync is postponed to the end of the quiet hours.
+	select {
+	case err := <-reports:
+		t.Fatalf("unexpected sync during the quiet hours: %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	_, err := srv.ReadFile("/Sync/a.txt")
+	require.Error(t, err)
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func fileExists(name string) bool {
+	_, err := os.Stat(name)
+	return err == nil
+}
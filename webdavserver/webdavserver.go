@@ -0,0 +1,287 @@
+// Package webdavserver serves a pCloud folder over WebDAV, so that it can be mounted by the file
+// managers of the desktops (Finder, Windows Explorer, GNOME Files, etc) or used by the tools that
+// speak WebDAV.
+//
+// Handler is the webdav.Handler of golang.org/x/net/webdav over the file system of pcloudfs.
+package webdavserver
+
+import (
+	"context"
+	"crypto/subtle"
+	"io/fs"
+	"log/slog"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/webdav"
+
+	"github.com/seborama/pcloud-sdk/pcloudfs"
+	"github.com/seborama/pcloud-sdk/sdk"
+)
+
+// Option configures a Handler.
+type Option func(*Handler)
+
+// WithLogger sets the logger of the requests that fail.
+func WithLogger(l *slog.Logger) Option {
+	return func(h *Handler) {
+		h.logger = l
+	}
+}
+
+// WithBasicAuth requires the requests to authenticate with HTTP basic authentication, as user
+// with password.
+func WithBasicAuth(user, password string) Option {
+	return func(h *Handler) {
+		h.user, h.password = user, password
+	}
+}
+
+// WithReadOnly makes the Handler refuse the requests that change files or folders, with 403
+// Forbidden.
+func WithReadOnly() Option {
+	return func(h *Handler) {
+		h.readOnly = true
+	}
+}
+
+// readMethods are the methods allowed when the Handler is read-only.
+var readMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+	"PROPFIND":         true,
+}
+
+// Handler is an http.Handler that serves a pcloudfs.FS over WebDAV. The paths of the requests
+// are relative to the root of the FS.
+// The locks of the clients are held in memory: they do not prevent the changes made to pCloud
+// by other means.
+type Handler struct {
+	dav      *webdav.Handler
+	logger   *slog.Logger
+	user     string
+	password string
+	readOnly bool
+}
+
+var _ http.Handler = (*Handler)(nil)
+
+// New creates a Handler that serves fsys.
+func New(fsys *pcloudfs.FS, opts ...Option) *Handler {
+	h := &Handler{}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	h.dav = &webdav.Handler{
+		FileSystem: &fileSystem{fsys: fsys},
+		LockSystem: webdav.NewMemLS(),
+		Logger:     h.logError,
+	}
+
+	return h
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="pCloud", charset="UTF-8"`)
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	if h.readOnly && !readMethods[r.Method] {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	h.dav.ServeHTTP(w, r)
+}
+
+// authorized returns whether r holds the credentials of WithBasicAuth, if any.
+func (h *Handler) authorized(r *http.Request) bool {
+	if h.user == "" && h.password == "" {
+		return true
+	}
+
+	user, password, ok := r.BasicAuth()
+
+	// both are compared in constant time, not to tell which one is wrong.
+	userOK := subtle.ConstantTimeCompare([]byte(user), []byte(h.user)) == 1
+	passwordOK := subtle.ConstantTimeCompare([]byte(password), []byte(h.password)) == 1
+
+	return ok && userOK && passwordOK
+}
+
+// logError logs the error err of the request r, if any.
+func (h *Handler) logError(r *http.Request, err error) {
+	if err == nil || h.logger == nil {
+		return
+	}
+
+	h.logger.Error("webdavserver: request failed", "method", r.Method, "path", r.URL.Path, "error", err)
+}
+
+// fileSystem implements webdav.FileSystem over a pcloudfs.FS.
+//
+// webdav stats the contents of a folder one by one after it lists it, and pcloudfs stats a file
+// by listing the folder that holds it: the last folder listed for a request is kept to stat its
+// contents (see listing).
+type fileSystem struct {
+	fsys *pcloudfs.FS
+
+	mu      sync.Mutex
+	listing listing
+}
+
+// listing holds the contents of the folder dir, listed for the request of ctx.
+type listing struct {
+	ctx   context.Context
+	dir   string
+	infos map[string]fs.FileInfo
+}
+
+var _ webdav.FileSystem = (*fileSystem)(nil)
+
+// Mkdir implements webdav.FileSystem.
+func (f *fileSystem) Mkdir(ctx context.Context, name string, perm os.FileMode) error {
+	f.forget()
+	return f.fsys.WithContext(ctx).Mkdir(fsName(name), perm)
+}
+
+// OpenFile implements webdav.FileSystem.
+func (f *fileSystem) OpenFile(ctx context.Context, name string, flag int, perm os.FileMode) (webdav.File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND) != 0 {
+		f.forget()
+	}
+
+	file, err := f.fsys.WithContext(ctx).OpenFile(fsName(name), flag, perm)
+	if err != nil {
+		return nil, err
+	}
+
+	return &webdavFile{File: file, fs: f, ctx: ctx, name: fsName(name)}, nil
+}
+
+// RemoveAll implements webdav.FileSystem.
+func (f *fileSystem) RemoveAll(ctx context.Context, name string) error {
+	f.forget()
+	return f.fsys.WithContext(ctx).RemoveAll(fsName(name))
+}
+
+// Rename implements webdav.FileSystem.
+func (f *fileSystem) Rename(ctx context.Context, oldName, newName string) error {
+	f.forget()
+	return f.fsys.WithContext(ctx).Rename(fsName(oldName), fsName(newName))
+}
+
+// Stat implements webdav.FileSystem.
+func (f *fileSystem) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	name = fsName(name)
+
+	f.mu.Lock()
+	l := f.listing
+	f.mu.Unlock()
+
+	if l.ctx == ctx && name != "." && path.Dir(name) == l.dir {
+		if info, ok := l.infos[path.Base(name)]; ok {
+			return info, nil
+		}
+	}
+
+	info, err := f.fsys.WithContext(ctx).Stat(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return fileInfo{info}, nil
+}
+
+// remember keeps the contents infos of the folder dir, listed for the request of ctx.
+func (f *fileSystem) remember(ctx context.Context, dir string, infos []fs.FileInfo) {
+	l := listing{ctx: ctx, dir: dir, infos: make(map[string]fs.FileInfo, len(infos))}
+	for _, info := range infos {
+		l.infos[info.Name()] = info
+	}
+
+	f.mu.Lock()
+	f.listing = l
+	f.mu.Unlock()
+}
+
+// forget drops the folder listed last, before a change.
+func (f *fileSystem) forget() {
+	f.mu.Lock()
+	f.listing = listing{}
+	f.mu.Unlock()
+}
+
+// webdavFile is a file or folder opened by fileSystem.
+type webdavFile struct {
+	*pcloudfs.File
+	fs   *fileSystem
+	ctx  context.Context
+	name string
+}
+
+// Readdir implements webdav.File.
+func (f *webdavFile) Readdir(count int) ([]fs.FileInfo, error) {
+	infos, err := f.File.Readdir(count)
+
+	for i, info := range infos {
+		infos[i] = fileInfo{info}
+	}
+
+	if err == nil && count <= 0 {
+		f.fs.remember(f.ctx, f.name, infos)
+	}
+
+	return infos, err
+}
+
+// Stat implements webdav.File.
+func (f *webdavFile) Stat() (fs.FileInfo, error) {
+	info, err := f.File.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	return fileInfo{info}, nil
+}
+
+// fileInfo implements webdav.ContentTyper, so that the content type of a file is that of
+// pCloud, or else that of its extension, rather than sniffed from its first bytes, which webdav
+// would read for each file of the folders that it lists.
+type fileInfo struct {
+	fs.FileInfo
+}
+
+// ContentType implements webdav.ContentTyper.
+func (fi fileInfo) ContentType(context.Context) (string, error) {
+	if m, ok := fi.Sys().(*sdk.Metadata); ok && m.ContentType != "" {
+		return m.ContentType, nil
+	}
+
+	if ct := mime.TypeByExtension(path.Ext(fi.Name())); ct != "" {
+		return ct, nil
+	}
+
+	return "application/octet-stream", nil
+}
+
+// fsName returns the name in the pcloudfs.FS of the WebDAV path name, which is slash-separated
+// and rooted.
+func fsName(name string) string {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if name == "" {
+		return "."
+	}
+
+	return name
+}
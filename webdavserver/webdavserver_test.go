@@ -0,0 +1,130 @@
+package webdavserver_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seborama/pcloud-sdk/pcloudfs"
+	"github.com/seborama/pcloud-sdk/sdk/sdktest"
+	"github.com/seborama/pcloud-sdk/webdavserver"
+)
+
+func newTestServer(t *testing.T, opts ...webdavserver.Option) (*sdktest.Server, *httptest.Server) {
+	t.Helper()
+
+	srv := sdktest.NewServer()
+	t.Cleanup(srv.Close)
+
+	_, err := srv.WriteFile("/Share/Docs/a.txt", []byte("0123456789"))
+	require.NoError(t, err)
+	_, err = srv.WriteFile("/Share/b.txt", []byte("b"))
+	require.NoError(t, err)
+	_, err = srv.WriteFile("/Secret.txt", []byte("secret"))
+	require.NoError(t, err)
+
+	hs := httptest.NewServer(webdavserver.New(pcloudfs.New(srv.NewClient(), "/Share"), opts...))
+	t.Cleanup(hs.Close)
+
+	return srv, hs
+}
+
+func do(t *testing.T, method, url, body string, header http.Header) (*http.Response, string) {
+	t.Helper()
+
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	require.NoError(t, err)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+
+	b, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	return resp, string(b)
+}
+
+func TestHandler(t *testing.T) {
+	srv, hs := newTestServer(t)
+
+	resp, body := do(t, "PROPFIND", hs.URL+"/", "", http.Header{"Depth": {"1"}})
+	require.Equal(t, http.StatusMultiStatus, resp.StatusCode)
+	assert.Contains(t, body, "<D:href>/Docs/</D:href>")
+	assert.Contains(t, body, "<D:href>/b.txt</D:href>")
+	assert.Contains(t, body, "<D:getcontenttype>text/plain; charset=utf-8</D:getcontenttype>")
+	assert.NotContains(t, body, "a.txt")
+	assert.NotContains(t, body, "Secret.txt")
+
+	resp, body = do(t, http.MethodGet, hs.URL+"/Docs/a.txt", "", http.Header{"Range": {"bytes=2-4"}})
+	require.Equal(t, http.StatusPartialContent, resp.StatusCode)
+	assert.Equal(t, "234", body)
+
+	resp, _ = do(t, "MKCOL", hs.URL+"/New", "", nil)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp, _ = do(t, http.MethodPut, hs.URL+"/New/c.txt", "hello", nil)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	data, err := srv.ReadFile("/Share/New/c.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+
+	resp, _ = do(t, "MOVE", hs.URL+"/New/c.txt", "", http.Header{"Destination": {hs.URL + "/Docs/c.txt"}})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	data, err = srv.ReadFile("/Share/Docs/c.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+
+	resp, _ = do(t, http.MethodDelete, hs.URL+"/Docs", "", nil)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp, _ = do(t, http.MethodGet, hs.URL+"/Docs/a.txt", "", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestHandler_ReadOnly(t *testing.T) {
+	srv, hs := newTestServer(t, webdavserver.WithReadOnly())
+
+	resp, body := do(t, http.MethodGet, hs.URL+"/b.txt", "", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "b", body)
+
+	resp, _ = do(t, "PROPFIND", hs.URL+"/Docs", "", http.Header{"Depth": {"1"}})
+	assert.Equal(t, http.StatusMultiStatus, resp.StatusCode)
+
+	for _, method := range []string{http.MethodPut, http.MethodDelete, "MKCOL", "MOVE", "COPY", "PROPPATCH", "LOCK"} {
+		resp, _ = do(t, method, hs.URL+"/b.txt", "x", nil)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode, method)
+	}
+
+	data, err := srv.ReadFile("/Share/b.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "b", string(data))
+}
+
+func TestHandler_BasicAuth(t *testing.T) {
+	_, hs := newTestServer(t, webdavserver.WithBasicAuth("me", "secret"))
+
+	resp, _ := do(t, http.MethodGet, hs.URL+"/b.txt", "", nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.NotEmpty(t, resp.Header.Get("WWW-Authenticate"))
+
+	req, err := http.NewRequest(http.MethodGet, hs.URL+"/b.txt", nil)
+	require.NoError(t, err)
+	req.SetBasicAuth("me", "secret")
+
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}